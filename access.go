@@ -0,0 +1,736 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/goki/ki"
+	"github.com/goki/ki/bitflag"
+	"github.com/goki/ki/kit"
+)
+
+// access.go contains the accessibility model: each Node2D can report its
+// semantic role, name, states and available actions via AccessInfo, and
+// perform those actions via AccessAction -- the AccessTree walker assembles
+// these into a tree for the window, which is delivered to any registered
+// AccessBridge (e.g., the AT-SPI bridge for screen readers on Linux).
+
+// AccessRoles are the semantic roles of elements for accessibility -- these
+// map onto the roles of the platform accessibility APIs (AT-SPI, etc)
+type AccessRoles int32
+
+const (
+	// RoleNone is for elements that have no semantic role of their own,
+	// e.g., layouts and decorations -- their children are reported as
+	// children of the nearest ancestor that has a role
+	RoleNone AccessRoles = iota
+
+	// RoleWindow is a top-level window
+	RoleWindow
+
+	// RoleDialog is a dialog -- window or popup
+	RoleDialog
+
+	// RoleGroup is a container that groups related elements, e.g., a Frame
+	// with a name
+	RoleGroup
+
+	// RoleLabel is static text
+	RoleLabel
+
+	// RoleImage is an icon, bitmap or other image
+	RoleImage
+
+	// RoleButton is a push button
+	RoleButton
+
+	// RoleToggleButton is a button that stays pressed (checkable Button)
+	RoleToggleButton
+
+	// RoleCheckBox is a check box
+	RoleCheckBox
+
+	// RoleMenuButton is a button that opens a menu
+	RoleMenuButton
+
+	// RoleMenu is a popup menu
+	RoleMenu
+
+	// RoleMenuItem is an item in a menu
+	RoleMenuItem
+
+	// RoleMenuBar is a main menu bar
+	RoleMenuBar
+
+	// RoleToolBar is a toolbar
+	RoleToolBar
+
+	// RoleTextField is a single-line editable text field
+	RoleTextField
+
+	// RoleTextArea is a multi-line text editor
+	RoleTextArea
+
+	// RoleSlider is a slider for selecting a value in a range
+	RoleSlider
+
+	// RoleScrollBar is a scrollbar
+	RoleScrollBar
+
+	// RoleSpinBox is a numeric field with increment / decrement buttons
+	RoleSpinBox
+
+	// RoleComboBox is a drop-down selector
+	RoleComboBox
+
+	// RoleTabList is the set of tabs in a TabView
+	RoleTabList
+
+	// RoleTab is one tab in a TabView
+	RoleTab
+
+	// RoleTree is a tree view
+	RoleTree
+
+	// RoleTreeItem is one node within a tree view
+	RoleTreeItem
+
+	// RoleTable is a table or list of items
+	RoleTable
+
+	// RoleTableCell is a cell within a table
+	RoleTableCell
+
+	// RoleSplitter is a splitter handle between panels
+	RoleSplitter
+
+	// RoleSeparator is a separator in a menu or toolbar
+	RoleSeparator
+
+	// RoleToolTip is a tooltip popup
+	RoleToolTip
+
+	AccessRolesN
+)
+
+//go:generate stringer -type=AccessRoles
+
+var KiT_AccessRoles = kit.Enums.AddEnum(AccessRolesN, false, nil)
+
+// AccessStates are bit flags for the accessibility states of an element
+type AccessStates int32
+
+const (
+	// AccessFocusable means the element can accept keyboard focus
+	AccessFocusable AccessStates = iota
+
+	// AccessFocused means the element has the keyboard focus
+	AccessFocused
+
+	// AccessDisabled means the element is inactive and does not respond to
+	// user input
+	AccessDisabled
+
+	// AccessInvisible means the element is not currently visible
+	AccessInvisible
+
+	// AccessSelectable means the element can be selected (e.g., tree and
+	// table items)
+	AccessSelectable
+
+	// AccessSelected means the element is selected
+	AccessSelected
+
+	// AccessCheckable means the element can be checked
+	AccessCheckable
+
+	// AccessChecked means the element is checked
+	AccessChecked
+
+	// AccessPressed means the element is currently pressed down
+	AccessPressed
+
+	// AccessExpandable means the element has children that can be shown or
+	// hidden (e.g., tree items)
+	AccessExpandable
+
+	// AccessExpanded means the element's children are shown
+	AccessExpanded
+
+	// AccessEditable means the element has text that can be edited
+	AccessEditable
+
+	// AccessMultiLine means the element holds multiple lines of text
+	AccessMultiLine
+
+	// AccessHasPopup means the element opens a popup menu
+	AccessHasPopup
+
+	// AccessModal means the element (dialog) is modal
+	AccessModal
+
+	// AccessVertical means the element (slider, scrollbar, splitter) is
+	// oriented vertically -- otherwise horizontally
+	AccessVertical
+
+	AccessStatesN
+)
+
+//go:generate stringer -type=AccessStates
+
+var KiT_AccessStates = kit.Enums.AddEnum(AccessStatesN, true, nil) // true = bitflags
+
+// AccessActions are the actions that can be performed on an element by
+// assistive technology, via AccessAction
+type AccessActions int32
+
+const (
+	// AccessClick clicks (presses and releases) a button or item
+	AccessClick AccessActions = iota
+
+	// AccessToggle toggles the checked state
+	AccessToggle
+
+	// AccessFocus gives the element keyboard focus
+	AccessFocus
+
+	// AccessIncrement increments the value by one step
+	AccessIncrement
+
+	// AccessDecrement decrements the value by one step
+	AccessDecrement
+
+	// AccessSetValue sets the value from the string argument
+	AccessSetValue
+
+	// AccessExpand shows the children of the element
+	AccessExpand
+
+	// AccessCollapse hides the children of the element
+	AccessCollapse
+
+	// AccessSelect selects the element
+	AccessSelect
+
+	// AccessOpenMenu opens the element's popup menu
+	AccessOpenMenu
+
+	AccessActionsN
+)
+
+//go:generate stringer -type=AccessActions
+
+var KiT_AccessActions = kit.Enums.AddEnum(AccessActionsN, false, nil)
+
+// AccessInfo is the accessibility information for one element
+type AccessInfo struct {
+	Role    AccessRoles     `desc:"semantic role of the element"`
+	Name    string          `desc:"accessible name -- typically the text label of the element"`
+	Desc    string          `desc:"longer description -- typically the tooltip"`
+	Value   string          `desc:"current value as a string, for elements that have one (text fields, sliders, etc)"`
+	Min     float32         `desc:"minimum value for range elements (sliders, spinboxes)"`
+	Max     float32         `desc:"maximum value for range elements (sliders, spinboxes)"`
+	Step    float32         `desc:"step size for range elements (sliders, spinboxes)"`
+	States  int64           `desc:"bit flags of AccessStates"`
+	Actions []AccessActions `desc:"actions that can be performed with AccessAction"`
+	Bounds  image.Rectangle `desc:"bounding box of element in window coordinates"`
+}
+
+// HasState returns true if given state flag is set
+func (ai *AccessInfo) HasState(st AccessStates) bool {
+	return bitflag.Has(ai.States, int(st))
+}
+
+// SetState sets given state flag(s) on or off
+func (ai *AccessInfo) SetState(on bool, st ...AccessStates) {
+	for _, s := range st {
+		bitflag.SetState(&ai.States, on, int(s))
+	}
+}
+
+// HasAction returns true if given action is supported
+func (ai *AccessInfo) HasAction(act AccessActions) bool {
+	for _, a := range ai.Actions {
+		if a == act {
+			return true
+		}
+	}
+	return false
+}
+
+// AddAction adds given action(s) to list of supported actions, if not
+// already present
+func (ai *AccessInfo) AddAction(act ...AccessActions) {
+	for _, a := range act {
+		if !ai.HasAction(a) {
+			ai.Actions = append(ai.Actions, a)
+		}
+	}
+}
+
+// StatesString returns the set state flags as a space-separated list of
+// names without the Access prefix, e.g., "Focusable Checked"
+func (ai *AccessInfo) StatesString() string {
+	var sts []string
+	for st := AccessStates(0); st < AccessStatesN; st++ {
+		if ai.HasState(st) {
+			sts = append(sts, strings.TrimPrefix(st.String(), "Access"))
+		}
+	}
+	return strings.Join(sts, " ")
+}
+
+// Accessible is the interface for elements that provide accessibility
+// information -- all Node2D elements implement it via Node2DBase, and
+// widgets override it to report their specific role, name and states.
+// Basic information can be set for any element via the "access-role",
+// "access-name" and "access-desc" properties, which override the defaults.
+type Accessible interface {
+	// AccessInfo returns the current accessibility info for the element
+	AccessInfo() AccessInfo
+
+	// AccessAction performs given action on the element, with optional
+	// string value for AccessSetValue -- returns false if not supported.
+	// Must be called from the window's event loop goroutine (see
+	// Window.AccessDoAction).
+	AccessAction(act AccessActions, val string) bool
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+// Node2DBase, WidgetBase defaults
+
+// AccessInfo returns the default accessibility info, from AccessInfoBase
+func (nb *Node2DBase) AccessInfo() AccessInfo {
+	return nb.AccessInfoBase()
+}
+
+// AccessInfoBase returns the basic accessibility info common to all
+// elements: bounds, focus, inactive, invisible and selected states, and
+// any access-role, access-name or access-desc properties -- widgets call
+// this first and then add their own info.
+func (nb *Node2DBase) AccessInfoBase() AccessInfo {
+	ai := AccessInfo{Bounds: nb.WinBBox}
+	ai.SetState(nb.CanFocus(), AccessFocusable)
+	ai.SetState(nb.HasFocus(), AccessFocused)
+	ai.SetState(nb.IsInactive(), AccessDisabled)
+	ai.SetState(nb.IsInvisible(), AccessInvisible)
+	ai.SetState(nb.IsSelected(), AccessSelected)
+	if nb.CanFocus() {
+		ai.AddAction(AccessFocus)
+	}
+	return ai
+}
+
+// AccessInfoProps applies any access-role, access-name or access-desc
+// properties on the node to given info -- called by AccessNodeInfo so that
+// these always override the element's own info.
+func (nb *Node2DBase) AccessInfoProps(ai *AccessInfo) {
+	if rp, ok := nb.Prop("access-role"); ok {
+		switch rv := rp.(type) {
+		case AccessRoles:
+			ai.Role = rv
+		case string:
+			if rl, err := StringToAccessRoles(rv); err == nil {
+				ai.Role = rl
+			} else if rl, err := StringToAccessRoles("Role" + rv); err == nil {
+				ai.Role = rl
+			}
+		}
+	}
+	if np, ok := nb.Prop("access-name"); ok {
+		ai.Name = kit.ToString(np)
+	}
+	if dp, ok := nb.Prop("access-desc"); ok {
+		ai.Desc = kit.ToString(dp)
+	}
+}
+
+// AccessAction performs the default actions, from AccessActionBase
+func (nb *Node2DBase) AccessAction(act AccessActions, val string) bool {
+	return nb.AccessActionBase(act, val)
+}
+
+// AccessActionBase performs actions common to all elements: AccessFocus
+func (nb *Node2DBase) AccessActionBase(act AccessActions, val string) bool {
+	if act == AccessFocus && nb.CanFocus() {
+		nb.GrabFocus()
+		return true
+	}
+	return false
+}
+
+// AccessInfo for widgets adds the Tooltip as the description
+func (wb *WidgetBase) AccessInfo() AccessInfo {
+	ai := wb.AccessInfoBase()
+	ai.Desc = wb.Tooltip
+	return ai
+}
+
+// AccessNodeInfo returns the accessibility info for given node, including
+// any overriding access-* properties -- ok is false if node is not a Node2D
+func AccessNodeInfo(k ki.Ki) (ai AccessInfo, ok bool) {
+	nii, ni := KiToNode2D(k)
+	if nii == nil {
+		return
+	}
+	if acc, isacc := nii.(Accessible); isacc {
+		ai = acc.AccessInfo()
+	} else {
+		ai = ni.AccessInfoBase()
+	}
+	ni.AccessInfoProps(&ai)
+	return ai, true
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+// AccessNode tree
+
+// AccessNode is one node in the accessibility tree for a window, built by
+// NewAccessTree -- only elements with a Role (and the root) are included,
+// with all elements having RoleNone being skipped over, so that their
+// children appear directly under the nearest ancestor with a role.
+type AccessNode struct {
+	AccessInfo
+	Node   ki.Ki         `desc:"the element that this node represents"`
+	Parent *AccessNode   `desc:"parent in the accessibility tree -- nil for root"`
+	Kids   []*AccessNode `desc:"children in the accessibility tree"`
+}
+
+// NewAccessTree returns the accessibility tree under given root node, which
+// is always included even if it has no role -- invisible elements are not
+// included.
+func NewAccessTree(root ki.Ki) *AccessNode {
+	ai, _ := AccessNodeInfo(root)
+	an := &AccessNode{AccessInfo: ai, Node: root}
+	an.addKids(root)
+	return an
+}
+
+// addKids adds accessible children of given ki node to this node
+func (an *AccessNode) addKids(k ki.Ki) {
+	for _, kid := range *k.Children() {
+		ai, ok := AccessNodeInfo(kid)
+		if !ok || ai.HasState(AccessInvisible) {
+			continue
+		}
+		if ai.Role == RoleNone {
+			an.addKids(kid)
+			continue
+		}
+		kn := &AccessNode{AccessInfo: ai, Node: kid, Parent: an}
+		an.Kids = append(an.Kids, kn)
+		kn.addKids(kid)
+	}
+}
+
+// Refresh updates the AccessInfo for this node from its element (not the
+// children)
+func (an *AccessNode) Refresh() {
+	if ai, ok := AccessNodeInfo(an.Node); ok {
+		if an.Parent == nil && an.Role != ai.Role { // root can be set by window
+			ai.Role = an.Role
+			ai.Name = an.Name
+		}
+		an.AccessInfo = ai
+	}
+}
+
+// IndexInParent returns the index of this node in its parent's Kids, -1 if
+// root
+func (an *AccessNode) IndexInParent() int {
+	if an.Parent == nil {
+		return -1
+	}
+	for i, kn := range an.Parent.Kids {
+		if kn == an {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindNode returns the AccessNode for given element, nil if not in tree
+func (an *AccessNode) FindNode(k ki.Ki) *AccessNode {
+	if an.Node == k {
+		return an
+	}
+	for _, kn := range an.Kids {
+		if fn := kn.FindNode(k); fn != nil {
+			return fn
+		}
+	}
+	return nil
+}
+
+// FuncDown calls given function on this node and all of its children
+// recursively, stopping descent into a node's children if fun returns false
+func (an *AccessNode) FuncDown(level int, fun func(an *AccessNode, level int) bool) {
+	if !fun(an, level) {
+		return
+	}
+	for _, kn := range an.Kids {
+		kn.FuncDown(level+1, fun)
+	}
+}
+
+// Label returns a one-line description of the node: role, quoted name, and
+// value, states and actions if present, e.g.,
+// Button "OK" [Focusable] {Click Focus}
+func (an *AccessNode) Label() string {
+	var b strings.Builder
+	b.WriteString(strings.TrimPrefix(an.Role.String(), "Role"))
+	fmt.Fprintf(&b, " %q", an.Name)
+	if an.Value != "" {
+		fmt.Fprintf(&b, " value=%q", an.Value)
+	}
+	if an.Max > an.Min {
+		fmt.Fprintf(&b, " range=%v-%v", an.Min, an.Max)
+	}
+	if sts := an.StatesString(); sts != "" {
+		fmt.Fprintf(&b, " [%v]", sts)
+	}
+	if len(an.Actions) > 0 {
+		acts := make([]string, len(an.Actions))
+		for i, a := range an.Actions {
+			acts[i] = strings.TrimPrefix(a.String(), "Access")
+		}
+		fmt.Fprintf(&b, " {%v}", strings.Join(acts, " "))
+	}
+	return b.String()
+}
+
+// Dump writes the tree as indented text, one node per line (see Label),
+// with 2 spaces per level -- this is used for verifying the tree in tests,
+// and by the AccessDumpBridge.
+func (an *AccessNode) Dump(out io.Writer) {
+	an.FuncDown(0, func(kn *AccessNode, level int) bool {
+		fmt.Fprintf(out, "%v%v\n", strings.Repeat("  ", level), kn.Label())
+		return true
+	})
+}
+
+// String returns the Dump of the tree
+func (an *AccessNode) String() string {
+	var b strings.Builder
+	an.Dump(&b)
+	return b.String()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+// Window
+
+// AccessTree returns the accessibility tree for the window, with the window
+// as the root (RoleWindow, or RoleDialog for dialog windows), including the
+// current popup (menu, dialog) if any.
+func (w *Window) AccessTree() *AccessNode {
+	if w.Viewport == nil {
+		return nil
+	}
+	an := NewAccessTree(w.Viewport.This)
+	an.Role = RoleWindow
+	if w.OSWin != nil && w.OSWin.IsDialog() {
+		an.Role = RoleDialog
+	}
+	an.Name = w.Title
+	if w.Popup != nil {
+		if ai, ok := AccessNodeInfo(w.Popup); ok {
+			pn := NewAccessTree(w.Popup)
+			pn.AccessInfo = ai
+			if pn.Role == RoleNone {
+				pn.Role = RoleDialog
+			}
+			pn.Parent = an
+			an.Kids = append(an.Kids, pn)
+		}
+	}
+	return an
+}
+
+// AccessDoAction performs given accessibility action on given element, in
+// the window's event loop goroutine (as all GUI updates must be) -- this is
+// what bridges should call, as they typically receive requests on their own
+// goroutines -- returns false if the element does not support the action.
+func (w *Window) AccessDoAction(k ki.Ki, act AccessActions, val string) bool {
+	nii, _ := KiToNode2D(k)
+	acc, ok := nii.(Accessible)
+	if !ok {
+		return false
+	}
+	ai := acc.AccessInfo()
+	if !ai.HasAction(act) {
+		return false
+	}
+	w.RunInEventLoop(func() {
+		acc.AccessAction(act, val)
+	})
+	return true
+}
+
+// RunInEventLoop runs given function in the window's event loop goroutine,
+// by sending a custom event, and returns immediately.
+func (w *Window) RunInEventLoop(fun func()) {
+	if w.OSWin == nil {
+		return
+	}
+	fe := &funcEvent{fun: fun}
+	fe.Init()
+	w.OSWin.Send(fe)
+}
+
+// funcEvent is an event carrying a function to run in the EventLoop -- it
+// uses the Event methods of syncEvent, which is handled the same way
+type funcEvent struct {
+	syncEvent
+	fun func()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+// Bridges
+
+// AccessBridge is the interface for exporting accessibility trees to
+// assistive technology -- e.g., the AT-SPI bridge in package gi/atspi, or the
+// AccessDumpBridge used for testing.  Methods are called from the window's
+// event loop goroutine.
+type AccessBridge interface {
+	// AccessActive returns true if the bridge is connected to its assistive
+	// technology and wants tree updates -- the tree is only built if at
+	// least one bridge is active
+	AccessActive() bool
+
+	// AccessTreeUpdated is called with the new tree after the window does
+	// a full re-render, as its structure may have changed
+	AccessTreeUpdated(win *Window, tree *AccessNode)
+
+	// AccessFocusChanged is called when the keyboard focus changes, with
+	// the element that now has the focus
+	AccessFocusChanged(win *Window, focus ki.Ki)
+
+	// AccessWindowClosed is called when the window is closed
+	AccessWindowClosed(win *Window)
+}
+
+// AccessBridges are the registered accessibility bridges -- if empty, no
+// accessibility trees are built
+var AccessBridges []AccessBridge
+
+// accessMu protects AccessBridges
+var accessMu sync.Mutex
+
+// RegisterAccessBridge adds given bridge to the list of bridges that
+// receive accessibility updates for all windows
+func RegisterAccessBridge(br AccessBridge) {
+	accessMu.Lock()
+	AccessBridges = append(AccessBridges, br)
+	accessMu.Unlock()
+}
+
+// accessBridges returns a copy of the current list of bridges
+func accessBridges() []AccessBridge {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+	if len(AccessBridges) == 0 {
+		return nil
+	}
+	brs := make([]AccessBridge, len(AccessBridges))
+	copy(brs, AccessBridges)
+	return brs
+}
+
+// AccessUpdate builds the accessibility tree and sends it to any registered
+// bridges that are active -- called after a full re-render
+func (w *Window) AccessUpdate() {
+	var brs []AccessBridge
+	for _, br := range accessBridges() {
+		if br.AccessActive() {
+			brs = append(brs, br)
+		}
+	}
+	if brs == nil {
+		return
+	}
+	tree := w.AccessTree()
+	if tree == nil {
+		return
+	}
+	for _, br := range brs {
+		br.AccessTreeUpdated(w, tree)
+	}
+}
+
+// accessFocus notifies bridges of a focus change
+func (w *Window) accessFocus(k ki.Ki) {
+	for _, br := range accessBridges() {
+		br.AccessFocusChanged(w, k)
+	}
+}
+
+// accessClosed notifies bridges that the window has closed
+func (w *Window) accessClosed() {
+	for _, br := range accessBridges() {
+		br.AccessWindowClosed(w)
+	}
+}
+
+// AccessDumpBridge is an AccessBridge that writes a Dump of each updated
+// tree, and each focus change, to Out -- useful for verifying the
+// accessibility of an interface without any assistive technology or
+// desktop, e.g., with the offscreen driver.
+type AccessDumpBridge struct {
+	Out  io.Writer               `desc:"where to write the dumps"`
+	Last map[*Window]*AccessNode `desc:"last tree for each window"`
+	mu   sync.Mutex
+}
+
+// NewAccessDumpBridge returns a new dump bridge writing to given writer --
+// call RegisterAccessBridge to activate it.
+func NewAccessDumpBridge(out io.Writer) *AccessDumpBridge {
+	return &AccessDumpBridge{Out: out, Last: make(map[*Window]*AccessNode)}
+}
+
+func (db *AccessDumpBridge) AccessActive() bool {
+	return true
+}
+
+func (db *AccessDumpBridge) AccessTreeUpdated(win *Window, tree *AccessNode) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.Last[win] = tree
+	if db.Out != nil {
+		fmt.Fprintf(db.Out, "== tree: %v\n", win.Nm)
+		tree.Dump(db.Out)
+	}
+}
+
+func (db *AccessDumpBridge) AccessFocusChanged(win *Window, focus ki.Ki) {
+	if db.Out == nil {
+		return
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if ai, ok := AccessNodeInfo(focus); ok {
+		fmt.Fprintf(db.Out, "== focus: %v: %v %q\n", win.Nm, strings.TrimPrefix(ai.Role.String(), "Role"), ai.Name)
+	}
+}
+
+func (db *AccessDumpBridge) AccessWindowClosed(win *Window) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.Last, win)
+	if db.Out != nil {
+		fmt.Fprintf(db.Out, "== closed: %v\n", win.Nm)
+	}
+}
+
+// Tree returns the last tree received for given window -- nil if none
+func (db *AccessDumpBridge) Tree(win *Window) *AccessNode {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.Last[win]
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+	"github.com/goki/ki"
+)
+
+// testViewport returns a new viewport of given size, with a vertical
+// layout, for rendering widgets in tests with the offscreen driver and
+// default preferences
+func testViewport(t *testing.T, width, height int) (*Viewport2D, *Layout) {
+	if oswin.TheApp == nil {
+		offscreen.Main(func(app oswin.App) {})
+	}
+	if Prefs.LogicalDPIScale == 0 {
+		Prefs.Defaults()
+		Prefs.Apply()
+	}
+	vp := NewViewport2D(width, height)
+	if vp == nil {
+		t.Fatalf("could not create viewport")
+	}
+	vp.InitName(vp, "test-vp")
+	vp.Fill = true
+	lay := vp.AddNewChild(KiT_Layout, "lay").(*Layout)
+	lay.Lay = LayoutVert
+	return vp, lay
+}
+
+func TestAccessDumpBridge(t *testing.T) {
+	vp, lay := testViewport(t, 400, 300)
+	bt := lay.AddNewChild(KiT_Button, "ok").(*Button)
+	bt.SetText("OK")
+	cb := lay.AddNewChild(KiT_CheckBox, "check").(*CheckBox)
+	cb.SetText("Enable")
+	cb.SetChecked(true)
+	tf := lay.AddNewChild(KiT_TextField, "name").(*TextField)
+	tf.Placeholder = "Name"
+	tf.SetText("abc")
+	tf.SetInactive()
+	hid := lay.AddNewChild(KiT_Button, "hidden").(*Button)
+	hid.SetText("Hidden")
+	hid.SetInvisible()
+	vp.FullRender2DTree()
+
+	win := &Window{}
+	win.InitName(win, "access-test")
+	win.Title = "Access Test"
+	win.Viewport = vp
+
+	var out bytes.Buffer
+	db := NewAccessDumpBridge(&out)
+	RegisterAccessBridge(db)
+	defer func() {
+		accessMu.Lock()
+		AccessBridges = nil
+		accessMu.Unlock()
+	}()
+	win.AccessUpdate()
+
+	tree := db.Tree(win)
+	if tree == nil {
+		t.Fatal("no tree received by the dump bridge")
+	}
+	if tree.Role != RoleWindow || tree.Name != "Access Test" {
+		t.Errorf("root: expected window %q, got: %v", "Access Test", tree.Label())
+	}
+	if len(tree.Kids) != 3 {
+		t.Fatalf("expected 3 accessible children (layout flattened, hidden skipped), got:\n%v", tree)
+	}
+	tests := []struct {
+		node  *AccessNode
+		role  AccessRoles
+		name  string
+		on    []AccessStates
+		off   []AccessStates
+		value string
+	}{
+		{tree.FindNode(bt.This), RoleButton, "OK", []AccessStates{AccessFocusable}, []AccessStates{AccessChecked, AccessDisabled}, ""},
+		{tree.FindNode(cb.This), RoleCheckBox, "Enable", []AccessStates{AccessCheckable, AccessChecked}, nil, ""},
+		{tree.FindNode(tf.This), RoleTextField, "Name", []AccessStates{AccessDisabled}, []AccessStates{AccessEditable}, "abc"},
+	}
+	for i, ts := range tests {
+		if ts.node == nil {
+			t.Errorf("%v: node not found in tree:\n%v", i, tree)
+			continue
+		}
+		if ts.node.Role != ts.role || ts.node.Name != ts.name || ts.node.Value != ts.value {
+			t.Errorf("%v: expected %v %q value %q, got: %v", i, ts.role, ts.name, ts.value, ts.node.Label())
+		}
+		for _, st := range ts.on {
+			if !ts.node.HasState(st) {
+				t.Errorf("%v: expected state %v, got: %v", i, st, ts.node.Label())
+			}
+		}
+		for _, st := range ts.off {
+			if ts.node.HasState(st) {
+				t.Errorf("%v: unexpected state %v, got: %v", i, st, ts.node.Label())
+			}
+		}
+	}
+	if tree.FindNode(hid.This) != nil {
+		t.Errorf("invisible button should not be in tree:\n%v", tree)
+	}
+	dump := out.String()
+	if !strings.HasPrefix(dump, "== tree: access-test\nWindow \"Access Test\"\n") ||
+		!strings.Contains(dump, "\n  CheckBox \"Enable\" [") {
+		t.Errorf("unexpected dump:\n%v", dump)
+	}
+}
+
+// testInactiveBridge is a bridge that is never active
+type testInactiveBridge struct {
+	updates int
+}
+
+func (tb *testInactiveBridge) AccessActive() bool                              { return false }
+func (tb *testInactiveBridge) AccessTreeUpdated(win *Window, tree *AccessNode) { tb.updates++ }
+func (tb *testInactiveBridge) AccessFocusChanged(win *Window, focus ki.Ki)     {}
+func (tb *testInactiveBridge) AccessWindowClosed(win *Window)                  {}
+
+func TestAccessUpdateInactive(t *testing.T) {
+	vp, lay := testViewport(t, 200, 100)
+	lay.AddNewChild(KiT_Button, "ok")
+	win := &Window{}
+	win.InitName(win, "access-inactive")
+	win.Viewport = vp
+
+	ib := &testInactiveBridge{}
+	RegisterAccessBridge(ib)
+	defer func() {
+		accessMu.Lock()
+		AccessBridges = nil
+		accessMu.Unlock()
+	}()
+	win.AccessUpdate()
+	if ib.updates != 0 {
+		t.Errorf("inactive bridge received %v tree updates", ib.updates)
+	}
+}
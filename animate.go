@@ -0,0 +1,505 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+)
+
+// Animation: a Tween calls its Update function on each frame with the
+// (eased) progress from 0 to 1 over its duration, and the Timeline of each
+// window steps all of its active tweens from the window's event loop, every
+// AnimFrameMSec while any are active.  The Lerp functions interpolate the
+// various types of style values (and see Vec2D.Interpolate for positions).
+// Node2DBase.Animate is the main entry point -- see also CSS transitions
+// (StyleTransition) and the svg Animate element.
+
+// AnimFrameMSec is the interval between animation frames, in milliseconds
+var AnimFrameMSec = 16
+
+// AnimationsOff turns off all animation -- tweens jump directly to their
+// final state -- e.g., for a reduced-motion preference, or for testing
+var AnimationsOff = false
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Easing
+
+// EasingFunc maps linear progress t (0-1) over time to eased progress,
+// which is typically also 0-1 but can overshoot
+type EasingFunc func(t float32) float32
+
+// EaseLinear is linear easing -- no change in progress
+func EaseLinear(t float32) float32 {
+	return t
+}
+
+var (
+	// EaseCSS is the CSS default "ease" easing: quick start, slow end
+	EaseCSS = CubicBezierEasing(0.25, 0.1, 0.25, 1)
+
+	// EaseIn starts slowly and ends quickly
+	EaseIn = CubicBezierEasing(0.42, 0, 1, 1)
+
+	// EaseOut starts quickly and ends slowly
+	EaseOut = CubicBezierEasing(0, 0, 0.58, 1)
+
+	// EaseInOut starts and ends slowly
+	EaseInOut = CubicBezierEasing(0.42, 0, 0.58, 1)
+)
+
+// CubicBezierEasing returns the easing function for a CSS cubic-bezier
+// timing function with given control points -- x1 and x2 must be in 0-1
+func CubicBezierEasing(x1, y1, x2, y2 float32) EasingFunc {
+	bez := func(t, p1, p2 float32) float32 {
+		mt := 1 - t
+		return 3*mt*mt*t*p1 + 3*mt*t*t*p2 + t*t*t
+	}
+	dbez := func(t, p1, p2 float32) float32 {
+		mt := 1 - t
+		return 3*mt*mt*p1 + 6*mt*t*(p2-p1) + 3*t*t*(1-p2)
+	}
+	return func(x float32) float32 {
+		if x <= 0 || x >= 1 {
+			return x
+		}
+		t := x
+		for i := 0; i < 8; i++ { // newton
+			dx := bez(t, x1, x2) - x
+			if math32.Abs(dx) < 1e-5 {
+				return bez(t, y1, y2)
+			}
+			d := dbez(t, x1, x2)
+			if math32.Abs(d) < 1e-6 {
+				break
+			}
+			t -= dx / d
+		}
+		lo, hi := float32(0), float32(1) // bisection fallback
+		t = x
+		for i := 0; i < 30; i++ {
+			xt := bez(t, x1, x2)
+			if math32.Abs(xt-x) < 1e-5 {
+				break
+			}
+			if xt < x {
+				lo = t
+			} else {
+				hi = t
+			}
+			t = 0.5 * (lo + hi)
+		}
+		return bez(t, y1, y2)
+	}
+}
+
+// StepsEasing returns the easing function for a CSS steps timing function
+// with n steps -- if start is true, the first step happens at the start
+// (jump-start), otherwise at the end of each interval (jump-end)
+func StepsEasing(n int, start bool) EasingFunc {
+	if n < 1 {
+		n = 1
+	}
+	nf := float32(n)
+	return func(t float32) float32 {
+		if t >= 1 {
+			return 1
+		}
+		s := math32.Floor(t * nf)
+		if start {
+			s++
+		}
+		return math32.Min(s/nf, 1)
+	}
+}
+
+// EasingByName returns the easing function for a CSS timing function:
+// linear, ease, ease-in, ease-out, ease-in-out, step-start, step-end,
+// cubic-bezier(x1, y1, x2, y2) or steps(n[, start|end])
+func EasingByName(name string) (EasingFunc, error) {
+	nm := strings.ToLower(strings.TrimSpace(name))
+	switch nm {
+	case "linear":
+		return EaseLinear, nil
+	case "ease":
+		return EaseCSS, nil
+	case "ease-in":
+		return EaseIn, nil
+	case "ease-out":
+		return EaseOut, nil
+	case "ease-in-out":
+		return EaseInOut, nil
+	case "step-start":
+		return StepsEasing(1, true), nil
+	case "step-end":
+		return StepsEasing(1, false), nil
+	}
+	switch {
+	case strings.HasPrefix(nm, "cubic-bezier(") && strings.HasSuffix(nm, ")"):
+		args := strings.Split(nm[13:len(nm)-1], ",")
+		if len(args) == 4 {
+			var p [4]float32
+			ok := true
+			for i, a := range args {
+				v, err := strconv.ParseFloat(strings.TrimSpace(a), 32)
+				if err != nil {
+					ok = false
+					break
+				}
+				p[i] = float32(v)
+			}
+			if ok && p[0] >= 0 && p[0] <= 1 && p[2] >= 0 && p[2] <= 1 {
+				return CubicBezierEasing(p[0], p[1], p[2], p[3]), nil
+			}
+		}
+	case strings.HasPrefix(nm, "steps(") && strings.HasSuffix(nm, ")"):
+		args := strings.Split(nm[6:len(nm)-1], ",")
+		n, err := strconv.Atoi(strings.TrimSpace(args[0]))
+		if err == nil {
+			start := len(args) > 1 && strings.Contains(args[1], "start")
+			return StepsEasing(n, start), nil
+		}
+	}
+	return nil, fmt.Errorf("gi.EasingByName: timing function not recognized: %v", name)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Interpolation
+
+// LerpFloat32 linearly interpolates between a and b by t
+func LerpFloat32(a, b, t float32) float32 {
+	return a + t*(b-a)
+}
+
+// LerpValue interpolates between two units values by t -- if they have the
+// same units, the value and dots are both interpolated, and otherwise the
+// result is in raw dots (which must already be computed, via ToDots)
+func LerpValue(a, b units.Value, t float32) units.Value {
+	if a.Un == b.Un && a.Calc == nil && b.Calc == nil {
+		return units.Value{Val: LerpFloat32(a.Val, b.Val, t), Un: a.Un, Dots: LerpFloat32(a.Dots, b.Dots, t)}
+	}
+	d := LerpFloat32(a.Dots, b.Dots, t)
+	return units.Value{Val: d, Un: units.Dot, Dots: d}
+}
+
+// LerpColor interpolates between two colors by t, including alpha
+func LerpColor(a, b Color, t float32) Color {
+	lc := func(x, y uint8) uint8 {
+		v := LerpFloat32(float32(x), float32(y), t) + 0.5
+		if v < 0 {
+			return 0
+		}
+		if v > 255 {
+			return 255
+		}
+		return uint8(v)
+	}
+	return Color{lc(a.R, b.R), lc(a.G, b.G), lc(a.B, b.B), lc(a.A, b.A)}
+}
+
+// Decompose2D decomposes the matrix into translation, rotation (radians),
+// scale, and skew (shear of y along x) components, such that:
+// Translate2D(tx, ty) * Rotate2D(rot) * [sx, skew*sy; 0, sy] recreates it
+func (a Matrix2D) Decompose2D() (tx, ty, rot, sx, sy, skew float32) {
+	tx, ty = a.X0, a.Y0
+	sx = math32.Hypot(a.XX, a.YX)
+	if sx == 0 {
+		return tx, ty, 0, 0, math32.Hypot(a.XY, a.YY), 0
+	}
+	rot = math32.Atan2(a.YX, a.XX)
+	c, s := a.XX/sx, a.YX/sx
+	k := c*a.XY + s*a.YY // component of second column along first
+	sy = -s*a.XY + c*a.YY
+	if sy != 0 {
+		skew = k / sy
+	}
+	return
+}
+
+// Compose2D creates a matrix from the components returned by Decompose2D
+func Compose2D(tx, ty, rot, sx, sy, skew float32) Matrix2D {
+	c, s := math32.Cos(rot), math32.Sin(rot)
+	return Matrix2D{
+		XX: c * sx, YX: s * sx,
+		XY: c*skew*sy - s*sy, YY: s*skew*sy + c*sy,
+		X0: tx, Y0: ty,
+	}
+}
+
+// LerpMatrix2D interpolates between two transforms by t, by interpolating
+// their translation, rotation (the short way around), scale and skew
+// components, so that rotations stay rigid along the way
+func LerpMatrix2D(a, b Matrix2D, t float32) Matrix2D {
+	atx, aty, arot, asx, asy, ask := a.Decompose2D()
+	btx, bty, brot, bsx, bsy, bsk := b.Decompose2D()
+	dr := brot - arot
+	for dr > math32.Pi {
+		dr -= 2 * math32.Pi
+	}
+	for dr < -math32.Pi {
+		dr += 2 * math32.Pi
+	}
+	return Compose2D(LerpFloat32(atx, btx, t), LerpFloat32(aty, bty, t), arot+t*dr,
+		LerpFloat32(asx, bsx, t), LerpFloat32(asy, bsy, t), LerpFloat32(ask, bsk, t))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Tween
+
+// Tween animates something over time, by calling Update on each frame with
+// the eased progress -- see Node2DBase.Animate to create and start one
+type Tween struct {
+	Node      ki.Ki           `desc:"node that is animated -- Timeline does an UpdateStart / UpdateEnd on it around each frame, and stops the tween if it is deleted"`
+	Key       string          `desc:"identifies what is being animated on the node -- starting a tween with the same node and key replaces the existing one"`
+	Dur       time.Duration   `desc:"duration of one run of the animation"`
+	Delay     time.Duration   `desc:"delay before the start of the animation"`
+	Ease      EasingFunc      `view:"-" desc:"easing function -- nil = linear"`
+	Repeat    int             `desc:"number of additional times to run the animation after the first -- -1 = forever"`
+	Alternate bool            `desc:"alternate direction on each repeat"`
+	Update    func(t float32) `view:"-" desc:"function called on each frame with eased progress (typically 0-1) -- does the actual update"`
+	Done      func()          `view:"-" desc:"optional function called when the tween finishes (not if it is stopped)"`
+	Pos       float32         `desc:"current eased progress"`
+	start     time.Time
+}
+
+// Set sets the position of the tween to linear progress t (0-1), calling
+// Update with the eased progress
+func (tw *Tween) Set(t float32) {
+	if tw.Ease != nil {
+		tw.Pos = tw.Ease(t)
+	} else {
+		tw.Pos = t
+	}
+	if tw.Update != nil {
+		tw.Update(tw.Pos)
+	}
+}
+
+// Finish sets the tween to its final state and calls Done
+func (tw *Tween) Finish() {
+	if tw.Alternate && tw.Repeat > 0 && tw.Repeat%2 == 1 {
+		tw.Set(0)
+	} else {
+		tw.Set(1)
+	}
+	if tw.Done != nil {
+		tw.Done()
+	}
+}
+
+// Step updates the tween for given time, returning true when it is done
+// (having called Finish)
+func (tw *Tween) Step(now time.Time) bool {
+	el := now.Sub(tw.start)
+	if el < 0 {
+		return false
+	}
+	if tw.Dur <= 0 {
+		tw.Finish()
+		return true
+	}
+	iter := int(el / tw.Dur)
+	if tw.Repeat >= 0 && iter > tw.Repeat {
+		tw.Finish()
+		return true
+	}
+	t := float32(el%tw.Dur) / float32(tw.Dur)
+	if tw.Alternate && iter%2 == 1 {
+		t = 1 - t
+	}
+	tw.Set(t)
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Timeline
+
+// Timeline holds the active tweens for a window, and steps them in the
+// window's event loop on each animation frame
+type Timeline struct {
+	Win      *Window  `desc:"window that we animate"`
+	Tweens   []*Tween `desc:"active tweens"`
+	mu       sync.Mutex
+	running  bool
+	stepping int32
+}
+
+// Add starts given tween, replacing any active tween with the same node
+// and (non-empty) key
+func (tl *Timeline) Add(tw *Tween) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	tw.start = time.Now().Add(tw.Delay)
+	if tw.Key != "" {
+		for i, ot := range tl.Tweens {
+			if ot.Node == tw.Node && ot.Key == tw.Key {
+				tl.Tweens[i] = tw
+				tw = nil
+				break
+			}
+		}
+	}
+	if tw != nil {
+		tl.Tweens = append(tl.Tweens, tw)
+	}
+	if !tl.running && tl.Win != nil {
+		tl.running = true
+		go tl.run()
+	}
+}
+
+// Stop stops the active tweens for given node and key, or all the tweens
+// for the node if key is empty -- they are left where they are and Done is
+// not called
+func (tl *Timeline) Stop(node ki.Ki, key string) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	nt := tl.Tweens[:0]
+	for _, tw := range tl.Tweens {
+		if tw.Node == node && (key == "" || tw.Key == key) {
+			continue
+		}
+		nt = append(nt, tw)
+	}
+	tl.Tweens = nt
+}
+
+// IsActive returns true if there are any active tweens for given node and
+// key, or for the node at all if key is empty
+func (tl *Timeline) IsActive(node ki.Ki, key string) bool {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	for _, tw := range tl.Tweens {
+		if tw.Node == node && (key == "" || tw.Key == key) {
+			return true
+		}
+	}
+	return false
+}
+
+// run sends a Step to the window event loop on each frame while there are
+// active tweens -- it skips frames while the previous step is pending.  If
+// the window has no OS window (and thus no event loop), the tweens are
+// finished.
+func (tl *Timeline) run() {
+	tick := time.NewTicker(time.Duration(AnimFrameMSec) * time.Millisecond)
+	defer tick.Stop()
+	for range tick.C {
+		tl.mu.Lock()
+		if len(tl.Tweens) == 0 || tl.Win.IsClosed() {
+			tl.running = false
+			tl.mu.Unlock()
+			return
+		}
+		if tl.Win.OSWin == nil {
+			tws := tl.Tweens
+			tl.Tweens = nil
+			tl.running = false
+			tl.mu.Unlock()
+			for _, tw := range tws {
+				tw.Finish()
+			}
+			return
+		}
+		tl.mu.Unlock()
+		if atomic.CompareAndSwapInt32(&tl.stepping, 0, 1) {
+			tl.Win.RunInEventLoop(func() {
+				tl.Step(time.Now())
+				atomic.StoreInt32(&tl.stepping, 0)
+			})
+		}
+	}
+}
+
+// Step steps all the active tweens for given time, removing those that are
+// done -- called in the window event loop.  Each animated node is updated
+// once after all of its tweens have been stepped.
+func (tl *Timeline) Step(now time.Time) {
+	tl.mu.Lock()
+	tws := make([]*Tween, len(tl.Tweens))
+	copy(tws, tl.Tweens)
+	tl.mu.Unlock()
+	updts := make(map[ki.Ki]bool)
+	var done []*Tween
+	for _, tw := range tws {
+		if tw.Node != nil {
+			if tw.Node.IsDeleted() || tw.Node.IsDestroyed() {
+				done = append(done, tw)
+				continue
+			}
+			if _, has := updts[tw.Node]; !has {
+				updts[tw.Node] = tw.Node.UpdateStart()
+			}
+		}
+		if tw.Step(now) {
+			done = append(done, tw)
+		}
+	}
+	for nd, updt := range updts {
+		nd.UpdateEnd(updt)
+	}
+	if len(done) == 0 {
+		return
+	}
+	tl.mu.Lock()
+	nt := tl.Tweens[:0]
+	for _, tw := range tl.Tweens {
+		fin := false
+		for _, dt := range done {
+			if dt == tw {
+				fin = true
+				break
+			}
+		}
+		if !fin {
+			nt = append(nt, tw)
+		}
+	}
+	tl.Tweens = nt
+	tl.mu.Unlock()
+}
+
+// Animate starts given tween in the window's timeline -- if AnimationsOff,
+// or the window has no OS window to run the animation in, the tween is
+// finished immediately
+func (w *Window) Animate(tw *Tween) {
+	if AnimationsOff || w.OSWin == nil {
+		tw.Finish()
+		return
+	}
+	w.Anim.Win = w
+	w.Anim.Add(tw)
+}
+
+// Animate creates and starts a Tween for this node in the timeline of its
+// window, calling update on each frame with progress 0-1 eased by ease (nil
+// = linear) over given duration.  Any active tween on the node with the
+// same key is replaced.  If the node is not in a window, or the duration is
+// 0, or AnimationsOff is set, update is called once with 1 (within an
+// UpdateStart / UpdateEnd).  Returns the tween, which can be further
+// configured (e.g., Repeat, Done) before the next frame.
+func (nb *Node2DBase) Animate(key string, dur time.Duration, ease EasingFunc, update func(t float32)) *Tween {
+	tw := &Tween{Node: nb.This, Key: key, Dur: dur, Ease: ease, Update: update}
+	win := nb.ParentWindow()
+	if win == nil || dur <= 0 || AnimationsOff {
+		if win != nil {
+			win.Anim.Stop(nb.This, key)
+		}
+		updt := nb.UpdateStart()
+		tw.Finish()
+		nb.UpdateEnd(updt)
+		return tw
+	}
+	win.Animate(tw)
+	return tw
+}
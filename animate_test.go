@@ -0,0 +1,143 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+	"time"
+)
+
+func testNear(a, b float32) bool {
+	d := a - b
+	return d > -1e-4 && d < 1e-4
+}
+
+func TestEasing(t *testing.T) {
+	names := []string{"linear", "ease", "ease-in", "ease-out", "ease-in-out", "cubic-bezier(0.1, 0.7, 1.0, 0.1)", "steps(4)", "step-end"}
+	for _, nm := range names {
+		ef, err := EasingByName(nm)
+		if err != nil {
+			t.Errorf("%v: %v", nm, err)
+			continue
+		}
+		if e0, e1 := ef(0), ef(1); !testNear(e0, 0) || !testNear(e1, 1) {
+			t.Errorf("%v: endpoints: %v %v != 0 1", nm, e0, e1)
+		}
+	}
+	if v := EaseIn(0.5); v >= 0.5 {
+		t.Errorf("ease-in midpoint: %v >= 0.5", v)
+	}
+	if v := EaseOut(0.5); v <= 0.5 {
+		t.Errorf("ease-out midpoint: %v <= 0.5", v)
+	}
+	if v := EaseInOut(0.5); !testNear(v, 0.5) {
+		t.Errorf("ease-in-out midpoint: %v != 0.5", v)
+	}
+	steps := []struct {
+		ef  EasingFunc
+		t   float32
+		exp float32
+	}{
+		{StepsEasing(4, false), 0.3, 0.25},
+		{StepsEasing(4, true), 0.3, 0.5},
+		{StepsEasing(1, true), 0, 1},
+		{StepsEasing(1, false), 0.99, 0},
+	}
+	for i, ts := range steps {
+		if v := ts.ef(ts.t); v != ts.exp {
+			t.Errorf("steps %v: %v != %v", i, v, ts.exp)
+		}
+	}
+	for _, nm := range []string{"bogus", "cubic-bezier(2, 0, 0, 1)", "cubic-bezier(0, 0, 1)", "steps(x)"} {
+		if _, err := EasingByName(nm); err == nil {
+			t.Errorf("expected error for: %v", nm)
+		}
+	}
+}
+
+func TestTweenStep(t *testing.T) {
+	t0 := time.Now()
+	var pos float32
+	ndone := 0
+	tw := &Tween{Dur: 100 * time.Millisecond, Update: func(p float32) { pos = p }, Done: func() { ndone++ }, start: t0}
+	if tw.Step(t0.Add(-10*time.Millisecond)) || pos != 0 {
+		t.Errorf("before start: expected no update, got: %v", pos)
+	}
+	if tw.Step(t0.Add(50*time.Millisecond)) || !testNear(pos, 0.5) {
+		t.Errorf("half way: %v != 0.5", pos)
+	}
+	if !tw.Step(t0.Add(100*time.Millisecond)) || pos != 1 || ndone != 1 {
+		t.Errorf("finish: expected done at 1, got: %v %v", pos, ndone)
+	}
+
+	tw = &Tween{Dur: 100 * time.Millisecond, Repeat: 2, Alternate: true, Update: func(p float32) { pos = p }, start: t0}
+	alt := []struct {
+		el   time.Duration
+		pos  float32
+		done bool
+	}{
+		{20 * time.Millisecond, 0.2, false},
+		{120 * time.Millisecond, 0.8, false}, // reversed on odd runs
+		{220 * time.Millisecond, 0.2, false},
+		{300 * time.Millisecond, 1, true}, // 3 runs: ends forward
+	}
+	for _, ts := range alt {
+		if done := tw.Step(t0.Add(ts.el)); done != ts.done || !testNear(pos, ts.pos) {
+			t.Errorf("alternate at %v: %v %v != %v %v", ts.el, pos, done, ts.pos, ts.done)
+		}
+	}
+	tw.Repeat = 1
+	tw.Finish()
+	if pos != 0 {
+		t.Errorf("alternate with 2 runs should finish at 0, got: %v", pos)
+	}
+
+	tw = &Tween{Dur: 100 * time.Millisecond, Repeat: -1, Update: func(p float32) { pos = p }, start: t0}
+	if tw.Step(t0.Add(10*time.Second+30*time.Millisecond)) || !testNear(pos, 0.3) {
+		t.Errorf("repeat forever: %v != 0.3", pos)
+	}
+
+	tw = &Tween{Ease: StepsEasing(2, false), Dur: 100 * time.Millisecond, Update: func(p float32) { pos = p }, start: t0}
+	if tw.Step(t0.Add(30*time.Millisecond)) || pos != 0 {
+		t.Errorf("eased: %v != 0", pos)
+	}
+	tw = &Tween{Update: func(p float32) { pos = p }, start: t0}
+	pos = 0
+	if !tw.Step(t0) || pos != 1 {
+		t.Errorf("zero duration: expected done at 1, got: %v", pos)
+	}
+}
+
+func TestTimelineStep(t *testing.T) {
+	nd := &Layout{}
+	nd.InitName(nd, "node")
+	tl := &Timeline{}
+	var a, b float32
+	tl.Add(&Tween{Node: nd.This, Key: "a", Dur: time.Second, Update: func(p float32) { a = -1 }})
+	tl.Add(&Tween{Node: nd.This, Key: "a", Dur: 100 * time.Millisecond, Update: func(p float32) { a = p }})
+	tl.Add(&Tween{Node: nd.This, Key: "b", Dur: time.Hour, Update: func(p float32) { b = p }})
+	if len(tl.Tweens) != 2 || !tl.IsActive(nd.This, "a") {
+		t.Fatalf("expected replaced tween, got: %v", len(tl.Tweens))
+	}
+	tl.Step(time.Now().Add(time.Second))
+	if a != 1 || b <= 0 || tl.IsActive(nd.This, "a") || !tl.IsActive(nd.This, "b") {
+		t.Errorf("expected a done and b active, got: %v %v %v", a, b, len(tl.Tweens))
+	}
+	tl.Stop(nd.This, "")
+	if tl.IsActive(nd.This, "") {
+		t.Errorf("expected all tweens stopped")
+	}
+}
+
+func TestAnimateNoOSWin(t *testing.T) {
+	win := &Window{}
+	win.InitName(win, "no-oswin")
+	var pos float32
+	done := false
+	win.Animate(&Tween{Dur: time.Second, Update: func(p float32) { pos = p }, Done: func() { done = true }})
+	if pos != 1 || !done || len(win.Anim.Tweens) != 0 {
+		t.Errorf("expected tween finished immediately without an OS window, got: %v %v %v", pos, done, len(win.Anim.Tweens))
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux,!android dragonfly openbsd
+
+// Package atspi provides a gi.AccessBridge that exports the accessibility
+// trees of all GoGi windows over D-Bus using the AT-SPI2 protocol, so that
+// screen readers (e.g., Orca) and other assistive technology on Linux / X11
+// desktops can read and operate GoGi interfaces.
+//
+// The bridge is registered by gimain on these platforms if the
+// GOGI_ACCESSIBILITY environment variable is set (to 1, true or on) -- it
+// connects to the accessibility bus the first time a window renders, and
+// if no accessibility bus is available (e.g., no desktop session), it
+// silently disables itself, and no more accessibility trees are built.
+//
+// Each accessible element is exported as an object under
+// /org/a11y/atspi/accessible/, implementing the Accessible, Component,
+// Action and Value interfaces as appropriate, and the application root
+// object is embedded into the desktop via the AT-SPI registry.
+package atspi
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/goki/gi"
+	"github.com/goki/ki"
+)
+
+// Trace can be set to true to log all calls and events
+var Trace = false
+
+const (
+	// registryName is the bus name of the AT-SPI registry
+	registryName = "org.a11y.atspi.Registry"
+
+	// pathPrefix is the prefix for all accessible object paths
+	pathPrefix = "/org/a11y/atspi/accessible/"
+
+	// rootPath is the path of the application root object
+	rootPath = dbus.ObjectPath(pathPrefix + "root")
+
+	// nullPath is used for null object references
+	nullPath = dbus.ObjectPath("/org/a11y/atspi/null")
+)
+
+// ref is an AT-SPI object reference: (bus name, object path)
+type ref struct {
+	Name string
+	Path dbus.ObjectPath
+}
+
+// object is the bridge's record of one exported accessible object
+type object struct {
+	path dbus.ObjectPath
+	win  *gi.Window     // nil for application root
+	node *gi.AccessNode // nil for application root
+}
+
+// Bridge is the AT-SPI accessibility bridge -- see package docs.
+type Bridge struct {
+	AppName string `desc:"name of the application, reported to the registry -- defaults to oswin.TheApp.Name()"`
+	conn    *dbus.Conn
+	failed  bool
+	desktop ref
+	mu      sync.Mutex
+	wins    []*gi.Window
+	trees   map[*gi.Window]*gi.AccessNode
+	objs    map[dbus.ObjectPath]*object
+	paths   map[ki.Ki]dbus.ObjectPath
+	nextID  int
+}
+
+// NewBridge returns a new bridge -- call gi.RegisterAccessBridge to
+// activate it.
+func NewBridge() *Bridge {
+	br := &Bridge{}
+	br.trees = make(map[*gi.Window]*gi.AccessNode)
+	br.objs = make(map[dbus.ObjectPath]*object)
+	br.paths = make(map[ki.Ki]dbus.ObjectPath)
+	br.objs[rootPath] = &object{path: rootPath}
+	return br
+}
+
+// Connected returns true if connected to the accessibility bus
+func (br *Bridge) Connected() bool {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	return br.conn != nil
+}
+
+// Connect connects to the accessibility bus, exports the objects and
+// embeds the application in the desktop -- called automatically on first
+// tree update -- returns error if AT-SPI is not available, in which case
+// the bridge is disabled.
+func (br *Bridge) Connect() error {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	if br.conn != nil {
+		return nil
+	}
+	if br.failed {
+		return fmt.Errorf("atspi: bridge disabled after previous connection failure")
+	}
+	err := br.connect()
+	if err != nil {
+		br.failed = true
+		if br.conn != nil {
+			br.conn.Close()
+			br.conn = nil
+		}
+		if Trace {
+			log.Printf("atspi: not available: %v\n", err)
+		}
+	}
+	return err
+}
+
+func (br *Bridge) connect() error {
+	sess, err := dbus.SessionBus()
+	if err != nil {
+		return err
+	}
+	var addr string
+	err = sess.Object("org.a11y.Bus", "/org/a11y/bus").Call("org.a11y.Bus.GetAddress", 0).Store(&addr)
+	if err != nil {
+		return err
+	}
+	conn, err := dbus.Dial(addr)
+	if err != nil {
+		return err
+	}
+	br.conn = conn
+	if err = conn.Auth(nil); err != nil {
+		return err
+	}
+	if err = conn.Hello(); err != nil {
+		return err
+	}
+	ex := &exporter{br: br}
+	ifaces := map[string]interface{}{
+		"org.a11y.atspi.Accessible":       accessibleIface{ex},
+		"org.a11y.atspi.Component":        componentIface{ex},
+		"org.a11y.atspi.Action":           actionIface{ex},
+		"org.a11y.atspi.Application":      applicationIface{ex},
+		"org.freedesktop.DBus.Properties": propertiesIface{ex},
+	}
+	for iface, obj := range ifaces {
+		if err = conn.ExportSubtree(obj, pathPrefix, iface); err != nil {
+			return err
+		}
+	}
+	err = conn.Object(registryName, rootPath).Call("org.a11y.atspi.Socket.Embed", 0, ref{br.busName(), rootPath}).Store(&br.desktop)
+	return err
+}
+
+// busName returns our unique bus name
+func (br *Bridge) busName() string {
+	if br.conn == nil {
+		return ""
+	}
+	nms := br.conn.Names()
+	if len(nms) == 0 {
+		return ""
+	}
+	return nms[0]
+}
+
+// ref returns the reference for given object path
+func (br *Bridge) ref(path dbus.ObjectPath) ref {
+	if path == "" {
+		return ref{br.busName(), nullPath}
+	}
+	return ref{br.busName(), path}
+}
+
+// pathFor returns the object path for given element, assigning a new one
+// if needed -- paths are stable across tree updates -- must be called with
+// mu locked
+func (br *Bridge) pathFor(k ki.Ki) dbus.ObjectPath {
+	if path, ok := br.paths[k]; ok {
+		return path
+	}
+	br.nextID++
+	path := dbus.ObjectPath(fmt.Sprintf("%v%d", pathPrefix, br.nextID))
+	br.paths[k] = path
+	return path
+}
+
+// rebuild rebuilds the path -> object maps from the current trees -- must
+// be called with mu locked
+func (br *Bridge) rebuild() {
+	objs := make(map[dbus.ObjectPath]*object, len(br.objs))
+	paths := make(map[ki.Ki]dbus.ObjectPath, len(br.paths))
+	objs[rootPath] = br.objs[rootPath]
+	for _, win := range br.wins {
+		tree := br.trees[win]
+		if tree == nil {
+			continue
+		}
+		tree.FuncDown(0, func(an *gi.AccessNode, level int) bool {
+			path := br.pathFor(an.Node)
+			paths[an.Node] = path
+			objs[path] = &object{path: path, win: win, node: an}
+			return true
+		})
+	}
+	br.objs = objs
+	br.paths = paths
+}
+
+// AccessActive connects to the accessibility bus if not yet tried, and
+// returns true if connected
+func (br *Bridge) AccessActive() bool {
+	return br.Connect() == nil
+}
+
+// AccessTreeUpdated is called by the window after a full re-render
+func (br *Bridge) AccessTreeUpdated(win *gi.Window, tree *gi.AccessNode) {
+	if br.Connect() != nil {
+		return
+	}
+	br.mu.Lock()
+	_, had := br.trees[win]
+	if !had {
+		br.wins = append(br.wins, win)
+	}
+	br.trees[win] = tree
+	br.rebuild()
+	wpath := br.paths[tree.Node]
+	br.mu.Unlock()
+	if !had {
+		br.emit(rootPath, "Object", "ChildrenChanged", "add", int32(len(br.wins)-1), 0, dbus.MakeVariant(br.ref(wpath)))
+	} else {
+		br.emit(wpath, "Object", "ChildrenChanged", "add", -1, 0, dbus.MakeVariant(br.ref(wpath)))
+	}
+}
+
+// AccessFocusChanged is called by the window when the focus changes
+func (br *Bridge) AccessFocusChanged(win *gi.Window, focus ki.Ki) {
+	if !br.Connected() {
+		return
+	}
+	br.mu.Lock()
+	path, ok := br.paths[focus]
+	br.mu.Unlock()
+	if !ok {
+		return
+	}
+	br.emit(path, "Object", "StateChanged", "focused", 1, 0, dbus.MakeVariant(int32(0)))
+	br.emit(path, "Focus", "Focus", "", 0, 0, dbus.MakeVariant(int32(0)))
+}
+
+// AccessWindowClosed is called by the window when it is closed
+func (br *Bridge) AccessWindowClosed(win *gi.Window) {
+	if !br.Connected() {
+		return
+	}
+	br.mu.Lock()
+	tree, had := br.trees[win]
+	if !had {
+		br.mu.Unlock()
+		return
+	}
+	idx := 0
+	for i, w := range br.wins {
+		if w == win {
+			idx = i
+			br.wins = append(br.wins[:i], br.wins[i+1:]...)
+			break
+		}
+	}
+	delete(br.trees, win)
+	wpath := br.paths[tree.Node]
+	br.rebuild()
+	br.mu.Unlock()
+	br.emit(rootPath, "Object", "ChildrenChanged", "remove", int32(idx), 0, dbus.MakeVariant(br.ref(wpath)))
+}
+
+// emit emits an org.a11y.atspi.Event.<iface> signal from given path
+func (br *Bridge) emit(path dbus.ObjectPath, iface, member, detail string, detail1, detail2 int32, val dbus.Variant) {
+	if br.conn == nil || path == "" {
+		return
+	}
+	if Trace {
+		log.Printf("atspi: event %v.%v %v %v from %v\n", iface, member, detail, detail1, path)
+	}
+	props := map[string]dbus.Variant{}
+	br.conn.Emit(path, "org.a11y.atspi.Event."+iface+"."+member, detail, detail1, detail2, val, props)
+}
+
+// object returns the object for given path, nil if not found
+func (br *Bridge) object(path dbus.ObjectPath) *object {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	return br.objs[path]
+}
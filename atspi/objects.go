@@ -0,0 +1,609 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux,!android dragonfly openbsd
+
+package atspi
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/godbus/dbus"
+	"github.com/goki/gi"
+	"github.com/goki/gi/oswin"
+)
+
+// exporter handles all the D-Bus method calls on our exported objects --
+// the object path is obtained from the message, and looked up in the bridge
+// -- it is wrapped in a different type for each interface, as godbus
+// exports all methods of the given value.
+type exporter struct {
+	br *Bridge
+}
+
+// errNoObject is returned for calls on unknown (stale) object paths
+var errNoObject = dbus.NewError("org.freedesktop.DBus.Error.UnknownObject", []interface{}{"atspi: object does not exist"})
+
+// object returns the object that the message was sent to
+func (ex *exporter) object(msg dbus.Message) *object {
+	pv, ok := msg.Headers[dbus.FieldPath]
+	if !ok {
+		return nil
+	}
+	path, _ := pv.Value().(dbus.ObjectPath)
+	if Trace {
+		mv := msg.Headers[dbus.FieldMember]
+		log.Printf("atspi: call %v on %v\n", mv.Value(), path)
+	}
+	return ex.br.object(path)
+}
+
+// children returns the paths of the children of given object
+func (ex *exporter) children(obj *object) []dbus.ObjectPath {
+	br := ex.br
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	var kids []dbus.ObjectPath
+	if obj.node == nil {
+		for _, win := range br.wins {
+			if tree := br.trees[win]; tree != nil {
+				kids = append(kids, br.paths[tree.Node])
+			}
+		}
+		return kids
+	}
+	for _, kn := range obj.node.Kids {
+		kids = append(kids, br.paths[kn.Node])
+	}
+	return kids
+}
+
+// parent returns the reference to the parent of given object
+func (ex *exporter) parent(obj *object) ref {
+	br := ex.br
+	if obj.node == nil {
+		return br.desktop
+	}
+	if obj.node.Parent == nil {
+		return br.ref(rootPath)
+	}
+	br.mu.Lock()
+	path := br.paths[obj.node.Parent.Node]
+	br.mu.Unlock()
+	return br.ref(path)
+}
+
+// info returns the current AccessInfo for given object, refreshed from the
+// element
+func (ex *exporter) info(obj *object) *gi.AccessInfo {
+	if obj.node == nil {
+		return &gi.AccessInfo{Role: gi.RoleNone, Name: ex.br.appName()}
+	}
+	obj.node.Refresh()
+	return &obj.node.AccessInfo
+}
+
+// appName returns the application name
+func (br *Bridge) appName() string {
+	if br.AppName != "" {
+		return br.AppName
+	}
+	if oswin.TheApp != nil {
+		return oswin.TheApp.Name()
+	}
+	return "GoGi"
+}
+
+// role returns the AT-SPI role info for given object
+func (ex *exporter) role(obj *object) roleInfo {
+	if obj.node == nil {
+		return roleInfo{roleApplication, "application"}
+	}
+	return roles[ex.info(obj).Role]
+}
+
+// actions returns the actions exposed through the Action interface for
+// given info -- SetValue, Increment and Decrement are handled through the
+// Value interface instead
+func actions(ai *gi.AccessInfo) []gi.AccessActions {
+	var acts []gi.AccessActions
+	for _, act := range ai.Actions {
+		switch act {
+		case gi.AccessSetValue, gi.AccessIncrement, gi.AccessDecrement:
+			continue
+		}
+		acts = append(acts, act)
+	}
+	return acts
+}
+
+// actionName returns the AT-SPI action name for given action -- the
+// standard names are used where they exist
+func actionName(act gi.AccessActions) string {
+	switch act {
+	case gi.AccessExpand, gi.AccessCollapse:
+		return "expand or contract"
+	case gi.AccessOpenMenu:
+		return "showmenu"
+	}
+	return strings.ToLower(strings.TrimPrefix(act.String(), "Access"))
+}
+
+// hasValue returns true if the given info should expose the Value interface
+func hasValue(ai *gi.AccessInfo) bool {
+	switch ai.Role {
+	case gi.RoleSlider, gi.RoleScrollBar, gi.RoleSpinBox, gi.RoleSplitter:
+		return true
+	}
+	return false
+}
+
+// extents returns the bounds of given object, in screen coordinates if
+// coordType == 0, else window coordinates
+func (ex *exporter) extents(obj *object, coordType uint32) image.Rectangle {
+	if obj.node == nil {
+		return image.ZR
+	}
+	bb := ex.info(obj).Bounds
+	if coordType == 0 && obj.win != nil && obj.win.OSWin != nil {
+		bb = bb.Add(obj.win.OSWin.Position())
+	}
+	return bb
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+// org.a11y.atspi.Accessible
+
+type accessibleIface struct {
+	ex *exporter
+}
+
+func (ai accessibleIface) GetChildAtIndex(msg dbus.Message, idx int32) (ref, *dbus.Error) {
+	obj := ai.ex.object(msg)
+	if obj == nil {
+		return ref{}, errNoObject
+	}
+	kids := ai.ex.children(obj)
+	if idx < 0 || int(idx) >= len(kids) {
+		return ai.ex.br.ref(""), nil
+	}
+	return ai.ex.br.ref(kids[idx]), nil
+}
+
+func (ai accessibleIface) GetChildren(msg dbus.Message) ([]ref, *dbus.Error) {
+	obj := ai.ex.object(msg)
+	if obj == nil {
+		return nil, errNoObject
+	}
+	kids := ai.ex.children(obj)
+	refs := make([]ref, len(kids))
+	for i, kp := range kids {
+		refs[i] = ai.ex.br.ref(kp)
+	}
+	return refs, nil
+}
+
+func (ai accessibleIface) GetIndexInParent(msg dbus.Message) (int32, *dbus.Error) {
+	obj := ai.ex.object(msg)
+	if obj == nil {
+		return -1, errNoObject
+	}
+	if obj.node == nil {
+		return -1, nil
+	}
+	if obj.node.Parent == nil {
+		br := ai.ex.br
+		br.mu.Lock()
+		defer br.mu.Unlock()
+		for i, win := range br.wins {
+			if win == obj.win {
+				return int32(i), nil
+			}
+		}
+		return -1, nil
+	}
+	return int32(obj.node.IndexInParent()), nil
+}
+
+func (ai accessibleIface) GetRelationSet(msg dbus.Message) ([]struct {
+	Type    uint32
+	Targets []ref
+}, *dbus.Error) {
+	return nil, nil
+}
+
+func (ai accessibleIface) GetRole(msg dbus.Message) (uint32, *dbus.Error) {
+	obj := ai.ex.object(msg)
+	if obj == nil {
+		return 0, errNoObject
+	}
+	return ai.ex.role(obj).role, nil
+}
+
+func (ai accessibleIface) GetRoleName(msg dbus.Message) (string, *dbus.Error) {
+	obj := ai.ex.object(msg)
+	if obj == nil {
+		return "", errNoObject
+	}
+	return ai.ex.role(obj).name, nil
+}
+
+func (ai accessibleIface) GetLocalizedRoleName(msg dbus.Message) (string, *dbus.Error) {
+	return ai.GetRoleName(msg)
+}
+
+func (ai accessibleIface) GetState(msg dbus.Message) ([]uint32, *dbus.Error) {
+	obj := ai.ex.object(msg)
+	if obj == nil {
+		return nil, errNoObject
+	}
+	if obj.node == nil {
+		return []uint32{0, 0}, nil
+	}
+	return stateSet(ai.ex.info(obj)), nil
+}
+
+func (ai accessibleIface) GetAttributes(msg dbus.Message) (map[string]string, *dbus.Error) {
+	obj := ai.ex.object(msg)
+	if obj == nil {
+		return nil, errNoObject
+	}
+	attrs := map[string]string{"toolkit": "GoGi"}
+	if obj.node != nil {
+		attrs["id"] = obj.node.Node.UniqueName()
+	}
+	return attrs, nil
+}
+
+func (ai accessibleIface) GetApplication(msg dbus.Message) (ref, *dbus.Error) {
+	return ai.ex.br.ref(rootPath), nil
+}
+
+func (ai accessibleIface) GetInterfaces(msg dbus.Message) ([]string, *dbus.Error) {
+	obj := ai.ex.object(msg)
+	if obj == nil {
+		return nil, errNoObject
+	}
+	if obj.node == nil {
+		return []string{"org.a11y.atspi.Accessible", "org.a11y.atspi.Application"}, nil
+	}
+	ifs := []string{"org.a11y.atspi.Accessible", "org.a11y.atspi.Component"}
+	inf := ai.ex.info(obj)
+	if len(actions(inf)) > 0 {
+		ifs = append(ifs, "org.a11y.atspi.Action")
+	}
+	if hasValue(inf) {
+		ifs = append(ifs, "org.a11y.atspi.Value")
+	}
+	return ifs, nil
+}
+
+// accessibleProp returns the org.a11y.atspi.Accessible properties
+func (ex *exporter) accessibleProp(obj *object, prop string) (interface{}, bool) {
+	switch prop {
+	case "Name":
+		return ex.info(obj).Name, true
+	case "Description":
+		return ex.info(obj).Desc, true
+	case "Parent":
+		return ex.parent(obj), true
+	case "ChildCount":
+		return int32(len(ex.children(obj))), true
+	case "Locale":
+		return "", true
+	case "AccessibleId":
+		if obj.node == nil {
+			return "", true
+		}
+		return obj.node.Node.UniqueName(), true
+	}
+	return nil, false
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+// org.a11y.atspi.Component
+
+type componentIface struct {
+	ex *exporter
+}
+
+type extents struct {
+	X, Y, Width, Height int32
+}
+
+func (ci componentIface) GetExtents(msg dbus.Message, coordType uint32) (extents, *dbus.Error) {
+	obj := ci.ex.object(msg)
+	if obj == nil {
+		return extents{}, errNoObject
+	}
+	bb := ci.ex.extents(obj, coordType)
+	return extents{int32(bb.Min.X), int32(bb.Min.Y), int32(bb.Dx()), int32(bb.Dy())}, nil
+}
+
+func (ci componentIface) GetPosition(msg dbus.Message, coordType uint32) (int32, int32, *dbus.Error) {
+	obj := ci.ex.object(msg)
+	if obj == nil {
+		return 0, 0, errNoObject
+	}
+	bb := ci.ex.extents(obj, coordType)
+	return int32(bb.Min.X), int32(bb.Min.Y), nil
+}
+
+func (ci componentIface) GetSize(msg dbus.Message) (int32, int32, *dbus.Error) {
+	obj := ci.ex.object(msg)
+	if obj == nil {
+		return 0, 0, errNoObject
+	}
+	bb := ci.ex.extents(obj, 1)
+	return int32(bb.Dx()), int32(bb.Dy()), nil
+}
+
+func (ci componentIface) Contains(msg dbus.Message, x, y int32, coordType uint32) (bool, *dbus.Error) {
+	obj := ci.ex.object(msg)
+	if obj == nil {
+		return false, errNoObject
+	}
+	return image.Point{int(x), int(y)}.In(ci.ex.extents(obj, coordType)), nil
+}
+
+func (ci componentIface) GetAccessibleAtPoint(msg dbus.Message, x, y int32, coordType uint32) (ref, *dbus.Error) {
+	obj := ci.ex.object(msg)
+	if obj == nil {
+		return ref{}, errNoObject
+	}
+	pt := image.Point{int(x), int(y)}
+	if obj.node == nil || !pt.In(ci.ex.extents(obj, coordType)) {
+		return ci.ex.br.ref(""), nil
+	}
+	if coordType == 0 && obj.win != nil && obj.win.OSWin != nil {
+		pt = pt.Sub(obj.win.OSWin.Position())
+	}
+	// deepest node containing point
+	var hit *gi.AccessNode
+	obj.node.FuncDown(0, func(an *gi.AccessNode, level int) bool {
+		if !pt.In(an.Bounds) {
+			return false
+		}
+		if an != obj.node {
+			hit = an
+		}
+		return true
+	})
+	if hit == nil {
+		return ci.ex.br.ref(""), nil
+	}
+	br := ci.ex.br
+	br.mu.Lock()
+	path := br.paths[hit.Node]
+	br.mu.Unlock()
+	return br.ref(path), nil
+}
+
+func (ci componentIface) GetLayer(msg dbus.Message) (uint32, *dbus.Error) {
+	return 3, nil // ATSPI_LAYER_WIDGET
+}
+
+func (ci componentIface) GetMDIZOrder(msg dbus.Message) (int16, *dbus.Error) {
+	return 0, nil
+}
+
+func (ci componentIface) GetAlpha(msg dbus.Message) (float64, *dbus.Error) {
+	return 1, nil
+}
+
+func (ci componentIface) GrabFocus(msg dbus.Message) (bool, *dbus.Error) {
+	obj := ci.ex.object(msg)
+	if obj == nil {
+		return false, errNoObject
+	}
+	if obj.node == nil {
+		return false, nil
+	}
+	return obj.win.AccessDoAction(obj.node.Node, gi.AccessFocus, ""), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+// org.a11y.atspi.Action
+
+type actionIface struct {
+	ex *exporter
+}
+
+// action returns the object's idx'th action
+func (ai actionIface) action(msg dbus.Message, idx int32) (*object, gi.AccessActions, *dbus.Error) {
+	obj := ai.ex.object(msg)
+	if obj == nil || obj.node == nil {
+		return nil, 0, errNoObject
+	}
+	acts := actions(ai.ex.info(obj))
+	if idx < 0 || int(idx) >= len(acts) {
+		return nil, 0, dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{fmt.Sprintf("atspi: action index %v out of range", idx)})
+	}
+	return obj, acts[idx], nil
+}
+
+func (ai actionIface) GetName(msg dbus.Message, idx int32) (string, *dbus.Error) {
+	_, act, err := ai.action(msg, idx)
+	if err != nil {
+		return "", err
+	}
+	return actionName(act), nil
+}
+
+func (ai actionIface) GetLocalizedName(msg dbus.Message, idx int32) (string, *dbus.Error) {
+	return ai.GetName(msg, idx)
+}
+
+func (ai actionIface) GetDescription(msg dbus.Message, idx int32) (string, *dbus.Error) {
+	return "", nil
+}
+
+func (ai actionIface) GetKeyBinding(msg dbus.Message, idx int32) (string, *dbus.Error) {
+	return "", nil
+}
+
+func (ai actionIface) GetActions(msg dbus.Message) ([]struct {
+	Name, Desc, KeyBinding string
+}, *dbus.Error) {
+	obj := ai.ex.object(msg)
+	if obj == nil || obj.node == nil {
+		return nil, errNoObject
+	}
+	acts := actions(ai.ex.info(obj))
+	res := make([]struct{ Name, Desc, KeyBinding string }, len(acts))
+	for i, act := range acts {
+		res[i].Name = actionName(act)
+	}
+	return res, nil
+}
+
+func (ai actionIface) DoAction(msg dbus.Message, idx int32) (bool, *dbus.Error) {
+	obj, act, err := ai.action(msg, idx)
+	if err != nil {
+		return false, err
+	}
+	if act == gi.AccessExpand || act == gi.AccessCollapse { // "expand or contract" toggles
+		if ai.ex.info(obj).HasState(gi.AccessExpanded) {
+			act = gi.AccessCollapse
+		} else {
+			act = gi.AccessExpand
+		}
+	}
+	return obj.win.AccessDoAction(obj.node.Node, act, ""), nil
+}
+
+// actionProp returns the org.a11y.atspi.Action properties
+func (ex *exporter) actionProp(obj *object, prop string) (interface{}, bool) {
+	if prop == "NActions" && obj.node != nil {
+		return int32(len(actions(ex.info(obj)))), true
+	}
+	return nil, false
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+// org.a11y.atspi.Value -- properties only
+
+// valueProp returns the org.a11y.atspi.Value properties
+func (ex *exporter) valueProp(obj *object, prop string) (interface{}, bool) {
+	if obj.node == nil {
+		return nil, false
+	}
+	inf := ex.info(obj)
+	switch prop {
+	case "MinimumValue":
+		return float64(inf.Min), true
+	case "MaximumValue":
+		return float64(inf.Max), true
+	case "MinimumIncrement":
+		return float64(inf.Step), true
+	case "CurrentValue":
+		v, _ := strconv.ParseFloat(inf.Value, 64)
+		return v, true
+	}
+	return nil, false
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+// org.a11y.atspi.Application -- properties only, on root
+
+type applicationIface struct {
+	ex *exporter
+}
+
+// applicationProp returns the org.a11y.atspi.Application properties
+func (ex *exporter) applicationProp(obj *object, prop string) (interface{}, bool) {
+	switch prop {
+	case "ToolkitName":
+		return "GoGi", true
+	case "Version":
+		return "", true
+	case "AtspiVersion":
+		return "2.1", true
+	case "Id":
+		return int32(0), true
+	}
+	return nil, false
+}
+
+// SetId is set by the registry
+func (ai applicationIface) SetId(msg dbus.Message, id int32) *dbus.Error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+// org.freedesktop.DBus.Properties
+
+type propertiesIface struct {
+	ex *exporter
+}
+
+// prop returns given property of given interface
+func (ex *exporter) prop(obj *object, iface, prop string) (interface{}, bool) {
+	switch iface {
+	case "org.a11y.atspi.Accessible":
+		return ex.accessibleProp(obj, prop)
+	case "org.a11y.atspi.Action":
+		return ex.actionProp(obj, prop)
+	case "org.a11y.atspi.Value":
+		return ex.valueProp(obj, prop)
+	case "org.a11y.atspi.Application":
+		return ex.applicationProp(obj, prop)
+	}
+	return nil, false
+}
+
+// propNames are the property names for each interface
+var propNames = map[string][]string{
+	"org.a11y.atspi.Accessible":  {"Name", "Description", "Parent", "ChildCount", "Locale", "AccessibleId"},
+	"org.a11y.atspi.Action":      {"NActions"},
+	"org.a11y.atspi.Value":       {"MinimumValue", "MaximumValue", "MinimumIncrement", "CurrentValue"},
+	"org.a11y.atspi.Application": {"ToolkitName", "Version", "AtspiVersion", "Id"},
+}
+
+func (pi propertiesIface) Get(msg dbus.Message, iface, prop string) (dbus.Variant, *dbus.Error) {
+	obj := pi.ex.object(msg)
+	if obj == nil {
+		return dbus.Variant{}, errNoObject
+	}
+	v, ok := pi.ex.prop(obj, iface, prop)
+	if !ok {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.UnknownProperty", []interface{}{iface + "." + prop})
+	}
+	return dbus.MakeVariant(v), nil
+}
+
+func (pi propertiesIface) GetAll(msg dbus.Message, iface string) (map[string]dbus.Variant, *dbus.Error) {
+	obj := pi.ex.object(msg)
+	if obj == nil {
+		return nil, errNoObject
+	}
+	props := make(map[string]dbus.Variant)
+	for _, prop := range propNames[iface] {
+		if v, ok := pi.ex.prop(obj, iface, prop); ok {
+			props[prop] = dbus.MakeVariant(v)
+		}
+	}
+	return props, nil
+}
+
+// Set only supports setting Value.CurrentValue
+func (pi propertiesIface) Set(msg dbus.Message, iface, prop string, val dbus.Variant) *dbus.Error {
+	obj := pi.ex.object(msg)
+	if obj == nil || obj.node == nil {
+		return errNoObject
+	}
+	if iface != "org.a11y.atspi.Value" || prop != "CurrentValue" {
+		return dbus.NewError("org.freedesktop.DBus.Error.PropertyReadOnly", []interface{}{iface + "." + prop})
+	}
+	v, ok := val.Value().(float64)
+	if !ok {
+		return dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{"atspi: CurrentValue must be a double"})
+	}
+	obj.win.AccessDoAction(obj.node.Node, gi.AccessSetValue, strconv.FormatFloat(v, 'g', -1, 64))
+	return nil
+}
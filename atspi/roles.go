@@ -0,0 +1,162 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux,!android dragonfly openbsd
+
+package atspi
+
+import (
+	"github.com/goki/gi"
+)
+
+// AT-SPI role values, from the AtspiRole enum in atspi-constants.h (only
+// those used)
+const (
+	roleCheckBox     = 7
+	roleComboBox     = 11
+	roleDialog       = 16
+	roleFrame        = 23
+	roleImage        = 27
+	roleLabel        = 29
+	roleMenu         = 33
+	roleMenuBar      = 34
+	roleMenuItem     = 35
+	rolePageTab      = 37
+	rolePageTabList  = 38
+	rolePanel        = 39
+	rolePushButton   = 43
+	roleScrollBar    = 48
+	roleSeparator    = 50
+	roleSlider       = 51
+	roleSpinButton   = 52
+	roleSplitPane    = 53
+	roleTable        = 55
+	roleTableCell    = 56
+	roleText         = 61
+	roleToggleButton = 62
+	roleToolBar      = 63
+	roleToolTip      = 64
+	roleTree         = 65
+	roleUnknown      = 67
+	roleApplication  = 75
+)
+
+// roleInfo is the AT-SPI role and role name for a gi.AccessRoles value
+type roleInfo struct {
+	role uint32
+	name string
+}
+
+// roles maps gi.AccessRoles to AT-SPI roles
+var roles = [gi.AccessRolesN]roleInfo{
+	gi.RoleNone:         {roleUnknown, "unknown"},
+	gi.RoleWindow:       {roleFrame, "frame"},
+	gi.RoleDialog:       {roleDialog, "dialog"},
+	gi.RoleGroup:        {rolePanel, "panel"},
+	gi.RoleLabel:        {roleLabel, "label"},
+	gi.RoleImage:        {roleImage, "image"},
+	gi.RoleButton:       {rolePushButton, "push button"},
+	gi.RoleToggleButton: {roleToggleButton, "toggle button"},
+	gi.RoleCheckBox:     {roleCheckBox, "check box"},
+	gi.RoleMenuButton:   {rolePushButton, "push button"},
+	gi.RoleMenu:         {roleMenu, "menu"},
+	gi.RoleMenuItem:     {roleMenuItem, "menu item"},
+	gi.RoleMenuBar:      {roleMenuBar, "menu bar"},
+	gi.RoleToolBar:      {roleToolBar, "tool bar"},
+	gi.RoleTextField:    {roleText, "text"},
+	gi.RoleTextArea:     {roleText, "text"},
+	gi.RoleSlider:       {roleSlider, "slider"},
+	gi.RoleScrollBar:    {roleScrollBar, "scroll bar"},
+	gi.RoleSpinBox:      {roleSpinButton, "spin button"},
+	gi.RoleComboBox:     {roleComboBox, "combo box"},
+	gi.RoleTabList:      {rolePageTabList, "page tab list"},
+	gi.RoleTab:          {rolePageTab, "page tab"},
+	gi.RoleTree:         {roleTree, "tree"},
+	gi.RoleTreeItem:     {roleTableCell, "table cell"}, // as gtk tree views report
+	gi.RoleTable:        {roleTable, "table"},
+	gi.RoleTableCell:    {roleTableCell, "table cell"},
+	gi.RoleSplitter:     {roleSplitPane, "split pane"},
+	gi.RoleSeparator:    {roleSeparator, "separator"},
+	gi.RoleToolTip:      {roleToolTip, "tool tip"},
+}
+
+// AT-SPI state values, from the AtspiStateType enum (only those used)
+const (
+	stateActive     = 1
+	stateChecked    = 4
+	stateCollapsed  = 5
+	stateEditable   = 7
+	stateEnabled    = 8
+	stateExpandable = 9
+	stateExpanded   = 10
+	stateFocusable  = 11
+	stateFocused    = 12
+	stateHorizontal = 14
+	stateModal      = 16
+	stateMultiLine  = 17
+	statePressed    = 20
+	stateSelectable = 22
+	stateSelected   = 23
+	stateSensitive  = 24
+	stateShowing    = 25
+	stateSingleLine = 26
+	stateVertical   = 29
+	stateVisible    = 30
+	stateCheckable  = 41
+	stateHasPopup   = 42
+)
+
+// states maps gi.AccessStates to AT-SPI states, for those that map directly
+var states = map[gi.AccessStates]uint32{
+	gi.AccessFocusable:  stateFocusable,
+	gi.AccessFocused:    stateFocused,
+	gi.AccessSelectable: stateSelectable,
+	gi.AccessSelected:   stateSelected,
+	gi.AccessCheckable:  stateCheckable,
+	gi.AccessChecked:    stateChecked,
+	gi.AccessPressed:    statePressed,
+	gi.AccessExpandable: stateExpandable,
+	gi.AccessExpanded:   stateExpanded,
+	gi.AccessEditable:   stateEditable,
+	gi.AccessMultiLine:  stateMultiLine,
+	gi.AccessHasPopup:   stateHasPopup,
+	gi.AccessModal:      stateModal,
+}
+
+// stateSet returns the AT-SPI state set (two 32-bit words) for given info
+func stateSet(ai *gi.AccessInfo) []uint32 {
+	set := []uint32{0, 0}
+	add := func(st uint32) {
+		set[st/32] |= 1 << (st % 32)
+	}
+	for gs, st := range states {
+		if ai.HasState(gs) {
+			add(st)
+		}
+	}
+	if !ai.HasState(gi.AccessDisabled) {
+		add(stateEnabled)
+		add(stateSensitive)
+	}
+	if !ai.HasState(gi.AccessInvisible) {
+		add(stateVisible)
+		add(stateShowing)
+	}
+	if ai.HasState(gi.AccessExpandable) && !ai.HasState(gi.AccessExpanded) {
+		add(stateCollapsed)
+	}
+	switch ai.Role {
+	case gi.RoleTextField:
+		add(stateSingleLine)
+	case gi.RoleSlider, gi.RoleScrollBar, gi.RoleSplitter, gi.RoleSeparator:
+		if ai.HasState(gi.AccessVertical) {
+			add(stateVertical)
+		} else {
+			add(stateHorizontal)
+		}
+	case gi.RoleWindow, gi.RoleDialog:
+		add(stateActive)
+	}
+	return set
+}
@@ -89,6 +89,13 @@ func (mb *MenuBar) Render2D() {
 	}
 }
 
+// AccessInfo returns the accessibility info for the menu bar
+func (mb *MenuBar) AccessInfo() AccessInfo {
+	ai := mb.WidgetBase.AccessInfo()
+	ai.Role = RoleMenuBar
+	return ai
+}
+
 // UpdateActions calls UpdateFunc on all actions in menu -- individual menus
 // are automatically updated just prior to menu popup
 func (mb *MenuBar) UpdateActions() {
@@ -314,6 +321,13 @@ func (tb *ToolBar) Render2D() {
 	}
 }
 
+// AccessInfo returns the accessibility info for the toolbar
+func (tb *ToolBar) AccessInfo() AccessInfo {
+	ai := tb.WidgetBase.AccessInfo()
+	ai.Role = RoleToolBar
+	return ai
+}
+
 func (tb *ToolBar) MouseFocusEvent() {
 	tb.ConnectEvent(oswin.MouseFocusEvent, HiPri, func(recv, send ki.Ki, sig int64, d interface{}) {
 		me := d.(*mouse.FocusEvent)
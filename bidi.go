@@ -0,0 +1,508 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"golang.org/x/text/unicode/bidi"
+)
+
+// bidi.go implements the Unicode Bidirectional Algorithm (UAX #9) for
+// computing the embedding levels of the runes in a paragraph, and the visual
+// ordering of a line of text from those levels -- golang.org/x/text provides
+// the character class data, but its Paragraph API is not functional yet.
+// Isolating run sequences are approximated by level runs, and bracket pairs
+// (rule N0) are not matched -- otherwise the standard rules are applied.
+
+// BidiMaxDepth is the maximum explicit embedding depth (rule BD2)
+const BidiMaxDepth = 125
+
+// BidiClassOf returns the bidi character class of given rune
+func BidiClassOf(r rune) bidi.Class {
+	p, _ := bidi.LookupRune(r)
+	return p.Class()
+}
+
+// BidiParaLevel returns the paragraph embedding level (0 = LTR, 1 = RTL)
+// for given text, from the first strong character (rules P2, P3), skipping
+// over any isolates -- returns 0 if there are no strong characters.
+func BidiParaLevel(txt []rune) uint8 {
+	iso := 0
+	for _, r := range txt {
+		switch BidiClassOf(r) {
+		case bidi.L:
+			if iso == 0 {
+				return 0
+			}
+		case bidi.R, bidi.AL:
+			if iso == 0 {
+				return 1
+			}
+		case bidi.LRI, bidi.RLI, bidi.FSI:
+			iso++
+		case bidi.PDI:
+			if iso > 0 {
+				iso--
+			}
+		case bidi.B:
+			return 0
+		}
+	}
+	return 0
+}
+
+// bidiStatus is an entry on the directional status stack (rules X1-X8)
+type bidiStatus struct {
+	level    uint8
+	override bidi.Class // L, R or ON = none
+	isolate  bool
+}
+
+// BidiLevels computes the embedding level for each rune in given paragraph
+// text -- paraLevel is the paragraph embedding level (0 = LTR, 1 = RTL), or
+// -1 to determine it from the text -- returns the levels and the paragraph
+// level used.  If override is true, all runes are given the paragraph
+// direction (as in the CSS unicode-bidi: bidi-override style).  Rules L1 -
+// L2 are applied per line by BidiVisualOrder.
+func BidiLevels(txt []rune, paraLevel int, override bool) ([]uint8, uint8) {
+	sz := len(txt)
+	plev := uint8(0)
+	if paraLevel < 0 {
+		plev = BidiParaLevel(txt)
+	} else {
+		plev = uint8(paraLevel & 1)
+	}
+	levels := make([]uint8, sz)
+	if sz == 0 {
+		return levels, plev
+	}
+	if override {
+		for i := range levels {
+			levels[i] = plev
+		}
+		return levels, plev
+	}
+	cls := make([]bidi.Class, sz)
+	hasRTL := false
+	for i, r := range txt {
+		cls[i] = BidiClassOf(r)
+		switch cls[i] {
+		case bidi.R, bidi.AL, bidi.AN, bidi.RLE, bidi.RLO, bidi.RLI, bidi.FSI:
+			hasRTL = true
+		}
+	}
+	if !hasRTL && plev == 0 { // fast path for the usual case
+		return levels, plev
+	}
+	removed := bidiExplicit(txt, cls, levels, plev)
+
+	// X10: level runs, each processed as an isolating run sequence
+	idxs := make([]int, 0, sz)
+	st := 0
+	for st < sz {
+		for st < sz && removed[st] {
+			st++
+		}
+		if st >= sz {
+			break
+		}
+		lev := levels[st]
+		idxs = idxs[:0]
+		ed := st
+		for ed < sz && (removed[ed] || levels[ed] == lev) {
+			if !removed[ed] {
+				idxs = append(idxs, ed)
+			}
+			ed++
+		}
+		prev := plev
+		for p := st - 1; p >= 0; p-- {
+			if !removed[p] {
+				prev = levels[p]
+				break
+			}
+		}
+		next := plev
+		for n := ed; n < sz; n++ {
+			if !removed[n] {
+				next = levels[n]
+				break
+			}
+		}
+		sos := bidiLevelDir(maxLevel(prev, lev))
+		eos := bidiLevelDir(maxLevel(next, lev))
+		bidiResolveRun(cls, idxs, lev, sos, eos)
+		st = ed
+	}
+
+	// I1, I2: implicit levels
+	for i := range txt {
+		if removed[i] {
+			continue
+		}
+		lev := levels[i]
+		switch {
+		case lev&1 == 0 && cls[i] == bidi.R:
+			levels[i]++
+		case lev&1 == 0 && (cls[i] == bidi.AN || cls[i] == bidi.EN):
+			levels[i] += 2
+		case lev&1 == 1 && (cls[i] == bidi.L || cls[i] == bidi.EN || cls[i] == bidi.AN):
+			levels[i]++
+		}
+	}
+	// removed chars (X9) take the level of the preceding char, so they stay
+	// within their run
+	for i := range txt {
+		if removed[i] {
+			if i > 0 {
+				levels[i] = levels[i-1]
+			} else {
+				levels[i] = plev
+			}
+		}
+	}
+	return levels, plev
+}
+
+// bidiExplicit applies the explicit embedding rules X1-X9, setting levels and
+// updating classes for overrides -- returns the chars removed by X9
+func bidiExplicit(txt []rune, cls []bidi.Class, levels []uint8, plev uint8) []bool {
+	sz := len(txt)
+	removed := make([]bool, sz)
+	stack := make([]bidiStatus, 1, 8)
+	stack[0] = bidiStatus{level: plev, override: bidi.ON}
+	ovfIso, ovfEmb, validIso := 0, 0, 0
+	for i := 0; i < sz; i++ {
+		top := stack[len(stack)-1]
+		c := cls[i]
+		switch c {
+		case bidi.RLE, bidi.LRE, bidi.RLO, bidi.LRO:
+			nl := nextOdd(top.level)
+			if c == bidi.LRE || c == bidi.LRO {
+				nl = nextEven(top.level)
+			}
+			if nl <= BidiMaxDepth && ovfIso == 0 && ovfEmb == 0 {
+				ov := bidi.ON
+				if c == bidi.RLO {
+					ov = bidi.R
+				} else if c == bidi.LRO {
+					ov = bidi.L
+				}
+				stack = append(stack, bidiStatus{level: nl, override: ov})
+			} else if ovfIso == 0 {
+				ovfEmb++
+			}
+			levels[i] = top.level
+			removed[i] = true
+		case bidi.RLI, bidi.LRI, bidi.FSI:
+			levels[i] = top.level
+			if top.override != bidi.ON {
+				cls[i] = top.override
+			}
+			rtl := c == bidi.RLI
+			if c == bidi.FSI {
+				ed := bidiMatchingPDI(cls, i)
+				rtl = BidiParaLevel(txt[i+1:ed]) == 1
+			}
+			nl := nextEven(top.level)
+			if rtl {
+				nl = nextOdd(top.level)
+			}
+			if nl <= BidiMaxDepth && ovfIso == 0 && ovfEmb == 0 {
+				validIso++
+				stack = append(stack, bidiStatus{level: nl, override: bidi.ON, isolate: true})
+			} else {
+				ovfIso++
+			}
+		case bidi.PDI:
+			if ovfIso > 0 {
+				ovfIso--
+			} else if validIso > 0 {
+				ovfEmb = 0
+				for !stack[len(stack)-1].isolate {
+					stack = stack[:len(stack)-1]
+				}
+				stack = stack[:len(stack)-1]
+				validIso--
+			}
+			top = stack[len(stack)-1]
+			levels[i] = top.level
+			if top.override != bidi.ON {
+				cls[i] = top.override
+			}
+		case bidi.PDF:
+			if ovfIso > 0 {
+			} else if ovfEmb > 0 {
+				ovfEmb--
+			} else if !top.isolate && len(stack) >= 2 {
+				stack = stack[:len(stack)-1]
+			}
+			levels[i] = top.level
+			removed[i] = true
+		case bidi.B:
+			levels[i] = plev
+		case bidi.BN:
+			levels[i] = top.level
+			removed[i] = true
+		default:
+			levels[i] = top.level
+			if top.override != bidi.ON {
+				cls[i] = top.override
+			}
+		}
+	}
+	return removed
+}
+
+// bidiMatchingPDI returns the index of the PDI matching the isolate
+// initiator at given index, or len(cls) if none
+func bidiMatchingPDI(cls []bidi.Class, st int) int {
+	depth := 1
+	for i := st + 1; i < len(cls); i++ {
+		switch cls[i] {
+		case bidi.LRI, bidi.RLI, bidi.FSI:
+			depth++
+		case bidi.PDI:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case bidi.B:
+			return i
+		}
+	}
+	return len(cls)
+}
+
+// bidiResolveRun applies the weak (W1-W7) and neutral (N1-N2) rules to the
+// chars at given indexes, which form one isolating run sequence at given
+// level, with given start and end of sequence types (L or R)
+func bidiResolveRun(cls []bidi.Class, idxs []int, lev uint8, sos, eos bidi.Class) {
+	n := len(idxs)
+	// W1: NSM
+	prev := sos
+	for _, i := range idxs {
+		c := cls[i]
+		if c == bidi.NSM {
+			switch prev {
+			case bidi.LRI, bidi.RLI, bidi.FSI, bidi.PDI:
+				cls[i] = bidi.ON
+			default:
+				cls[i] = prev
+			}
+		}
+		prev = cls[i]
+	}
+	// W2: EN after AL -> AN, W3: AL -> R
+	strong := sos
+	for _, i := range idxs {
+		switch cls[i] {
+		case bidi.L, bidi.R:
+			strong = cls[i]
+		case bidi.AL:
+			strong = bidi.AL
+			cls[i] = bidi.R
+		case bidi.EN:
+			if strong == bidi.AL {
+				cls[i] = bidi.AN
+			}
+		}
+	}
+	// W4: single separators between numbers
+	for k := 1; k < n-1; k++ {
+		i := idxs[k]
+		pc, nc := cls[idxs[k-1]], cls[idxs[k+1]]
+		switch cls[i] {
+		case bidi.ES:
+			if pc == bidi.EN && nc == bidi.EN {
+				cls[i] = bidi.EN
+			}
+		case bidi.CS:
+			if pc == nc && (pc == bidi.EN || pc == bidi.AN) {
+				cls[i] = pc
+			}
+		}
+	}
+	// W5: terminators adjacent to EN
+	for k := 0; k < n; k++ {
+		if cls[idxs[k]] != bidi.ET {
+			continue
+		}
+		ed := k
+		for ed < n && cls[idxs[ed]] == bidi.ET {
+			ed++
+		}
+		if (k > 0 && cls[idxs[k-1]] == bidi.EN) || (ed < n && cls[idxs[ed]] == bidi.EN) {
+			for j := k; j < ed; j++ {
+				cls[idxs[j]] = bidi.EN
+			}
+		}
+		k = ed - 1
+	}
+	// W6: remaining separators and terminators -> ON
+	for _, i := range idxs {
+		switch cls[i] {
+		case bidi.ES, bidi.ET, bidi.CS:
+			cls[i] = bidi.ON
+		}
+	}
+	// W7: EN after L -> L
+	strong = sos
+	for _, i := range idxs {
+		switch cls[i] {
+		case bidi.L, bidi.R:
+			strong = cls[i]
+		case bidi.EN:
+			if strong == bidi.L {
+				cls[i] = bidi.L
+			}
+		}
+	}
+	// N1, N2: neutrals take the direction of surrounding strong text if the
+	// same, else the embedding direction
+	edir := bidiLevelDir(lev)
+	for k := 0; k < n; k++ {
+		if !bidiIsNeutral(cls[idxs[k]]) {
+			continue
+		}
+		ed := k
+		for ed < n && bidiIsNeutral(cls[idxs[ed]]) {
+			ed++
+		}
+		before := sos
+		if k > 0 {
+			before = bidiStrongDir(cls[idxs[k-1]])
+		}
+		after := eos
+		if ed < n {
+			after = bidiStrongDir(cls[idxs[ed]])
+		}
+		dir := edir
+		if before == after {
+			dir = before
+		}
+		for j := k; j < ed; j++ {
+			cls[idxs[j]] = dir
+		}
+		k = ed - 1
+	}
+}
+
+// bidiIsNeutral returns true for neutral and isolate classes (NI)
+func bidiIsNeutral(c bidi.Class) bool {
+	switch c {
+	case bidi.B, bidi.S, bidi.WS, bidi.ON, bidi.LRI, bidi.RLI, bidi.FSI, bidi.PDI:
+		return true
+	}
+	return false
+}
+
+// bidiStrongDir returns the direction of a resolved class for rule N1 --
+// numbers count as R
+func bidiStrongDir(c bidi.Class) bidi.Class {
+	if c == bidi.L {
+		return bidi.L
+	}
+	return bidi.R
+}
+
+// bidiLevelDir returns the direction (L or R) of given level
+func bidiLevelDir(lev uint8) bidi.Class {
+	if lev&1 == 1 {
+		return bidi.R
+	}
+	return bidi.L
+}
+
+func nextOdd(lev uint8) uint8 {
+	if lev&1 == 1 {
+		return lev + 2
+	}
+	return lev + 1
+}
+
+func nextEven(lev uint8) uint8 {
+	if lev&1 == 1 {
+		return lev + 1
+	}
+	return lev + 2
+}
+
+func maxLevel(a, b uint8) uint8 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// BidiLineLevels applies rule L1 to the levels of one line of text, resetting
+// segment and paragraph separators, and any whitespace before them or at the
+// end of the line, to the paragraph level -- levels are modified in place.
+func BidiLineLevels(txt []rune, levels []uint8, plev uint8) {
+	trail := true
+	for i := len(txt) - 1; i >= 0; i-- {
+		switch BidiClassOf(txt[i]) {
+		case bidi.S, bidi.B:
+			levels[i] = plev
+			trail = true
+		case bidi.WS, bidi.LRI, bidi.RLI, bidi.FSI, bidi.PDI, bidi.BN,
+			bidi.LRE, bidi.RLE, bidi.LRO, bidi.RLO, bidi.PDF:
+			if trail {
+				levels[i] = plev
+			}
+		default:
+			trail = false
+		}
+	}
+}
+
+// BidiVisualOrder returns the logical index of each rune in visual
+// (left-to-right display) order, for one line of text with given levels
+// (after BidiLineLevels), by reversing each sequence of runes at or above
+// each odd level, from the highest level down (rule L2).
+func BidiVisualOrder(levels []uint8) []int {
+	sz := len(levels)
+	order := make([]int, sz)
+	hi := uint8(0)
+	lo := uint8(255)
+	for i, lev := range levels {
+		order[i] = i
+		if lev > hi {
+			hi = lev
+		}
+		if lev&1 == 1 && lev < lo {
+			lo = lev
+		}
+	}
+	for lev := hi; lev >= lo && lev > 0; lev-- {
+		for i := 0; i < sz; i++ {
+			if levels[order[i]] < lev {
+				continue
+			}
+			ed := i
+			for ed < sz && levels[order[ed]] >= lev {
+				ed++
+			}
+			for a, b := i, ed-1; a < b; a, b = a+1, b-1 {
+				order[a], order[b] = order[b], order[a]
+			}
+			i = ed
+		}
+	}
+	return order
+}
+
+// bidiMirrors are the common mirrored glyph pairs, drawn mirrored in
+// right-to-left text (rule L4)
+var bidiMirrors = map[rune]rune{
+	'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{',
+	'<': '>', '>': '<', '«': '»', '»': '«', '‹': '›', '›': '‹',
+	'≤': '≥', '≥': '≤',
+}
+
+// BidiMirror returns the mirrored glyph for given rune, for display in
+// right-to-left text, or 0 if it has none
+func BidiMirror(r rune) rune {
+	return bidiMirrors[r]
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+)
+
+// visual returns the visual order string of given logical string
+func visual(str string, plev int) string {
+	txt := []rune(str)
+	levels, pl := BidiLevels(txt, plev, false)
+	BidiLineLevels(txt, levels, pl)
+	order := BidiVisualOrder(levels)
+	vis := make([]rune, len(txt))
+	for i, li := range order {
+		vis[i] = txt[li]
+	}
+	return string(vis)
+}
+
+func TestBidiVisualOrder(t *testing.T) {
+	tests := []struct {
+		logical string
+		plev    int
+		visual  string
+	}{
+		{"hello world", -1, "hello world"},
+		{"abc אבג def", -1, "abc גבא def"},
+		{"אבג abc דהו", -1, "והד abc גבא"},
+		{"אבג 123 דהו", -1, "והד 123 גבא"},
+		{"abc אב", 1, "בא abc"},
+		{"שלום!", -1, "!םולש"},
+		{"abc‫אב cd‬", -1, "abc‫cd בא‬"},
+	}
+	for _, ts := range tests {
+		got := visual(ts.logical, ts.plev)
+		if got != ts.visual {
+			t.Errorf("logical: %q  visual: %q != %q", ts.logical, got, ts.visual)
+		}
+	}
+}
+
+func TestBidiParaLevel(t *testing.T) {
+	if lev := BidiParaLevel([]rune("123 שלום abc")); lev != 1 {
+		t.Errorf("para level: %v != 1", lev)
+	}
+	if lev := BidiParaLevel([]rune("⁧שלום⁩ abc")); lev != 0 {
+		t.Errorf("para level skipping isolate: %v != 0", lev)
+	}
+}
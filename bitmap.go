@@ -65,6 +65,13 @@ func (bm *Bitmap) Render2D() {
 	}
 }
 
+// AccessInfo returns the accessibility info for the bitmap
+func (bm *Bitmap) AccessInfo() AccessInfo {
+	ai := bm.WidgetBase.AccessInfo()
+	ai.Role = RoleImage
+	return ai
+}
+
 //////////////////////////////////////////////////////////////////////////////////
 //  Image IO
 
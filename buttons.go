@@ -215,8 +215,10 @@ func (bb *ButtonBase) SetButtonState(state ButtonStates) bool {
 		}
 	}
 	bb.State = state
+	from := bb.Sty
 	bb.Sty = bb.StateStyles[state]
 	if prev != bb.State {
+		bb.StartTransitions(&from)
 		bb.SetFullReRenderIconLabel() // needs full rerender to update text, icon
 		return true
 	}
@@ -244,9 +246,11 @@ func (bb *ButtonBase) UpdateButtonStyle() bool {
 			bb.State = ButtonActive
 		}
 	}
+	from := bb.Sty
 	bb.Sty = bb.StateStyles[bb.State]
 	bb.This.(ButtonWidget).ConfigPartsIfNeeded()
 	if prev != bb.State {
+		bb.StartTransitions(&from)
 		bb.SetFullReRenderIconLabel() // needs full rerender
 		return true
 	}
@@ -555,6 +559,7 @@ func (bb *ButtonBase) StyleButton() {
 				bb.StateStyles[i].SetStyleProps(pst, stclsp)
 			}
 		}
+		bb.StateStyles[i].StyleCSS(bb.This.(Node2D), bb.CSSAgg, ButtonSelectors[i])
 		bb.StateStyles[i].CopyUnitContext(&bb.Sty.UnContext)
 	}
 }
@@ -615,6 +620,58 @@ func (bb *ButtonBase) FocusChanged2D(change FocusChanges) {
 	}
 }
 
+// AccessInfo returns the accessibility info for the button -- the role
+// depends on whether it is a menu item, opens a menu, or is checkable
+func (bb *ButtonBase) AccessInfo() AccessInfo {
+	ai := bb.WidgetBase.AccessInfo()
+	ai.Name = bb.Text
+	if ai.Name == "" {
+		ai.Name = bb.Tooltip
+	}
+	switch {
+	case bb.IsMenu():
+		ai.Role = RoleMenuItem
+	case bb.HasMenu():
+		ai.Role = RoleMenuButton
+	case bb.IsCheckable():
+		ai.Role = RoleToggleButton
+	default:
+		ai.Role = RoleButton
+	}
+	ai.SetState(bb.HasMenu(), AccessHasPopup)
+	ai.SetState(bb.IsCheckable(), AccessCheckable)
+	ai.SetState(bb.IsChecked(), AccessChecked)
+	ai.SetState(bb.State == ButtonDown, AccessPressed)
+	if !bb.IsInactive() {
+		ai.AddAction(AccessClick)
+		if bb.IsCheckable() {
+			ai.AddAction(AccessToggle)
+		}
+		if bb.HasMenu() {
+			ai.AddAction(AccessOpenMenu)
+		}
+	}
+	return ai
+}
+
+// AccessAction performs accessibility actions for the button: click and
+// toggle act as a press and release, as from the keyboard
+func (bb *ButtonBase) AccessAction(act AccessActions, val string) bool {
+	switch act {
+	case AccessClick, AccessToggle:
+		bw, ok := bb.This.(ButtonWidget)
+		if !ok || bb.IsInactive() {
+			return false
+		}
+		bb.ButtonPressed()
+		bw.ButtonRelease()
+		return true
+	case AccessOpenMenu:
+		return bb.OpenMenu()
+	}
+	return bb.AccessActionBase(act, val)
+}
+
 ///////////////////////////////////////////////////////////
 // Button
 
@@ -643,6 +700,7 @@ var ButtonProps = ki.Props{
 	"text-align":       AlignCenter,
 	"background-color": &Prefs.Colors.Control,
 	"color":            &Prefs.Colors.Font,
+	"transition":       "background-color 100ms ease-out, border-color 100ms ease-out",
 	"#space": ki.Props{
 		"width":     units.NewValue(.5, units.Ch),
 		"min-width": units.NewValue(.5, units.Ch),
@@ -890,3 +948,12 @@ func (cb *CheckBox) ConfigPartsIfNeeded() {
 		ist.StackTop = 1
 	}
 }
+
+// AccessInfo returns the accessibility info for the checkbox
+func (cb *CheckBox) AccessInfo() AccessInfo {
+	ai := cb.ButtonBase.AccessInfo()
+	ai.Role = RoleCheckBox
+	ai.SetState(true, AccessCheckable)
+	ai.AddAction(AccessToggle)
+	return ai
+}
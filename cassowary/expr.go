@@ -0,0 +1,179 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cassowary is an implementation of the Cassowary incremental linear
+// constraint solving algorithm, following the design of the Kiwi solver:
+// constraints are linear equalities or inequalities among variables, each
+// with a strength -- required constraints must all be satisfied, and the
+// rest are satisfied as well as possible, with stronger constraints taking
+// precedence over weaker ones.
+//
+// Typical usage:
+//
+//	s := cassowary.NewSolver()
+//	left := cassowary.NewVariable("left")
+//	width := cassowary.NewVariable("width")
+//	s.AddConstraint(cassowary.NewConstraint(cassowary.Expr(width), cassowary.GE, cassowary.Const(100), cassowary.Required))
+//	s.AddConstraint(cassowary.NewConstraint(cassowary.Expr(left).Add(cassowary.Expr(width)), cassowary.EQ, cassowary.Const(400), cassowary.Strong))
+//	s.UpdateVariables()
+package cassowary
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Variable is a variable to be solved for -- Value is set by
+// Solver.UpdateVariables
+type Variable struct {
+	Name  string
+	Value float64
+}
+
+// NewVariable returns a new variable with given name
+func NewVariable(name string) *Variable {
+	return &Variable{Name: name}
+}
+
+// String satisfies the fmt.Stringer interface
+func (v *Variable) String() string {
+	return v.Name
+}
+
+// Term is a variable multiplied by a coefficient
+type Term struct {
+	Var  *Variable
+	Coef float64
+}
+
+// Expression is a linear expression: a sum of terms plus a constant
+type Expression struct {
+	Terms    []Term
+	Constant float64
+}
+
+// Expr returns an expression for given variable, with coefficient 1
+func Expr(v *Variable) Expression {
+	return Expression{Terms: []Term{{Var: v, Coef: 1}}}
+}
+
+// Const returns a constant expression
+func Const(c float64) Expression {
+	return Expression{Constant: c}
+}
+
+// Add returns the sum of this expression and another
+func (e Expression) Add(o Expression) Expression {
+	terms := make([]Term, 0, len(e.Terms)+len(o.Terms))
+	terms = append(terms, e.Terms...)
+	terms = append(terms, o.Terms...)
+	return Expression{Terms: terms, Constant: e.Constant + o.Constant}
+}
+
+// Sub returns this expression minus another
+func (e Expression) Sub(o Expression) Expression {
+	return e.Add(o.Mul(-1))
+}
+
+// Mul returns this expression multiplied by a constant
+func (e Expression) Mul(c float64) Expression {
+	terms := make([]Term, len(e.Terms))
+	for i, t := range e.Terms {
+		terms[i] = Term{Var: t.Var, Coef: t.Coef * c}
+	}
+	return Expression{Terms: terms, Constant: e.Constant * c}
+}
+
+// AddConst returns this expression plus a constant
+func (e Expression) AddConst(c float64) Expression {
+	return Expression{Terms: e.Terms, Constant: e.Constant + c}
+}
+
+// String satisfies the fmt.Stringer interface
+func (e Expression) String() string {
+	var sb strings.Builder
+	for i, t := range e.Terms {
+		if i > 0 {
+			sb.WriteString(" + ")
+		}
+		fmt.Fprintf(&sb, "%g * %v", t.Coef, t.Var)
+	}
+	if len(e.Terms) == 0 || e.Constant != 0 {
+		if len(e.Terms) > 0 {
+			sb.WriteString(" + ")
+		}
+		fmt.Fprintf(&sb, "%g", e.Constant)
+	}
+	return sb.String()
+}
+
+// Op is the relational operator of a constraint
+type Op int
+
+const (
+	// LE is less than or equal: <=
+	LE Op = iota
+	// GE is greater than or equal: >=
+	GE
+	// EQ is equal: ==
+	EQ
+)
+
+// String satisfies the fmt.Stringer interface
+func (op Op) String() string {
+	switch op {
+	case LE:
+		return "<="
+	case GE:
+		return ">="
+	}
+	return "=="
+}
+
+// CreateStrength returns a strength from the given strong, medium and weak
+// components (each clipped to 0..1000), multiplied by w
+func CreateStrength(a, b, c, w float64) float64 {
+	clip := func(v float64) float64 {
+		return math.Max(0, math.Min(1000, v))
+	}
+	return clip(a*w)*1000000 + clip(b*w)*1000 + clip(c*w)
+}
+
+// Strengths of constraints -- Required constraints must be satisfied, and
+// any one constraint of a higher strength takes precedence over any number
+// of constraints of a lower strength
+var (
+	Required = CreateStrength(1000, 1000, 1000, 1)
+	Strong   = CreateStrength(1, 0, 0, 1)
+	Medium   = CreateStrength(0, 1, 0, 1)
+	Weak     = CreateStrength(0, 0, 1, 1)
+)
+
+// Constraint is a linear constraint: Expr Op 0, with a strength
+type Constraint struct {
+	Expr     Expression `desc:"expression that is related to 0 by Op"`
+	Op       Op         `desc:"relational operator"`
+	Strength float64    `desc:"strength of the constraint -- see Required, Strong, Medium, Weak"`
+	Desc     string     `desc:"optional description, e.g., the source of the constraint, used in errors"`
+}
+
+// NewConstraint returns a new constraint: lhs op rhs, with given strength
+// (clipped to Required)
+func NewConstraint(lhs Expression, op Op, rhs Expression, strength float64) *Constraint {
+	return &Constraint{Expr: lhs.Sub(rhs), Op: op, Strength: math.Max(0, math.Min(Required, strength))}
+}
+
+// IsRequired returns true if this is a required constraint
+func (c *Constraint) IsRequired() bool {
+	return c.Strength >= Required
+}
+
+// String satisfies the fmt.Stringer interface
+func (c *Constraint) String() string {
+	if c.Desc != "" {
+		return c.Desc
+	}
+	return fmt.Sprintf("%v %v 0", c.Expr, c.Op)
+}
@@ -0,0 +1,531 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cassowary
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// UnsatisfiableError is returned when adding a required constraint that
+// conflicts with the required constraints already in the solver
+type UnsatisfiableError struct {
+	Constraint *Constraint
+}
+
+func (e *UnsatisfiableError) Error() string {
+	return fmt.Sprintf("cassowary: unsatisfiable required constraint: %v", e.Constraint)
+}
+
+// ErrDuplicateConstraint is returned when adding a constraint that is
+// already in the solver
+var ErrDuplicateConstraint = errors.New("cassowary: duplicate constraint")
+
+// ErrUnknownConstraint is returned when removing a constraint that is not in
+// the solver
+var ErrUnknownConstraint = errors.New("cassowary: unknown constraint")
+
+// ErrUnbounded is returned if the objective function is unbounded, which
+// indicates an internal error
+var ErrUnbounded = errors.New("cassowary: the objective is unbounded")
+
+// eps is the tolerance for treating values as zero
+const eps = 1.0e-8
+
+func nearZero(v float64) bool {
+	return math.Abs(v) < eps
+}
+
+// symbolTypes are the types of symbols in the tableau
+type symbolTypes int
+
+const (
+	invalidSym symbolTypes = iota
+	externalSym
+	slackSym
+	errorSym
+	dummySym
+)
+
+// symbol is a symbol in the tableau -- the id also gives a stable order
+type symbol struct {
+	id int
+	tp symbolTypes
+}
+
+// row is a row in the tableau: a basic symbol = constant + sum of cells
+type row struct {
+	cells    map[symbol]float64
+	constant float64
+}
+
+func newRow(constant float64) *row {
+	return &row{cells: make(map[symbol]float64), constant: constant}
+}
+
+func (r *row) copy() *row {
+	nr := newRow(r.constant)
+	for s, c := range r.cells {
+		nr.cells[s] = c
+	}
+	return nr
+}
+
+// syms returns the symbols in the row in id order, for deterministic pivots
+func (r *row) syms() []symbol {
+	syms := make([]symbol, 0, len(r.cells))
+	for s := range r.cells {
+		syms = append(syms, s)
+	}
+	sort.Slice(syms, func(i, j int) bool { return syms[i].id < syms[j].id })
+	return syms
+}
+
+// insert adds coef * sym to the row, removing it if it becomes zero
+func (r *row) insert(s symbol, coef float64) {
+	v := r.cells[s] + coef
+	if nearZero(v) {
+		delete(r.cells, s)
+	} else {
+		r.cells[s] = v
+	}
+}
+
+// insertRow adds coef * other row to the row
+func (r *row) insertRow(o *row, coef float64) {
+	r.constant += o.constant * coef
+	for s, c := range o.cells {
+		r.insert(s, c*coef)
+	}
+}
+
+func (r *row) reverseSign() {
+	r.constant = -r.constant
+	for s, c := range r.cells {
+		r.cells[s] = -c
+	}
+}
+
+// solveFor solves the row for given symbol, which is removed from the row
+func (r *row) solveFor(s symbol) {
+	coef := -1.0 / r.cells[s]
+	delete(r.cells, s)
+	r.constant *= coef
+	for k, c := range r.cells {
+		r.cells[k] = c * coef
+	}
+}
+
+// solveForEx solves the row for rhs, where the row was the definition of lhs
+func (r *row) solveForEx(lhs, rhs symbol) {
+	r.insert(lhs, -1.0)
+	r.solveFor(rhs)
+}
+
+// substitute replaces sym with the given row, if present
+func (r *row) substitute(s symbol, o *row) {
+	if c, has := r.cells[s]; has {
+		delete(r.cells, s)
+		r.insertRow(o, c)
+	}
+}
+
+func (r *row) allDummies() bool {
+	for s := range r.cells {
+		if s.tp != dummySym {
+			return false
+		}
+	}
+	return true
+}
+
+// tag records the marker and other symbols for a constraint
+type tag struct {
+	marker symbol
+	other  symbol
+}
+
+// Solver solves a system of constraints, incrementally as constraints are
+// added or removed
+type Solver struct {
+	cns        map[*Constraint]tag
+	cnsOrder   []*Constraint
+	rows       map[symbol]*row
+	vars       map[*Variable]symbol
+	objective  *row
+	artificial *row
+	idCtr      int
+}
+
+// NewSolver returns a new, empty solver
+func NewSolver() *Solver {
+	s := &Solver{}
+	s.Reset()
+	return s
+}
+
+// Reset clears all constraints and variables from the solver
+func (s *Solver) Reset() {
+	s.cns = make(map[*Constraint]tag)
+	s.cnsOrder = nil
+	s.rows = make(map[symbol]*row)
+	s.vars = make(map[*Variable]symbol)
+	s.objective = newRow(0)
+	s.artificial = nil
+	s.idCtr = 0
+}
+
+// HasConstraint returns true if the constraint has been added to the solver
+func (s *Solver) HasConstraint(c *Constraint) bool {
+	_, has := s.cns[c]
+	return has
+}
+
+// AddConstraint adds a constraint to the solver -- returns an
+// *UnsatisfiableError if it is a required constraint that cannot be
+// satisfied along with the other required constraints, in which case it is
+// not added
+func (s *Solver) AddConstraint(c *Constraint) error {
+	if s.HasConstraint(c) {
+		return ErrDuplicateConstraint
+	}
+	rw, tg := s.createRow(c)
+	subject := s.chooseSubject(rw, tg)
+	if subject.tp == invalidSym && rw.allDummies() {
+		if !nearZero(rw.constant) {
+			return &UnsatisfiableError{Constraint: c}
+		}
+		subject = tg.marker
+	}
+	if subject.tp == invalidSym {
+		ok, err := s.addWithArtificialVariable(rw)
+		if err != nil || !ok {
+			// the failed pivots leave the tableau inconsistent, so rebuild
+			// it from the existing constraints
+			if rerr := s.rebuild(); rerr != nil {
+				return rerr
+			}
+			if err != nil {
+				return err
+			}
+			return &UnsatisfiableError{Constraint: c}
+		}
+	} else {
+		rw.solveFor(subject)
+		s.substitute(subject, rw)
+		s.rows[subject] = rw
+	}
+	s.cns[c] = tg
+	s.cnsOrder = append(s.cnsOrder, c)
+	return s.optimize(s.objective)
+}
+
+// rebuild resets the solver and adds back all of its constraints
+func (s *Solver) rebuild() error {
+	cns := s.cnsOrder
+	vars := make([]*Variable, 0, len(s.vars))
+	for v := range s.vars {
+		vars = append(vars, v)
+	}
+	sort.Slice(vars, func(i, j int) bool { return s.vars[vars[i]].id < s.vars[vars[j]].id })
+	s.Reset()
+	for _, v := range vars { // keep reporting values for all variables, in the same order
+		s.varSymbol(v)
+	}
+	for _, c := range cns {
+		if err := s.AddConstraint(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveConstraint removes a constraint from the solver
+func (s *Solver) RemoveConstraint(c *Constraint) error {
+	tg, has := s.cns[c]
+	if !has {
+		return ErrUnknownConstraint
+	}
+	delete(s.cns, c)
+	for i, oc := range s.cnsOrder {
+		if oc == c {
+			s.cnsOrder = append(s.cnsOrder[:i], s.cnsOrder[i+1:]...)
+			break
+		}
+	}
+	// remove the error effects from the objective
+	if tg.marker.tp == errorSym {
+		s.removeMarkerEffects(tg.marker, c.Strength)
+	}
+	if tg.other.tp == errorSym {
+		s.removeMarkerEffects(tg.other, c.Strength)
+	}
+	if _, has := s.rows[tg.marker]; has {
+		delete(s.rows, tg.marker)
+	} else {
+		leaving, ok := s.getMarkerLeavingRow(tg.marker)
+		if !ok {
+			return ErrUnknownConstraint
+		}
+		rw := s.rows[leaving]
+		delete(s.rows, leaving)
+		rw.solveForEx(leaving, tg.marker)
+		s.substitute(tg.marker, rw)
+	}
+	return s.optimize(s.objective)
+}
+
+// UpdateVariables sets the Value of all the variables from the solution
+func (s *Solver) UpdateVariables() {
+	for v, sym := range s.vars {
+		if rw, has := s.rows[sym]; has {
+			v.Value = rw.constant
+		} else {
+			v.Value = 0
+		}
+	}
+}
+
+func (s *Solver) newSymbol(tp symbolTypes) symbol {
+	s.idCtr++
+	return symbol{id: s.idCtr, tp: tp}
+}
+
+func (s *Solver) varSymbol(v *Variable) symbol {
+	if sym, has := s.vars[v]; has {
+		return sym
+	}
+	sym := s.newSymbol(externalSym)
+	s.vars[v] = sym
+	return sym
+}
+
+// createRow creates a new tableau row for the constraint, with slack and
+// error symbols as needed, and the error symbols added to the objective
+func (s *Solver) createRow(c *Constraint) (*row, tag) {
+	rw := newRow(c.Expr.Constant)
+	for _, t := range c.Expr.Terms {
+		if nearZero(t.Coef) {
+			continue
+		}
+		sym := s.varSymbol(t.Var)
+		if o, has := s.rows[sym]; has {
+			rw.insertRow(o, t.Coef)
+		} else {
+			rw.insert(sym, t.Coef)
+		}
+	}
+	var tg tag
+	switch c.Op {
+	case LE, GE:
+		coef := 1.0
+		if c.Op == GE {
+			coef = -1.0
+		}
+		slack := s.newSymbol(slackSym)
+		tg.marker = slack
+		rw.insert(slack, coef)
+		if !c.IsRequired() {
+			errs := s.newSymbol(errorSym)
+			tg.other = errs
+			rw.insert(errs, -coef)
+			s.objective.insert(errs, c.Strength)
+		}
+	case EQ:
+		if !c.IsRequired() {
+			errplus := s.newSymbol(errorSym)
+			errminus := s.newSymbol(errorSym)
+			tg.marker = errplus
+			tg.other = errminus
+			rw.insert(errplus, -1.0)
+			rw.insert(errminus, 1.0)
+			s.objective.insert(errplus, c.Strength)
+			s.objective.insert(errminus, c.Strength)
+		} else {
+			dummy := s.newSymbol(dummySym)
+			tg.marker = dummy
+			rw.insert(dummy, 1.0)
+		}
+	}
+	if rw.constant < 0 {
+		rw.reverseSign()
+	}
+	return rw, tg
+}
+
+// chooseSubject chooses the symbol to solve the new row for: any external
+// symbol, else a new slack or error symbol with a negative coefficient
+func (s *Solver) chooseSubject(rw *row, tg tag) symbol {
+	for _, sym := range rw.syms() {
+		if sym.tp == externalSym {
+			return sym
+		}
+	}
+	for _, sym := range []symbol{tg.marker, tg.other} {
+		if (sym.tp == slackSym || sym.tp == errorSym) && rw.cells[sym] < 0 {
+			return sym
+		}
+	}
+	return symbol{}
+}
+
+// addWithArtificialVariable adds the row using an artificial variable,
+// returning false if the row cannot be satisfied
+func (s *Solver) addWithArtificialVariable(rw *row) (bool, error) {
+	art := s.newSymbol(slackSym)
+	s.rows[art] = rw.copy()
+	s.artificial = rw.copy()
+	err := s.optimize(s.artificial)
+	success := nearZero(s.artificial.constant)
+	s.artificial = nil
+	if err != nil {
+		return false, err
+	}
+	if arow, has := s.rows[art]; has {
+		delete(s.rows, art)
+		if len(arow.cells) == 0 {
+			return success, nil
+		}
+		entering := anyPivotableSymbol(arow)
+		if entering.tp == invalidSym {
+			return false, nil
+		}
+		arow.solveForEx(art, entering)
+		s.substitute(entering, arow)
+		s.rows[entering] = arow
+	}
+	for _, r := range s.rows {
+		delete(r.cells, art)
+	}
+	delete(s.objective.cells, art)
+	return success, nil
+}
+
+// substitute replaces sym with the row everywhere in the tableau
+func (s *Solver) substitute(sym symbol, rw *row) {
+	for _, r := range s.rows {
+		r.substitute(sym, rw)
+	}
+	s.objective.substitute(sym, rw)
+	if s.artificial != nil {
+		s.artificial.substitute(sym, rw)
+	}
+}
+
+// optimize minimizes the objective by pivoting, using the primal simplex
+func (s *Solver) optimize(obj *row) error {
+	for {
+		entering := enteringSymbol(obj)
+		if entering.tp == invalidSym {
+			return nil
+		}
+		leaving, ok := s.leavingRow(entering)
+		if !ok {
+			return ErrUnbounded
+		}
+		rw := s.rows[leaving]
+		delete(s.rows, leaving)
+		rw.solveForEx(leaving, entering)
+		s.substitute(entering, rw)
+		s.rows[entering] = rw
+	}
+}
+
+// rowSyms returns the basic symbols in id order, for deterministic pivots
+func (s *Solver) rowSyms() []symbol {
+	syms := make([]symbol, 0, len(s.rows))
+	for sym := range s.rows {
+		syms = append(syms, sym)
+	}
+	sort.Slice(syms, func(i, j int) bool { return syms[i].id < syms[j].id })
+	return syms
+}
+
+// enteringSymbol returns the first non-dummy symbol with a negative
+// coefficient in the objective
+func enteringSymbol(obj *row) symbol {
+	for _, sym := range obj.syms() {
+		if sym.tp != dummySym && obj.cells[sym] < 0 {
+			return sym
+		}
+	}
+	return symbol{}
+}
+
+// anyPivotableSymbol returns the first slack or error symbol in the row
+func anyPivotableSymbol(rw *row) symbol {
+	for _, sym := range rw.syms() {
+		if sym.tp == slackSym || sym.tp == errorSym {
+			return sym
+		}
+	}
+	return symbol{}
+}
+
+// leavingRow returns the basic symbol of the row that most restricts the
+// entering symbol
+func (s *Solver) leavingRow(entering symbol) (symbol, bool) {
+	ratio := math.MaxFloat64
+	found := symbol{}
+	ok := false
+	for _, sym := range s.rowSyms() {
+		if sym.tp == externalSym {
+			continue
+		}
+		rw := s.rows[sym]
+		if c := rw.cells[entering]; c < 0 {
+			if r := -rw.constant / c; r < ratio {
+				ratio = r
+				found = sym
+				ok = true
+			}
+		}
+	}
+	return found, ok
+}
+
+// getMarkerLeavingRow returns the row to pivot out for removing a marker
+func (s *Solver) getMarkerLeavingRow(marker symbol) (symbol, bool) {
+	r1, r2 := math.MaxFloat64, math.MaxFloat64
+	var first, second, third symbol
+	for _, sym := range s.rowSyms() {
+		rw := s.rows[sym]
+		c, has := rw.cells[marker]
+		if !has {
+			continue
+		}
+		switch {
+		case sym.tp == externalSym:
+			third = sym
+		case c < 0:
+			if r := -rw.constant / c; r < r1 {
+				r1 = r
+				first = sym
+			}
+		default:
+			if r := rw.constant / c; r < r2 {
+				r2 = r
+				second = sym
+			}
+		}
+	}
+	for _, sym := range []symbol{first, second, third} {
+		if sym.tp != invalidSym {
+			return sym, true
+		}
+	}
+	return symbol{}, false
+}
+
+// removeMarkerEffects removes the effects of an error marker on the objective
+func (s *Solver) removeMarkerEffects(marker symbol, strength float64) {
+	if rw, has := s.rows[marker]; has {
+		s.objective.insertRow(rw, -strength)
+	} else {
+		s.objective.insert(marker, -strength)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cassowary
+
+import (
+	"math"
+	"testing"
+)
+
+func testValue(t *testing.T, v *Variable, exp float64) {
+	if math.Abs(v.Value-exp) > 1.0e-6 {
+		t.Errorf("variable %v: expected %v, got %v", v, exp, v.Value)
+	}
+}
+
+func testAdd(t *testing.T, s *Solver, c *Constraint) {
+	if err := s.AddConstraint(c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSolverStrengths(t *testing.T) {
+	s := NewSolver()
+	x := NewVariable("x")
+	testAdd(t, s, NewConstraint(Expr(x), GE, Const(10), Required))
+	wk := NewConstraint(Expr(x), EQ, Const(20), Weak)
+	testAdd(t, s, wk)
+	s.UpdateVariables()
+	testValue(t, x, 20)
+
+	st := NewConstraint(Expr(x), EQ, Const(15), Strong)
+	testAdd(t, s, st)
+	for i := 0; i < 10; i++ { // any number of weaker constraints lose
+		testAdd(t, s, NewConstraint(Expr(x), EQ, Const(30), Medium))
+	}
+	s.UpdateVariables()
+	testValue(t, x, 15)
+
+	testAdd(t, s, NewConstraint(Expr(x), LE, Const(12), Required))
+	s.UpdateVariables()
+	testValue(t, x, 12)
+
+	if err := s.AddConstraint(wk); err != ErrDuplicateConstraint {
+		t.Errorf("expected duplicate constraint error, got: %v", err)
+	}
+}
+
+func TestSolverRemove(t *testing.T) {
+	s := NewSolver()
+	x := NewVariable("x")
+	testAdd(t, s, NewConstraint(Expr(x), EQ, Const(20), Weak))
+	st := NewConstraint(Expr(x), EQ, Const(10), Strong)
+	testAdd(t, s, st)
+	req := NewConstraint(Expr(x), GE, Const(5), Required)
+	testAdd(t, s, req)
+	s.UpdateVariables()
+	testValue(t, x, 10)
+	if err := s.RemoveConstraint(st); err != nil {
+		t.Fatal(err)
+	}
+	s.UpdateVariables()
+	testValue(t, x, 20)
+	if err := s.RemoveConstraint(req); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveConstraint(req); err != ErrUnknownConstraint {
+		t.Errorf("expected unknown constraint error, got: %v", err)
+	}
+}
+
+func TestSolverUnsatisfiable(t *testing.T) {
+	s := NewSolver()
+	x := NewVariable("x")
+	y := NewVariable("y")
+	testAdd(t, s, NewConstraint(Expr(x), GE, Const(10), Required))
+	bad := NewConstraint(Expr(x), LE, Const(5), Required)
+	bad.Desc = "x <= 5"
+	err := s.AddConstraint(bad)
+	if ue, ok := err.(*UnsatisfiableError); !ok || ue.Constraint != bad {
+		t.Fatalf("expected unsatisfiable error, got: %v", err)
+	}
+	if err.Error() != "cassowary: unsatisfiable required constraint: x <= 5" {
+		t.Errorf("unexpected error message: %v", err)
+	}
+	if s.HasConstraint(bad) {
+		t.Error("unsatisfiable constraint should not be added")
+	}
+
+	testAdd(t, s, NewConstraint(Expr(y), EQ, Expr(x).AddConst(5), Required))
+	err = s.AddConstraint(NewConstraint(Expr(y), EQ, Expr(x).AddConst(7), Required))
+	if _, ok := err.(*UnsatisfiableError); !ok {
+		t.Errorf("expected unsatisfiable error for conflicting equalities, got: %v", err)
+	}
+
+	// still solves what is there
+	testAdd(t, s, NewConstraint(Expr(x), EQ, Const(100), Weak))
+	s.UpdateVariables()
+	testValue(t, x, 100)
+	testValue(t, y, 105)
+}
+
+func TestSolverLayout(t *testing.T) {
+	// two boxes side by side within 300, the second as wide as it can be
+	s := NewSolver()
+	l1, w1 := NewVariable("l1"), NewVariable("w1")
+	l2, w2 := NewVariable("l2"), NewVariable("w2")
+	testAdd(t, s, NewConstraint(Expr(l1), EQ, Const(0), Required))
+	testAdd(t, s, NewConstraint(Expr(w1), EQ, Const(100), Strong))
+	testAdd(t, s, NewConstraint(Expr(l2), EQ, Expr(l1).Add(Expr(w1)).AddConst(10), Required))
+	testAdd(t, s, NewConstraint(Expr(w2), GE, Const(50), Required))
+	testAdd(t, s, NewConstraint(Expr(l2).Add(Expr(w2)), LE, Const(300), Required))
+	testAdd(t, s, NewConstraint(Expr(w2), EQ, Const(500), Weak))
+	s.UpdateVariables()
+	testValue(t, l1, 0)
+	testValue(t, w1, 100)
+	testValue(t, l2, 110)
+	testValue(t, w2, 190)
+
+	// first is half of the total width: second shrinks to keep the strong width
+	testAdd(t, s, NewConstraint(Expr(w1), EQ, Expr(l2).Add(Expr(w2)).Mul(0.5), Required))
+	s.UpdateVariables()
+	testValue(t, w1, 100)
+	testValue(t, l2, 110)
+	testValue(t, w2, 90)
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+)
+
+func TestColorBlend(t *testing.T) {
+	near := func(a, b Color) bool {
+		d := func(x, y uint8) bool { return x-y <= 1 || y-x <= 1 }
+		return d(a.R, b.R) && d(a.G, b.G) && d(a.B, b.B) && d(a.A, b.A)
+	}
+	black := Color{0, 0, 0, 255}
+	white := Color{255, 255, 255, 255}
+	red := Color{255, 0, 0, 255}
+	blue := Color{0, 0, 255, 255}
+	tests := []struct {
+		c, oth Color
+		pct    float32
+		exp    Color
+	}{
+		{black, white, 0, black},
+		{black, white, 100, white},
+		{black, white, 50, Color{128, 128, 128, 255}},
+		{red, blue, 25, Color{191, 0, 64, 255}},
+		{blue, red, 150, red}, // clamped to 100
+		{white, black, -10, white},
+	}
+	for _, ts := range tests {
+		c := ts.c
+		if bc := c.Blend(ts.pct, ts.oth); !near(bc, ts.exp) {
+			t.Errorf("%v blend %v%% %v: %v != %v", ts.c, ts.pct, ts.oth, bc, ts.exp)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"math"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// ColorFace is a font.Face for fonts with color glyphs (see ColorFont).
+// Runes with color glyphs are rendered as full-color images by ColorGlyph,
+// with bitmaps scaled to the face size, and COLR layers rasterized from the
+// outlines.  Other runes are delegated to the Base outline face, if any.
+// Glyph returns the alpha of color glyphs as the mask, for renderers that
+// only support masks.  As with all faces, it must be used with
+// TextFontRenderMu locked.
+type ColorFace struct {
+	Font     *ColorFont `desc:"color glyph tables"`
+	Base     font.Face  `desc:"outline face for runes without color glyphs -- can be nil for bitmap-only fonts"`
+	Outlines *sfnt.Font `desc:"outlines for COLR layers -- nil if not available"`
+	Size     int        `desc:"size in dots (pixels per em)"`
+
+	gids  map[rune]uint16
+	cache map[colorGlyphKey]*colorGlyph
+	buf   sfnt.Buffer
+}
+
+// colorGlyphKey is the cache key for rendered color glyphs -- Fore is only
+// set for COLR glyphs that use the text color
+type colorGlyphKey struct {
+	r    rune
+	fore color.RGBA
+}
+
+// colorGlyph is a cached color glyph rendered at face size
+type colorGlyph struct {
+	img *image.RGBA // bounds start at 0,0
+	off image.Point // offset of image top-left from the dot
+}
+
+// NewColorFace returns a new ColorFace for given color font at given size.
+// data is the font file data, used for COLR outlines, and base is an
+// optional outline face for the same font.
+func NewColorFace(cf *ColorFont, data []byte, size int, base font.Face) *ColorFace {
+	fc := &ColorFace{Font: cf, Base: base, Size: size}
+	fc.gids = make(map[rune]uint16)
+	fc.cache = make(map[colorGlyphKey]*colorGlyph)
+	if cf.colrNBase > 0 {
+		if f, err := sfnt.Parse(data); err == nil {
+			fc.Outlines = f
+		} else {
+			log.Printf("gi.ColorFace: COLR font outlines could not be parsed: %v\n", err)
+		}
+	}
+	return fc
+}
+
+// scale converts font units to 26.6 fixed dots
+func (fc *ColorFace) scale(v int) fixed.Int26_6 {
+	return fixed.Int26_6((int64(v)*int64(fc.Size)*64 + int64(fc.Font.UnitsPerEm)/2) / int64(fc.Font.UnitsPerEm))
+}
+
+// colorIndex returns the glyph index if the rune has a color glyph, else 0
+// -- results are cached
+func (fc *ColorFace) colorIndex(r rune) uint16 {
+	if gid, ok := fc.gids[r]; ok {
+		return gid
+	}
+	gid := fc.Font.GlyphIndex(r)
+	switch {
+	case gid == 0:
+	case fc.Outlines != nil && fc.Font.colrBaseRecord(gid) >= 0:
+	case fc.Font.HasBitmaps() && fc.Font.HasColorGlyph(gid):
+	default:
+		gid = 0
+	}
+	fc.gids[r] = gid
+	return gid
+}
+
+// IsColor returns true if the rune is rendered in color by this face
+func (fc *ColorFace) IsColor(r rune) bool {
+	return fc.colorIndex(r) != 0
+}
+
+// ColorGlyph returns the full-color image for rune r drawn at dot, with
+// fore as the text color for COLR layers that use it.  The image bounds
+// start at 0,0 and dr is where it goes in the destination.  ok is false if
+// the rune does not have a color glyph, in which case Glyph should be used.
+func (fc *ColorFace) ColorGlyph(dot fixed.Point26_6, r rune, fore color.Color) (dr image.Rectangle, img image.Image, ok bool) {
+	gid := fc.colorIndex(r)
+	if gid == 0 {
+		return
+	}
+	key := colorGlyphKey{r: r}
+	lays := fc.Font.Layers(gid)
+	for _, l := range lays {
+		if l.Fore {
+			if fore == nil {
+				fore = color.Black
+			}
+			key.fore = color.RGBAModel.Convert(fore).(color.RGBA)
+			break
+		}
+	}
+	cg, has := fc.cache[key]
+	if !has {
+		if lays != nil && fc.Outlines != nil {
+			cg = fc.renderLayers(lays, key.fore)
+		} else {
+			cg = fc.renderBitmap(gid)
+		}
+		fc.cache[key] = cg
+	}
+	if cg == nil {
+		return
+	}
+	x0 := dot.X.Round() + cg.off.X
+	y0 := dot.Y.Round() + cg.off.Y
+	dr = cg.img.Bounds().Add(image.Point{x0, y0})
+	return dr, cg.img, true
+}
+
+// renderBitmap decodes and scales the bitmap for glyph to the face size
+func (fc *ColorFace) renderBitmap(gid uint16) *colorGlyph {
+	bg, err := fc.Font.Bitmap(gid, fc.Size)
+	if err != nil {
+		log.Printf("%v\n", err)
+		return nil
+	}
+	if bg == nil || bg.PPEM == 0 {
+		return nil
+	}
+	sc := float64(fc.Size) / float64(bg.PPEM)
+	sb := bg.Img.Bounds()
+	w := int(math.Round(float64(sb.Dx()) * sc))
+	h := int(math.Round(float64(sb.Dy()) * sc))
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(img, img.Bounds(), bg.Img, sb, draw.Src, nil)
+	off := image.Point{int(math.Round(float64(bg.BearingX) * sc)), -int(math.Round(float64(bg.BearingY) * sc))}
+	return &colorGlyph{img: img, off: off}
+}
+
+// renderLayers rasterizes the COLR layers for a glyph at the face size
+func (fc *ColorFace) renderLayers(lays []ColorLayer, fore color.RGBA) *colorGlyph {
+	ppem := fixed.I(fc.Size)
+	segs := make([]sfnt.Segments, len(lays))
+	var bb fixed.Rectangle26_6
+	first := true
+	for i, l := range lays {
+		sg, err := fc.Outlines.LoadGlyph(&fc.buf, sfnt.GlyphIndex(l.Glyph), ppem, nil)
+		if err != nil {
+			continue
+		}
+		segs[i] = append(sfnt.Segments(nil), sg...) // buf is reused
+		for _, s := range sg {
+			np := 1
+			switch s.Op {
+			case sfnt.SegmentOpQuadTo:
+				np = 2
+			case sfnt.SegmentOpCubeTo:
+				np = 3
+			}
+			for _, p := range s.Args[:np] {
+				if first {
+					bb.Min, bb.Max = p, p
+					first = false
+					continue
+				}
+				if p.X < bb.Min.X {
+					bb.Min.X = p.X
+				}
+				if p.Y < bb.Min.Y {
+					bb.Min.Y = p.Y
+				}
+				if p.X > bb.Max.X {
+					bb.Max.X = p.X
+				}
+				if p.Y > bb.Max.Y {
+					bb.Max.Y = p.Y
+				}
+			}
+		}
+	}
+	if first {
+		return nil
+	}
+	x0, y0 := bb.Min.X.Floor(), bb.Min.Y.Floor()
+	w, h := bb.Max.X.Ceil()-x0+1, bb.Max.Y.Ceil()-y0+1
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	fx, fy := float32(x0), float32(y0)
+	pt := func(p fixed.Point26_6) (float32, float32) {
+		return float32(p.X)/64 - fx, float32(p.Y)/64 - fy
+	}
+	for i, l := range lays {
+		if len(segs[i]) == 0 {
+			continue
+		}
+		z := vector.NewRasterizer(w, h)
+		for _, s := range segs[i] {
+			switch s.Op {
+			case sfnt.SegmentOpMoveTo:
+				z.ClosePath()
+				z.MoveTo(pt(s.Args[0]))
+			case sfnt.SegmentOpLineTo:
+				z.LineTo(pt(s.Args[0]))
+			case sfnt.SegmentOpQuadTo:
+				bx, by := pt(s.Args[0])
+				cx, cy := pt(s.Args[1])
+				z.QuadTo(bx, by, cx, cy)
+			case sfnt.SegmentOpCubeTo:
+				bx, by := pt(s.Args[0])
+				cx, cy := pt(s.Args[1])
+				dx, dy := pt(s.Args[2])
+				z.CubeTo(bx, by, cx, cy, dx, dy)
+			}
+		}
+		z.ClosePath()
+		clr := l.Color
+		if l.Fore {
+			clr = fore
+		}
+		z.Draw(img, img.Bounds(), image.NewUniform(clr), image.Point{})
+	}
+	return &colorGlyph{img: img, off: image.Point{x0, y0}}
+}
+
+// Close closes the base face
+func (fc *ColorFace) Close() error {
+	if fc.Base != nil {
+		return fc.Base.Close()
+	}
+	return nil
+}
+
+// Glyph returns the alpha mask for the rune -- for color glyphs, this is the
+// color image (only its alpha is used as a mask)
+func (fc *ColorFace) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	if dr, img, cok := fc.ColorGlyph(dot, r, nil); cok {
+		adv, _ := fc.GlyphAdvance(r)
+		return dr, img, image.Point{}, adv, true
+	}
+	if fc.Base != nil {
+		return fc.Base.Glyph(dot, r)
+	}
+	return
+}
+
+// GlyphBounds returns the bounding box of the rune -- for color glyphs,
+// this is the advance by the font ascent and descent
+func (fc *ColorFace) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	if fc.IsColor(r) {
+		advance, _ = fc.GlyphAdvance(r)
+		bounds.Min.Y = -fc.scale(fc.Font.Ascent)
+		bounds.Max = fixed.Point26_6{X: advance, Y: fc.scale(fc.Font.Descent)}
+		return bounds, advance, true
+	}
+	if fc.Base != nil {
+		return fc.Base.GlyphBounds(r)
+	}
+	return
+}
+
+// GlyphAdvance returns the advance width of the rune
+func (fc *ColorFace) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	if gid := fc.colorIndex(r); gid != 0 {
+		return fc.scale(fc.Font.Advance(gid)), true
+	}
+	if fc.Base != nil {
+		return fc.Base.GlyphAdvance(r)
+	}
+	return
+}
+
+// Kern returns the kerning from the base face, if any
+func (fc *ColorFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	if fc.Base != nil {
+		return fc.Base.Kern(r0, r1)
+	}
+	return 0
+}
+
+// Metrics returns the metrics of the base face if available, else from the
+// color font header
+func (fc *ColorFace) Metrics() font.Metrics {
+	if fc.Base != nil {
+		return fc.Base.Metrics()
+	}
+	cf := fc.Font
+	return font.Metrics{
+		Height:  fc.scale(cf.Ascent + cf.Descent + cf.LineGap),
+		Ascent:  fc.scale(cf.Ascent),
+		Descent: fc.scale(cf.Descent),
+	}
+}
@@ -374,6 +374,42 @@ func (cb *ComboBox) SelectItem(idx int) {
 	cb.UpdateEnd(updt)
 }
 
+// AccessInfo returns the accessibility info for the combobox -- the value
+// is the current item
+func (cb *ComboBox) AccessInfo() AccessInfo {
+	ai := cb.ButtonBase.AccessInfo()
+	ai.Role = RoleComboBox
+	ai.Name = cb.Tooltip
+	ai.Value = kit.ToString(cb.CurVal)
+	ai.SetState(true, AccessHasPopup)
+	ai.SetState(cb.Editable, AccessEditable)
+	if !cb.IsInactive() {
+		ai.AddAction(AccessOpenMenu, AccessSetValue)
+	}
+	return ai
+}
+
+// AccessAction performs accessibility actions for the combobox:
+// AccessSetValue selects the item whose string value matches
+func (cb *ComboBox) AccessAction(act AccessActions, val string) bool {
+	switch act {
+	case AccessSetValue:
+		if cb.IsInactive() {
+			return false
+		}
+		for i, it := range cb.Items {
+			if kit.ToString(it) == val {
+				cb.SelectItem(i)
+				return true
+			}
+		}
+		return false
+	case AccessOpenMenu:
+		return cb.AccessAction(AccessClick, val) // menu is made on release
+	}
+	return cb.ButtonBase.AccessAction(act, val)
+}
+
 // MakeItemsMenu makes menu of all the items
 func (cb *ComboBox) MakeItemsMenu() {
 	nitm := len(cb.Items)
@@ -6,6 +6,7 @@ package gi
 
 import (
 	"log"
+	"strings"
 
 	"github.com/aymerick/douceur/css"
 	"github.com/aymerick/douceur/parser"
@@ -37,7 +38,12 @@ func (ss *StyleSheet) ParseString(str string) error {
 }
 
 // CSSProps returns the properties for each of the rules in this style sheet,
-// suitable for setting the CSS value of a node -- returns nil if empty sheet
+// suitable for setting the CSS value of a node -- returns nil if empty sheet.
+// Each selector in a rule becomes a key in the props (see CSSSelector for
+// supported selectors), and multiple rules for the same selector are merged,
+// with later declarations taking precedence.  @media rules become a key of
+// "@media " plus the query, holding the props for the rules within it (see
+// CSSMedia) -- other at-rules are not supported.
 func (ss *StyleSheet) CSSProps() ki.Props {
 	if ss.Sheet == nil {
 		return nil
@@ -47,21 +53,40 @@ func (ss *StyleSheet) CSSProps() ki.Props {
 		return nil
 	}
 	pr := make(ki.Props, sz)
-	for _, r := range ss.Sheet.Rules {
+	cssAddRules(pr, ss.Sheet.Rules)
+	return pr
+}
+
+// cssAddRules adds the given css rules to the props map, as in CSSProps
+func cssAddRules(pr ki.Props, rules []*css.Rule) {
+	for _, r := range rules {
 		if r.Kind == css.AtRule {
-			continue // not supported
+			if r.Name != "@media" || len(r.Rules) == 0 {
+				continue // not supported
+			}
+			key := "@media " + strings.TrimSpace(r.Prelude)
+			mp, ok := pr[key].(ki.Props)
+			if !ok {
+				mp = make(ki.Props, len(r.Rules))
+				pr[key] = mp
+			}
+			cssAddRules(mp, r.Rules)
+			continue
 		}
 		nd := len(r.Declarations)
 		if nd == 0 {
 			continue
 		}
 		for _, sel := range r.Selectors {
-			sp := make(ki.Props, nd)
+			sel = strings.TrimSpace(sel)
+			sp, ok := pr[sel].(ki.Props)
+			if !ok {
+				sp = make(ki.Props, nd)
+				pr[sel] = sp
+			}
 			for _, de := range r.Declarations {
 				sp[de.Property] = de.Value
 			}
-			pr[sel] = sp
 		}
 	}
-	return pr
 }
@@ -0,0 +1,236 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+)
+
+// PrefersDarkColors is the light / dark color scheme preference, used for
+// matching prefers-color-scheme in @media rules -- set by the theme system
+// from the user's preference or the desktop setting.
+var PrefersDarkColors = false
+
+// CSSMedia has the properties of the window that are used to match @media
+// rules in CSS style sheets, e.g.:
+//
+//	@media (max-width: 600px) { ... }
+//	@media screen and (min-resolution: 2dppx), (orientation: portrait) { ... }
+//	@media (prefers-color-scheme: dark) { ... }
+//
+// Supported media features are width, height, aspect-ratio and resolution
+// (all with min- and max- forms), orientation and prefers-color-scheme.
+// Media types all and screen match, print and others do not, and queries
+// can use not, only, and, and comma-separated lists.
+type CSSMedia struct {
+	Width  float32 `desc:"width of the window in CSS px (1/96 inch at the logical DPI)"`
+	Height float32 `desc:"height of the window in CSS px (1/96 inch at the logical DPI)"`
+	DPI    float32 `desc:"logical dots per inch of the window"`
+	Dark   bool    `desc:"dark color scheme is preferred -- from PrefersDarkColors"`
+}
+
+// CSSMediaForNode returns the media properties for the window that the
+// given node is in -- uses the defaults of units.Context if the node is not
+// in a window
+func CSSMediaForNode(node ki.Ki) CSSMedia {
+	var uc units.Context
+	uc.Defaults()
+	cm := CSSMedia{Width: uc.VpW, Height: uc.VpH, DPI: uc.DPI, Dark: PrefersDarkColors}
+	nii, ok := node.(Node2D)
+	if !ok {
+		return cm
+	}
+	vp := nii.AsNode2D().Viewport
+	if vp == nil {
+		return cm
+	}
+	if win := vp.Win; win != nil && win.Viewport != nil {
+		vp = win.Viewport
+		cm.DPI = win.LogicalDPI()
+	}
+	if vp.Geom.Size.X > 0 && vp.Geom.Size.Y > 0 {
+		cm.Width = float32(vp.Geom.Size.X) * units.PxPerInch / cm.DPI
+		cm.Height = float32(vp.Geom.Size.Y) * units.PxPerInch / cm.DPI
+	}
+	return cm
+}
+
+// Match returns true if the given media query list (the part after @media)
+// matches these media properties -- any of the comma-separated queries can
+// match
+func (cm *CSSMedia) Match(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	for _, q := range strings.Split(query, ",") {
+		if cm.matchQuery(strings.TrimSpace(q)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchQuery matches one media query: [not|only] [type] [and (feature)]*
+func (cm *CSSMedia) matchQuery(q string) bool {
+	if q == "" {
+		return false
+	}
+	not := false
+	match := true
+	for first := true; q != ""; first = false {
+		if q[0] == '(' {
+			ed := cssParenEnd(q, 0)
+			if ed < 0 {
+				return false
+			}
+			if !cm.MatchFeature(q[1:ed]) {
+				match = false
+			}
+			q = strings.TrimSpace(q[ed+1:])
+			continue
+		}
+		wd := q
+		if sp := strings.IndexAny(q, " \t\n("); sp >= 0 {
+			wd = q[:sp]
+		}
+		q = strings.TrimSpace(q[len(wd):])
+		switch wd {
+		case "and", "only":
+		case "not":
+			if !first {
+				return false
+			}
+			not = true
+		case "all", "screen":
+		default: // print, speech, or unknown types
+			match = false
+		}
+	}
+	return match != not
+}
+
+// MatchFeature returns true if the given media feature expression (without
+// the enclosing parens), e.g., "max-width: 600px", matches these media
+// properties -- unknown features do not match
+func (cm *CSSMedia) MatchFeature(feat string) bool {
+	name, val := feat, ""
+	if ci := strings.Index(feat, ":"); ci >= 0 {
+		name, val = feat[:ci], strings.TrimSpace(feat[ci+1:])
+	}
+	name = strings.TrimSpace(name)
+	cmp := 0 // 0 = equal, -1 = max, 1 = min
+	switch {
+	case strings.HasPrefix(name, "min-"):
+		cmp = 1
+		name = name[4:]
+	case strings.HasPrefix(name, "max-"):
+		cmp = -1
+		name = name[4:]
+	}
+	if val == "" { // boolean context
+		if cmp != 0 {
+			return false
+		}
+		switch name {
+		case "width", "height", "resolution", "aspect-ratio", "orientation", "color", "prefers-color-scheme":
+			return true
+		}
+		return false
+	}
+	var have, want float32
+	switch name {
+	case "width", "height":
+		var uv units.Value
+		uv.SetString(val)
+		var uc units.Context
+		uc.Defaults()
+		uc.FontEm, uc.FontRem = 16, 16 // media queries use the initial font size
+		want = uv.ToDots(&uc)
+		have = cm.Width
+		if name == "height" {
+			have = cm.Height
+		}
+	case "aspect-ratio":
+		want = cssMediaRatio(val)
+		if cm.Height > 0 {
+			have = cm.Width / cm.Height
+		}
+	case "resolution":
+		want = cssMediaResolution(val)
+		have = cm.DPI
+	case "orientation":
+		if cmp != 0 {
+			return false
+		}
+		if cm.Height >= cm.Width {
+			return val == "portrait"
+		}
+		return val == "landscape"
+	case "prefers-color-scheme":
+		if cmp != 0 {
+			return false
+		}
+		if cm.Dark {
+			return val == "dark"
+		}
+		return val == "light" || val == "no-preference"
+	default:
+		return false
+	}
+	if want <= 0 {
+		return false
+	}
+	switch cmp {
+	case 1:
+		return have >= want
+	case -1:
+		return have <= want
+	}
+	return have == want
+}
+
+// cssMediaRatio parses an aspect ratio: w/h or a single number
+func cssMediaRatio(val string) float32 {
+	ws, hs := val, "1"
+	if si := strings.Index(val, "/"); si >= 0 {
+		ws, hs = val[:si], val[si+1:]
+	}
+	w, err := strconv.ParseFloat(strings.TrimSpace(ws), 32)
+	if err != nil {
+		return 0
+	}
+	h, err := strconv.ParseFloat(strings.TrimSpace(hs), 32)
+	if err != nil || h == 0 {
+		return 0
+	}
+	return float32(w / h)
+}
+
+// cssMediaResolution parses a resolution in dpi, dpcm, dppx or x units,
+// returning dots per inch
+func cssMediaResolution(val string) float32 {
+	scale := float32(0)
+	num := val
+	switch {
+	case strings.HasSuffix(val, "dpi"):
+		scale, num = 1, val[:len(val)-3]
+	case strings.HasSuffix(val, "dpcm"):
+		scale, num = units.CmPerInch, val[:len(val)-4]
+	case strings.HasSuffix(val, "dppx"):
+		scale, num = units.PxPerInch, val[:len(val)-4]
+	case strings.HasSuffix(val, "x"):
+		scale, num = units.PxPerInch, val[:len(val)-1]
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(num), 32)
+	if err != nil {
+		return 0
+	}
+	return scale * float32(v)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+
+	"github.com/goki/ki"
+)
+
+func TestCSSMediaMatch(t *testing.T) {
+	lo := CSSMedia{Width: 500, Height: 800, DPI: 96}
+	hi := CSSMedia{Width: 1200, Height: 800, DPI: 192, Dark: true}
+	tests := []struct {
+		query  string
+		lo, hi bool
+	}{
+		{"", true, true},
+		{"all", true, true},
+		{"print", false, false},
+		{"(max-width: 600px)", true, false},
+		{"(min-width: 600px)", false, true},
+		{"screen and (min-width: 40em)", false, true}, // em is 16px
+		{"(width: 500px)", true, false},
+		{"(min-width: 400px) and (max-width: 600px)", true, false},
+		{"not screen and (max-width: 600px)", false, true},
+		{"only screen and (width)", true, true},
+		{"(orientation: portrait)", true, false},
+		{"(orientation: landscape)", false, true},
+		{"(min-aspect-ratio: 4/3)", false, true},
+		{"(min-resolution: 2dppx)", false, true},
+		{"(min-resolution: 2x)", false, true},
+		{"(resolution: 96dpi)", true, false},
+		{"(max-resolution: 40dpcm)", true, false},
+		{"(prefers-color-scheme: dark)", false, true},
+		{"(prefers-color-scheme: light)", true, false},
+		{"(prefers-color-scheme)", true, true},
+		{"print, (prefers-color-scheme: dark)", false, true},
+		{"(max-width: 600px), (min-resolution: 2dppx)", true, true},
+		{"(hover: hover)", false, false},
+		{"(max-width: 600px", false, false},
+		{"screen not (width)", false, false},
+	}
+	for _, ts := range tests {
+		if m := lo.Match(ts.query); m != ts.lo {
+			t.Errorf("%q: low res match: %v != %v", ts.query, m, ts.lo)
+		}
+		if m := hi.Match(ts.query); m != ts.hi {
+			t.Errorf("%q: high res match: %v != %v", ts.query, m, ts.hi)
+		}
+	}
+}
+
+func TestCSSMediaTree(t *testing.T) {
+	svDark := PrefersDarkColors
+	defer func() { PrefersDarkColors = svDark }()
+	PrefersDarkColors = false
+
+	blue := Color{0, 0, 255, 255}
+	green := Color{0, 255, 0, 255}
+	red := Color{255, 0, 0, 255}
+	css := ki.Props{
+		"label": ki.Props{
+			"color": "#00f",
+		},
+		"@media (min-width: 400px)": ki.Props{
+			"label": ki.Props{
+				"color": "#0f0",
+			},
+		},
+		"@media (prefers-color-scheme: dark)": ki.Props{
+			".scheme": ki.Props{
+				"color": "#f00",
+			},
+		},
+	}
+	vp, lay := testViewport(t, 300, 200)
+	lay.CSS = css
+	lb := lay.AddNewChild(KiT_Label, "label").(*Label)
+	lb.SetText("media")
+	slb := lay.AddNewChild(KiT_Label, "scheme").(*Label)
+	slb.SetText("scheme")
+	slb.Class = "scheme"
+	vp.FullRender2DTree()
+	if cm := CSSMediaForNode(lb.This); cm.Width != 300 || cm.Height != 200 || cm.Dark {
+		t.Errorf("unexpected media for viewport: %+v", cm)
+	}
+	if lb.Sty.Font.Color != blue || slb.Sty.Font.Color != blue {
+		t.Errorf("expected blue labels in narrow viewport, got: %v %v", lb.Sty.Font.Color, slb.Sty.Font.Color)
+	}
+
+	wvp, wlay := testViewport(t, 600, 200)
+	wlay.CSS = css
+	wlb := wlay.AddNewChild(KiT_Label, "label").(*Label)
+	wlb.SetText("media")
+	wvp.FullRender2DTree()
+	if wlb.Sty.Font.Color != green {
+		t.Errorf("expected green label in wide viewport, got: %v", wlb.Sty.Font.Color)
+	}
+
+	PrefersDarkColors = true
+	vp.FullRender2DTree()
+	if slb.Sty.Font.Color != red || lb.Sty.Font.Color != blue {
+		t.Errorf("expected red label only for dark color scheme, got: %v %v", slb.Sty.Font.Color, lb.Sty.Font.Color)
+	}
+}
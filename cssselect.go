@@ -0,0 +1,608 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  CSS Selectors
+
+// CSSCombinators are the ways in which compound selectors are related to
+// the compound selector to their left in a CSS selector
+type CSSCombinators int32
+
+const (
+	// CSSCombNone is for the first (leftmost) compound selector
+	CSSCombNone CSSCombinators = iota
+
+	// CSSCombDescendant (whitespace) matches any ancestor
+	CSSCombDescendant
+
+	// CSSCombChild (>) matches the immediate parent
+	CSSCombChild
+
+	// CSSCombNext (+) matches the immediately preceding sibling
+	CSSCombNext
+
+	// CSSCombSibling (~) matches any preceding sibling
+	CSSCombSibling
+
+	CSSCombinatorsN
+)
+
+//go:generate stringer -type=CSSCombinators
+
+var KiT_CSSCombinators = kit.Enums.AddEnumAltLower(CSSCombinatorsN, false, nil, "CSSComb")
+
+func (ev CSSCombinators) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *CSSCombinators) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// CSSAttrSel is an attribute selector, e.g., [name=value] -- Op is one of
+// "" (attribute exists), =, ~=, |=, ^=, $=, *=
+type CSSAttrSel struct {
+	Name string
+	Op   string
+	Val  string
+}
+
+// CSSCompound is a compound selector: a type selector and any number of
+// #id, .class, [attr] and :pseudo-class selectors that all apply to the
+// same node.  Type, ID, Classes and Pseudos are all lower-case.
+type CSSCompound struct {
+	Comb    CSSCombinators `desc:"how this compound relates to the one to its left"`
+	Type    string         `desc:"type name, or empty for any type (*)"`
+	ID      string         `desc:"#id selector, matching node name"`
+	Classes []string       `desc:".class selectors -- all must be present in node Class"`
+	Attrs   []CSSAttrSel   `desc:"[attr] selectors"`
+	Pseudos []string       `desc:":pseudo-class selectors, without the colon"`
+}
+
+// CSSSpecificity is the specificity of a selector: counts of id selectors,
+// class, attribute and pseudo-class selectors, and type selectors
+type CSSSpecificity [3]int
+
+// Less returns true if specificity is lower than other
+func (sp CSSSpecificity) Less(o CSSSpecificity) bool {
+	for i := range sp {
+		if sp[i] != o[i] {
+			return sp[i] < o[i]
+		}
+	}
+	return false
+}
+
+// CSSSelector is a parsed CSS selector: a sequence of compound selectors
+// joined by combinators, the last of which (the subject) matches the node
+// being styled.
+type CSSSelector struct {
+	Str   string         `desc:"original selector string"`
+	Parts []CSSCompound  `desc:"compound selectors, from left to right"`
+	Spec  CSSSpecificity `desc:"specificity of the selector"`
+}
+
+// CSSPseudoStates maps CSS pseudo-classes to the widget state selectors used
+// for StateStyles (e.g., ButtonSelectors) -- these follow CSS semantics, so
+// :active is the pressed-down state, not the gi :active normal state.
+// Pseudo-classes that are not listed here (and not structural) map directly
+// to the same-named state selector, e.g., :value for sliders.
+var CSSPseudoStates = map[string]string{
+	"hover":    ":hover",
+	"focus":    ":focus",
+	"active":   ":down",
+	"down":     ":down",
+	"disabled": ":inactive",
+	"inactive": ":inactive",
+	"checked":  ":selected",
+	"selected": ":selected",
+}
+
+// cssStructPseudos are pseudo-classes that depend only on the tree, or
+// persistent state, not on the current widget state
+var cssStructPseudos = map[string]bool{
+	"first-child": true,
+	"last-child":  true,
+	"only-child":  true,
+	"empty":       true,
+	"root":        true,
+	"enabled":     true,
+}
+
+// cssSelCache caches parsed selectors by string -- nil for invalid ones
+var cssSelCache = map[string]*CSSSelector{}
+var cssSelMu sync.Mutex
+
+// CSSSelectorCached returns the parsed selector for given string, using a
+// cache -- returns nil if the selector is not valid
+func CSSSelectorCached(str string) *CSSSelector {
+	cssSelMu.Lock()
+	defer cssSelMu.Unlock()
+	if sel, ok := cssSelCache[str]; ok {
+		return sel
+	}
+	sel, err := ParseCSSSelector(str)
+	if err != nil {
+		sel = nil
+	}
+	cssSelCache[str] = sel
+	return sel
+}
+
+// cssIdentRune returns true if rune can be part of an identifier
+func cssIdentRune(r rune) bool {
+	return r == '-' || r == '_' || r == '\\' || unicode.IsLetter(r) || unicode.IsDigit(r) || r > 0x7F
+}
+
+// ParseCSSSelector parses a single (non-comma-separated) CSS selector
+func ParseCSSSelector(str string) (*CSSSelector, error) {
+	sel := &CSSSelector{Str: str}
+	rs := []rune(strings.TrimSpace(str))
+	sz := len(rs)
+	if sz == 0 {
+		return nil, fmt.Errorf("gi.ParseCSSSelector: empty selector")
+	}
+	i := 0
+	ident := func() string {
+		st := i
+		for i < sz && cssIdentRune(rs[i]) {
+			i++
+		}
+		return string(rs[st:i])
+	}
+	comb := CSSCombNone
+	cur := &CSSCompound{}
+	empty := true
+	finish := func() error {
+		if empty {
+			return fmt.Errorf("gi.ParseCSSSelector: missing selector in: %v", str)
+		}
+		cur.Comb = comb
+		sel.Parts = append(sel.Parts, *cur)
+		cur = &CSSCompound{}
+		empty = true
+		return nil
+	}
+	for i < sz {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r) || r == '>' || r == '+' || r == '~':
+			if !empty {
+				if err := finish(); err != nil {
+					return nil, err
+				}
+			}
+			nc := CSSCombDescendant
+			for i < sz && (unicode.IsSpace(rs[i]) || rs[i] == '>' || rs[i] == '+' || rs[i] == '~') {
+				switch rs[i] {
+				case '>':
+					nc = CSSCombChild
+				case '+':
+					nc = CSSCombNext
+				case '~':
+					nc = CSSCombSibling
+				}
+				i++
+			}
+			if len(sel.Parts) == 0 {
+				return nil, fmt.Errorf("gi.ParseCSSSelector: selector cannot start with combinator: %v", str)
+			}
+			comb = nc
+		case r == '*':
+			i++
+			empty = false
+		case r == '#':
+			i++
+			cur.ID = strings.ToLower(ident())
+			empty = false
+		case r == '.':
+			i++
+			cur.Classes = append(cur.Classes, strings.ToLower(ident()))
+			empty = false
+		case r == ':':
+			i++
+			if i < sz && rs[i] == ':' { // pseudo-elements not supported, treated as pseudo-class
+				i++
+			}
+			ps := strings.ToLower(ident())
+			if i < sz && rs[i] == '(' {
+				return nil, fmt.Errorf("gi.ParseCSSSelector: functional pseudo-classes not supported: %v", str)
+			}
+			cur.Pseudos = append(cur.Pseudos, ps)
+			empty = false
+		case r == '[':
+			end := i + 1
+			for end < sz && rs[end] != ']' {
+				end++
+			}
+			if end >= sz {
+				return nil, fmt.Errorf("gi.ParseCSSSelector: unterminated attribute selector: %v", str)
+			}
+			cur.Attrs = append(cur.Attrs, parseCSSAttr(string(rs[i+1:end])))
+			i = end + 1
+			empty = false
+		case cssIdentRune(r):
+			cur.Type = strings.ToLower(ident())
+			empty = false
+		default:
+			return nil, fmt.Errorf("gi.ParseCSSSelector: unexpected character %q in: %v", r, str)
+		}
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	for _, cp := range sel.Parts {
+		if cp.ID != "" {
+			sel.Spec[0]++
+		}
+		sel.Spec[1] += len(cp.Classes) + len(cp.Attrs) + len(cp.Pseudos)
+		if cp.Type != "" {
+			sel.Spec[2]++
+		}
+	}
+	return sel, nil
+}
+
+// parseCSSAttr parses the inside of an attribute selector
+func parseCSSAttr(str string) CSSAttrSel {
+	str = strings.TrimSpace(str)
+	eq := strings.Index(str, "=")
+	if eq < 0 {
+		return CSSAttrSel{Name: strings.ToLower(str)}
+	}
+	as := CSSAttrSel{}
+	nm := str[:eq]
+	if eq > 0 && strings.ContainsRune("~|^$*", rune(str[eq-1])) {
+		as.Op = str[eq-1 : eq+1]
+		nm = str[:eq-1]
+	} else {
+		as.Op = "="
+	}
+	as.Name = strings.ToLower(strings.TrimSpace(nm))
+	as.Val = strings.Trim(strings.TrimSpace(str[eq+1:]), `"'`)
+	return as
+}
+
+// States returns the widget state selectors required by the subject
+// (rightmost) compound of the selector, e.g., ":hover" -- empty if none
+func (sel *CSSSelector) States() []string {
+	var sts []string
+	subj := &sel.Parts[len(sel.Parts)-1]
+	for _, ps := range subj.Pseudos {
+		if cssStructPseudos[ps] {
+			continue
+		}
+		sts = append(sts, cssPseudoState(ps))
+	}
+	return sts
+}
+
+// cssPseudoState returns the widget state selector for a pseudo-class
+func cssPseudoState(ps string) string {
+	if st, ok := CSSPseudoStates[ps]; ok {
+		return st
+	}
+	return ":" + ps
+}
+
+// Match returns true if the selector matches given node, for given widget
+// state selector (e.g., ":hover", as used in StateStyles), or "" for the
+// base style of the node.  For the base style, state pseudo-classes on the
+// subject are matched against the persistent state of the node (:disabled,
+// :checked and :focus) -- transient ones such as :hover never match.  For a
+// state selector, all the state pseudo-classes on the subject must map to
+// that state, and there must be at least one.  Pseudo-classes on other
+// compounds are always matched against the current node state.
+func (sel *CSSSelector) Match(node ki.Ki, state string) bool {
+	np := len(sel.Parts)
+	subj := &sel.Parts[np-1]
+	if state != "" {
+		sts := sel.States()
+		if len(sts) == 0 {
+			return false
+		}
+		for _, st := range sts {
+			if st != state {
+				return false
+			}
+		}
+	}
+	if !subj.match(node, state == "", state != "") {
+		return false
+	}
+	return sel.matchLeft(node, np-1)
+}
+
+// matchLeft matches the compounds to the left of compound index ci, which
+// matched node k
+func (sel *CSSSelector) matchLeft(k ki.Ki, ci int) bool {
+	if ci == 0 {
+		return true
+	}
+	cp := &sel.Parts[ci-1]
+	switch sel.Parts[ci].Comb {
+	case CSSCombChild:
+		par := k.Parent()
+		return par != nil && cp.match(par, true, false) && sel.matchLeft(par, ci-1)
+	case CSSCombDescendant:
+		for par := k.Parent(); par != nil; par = par.Parent() {
+			if cp.match(par, true, false) && sel.matchLeft(par, ci-1) {
+				return true
+			}
+		}
+	case CSSCombNext:
+		if sib := cssSibling(k, -1); sib != nil {
+			return cp.match(sib, true, false) && sel.matchLeft(sib, ci-1)
+		}
+	case CSSCombSibling:
+		for off := -1; ; off-- {
+			sib := cssSibling(k, off)
+			if sib == nil {
+				break
+			}
+			if cp.match(sib, true, false) && sel.matchLeft(sib, ci-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cssSibling returns the sibling at given offset from node, nil if none
+func cssSibling(k ki.Ki, off int) ki.Ki {
+	par := k.Parent()
+	if par == nil {
+		return nil
+	}
+	idx, ok := k.IndexInParent()
+	if !ok {
+		return nil
+	}
+	kids := *par.Children()
+	si := idx + off
+	if si < 0 || si >= len(kids) {
+		return nil
+	}
+	return kids[si]
+}
+
+// cssNodeBase returns the NodeBase for given node, nil if not a gi node
+func cssNodeBase(k ki.Ki) *NodeBase {
+	nbi := k.Embed(KiT_NodeBase)
+	if nbi == nil {
+		return nil
+	}
+	return nbi.(*NodeBase)
+}
+
+// match returns true if the compound matches given node -- flagStates
+// determines whether state pseudo-classes are matched against the node
+// flags, and skipStates skips them entirely (already checked by caller)
+func (cp *CSSCompound) match(k ki.Ki, flagStates, skipStates bool) bool {
+	nb := cssNodeBase(k)
+	if nb == nil {
+		return false
+	}
+	if cp.Type != "" && strings.ToLower(k.Type().Name()) != cp.Type {
+		return false
+	}
+	if cp.ID != "" && strings.ToLower(k.Name()) != cp.ID {
+		return false
+	}
+	if len(cp.Classes) > 0 {
+		cls := strings.Fields(strings.ToLower(nb.Class))
+		for _, c := range cp.Classes {
+			if !cssHasWord(cls, c) {
+				return false
+			}
+		}
+	}
+	for _, as := range cp.Attrs {
+		if !as.match(k, nb) {
+			return false
+		}
+	}
+	for _, ps := range cp.Pseudos {
+		if cssStructPseudos[ps] {
+			if !cssMatchStruct(k, nb, ps) {
+				return false
+			}
+			continue
+		}
+		if skipStates {
+			continue
+		}
+		if !flagStates || !cssMatchFlag(k, nb, ps) {
+			return false
+		}
+	}
+	return true
+}
+
+func cssHasWord(words []string, w string) bool {
+	for _, wd := range words {
+		if wd == w {
+			return true
+		}
+	}
+	return false
+}
+
+// cssMatchStruct matches structural pseudo-classes
+func cssMatchStruct(k ki.Ki, nb *NodeBase, ps string) bool {
+	switch ps {
+	case "first-child":
+		return k.Parent() != nil && cssSibling(k, -1) == nil
+	case "last-child":
+		return k.Parent() != nil && cssSibling(k, 1) == nil
+	case "only-child":
+		return k.Parent() != nil && cssSibling(k, -1) == nil && cssSibling(k, 1) == nil
+	case "empty":
+		return !k.HasChildren()
+	case "root":
+		par := k.Parent()
+		return par == nil || cssNodeBase(par) == nil
+	case "enabled":
+		return !nb.IsInactive()
+	}
+	return false
+}
+
+// cssMatchFlag matches state pseudo-classes against persistent node state
+func cssMatchFlag(k ki.Ki, nb *NodeBase, ps string) bool {
+	switch cssPseudoState(ps) {
+	case ":inactive":
+		return nb.IsInactive()
+	case ":focus":
+		return nb.HasFocus()
+	case ":selected":
+		if ck, ok := k.(interface {
+			IsChecked() bool
+		}); ok && ck.IsChecked() {
+			return true
+		}
+		return nb.IsSelected()
+	}
+	return false
+}
+
+// cssAttrVal returns the value of attribute for the node: id / name, class,
+// and type are the node fields, and all others are node properties
+func cssAttrVal(k ki.Ki, nb *NodeBase, nm string) (string, bool) {
+	switch nm {
+	case "id", "name":
+		return k.Name(), true
+	case "class":
+		return nb.Class, nb.Class != ""
+	case "type":
+		return k.Type().Name(), true
+	}
+	pv, ok := k.Prop(nm)
+	if !ok {
+		return "", false
+	}
+	return kit.ToString(pv), true
+}
+
+// match returns true if the attribute selector matches the node
+func (as *CSSAttrSel) match(k ki.Ki, nb *NodeBase) bool {
+	v, ok := cssAttrVal(k, nb, as.Name)
+	if !ok {
+		return false
+	}
+	switch as.Op {
+	case "":
+		return true
+	case "=":
+		return v == as.Val
+	case "~=":
+		return cssHasWord(strings.Fields(v), as.Val)
+	case "|=":
+		return v == as.Val || strings.HasPrefix(v, as.Val+"-")
+	case "^=":
+		return as.Val != "" && strings.HasPrefix(v, as.Val)
+	case "$=":
+		return as.Val != "" && strings.HasSuffix(v, as.Val)
+	case "*=":
+		return as.Val != "" && strings.Contains(v, as.Val)
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  CSS cascade
+
+// cssMatch is one matching set of properties
+type cssMatch struct {
+	key   string
+	spec  CSSSpecificity
+	media int
+	props ki.Props
+}
+
+// CSSMatches returns the properties from css (keyed by selector) that match
+// given node for given widget state selector ("" for base style), in order
+// of increasing specificity (ties are ordered by selector string, as the
+// css map does not record source order), so that applying them in order
+// gives the proper cascade.  Selector keys may be any CSS selector, and
+// their props can also have nested state selector sub-props (e.g.,
+// "button": ki.Props{":hover": ki.Props{...}}), which apply when the key
+// itself matches with no state.  Keys starting with @media hold a nested
+// css map that applies when the media query matches the window of the node
+// (see CSSMedia) -- these rules win ties with non-media rules of the same
+// specificity, so that they can override the defaults.
+func CSSMatches(node ki.Ki, css ki.Props, state string) []ki.Props {
+	var ms []cssMatch
+	var media *CSSMedia
+	cssMatchesAdd(&ms, node, css, state, &media, 0)
+	if len(ms) == 0 {
+		return nil
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].spec != ms[j].spec {
+			return ms[i].spec.Less(ms[j].spec)
+		}
+		if ms[i].media != ms[j].media {
+			return ms[i].media < ms[j].media
+		}
+		return ms[i].key < ms[j].key
+	})
+	pms := make([]ki.Props, len(ms))
+	for i := range ms {
+		pms[i] = ms[i].props
+	}
+	return pms
+}
+
+// cssMatchesAdd adds the matches in css to ms -- media is computed the
+// first time an @media key is encountered, and level is the @media nesting
+// depth
+func cssMatchesAdd(ms *[]cssMatch, node ki.Ki, css ki.Props, state string, media **CSSMedia, level int) {
+	for key, val := range css {
+		pmap, ok := val.(ki.Props)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(key, "@media") {
+			if *media == nil {
+				cm := CSSMediaForNode(node)
+				*media = &cm
+			}
+			if (*media).Match(key[6:]) {
+				cssMatchesAdd(ms, node, pmap, state, media, level+1)
+			}
+			continue
+		}
+		sel := CSSSelectorCached(key)
+		if sel == nil {
+			continue
+		}
+		if sel.Match(node, state) {
+			*ms = append(*ms, cssMatch{key, sel.Spec, level, pmap})
+			continue
+		}
+		if state == "" || len(sel.States()) > 0 {
+			continue
+		}
+		sp, has := pmap[state]
+		if !has {
+			continue
+		}
+		spm, ok := sp.(ki.Props)
+		if !ok || !sel.Match(node, "") {
+			continue
+		}
+		spec := sel.Spec
+		spec[1]++ // as if :state was part of key
+		*ms = append(*ms, cssMatch{key + state, spec, level, spm})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+)
+
+func TestParseCSSSelector(t *testing.T) {
+	tests := []struct {
+		sel   string
+		parts int
+		spec  CSSSpecificity
+		last  CSSCombinators
+	}{
+		{"button", 1, CSSSpecificity{0, 0, 1}, CSSCombNone},
+		{"Frame > Button", 2, CSSSpecificity{0, 0, 2}, CSSCombChild},
+		{".toolbar Action", 2, CSSSpecificity{0, 1, 1}, CSSCombDescendant},
+		{"#main .toolbar>action:hover", 3, CSSSpecificity{1, 2, 1}, CSSCombChild},
+		{"label + textfield", 2, CSSSpecificity{0, 0, 2}, CSSCombNext},
+		{"label~ *[name^=fld]", 2, CSSSpecificity{0, 1, 1}, CSSCombSibling},
+		{"button.primary.big:disabled", 1, CSSSpecificity{0, 3, 1}, CSSCombNone},
+	}
+	for _, ts := range tests {
+		sel, err := ParseCSSSelector(ts.sel)
+		if err != nil {
+			t.Errorf("selector: %v  error: %v", ts.sel, err)
+			continue
+		}
+		if len(sel.Parts) != ts.parts || sel.Spec != ts.spec || sel.Parts[len(sel.Parts)-1].Comb != ts.last {
+			t.Errorf("selector: %v  parts: %v  spec: %v  comb: %v", ts.sel, len(sel.Parts), sel.Spec, sel.Parts[len(sel.Parts)-1].Comb)
+		}
+	}
+	for _, bad := range []string{"", "> button", "button >", "a[b", "button:not(.x)", "a $ b"} {
+		if _, err := ParseCSSSelector(bad); err == nil {
+			t.Errorf("selector: %q should be an error", bad)
+		}
+	}
+	sel, _ := ParseCSSSelector(`.toolbar Action[tooltip*="Open file"]:hover:focus`)
+	at := sel.Parts[1].Attrs[0]
+	if at.Name != "tooltip" || at.Op != "*=" || at.Val != "Open file" {
+		t.Errorf("attr: %+v", at)
+	}
+	if sts := sel.States(); len(sts) != 2 || sts[0] != ":hover" || sts[1] != ":focus" {
+		t.Errorf("states: %v", sts)
+	}
+	sel, _ = ParseCSSSelector("button:active:first-child")
+	if sts := sel.States(); len(sts) != 1 || sts[0] != ":down" {
+		t.Errorf("states: %v", sts)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+)
+
+// CSS custom properties: any property whose name starts with -- (e.g.,
+// "--accent": "#0af") defines a variable that is in effect for the element
+// and everything below it, and can be used in any other property value as
+// var(--accent) or var(--accent, fallback).  Variables are stored in the
+// Vars map of Style / Paint, which is inherited from the parent, and if a
+// name is not found there, the props of the node being styled and its
+// parents in the ki tree (including type props) are searched -- this allows
+// e.g., a window's Viewport to hold the variables for a whole theme.
+// Substitution is textual, so vars can be used within other values such as
+// calc(var(--pad) * 2) or "1px solid var(--border)".
+
+// CSSVarMaxDepth is the maximum depth of var() references within the values
+// of other variables -- guards against cycles
+const CSSVarMaxDepth = 10
+
+// IsCSSVar returns true if the property name is a custom property (--name)
+func IsCSSVar(key string) bool {
+	return len(key) > 2 && key[0] == '-' && key[1] == '-'
+}
+
+// HasCSSVarRef returns true if the value is a string containing a var()
+// reference
+func HasCSSVarRef(val interface{}) bool {
+	str, ok := val.(string)
+	return ok && strings.Contains(str, "var(")
+}
+
+// CSSVarsFromProps returns the variables in effect after applying any custom
+// properties (--name) in props on top of the given inherited vars.  The
+// vars map is only copied if props define any variables, so it can be
+// shared with the parent.  var() references in the new values are resolved
+// against the vars in effect so far.
+func CSSVarsFromProps(vars, props ki.Props) ki.Props {
+	copied := false
+	for key, val := range props {
+		if !IsCSSVar(key) {
+			continue
+		}
+		if !copied {
+			nv := make(ki.Props, len(vars)+1)
+			for k, v := range vars {
+				nv[k] = v
+			}
+			vars = nv
+			copied = true
+		}
+		if HasCSSVarRef(val) {
+			if rv, ok := CSSVarResolve(vars, val.(string), 0); ok {
+				val = rv
+			}
+		}
+		vars[key] = val
+	}
+	return vars
+}
+
+// CSSVarValue returns the value of given custom property name (--name),
+// looking first in vars and then in the props of CurStyleNode2D and its
+// parents (inherited, including type props)
+func CSSVarValue(vars ki.Props, name string) (interface{}, bool) {
+	if val, ok := vars[name]; ok {
+		return val, true
+	}
+	if CurStyleNode2D != nil {
+		return CurStyleNode2D.PropInherit(name, true, true)
+	}
+	return nil, false
+}
+
+// CSSVarResolve returns the given string value with all var() references
+// replaced by their values, using CSSVarValue and then the fallback if
+// given.  If the entire string is a single var() reference, the value is
+// returned as-is (e.g., a Color or units.Value), otherwise values are
+// substituted as strings.  Returns false if any reference could not be
+// resolved, in which case the property should be ignored.
+func CSSVarResolve(vars ki.Props, str string, depth int) (interface{}, bool) {
+	if depth > CSSVarMaxDepth {
+		return nil, false
+	}
+	var sb strings.Builder
+	for first := true; ; first = false {
+		st := strings.Index(str, "var(")
+		if st < 0 {
+			break
+		}
+		ed := cssParenEnd(str, st+3)
+		if ed < 0 {
+			return nil, false
+		}
+		name, fb := str[st+4:ed], ""
+		hasFb := false
+		if ci := strings.Index(name, ","); ci >= 0 {
+			name, fb = name[:ci], strings.TrimSpace(name[ci+1:])
+			hasFb = true
+		}
+		name = strings.TrimSpace(name)
+		val, ok := CSSVarValue(vars, name)
+		if !ok && hasFb {
+			val, ok = fb, true
+		}
+		if !ok {
+			return nil, false
+		}
+		if HasCSSVarRef(val) {
+			if val, ok = CSSVarResolve(vars, val.(string), depth+1); !ok {
+				return nil, false
+			}
+		}
+		if first && st == 0 && ed == len(str)-1 { // whole value
+			return val, true
+		}
+		sb.WriteString(str[:st])
+		sb.WriteString(cssVarString(val))
+		str = str[ed+1:]
+	}
+	sb.WriteString(str)
+	return sb.String(), true
+}
+
+// cssVarString returns the value of a var as a string for substitution
+// within a larger value -- Color and units.Value are formatted as CSS
+func cssVarString(val interface{}) string {
+	switch vv := val.(type) {
+	case string:
+		return vv
+	case units.Value:
+		return vv.String()
+	case Color:
+		return fmt.Sprintf("#%02x%02x%02x%02x", vv.R, vv.G, vv.B, vv.A)
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// cssParenEnd returns the index of the paren that closes the one at st, or
+// -1 if not found
+func cssParenEnd(str string, st int) int {
+	depth := 0
+	for i := st; i < len(str); i++ {
+		switch str[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// ResolveCSSVars returns props with all var() references in values resolved
+// using given vars (see CSSVarResolve) -- properties that cannot be resolved
+// are dropped.  Returns the same props map if there are no references.
+func ResolveCSSVars(vars, props ki.Props) ki.Props {
+	var np ki.Props
+	for key, val := range props {
+		if IsCSSVar(key) || !HasCSSVarRef(val) {
+			continue
+		}
+		if np == nil {
+			np = make(ki.Props, len(props))
+			for k, v := range props {
+				np[k] = v
+			}
+		}
+		if rv, ok := CSSVarResolve(vars, val.(string), 0); ok {
+			np[key] = rv
+		} else {
+			delete(np, key)
+		}
+	}
+	if np == nil {
+		return props
+	}
+	return np
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+)
+
+func TestCSSVarResolve(t *testing.T) {
+	par := ki.Props{"--fg": "#00f", "--pad": "2px"}
+	vars := CSSVarsFromProps(par, ki.Props{"--border": "1px solid var(--fg)", "--a": "var(--b)", "--b": "var(--a)", "color": "red"})
+	if len(par) != 2 {
+		t.Errorf("parent vars were modified: %v", par)
+	}
+	if vars["--border"] != "1px solid #00f" {
+		t.Errorf("expected var in var value to be resolved, got: %v", vars["--border"])
+	}
+	if same := CSSVarsFromProps(par, ki.Props{"color": "red"}); len(same) != 2 {
+		t.Errorf("expected inherited vars without new ones, got: %v", same)
+	}
+
+	uv := units.NewValue(4, units.Px)
+	vars["--width"] = uv
+	vars["--color"] = Color{255, 0, 0, 128}
+	tests := []struct {
+		str string
+		val interface{}
+		ok  bool
+	}{
+		{"var(--fg)", "#00f", true},
+		{"var(--width)", uv, true}, // whole value as-is
+		{"calc(var(--width) + var(--pad))", "calc(4.000000px + 2px)", true},
+		{"1px solid var(--color)", "1px solid #ff000080", true},
+		{"var(--border)", "1px solid #00f", true},
+		{"var(--none, #f00)", "#f00", true},
+		{"var(--none, var(--fg))", "#00f", true},
+		{"var( --fg , #f00)", "#00f", true},
+		{"var(--none)", nil, false},
+		{"var(--a)", nil, false}, // cycle
+		{"var(--fg", nil, false},
+	}
+	for _, ts := range tests {
+		val, ok := CSSVarResolve(vars, ts.str, 0)
+		if ok != ts.ok || val != ts.val {
+			t.Errorf("%v: got: %v %v != %v %v", ts.str, val, ok, ts.val, ts.ok)
+		}
+	}
+
+	props := ki.Props{"color": "var(--fg)", "background-color": "var(--none)", "width": "10px"}
+	rp := ResolveCSSVars(vars, props)
+	if rp["color"] != "#00f" || rp["width"] != "10px" || len(rp) != 2 {
+		t.Errorf("expected resolved props without unresolved ones, got: %v", rp)
+	}
+	if props["color"] != "var(--fg)" {
+		t.Errorf("original props were modified: %v", props)
+	}
+}
+
+func TestCSSVarsTree(t *testing.T) {
+	vp, lay := testViewport(t, 300, 200)
+	vp.SetProp("--bg", "#ff0")
+	lay.SetProp("--fg", "#00f")
+	lay.SetProp("--pad", "3px")
+	lb := lay.AddNewChild(KiT_Label, "label").(*Label)
+	lb.SetText("outer")
+	lb.SetProp("color", "var(--fg)")
+	lb.SetProp("padding", "calc(var(--pad) * 2)")
+	sub := lay.AddNewChild(KiT_Layout, "sub").(*Layout)
+	sub.SetProp("--fg", "#0f0")
+	sub.SetProp("background-color", "var(--bg)") // label states override background
+	ilb := sub.AddNewChild(KiT_Label, "inner").(*Label)
+	ilb.SetText("inner")
+	ilb.SetProp("color", "var(--fg)")
+	flb := sub.AddNewChild(KiT_Label, "fallback").(*Label)
+	flb.SetText("fallback")
+	flb.SetProp("color", "var(--nosuch, #f00)")
+	vp.FullRender2DTree()
+
+	blue := Color{0, 0, 255, 255}
+	green := Color{0, 255, 0, 255}
+	red := Color{255, 0, 0, 255}
+	yellow := Color{255, 255, 0, 255}
+	if lb.Sty.Font.Color != blue {
+		t.Errorf("expected blue from layout var, got: %v", lb.Sty.Font.Color)
+	}
+	var uc units.Context
+	uc.Defaults()
+	if d := lb.Sty.Layout.Padding.ToDots(&uc); d != 6 {
+		t.Errorf("expected padding from var in calc, got: %v", lb.Sty.Layout.Padding)
+	}
+	if ilb.Sty.Font.Color != green {
+		t.Errorf("expected green from overriding sub layout var, got: %v", ilb.Sty.Font.Color)
+	}
+	if sub.Sty.Font.BgColor.Color != yellow {
+		t.Errorf("expected yellow from viewport props, got: %v", sub.Sty.Font.BgColor.Color)
+	}
+	if flb.Sty.Font.Color != red {
+		t.Errorf("expected red from fallback, got: %v", flb.Sty.Font.Color)
+	}
+	if lb.Sty.Vars["--fg"] != "#00f" || ilb.Sty.Vars["--fg"] != "#0f0" {
+		t.Errorf("expected inherited vars, got: %v %v", lb.Sty.Vars, ilb.Sty.Vars)
+	}
+}
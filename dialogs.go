@@ -453,6 +453,17 @@ func (dlg *Dialog) HasFocus2D() bool {
 	return true // dialog ALWAYS gets all the events!
 }
 
+// AccessInfo returns the accessibility info for the dialog, named by its
+// title
+func (dlg *Dialog) AccessInfo() AccessInfo {
+	ai := dlg.Viewport2D.AccessInfo()
+	ai.Role = RoleDialog
+	ai.Name = dlg.Title
+	ai.Desc = dlg.Prompt
+	ai.SetState(dlg.Modal, AccessModal)
+	return ai
+}
+
 //////////////////////////////////////////////////////////////////////////
 //     Specific Dialogs
 
@@ -0,0 +1,558 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/ki/kit"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  DockState -- arrangement of dock panels
+
+// DockSides are the places where a panel can be docked relative to another
+// panel: stacked with it as a tab, or splitting one of its edges
+type DockSides int32
+
+const (
+	// DockCenter stacks the panel as a tab with the other panel
+	DockCenter DockSides = iota
+
+	// DockLeft splits the left edge of the other panel
+	DockLeft
+
+	// DockRight splits the right edge of the other panel
+	DockRight
+
+	// DockTop splits the top edge of the other panel
+	DockTop
+
+	// DockBottom splits the bottom edge of the other panel
+	DockBottom
+
+	DockSidesN
+)
+
+//go:generate stringer -type=DockSides
+
+var KiT_DockSides = kit.Enums.AddEnumAltLower(DockSidesN, false, nil, "Dock")
+
+func (ev DockSides) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *DockSides) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// Dim returns the dimension along which the side splits
+func (ds DockSides) Dim() Dims2D {
+	if ds == DockTop || ds == DockBottom {
+		return Y
+	}
+	return X
+}
+
+// DockEdgeFrac is the proportion of the size of a panel, from each edge,
+// within which dropping another panel splits that edge -- drops further in
+// stack the panel as a tab
+var DockEdgeFrac = float32(0.25)
+
+// DockSideAt returns where a panel dropped at given position within the
+// given box of another panel is docked relative to that panel
+func DockSideAt(pos image.Point, bb image.Rectangle) DockSides {
+	sz := bb.Size()
+	if sz.X <= 0 || sz.Y <= 0 {
+		return DockCenter
+	}
+	fx := float32(pos.X-bb.Min.X) / float32(sz.X)
+	fy := float32(pos.Y-bb.Min.Y) / float32(sz.Y)
+	side, min := DockCenter, DockEdgeFrac
+	for _, ed := range []struct {
+		side DockSides
+		dst  float32
+	}{{DockLeft, fx}, {DockRight, 1 - fx}, {DockTop, fy}, {DockBottom, 1 - fy}} {
+		if ed.dst < min {
+			side, min = ed.side, ed.dst
+		}
+	}
+	return side
+}
+
+// DockNode is a node in the tree of docked panels: either a split of the
+// child nodes along a dimension, or a set of panels stacked as tabs
+type DockNode struct {
+	Dim    Dims2D      `desc:"for a split, the dimension along which it is split"`
+	Splits []float32   `desc:"for a split, the proportion of space for each child node"`
+	Kids   []*DockNode `desc:"for a split, the child nodes -- a node without kids holds tabs"`
+	Panels []string    `desc:"for tabs, the names of the panels, in tab order"`
+	Cur    int         `desc:"for tabs, the index of the selected panel"`
+}
+
+// IsSplit returns true if this node is a split, false if it holds tabs
+func (dn *DockNode) IsSplit() bool {
+	return len(dn.Kids) > 0
+}
+
+// IsEmpty returns true if this node has no panels or kids
+func (dn *DockNode) IsEmpty() bool {
+	return len(dn.Kids) == 0 && len(dn.Panels) == 0
+}
+
+// FuncTabs calls given function on each node holding tabs, depth-first --
+// stops if the function returns false, and returns false then
+func (dn *DockNode) FuncTabs(fun func(tn *DockNode) bool) bool {
+	if !dn.IsSplit() {
+		return fun(dn)
+	}
+	for _, k := range dn.Kids {
+		if !k.FuncTabs(fun) {
+			return false
+		}
+	}
+	return true
+}
+
+// FindPanel returns the node holding given panel, and its tab index --
+// returns nil if not found
+func (dn *DockNode) FindPanel(panel string) (*DockNode, int) {
+	var fn *DockNode
+	fi := -1
+	dn.FuncTabs(func(tn *DockNode) bool {
+		for i, pn := range tn.Panels {
+			if pn == panel {
+				fn, fi = tn, i
+				return false
+			}
+		}
+		return true
+	})
+	return fn, fi
+}
+
+// ParentOf returns the split node that has given node as a child, and the
+// index of the child -- returns nil if not found
+func (dn *DockNode) ParentOf(kn *DockNode) (*DockNode, int) {
+	for i, k := range dn.Kids {
+		if k == kn {
+			return dn, i
+		}
+		if pn, pi := k.ParentOf(kn); pn != nil {
+			return pn, pi
+		}
+	}
+	return nil, -1
+}
+
+// FirstPanel returns the first panel within this node, depth-first
+func (dn *DockNode) FirstPanel() string {
+	fp := ""
+	dn.FuncTabs(func(tn *DockNode) bool {
+		if len(tn.Panels) > 0 {
+			fp = tn.Panels[0]
+			return false
+		}
+		return true
+	})
+	return fp
+}
+
+// NormSplits makes the splits the same length as the kids, summing to 1
+func (dn *DockNode) NormSplits() {
+	if len(dn.Splits) != len(dn.Kids) {
+		sp := make([]float32, len(dn.Kids))
+		copy(sp, dn.Splits)
+		dn.Splits = sp
+	}
+	sum := float32(0)
+	for _, sp := range dn.Splits {
+		sum += sp
+	}
+	for i := range dn.Splits {
+		if sum == 0 {
+			dn.Splits[i] = 1 / float32(len(dn.Splits))
+		} else {
+			dn.Splits[i] /= sum
+		}
+	}
+}
+
+// Prune removes empty nodes, replaces splits having only one child with
+// that child, and merges child splits along the same dimension into this
+// one
+func (dn *DockNode) Prune() {
+	if !dn.IsSplit() {
+		if dn.Cur >= len(dn.Panels) {
+			dn.Cur = len(dn.Panels) - 1
+		}
+		if dn.Cur < 0 {
+			dn.Cur = 0
+		}
+		return
+	}
+	dn.NormSplits()
+	var kids []*DockNode
+	var splits []float32
+	for i, k := range dn.Kids {
+		k.Prune()
+		switch {
+		case k.IsEmpty():
+		case k.IsSplit() && k.Dim == dn.Dim:
+			for j, kk := range k.Kids {
+				kids = append(kids, kk)
+				splits = append(splits, dn.Splits[i]*k.Splits[j])
+			}
+		default:
+			kids = append(kids, k)
+			splits = append(splits, dn.Splits[i])
+		}
+	}
+	dn.Kids, dn.Splits = kids, splits
+	switch len(dn.Kids) {
+	case 0:
+		*dn = DockNode{}
+	case 1:
+		*dn = *dn.Kids[0]
+	default:
+		dn.NormSplits()
+	}
+}
+
+// Copy returns a deep copy of this node
+func (dn *DockNode) Copy() *DockNode {
+	cp := &DockNode{Dim: dn.Dim, Cur: dn.Cur}
+	cp.Splits = append(cp.Splits, dn.Splits...)
+	cp.Panels = append(cp.Panels, dn.Panels...)
+	for _, k := range dn.Kids {
+		cp.Kids = append(cp.Kids, k.Copy())
+	}
+	return cp
+}
+
+// DockFloat records a panel floating in its own window
+type DockFloat struct {
+	Panel  string      `desc:"name of the panel"`
+	Pos    image.Point `desc:"initial position of the window -- the window geometry is then saved in WinGeomPrefs"`
+	Size   image.Point `desc:"initial size of the window"`
+	Target string      `desc:"panel that this panel was docked with, to return to"`
+	Side   DockSides   `desc:"where this panel was docked relative to Target"`
+}
+
+// DockMin records a panel minimized to the side bar
+type DockMin struct {
+	Panel  string    `desc:"name of the panel"`
+	Target string    `desc:"panel that this panel was docked with, to return to"`
+	Side   DockSides `desc:"where this panel was docked relative to Target"`
+}
+
+// DockState is the arrangement of a set of named panels: the docked ones in
+// a tree of splits and tabs, and others floating in their own windows or
+// minimized to a side bar -- it is saved as JSON in DockLayoutPrefs
+type DockState struct {
+	Root      *DockNode   `desc:"root of the tree of docked panels"`
+	Floating  []DockFloat `desc:"panels floating in their own windows"`
+	Minimized []DockMin   `desc:"panels minimized to the side bar"`
+}
+
+// IsDocked returns true if the panel is docked within the tree
+func (ds *DockState) IsDocked(panel string) bool {
+	if ds.Root == nil {
+		return false
+	}
+	tn, _ := ds.Root.FindPanel(panel)
+	return tn != nil
+}
+
+// FloatIndex returns the index of the panel in Floating, or -1
+func (ds *DockState) FloatIndex(panel string) int {
+	for i, fl := range ds.Floating {
+		if fl.Panel == panel {
+			return i
+		}
+	}
+	return -1
+}
+
+// MinIndex returns the index of the panel in Minimized, or -1
+func (ds *DockState) MinIndex(panel string) int {
+	for i, mn := range ds.Minimized {
+		if mn.Panel == panel {
+			return i
+		}
+	}
+	return -1
+}
+
+// Has returns true if the panel is anywhere in the arrangement
+func (ds *DockState) Has(panel string) bool {
+	return ds.IsDocked(panel) || ds.FloatIndex(panel) >= 0 || ds.MinIndex(panel) >= 0
+}
+
+// Panels returns the names of all the panels in the arrangement
+func (ds *DockState) Panels() []string {
+	var pns []string
+	if ds.Root != nil {
+		ds.Root.FuncTabs(func(tn *DockNode) bool {
+			pns = append(pns, tn.Panels...)
+			return true
+		})
+	}
+	for _, fl := range ds.Floating {
+		pns = append(pns, fl.Panel)
+	}
+	for _, mn := range ds.Minimized {
+		pns = append(pns, mn.Panel)
+	}
+	return pns
+}
+
+// Add adds the panel as a tab in the first set of tabs, if it is not
+// already in the arrangement
+func (ds *DockState) Add(panel string) {
+	if ds.Has(panel) {
+		return
+	}
+	if ds.Root == nil {
+		ds.Root = &DockNode{}
+	}
+	ds.Root.FuncTabs(func(tn *DockNode) bool {
+		tn.Panels = append(tn.Panels, panel)
+		tn.Cur = len(tn.Panels) - 1
+		return false
+	})
+}
+
+// Remove removes the panel from the arrangement, pruning the tree --
+// returns false if it was not there
+func (ds *DockState) Remove(panel string) bool {
+	if fi := ds.FloatIndex(panel); fi >= 0 {
+		ds.Floating = append(ds.Floating[:fi], ds.Floating[fi+1:]...)
+		return true
+	}
+	if mi := ds.MinIndex(panel); mi >= 0 {
+		ds.Minimized = append(ds.Minimized[:mi], ds.Minimized[mi+1:]...)
+		return true
+	}
+	if ds.Root == nil {
+		return false
+	}
+	tn, idx := ds.Root.FindPanel(panel)
+	if tn == nil {
+		return false
+	}
+	tn.Panels = append(tn.Panels[:idx], tn.Panels[idx+1:]...)
+	if tn.Cur > idx {
+		tn.Cur--
+	}
+	ds.Root.Prune()
+	return true
+}
+
+// Dock docks the panel at given side of the target panel, which must be
+// docked -- DockCenter stacks it as the tab after the target, and the
+// other sides split the node holding the target, or add to an existing
+// split along the same dimension
+func (ds *DockState) Dock(panel, target string, side DockSides) error {
+	if panel == target {
+		return fmt.Errorf("gi.DockState: cannot dock panel %v relative to itself", panel)
+	}
+	if !ds.IsDocked(target) {
+		return fmt.Errorf("gi.DockState: target panel %v is not docked", target)
+	}
+	ds.Remove(panel)
+	tn, tidx := ds.Root.FindPanel(target) // after Remove, which can move nodes
+	if side == DockCenter {
+		tn.Panels = append(tn.Panels, "")
+		copy(tn.Panels[tidx+2:], tn.Panels[tidx+1:])
+		tn.Panels[tidx+1] = panel
+		tn.Cur = tidx + 1
+		return nil
+	}
+	nn := &DockNode{Panels: []string{panel}}
+	dim := side.Dim()
+	after := side == DockRight || side == DockBottom
+	if pn, pidx := ds.Root.ParentOf(tn); pn != nil && pn.Dim == dim {
+		pn.NormSplits()
+		share := pn.Splits[pidx] / 2
+		pn.Splits[pidx] = share
+		if after {
+			pidx++
+		}
+		pn.Kids = append(pn.Kids, nil)
+		copy(pn.Kids[pidx+1:], pn.Kids[pidx:])
+		pn.Kids[pidx] = nn
+		pn.Splits = append(pn.Splits, 0)
+		copy(pn.Splits[pidx+1:], pn.Splits[pidx:])
+		pn.Splits[pidx] = share
+		return nil
+	}
+	old := *tn
+	kids := []*DockNode{nn, &old}
+	if after {
+		kids = []*DockNode{&old, nn}
+	}
+	*tn = DockNode{Dim: dim, Kids: kids, Splits: []float32{0.5, 0.5}}
+	return nil
+}
+
+// Anchor returns a docked panel, and side relative to it, that would
+// return the given docked panel to where it is now -- used to remember
+// where floating and minimized panels came from -- target is empty if it
+// is the only docked panel
+func (ds *DockState) Anchor(panel string) (target string, side DockSides) {
+	if ds.Root == nil {
+		return "", DockCenter
+	}
+	tn, idx := ds.Root.FindPanel(panel)
+	if tn == nil {
+		return "", DockCenter
+	}
+	if len(tn.Panels) > 1 {
+		if idx > 0 {
+			return tn.Panels[idx-1], DockCenter
+		}
+		return tn.Panels[1], DockCenter // will be before it, but close enough
+	}
+	pn, pidx := ds.Root.ParentOf(tn)
+	if pn == nil {
+		return "", DockCenter
+	}
+	if pidx > 0 {
+		side = DockRight
+		if pn.Dim == Y {
+			side = DockBottom
+		}
+		return pn.Kids[pidx-1].FirstPanel(), side
+	}
+	side = DockLeft
+	if pn.Dim == Y {
+		side = DockTop
+	}
+	return pn.Kids[pidx+1].FirstPanel(), side
+}
+
+// Float moves the panel to float in its own window, with given initial
+// position and size
+func (ds *DockState) Float(panel string, pos, size image.Point) {
+	tg, sd := ds.Anchor(panel)
+	ds.Remove(panel)
+	ds.Floating = append(ds.Floating, DockFloat{Panel: panel, Pos: pos, Size: size, Target: tg, Side: sd})
+}
+
+// Minimize moves the panel to the side bar
+func (ds *DockState) Minimize(panel string) {
+	tg, sd := ds.Anchor(panel)
+	ds.Remove(panel)
+	ds.Minimized = append(ds.Minimized, DockMin{Panel: panel, Target: tg, Side: sd})
+}
+
+// Restore docks a floating or minimized panel back where it came from, or
+// in the first set of tabs if that panel is not docked anymore
+func (ds *DockState) Restore(panel string) error {
+	var tg string
+	var sd DockSides
+	if fi := ds.FloatIndex(panel); fi >= 0 {
+		tg, sd = ds.Floating[fi].Target, ds.Floating[fi].Side
+	} else if mi := ds.MinIndex(panel); mi >= 0 {
+		tg, sd = ds.Minimized[mi].Target, ds.Minimized[mi].Side
+	} else {
+		return fmt.Errorf("gi.DockState: panel %v is not floating or minimized", panel)
+	}
+	ds.Remove(panel)
+	if tg != "" && ds.IsDocked(tg) {
+		return ds.Dock(panel, tg, sd)
+	}
+	ds.Add(panel)
+	return nil
+}
+
+// Copy returns a deep copy of the state
+func (ds *DockState) Copy() DockState {
+	cp := DockState{}
+	if ds.Root != nil {
+		cp.Root = ds.Root.Copy()
+	}
+	cp.Floating = append(cp.Floating, ds.Floating...)
+	cp.Minimized = append(cp.Minimized, ds.Minimized...)
+	return cp
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+//  DockPrefs
+
+// DockLayoutPrefs are the saved dock arrangements
+var DockLayoutPrefs = DockPrefs{}
+
+// DockPrefs records the dock arrangements by name (see DockView.PrefsName)
+// -- saved persistently alongside the WindowGeomPrefs
+type DockPrefs map[string]DockState
+
+// DockPrefsFileName is the name of the preferences file in GoGi prefs directory
+var DockPrefsFileName = "dock_prefs.json"
+
+// Open Dock preferences from GoGi standard prefs directory -- it is not an
+// error for the file not to exist yet
+func (dp *DockPrefs) Open() error {
+	if *dp == nil {
+		*dp = make(DockPrefs, 100)
+	}
+	pdir := oswin.TheApp.GoGiPrefsDir()
+	pnm := filepath.Join(pdir, DockPrefsFileName)
+	b, err := ioutil.ReadFile(pnm)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		log.Println(err)
+		return err
+	}
+	err = json.Unmarshal(b, dp)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+// Save Dock preferences to GoGi standard prefs directory
+func (dp *DockPrefs) Save() error {
+	if *dp == nil {
+		return nil
+	}
+	pdir := oswin.TheApp.GoGiPrefsDir()
+	pnm := filepath.Join(pdir, DockPrefsFileName)
+	b, err := json.MarshalIndent(dp, "", "  ")
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	err = ioutil.WriteFile(pnm, b, 0644)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+// RecordPref records a copy of the given arrangement under given name, and
+// saves
+func (dp *DockPrefs) RecordPref(name string, ds *DockState) {
+	if *dp == nil {
+		*dp = make(DockPrefs, 100)
+	}
+	(*dp)[name] = ds.Copy()
+	dp.Save()
+}
+
+// Pref returns a copy of the arrangement saved under given name, and false
+// if there is none
+func (dp *DockPrefs) Pref(name string) (DockState, bool) {
+	ds, ok := (*dp)[name]
+	if !ok {
+		return DockState{}, false
+	}
+	return ds.Copy(), true
+}
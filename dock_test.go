@@ -0,0 +1,180 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func testDockPanels(t *testing.T, nm string, dn *DockNode, exp ...string) {
+	if !reflect.DeepEqual(dn.Panels, exp) {
+		t.Errorf("%v: expected panels %v, got %v", nm, exp, dn.Panels)
+	}
+}
+
+func TestDockState(t *testing.T) {
+	ds := DockState{}
+	ds.Add("a")
+	ds.Add("b")
+	ds.Add("c")
+	testDockPanels(t, "add", ds.Root, "a", "b", "c")
+
+	if err := ds.Dock("c", "a", DockRight); err != nil {
+		t.Fatal(err)
+	}
+	if !ds.Root.IsSplit() || ds.Root.Dim != X || len(ds.Root.Kids) != 2 {
+		t.Fatalf("expected horizontal split, got: %+v", ds.Root)
+	}
+	testDockPanels(t, "split left", ds.Root.Kids[0], "a", "b")
+	testDockPanels(t, "split right", ds.Root.Kids[1], "c")
+
+	// same dim adds to the existing split, taking half of the target's share
+	if err := ds.Dock("b", "c", DockLeft); err != nil {
+		t.Fatal(err)
+	}
+	if len(ds.Root.Kids) != 3 || !reflect.DeepEqual(ds.Root.Splits, []float32{0.5, 0.25, 0.25}) {
+		t.Fatalf("expected 3-way split, got: %+v", ds.Root)
+	}
+	testDockPanels(t, "3-way middle", ds.Root.Kids[1], "b")
+
+	if err := ds.Dock("d", "b", DockBottom); err != nil {
+		t.Fatal(err)
+	}
+	vs := ds.Root.Kids[1]
+	if !vs.IsSplit() || vs.Dim != Y {
+		t.Fatalf("expected vertical split, got: %+v", vs)
+	}
+	testDockPanels(t, "vert bottom", vs.Kids[1], "d")
+
+	// moving d back out collapses the vertical split
+	if err := ds.Dock("d", "a", DockCenter); err != nil {
+		t.Fatal(err)
+	}
+	testDockPanels(t, "stack", ds.Root.Kids[0], "a", "d")
+	if ds.Root.Kids[0].Cur != 1 || ds.Root.Kids[1].IsSplit() {
+		t.Errorf("expected d selected and the split collapsed, got: %+v", ds.Root)
+	}
+
+	if tg, sd := ds.Anchor("b"); tg != "a" || sd != DockRight {
+		t.Errorf("expected anchor a right, got: %v %v", tg, sd)
+	}
+	ds.Minimize("b")
+	ds.Float("d", image.Point{10, 10}, image.Point{200, 100})
+	if ds.IsDocked("b") || ds.MinIndex("b") != 0 || ds.FloatIndex("d") != 0 || !ds.Has("d") {
+		t.Errorf("expected b minimized and d floating, got: %+v", ds)
+	}
+	if len(ds.Root.Kids) != 2 || !reflect.DeepEqual(ds.Root.Splits, []float32{0.5 / 0.75, 0.25 / 0.75}) {
+		t.Errorf("expected 2-way split after minimize, got: %+v", ds.Root)
+	}
+	if err := ds.Restore("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.Restore("d"); err != nil {
+		t.Fatal(err)
+	}
+	testDockPanels(t, "restore b", ds.Root.Kids[1], "b")
+	testDockPanels(t, "restore d", ds.Root.Kids[0], "a", "d")
+	if err := ds.Restore("d"); err == nil {
+		t.Error("expected error restoring a docked panel")
+	}
+
+	cp := ds.Copy()
+	ds.Remove("a")
+	ds.Remove("d")
+	ds.Remove("b")
+	testDockPanels(t, "remove", ds.Root, "c")
+	if ds.Root.IsSplit() || len(cp.Panels()) != 4 {
+		t.Errorf("expected single tabs node and an intact copy, got: %+v %+v", ds.Root, cp.Panels())
+	}
+	if err := ds.Dock("a", "nope", DockLeft); err == nil {
+		t.Error("expected error docking to missing target")
+	}
+}
+
+func TestDockSideAt(t *testing.T) {
+	bb := image.Rect(0, 0, 100, 200)
+	tests := []struct {
+		pos  image.Point
+		side DockSides
+	}{
+		{image.Point{50, 100}, DockCenter},
+		{image.Point{10, 100}, DockLeft},
+		{image.Point{90, 100}, DockRight},
+		{image.Point{50, 20}, DockTop},
+		{image.Point{50, 190}, DockBottom},
+		{image.Point{5, 195}, DockBottom},
+	}
+	for _, ts := range tests {
+		if sd := DockSideAt(ts.pos, bb); sd != ts.side {
+			t.Errorf("pos %v: expected %v, got %v", ts.pos, ts.side, sd)
+		}
+	}
+}
+
+// testDockView returns a new DockView with frames as panels of given names
+func testDockView(par *Frame, names ...string) *DockView {
+	dv := par.AddNewChild(KiT_DockView, "dock").(*DockView)
+	for _, nm := range names {
+		pw := &Frame{}
+		pw.InitName(pw, nm)
+		dv.AddPanel(pw)
+	}
+	return dv
+}
+
+func TestDockViewPanelOrder(t *testing.T) {
+	win, mfr := testWindow(t, "dockview-order-test", 400, 300)
+	win.GoStartEventLoop()
+	defer testClose(t, win)
+	names := []string{"p0", "p1", "p2", "p3", "p4", "p5", "p6", "p7"}
+	exp := append([]string{"p5"}, names[:5]...)
+	exp = append(exp, names[6:]...)
+	testInLoop(t, win, func() {
+		updt := mfr.UpdateStart()
+		defer mfr.UpdateEnd(updt)
+		for i := 0; i < 10; i++ { // map order would differ between runs
+			dv := testDockView(mfr, names...)
+			dv.State = DockState{} // as restored from prefs without the panels
+			dv.State.Add("p5")
+			dv.Config()
+			if pns := dv.State.Panels(); !reflect.DeepEqual(pns, exp) {
+				t.Errorf("panels added from restored state: %v != %v", pns, exp)
+				return
+			}
+			mfr.DeleteChildren(true)
+		}
+	})
+}
+
+func TestDockViewTabDeleted(t *testing.T) {
+	win, mfr := testWindow(t, "dockview-tab-test", 400, 300)
+	dv := testDockView(mfr, "a", "b", "c")
+	dv.Config()
+	win.GoStartEventLoop()
+	defer testClose(t, win)
+	testSync(t, win)
+	var tv *TabView
+	testInLoop(t, win, func() {
+		tv = dv.Views[dv.State.Root].(*TabView)
+		tv.DeleteTabIndexAction(1)
+		if tv.IsDestroyed() {
+			t.Errorf("tab view destroyed while handling its signal")
+		}
+		if _, has := dv.Panels["b"]; has {
+			t.Errorf("deleted panel still in Panels")
+		}
+	})
+	testSync(t, win)
+	if !tv.IsDestroyed() {
+		t.Errorf("tab view was not rebuilt after the tab was deleted")
+	}
+	ntv, ok := dv.Views[dv.State.Root].(*TabView)
+	if !ok || ntv.NTabs() != 2 {
+		t.Fatalf("tab view after delete: %v", dv.Views)
+	}
+	testDockPanels(t, "after tab deleted", dv.State.Root, "a", "c")
+}
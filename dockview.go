@@ -0,0 +1,459 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image"
+	"log"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/dnd"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//    DockView
+
+// DockPanelMimeType is the mime type of the data for dragging a DockView
+// panel by its tab -- the data is the name of the panel
+const DockPanelMimeType = "application/x-gogi-dock-panel"
+
+// DockView is a workspace of named panels that the user can arrange: each
+// panel can be dragged by its tab to split any edge of another panel or
+// stack with it as a tab, and via the tab context menu it can float in its
+// own Window or be minimized to a side bar.  The docked panels are shown in
+// a tree of SplitView's and TabView's, built from the State by Config.  If
+// PrefsName is set, the arrangement is saved in DockLayoutPrefs whenever the
+// user changes it, alongside the WindowGeomPrefs of the floating windows.
+type DockView struct {
+	Layout
+	State     DockState            `desc:"arrangement of the panels -- call Config after changing it directly"`
+	PrefsName string               `desc:"if set, the arrangement is saved under this name in DockLayoutPrefs whenever the user changes it, and RestorePrefs restores it"`
+	Panels    map[string]Node2D    `json:"-" xml:"-" desc:"the panel widgets, by name -- see AddPanel"`
+	Views     map[*DockNode]Node2D `json:"-" xml:"-" desc:"the SplitView or TabView showing each node of the State, as of the last Config"`
+	Floats    map[string]*Window   `json:"-" xml:"-" desc:"the windows of the floating panels, by panel name"`
+	DockSig   ki.Signal            `json:"-" xml:"-" desc:"signal emitted when the user changes the arrangement -- data is the name of the panel that moved"`
+	order     []string
+}
+
+var KiT_DockView = kit.Types.AddType(&DockView{}, DockViewProps)
+
+var DockViewProps = ki.Props{
+	"max-width":  -1.0,
+	"max-height": -1.0,
+	"margin":     0,
+	"padding":    0,
+	"#side-bar": ki.Props{
+		"background-color": "linear-gradient(pref(Control), highlight-10)",
+		"padding":          units.NewValue(2, units.Px),
+		"spacing":          units.NewValue(2, units.Px),
+	},
+}
+
+// AddPanel adds a panel widget, using its name as the tab label -- if it is
+// not in the State, it is added in the first set of tabs -- call Config
+// (or RestorePrefs) after adding the panels
+func (dv *DockView) AddPanel(widg Node2D) {
+	if dv.Panels == nil {
+		dv.Panels = make(map[string]Node2D)
+	}
+	nm := widg.Name()
+	if _, has := dv.Panels[nm]; !has {
+		dv.order = append(dv.order, nm)
+	}
+	dv.Panels[nm] = widg
+	dv.State.Add(nm)
+}
+
+// forgetPanel removes the panel with given name from Panels
+func (dv *DockView) forgetPanel(panel string) {
+	delete(dv.Panels, panel)
+	for i, pn := range dv.order {
+		if pn == panel {
+			dv.order = append(dv.order[:i], dv.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// DeletePanel removes the panel with given name, optionally destroying it
+// -- does Config
+func (dv *DockView) DeletePanel(panel string, destroy bool) {
+	pw, ok := dv.Panels[panel]
+	if !ok {
+		return
+	}
+	dv.SyncState()
+	dv.DetachPanel(pw)
+	dv.forgetPanel(panel)
+	dv.State.Remove(panel)
+	if destroy {
+		pw.Destroy()
+	}
+	dv.Changed(panel)
+}
+
+// DetachPanel removes the panel widget from its current parent, without
+// destroying it
+func (dv *DockView) DetachPanel(pw Node2D) {
+	if par := pw.Parent(); par != nil {
+		par.DeleteChild(pw, false)
+	}
+}
+
+// NodeForView returns the node of the State shown by given view, or nil
+func (dv *DockView) NodeForView(vw Node2D) *DockNode {
+	for dn, v := range dv.Views {
+		if v == vw {
+			return dn
+		}
+	}
+	return nil
+}
+
+// SyncState updates the State from the views: the splits that the user
+// has dragged, and the selected tabs
+func (dv *DockView) SyncState() {
+	for dn, v := range dv.Views {
+		switch vw := v.(type) {
+		case *SplitView:
+			if len(vw.Splits) == len(dn.Splits) {
+				copy(dn.Splits, vw.Splits)
+			}
+		case *TabView:
+			if _, idx, ok := vw.CurTab(); ok {
+				dn.Cur = idx
+			}
+		}
+	}
+}
+
+// Config configures the views for the current State: the side bar of
+// minimized panels, the tree of docked panels, and the floating windows --
+// panels missing from the State are added in the order of AddPanel
+func (dv *DockView) Config() {
+	updt := dv.UpdateStart()
+	dv.SetFullReRender()
+	dv.Lay = LayoutHoriz
+	for _, pn := range dv.State.Panels() {
+		if _, ok := dv.Panels[pn]; !ok {
+			dv.State.Remove(pn)
+		}
+	}
+	for _, pn := range dv.order {
+		dv.State.Add(pn)
+		dv.DetachPanel(dv.Panels[pn])
+	}
+	dv.DeleteChildren(true)
+	dv.Views = make(map[*DockNode]Node2D)
+
+	if len(dv.State.Minimized) > 0 {
+		sb := dv.AddNewChild(KiT_Frame, "side-bar").(*Frame)
+		sb.Lay = LayoutVert
+		sb.SetStretchMaxHeight()
+		for _, mn := range dv.State.Minimized {
+			ac := sb.AddNewChild(KiT_Action, mn.Panel).(*Action)
+			ac.SetText(mn.Panel)
+			ac.Tooltip = "restore panel " + mn.Panel
+			ac.Data = mn.Panel
+			ac.ActionSig.ConnectOnly(dv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+				dvv := recv.Embed(KiT_DockView).(*DockView)
+				pn := data.(string)
+				dvv.changeLater(func() { dvv.RestorePanel(pn) })
+			})
+		}
+	}
+	if dv.State.Root != nil && !dv.State.Root.IsEmpty() {
+		dv.ConfigNode(dv.This, dv.State.Root, "dock")
+	}
+	dv.ConfigFloats()
+	dv.UpdateEnd(updt)
+}
+
+// ConfigNode adds the view for given node of the State, and its children,
+// to given parent
+func (dv *DockView) ConfigNode(par ki.Ki, dn *DockNode, name string) {
+	if dn.IsSplit() {
+		sv := par.AddNewChild(KiT_SplitView, name).(*SplitView)
+		sv.Dim = dn.Dim
+		for i, k := range dn.Kids {
+			dv.ConfigNode(sv.This, k, fmt.Sprintf("%v-%v", name, i))
+		}
+		sv.SetSplits(dn.Splits...)
+		dv.Views[dn] = sv
+		return
+	}
+	tv := par.AddNewChild(KiT_TabView, name).(*TabView)
+	tv.SetStretchMaxWidth()
+	tv.SetStretchMaxHeight()
+	for _, pn := range dn.Panels {
+		tv.AddTab(dv.Panels[pn], pn)
+	}
+	tv.SelectTabIndex(dn.Cur)
+	for _, tk := range tv.Tabs().Kids {
+		if tb, ok := tk.Embed(KiT_TabButton).(*TabButton); ok {
+			tb.CtxtMenuFunc = dv.TabCtxtMenu
+		}
+	}
+	tv.TabViewSig.ConnectOnly(dv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		dvv := recv.Embed(KiT_DockView).(*DockView)
+		tvv := send.Embed(KiT_TabView).(*TabView)
+		dvv.TabViewSignal(tvv, TabViewSignals(sig), data.(int))
+	})
+	dv.Views[dn] = tv
+}
+
+// TabViewSignal handles the signals from the TabView's: records the selected
+// tab, and removes panels whose tabs were closed
+func (dv *DockView) TabViewSignal(tv *TabView, sig TabViewSignals, idx int) {
+	dn := dv.NodeForView(tv.This.(Node2D))
+	if dn == nil || idx < 0 || idx >= len(dn.Panels) {
+		return
+	}
+	switch sig {
+	case TabSelected:
+		dn.Cur = idx
+	case TabDeleted: // TabView destroyed the panel
+		pn := dn.Panels[idx]
+		dv.forgetPanel(pn)
+		dv.SyncState() // selected tab is already among the remaining ones
+		dn.Panels = append(dn.Panels[:idx], dn.Panels[idx+1:]...)
+		dv.State.Root.Prune()
+		dv.changeLater(func() { dv.Changed(pn) })
+	}
+}
+
+// TabCtxtMenu is the context menu for the tabs of the panels
+func (dv *DockView) TabCtxtMenu(g Node2D, m *Menu) {
+	tb := g.Embed(KiT_TabButton).(*TabButton)
+	tv := tb.TabView()
+	if tv == nil {
+		return
+	}
+	dn := dv.NodeForView(tv.This.(Node2D))
+	idx := tb.Data.(int)
+	if dn == nil || idx < 0 || idx >= len(dn.Panels) {
+		return
+	}
+	pn := dn.Panels[idx]
+	m.AddAction(ActOpts{Label: "Float", Tooltip: "show the panel in its own window", Data: pn}, dv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		dvv := recv.Embed(KiT_DockView).(*DockView)
+		dvv.FloatPanel(data.(string))
+	})
+	m.AddAction(ActOpts{Label: "Minimize", Tooltip: "minimize the panel to the side bar", Data: pn}, dv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		dvv := recv.Embed(KiT_DockView).(*DockView)
+		dvv.MinimizePanel(data.(string))
+	})
+}
+
+// changeLater runs given function, which changes the arrangement, in the
+// event loop after the signal or event being handled, as the Config that it
+// does destroys the views that may have sent it -- runs it directly if
+// there is no window
+func (dv *DockView) changeLater(fun func()) {
+	win := dv.ParentWindow()
+	if win == nil || win.OSWin == nil {
+		fun()
+		return
+	}
+	win.RunInEventLoop(fun)
+}
+
+// Changed is called when the user has changed the arrangement: does
+// Config, saves the prefs, and emits the DockSig
+func (dv *DockView) Changed(panel string) {
+	dv.Config()
+	dv.SavePrefs()
+	dv.DockSig.Emit(dv.This, 0, panel)
+}
+
+// DockPanel docks the panel at given side of the target panel -- see
+// DockState.Dock
+func (dv *DockView) DockPanel(panel, target string, side DockSides) error {
+	dv.SyncState()
+	if err := dv.State.Dock(panel, target, side); err != nil {
+		return err
+	}
+	dv.Changed(panel)
+	return nil
+}
+
+// FloatPanel moves the panel to float in its own window, over where it is now
+func (dv *DockView) FloatPanel(panel string) {
+	pw, ok := dv.Panels[panel]
+	if !ok {
+		return
+	}
+	dv.SyncState()
+	wb := pw.AsWidget()
+	pos := wb.WinBBox.Min
+	if win := dv.ParentWindow(); win != nil && win.OSWin != nil {
+		pos = pos.Add(win.OSWin.Position())
+	}
+	size := wb.WinBBox.Size()
+	if size.X < 100 || size.Y < 100 {
+		size = image.Point{400, 300}
+	}
+	dv.State.Float(panel, pos, size)
+	dv.Changed(panel)
+}
+
+// MinimizePanel moves the panel to the side bar
+func (dv *DockView) MinimizePanel(panel string) {
+	if _, ok := dv.Panels[panel]; !ok {
+		return
+	}
+	dv.SyncState()
+	dv.State.Minimize(panel)
+	dv.Changed(panel)
+}
+
+// RestorePanel docks a floating or minimized panel back where it came from
+func (dv *DockView) RestorePanel(panel string) {
+	dv.SyncState()
+	if err := dv.State.Restore(panel); err != nil {
+		log.Println(err)
+		return
+	}
+	dv.Changed(panel)
+}
+
+// DropPanel handles a panel dropped at given window position: on the side
+// bar it is minimized, and on a set of tabs it is docked according to
+// DockSideAt
+func (dv *DockView) DropPanel(panel string, pos image.Point) {
+	if sbk, ok := dv.ChildByName("side-bar", 0); ok {
+		if sb := sbk.(Node2D).AsWidget(); sb != nil && pos.In(sb.WinBBox) {
+			dv.MinimizePanel(panel)
+			return
+		}
+	}
+	for dn, v := range dv.Views {
+		tv, ok := v.(*TabView)
+		if !ok || !pos.In(tv.WinBBox) {
+			continue
+		}
+		target := ""
+		for _, pn := range dn.Panels {
+			if pn != panel {
+				target = pn
+				break
+			}
+		}
+		if target == "" { // only panel in these tabs -- nothing to do
+			return
+		}
+		if err := dv.DockPanel(panel, target, DockSideAt(pos, tv.WinBBox)); err != nil {
+			log.Println(err)
+		}
+		return
+	}
+}
+
+// ConfigFloats opens a window for each floating panel that doesn't have one
+// yet, and closes those of panels that are not floating anymore
+func (dv *DockView) ConfigFloats() {
+	if dv.Floats == nil {
+		dv.Floats = make(map[string]*Window)
+	}
+	for pn, win := range dv.Floats {
+		if dv.State.FloatIndex(pn) < 0 {
+			delete(dv.Floats, pn)
+			win.OSWin.Close()
+		}
+	}
+	for _, fl := range dv.State.Floating {
+		pw := dv.Panels[fl.Panel]
+		win, has := dv.Floats[fl.Panel]
+		if !has {
+			win = dv.NewFloatWindow(fl)
+		}
+		vp := win.WinViewport2D()
+		updt := vp.UpdateStart()
+		mfr := win.SetMainFrame()
+		mfr.AddChild(pw)
+		vp.SetFullReRender()
+		if has {
+			vp.UpdateEnd(updt)
+		} else {
+			vp.UpdateEndNoSig(updt)
+			win.GoStartEventLoop()
+		}
+	}
+}
+
+// NewFloatWindow returns a new window for the floating panel -- closing the
+// window docks the panel back where it came from
+func (dv *DockView) NewFloatWindow(fl DockFloat) *Window {
+	wnm := dv.Nm
+	if dv.PrefsName != "" {
+		wnm = dv.PrefsName
+	}
+	wnm += "-" + fl.Panel
+	win := NewWindow2D(wnm, fl.Panel, fl.Size.X, fl.Size.Y, false)
+	if !win.HasGeomPrefs && fl.Pos != image.ZP {
+		win.OSWin.SetPos(fl.Pos)
+	}
+	pn := fl.Panel
+	win.OSWin.SetCloseReqFunc(func(w oswin.Window) {
+		dv.RestorePanel(pn)
+	})
+	dv.Floats[pn] = win
+	return win
+}
+
+// SavePrefs saves the arrangement in DockLayoutPrefs, if PrefsName is set
+func (dv *DockView) SavePrefs() {
+	if dv.PrefsName == "" {
+		return
+	}
+	dv.SyncState()
+	DockLayoutPrefs.RecordPref(dv.PrefsName, &dv.State)
+}
+
+// RestorePrefs restores the arrangement saved under PrefsName, if any, and
+// does Config -- panels that are not present anymore are dropped, and new
+// ones are added in the order of AddPanel -- call after adding the panels
+func (dv *DockView) RestorePrefs() {
+	if dv.PrefsName != "" {
+		if ds, ok := DockLayoutPrefs.Pref(dv.PrefsName); ok {
+			dv.State = ds
+		}
+	}
+	dv.Config()
+}
+
+// DockViewEvents handles the drop of panels dragged by their tabs
+func (dv *DockView) DockViewEvents() {
+	dv.ConnectEvent(oswin.DNDEvent, HiPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		de := d.(*dnd.Event)
+		if de.Action != dnd.DropOnTarget {
+			return
+		}
+		dvv := recv.Embed(KiT_DockView).(*DockView)
+		for _, md := range de.Data {
+			if md.Type != DockPanelMimeType {
+				continue
+			}
+			pn := string(md.Data)
+			if _, ok := dvv.Panels[pn]; !ok { // from another dock
+				return
+			}
+			de.Target = dvv.This
+			de.SetProcessed()
+			where := de.Where
+			dvv.changeLater(func() { dvv.DropPanel(pn, where) })
+			return
+		}
+	})
+}
+
+func (dv *DockView) ConnectEvents2D() {
+	dv.Layout.ConnectEvents2D()
+	dv.DockViewEvents()
+}
@@ -0,0 +1,555 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// EventRecord is one line in an event recording file, which is stored in
+// JSON lines format (one JSON object per line).  It either records a GUI
+// event that was received by a window (Type is the oswin.EventType name), or
+// a check on the state of a widget (Type is "Check"), which is verified by
+// the EventPlayer.  Mouse positions are recorded relative to the widget
+// under the mouse, identified by its Path, so recordings remain valid if
+// the window layout shifts.
+type EventRecord struct {
+	Type   string      `desc:"oswin.EventType name of event, or Check for a widget state check"`
+	T      int64       `desc:"time of event in msec since start of recording"`
+	Delay  int64       `json:",omitempty" desc:"delay in msec since previous record"`
+	Action string      `json:",omitempty" desc:"mouse.Actions or window.Actions name"`
+	Button string      `json:",omitempty" desc:"mouse.Buttons name"`
+	Mods   int32       `json:",omitempty" desc:"key.Modifiers bit flags"`
+	Chord  key.Chord   `json:",omitempty" desc:"key chord for key events"`
+	Path   string      `json:",omitempty" desc:"widget path relative to window (see Window.WidgetPath) -- position is relative to this widget"`
+	Offset image.Point `desc:"mouse position relative to upper-left of widget at Path"`
+	Pos    image.Point `desc:"absolute mouse position in the window -- used if Path is empty or cannot be found"`
+	Delta  image.Point `json:",omitempty" desc:"scroll delta for scroll events"`
+	Size   image.Point `json:",omitempty" desc:"window size for resize events"`
+	Field  string      `json:",omitempty" desc:"for Check: name of field on widget at Path, e.g., Text -- can be a path of fields separated by ."`
+	Value  string      `json:",omitempty" desc:"for Check: expected string value of Field (as from kit.ToString)"`
+}
+
+// EventRecordCheck is the EventRecord Type for widget state checks
+const EventRecordCheck = "Check"
+
+// EventPopupPath is the first element of a widget path for nodes within the
+// current popup (e.g., a menu), which are not children of the main Viewport
+const EventPopupPath = "@popup"
+
+/////////////////////////////////////////////////////////////////////////////
+//                   Widget paths
+
+// WidgetPath returns the path to given node relative to the window, as
+// unique names separated by / starting from the window Viewport (or
+// EventPopupPath for nodes within the current popup) -- returns empty
+// string if node is not within the window.
+func (w *Window) WidgetPath(k ki.Ki) string {
+	var els []string
+	for cur := k; cur != nil; cur = cur.Parent() {
+		if cur == w.Popup {
+			els = append(els, EventPopupPath)
+			break
+		}
+		if w.Viewport != nil && cur == w.Viewport.This {
+			break
+		}
+		els = append(els, cur.UniqueName())
+		if cur.Parent() == nil {
+			return ""
+		}
+	}
+	for i, j := 0, len(els)-1; i < j; i, j = i+1, j-1 {
+		els[i], els[j] = els[j], els[i]
+	}
+	return strings.Join(els, "/")
+}
+
+// WidgetByPath returns the node at given path relative to the window, as
+// returned by WidgetPath -- nil if not found.
+func (w *Window) WidgetByPath(path string) Node2D {
+	if w.Viewport == nil {
+		return nil
+	}
+	var cur ki.Ki = w.Viewport.This
+	els := strings.Split(path, "/")
+	if len(els) > 0 && els[0] == EventPopupPath {
+		if w.Popup == nil {
+			return nil
+		}
+		cur = w.Popup
+		els = els[1:]
+	}
+	for _, el := range els {
+		if el == "" {
+			continue
+		}
+		var nxt ki.Ki
+		for _, kid := range *cur.Children() {
+			if kid.UniqueName() == el {
+				nxt = kid
+				break
+			}
+		}
+		if nxt == nil {
+			return nil
+		}
+		cur = nxt
+	}
+	nii, _ := KiToNode2D(cur)
+	return nii
+}
+
+// WidgetAtPos returns the deepest visible node whose WinBBox contains the
+// given window position, looking first in the current popup if any -- nil
+// if none.
+func (w *Window) WidgetAtPos(pos image.Point) Node2D {
+	var root ki.Ki
+	if w.Popup != nil {
+		if _, pni := KiToNode2D(w.Popup); pni != nil && pos.In(pni.WinBBox) {
+			root = w.Popup
+		}
+	}
+	if root == nil {
+		if w.Viewport == nil {
+			return nil
+		}
+		root = w.Viewport.This
+	}
+	var rval Node2D
+	cur := root
+	for cur != nil {
+		var nxt ki.Ki
+		for _, kid := range *cur.Children() {
+			nii, ni := KiToNode2D(kid)
+			if nii == nil || ni.IsInvisible() {
+				continue
+			}
+			if pos.In(ni.WinBBox) {
+				rval = nii
+				nxt = kid
+				// keep going -- later siblings are rendered on top
+			}
+		}
+		cur = nxt
+	}
+	if rval == nil {
+		rval, _ = KiToNode2D(root)
+	}
+	return rval
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//                   EventRecorder
+
+// EventRecorder records events received by a Window to an io.Writer, in
+// EventRecord JSON lines format.  Install it using Window.StartRecording.
+// Only the primary input events are recorded: mouse button, move, drag and
+// scroll events, key chords, and window resizes -- all other events are
+// derived from these by the Window or driver.
+type EventRecorder struct {
+	Win   *Window   `desc:"window we are recording"`
+	Start time.Time `desc:"time that recording started"`
+	Last  time.Time `desc:"time of last record"`
+	Err   error     `desc:"first error encountered in writing -- no further records are written after an error"`
+	out   io.Writer
+	file  *os.File
+	enc   *json.Encoder
+	mu    sync.Mutex
+}
+
+// NewEventRecorder returns a new recorder for given window writing to given
+// writer -- does not install it in the window -- see Window.StartRecording.
+func NewEventRecorder(win *Window, out io.Writer) *EventRecorder {
+	er := &EventRecorder{Win: win, out: out}
+	er.enc = json.NewEncoder(out)
+	er.Start = time.Now()
+	er.Last = er.Start
+	return er
+}
+
+// Write writes the given record, filling in the times, and returning any
+// error.
+func (er *EventRecorder) Write(rec *EventRecord) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	if er.Err != nil {
+		return er.Err
+	}
+	now := time.Now()
+	rec.T = int64(now.Sub(er.Start) / time.Millisecond)
+	rec.Delay = int64(now.Sub(er.Last) / time.Millisecond)
+	er.Last = now
+	er.Err = er.enc.Encode(rec)
+	return er.Err
+}
+
+// setPos sets the Path, Offset and Pos of the record from given position
+func (er *EventRecorder) setPos(rec *EventRecord, pos image.Point) {
+	rec.Pos = pos
+	nii := er.Win.WidgetAtPos(pos)
+	if nii == nil {
+		return
+	}
+	ni := nii.AsNode2D()
+	rec.Path = er.Win.WidgetPath(ni.This)
+	rec.Offset = pos.Sub(ni.WinBBox.Min)
+}
+
+// Record records given event if it is one of the recorded types -- called
+// by the Window EventLoop for each event received.
+func (er *EventRecorder) Record(evi oswin.Event) {
+	rec := &EventRecord{Type: evi.Type().String()}
+	switch e := evi.(type) {
+	case *mouse.Event:
+		rec.Action = e.Action.String()
+		rec.Button = e.Button.String()
+		rec.Mods = e.Modifiers
+		er.setPos(rec, e.Where)
+	case *mouse.MoveEvent:
+		rec.Mods = e.Modifiers
+		er.setPos(rec, e.Where)
+	case *mouse.DragEvent:
+		rec.Button = e.Button.String()
+		rec.Mods = e.Modifiers
+		er.setPos(rec, e.Where)
+	case *mouse.ScrollEvent:
+		rec.Mods = e.Modifiers
+		rec.Delta = e.Delta
+		er.setPos(rec, e.Where)
+	case *key.ChordEvent:
+		rec.Chord = e.Chord()
+	case *window.Event:
+		if e.Action != window.Resize {
+			return
+		}
+		rec.Action = e.Action.String()
+		rec.Size = er.Win.OSWin.Size()
+	default:
+		return
+	}
+	er.Write(rec)
+}
+
+// RecordCheck records a Check of the current value of given field (or path
+// of fields separated by .) on given node, which the EventPlayer will verify
+// at this point in the playback.
+func (er *EventRecorder) RecordCheck(k ki.Ki, field string) error {
+	val, err := WidgetFieldValue(k, field)
+	if err != nil {
+		return err
+	}
+	rec := &EventRecord{Type: EventRecordCheck, Path: er.Win.WidgetPath(k), Field: field, Value: val}
+	return er.Write(rec)
+}
+
+// Close closes the output file if the recorder was started with a file name.
+func (er *EventRecorder) Close() error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	if er.file != nil {
+		err := er.file.Close()
+		er.file = nil
+		return err
+	}
+	return nil
+}
+
+// StartRecording starts recording all events received by the window to the
+// given file, in EventRecord JSON lines format.
+func (w *Window) StartRecording(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	er := NewEventRecorder(w, f)
+	er.file = f
+	w.StopRecording()
+	w.Recorder = er
+	return nil
+}
+
+// StopRecording stops any current recording, closing the file.
+func (w *Window) StopRecording() error {
+	if w.Recorder == nil {
+		return nil
+	}
+	err := w.Recorder.Close()
+	w.Recorder = nil
+	return err
+}
+
+// WidgetFieldValue returns the string value (as from kit.ToString) of the
+// given field (or path of fields separated by .) on the given node -- fields
+// in embedded structs are found as usual.
+func WidgetFieldValue(k ki.Ki, field string) (string, error) {
+	v := reflect.ValueOf(k)
+	for _, fn := range strings.Split(field, ".") {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return "", fmt.Errorf("gi.WidgetFieldValue: field %v not found on %v -- not a struct", field, k.PathUnique())
+		}
+		v = v.FieldByName(fn)
+		if !v.IsValid() {
+			return "", fmt.Errorf("gi.WidgetFieldValue: field %v not found on %v", field, k.PathUnique())
+		}
+	}
+	return kit.ToString(v.Interface()), nil
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//                   EventPlayer
+
+// EventPlayer plays back EventRecord records against a window, by sending
+// the events to the OS window event queue as if they came from the user, and
+// waiting for each to be processed by the EventLoop before sending the next.
+// Checks are verified when encountered, and any failures are recorded in
+// Errs.  The window must already be open with its EventLoop running.
+type EventPlayer struct {
+	Win         *Window                          `desc:"window to play events into"`
+	Recs        []*EventRecord                   `desc:"records to play"`
+	Speed       float32                          `desc:"speed multiplier for delays between events -- 1 = recorded speed, 0 = no delays at all (note: drag and hover events require some delay to be recognized)"`
+	MaxDelay    time.Duration                    `desc:"maximum delay between events, regardless of recorded delay"`
+	SyncTimeout time.Duration                    `desc:"maximum amount of time to wait for the window to process each event"`
+	StopOnErr   bool                             `desc:"stop playback at first failed check or other error"`
+	Errs        []error                          `desc:"errors from failed checks or missing widgets"`
+	StepFunc    func(step int, rec *EventRecord) `json:"-" desc:"optional function called after each record has been played and processed -- can perform additional checks on widget state"`
+	lastPos     image.Point
+	lastBut     mouse.Buttons
+}
+
+// NewEventPlayer returns a new player for given window, reading the records
+// from given reader.
+func NewEventPlayer(win *Window, r io.Reader) (*EventPlayer, error) {
+	ep := &EventPlayer{Win: win, Speed: 1, MaxDelay: 2 * time.Second, SyncTimeout: 5 * time.Second}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	ln := 0
+	for sc.Scan() {
+		ln++
+		lb := sc.Bytes()
+		if len(strings.TrimSpace(string(lb))) == 0 {
+			continue
+		}
+		rec := &EventRecord{}
+		if err := json.Unmarshal(lb, rec); err != nil {
+			return nil, fmt.Errorf("gi.EventPlayer: line %v: %v", ln, err)
+		}
+		ep.Recs = append(ep.Recs, rec)
+	}
+	return ep, sc.Err()
+}
+
+// OpenEventPlayer returns a new player for given window, reading the
+// records from given file.
+func OpenEventPlayer(win *Window, filename string) (*EventPlayer, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewEventPlayer(win, f)
+}
+
+// Play plays all the records, returning the first error if any (all errors
+// are in Errs).
+func (ep *EventPlayer) Play() error {
+	for i, rec := range ep.Recs {
+		if ep.Win.IsClosed() {
+			ep.addErr(fmt.Errorf("gi.EventPlayer: window closed at step %v", i))
+			break
+		}
+		if ep.Speed > 0 && rec.Delay > 0 {
+			dly := time.Duration(float32(rec.Delay)*ep.Speed) * time.Millisecond
+			if ep.MaxDelay > 0 && dly > ep.MaxDelay {
+				dly = ep.MaxDelay
+			}
+			time.Sleep(dly)
+		}
+		err := ep.PlayRecord(rec)
+		if err == nil {
+			err = ep.Sync()
+		}
+		if err != nil {
+			ep.addErr(fmt.Errorf("step %v: %v", i, err))
+			if ep.StopOnErr {
+				break
+			}
+		}
+		if ep.StepFunc != nil {
+			ep.StepFunc(i, rec)
+		}
+	}
+	if len(ep.Errs) > 0 {
+		return ep.Errs[0]
+	}
+	return nil
+}
+
+func (ep *EventPlayer) addErr(err error) {
+	ep.Errs = append(ep.Errs, err)
+}
+
+// Sync waits until all events sent to the window so far have been processed
+// by its EventLoop.
+func (ep *EventPlayer) Sync() error {
+	se := &syncEvent{done: make(chan struct{})}
+	se.Init()
+	ep.Win.OSWin.Send(se)
+	select {
+	case <-se.done:
+		return nil
+	case <-time.After(ep.SyncTimeout):
+		return fmt.Errorf("gi.EventPlayer: timed out waiting for window to process events")
+	}
+}
+
+// RecordPos returns the window position for given record, based on the
+// current position of the widget at its Path
+func (ep *EventPlayer) RecordPos(rec *EventRecord) (image.Point, error) {
+	if rec.Path == "" {
+		return rec.Pos, nil
+	}
+	nii := ep.Win.WidgetByPath(rec.Path)
+	if nii == nil {
+		return rec.Pos, fmt.Errorf("widget not found: %v", rec.Path)
+	}
+	return nii.AsNode2D().WinBBox.Min.Add(rec.Offset), nil
+}
+
+// PlayRecord sends the event(s) for given record to the window, or performs
+// the check -- does not wait for the events to be processed (see Sync).
+func (ep *EventPlayer) PlayRecord(rec *EventRecord) error {
+	if rec.Type == EventRecordCheck {
+		return ep.Check(rec)
+	}
+	osw := ep.Win.OSWin
+	send := func(ev oswin.Event) {
+		ev.Init()
+		osw.Send(ev)
+	}
+	switch rec.Type {
+	case oswin.MouseEvent.String(), oswin.MouseMoveEvent.String(), oswin.MouseDragEvent.String(), oswin.MouseScrollEvent.String():
+		pos, err := ep.RecordPos(rec) // on err, still send event at recorded pos
+		me := mouse.Event{Where: pos, Modifiers: rec.Mods}
+		if bt, ok := enumFromString(rec.Button, int(mouse.ButtonsN), func(i int) string { return mouse.Buttons(i).String() }); ok {
+			me.Button = mouse.Buttons(bt)
+		}
+		switch rec.Type {
+		case oswin.MouseEvent.String():
+			if act, ok := enumFromString(rec.Action, int(mouse.ActionsN), func(i int) string { return mouse.Actions(i).String() }); ok {
+				me.Action = mouse.Actions(act)
+			}
+			ep.lastBut = mouse.NoButton
+			if me.Action == mouse.Press || me.Action == mouse.DoubleClick {
+				ep.lastBut = me.Button
+			}
+			send(&me)
+		case oswin.MouseMoveEvent.String():
+			me.Action = mouse.Move
+			send(&mouse.MoveEvent{Event: me, From: ep.lastPos})
+		case oswin.MouseDragEvent.String():
+			me.Action = mouse.Drag
+			if me.Button == mouse.NoButton {
+				me.Button = ep.lastBut
+			}
+			send(&mouse.DragEvent{MoveEvent: mouse.MoveEvent{Event: me, From: ep.lastPos}})
+		case oswin.MouseScrollEvent.String():
+			me.Action = mouse.Scroll
+			send(&mouse.ScrollEvent{Event: me, Delta: rec.Delta})
+		}
+		ep.lastPos = pos
+		return err
+	case oswin.KeyChordEvent.String():
+		r, code, mods, err := rec.Chord.DecodeCode()
+		if err != nil {
+			return err
+		}
+		ke := key.Event{Rune: r, Code: code, Modifiers: mods, Action: key.Press}
+		kp := ke
+		send(&kp)
+		send(&key.ChordEvent{Event: ke})
+		kr := ke
+		kr.Action = key.Release
+		send(&kr)
+	case oswin.WindowResizeEvent.String():
+		osw.SetSize(rec.Size)
+	default:
+		return fmt.Errorf("unknown event record type: %v", rec.Type)
+	}
+	return nil
+}
+
+// Check verifies the widget state check in given record, returning an error
+// if it fails.
+func (ep *EventPlayer) Check(rec *EventRecord) error {
+	nii := ep.Win.WidgetByPath(rec.Path)
+	if nii == nil {
+		return fmt.Errorf("check: widget not found: %v", rec.Path)
+	}
+	val, err := WidgetFieldValue(nii, rec.Field)
+	if err != nil {
+		return err
+	}
+	if val != rec.Value {
+		return fmt.Errorf("check failed: %v.%v = %q, expected %q", rec.Path, rec.Field, val, rec.Value)
+	}
+	return nil
+}
+
+// enumFromString returns the enum value among the first n values whose
+// String() (given by str) matches s
+func enumFromString(s string, n int, str func(i int) string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	for i := 0; i < n; i++ {
+		if str(i) == s {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// syncEvent is sent by the EventPlayer to determine when all prior events
+// have been processed -- the EventLoop closes done when it receives it
+type syncEvent struct {
+	oswin.EventBase
+	done chan struct{}
+}
+
+func (ev syncEvent) Type() oswin.EventType {
+	return oswin.EventTypeN
+}
+
+func (ev syncEvent) HasPos() bool {
+	return false
+}
+
+func (ev syncEvent) Pos() image.Point {
+	return image.ZP
+}
+
+func (ev syncEvent) OnFocus() bool {
+	return false
+}
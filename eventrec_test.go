@@ -0,0 +1,180 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/ki"
+)
+
+// testWindow returns a new window of given size, with the offscreen driver
+// and default preferences, and its main frame -- start the event loop after
+// adding the widgets
+func testWindow(t *testing.T, name string, width, height int) (*Window, *Frame) {
+	if oswin.TheApp == nil {
+		offscreen.Main(func(app oswin.App) {})
+	}
+	if Prefs.LogicalDPIScale == 0 {
+		Prefs.Defaults()
+		Prefs.Apply()
+	}
+	win := NewWindow2D(name, name, width, height, true)
+	if win == nil {
+		t.Fatalf("could not create window")
+	}
+	return win, win.SetMainFrame()
+}
+
+// testSync waits until the window has processed all events sent so far
+func testSync(t *testing.T, win *Window) {
+	ep := &EventPlayer{Win: win, SyncTimeout: 5 * time.Second}
+	if err := ep.Sync(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testInLoop runs given function in the event loop of the window and waits
+// for it to finish
+func testInLoop(t *testing.T, win *Window, fun func()) {
+	win.RunInEventLoop(fun)
+	testSync(t, win)
+}
+
+// testClose closes the window and waits until it has been removed from
+// AllWindows, so later tests do not see a half-closed window
+func testClose(t *testing.T, win *Window) {
+	win.OSWin.Close()
+	for i := 0; i < 500; i++ {
+		if _, ok := AllWindows.FindName(win.Nm); !ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("window %v was not closed", win.Nm)
+}
+
+// testCenter returns the center of the widget in window coordinates
+func testCenter(nii Node2D) image.Point {
+	bb := nii.AsNode2D().WinBBox
+	return bb.Min.Add(bb.Max).Div(2)
+}
+
+func TestEventRecordReplay(t *testing.T) {
+	win, mfr := testWindow(t, "eventrec-test", 300, 200)
+	tf := mfr.AddNewChild(KiT_TextField, "text").(*TextField)
+	tf.SetProp("width", "20em")
+	bt := mfr.AddNewChild(KiT_Button, "clear").(*Button)
+	bt.SetText("Clear")
+	nclick := 0
+	bt.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(ButtonClicked) {
+			nclick++
+		}
+	})
+	var rec bytes.Buffer
+	er := NewEventRecorder(win, &rec)
+	win.Recorder = er
+	win.GoStartEventLoop()
+	defer testClose(t, win)
+	if !offscreen.WaitPublish(win.OSWin, 0, 5*time.Second) {
+		t.Fatal("window was not rendered")
+	}
+	testSync(t, win)
+
+	osw := win.OSWin
+	offscreen.MouseClick(osw, testCenter(tf), mouse.Left)
+	offscreen.TypeString(osw, "abc")
+	offscreen.KeyChord(osw, "ReturnEnter")
+	offscreen.MouseClick(osw, testCenter(bt), mouse.Left)
+	testSync(t, win)
+	if err := er.RecordCheck(tf.This, "Txt"); err != nil {
+		t.Fatal(err)
+	}
+	testInLoop(t, win, func() { win.Recorder = nil })
+	if err := er.Err; err != nil {
+		t.Fatal(err)
+	}
+	if tf.Txt != "abc" || nclick != 1 {
+		t.Fatalf("recording: expected text abc and 1 click, got: %q %v", tf.Txt, nclick)
+	}
+	if !strings.Contains(rec.String(), mfr.UniqueName()+`/text"`) {
+		t.Errorf("expected events recorded relative to the text field, got:\n%v", rec.String())
+	}
+
+	testInLoop(t, win, func() { tf.SetText("") })
+	ep, err := NewEventPlayer(win, bytes.NewReader(rec.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep.Speed = 0
+	steps := 0
+	ep.StepFunc = func(step int, rec *EventRecord) { steps++ }
+	if err := ep.Play(); err != nil {
+		t.Errorf("replay: %v", err)
+	}
+	if steps != len(ep.Recs) || ep.Recs[len(ep.Recs)-1].Type != EventRecordCheck {
+		t.Errorf("expected %v steps ending in a check, got: %v", len(ep.Recs), steps)
+	}
+	if tf.Txt != "abc" || nclick != 2 {
+		t.Errorf("replay: expected text abc and 2 clicks, got: %q %v", tf.Txt, nclick)
+	}
+
+	// a failed check is reported
+	chk := &EventRecord{Type: EventRecordCheck, Path: ep.Recs[len(ep.Recs)-1].Path, Field: "Txt", Value: "xyz"}
+	cb, _ := json.Marshal(chk)
+	ep, err = NewEventPlayer(win, bytes.NewReader(cb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ep.Play(); err == nil || !strings.Contains(err.Error(), "check failed") {
+		t.Errorf("expected failed check, got: %v", err)
+	}
+}
+
+func TestEventPlayerBadRecords(t *testing.T) {
+	win, mfr := testWindow(t, "eventrec-bad", 200, 100)
+	mfr.AddNewChild(KiT_Button, "ok")
+	win.GoStartEventLoop()
+	defer testClose(t, win)
+
+	bad := []string{
+		`{"Type":"MouseEvent","Action":"Press","Pos":{"X":1`, // truncated
+		`{"Type":"MouseEvent"}` + "\n" + `not json`,
+		`["Type"]`,
+	}
+	for _, src := range bad {
+		if _, err := NewEventPlayer(win, strings.NewReader(src)); err == nil {
+			t.Errorf("expected error reading: %v", src)
+		}
+	}
+
+	errs := []string{
+		`{"Type":"NoSuchEvent"}`,
+		`{"Type":"KeyChordEvent","Chord":"Control+NoSuchKey"}`,
+		`{"Type":"Check","Path":"/nowhere","Field":"Txt"}`,
+		`{"Type":"Check","Path":"` + mfr.UniqueName() + `/ok","Field":"NoSuchField"}`,
+		`{"Type":"MouseEvent","Action":"Press","Button":"Left","Path":"/nowhere"}`,
+	}
+	for _, src := range errs {
+		ep, err := NewEventPlayer(win, strings.NewReader(src))
+		if err != nil {
+			t.Errorf("reading %v: %v", src, err)
+			continue
+		}
+		ep.Speed = 0
+		if err := ep.Play(); err == nil {
+			t.Errorf("expected error playing: %v", src)
+		}
+	}
+}
@@ -138,11 +138,7 @@ func CompleteGocode(data interface{}, text string, pos token.Position) (matches
 
 	seed = complete.SeedGolang(text)
 
-	textbytes := make([]byte, 0, txbuf.NLines*40)
-	for _, lr := range txbuf.Lines {
-		textbytes = append(textbytes, []byte(string(lr))...)
-		textbytes = append(textbytes, '\n')
-	}
+	textbytes := txbuf.LinesToBytesCopy()
 
 	// check first for file level declarations, import, const, type, var, func
 	// by parsing the file to create AST - if none returned let gocode have a try
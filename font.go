@@ -1032,7 +1032,8 @@ var shortFontMods = map[string]string{
 
 // OpenFontFace loads a font file at given path, with given raw size in
 // display dots, and if strokeWidth is > 0, the font is drawn in outline form
-// (stroked) instead of filled (supported in SVG).
+// (stroked) instead of filled (supported in SVG).  Fonts with color glyph
+// tables (CBDT, sbix, COLR) are returned as a ColorFace, unless stroked.
 func OpenFontFace(path string, size int, strokeWidth int) (font.Face, error) {
 	if strings.HasPrefix(path, "gofont") {
 		return OpenGoFont(path, size, strokeWidth)
@@ -1041,31 +1042,42 @@ func OpenFontFace(path string, size int, strokeWidth int) (font.Face, error) {
 	if err != nil {
 		return nil, err
 	}
+	var cf *ColorFont
+	if strokeWidth == 0 {
+		cf, err = ParseColorFont(fontBytes)
+		if err != nil {
+			log.Printf("gi.OpenFontFace: color tables in font %v not usable: %v\n", path, err)
+		}
+	}
+	var face font.Face
 	ext := strings.ToLower(filepath.Ext(path))
 	if ext == ".otf" {
 		// note: this compiles but otf fonts are NOT yet supported apparently
-		f, err := sfnt.Parse(fontBytes)
-		if err != nil {
-			return nil, err
+		var f *sfnt.Font
+		f, err = sfnt.Parse(fontBytes)
+		if err == nil {
+			face, err = opentype.NewFace(f, &opentype.FaceOptions{
+				Size: float64(size),
+				// Hinting: font.HintingFull,
+			})
 		}
-		face, err := opentype.NewFace(f, &opentype.FaceOptions{
-			Size: float64(size),
-			// Hinting: font.HintingFull,
-		})
-		return face, err
 	} else {
-		f, err := truetype.Parse(fontBytes)
-		if err != nil {
-			return nil, err
+		var f *truetype.Font
+		f, err = truetype.Parse(fontBytes)
+		if err == nil {
+			face = truetype.NewFace(f, &truetype.Options{
+				Size:   float64(size),
+				Stroke: strokeWidth,
+				// Hinting: font.HintingFull,
+				// GlyphCacheEntries: 1024, // default is 512 -- todo benchmark
+			})
 		}
-		face := truetype.NewFace(f, &truetype.Options{
-			Size:   float64(size),
-			Stroke: strokeWidth,
-			// Hinting: font.HintingFull,
-			// GlyphCacheEntries: 1024, // default is 512 -- todo benchmark
-		})
-		return face, nil
 	}
+	if cf != nil {
+		// bitmap-only fonts may not parse as outline fonts -- fine
+		return NewColorFace(cf, fontBytes, size, face), nil
+	}
+	return face, err
 }
 
 // see: https://blog.golang.org/go-fonts
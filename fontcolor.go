@@ -0,0 +1,613 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// ColorFont holds the color glyph tables of an OpenType / TrueType font
+// file: CBDT / CBLC (Google / Android) and sbix (Apple) embedded bitmaps,
+// and COLR / CPAL layered color glyphs (Microsoft), along with the cmap,
+// hmtx and header info needed to lay out bitmap glyphs without any outline
+// font.  Only PNG bitmaps are supported, which is what all current color
+// emoji fonts use.  See ColorFace for the font.Face that renders these.
+type ColorFont struct {
+	UnitsPerEm int          `desc:"font design units per em"`
+	Ascent     int          `desc:"ascent from hhea table, in font units"`
+	Descent    int          `desc:"descent from hhea table, in font units -- positive number"`
+	LineGap    int          `desc:"line gap from hhea table, in font units"`
+	NGlyphs    int          `desc:"number of glyphs in the font"`
+	Palette    []color.RGBA `desc:"first CPAL palette, for COLR layers"`
+
+	data      []byte
+	tables    map[string][]byte
+	cmap      []byte
+	hmtx      []byte
+	nHMetrics int
+	cbdt      []byte
+	cbStrikes []cbStrike
+	sbix      []byte
+	sbStrikes []sbStrike
+	colr      []byte
+	colrNBase int
+}
+
+// BitmapGlyph is a color bitmap glyph decoded from a CBDT or sbix table, at
+// the native pixels-per-em of the strike it came from.  All metrics are in
+// pixels at that size, with Y up.
+type BitmapGlyph struct {
+	Img      image.Image `desc:"decoded bitmap image"`
+	PPEM     int         `desc:"pixels per em of the strike"`
+	BearingX int         `desc:"offset from the origin to the left edge of the image"`
+	BearingY int         `desc:"offset from the baseline up to the top edge of the image"`
+	Advance  int         `desc:"horizontal advance, if specified in the bitmap metrics, else 0"`
+}
+
+// ColorLayer is one layer of a COLR color glyph -- layers are drawn in
+// order using the outline of the given glyph, filled with the color.
+type ColorLayer struct {
+	Glyph uint16     `desc:"glyph index providing the outline for this layer"`
+	Color color.RGBA `desc:"fill color for this layer"`
+	Fore  bool       `desc:"use the current text foreground color instead of Color"`
+}
+
+// cbStrike is one bitmap size (strike) from a CBLC table
+type cbStrike struct {
+	ppem  int
+	start uint16
+	end   uint16
+	subs  []cbSub
+}
+
+// cbSub is one index subtable range within a CBLC strike
+type cbSub struct {
+	first uint16
+	last  uint16
+	off   int // offset of the subtable within CBLC
+}
+
+// sbStrike is one bitmap size (strike) from an sbix table
+type sbStrike struct {
+	ppem int
+	off  int // offset of the strike within sbix
+}
+
+// fontData provides bounds-safe big-endian reads of font table data --
+// reads out of range return 0, which results in missing glyphs for
+// malformed fonts rather than panics
+type fontData []byte
+
+func (d fontData) u8(off int) int {
+	if off < 0 || off >= len(d) {
+		return 0
+	}
+	return int(d[off])
+}
+
+func (d fontData) i8(off int) int {
+	return int(int8(d.u8(off)))
+}
+
+func (d fontData) u16(off int) int {
+	if off < 0 || off+2 > len(d) {
+		return 0
+	}
+	return int(d[off])<<8 | int(d[off+1])
+}
+
+func (d fontData) i16(off int) int {
+	return int(int16(d.u16(off)))
+}
+
+func (d fontData) u32(off int) int {
+	if off < 0 || off+4 > len(d) {
+		return 0
+	}
+	return int(uint32(d[off])<<24 | uint32(d[off+1])<<16 | uint32(d[off+2])<<8 | uint32(d[off+3]))
+}
+
+func (d fontData) slice(off, n int) []byte {
+	if off < 0 || n < 0 || off+n > len(d) {
+		return nil
+	}
+	return d[off : off+n]
+}
+
+// ParseColorFont parses the color glyph tables in given font file data.
+// Returns nil, nil if the font does not have any supported color tables.
+// For font collections (.ttc), the first font is used.
+func ParseColorFont(data []byte) (*ColorFont, error) {
+	fd := fontData(data)
+	base := 0
+	if string(fd.slice(0, 4)) == "ttcf" {
+		base = fd.u32(12)
+	}
+	ntab := fd.u16(base + 4)
+	if ntab == 0 {
+		return nil, errors.New("gi.ParseColorFont: invalid font data")
+	}
+	cf := &ColorFont{data: data, tables: make(map[string][]byte, ntab)}
+	for i := 0; i < ntab; i++ {
+		rec := base + 12 + 16*i
+		tag := string(fd.slice(rec, 4))
+		tb := fd.slice(fd.u32(rec+8), fd.u32(rec+12))
+		if tb != nil {
+			cf.tables[tag] = tb
+		}
+	}
+	_, hasCB := cf.tables["CBDT"]
+	_, hasSB := cf.tables["sbix"]
+	_, hasCOLR := cf.tables["COLR"]
+	if !hasCB && !hasSB && !hasCOLR {
+		return nil, nil
+	}
+	head := fontData(cf.tables["head"])
+	hhea := fontData(cf.tables["hhea"])
+	maxp := fontData(cf.tables["maxp"])
+	if head == nil || hhea == nil || maxp == nil {
+		return nil, errors.New("gi.ParseColorFont: missing head, hhea or maxp table")
+	}
+	cf.UnitsPerEm = head.u16(18)
+	if cf.UnitsPerEm == 0 {
+		cf.UnitsPerEm = 2048
+	}
+	cf.Ascent = hhea.i16(4)
+	cf.Descent = -hhea.i16(6)
+	cf.LineGap = hhea.i16(8)
+	cf.nHMetrics = hhea.u16(34)
+	cf.NGlyphs = maxp.u16(4)
+	cf.hmtx = cf.tables["hmtx"]
+	if err := cf.parseCmap(); err != nil {
+		return nil, err
+	}
+	if hasCB {
+		cf.parseCBLC()
+	}
+	if hasSB {
+		cf.parseSbix()
+	}
+	if hasCOLR {
+		cf.parseCOLR()
+	}
+	if len(cf.cbStrikes) == 0 && len(cf.sbStrikes) == 0 && cf.colrNBase == 0 {
+		return nil, nil
+	}
+	return cf, nil
+}
+
+// parseCmap finds the best unicode cmap subtable -- format 12 (full
+// unicode) is preferred over format 4 (BMP only)
+func (cf *ColorFont) parseCmap() error {
+	cm := fontData(cf.tables["cmap"])
+	if cm == nil {
+		return errors.New("gi.ParseColorFont: missing cmap table")
+	}
+	n := cm.u16(2)
+	var bmp []byte
+	for i := 0; i < n; i++ {
+		rec := 4 + 8*i
+		pid, eid := cm.u16(rec), cm.u16(rec+2)
+		if !(pid == 0 || (pid == 3 && (eid == 1 || eid == 10))) {
+			continue
+		}
+		off := cm.u32(rec + 4)
+		if off >= len(cm) {
+			continue
+		}
+		sub := cm[off:]
+		switch sub.u16(0) {
+		case 12:
+			cf.cmap = sub
+			return nil
+		case 4:
+			bmp = sub
+		}
+	}
+	if bmp == nil {
+		return errors.New("gi.ParseColorFont: no unicode cmap subtable")
+	}
+	cf.cmap = bmp
+	return nil
+}
+
+// GlyphIndex returns the glyph index for given rune, 0 if not in the font
+func (cf *ColorFont) GlyphIndex(r rune) uint16 {
+	cm := fontData(cf.cmap)
+	c := int(r)
+	if cm.u16(0) == 12 {
+		ng := cm.u32(12)
+		lo, hi := 0, ng
+		for lo < hi {
+			mid := (lo + hi) / 2
+			gp := 16 + 12*mid
+			switch {
+			case c < cm.u32(gp):
+				hi = mid
+			case c > cm.u32(gp+4):
+				lo = mid + 1
+			default:
+				return uint16(cm.u32(gp+8) + c - cm.u32(gp))
+			}
+		}
+		return 0
+	}
+	if c > 0xFFFF {
+		return 0
+	}
+	segs := cm.u16(6) / 2
+	ends := 14
+	starts := ends + 2*segs + 2
+	deltas := starts + 2*segs
+	ranges := deltas + 2*segs
+	lo, hi := 0, segs
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if c > cm.u16(ends+2*mid) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo >= segs {
+		return 0
+	}
+	start := cm.u16(starts + 2*lo)
+	if c < start {
+		return 0
+	}
+	delta := cm.u16(deltas + 2*lo)
+	roff := cm.u16(ranges + 2*lo)
+	if roff == 0 {
+		return uint16(c + delta)
+	}
+	g := cm.u16(ranges + 2*lo + roff + 2*(c-start))
+	if g == 0 {
+		return 0
+	}
+	return uint16(g + delta)
+}
+
+// Advance returns the horizontal advance of glyph, in font units
+func (cf *ColorFont) Advance(gid uint16) int {
+	hm := fontData(cf.hmtx)
+	if cf.nHMetrics == 0 {
+		return cf.UnitsPerEm
+	}
+	i := int(gid)
+	if i >= cf.nHMetrics {
+		i = cf.nHMetrics - 1
+	}
+	return hm.u16(4 * i)
+}
+
+// HasColorGlyph returns true if the glyph has a color bitmap or color layers
+func (cf *ColorFont) HasColorGlyph(gid uint16) bool {
+	if gid == 0 {
+		return false
+	}
+	if cf.colrBaseRecord(gid) >= 0 {
+		return true
+	}
+	for si := range cf.cbStrikes {
+		if _, _, _, ok := cf.cbGlyph(&cf.cbStrikes[si], gid); ok {
+			return true
+		}
+	}
+	for si := range cf.sbStrikes {
+		if cf.sbGlyph(&cf.sbStrikes[si], gid) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasBitmaps returns true if the font has any bitmap strikes
+func (cf *ColorFont) HasBitmaps() bool {
+	return len(cf.cbStrikes) > 0 || len(cf.sbStrikes) > 0
+}
+
+// bestStrike returns the index of the strike with the smallest ppem that is
+// at least the given ppem (for best quality downscaling), else the largest
+func bestStrike(ppems []int, ppem int) int {
+	best := -1
+	for i, p := range ppems {
+		switch {
+		case best < 0:
+			best = i
+		case p >= ppem && (ppems[best] < ppem || p < ppems[best]):
+			best = i
+		case ppems[best] < ppem && p > ppems[best]:
+			best = i
+		}
+	}
+	return best
+}
+
+// Bitmap returns the color bitmap for given glyph, from the strike best
+// suited to rendering at given pixels per em -- returns nil if the glyph has
+// no bitmap.  The PNG is decoded on each call, so results should be cached.
+func (cf *ColorFont) Bitmap(gid uint16, ppem int) (*BitmapGlyph, error) {
+	if len(cf.cbStrikes) > 0 {
+		ppems := make([]int, len(cf.cbStrikes))
+		for i := range cf.cbStrikes {
+			ppems[i] = cf.cbStrikes[i].ppem
+		}
+		st := &cf.cbStrikes[bestStrike(ppems, ppem)]
+		if off, ifmt, met, ok := cf.cbGlyph(st, gid); ok {
+			return cf.cbBitmap(st, off, ifmt, met)
+		}
+	}
+	if len(cf.sbStrikes) > 0 {
+		ppems := make([]int, len(cf.sbStrikes))
+		for i := range cf.sbStrikes {
+			ppems[i] = cf.sbStrikes[i].ppem
+		}
+		st := &cf.sbStrikes[bestStrike(ppems, ppem)]
+		return cf.sbBitmap(st, gid, 0)
+	}
+	return nil, nil
+}
+
+// decodePNG decodes png data with error context
+func decodePNG(data []byte) (image.Image, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("gi.ColorFont: bad png bitmap: " + err.Error())
+	}
+	return img, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+//  CBDT / CBLC
+
+// parseCBLC reads the strikes and index subtable ranges from CBLC
+func (cf *ColorFont) parseCBLC() {
+	cb := fontData(cf.tables["CBLC"])
+	cf.cbdt = cf.tables["CBDT"]
+	if cb == nil || cf.cbdt == nil {
+		return
+	}
+	nsz := cb.u32(4)
+	for i := 0; i < nsz; i++ {
+		bs := 8 + 48*i
+		arr := cb.u32(bs)
+		nsub := cb.u32(bs + 8)
+		st := cbStrike{ppem: cb.u8(bs + 44), start: uint16(cb.u16(bs + 40)), end: uint16(cb.u16(bs + 42))}
+		for j := 0; j < nsub; j++ {
+			ep := arr + 8*j
+			st.subs = append(st.subs, cbSub{first: uint16(cb.u16(ep)), last: uint16(cb.u16(ep + 2)), off: arr + cb.u32(ep+4)})
+		}
+		if st.ppem > 0 && len(st.subs) > 0 {
+			cf.cbStrikes = append(cf.cbStrikes, st)
+		}
+	}
+}
+
+// cbGlyph returns the CBDT offset, image format, and subtable big metrics
+// offset within CBLC (0 if none) for glyph in strike
+func (cf *ColorFont) cbGlyph(st *cbStrike, gid uint16) (off, ifmt, met int, ok bool) {
+	if gid < st.start || gid > st.end {
+		return
+	}
+	cb := fontData(cf.tables["CBLC"])
+	for _, sb := range st.subs {
+		if gid < sb.first || gid > sb.last {
+			continue
+		}
+		ixfmt := cb.u16(sb.off)
+		ifmt = cb.u16(sb.off + 2)
+		doff := cb.u32(sb.off + 4)
+		idx := int(gid - sb.first)
+		hd := sb.off + 8
+		switch ixfmt {
+		case 1:
+			o0, o1 := cb.u32(hd+4*idx), cb.u32(hd+4*idx+4)
+			if o1 <= o0 {
+				return
+			}
+			return doff + o0, ifmt, 0, true
+		case 2:
+			isz := cb.u32(hd)
+			return doff + isz*idx, ifmt, hd + 4, true
+		case 3:
+			o0, o1 := cb.u16(hd+2*idx), cb.u16(hd+2*idx+2)
+			if o1 <= o0 {
+				return
+			}
+			return doff + o0, ifmt, 0, true
+		case 4:
+			ng := cb.u32(hd)
+			for k := 0; k < ng; k++ {
+				if cb.u16(hd+4+4*k) == int(gid) {
+					return doff + cb.u16(hd+4+4*k+2), ifmt, 0, true
+				}
+			}
+		case 5:
+			isz := cb.u32(hd)
+			ng := cb.u32(hd + 12)
+			for k := 0; k < ng; k++ {
+				if cb.u16(hd+16+2*k) == int(gid) {
+					return doff + isz*k, ifmt, hd + 4, true
+				}
+			}
+		}
+		return
+	}
+	return
+}
+
+// cbBitmap decodes the CBDT bitmap at given offset and image format
+func (cf *ColorFont) cbBitmap(st *cbStrike, off, ifmt, met int) (*BitmapGlyph, error) {
+	cd := fontData(cf.cbdt)
+	bg := &BitmapGlyph{PPEM: st.ppem}
+	var pdata []byte
+	switch ifmt {
+	case 17: // small metrics
+		bg.BearingX = cd.i8(off + 2)
+		bg.BearingY = cd.i8(off + 3)
+		bg.Advance = cd.u8(off + 4)
+		pdata = cd.slice(off+9, cd.u32(off+5))
+	case 18: // big metrics
+		bg.BearingX = cd.i8(off + 2)
+		bg.BearingY = cd.i8(off + 3)
+		bg.Advance = cd.u8(off + 4)
+		pdata = cd.slice(off+12, cd.u32(off+8))
+	case 19: // metrics in CBLC
+		cb := fontData(cf.tables["CBLC"])
+		if met > 0 {
+			bg.BearingX = cb.i8(met + 2)
+			bg.BearingY = cb.i8(met + 3)
+			bg.Advance = cb.u8(met + 4)
+		}
+		pdata = cd.slice(off+4, cd.u32(off))
+	default:
+		return nil, nil // non-png formats not supported
+	}
+	if pdata == nil {
+		return nil, nil
+	}
+	img, err := decodePNG(pdata)
+	if err != nil {
+		return nil, err
+	}
+	bg.Img = img
+	return bg, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+//  sbix
+
+// parseSbix reads the strikes from sbix
+func (cf *ColorFont) parseSbix() {
+	sb := fontData(cf.tables["sbix"])
+	cf.sbix = sb
+	ns := sb.u32(4)
+	for i := 0; i < ns; i++ {
+		so := sb.u32(8 + 4*i)
+		if ppem := sb.u16(so); ppem > 0 {
+			cf.sbStrikes = append(cf.sbStrikes, sbStrike{ppem: ppem, off: so})
+		}
+	}
+}
+
+// sbGlyph returns the sbix glyph data record for glyph in strike, nil if none
+func (cf *ColorFont) sbGlyph(st *sbStrike, gid uint16) []byte {
+	if int(gid) >= cf.NGlyphs {
+		return nil
+	}
+	sb := fontData(cf.sbix)
+	op := st.off + 4 + 4*int(gid)
+	o0, o1 := sb.u32(op), sb.u32(op+4)
+	if o1 <= o0+8 {
+		return nil
+	}
+	return sb.slice(st.off+o0, o1-o0)
+}
+
+// sbBitmap decodes the sbix bitmap for glyph, following 'dupe' references
+func (cf *ColorFont) sbBitmap(st *sbStrike, gid uint16, depth int) (*BitmapGlyph, error) {
+	gd := fontData(cf.sbGlyph(st, gid))
+	if gd == nil {
+		return nil, nil
+	}
+	switch string(gd.slice(4, 4)) {
+	case "png ":
+	case "dupe":
+		if depth > 4 {
+			return nil, nil
+		}
+		return cf.sbBitmap(st, uint16(gd.u16(8)), depth+1)
+	default:
+		return nil, nil // jpg, tiff, pdf not supported
+	}
+	img, err := decodePNG(gd[8:])
+	if err != nil {
+		return nil, err
+	}
+	bg := &BitmapGlyph{Img: img, PPEM: st.ppem}
+	bg.BearingX = gd.i16(0)
+	bg.BearingY = gd.i16(2) + img.Bounds().Dy()
+	bg.Advance = (cf.Advance(gid)*st.ppem + cf.UnitsPerEm/2) / cf.UnitsPerEm
+	return bg, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+//  COLR / CPAL
+
+// parseCOLR reads the COLR version 0 header and the first CPAL palette
+func (cf *ColorFont) parseCOLR() {
+	cl := fontData(cf.tables["COLR"])
+	cf.colr = cl
+	cf.colrNBase = cl.u16(2)
+	cp := fontData(cf.tables["CPAL"])
+	if cp == nil {
+		return
+	}
+	nent := cp.u16(2)
+	crec := cp.u32(8)
+	first := cp.u16(12)
+	cf.Palette = make([]color.RGBA, nent)
+	for i := 0; i < nent; i++ {
+		co := crec + 4*(first+i)
+		cf.Palette[i] = color.RGBA{B: uint8(cp.u8(co)), G: uint8(cp.u8(co + 1)), R: uint8(cp.u8(co + 2)), A: uint8(cp.u8(co + 3))}
+	}
+}
+
+// colrBaseRecord returns the offset of the COLR base glyph record for glyph,
+// or -1 if not found
+func (cf *ColorFont) colrBaseRecord(gid uint16) int {
+	if cf.colrNBase == 0 {
+		return -1
+	}
+	cl := fontData(cf.colr)
+	boff := cl.u32(4)
+	lo, hi := 0, cf.colrNBase
+	for lo < hi {
+		mid := (lo + hi) / 2
+		rec := boff + 6*mid
+		g := cl.u16(rec)
+		switch {
+		case int(gid) < g:
+			hi = mid
+		case int(gid) > g:
+			lo = mid + 1
+		default:
+			return rec
+		}
+	}
+	return -1
+}
+
+// Layers returns the COLR layers for given glyph, nil if not a color glyph.
+// Colors are premultiplied by alpha, ready for use as image sources.
+func (cf *ColorFont) Layers(gid uint16) []ColorLayer {
+	rec := cf.colrBaseRecord(gid)
+	if rec < 0 {
+		return nil
+	}
+	cl := fontData(cf.colr)
+	loff := cl.u32(8)
+	first := cl.u16(rec + 2)
+	n := cl.u16(rec + 4)
+	lays := make([]ColorLayer, n)
+	for i := range lays {
+		lo := loff + 4*(first+i)
+		lays[i].Glyph = uint16(cl.u16(lo))
+		pi := cl.u16(lo + 2)
+		if pi == 0xFFFF || pi >= len(cf.Palette) {
+			lays[i].Fore = true
+			continue
+		}
+		c := cf.Palette[pi]
+		a := uint32(c.A)
+		lays[i].Color = color.RGBA{uint8(uint32(c.R) * a / 255), uint8(uint32(c.G) * a / 255), uint8(uint32(c.B) * a / 255), c.A}
+	}
+	return lays
+}
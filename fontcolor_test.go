@@ -0,0 +1,185 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"sort"
+	"testing"
+)
+
+// fontBuilder builds minimal font files from raw tables, for tests
+type fontBuilder struct {
+	tables map[string][]byte
+}
+
+func (fb *fontBuilder) add(tag string, vals ...interface{}) {
+	var buf bytes.Buffer
+	for _, v := range vals {
+		binary.Write(&buf, binary.BigEndian, v)
+	}
+	if fb.tables == nil {
+		fb.tables = make(map[string][]byte)
+	}
+	fb.tables[tag] = buf.Bytes()
+}
+
+func (fb *fontBuilder) bytes() []byte {
+	tags := make([]string, 0, len(fb.tables))
+	for t := range fb.tables {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	var hdr, body bytes.Buffer
+	binary.Write(&hdr, binary.BigEndian, []uint32{0x00010000})
+	binary.Write(&hdr, binary.BigEndian, []uint16{uint16(len(tags)), 0, 0, 0})
+	off := 12 + 16*len(tags)
+	for _, t := range tags {
+		tb := fb.tables[t]
+		hdr.WriteString(t)
+		binary.Write(&hdr, binary.BigEndian, []uint32{0, uint32(off + body.Len()), uint32(len(tb))})
+		body.Write(tb)
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+	}
+	hdr.Write(body.Bytes())
+	return hdr.Bytes()
+}
+
+func testPNG(w, h int, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// testFontBase adds the header tables for a 4 glyph font mapping
+// U+1F600 -> 1, U+2764 -> 2, 'A' -> 3, using a format 12 cmap if full
+func testFontBase(fb *fontBuilder, full bool) {
+	fb.add("head", make([]byte, 18), uint16(1000), make([]byte, 34))
+	fb.add("hhea", uint32(0x00010000), int16(800), int16(-200), int16(0), make([]byte, 24), uint16(4))
+	fb.add("maxp", uint32(0x00005000), uint16(4))
+	fb.add("hmtx", []uint16{500, 0, 1200, 0, 1100, 0, 600, 0})
+	if full {
+		fb.add("cmap", []uint16{0, 1, 3, 10}, uint32(12),
+			[]uint16{12, 0}, []uint32{52, 0, 3},
+			[]uint32{'A', 'A', 3, 0x2764, 0x2764, 2, 0x1F600, 0x1F600, 1})
+		return
+	}
+	// format 4: segments 'A', U+2764, 0xFFFF
+	fb.add("cmap", []uint16{0, 1, 3, 1}, uint32(12),
+		[]uint16{4, 40, 0, 6, 0, 0, 0},
+		[]uint16{'A', 0x2764, 0xFFFF}, uint16(0),
+		[]uint16{'A', 0x2764, 0xFFFF},
+		[]int16{3 - 'A', 0, 1},
+		[]uint16{0, 4, 0},
+		[]uint16{2})
+}
+
+func TestColorFontCmap(t *testing.T) {
+	for _, full := range []bool{false, true} {
+		fb := &fontBuilder{}
+		testFontBase(fb, full)
+		fb.add("COLR", []uint16{0, 1}, []uint32{14, 20}, uint16(1), []uint16{2, 0, 1}, []uint16{3, 0})
+		fb.add("CPAL", []uint16{0, 1, 1, 1}, uint32(14), uint16(0), []uint8{0, 0, 255, 255})
+		cf, err := ParseColorFont(fb.bytes())
+		if err != nil || cf == nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+		if g := cf.GlyphIndex('A'); g != 3 {
+			t.Errorf("full: %v  'A' glyph: %v != 3", full, g)
+		}
+		if g := cf.GlyphIndex(0x2764); g != 2 {
+			t.Errorf("full: %v  U+2764 glyph: %v != 2", full, g)
+		}
+		if g := cf.GlyphIndex('B'); g != 0 {
+			t.Errorf("full: %v  'B' glyph: %v != 0", full, g)
+		}
+		want := uint16(0) // not in BMP cmap
+		if full {
+			want = 1
+		}
+		if g := cf.GlyphIndex(0x1F600); g != want {
+			t.Errorf("full: %v  U+1F600 glyph: %v != %v", full, g, want)
+		}
+		lays := cf.Layers(2)
+		if len(lays) != 1 || lays[0].Glyph != 3 || lays[0].Color != (color.RGBA{255, 0, 0, 255}) {
+			t.Errorf("COLR layers: %v", lays)
+		}
+		if !cf.HasColorGlyph(2) || cf.HasColorGlyph(3) {
+			t.Errorf("HasColorGlyph wrong")
+		}
+		if cf.Advance(2) != 1100 {
+			t.Errorf("advance: %v != 1100", cf.Advance(2))
+		}
+	}
+}
+
+func TestColorFontBitmaps(t *testing.T) {
+	pd := testPNG(4, 3, color.RGBA{0, 255, 0, 255})
+	// CBDT format 17 for glyph 1 in one strike at 16 ppem, index format 1
+	fb := &fontBuilder{}
+	testFontBase(fb, true)
+	fb.add("CBDT", uint32(0x00030000), []uint8{3, 4, 1, 10, 18}, uint32(len(pd)), pd)
+	fb.add("CBLC", uint32(0x00030000), uint32(1),
+		[]uint32{56, 0, 1, 0}, make([]byte, 24), []uint16{1, 1}, []uint8{16, 16, 32, 1},
+		[]uint16{1, 1}, uint32(8),
+		[]uint16{1, 17}, uint32(4), []uint32{0, uint32(9 + len(pd))})
+	cf, err := ParseColorFont(fb.bytes())
+	if err != nil || cf == nil {
+		t.Fatalf("CBDT parse failed: %v", err)
+	}
+	bg, err := cf.Bitmap(1, 20)
+	if err != nil || bg == nil {
+		t.Fatalf("CBDT bitmap failed: %v", err)
+	}
+	if bg.PPEM != 16 || bg.BearingX != 1 || bg.BearingY != 10 || bg.Advance != 18 || bg.Img.Bounds().Dx() != 4 {
+		t.Errorf("CBDT bitmap: %+v", bg)
+	}
+	if bg, _ := cf.Bitmap(2, 20); bg != nil {
+		t.Errorf("CBDT bitmap for glyph without one")
+	}
+
+	// sbix with two strikes, glyph 2 is a dupe of glyph 1
+	gd := append([]byte{0, 2, 0xff, 0xfe, 'p', 'n', 'g', ' '}, pd...)
+	dupe := []byte{0, 0, 0, 0, 'd', 'u', 'p', 'e', 0, 1}
+	strike := func(ppem uint16) []byte {
+		var buf bytes.Buffer
+		hd := uint32(4 + 4*5)
+		binary.Write(&buf, binary.BigEndian, []uint16{ppem, 72})
+		binary.Write(&buf, binary.BigEndian, []uint32{hd, hd, hd + uint32(len(gd)), hd + uint32(len(gd)+len(dupe)), hd + uint32(len(gd)+len(dupe))})
+		buf.Write(gd)
+		buf.Write(dupe)
+		return buf.Bytes()
+	}
+	s1, s2 := strike(20), strike(64)
+	fb = &fontBuilder{}
+	testFontBase(fb, true)
+	fb.add("sbix", []uint16{1, 1}, uint32(2), []uint32{16, uint32(16 + len(s1))}, s1, s2)
+	cf, err = ParseColorFont(fb.bytes())
+	if err != nil || cf == nil {
+		t.Fatalf("sbix parse failed: %v", err)
+	}
+	bg, err = cf.Bitmap(2, 32)
+	if err != nil || bg == nil {
+		t.Fatalf("sbix bitmap failed: %v", err)
+	}
+	if bg.PPEM != 64 || bg.BearingX != 2 || bg.BearingY != 1 || bg.Img.Bounds().Dy() != 3 {
+		t.Errorf("sbix bitmap: %+v", bg)
+	}
+	if bg, _ = cf.Bitmap(1, 12); bg == nil || bg.PPEM != 20 {
+		t.Errorf("sbix strike selection: %+v", bg)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font"
+)
+
+// FontFallbackChain is the list of font families tried, in order, for runes
+// that are missing from the selected font, after Prefs.FontFamily and the
+// FontAlts candidates for the selected family, and before scanning all of
+// the fonts in the library.  These are the common wide-coverage fonts on
+// each platform -- any that are not installed are skipped.  Apps can
+// prepend their own fonts (e.g., a specific CJK font) to take precedence.
+var FontFallbackChain = []string{
+	"NotoSans",
+	"Noto Sans CJK SC",
+	"Noto Sans CJK JP",
+	"Noto Sans Symbols",
+	"Noto Sans Symbols2",
+	"Noto Sans Math",
+	"Noto Color Emoji",
+	"NotoColorEmoji",
+	"Apple Color Emoji",
+	"Segoe UI Emoji",
+	"Noto Emoji",
+	"DejaVu Sans",
+	"Arial Unicode",
+	"Segoe UI Symbol",
+	"Microsoft YaHei",
+	"MS Gothic",
+	"PingFang SC",
+	"Hiragino Sans",
+	"Apple Symbols",
+	"Symbola",
+	"Unifont",
+}
+
+// FontFallbackScan determines whether all the fonts in the library are
+// scanned as a last resort when no font on the fallback chain has a glyph
+// for a rune.  This opens every regular font in the library the first time
+// a given range of runes is missing, which can be slow with many fonts
+// installed.
+var FontFallbackScan = true
+
+// FontFallbackBlock is the number of low-order bits of a rune that are
+// ignored in caching fallback faces -- all runes within a block of
+// 1<<FontFallbackBlock codepoints are first tried in the face that was
+// found for the first missing rune in that block, which is typically the
+// same script.
+const FontFallbackBlock = 7
+
+// fontFallbackKey is the key for cached fallback faces: the primary face
+// and the rune block
+type fontFallbackKey struct {
+	face  font.Face
+	block rune
+}
+
+// fontFallbacks caches the fallback face found for each face and rune block
+// -- it is tried first for the other runes in the block.  Protected by
+// TextFontRenderMu.
+var fontFallbacks = map[fontFallbackKey]font.Face{}
+
+// fontFallbackMissKey is the key for runes that no face has a glyph for:
+// the primary face and the rune
+type fontFallbackMissKey struct {
+	face font.Face
+	r    rune
+}
+
+// fontFallbackMisses records the runes that no face has a glyph for, so
+// they are not searched for again -- these are recorded per rune, as other
+// runes in the same block may well be covered.  Protected by
+// TextFontRenderMu.
+var fontFallbackMisses = map[fontFallbackMissKey]bool{}
+
+// FallbackFace returns the face to use for rendering rune r, given the
+// primary face selected for font family fam (comma-separated list, can be
+// empty) at given integer dots size.  If the primary face has a glyph for
+// the rune, it is returned.  Otherwise, faces are tried in this order:
+// Prefs.FontFamily, the FontAlts for fam, FontFallbackChain, and then all
+// regular fonts in the library if FontFallbackScan is set.  Faces found
+// are cached by codepoint block, and runes that no face has are cached
+// individually.  If no face has the glyph, the primary face is returned
+// (rendering the missing glyph box).  Must be called with TextFontRenderMu
+// locked.
+func (fl *FontLib) FallbackFace(face font.Face, r rune, fam string, size int) font.Face {
+	if face == nil || FaceHasGlyph(face, r) {
+		return face
+	}
+	key := fontFallbackKey{face, r >> FontFallbackBlock}
+	if fb := fontFallbacks[key]; fb != nil && FaceHasGlyph(fb, r) {
+		return fb
+	}
+	mkey := fontFallbackMissKey{face, r}
+	if fontFallbackMisses[mkey] {
+		return face
+	}
+	fb := fl.findFallback(face, r, fam, size)
+	if fb == nil {
+		fontFallbackMisses[mkey] = true
+		return face
+	}
+	fontFallbacks[key] = fb
+	return fb
+}
+
+// findFallback searches the fallback chain for a face with a glyph for
+// rune r -- returns nil if none found
+func (fl *FontLib) findFallback(face font.Face, r rune, fam string, size int) font.Face {
+	tried := make(map[string]bool)
+	try := func(fn string) font.Face {
+		fn = strings.ToLower(strings.TrimSpace(fn))
+		if fn == "" || tried[fn] {
+			return nil
+		}
+		tried[fn] = true
+		if !fl.FontAvail(fn) {
+			return nil
+		}
+		fc, err := fl.Font(fn, size)
+		if err != nil || fc == face {
+			return nil
+		}
+		if FaceHasGlyph(fc, r) {
+			return fc
+		}
+		return nil
+	}
+	if fc := try(string(Prefs.FontFamily)); fc != nil {
+		return fc
+	}
+	if fam == "" {
+		fam = string(Prefs.FontFamily)
+	}
+	alts, _, _ := FontAlts(fam)
+	for _, fn := range alts {
+		if fc := try(fn); fc != nil {
+			return fc
+		}
+	}
+	for _, fn := range FontFallbackChain {
+		if fc := try(fn); fc != nil {
+			return fc
+		}
+	}
+	if !FontFallbackScan {
+		return nil
+	}
+	fis := append([]FontInfo(nil), fl.FontInfo...) // fonts that fail to open are deleted
+	for _, fi := range fis {
+		if fi.Stretch != FontStrNormal || fi.Weight != WeightNormal || fi.Style != FontNormal {
+			continue
+		}
+		if fc := try(fi.Name); fc != nil {
+			return fc
+		}
+	}
+	return nil
+}
+
+// SetFallbackFaces sets the face for runes starting at index st that are
+// missing from the given primary face to a fallback face from
+// FontLibrary.FallbackFace, and restores the primary face after each run
+// of fallback runes.  The primary face must already be in effect at st.
+func (sr *SpanRender) SetFallbackFaces(st int, face font.Face, sty *FontStyle) {
+	sz := len(sr.Text)
+	if face == nil || st >= sz {
+		return
+	}
+	size := int(math.Round(float64(sty.Size.Dots)))
+	if size == 0 {
+		size = 12
+	}
+	TextFontRenderMu.Lock()
+	defer TextFontRenderMu.Unlock()
+	cur := face
+	for i := st; i < sz; i++ {
+		r := sr.Text[i]
+		fc := face
+		switch {
+		case unicode.IsSpace(r) || IsZeroWidth(r):
+		case IsCombiningMark(r) && FaceHasGlyph(cur, r): // keep with base
+			fc = cur
+		default:
+			fc = FontLibrary.FallbackFace(face, r, sty.Family, size)
+		}
+		if fc != cur {
+			sr.Render[i].Face = fc
+			cur = fc
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// testGlyphFace is a font.Face that has glyphs only for the given runes --
+// all others get the missing glyph, as real faces do -- runes in zero have
+// no advance, as combining marks do in many fonts
+type testGlyphFace struct {
+	runes map[rune]bool
+	zero  map[rune]bool
+}
+
+func newTestGlyphFace(rs ...rune) *testGlyphFace {
+	fc := &testGlyphFace{runes: make(map[rune]bool)}
+	for _, r := range rs {
+		fc.runes[r] = true
+	}
+	return fc
+}
+
+func (fc *testGlyphFace) Close() error { return nil }
+
+func (fc *testGlyphFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	_, adv, _ := fc.GlyphBounds(r)
+	return image.Rectangle{}, nil, image.Point{}, adv, true
+}
+
+func (fc *testGlyphFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	if fc.zero[r] {
+		return fixed.R(-6, -8, 0, 0), 0, true
+	}
+	if fc.runes[r] {
+		return fixed.R(0, -8, 6, 0), fixed.I(7), true
+	}
+	return fixed.R(0, -10, 8, 0), fixed.I(9), true // missing glyph box
+}
+
+func (fc *testGlyphFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	_, adv, ok := fc.GlyphBounds(r)
+	return adv, ok
+}
+
+func (fc *testGlyphFace) Kern(r0, r1 rune) fixed.Int26_6 { return 0 }
+
+func (fc *testGlyphFace) Metrics() font.Metrics { return font.Metrics{} }
+
+func TestFallbackFace(t *testing.T) {
+	prim := newTestGlyphFace('a', 'b')
+	fall := newTestGlyphFace('β', 'γ') // same block as α, which no face has
+
+	svLib, svChain, svScan, svFam := FontLibrary, FontFallbackChain, FontFallbackScan, Prefs.FontFamily
+	defer func() {
+		FontLibrary, FontFallbackChain, FontFallbackScan, Prefs.FontFamily = svLib, svChain, svScan, svFam
+	}()
+	FontLibrary = FontLib{
+		FontPaths:  []string{"testdata"},
+		FontsAvail: map[string]string{"testfallback": "testfallback.ttf"},
+		Faces:      map[string]map[int]font.Face{"testfallback": {12: fall}},
+	}
+	FontFallbackChain = []string{"NoSuchFont", "TestFallback"}
+	FontFallbackScan = false
+	Prefs.FontFamily = "NoSuchFont"
+
+	TextFontRenderMu.Lock()
+	defer TextFontRenderMu.Unlock()
+	tests := []struct {
+		r    rune
+		face font.Face
+	}{
+		{'a', prim},
+		{'α', prim}, // no face has it
+		{'β', fall}, // not hidden by the miss for α in the same block
+		{'α', prim},
+		{'γ', fall},
+		{'b', prim},
+	}
+	for _, ts := range tests {
+		if fc := FontLibrary.FallbackFace(prim, ts.r, "", 12); fc != ts.face {
+			t.Errorf("rune %q: got wrong face: %v", ts.r, fc)
+		}
+	}
+	if !fontFallbackMisses[fontFallbackMissKey{prim, 'α'}] {
+		t.Errorf("expected miss for α to be cached")
+	}
+	if fontFallbacks[fontFallbackKey{prim, 'β' >> FontFallbackBlock}] != fall {
+		t.Errorf("expected fallback face to be cached for the block")
+	}
+}
+
+func TestFallbackFaceScan(t *testing.T) {
+	prim := newTestGlyphFace('a')
+	fall := newTestGlyphFace('β')
+	other := newTestGlyphFace('x')
+
+	svLib, svChain, svScan, svFam := FontLibrary, FontFallbackChain, FontFallbackScan, Prefs.FontFamily
+	defer func() {
+		FontLibrary, FontFallbackChain, FontFallbackScan, Prefs.FontFamily = svLib, svChain, svScan, svFam
+	}()
+	FontLibrary = FontLib{
+		FontPaths:  []string{"testdata"},
+		FontsAvail: map[string]string{"testbad": "nosuchfont.ttf", "testfallback": "testfallback.ttf", "testother": "testother.ttf"},
+		Faces:      map[string]map[int]font.Face{"testfallback": {12: fall}, "testother": {12: other}},
+	}
+	for _, nm := range []string{"TestBad", "TestFallback", "TestOther"} {
+		FontLibrary.FontInfo = append(FontLibrary.FontInfo, FontInfo{Name: nm, Stretch: FontStrNormal, Weight: WeightNormal, Style: FontNormal})
+	}
+	FontFallbackChain = nil
+	FontFallbackScan = true
+	Prefs.FontFamily = "NoSuchFont"
+
+	TextFontRenderMu.Lock()
+	defer TextFontRenderMu.Unlock()
+	// the bad font is deleted when it fails to open, during the scan
+	if fc := FontLibrary.FallbackFace(prim, 'β', "", 12); fc != fall {
+		t.Errorf("scan did not find the fallback face after a font failed to open: %v", fc)
+	}
+	if FontLibrary.FontAvail("testbad") || len(FontLibrary.FontInfo) != 2 {
+		t.Errorf("font that failed to open was not deleted: %v", FontLibrary.FontInfo)
+	}
+}
@@ -6,10 +6,20 @@
 
 package gimain
 
-import "github.com/goki/gi"
+import (
+	"os"
+	"strings"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/atspi"
+)
 
 func init() {
 	gi.DefaultKeyMap = gi.KeyMapName("LinuxStd")
 	gi.SetActiveKeyMapName(gi.DefaultKeyMap)
 	gi.Prefs.FontFamily = "Liberation Sans"
+	switch strings.ToLower(os.Getenv("GOGI_ACCESSIBILITY")) {
+	case "1", "true", "on", "yes":
+		gi.RegisterAccessBridge(atspi.NewBridge())
+	}
 }
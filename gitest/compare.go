@@ -0,0 +1,135 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Tolerance specifies how different two images can be while still being
+// considered the same -- anti-aliasing and font hinting differences
+// typically produce small color differences along edges, which should not
+// count as regressions.
+type Tolerance struct {
+	PixelDelta float32 `desc:"maximum perceptual color difference (0-1, see ColorDelta) for two pixels to be considered the same -- 0.1 ignores most anti-aliasing differences"`
+	MaxDiffPct float32 `desc:"maximum percent (0-100) of pixels that can differ by more than PixelDelta for the images to be considered the same"`
+}
+
+// DefaultTol is the default tolerance, which ignores minor anti-aliasing
+// differences but catches any visible change in styling or layout.
+var DefaultTol = Tolerance{PixelDelta: 0.1, MaxDiffPct: 0.1}
+
+// ExactTol requires that images be identical.
+var ExactTol = Tolerance{}
+
+// CompareResult records the results of comparing two images.
+type CompareResult struct {
+	SizeDiff bool        `desc:"images are different sizes -- nothing else is compared"`
+	GotSize  image.Point `desc:"size of the image being tested"`
+	WantSize image.Point `desc:"size of the reference (golden) image"`
+	NPixels  int         `desc:"number of pixels compared"`
+	NDiff    int         `desc:"number of pixels that differ by more than the tolerance PixelDelta"`
+	MaxDelta float32     `desc:"maximum perceptual difference found across all pixels"`
+	Pass     bool        `desc:"images are within tolerance"`
+}
+
+// DiffPct returns the percent of pixels that differ
+func (cr *CompareResult) DiffPct() float32 {
+	if cr.NPixels == 0 {
+		return 0
+	}
+	return 100 * float32(cr.NDiff) / float32(cr.NPixels)
+}
+
+// String returns a summary of the comparison
+func (cr *CompareResult) String() string {
+	if cr.SizeDiff {
+		return fmt.Sprintf("image size: %v != golden size: %v", cr.GotSize, cr.WantSize)
+	}
+	return fmt.Sprintf("%v of %v pixels differ (%.3g%%), max delta: %.3g", cr.NDiff, cr.NPixels, cr.DiffPct(), cr.MaxDelta)
+}
+
+// CompareImages compares got against want using given tolerance, and
+// returns the result, along with a diff image (nil if the images are
+// different sizes) that shows want faded to light gray with the differing
+// pixels in red.
+func CompareImages(got, want image.Image, tol Tolerance) (*CompareResult, *image.RGBA) {
+	gb := got.Bounds()
+	wb := want.Bounds()
+	cr := &CompareResult{GotSize: gb.Size(), WantSize: wb.Size()}
+	if cr.GotSize != cr.WantSize {
+		cr.SizeDiff = true
+		return cr, nil
+	}
+	sz := cr.GotSize
+	diff := image.NewRGBA(image.Rectangle{Max: sz})
+	cr.NPixels = sz.X * sz.Y
+	for y := 0; y < sz.Y; y++ {
+		for x := 0; x < sz.X; x++ {
+			gc := got.At(gb.Min.X+x, gb.Min.Y+y)
+			wc := want.At(wb.Min.X+x, wb.Min.Y+y)
+			d := ColorDelta(gc, wc)
+			if d > cr.MaxDelta {
+				cr.MaxDelta = d
+			}
+			if d > tol.PixelDelta {
+				cr.NDiff++
+				diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				l := uint8(255 - (255-luma(wc))/8)
+				diff.SetRGBA(x, y, color.RGBA{l, l, l, 255})
+			}
+		}
+	}
+	cr.Pass = cr.DiffPct() <= tol.MaxDiffPct
+	return cr, diff
+}
+
+// ColorDelta returns the perceptual difference between two colors, from 0
+// (same) to 1 (black vs. white, or more), computed in the YIQ color space
+// after blending any transparency against white, which weights differences
+// in brightness more than hue, as the eye does -- see Kotsarenko & Ramos
+// (2010), "Measuring perceived color difference using YIQ NTSC transmission
+// color space in mobile applications".
+func ColorDelta(a, b color.Color) float32 {
+	ar, ag, ab := blendWhite(a)
+	br, bg, bb := blendWhite(b)
+	ay, ai, aq := yiq(ar, ag, ab)
+	by, bi, bq := yiq(br, bg, bb)
+	dy := ay - by
+	di := ai - bi
+	dq := aq - bq
+	const bwDelta = 0.5053 * 255 * 255 // delta for black vs. white
+	d := (0.5053*dy*dy + 0.299*di*di + 0.1957*dq*dq) / bwDelta
+	if d > 1 {
+		d = 1
+	}
+	return d
+}
+
+// blendWhite returns the 0-255 color components of c blended over white
+func blendWhite(c color.Color) (r, g, b float32) {
+	cr, cg, cb, ca := c.RGBA() // premultiplied 0-0xffff
+	wt := float32(0xffff-ca) / 0xffff
+	r = (float32(cr)/0xffff + wt) * 255
+	g = (float32(cg)/0xffff + wt) * 255
+	b = (float32(cb)/0xffff + wt) * 255
+	return
+}
+
+func yiq(r, g, b float32) (y, i, q float32) {
+	y = 0.29889531*r + 0.58662247*g + 0.11448223*b
+	i = 0.59597799*r - 0.27417610*g - 0.32180189*b
+	q = 0.21147017*r - 0.52261711*g + 0.31114694*b
+	return
+}
+
+func luma(c color.Color) float32 {
+	r, g, b := blendWhite(c)
+	y, _, _ := yiq(r, g, b)
+	return y
+}
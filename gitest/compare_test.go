@@ -0,0 +1,54 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestColorDelta(t *testing.T) {
+	blk := color.RGBA{0, 0, 0, 255}
+	wht := color.RGBA{255, 255, 255, 255}
+	if d := ColorDelta(blk, wht); d < 0.99 || d > 1.01 {
+		t.Errorf("black vs. white delta: %v != 1", d)
+	}
+	if d := ColorDelta(blk, blk); d != 0 {
+		t.Errorf("black vs. black delta: %v != 0", d)
+	}
+	if d := ColorDelta(color.RGBA{}, wht); d > 0.0001 {
+		t.Errorf("transparent vs. white delta: %v != 0", d)
+	}
+	if d := ColorDelta(color.RGBA{100, 100, 100, 255}, color.RGBA{102, 100, 100, 255}); d > DefaultTol.PixelDelta {
+		t.Errorf("small difference delta: %v > %v", d, DefaultTol.PixelDelta)
+	}
+}
+
+func TestCompareImages(t *testing.T) {
+	sz := image.Rectangle{Max: image.Point{10, 10}}
+	a := image.NewRGBA(sz)
+	b := image.NewRGBA(sz)
+	cr, diff := CompareImages(a, b, ExactTol)
+	if !cr.Pass || cr.NDiff != 0 || cr.NPixels != 100 {
+		t.Errorf("identical images: %v", cr)
+	}
+	b.SetRGBA(3, 4, color.RGBA{255, 0, 0, 255})
+	cr, diff = CompareImages(a, b, ExactTol)
+	if cr.Pass || cr.NDiff != 1 {
+		t.Errorf("one pixel diff: %v", cr)
+	}
+	if c := diff.RGBAAt(3, 4); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("diff pixel color: %v", c)
+	}
+	cr, _ = CompareImages(a, b, Tolerance{MaxDiffPct: 1})
+	if !cr.Pass {
+		t.Errorf("one pixel diff within 1%%: %v", cr)
+	}
+	cr, diff = CompareImages(a, image.NewRGBA(image.Rectangle{Max: image.Point{5, 5}}), DefaultTol)
+	if cr.Pass || !cr.SizeDiff || diff != nil {
+		t.Errorf("size diff: %v", cr)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gitest provides support for visual regression testing of GoGi
+// widgets: any Node2D (a Viewport2D, a single Button, a giv.TableView, an
+// svg.SVG) can be rendered to an image without a live window, and compared
+// against a stored "golden" PNG image using a perceptual tolerance, with a
+// diff image written on failure.
+//
+// Rendering uses the offscreen oswin driver, standard default Prefs (the
+// user's saved preferences are never loaded), and no Window, so all units are
+// converted at the fixed standard DPI of units.PxPerInch -- results are thus
+// the same on all machines, given the same fonts.
+//
+// Typical use in a test:
+//
+//	func TestButton(t *testing.T) {
+//		bt := &gi.Button{}
+//		bt.InitName(bt, "button")
+//		bt.SetText("Press Me")
+//		gitest.AssertRender(t, "button", bt, image.Point{200, 100}, gitest.DefaultTol)
+//	}
+//
+// Run tests with -gitest.update to (re)write the golden images from the
+// current renders, after verifying that they are correct -- a missing golden
+// image fails the test otherwise.
+package gitest
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+)
+
+// DefaultSize is the size of the viewport used for rendering a node that is
+// not itself a viewport, if no size is specified -- the resulting image is
+// cropped to the node's own bounding box.
+var DefaultSize = image.Point{1024, 768}
+
+// Init initializes the gi system for rendering without a window: starts
+// the offscreen driver if no oswin.TheApp driver is running, and sets the
+// default preferences -- called automatically by RenderNode.
+func Init() {
+	if oswin.TheApp == nil {
+		offscreen.Main(func(app oswin.App) {})
+	}
+	if gi.Prefs.LogicalDPIScale == 0 {
+		gi.Prefs.Defaults()
+		gi.Prefs.Apply()
+	}
+}
+
+// RenderNode renders the given node and returns the resulting image.
+//
+// If the node is a Viewport2D (including svg.SVG) without a parent, it is
+// resized to given size (if non-zero) and rendered in its entirety.
+//
+// If the node has no parent, it is added to a new Viewport2D of the given size
+// (DefaultSize if zero), within a vertical Layout, and the returned image is
+// cropped to the node's bounding box -- the node remains in that viewport
+// afterward, and can be re-rendered by calling RenderNode again.
+//
+// Otherwise (the node is already within a tree), the viewport at the root of
+// its tree is fully rendered and the image is cropped to the node.
+func RenderNode(nii gi.Node2D, size image.Point) (*image.RGBA, error) {
+	Init()
+	ni := nii.AsNode2D()
+	if ni == nil || ni.This == nil {
+		return nil, fmt.Errorf("gitest.RenderNode: node is not initialized -- call InitName first")
+	}
+	if vp := nii.AsViewport2D(); vp != nil && ni.Par == nil {
+		if size != image.ZP {
+			vp.Resize(size)
+		}
+		vp.FullRender2DTree()
+		return copyImage(vp.Pixels, vp.Pixels.Bounds()), nil
+	}
+	var rvp *gi.Viewport2D
+	if ni.Par == nil {
+		if size == image.ZP {
+			size = DefaultSize
+		}
+		rvp = gi.NewViewport2D(size.X, size.Y)
+		if rvp == nil {
+			return nil, fmt.Errorf("gitest.RenderNode: could not create viewport of size: %v", size)
+		}
+		rvp.InitName(rvp, "gitest-vp")
+		rvp.Fill = true
+		rvp.SetProp("color", &gi.Prefs.Colors.Font)
+		lay := rvp.AddNewChild(gi.KiT_Layout, "gitest-lay").(*gi.Layout)
+		lay.Lay = gi.LayoutVert
+		lay.AddChild(nii)
+	} else {
+		rt := ni.Par
+		for rt.Parent() != nil {
+			rt = rt.Parent()
+		}
+		rnii, _ := gi.KiToNode2D(rt)
+		if rnii == nil || rnii.AsViewport2D() == nil {
+			return nil, fmt.Errorf("gitest.RenderNode: root of tree for node %v is not a Viewport2D", ni.PathUnique())
+		}
+		rvp = rnii.AsViewport2D()
+	}
+	rvp.FullRender2DTree()
+	if ni.Viewport == nil || ni.Viewport.Pixels == nil {
+		return nil, fmt.Errorf("gitest.RenderNode: node %v was not rendered into a viewport", ni.PathUnique())
+	}
+	if ni.VpBBox.Empty() {
+		return nil, fmt.Errorf("gitest.RenderNode: node %v has empty bounding box after render", ni.PathUnique())
+	}
+	return copyImage(ni.Viewport.Pixels, ni.VpBBox), nil
+}
+
+// copyImage returns a copy of given region of image, with bounds starting at
+// 0,0
+func copyImage(src *image.RGBA, r image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rectangle{Max: r.Size()})
+	draw.Draw(img, img.Bounds(), src, r.Min, draw.Src)
+	return img
+}
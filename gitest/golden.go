@@ -0,0 +1,106 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goki/gi"
+)
+
+// Update causes golden images to be (re)written from the current renders,
+// instead of being compared -- set by the -gitest.update test flag (which
+// is namespaced so as not to conflict with any -update flag of the tests).
+var Update = flag.Bool("gitest.update", false, "gitest: update golden image files instead of comparing against them")
+
+// GoldenDir is the directory where golden images are stored, relative to
+// the package directory that the test is run in.
+var GoldenDir = "testdata"
+
+// FailDir is the directory (relative to GoldenDir) where the rendered image
+// (name.png) and diff image (name.diff.png) are written for failed
+// comparisons.
+var FailDir = "failed"
+
+// GoldenPath returns the path to the golden image file for given name
+func GoldenPath(name string) string {
+	return filepath.Join(GoldenDir, name+".png")
+}
+
+// CheckGolden compares given image against the golden image file for given
+// name, returning the comparison result.  If the comparison fails, the
+// image and a diff image are saved in FailDir.  If Update is set, the image
+// is saved as the golden image and a nil result is returned -- otherwise a
+// missing golden image is an error.
+func CheckGolden(name string, img image.Image, tol Tolerance) (*CompareResult, error) {
+	gpath := GoldenPath(name)
+	if *Update {
+		if err := os.MkdirAll(filepath.Dir(gpath), 0755); err != nil {
+			return nil, err
+		}
+		return nil, gi.SavePNG(gpath, img)
+	}
+	want, err := gi.OpenPNG(gpath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("missing golden image: %v -- run the test with -gitest.update to create it", gpath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cr, diff := CompareImages(img, want, tol)
+	if cr.Pass {
+		return cr, nil
+	}
+	fdir := filepath.Join(GoldenDir, FailDir)
+	fpath := filepath.Join(fdir, name+".png")
+	if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+		return cr, err
+	}
+	if err := gi.SavePNG(fpath, img); err != nil {
+		return cr, err
+	}
+	if diff != nil {
+		if err := gi.SavePNG(filepath.Join(fdir, name+".diff.png"), diff); err != nil {
+			return cr, err
+		}
+	}
+	return cr, nil
+}
+
+// AssertGolden compares given image against the golden image for given name
+// (see CheckGolden), reporting a test error if it fails, or if the golden
+// image is missing.  In Update mode, the written golden image is logged.
+func AssertGolden(t testing.TB, name string, img image.Image, tol Tolerance) {
+	t.Helper()
+	cr, err := CheckGolden(name, img, tol)
+	if err != nil {
+		t.Errorf("gitest: golden image %v: %v", name, err)
+		return
+	}
+	if cr == nil {
+		t.Logf("gitest: wrote golden image: %v", GoldenPath(name))
+		return
+	}
+	if !cr.Pass {
+		t.Errorf("gitest: render of %v does not match golden image: %v -- see %v", name, cr, filepath.Join(GoldenDir, FailDir))
+	}
+}
+
+// AssertRender renders given node (see RenderNode) and compares it against
+// the golden image for given name (see AssertGolden).
+func AssertRender(t testing.TB, name string, nii gi.Node2D, size image.Point, tol Tolerance) {
+	t.Helper()
+	img, err := RenderNode(nii, size)
+	if err != nil {
+		t.Errorf("gitest: %v", err)
+		return
+	}
+	AssertGolden(t, name, img, tol)
+}
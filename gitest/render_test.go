@@ -0,0 +1,101 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/units"
+)
+
+// testFrame returns a frame of given size filled with given color
+func testFrame(sz int, clr string) *gi.Frame {
+	fr := &gi.Frame{}
+	fr.InitName(fr, "frame")
+	fr.SetProp("background-color", clr)
+	fr.SetProp("border-width", units.NewValue(0, units.Px))
+	fr.SetProp("border-radius", units.NewValue(0, units.Px))
+	fr.SetProp("margin", units.NewValue(0, units.Px))
+	fr.SetProp("padding", units.NewValue(0, units.Px))
+	fr.SetProp("width", units.NewValue(float32(sz), units.Px))
+	fr.SetProp("height", units.NewValue(float32(sz), units.Px))
+	return fr
+}
+
+func TestRenderNode(t *testing.T) {
+	fr := testFrame(40, "#f00")
+	img, err := RenderNode(fr, image.Point{200, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sz := img.Bounds().Size(); sz != (image.Point{40, 40}) {
+		t.Errorf("image size: %v != 40x40", sz)
+	}
+	if c := img.RGBAAt(20, 20); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("center pixel: %v != red", c)
+	}
+
+	// re-rendering after a change uses the same viewport
+	fr.SetProp("background-color", "#00f")
+	img, err = RenderNode(fr, image.Point{200, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.RGBAAt(20, 20); c != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("center pixel after change: %v != blue", c)
+	}
+
+	nf := &gi.Frame{}
+	if _, err := RenderNode(nf, image.ZP); err == nil {
+		t.Error("expected error rendering uninitialized node")
+	}
+}
+
+func TestCheckGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	gdir := GoldenDir
+	GoldenDir = dir
+	defer func() { GoldenDir = gdir }()
+
+	img, err := RenderNode(testFrame(20, "#0f0"), image.Point{100, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CheckGolden("frame", img, DefaultTol); err == nil {
+		t.Error("expected error for missing golden image")
+	}
+
+	*Update = true
+	cr, err := CheckGolden("frame", img, DefaultTol)
+	*Update = false
+	if err != nil || cr != nil {
+		t.Fatalf("update: expected nil result and error, got: %v %v", cr, err)
+	}
+	cr, err = CheckGolden("frame", img, DefaultTol)
+	if err != nil || cr == nil || !cr.Pass {
+		t.Errorf("expected pass against written golden image, got: %v %v", cr, err)
+	}
+
+	oimg, err := RenderNode(testFrame(20, "#f0f"), image.Point{100, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr, err = CheckGolden("frame", oimg, DefaultTol)
+	if err != nil || cr == nil || cr.Pass {
+		t.Errorf("expected failure against different golden image, got: %v %v", cr, err)
+	}
+	if _, err := os.Stat(GoldenPath(FailDir + "/frame")); err != nil {
+		t.Errorf("expected failed render to be saved: %v", err)
+	}
+}
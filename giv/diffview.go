@@ -0,0 +1,540 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bytes"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  DiffView
+
+// DiffView shows the differences between two text buffers side-by-side, in
+// two TextViews that scroll together, with blank filler lines keeping the
+// lines of both sides aligned.  Lines that differ are colored by the kind of
+// difference (see DiffViewColors), and the changed characters within
+// replaced lines are highlighted.  The hunks of differences can be navigated
+// in turn, and applied to either buffer to make it the same as the other
+// there.  The TextViews show copies of the text, which are updated from the
+// buffers by Update, whenever either buffer signals an edit.
+type DiffView struct {
+	gi.Frame
+	BufA    *TextBuf  `json:"-" xml:"-" desc:"buffer shown on the left (a) -- the diffs convert it into BufB"`
+	BufB    *TextBuf  `json:"-" xml:"-" desc:"buffer shown on the right (b)"`
+	Diffs   TextDiffs `json:"-" xml:"-" desc:"current differences between BufA and BufB, from DiffBufs -- each op other than 'e' (equal) is a hunk"`
+	Hunk    int       `json:"-" xml:"-" desc:"index within Diffs of the current hunk, which the apply actions act on -- -1 if none"`
+	LinesA  []int     `json:"-" xml:"-" desc:"for each aligned display line, the line of BufA shown there, or -1 for a filler line"`
+	LinesB  []int     `json:"-" xml:"-" desc:"for each aligned display line, the line of BufB shown there, or -1 for a filler line"`
+	HunkLns []int     `json:"-" xml:"-" desc:"aligned display line at which each op in Diffs starts"`
+	AlignA  *TextBuf  `json:"-" xml:"-" view:"-" desc:"aligned copy of BufA, with filler lines, shown in the left TextView"`
+	AlignB  *TextBuf  `json:"-" xml:"-" view:"-" desc:"aligned copy of BufB, with filler lines, shown in the right TextView"`
+
+	applying bool
+}
+
+var KiT_DiffView = kit.Types.AddType(&DiffView{}, DiffViewProps)
+
+var DiffViewProps = ki.Props{
+	"background-color": &gi.Prefs.Colors.Background,
+	"color":            &gi.Prefs.Colors.Font,
+	"max-width":        -1,
+	"max-height":       -1,
+}
+
+// DiffViewColors are the colors for the lines of a DiffView for each kind
+// of diff op: 'r' (replaced), 'd' (deleted from a) and 'i' (inserted in b),
+// and 'f' for the blank filler lines -- they are blended into the background
+// by DiffViewBlend percent, or twice that for the current hunk
+var DiffViewColors = map[byte]string{
+	'r': "#e0a000",
+	'd': "#ff2020",
+	'i': "#00c020",
+	'f': "#808080",
+}
+
+// DiffViewBlend is the percent of the DiffViewColors blended into the
+// background color of the lines
+var DiffViewBlend = float32(20)
+
+// SetBufs sets the two buffers to compare and updates the view -- the view
+// is updated again whenever either buffer signals an edit
+func (dv *DiffView) SetBufs(bufa, bufb *TextBuf) {
+	for _, tb := range []*TextBuf{dv.BufA, dv.BufB} {
+		if tb != nil {
+			tb.TextBufSig.Disconnect(dv.This)
+		}
+	}
+	dv.BufA = bufa
+	dv.BufB = bufb
+	for _, tb := range []*TextBuf{bufa, bufb} {
+		if tb == nil || (tb == bufb && bufb == bufa) {
+			continue
+		}
+		tb.TextBufSig.Connect(dv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+			switch TextBufSignals(sig) {
+			case TextBufNew, TextBufInsert, TextBufDelete:
+				dvv := recv.Embed(KiT_DiffView).(*DiffView)
+				if !dvv.applying {
+					dvv.Update()
+				}
+			}
+		})
+	}
+	dv.Config()
+	dv.Update()
+}
+
+// Config configures the toolbar and side-by-side views, if not already done
+func (dv *DiffView) Config() {
+	dv.Lay = gi.LayoutVert
+	config := kit.TypeAndNameList{}
+	config.Add(gi.KiT_ToolBar, "toolbar")
+	config.Add(gi.KiT_SplitView, "splitview")
+	mods, updt := dv.ConfigChildren(config, false)
+	if !mods {
+		updt = dv.UpdateStart()
+	}
+	dv.ConfigToolbar()
+	dv.ConfigSplitView()
+	dv.UpdateEnd(updt)
+}
+
+// ToolBar returns the toolbar widget
+func (dv *DiffView) ToolBar() *gi.ToolBar {
+	return dv.KnownChildByName("toolbar", 0).(*gi.ToolBar)
+}
+
+// SplitView returns the SplitView holding the two sides
+func (dv *DiffView) SplitView() *gi.SplitView {
+	return dv.KnownChildByName("splitview", 1).(*gi.SplitView)
+}
+
+// TextViews returns the TextViews for the a (left) and b (right) sides
+func (dv *DiffView) TextViews() (tva, tvb *TextView) {
+	split := dv.SplitView()
+	tva = split.KnownChild(0).KnownChild(0).(*TextView)
+	tvb = split.KnownChild(1).KnownChild(0).(*TextView)
+	return
+}
+
+// ConfigToolbar adds the navigation and apply actions to the toolbar
+func (dv *DiffView) ConfigToolbar() {
+	tb := dv.ToolBar()
+	if tb.HasChildren() {
+		return
+	}
+	tb.SetStretchMaxWidth()
+	hasHunk := func(act *gi.Action) {
+		act.SetActiveStateUpdt(dv.Hunk >= 0)
+	}
+	ac := tb.AddAction(gi.ActOpts{Label: "Prev", Icon: "widget-wedge-up", UpdateFunc: hasHunk}, dv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		dvv := recv.Embed(KiT_DiffView).(*DiffView)
+		dvv.PrevHunk()
+	})
+	ac.Tooltip = "go to the previous hunk of differences"
+	ac = tb.AddAction(gi.ActOpts{Label: "Next", Icon: "widget-wedge-down", UpdateFunc: hasHunk}, dv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		dvv := recv.Embed(KiT_DiffView).(*DiffView)
+		dvv.NextHunk()
+	})
+	ac.Tooltip = "go to the next hunk of differences"
+	ac = tb.AddAction(gi.ActOpts{Label: "Apply Left", Icon: "widget-wedge-left", UpdateFunc: hasHunk}, dv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		dvv := recv.Embed(KiT_DiffView).(*DiffView)
+		dvv.ApplyToA(dvv.Hunk)
+	})
+	ac.Tooltip = "apply the current hunk to the left buffer, making it the same as the right one there"
+	ac = tb.AddAction(gi.ActOpts{Label: "Apply Right", Icon: "widget-wedge-right", UpdateFunc: hasHunk}, dv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		dvv := recv.Embed(KiT_DiffView).(*DiffView)
+		dvv.ApplyToB(dvv.Hunk)
+	})
+	ac.Tooltip = "apply the current hunk to the right buffer, making it the same as the left one there"
+	ac = tb.AddAction(gi.ActOpts{Label: "Update", Icon: "update"}, dv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		dvv := recv.Embed(KiT_DiffView).(*DiffView)
+		dvv.Update()
+	})
+	ac.Tooltip = "update the differences from the current text of the buffers"
+}
+
+// ConfigSplitView configures the two sides, each a TextView within a
+// DiffLayout that scrolls along with the other
+func (dv *DiffView) ConfigSplitView() {
+	split := dv.SplitView()
+	if split.HasChildren() {
+		return
+	}
+	split.Dim = gi.X
+	split.SetProp("white-space", gi.WhiteSpacePre) // no wrapping, to keep lines aligned
+	split.SetProp("font-family", "Go Mono")
+	var lys [2]*DiffLayout
+	for i, nm := range []string{"a", "b"} {
+		ly := split.AddNewChild(KiT_DiffLayout, "layout-"+nm).(*DiffLayout)
+		ly.SetStretchMaxWidth()
+		ly.SetStretchMaxHeight()
+		ly.SetMinPrefWidth(units.NewValue(20, units.Ch))
+		ly.SetMinPrefHeight(units.NewValue(10, units.Ch))
+		lys[i] = ly
+		tv := ly.AddNewChild(KiT_TextView, "text-"+nm).(*TextView)
+		tv.SetInactive()
+		ab := &TextBuf{}
+		ab.InitName(ab, "align-"+nm)
+		if i == 0 {
+			dv.AlignA = ab
+		} else {
+			dv.AlignB = ab
+		}
+		tv.SetBuf(ab)
+		tv.TextViewSig.Connect(dv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig != int64(TextViewCursorMoved) {
+				return
+			}
+			dvv := recv.Embed(KiT_DiffView).(*DiffView)
+			if pos, ok := data.(TextPos); ok {
+				if h := dvv.HunkAtLine(pos.Ln); h >= 0 {
+					dvv.SetHunk(h)
+				}
+			}
+		})
+	}
+	lys[0].Other = lys[1]
+	lys[1].Other = lys[0]
+	split.SetSplits(.5, .5)
+}
+
+// Update recomputes the differences between the buffers and updates the
+// aligned text shown in the views -- the current hunk stays at about the
+// same place
+func (dv *DiffView) Update() {
+	if dv.BufA == nil || dv.BufB == nil || dv.AlignA == nil {
+		return
+	}
+	hln := -1
+	if dv.Hunk >= 0 && dv.Hunk < len(dv.HunkLns) {
+		hln = dv.HunkLns[dv.Hunk]
+	}
+	dv.Diffs = dv.BufA.DiffBufs(dv.BufB)
+	dv.LinesA, dv.LinesB, dv.HunkLns = DiffAlign(dv.Diffs)
+	dv.Hunk = -1
+	if hln >= 0 {
+		if dv.Hunk = dv.hunkFrom(hln, 1); dv.Hunk < 0 {
+			dv.Hunk = dv.hunkFrom(hln, -1)
+		}
+	}
+	dv.setAlignBuf(dv.AlignA, dv.BufA, dv.LinesA)
+	dv.setAlignBuf(dv.AlignB, dv.BufB, dv.LinesB)
+	tva, tvb := dv.TextViews()
+	tva.Highlights, tvb.Highlights = dv.changedChars()
+	dv.UpdateColors()
+}
+
+// setAlignBuf sets the text of aligned buffer ab from lines of buffer tb,
+// highlighted the same way
+func (dv *DiffView) setAlignBuf(ab, tb *TextBuf, lns []int) {
+	var buf bytes.Buffer
+	for _, ln := range lns {
+		if ln >= 0 {
+			buf.Write(tb.LineBytes(ln))
+		}
+		buf.WriteByte('\n')
+	}
+	ab.Hi.Lang = tb.Hi.Lang
+	ab.Hi.Style = tb.Hi.Style
+	ab.Hi.TabSize = tb.Hi.TabSize
+	ab.Txt = buf.Bytes()
+	ab.BytesToLines()
+	ab.MarkupAllLines()
+}
+
+// changedChars returns the regions of characters that differ between the
+// lines paired up in replace ops, for each side
+func (dv *DiffView) changedChars() (rega, regb []TextRegion) {
+	for i, df := range dv.Diffs {
+		if df.Tag != 'r' {
+			continue
+		}
+		n := df.I2 - df.I1
+		if df.J2-df.J1 < n {
+			n = df.J2 - df.J1
+		}
+		for k := 0; k < n; k++ {
+			ln := dv.HunkLns[i] + k
+			ra, rb := DiffLineChars(dv.BufA.Line(df.I1+k), dv.BufB.Line(df.J1+k))
+			for _, r := range ra {
+				rega = append(rega, TextRegion{Start: TextPos{Ln: ln, Ch: r[0]}, End: TextPos{Ln: ln, Ch: r[1]}})
+			}
+			for _, r := range rb {
+				regb = append(regb, TextRegion{Start: TextPos{Ln: ln, Ch: r[0]}, End: TextPos{Ln: ln, Ch: r[1]}})
+			}
+		}
+	}
+	return
+}
+
+// UpdateColors sets the line colors of the views for the kinds of
+// differences, with those of the current hunk stronger, and renders them
+func (dv *DiffView) UpdateColors() {
+	tva, tvb := dv.TextViews()
+	tva.DeleteLineColor(-1)
+	tvb.DeleteLineColor(-1)
+	bg := gi.Prefs.Colors.Background
+	for i, df := range dv.Diffs {
+		if df.Tag == 'e' {
+			continue
+		}
+		pct := DiffViewBlend
+		if i == dv.Hunk {
+			pct *= 2
+		}
+		var dclr, fclr gi.Color
+		dclr.SetString(DiffViewColors[df.Tag], nil)
+		fclr.SetString(DiffViewColors['f'], nil)
+		dclr = bg.Blend(pct, dclr)
+		fclr = bg.Blend(pct, fclr)
+		st, ed := dv.hunkLines(i)
+		for ln := st; ln < ed; ln++ {
+			if dv.LinesA[ln] >= 0 {
+				tva.SetLineColor(ln, dclr)
+			} else {
+				tva.SetLineColor(ln, fclr)
+			}
+			if dv.LinesB[ln] >= 0 {
+				tvb.SetLineColor(ln, dclr)
+			} else {
+				tvb.SetLineColor(ln, fclr)
+			}
+		}
+	}
+	tva.RenderAllLines()
+	tvb.RenderAllLines()
+	dv.ToolBar().UpdateActions()
+}
+
+// hunkLines returns the range of aligned lines of given op (ed exclusive)
+func (dv *DiffView) hunkLines(idx int) (st, ed int) {
+	st = dv.HunkLns[idx]
+	if idx+1 < len(dv.HunkLns) {
+		return st, dv.HunkLns[idx+1]
+	}
+	return st, len(dv.LinesA)
+}
+
+// HunkAtLine returns the index within Diffs of the hunk containing given
+// aligned line, or -1 if it is not within one
+func (dv *DiffView) HunkAtLine(ln int) int {
+	for i, df := range dv.Diffs {
+		if df.Tag == 'e' {
+			continue
+		}
+		if st, ed := dv.hunkLines(i); ln >= st && ln < ed {
+			return i
+		}
+	}
+	return -1
+}
+
+// hunkFrom returns the index of the first hunk starting at or after given
+// aligned line, searching forward (dir > 0) or backward from it, and -1 if
+// none
+func (dv *DiffView) hunkFrom(ln int, dir int) int {
+	if dir > 0 {
+		for i, df := range dv.Diffs {
+			if df.Tag != 'e' && dv.HunkLns[i] >= ln {
+				return i
+			}
+		}
+		return -1
+	}
+	for i := len(dv.Diffs) - 1; i >= 0; i-- {
+		if dv.Diffs[i].Tag != 'e' && dv.HunkLns[i] <= ln {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetHunk sets the current hunk to given index within Diffs, and updates
+// the line colors to show it
+func (dv *DiffView) SetHunk(idx int) {
+	if idx == dv.Hunk {
+		return
+	}
+	dv.Hunk = idx
+	dv.UpdateColors()
+}
+
+// ShowHunk makes given hunk the current one, and moves the cursor of both
+// views to its start, scrolling to it as needed
+func (dv *DiffView) ShowHunk(idx int) {
+	if idx < 0 || idx >= len(dv.Diffs) {
+		return
+	}
+	dv.SetHunk(idx)
+	tva, tvb := dv.TextViews()
+	pos := TextPos{Ln: dv.HunkLns[idx]}
+	tva.SetCursorShow(pos)
+	tvb.SetCursorShow(pos)
+}
+
+// NextHunk goes to the next hunk after the current one, returning false if
+// there are no more
+func (dv *DiffView) NextHunk() bool {
+	ln := 0
+	if dv.Hunk >= 0 {
+		ln = dv.HunkLns[dv.Hunk] + 1
+	}
+	h := dv.hunkFrom(ln, 1)
+	if h < 0 {
+		return false
+	}
+	dv.ShowHunk(h)
+	return true
+}
+
+// PrevHunk goes to the hunk before the current one, returning false if
+// there are none
+func (dv *DiffView) PrevHunk() bool {
+	ln := len(dv.LinesA)
+	if dv.Hunk >= 0 {
+		ln = dv.HunkLns[dv.Hunk] - 1
+	}
+	h := dv.hunkFrom(ln, -1)
+	if h < 0 {
+		return false
+	}
+	dv.ShowHunk(h)
+	return true
+}
+
+// ApplyToA patches BufA with the text of BufB for given hunk (index within
+// Diffs), so they are the same there, as one undoable edit, and updates the
+// view -- returns false if it is not a hunk
+func (dv *DiffView) ApplyToA(idx int) bool {
+	if idx < 0 || idx >= len(dv.Diffs) || dv.Diffs[idx].Tag == 'e' {
+		return false
+	}
+	dv.applying = true
+	dv.BufA.UndoGroupStart()
+	dv.BufA.patchFromBuf(dv.BufB, dv.Diffs[idx:idx+1], true, true)
+	dv.BufA.UndoGroupEnd()
+	dv.applying = false
+	dv.Update()
+	return true
+}
+
+// ApplyToB patches BufB with the text of BufA for given hunk (index within
+// Diffs), so they are the same there, as one undoable edit, and updates the
+// view -- returns false if it is not a hunk
+func (dv *DiffView) ApplyToB(idx int) bool {
+	if idx < 0 || idx >= len(dv.Diffs) || dv.Diffs[idx].Tag == 'e' {
+		return false
+	}
+	dv.applying = true
+	dv.BufB.UndoGroupStart()
+	dv.BufB.patchFromBuf(dv.BufA, dv.Diffs[idx:idx+1].Reverse(), true, true)
+	dv.BufB.UndoGroupEnd()
+	dv.applying = false
+	dv.Update()
+	return true
+}
+
+// DiffAlign returns the side-by-side alignment of two texts with given
+// differences: for each aligned line, the line of text a and of text b shown
+// there, or -1 for a blank filler line, and the aligned line at which each
+// op starts.  Replaced lines are paired up in order, with filler after the
+// shorter side.
+func DiffAlign(diffs TextDiffs) (lna, lnb, opln []int) {
+	opln = make([]int, len(diffs))
+	for i, df := range diffs {
+		opln[i] = len(lna)
+		na := df.I2 - df.I1
+		nb := df.J2 - df.J1
+		n := na
+		if nb > n {
+			n = nb
+		}
+		for k := 0; k < n; k++ {
+			if k < na {
+				lna = append(lna, df.I1+k)
+			} else {
+				lna = append(lna, -1)
+			}
+			if k < nb {
+				lnb = append(lnb, df.J1+k)
+			} else {
+				lnb = append(lnb, -1)
+			}
+		}
+	}
+	return
+}
+
+// DiffLineChars returns the ranges of runes (start, end exclusive) that
+// differ between two versions of a line, within each of them
+func DiffLineChars(a, b []rune) (ra, rb [][2]int) {
+	astr := make([]string, len(a))
+	for i, r := range a {
+		astr[i] = string(r)
+	}
+	bstr := make([]string, len(b))
+	for i, r := range b {
+		bstr[i] = string(r)
+	}
+	m := difflib.NewMatcherWithJunk(astr, bstr, false, nil)
+	for _, op := range m.GetOpCodes() {
+		if op.Tag == 'e' {
+			continue
+		}
+		if op.I2 > op.I1 {
+			ra = append(ra, [2]int{op.I1, op.I2})
+		}
+		if op.J2 > op.J1 {
+			rb = append(rb, [2]int{op.J1, op.J2})
+		}
+	}
+	return
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  DiffLayout
+
+// DiffLayout is the scrolling layout around each TextView of a DiffView,
+// which scrolls the other side along with it
+type DiffLayout struct {
+	gi.Layout
+	Other      *DiffLayout `json:"-" xml:"-" desc:"layout of the other side, which is scrolled to the same position as this one"`
+	lastScroll [gi.Dims2DN]float32
+}
+
+var KiT_DiffLayout = kit.Types.AddType(&DiffLayout{}, nil)
+
+func (dl *DiffLayout) Render2D() {
+	dl.Layout.Render2D()
+	dl.SyncScroll()
+}
+
+// SyncScroll scrolls the other side to the scroll position of this one, if
+// it has changed since last time (from either side)
+func (dl *DiffLayout) SyncScroll() {
+	ol := dl.Other
+	if ol == nil {
+		return
+	}
+	for d := gi.X; d < gi.Dims2DN; d++ {
+		if !dl.HasScroll[d] || !ol.HasScroll[d] {
+			continue
+		}
+		v := dl.Scrolls[d].Value
+		if v == dl.lastScroll[d] {
+			continue
+		}
+		dl.lastScroll[d] = v
+		ol.lastScroll[d] = v
+		ol.Scrolls[d].SetValueAction(v)
+	}
+}
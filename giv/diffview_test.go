@@ -0,0 +1,189 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/oswin/driver/offscreen"
+)
+
+// testWindow returns a new offscreen window with given size, and its main
+// frame
+func testWindow(t *testing.T, name string, width, height int) (*gi.Window, *gi.Frame) {
+	testApp()
+	win := gi.NewWindow2D(name, name, width, height, true)
+	if win == nil {
+		t.Fatalf("could not create window")
+	}
+	return win, win.SetMainFrame()
+}
+
+// testStart starts the event loop of the window and waits for it to render
+func testStart(t *testing.T, win *gi.Window) {
+	win.GoStartEventLoop()
+	if !offscreen.WaitPublish(win.OSWin, 0, 5*time.Second) {
+		t.Fatal("window was not rendered")
+	}
+	testSync(t, win)
+}
+
+// testSync waits until the window has processed all events sent so far
+func testSync(t *testing.T, win *gi.Window) {
+	ep := &gi.EventPlayer{Win: win, SyncTimeout: 5 * time.Second}
+	if err := ep.Sync(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testInLoop runs given function in the event loop of the window and waits
+// for it to finish
+func testInLoop(t *testing.T, win *gi.Window, fun func()) {
+	win.RunInEventLoop(fun)
+	testSync(t, win)
+}
+
+// testClose closes the window and waits until it has been removed from
+// AllWindows, so later tests do not see a half-closed window
+func testClose(t *testing.T, win *gi.Window) {
+	win.OSWin.Close()
+	for i := 0; i < 500; i++ {
+		if _, ok := gi.AllWindows.FindName(win.Nm); !ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("window %v was not closed", win.Nm)
+}
+
+// testDiffView returns a new DiffView comparing buffers with given texts, in
+// a running window that must be closed with testClose
+func testDiffView(t *testing.T, txta, txtb string) (*gi.Window, *DiffView) {
+	win, mfr := testWindow(t, "diffview-test", 600, 400)
+	dv := mfr.AddNewChild(KiT_DiffView, "diffview").(*DiffView)
+	dv.SetBufs(testTextBuf([]byte(txta)), testTextBuf([]byte(txtb)))
+	testStart(t, win)
+	return win, dv
+}
+
+// testDiffTags returns the tags of the ops of the view's diffs
+func testDiffTags(dv *DiffView) string {
+	tags := make([]byte, len(dv.Diffs))
+	for i, df := range dv.Diffs {
+		tags[i] = df.Tag
+	}
+	return string(tags)
+}
+
+func TestDiffViewHunks(t *testing.T) {
+	win, dv := testDiffView(t, "a\nb\nc\nd\ne\n", "a\nB\nc\nd\ne\nf\n")
+	defer testClose(t, win)
+	testInLoop(t, win, func() { testDiffViewHunks(t, dv) })
+}
+
+func testDiffViewHunks(t *testing.T, dv *DiffView) {
+	if tags := testDiffTags(dv); tags != "erei" {
+		t.Errorf("diff tags: %q != %q", tags, "erei")
+		return
+	}
+	if dv.Hunk != -1 {
+		t.Errorf("initial hunk: %d != -1", dv.Hunk)
+	}
+	if h := dv.HunkAtLine(1); h != 1 {
+		t.Errorf("HunkAtLine(1): %d != 1", h)
+	}
+	if h := dv.HunkAtLine(2); h != -1 {
+		t.Errorf("HunkAtLine(2): %d != -1", h)
+	}
+	if !dv.NextHunk() || dv.Hunk != 1 {
+		t.Errorf("first NextHunk: hunk %d != 1", dv.Hunk)
+	}
+	if !dv.NextHunk() || dv.Hunk != 3 {
+		t.Errorf("second NextHunk: hunk %d != 3", dv.Hunk)
+	}
+	if dv.NextHunk() || dv.Hunk != 3 {
+		t.Errorf("NextHunk past the last: hunk %d != 3", dv.Hunk)
+	}
+	if !dv.PrevHunk() || dv.Hunk != 1 {
+		t.Errorf("PrevHunk: hunk %d != 1", dv.Hunk)
+	}
+	if dv.PrevHunk() || dv.Hunk != 1 {
+		t.Errorf("PrevHunk before the first: hunk %d != 1", dv.Hunk)
+	}
+}
+
+func TestDiffViewApply(t *testing.T) {
+	win, dv := testDiffView(t, "a\nb\nc\nd\ne\n", "a\nB\nx\ny\nc\nd\ne\n")
+	defer testClose(t, win)
+	testInLoop(t, win, func() { testDiffViewApply(t, dv) })
+}
+
+func testDiffViewApply(t *testing.T, dv *DiffView) {
+	a, b := dv.BufA, dv.BufB
+	origA := string(a.LinesToBytesCopy())
+	if !dv.ApplyToA(1) {
+		t.Error("ApplyToA(1) failed")
+		return
+	}
+	if txt := string(a.LinesToBytesCopy()); txt != string(b.LinesToBytesCopy()) {
+		t.Errorf("text after ApplyToA: %q != %q", txt, string(b.LinesToBytesCopy()))
+	}
+	if tags := testDiffTags(dv); tags != "e" {
+		t.Errorf("diff tags after ApplyToA: %q != %q", tags, "e")
+	}
+	if dv.ApplyToA(0) {
+		t.Errorf("ApplyToA of an equal op succeeded")
+	}
+	a.Undo()
+	if txt := string(a.LinesToBytesCopy()); txt != origA {
+		t.Errorf("text after one undo: %q != %q", txt, origA)
+	}
+	if a.Undo() != nil {
+		t.Errorf("apply was more than one undo group")
+	}
+	if tags := testDiffTags(dv); tags != "ere" {
+		t.Errorf("diff tags after undo: %q != %q", tags, "ere")
+	}
+
+	// edits to the buffers update the diffs, so apply uses the current text
+	b.InsertText(TextPos{Ln: 0, Ch: 0}, []byte("top\n"), true, true)
+	if tags := testDiffTags(dv); tags != "iere" {
+		t.Errorf("diff tags after edit: %q != %q", tags, "iere")
+		return
+	}
+	if !dv.ApplyToB(2) {
+		t.Error("ApplyToB(2) failed")
+		return
+	}
+	if exp := "top\na\nb\nc\nd\ne\n"; string(b.LinesToBytesCopy()) != exp {
+		t.Errorf("text after ApplyToB: %q != %q", string(b.LinesToBytesCopy()), exp)
+	}
+	b.Undo()
+	if exp := "top\na\nB\nx\ny\nc\nd\ne\n"; string(b.LinesToBytesCopy()) != exp {
+		t.Errorf("text after ApplyToB undo: %q != %q", string(b.LinesToBytesCopy()), exp)
+	}
+}
+
+func TestDiffLineChars(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		ra, rb [][2]int
+	}{
+		{"abcd", "abXd", [][2]int{{2, 3}}, [][2]int{{2, 3}}},
+		{"ab", "aXYb", nil, [][2]int{{1, 3}}},
+		{"hello world", "hello", [][2]int{{5, 11}}, nil},
+		{"añb", "aüb", [][2]int{{1, 2}}, [][2]int{{1, 2}}}, // runes, not bytes
+		{"same", "same", nil, nil},
+	}
+	for _, tt := range tests {
+		ra, rb := DiffLineChars([]rune(tt.a), []rune(tt.b))
+		if !reflect.DeepEqual(ra, tt.ra) || !reflect.DeepEqual(rb, tt.rb) {
+			t.Errorf("%q vs %q: %v %v != %v %v", tt.a, tt.b, ra, rb, tt.ra, tt.rb)
+		}
+	}
+}
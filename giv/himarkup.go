@@ -6,7 +6,6 @@ package giv
 
 import (
 	"bytes"
-	// htmlstd "html"
 	"log"
 	"strings"
 
@@ -21,7 +20,9 @@ import (
 // HiStyleName is a highlighting style name
 type HiStyleName string
 
-// HiMarkup manages the syntax highlighting state for TextBuf
+// HiMarkup manages the syntax highlighting state for TextBuf -- the lexer
+// produces HiToken tokens for each line (see TokenizeLines), which are
+// rendered with the styles from the CSS for the highlighting style
 type HiMarkup struct {
 	Lang      string        `desc:"language for syntax highlighting the code"`
 	Style     HiStyleName   `desc:"syntax highlighting style"`
@@ -72,57 +73,6 @@ func (hm *HiMarkup) Init() {
 	hm.lastStyle = hm.Style
 }
 
-// MarkupText returns a line-wise split of marked-up text according to current
-// syntax highlighting settings
-func (hm *HiMarkup) MarkupText(txt []byte) ([][]byte, error) {
-	var htmlBuf bytes.Buffer
-	// txtstr := htmlstd.EscapeString(string(txt)) // not getting properly uensc
-	txtstr := string(txt)
-	iterator, err := hm.lexer.Tokenise(nil, txtstr)
-	err = hm.formatter.Format(&htmlBuf, hm.style, iterator)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	mtlns := bytes.Split(htmlBuf.Bytes(), []byte("\n"))
-	return mtlns, nil
-}
-
-// MarkupLine returns a marked-up version of line of text
-func (hm *HiMarkup) MarkupLine(txtln []byte) ([]byte, error) {
-	var htmlBuf bytes.Buffer
-	// txtstr := htmlstd.EscapeString(string(txtln)) + "\n" // not getting properly unesc..
-	txtstr := string(txtln) + "\n"
-	iterator, err := hm.lexer.Tokenise(nil, txtstr)
-	// adding \n b/c it needs to see that for comments..
-	err = hm.formatter.Format(&htmlBuf, hm.style, iterator)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	b := htmlBuf.Bytes()
-	lfidx := bytes.Index(b, []byte("\n"))
-	if lfidx > 0 {
-		b = b[:lfidx]
-	}
-	return hm.FixMarkupLine(b), nil
-}
-
-// FixMarkupLine fixes the output of chroma markup
-func (hm *HiMarkup) FixMarkupLine(mt []byte) []byte {
-	esp := []byte(`</span>`)
-	mt = bytes.TrimPrefix(mt, esp) // leftovers
-	mt = bytes.TrimPrefix(mt, []byte(`<span class="err">`))
-	mt = bytes.Replace(mt, []byte(`**</span>**`), []byte(`**</span>`), -1)
-	mt = bytes.Replace(mt, []byte(`__</span>__`), []byte(`__</span>`), -1)
-	mtnsp := bytes.TrimSpace(mt)
-	if bytes.HasPrefix(mtnsp, esp) {
-		idx := bytes.Index(mt, esp)
-		mt = append(mt[:idx], mt[idx+len(esp):]...)
-	}
-	return mt
-}
-
 // todo: currently based on https://github.com/alecthomas/chroma styles, but we should
 // impl our own structured style obj with a list of categories and
 // corresponding colors, once we do the parsing etc
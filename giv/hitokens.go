@@ -0,0 +1,227 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma"
+	"github.com/goki/ki/ints"
+)
+
+// HiToken is a syntax highlighting token within one line of text -- a
+// TextLine holds the tokens for its line, which are rendered directly with
+// the style for their type, without going through HTML markup
+type HiToken struct {
+	St   int              `desc:"starting rune index within the line"`
+	Ed   int              `desc:"ending rune index within the line (exclusive)"`
+	Type chroma.TokenType `desc:"lexer token type, which determines the style"`
+}
+
+// HiClass returns the CSS class name used for given token type in the
+// highlighting style sheet (e.g., "k" for keywords) -- types without their
+// own class use that of their parent, as in the chroma html formatter
+func HiClass(tt chroma.TokenType) string {
+	for tt != 0 {
+		if cls, ok := chroma.StandardTypes[tt]; ok {
+			return cls
+		}
+		tt = tt.Parent()
+	}
+	return chroma.StandardTypes[tt]
+}
+
+// HiLookAheadLines is the number of lines beyond those being highlighted
+// that are given to the lexer, so that constructs spanning lines, such as
+// comments, are recognized by their ending -- longer ones are only
+// highlighted approximately
+var HiLookAheadLines = 2000
+
+// HiRootStride is the number of lines between the lines that are checked
+// for ending in the root lexer state while lexing, so that highlighting
+// after an edit can restart from one of them (see TextLine.HiRoot)
+var HiRootStride = 32
+
+// HiProbeLines is the number of non-blank lines after a line that must be
+// lexed the same starting in the root lexer state as they are continuing
+// from the line, for it to be known to end in the root state
+var HiProbeLines = 3
+
+// hiLineSplit splits the tokens from a lexer into the tokens of each line
+type hiLineSplit struct {
+	iter  chroma.Iterator
+	lines [][]HiToken
+	cands []bool
+	toks  []HiToken
+	ch    int
+	tt    chroma.TokenType
+	val   string
+	done  bool
+}
+
+func (ls *hiLineSplit) addTok(s string, tt chroma.TokenType) {
+	n := utf8.RuneCountInString(s)
+	if n == 0 {
+		return
+	}
+	if nt := len(ls.toks); nt > 0 && ls.toks[nt-1].Type == tt {
+		ls.toks[nt-1].Ed += n
+	} else {
+		ls.toks = append(ls.toks, HiToken{St: ls.ch, Ed: ls.ch + n, Type: tt})
+	}
+	ls.ch += n
+}
+
+// next lexes the next line, returning false if the lexer is done.  A line
+// is a candidate for ending in the root state only if nothing but
+// indentation follows its newline within the same token -- a token
+// spanning lines (a comment, or a block matched as a whole, such as a
+// markdown code fence or a script in html) means the next line can only be
+// lexed in context.
+func (ls *hiLineSplit) next() bool {
+	for !ls.done {
+		if ls.val == "" {
+			tok := ls.iter()
+			if tok == nil {
+				ls.done = true
+				break
+			}
+			ls.tt, ls.val = tok.Type, tok.Value
+			continue
+		}
+		nl := strings.IndexByte(ls.val, '\n')
+		if nl < 0 {
+			ls.addTok(ls.val, ls.tt)
+			ls.val = ""
+			continue
+		}
+		ls.addTok(ls.val[:nl], ls.tt)
+		ls.val = ls.val[nl+1:]
+		ls.lines = append(ls.lines, ls.toks)
+		ls.cands = append(ls.cands, strings.TrimLeft(ls.val, " \t\r\n") == "")
+		ls.toks = nil
+		ls.ch = 0
+		return true
+	}
+	return false
+}
+
+// hiLinesText returns the text of the lines, each ending in a newline
+func hiLinesText(lns []*TextLine) string {
+	var buf bytes.Buffer
+	for _, tl := range lns {
+		buf.Write(tl.Bytes)
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// hiSameToks returns true if the two token slices are the same
+func hiSameToks(a, b []HiToken) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TokenizeLines lexes the given lines as a sequence starting in the root
+// lexer state, setting the HiToks, HiRoot and HiOk of each line, and
+// returns the number of lines updated.  It stops at the first line from
+// index conv on that ended in the root state before and still does,
+// returning true as the lexer state has converged so the following lines
+// are unchanged, or else at the first line from max lines on that ends in
+// the root state (or HiRootStride lines after that, if none does), so the
+// rest can be lexed separately.  Lines are only lexed as needed, so lines
+// after that are just look-ahead.
+//
+// The state stack of the lexer is not available, so a line is known to end
+// in the root state when the next HiProbeLines non-blank lines are lexed
+// the same starting afresh as they are continuing from it -- this is
+// checked for lines every HiRootStride lines, and where needed to stop.
+// Constructs that a lexer matches as a whole with one pattern, such as a
+// markdown code fence, are only found when their end is within the lines
+// lexed, so an edit completing one far after its start is approximate.
+func (hm *HiMarkup) TokenizeLines(lns []*TextLine, conv, max int) (int, bool, error) {
+	if hm.lexer == nil || len(lns) == 0 {
+		return 0, false, nil
+	}
+	iterator, err := hm.lexer.Tokenise(nil, hiLinesText(lns))
+	if err != nil {
+		return 0, false, err
+	}
+	ls := &hiLineSplit{iter: iterator}
+	// lexTo lexes up to and including line ln, returning false if past the end
+	lexTo := func(ln int) bool {
+		if ln >= len(lns) {
+			return false
+		}
+		for len(ls.lines) <= ln {
+			if !ls.next() { // only if lexer dropped text -- should not happen
+				ls.lines = append(ls.lines, nil)
+				ls.cands = append(ls.cands, false)
+			}
+		}
+		return true
+	}
+	// probe returns true if candidate line ln is known to end in the root
+	// state -- the fresh lexing goes HiProbeLines lines beyond those
+	// compared, so constructs ending there are recognized
+	probe := func(ln int) bool {
+		nb := 0
+		ed := ln + 1
+		for ; nb < HiProbeLines && lexTo(ed); ed++ {
+			if len(bytes.TrimSpace(lns[ed].Bytes)) > 0 {
+				nb++
+			}
+		}
+		if nb == 0 {
+			return false
+		}
+		pit, err := hm.lexer.Tokenise(nil, hiLinesText(lns[ln+1:ints.MinInt(ed+HiProbeLines, len(lns))]))
+		if err != nil {
+			return false
+		}
+		ps := &hiLineSplit{iter: pit}
+		for pl := ln + 1; pl < ed; pl++ {
+			if !ps.next() || !hiSameToks(ps.lines[pl-ln-1], ls.lines[pl]) {
+				return false
+			}
+		}
+		return true
+	}
+	lastProbe := -1 // the line before the first starts in the root state
+	nextProbe := 0
+	for ln := 0; lexTo(ln); ln++ {
+		tl := lns[ln]
+		wasRoot := tl.HiOk && tl.HiRoot
+		root := false
+		atConv := ln >= conv && wasRoot
+		atMax := ln >= max-1
+		if ls.cands[ln] && ((ln >= nextProbe && (atConv || atMax)) || ln-lastProbe >= HiRootStride) {
+			root = probe(ln)
+			lastProbe = ln
+			if !root {
+				nextProbe = ln + 1 + HiProbeLines
+			}
+		}
+		tl.HiToks = ls.lines[ln]
+		tl.HiRoot = root
+		tl.HiOk = true
+		switch {
+		case root && atConv:
+			return ln + 1, true, nil
+		case root && atMax, ln >= max-1+HiRootStride:
+			return ln + 1, false, nil
+		}
+	}
+	return len(lns), false, nil
+}
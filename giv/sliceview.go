@@ -38,7 +38,7 @@ type SliceView struct {
 	StyleFunc        SliceViewStyleFunc `view:"-" json:"-" xml:"-" desc:"optional styling function"`
 	ShowViewCtxtMenu bool               `desc:"if the type we're viewing has its own CtxtMenu property defined, should we also still show the view's standard context menu?"`
 	Changed          bool               `desc:"has the slice been edited?"`
-	Values           []ValueView        `json:"-" xml:"-" desc:"ValueView representations of the slice values"`
+	Values           []ValueView        `json:"-" xml:"-" desc:"ValueView representations of the visible slice values -- Values[i] represents slice index StartIdx+i"`
 	ShowIndex        bool               `xml:"index" desc:"whether to show index or not -- updated from "index" property (bool)"`
 	InactKeyNav      bool               `xml:"inact-key-nav" desc:"support key navigation when inactive (default true) -- updated from "intact-key-nav" property (bool) -- no focus really plausible in inactive case, so it uses a low-pri capture of up / down events"`
 	SelVal           interface{}        `view:"-" json:"-" xml:"-" desc:"current selection value -- initially select this value if set"`
@@ -51,12 +51,19 @@ type SliceView struct {
 	TmpSave          ValueView          `json:"-" xml:"-" desc:"value view that needs to have SaveTmp called on it whenever a change is made to one of the underlying values -- pass this down to any sub-views created from a parent"`
 	BuiltSlice       interface{}        `view:"-" json:"-" xml:"-" desc:"the built slice"`
 	BuiltSize        int
+	StartIdx         int         `json:"-" xml:"-" desc:"slice index of the first visible row -- widgets are only built for the visible rows, and are re-used for other rows as the view is scrolled"`
+	VisRows          int         `json:"-" xml:"-" desc:"number of rows that fit within the SliceGrid, computed during layout -- number of rows of widgets built is the min of this and BuiltSize"`
+	RowHeight        float32     `json:"-" xml:"-" desc:"height of one row of widgets, including spacing, as measured during layout"`
 	ToolbarSlice     interface{} `desc:"the slice that we successfully set a toolbar for"`
 	inFocusGrab      bool
 }
 
 var KiT_SliceView = kit.Types.AddType(&SliceView{}, SliceViewProps)
 
+// SliceViewInitRows is the number of rows of widgets built for a SliceView
+// or TableView before the number of visible rows is known from the layout
+var SliceViewInitRows = 10
+
 // Note: the overall strategy here is similar to Dialog, where we provide lots
 // of flexible configuration elements that can be easily extended and modified
 
@@ -135,12 +142,25 @@ func (sv *SliceView) UpdateValues() {
 	sv.UpdateEnd(updt)
 }
 
+// AccessInfo returns the accessibility info for the slice view, named by the
+// element type (unless it has a tooltip) -- the cells are the widgets
+// within it
+func (sv *SliceView) AccessInfo() gi.AccessInfo {
+	ai := sv.Frame.AccessInfo()
+	ai.Role = gi.RoleTable
+	ai.Name = sv.Tooltip
+	if ai.Name == "" && sv.Slice != nil {
+		ai.Name = kit.NonPtrType(kit.SliceElType(sv.Slice)).Name()
+	}
+	return ai
+}
+
 // StdFrameConfig returns a TypeAndNameList for configuring a standard Frame
 // -- can modify as desired before calling ConfigChildren on Frame using this
 func (sv *SliceView) StdFrameConfig() kit.TypeAndNameList {
 	config := kit.TypeAndNameList{}
 	config.Add(gi.KiT_ToolBar, "toolbar")
-	config.Add(gi.KiT_Frame, "slice-grid")
+	config.Add(gi.KiT_Layout, "grid-lay")
 	return config
 }
 
@@ -154,14 +174,41 @@ func (sv *SliceView) StdConfig() (mods, updt bool) {
 	return
 }
 
+// GridLayout returns the layout containing the SliceGrid and its ScrollBar
+func (sv *SliceView) GridLayout() *gi.Layout {
+	idx, ok := sv.Children().IndexByName("grid-lay", 0)
+	if !ok {
+		return nil
+	}
+	return sv.KnownChild(idx).(*gi.Layout)
+}
+
 // SliceGrid returns the SliceGrid grid frame widget, which contains all the
-// fields and values, and its index, within frame -- nil, -1 if not found
+// fields and values, and its index, within GridLayout -- nil, -1 if not found
 func (sv *SliceView) SliceGrid() (*gi.Frame, int) {
-	idx, ok := sv.Children().IndexByName("slice-grid", 0)
+	gl := sv.GridLayout()
+	if gl == nil {
+		return nil, -1
+	}
+	idx, ok := gl.Children().IndexByName("slice-grid", 0)
 	if !ok {
 		return nil, -1
 	}
-	return sv.KnownChild(idx).(*gi.Frame), idx
+	return gl.KnownChild(idx).(*gi.Frame), idx
+}
+
+// ScrollBar returns the vertical scrollbar that scrolls the rows of the
+// SliceGrid through the slice
+func (sv *SliceView) ScrollBar() *gi.ScrollBar {
+	gl := sv.GridLayout()
+	if gl == nil {
+		return nil
+	}
+	idx, ok := gl.Children().IndexByName("scrollbar", 0)
+	if !ok {
+		return nil
+	}
+	return gl.KnownChild(idx).(*gi.ScrollBar)
 }
 
 // ToolBar returns the toolbar widget
@@ -187,7 +234,8 @@ func (sv *SliceView) RowWidgetNs() (nWidgPerRow, idxOff int) {
 	return
 }
 
-// ConfigSliceGrid configures the SliceGrid for the current slice
+// ConfigSliceGrid configures the SliceGrid for the current slice -- only
+// the visible rows get widgets, see ConfigVisRows
 func (sv *SliceView) ConfigSliceGrid(forceUpdt bool) {
 	if kit.IfaceIsNil(sv.Slice) {
 		return
@@ -202,14 +250,24 @@ func (sv *SliceView) ConfigSliceGrid(forceUpdt bool) {
 	sv.BuiltSlice = sv.Slice
 	sv.BuiltSize = sz
 
-	sg, _ := sv.SliceGrid()
-	if sg == nil {
+	gl := sv.GridLayout()
+	if gl == nil {
 		return
 	}
-	updt := sg.UpdateStart()
-	sg.SetFullReRender()
-	defer sg.UpdateEnd(updt)
+	gl.Lay = gi.LayoutHoriz
+	gl.SetStretchMaxHeight()
+	gl.SetStretchMaxWidth()
+	glcfg := kit.TypeAndNameList{}
+	glcfg.Add(gi.KiT_Frame, "slice-grid")
+	glcfg.Add(gi.KiT_ScrollBar, "scrollbar")
+	mods, updt := gl.ConfigChildren(glcfg, false)
+	if !mods {
+		updt = gl.UpdateStart()
+	}
+	gl.SetFullReRender()
+	defer gl.UpdateEnd(updt)
 
+	sg, _ := sv.SliceGrid()
 	nWidgPerRow, _ := sv.RowWidgetNs()
 
 	sg.Lay = gi.LayoutGrid
@@ -220,40 +278,105 @@ func (sv *SliceView) ConfigSliceGrid(forceUpdt bool) {
 	sg.SetStretchMaxHeight() // for this to work, ALL layers above need it too
 	sg.SetStretchMaxWidth()  // for this to work, ALL layers above need it too
 
-	sv.Values = make([]ValueView, sz)
+	if sv.SelVal != nil {
+		sv.SelectedIdx, _ = SliceRowByValue(sv.Slice, sv.SelVal)
+	}
+	if sv.VisRows == 0 {
+		sv.VisRows = SliceViewInitRows
+	}
+	if sv.SelectedIdx >= 0 {
+		sv.StartIdx = SliceStartIdxForRow(sv.StartIdx, sv.SelectedIdx, ints.MinInt(sv.VisRows, sz))
+	}
+	sv.ConfigVisRows()
+}
 
+// ConfigVisRows creates the Values and SliceGrid widgets for the number of
+// visible rows (min of VisRows and BuiltSize), and configures them to show
+// the slice starting at StartIdx -- if the selected row was visible, it is
+// kept visible
+func (sv *SliceView) ConfigVisRows() {
+	sg, _ := sv.SliceGrid()
+	if sg == nil {
+		return
+	}
+	selVis := sv.SelectedIdx >= 0 && sv.RowIsVisible(sv.SelectedIdx)
+	nrows := ints.MinInt(sv.VisRows, sv.BuiltSize)
+	if selVis {
+		sv.StartIdx = SliceStartIdxForRow(sv.StartIdx, sv.SelectedIdx, nrows)
+	}
+	sv.StartIdx = SliceStartIdxClamp(sv.StartIdx, sv.BuiltSize, nrows)
+
+	nWidgPerRow, _ := sv.RowWidgetNs()
+	sv.Values = make([]ValueView, nrows)
 	sg.DeleteChildren(true)
-	sg.Kids = make(ki.Slice, nWidgPerRow*sz)
+	sg.Kids = make(ki.Slice, nWidgPerRow*nrows)
 
+	sv.ConfigScrollBar()
 	sv.ConfigSliceGridRows()
 }
 
-// ConfigSliceGridRows configures the SliceGrid rows for the current slice --
-// assumes .Kids is created at the right size -- only call this for a direct
-// re-render e.g., after sorting
+// ConfigScrollBar configures the ScrollBar to scroll through the rows of the
+// slice, in units of rows
+func (sv *SliceView) ConfigScrollBar() {
+	sb := sv.ScrollBar()
+	if sb == nil {
+		return
+	}
+	nrows := len(sv.Values)
+	sb.Defaults()
+	sb.Dim = gi.Y
+	sb.Tracking = true
+	sb.Min = 0
+	sb.Max = float32(sv.BuiltSize)
+	sb.Step = 1
+	sb.PageStep = float32(ints.MaxInt(nrows-1, 1))
+	sb.TrackThr = 1
+	sb.ThumbVal = float32(nrows)
+	sb.Value = float32(sv.StartIdx)
+	sb.SetFixedWidth(units.NewValue(16, units.Px)) // default layout scrollbar-width
+	sb.SetMinPrefHeight(units.NewValue(10, units.Em))
+	sb.SetStretchMaxHeight()
+	sb.SliderSig.ConnectOnly(sv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig != int64(gi.SliderValueChanged) {
+			return
+		}
+		svv := recv.Embed(KiT_SliceView).(*SliceView)
+		svv.SetStartIdx(int(data.(float32)))
+	})
+}
+
+// ConfigSliceGridRows configures the SliceGrid rows to show the slice
+// starting at StartIdx -- assumes .Kids and Values are created for the
+// visible rows, and re-uses any existing widgets -- call this for a direct
+// re-render e.g., after sorting or scrolling
 func (sv *SliceView) ConfigSliceGridRows() {
 	mv := reflect.ValueOf(sv.Slice)
 	mvnp := kit.NonPtrValue(mv)
-	sz := mvnp.Len()
 	sg, _ := sv.SliceGrid()
 
 	nWidgPerRow, idxOff := sv.RowWidgetNs()
 	updt := sg.UpdateStart()
 	defer sg.UpdateEnd(updt)
 
-	for i := 0; i < sz; i++ {
+	for i := range sv.Values {
+		si := sv.StartIdx + i
 		ridx := i * nWidgPerRow
-		val := kit.OnePtrValue(mvnp.Index(i)) // deal with pointer lists
+		val := kit.OnePtrValue(mvnp.Index(si)) // deal with pointer lists
 		vv := ToValueView(val.Interface())
 		if vv == nil { // shouldn't happen
 			continue
 		}
-		vv.SetSliceValue(val, sv.Slice, i, sv.TmpSave)
+		vv.SetSliceValue(val, sv.Slice, si, sv.TmpSave)
 		sv.Values[i] = vv
 		vtyp := vv.WidgetType()
-		idxtxt := fmt.Sprintf("%05d", i)
-		labnm := fmt.Sprintf("index-%v", idxtxt)
-		valnm := fmt.Sprintf("value-%v", idxtxt)
+		idxtxt := fmt.Sprintf("%05d", si)
+		rowtxt := fmt.Sprintf("%05d", i)
+		labnm := fmt.Sprintf("index-%v", rowtxt)
+		valnm := fmt.Sprintf("value-%v", rowtxt)
+		sel := sv.RowIsSelected(si)
+		if sv.IsInactive() {
+			sel = si == sv.SelectedIdx
+		}
 
 		if sv.ShowIndex {
 			var idxlab *gi.Label
@@ -262,26 +385,32 @@ func (sv *SliceView) ConfigSliceGridRows() {
 			} else {
 				idxlab = &gi.Label{}
 				sg.SetChild(idxlab, ridx, labnm)
+				idxlab.WidgetSig.ConnectOnly(sv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+					if sig == int64(gi.WidgetSelected) {
+						wbb := send.(gi.Node2D).AsWidget()
+						idx := wbb.KnownProp("slv-index").(int)
+						svv := recv.Embed(KiT_SliceView).(*SliceView)
+						svv.UpdateSelect(idx, wbb.IsSelected())
+					}
+				})
 			}
 			idxlab.Text = idxtxt
-			idxlab.SetProp("slv-index", i)
+			idxlab.SetProp("slv-index", si)
 			idxlab.Selectable = true
-			idxlab.WidgetSig.ConnectOnly(sv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
-				if sig == int64(gi.WidgetSelected) {
-					wbb := send.(gi.Node2D).AsWidget()
-					idx := wbb.KnownProp("slv-index").(int)
-					svv := recv.Embed(KiT_SliceView).(*SliceView)
-					svv.UpdateSelect(idx, wbb.IsSelected())
-				}
-			})
+			idxlab.SetSelectedState(sel)
 		}
 
 		var widg gi.Node2D
-		if sg.Kids[ridx+idxOff] != nil {
+		if sg.Kids[ridx+idxOff] != nil && reflect.TypeOf(sg.Kids[ridx+idxOff]).Elem() == vtyp {
 			widg = sg.Kids[ridx+idxOff].(gi.Node2D)
 		} else {
-			widg = ki.NewOfType(vtyp).(gi.Node2D)
-			sg.SetChild(widg, ridx+idxOff, valnm)
+			if sg.Kids[ridx+idxOff] != nil { // different type of value in this row now
+				sg.DeleteChildAtIndex(ridx+idxOff, true)
+				widg = sg.InsertNewChild(vtyp, ridx+idxOff, valnm).(gi.Node2D)
+			} else {
+				widg = ki.NewOfType(vtyp).(gi.Node2D)
+				sg.SetChild(widg, ridx+idxOff, valnm)
+			}
 		}
 		vv.ConfigWidget(widg)
 
@@ -289,8 +418,8 @@ func (sv *SliceView) ConfigSliceGridRows() {
 			widg.AsNode2D().SetInactive()
 			wb := widg.AsWidget()
 			if wb != nil {
-				wb.SetProp("slv-index", i)
-				wb.ClearSelected()
+				wb.SetProp("slv-index", si)
+				wb.SetSelectedState(sel)
 				wb.WidgetSig.ConnectOnly(sv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 					if sig == int64(gi.WidgetSelected) || sig == int64(gi.WidgetFocused) {
 						wbb := send.(gi.Node2D).AsWidget()
@@ -301,48 +430,137 @@ func (sv *SliceView) ConfigSliceGridRows() {
 				})
 			}
 		} else {
+			widg.AsNode2D().SetSelectedState(sel)
 			vvb := vv.AsValueViewBase()
 			vvb.ViewSig.ConnectOnly(sv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 				svv, _ := recv.Embed(KiT_SliceView).(*SliceView)
 				svv.SetChanged()
 			})
 			if !sv.IsArray {
-				addnm := fmt.Sprintf("add-%v", idxtxt)
-				delnm := fmt.Sprintf("del-%v", idxtxt)
-				addact := gi.Action{}
-				delact := gi.Action{}
-				sg.SetChild(&addact, ridx+idxOff+1, addnm)
-				sg.SetChild(&delact, ridx+idxOff+2, delnm)
-
-				addact.SetIcon("plus")
-				addact.Tooltip = "insert a new element at this index"
-				addact.Data = i
-				addact.ActionSig.ConnectOnly(sv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
-					act := send.(*gi.Action)
-					svv := recv.Embed(KiT_SliceView).(*SliceView)
-					svv.SliceNewAt(act.Data.(int)+1, true)
-				})
-				delact.SetIcon("minus")
-				delact.Tooltip = "delete this element"
-				delact.Data = i
-				delact.ActionSig.ConnectOnly(sv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
-					act := send.(*gi.Action)
-					svv := recv.Embed(KiT_SliceView).(*SliceView)
-					svv.SliceDeleteAt(act.Data.(int), true)
-				})
+				if sg.Kids[ridx+idxOff+1] == nil {
+					addnm := fmt.Sprintf("add-%v", rowtxt)
+					delnm := fmt.Sprintf("del-%v", rowtxt)
+					addact := gi.Action{}
+					delact := gi.Action{}
+					sg.SetChild(&addact, ridx+idxOff+1, addnm)
+					sg.SetChild(&delact, ridx+idxOff+2, delnm)
+
+					addact.SetIcon("plus")
+					addact.Tooltip = "insert a new element at this index"
+					addact.ActionSig.ConnectOnly(sv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+						act := send.(*gi.Action)
+						svv := recv.Embed(KiT_SliceView).(*SliceView)
+						svv.SliceNewAt(act.Data.(int)+1, true)
+					})
+					delact.SetIcon("minus")
+					delact.Tooltip = "delete this element"
+					delact.ActionSig.ConnectOnly(sv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+						act := send.(*gi.Action)
+						svv := recv.Embed(KiT_SliceView).(*SliceView)
+						svv.SliceDeleteAt(act.Data.(int), true)
+					})
+				}
+				sg.Kids[ridx+idxOff+1].(*gi.Action).Data = si
+				sg.Kids[ridx+idxOff+2].(*gi.Action).Data = si
 			}
 		}
 		if sv.StyleFunc != nil {
-			sv.StyleFunc(sv, mvnp.Interface(), widg, i, vv)
+			sv.StyleFunc(sv, mvnp.Interface(), widg, si, vv)
 		}
+	}
+}
 
+// SetStartIdx sets the slice index of the first visible row, keeping it
+// within range, and re-configures the row widgets to show the slice from
+// there -- this is how the view scrolls
+func (sv *SliceView) SetStartIdx(idx int) {
+	idx = SliceStartIdxClamp(idx, sv.BuiltSize, len(sv.Values))
+	if idx == sv.StartIdx {
+		return
 	}
-	if sv.SelVal != nil {
-		sv.SelectedIdx, _ = SliceRowByValue(sv.Slice, sv.SelVal)
+	sv.StartIdx = idx
+	if sb := sv.ScrollBar(); sb != nil && int(sb.Value) != idx {
+		sb.SetValue(float32(idx))
+	}
+	updt := sv.UpdateStart()
+	sv.SetFullReRender()
+	sv.ConfigSliceGridRows()
+	sv.UpdateEnd(updt)
+}
+
+// UpdateVisRows computes the number of rows that fit within the allocated
+// height of the SliceGrid, and re-builds the row widgets if that changes the
+// number of rows shown -- returns true if so, in which case the layout must
+// be redone -- called during Layout2D
+func (sv *SliceView) UpdateVisRows() bool {
+	sg, _ := sv.SliceGrid()
+	if sg == nil {
+		return false
+	}
+	nvis, rowHt := SliceGridVisRows(sg)
+	if nvis == 0 {
+		return false
 	}
-	if sv.IsInactive() && sv.SelectedIdx >= 0 {
-		sv.SelectRowWidgets(sv.SelectedIdx, true)
+	sv.RowHeight = rowHt
+	if nvis == sv.VisRows {
+		return false
 	}
+	sv.VisRows = nvis
+	if ints.MinInt(nvis, sv.BuiltSize) == len(sv.Values) {
+		return false
+	}
+	updt := sv.UpdateStart()
+	sv.ConfigVisRows()
+	gl := sv.GridLayout()
+	gl.Init2DTree()
+	gl.Style2DTree()
+	sv.UpdateEndNoSig(updt)
+	return true
+}
+
+// SliceGridVisRows returns the number of rows of the given SliceGrid grid
+// layout that fit within its allocated size, based on the average height of
+// its current rows, and that height (including spacing) -- returns 0 if
+// there are no rows yet
+func SliceGridVisRows(sg *gi.Frame) (int, float32) {
+	rows := sg.GridData[gi.Row]
+	if len(rows) == 0 || sg.LayData.AllocSize.Y <= 0 {
+		return 0, 0
+	}
+	sumht := float32(0)
+	for _, gd := range rows {
+		sumht += gd.SizePref
+	}
+	spc := sg.Spacing.Dots
+	rowHt := sumht/float32(len(rows)) + spc
+	if rowHt <= 0 {
+		return 0, 0
+	}
+	avail := sg.LayData.AllocSize.Y - 2.0*sg.Sty.BoxSpace()
+	if sg.HasScroll[gi.X] {
+		avail -= sg.Sty.Layout.ScrollBarWidth.Dots
+	}
+	return ints.MaxInt(int((avail+spc)/rowHt), 1), rowHt
+}
+
+// SliceStartIdxClamp returns given starting index for showing nrows rows of
+// a slice of given size, limited to the valid range
+func SliceStartIdxClamp(start, sz, nrows int) int {
+	start = ints.MinInt(start, sz-nrows)
+	return ints.MaxInt(start, 0)
+}
+
+// SliceStartIdxForRow returns the starting index for showing nrows rows of a
+// slice such that given row is visible, changing the current start as little
+// as possible
+func SliceStartIdxForRow(start, row, nrows int) int {
+	if row < start {
+		return row
+	}
+	if row >= start+nrows {
+		return ints.MaxInt(row-nrows+1, 0)
+	}
+	return start
 }
 
 // SetChanged sets the Changed flag and emits the ViewSig signal for the
@@ -448,11 +666,20 @@ func (sv *SliceView) Style2D() {
 	if sv.Viewport != nil && sv.Viewport.IsDoingFullRender() {
 		sv.UpdateFromSlice()
 	}
-	sg, _ := sv.SliceGrid()
-	sg.StartFocus() // need to call this when window is actually active
+	if sg, _ := sv.SliceGrid(); sg != nil {
+		sg.StartFocus() // need to call this when window is actually active
+	}
 	sv.Frame.Style2D()
 }
 
+func (sv *SliceView) Layout2D(parBBox image.Rectangle, iter int) bool {
+	redo := sv.Frame.Layout2D(parBBox, iter)
+	if iter == 0 && sv.UpdateVisRows() {
+		return true
+	}
+	return redo
+}
+
 func (sv *SliceView) Render2D() {
 	sv.ToolBar().UpdateActions()
 	if win := sv.ParentWindow(); win != nil {
@@ -469,9 +696,6 @@ func (sv *SliceView) Render2D() {
 		sv.RenderScrolls()
 		sv.Render2DChildren()
 		sv.PopBounds()
-		if sv.SelectedIdx > -1 {
-			sv.ScrollToRow(sv.SelectedIdx)
-		}
 	} else {
 		sv.DisconnectAllEvents(gi.AllPris)
 	}
@@ -510,20 +734,29 @@ func (sv *SliceView) RowFirstWidget(row int) (*gi.WidgetBase, bool) {
 	if sv.RowVal(row) == nil { // range check
 		return nil, false
 	}
+	if !sv.RowIsVisible(row) {
+		return nil, false
+	}
 	nWidgPerRow, _ := sv.RowWidgetNs()
 	sg, _ := sv.SliceGrid()
 	if sg == nil {
 		return nil, false
 	}
-	widg := sg.Kids[row*nWidgPerRow].(gi.Node2D).AsWidget()
+	widg := sg.Kids[(row-sv.StartIdx)*nWidgPerRow].(gi.Node2D).AsWidget()
 	return widg, true
 }
 
+// RowIsVisible returns true if given slice row is currently shown, i.e.,
+// has widgets in the SliceGrid
+func (sv *SliceView) RowIsVisible(row int) bool {
+	return row >= sv.StartIdx && row < sv.StartIdx+len(sv.Values)
+}
+
 // RowGrabFocus grabs the focus for the first focusable widget in given row --
 // returns that element or nil if not successful -- note: grid must have
-// already rendered for focus to be grabbed!
+// already rendered for focus to be grabbed, and row must be visible!
 func (sv *SliceView) RowGrabFocus(row int) *gi.WidgetBase {
-	if sv.RowVal(row) == nil || sv.inFocusGrab { // range check
+	if !sv.RowIsVisible(row) || sv.inFocusGrab { // range check
 		return nil
 	}
 	nWidgPerRow, idxOff := sv.RowWidgetNs()
@@ -531,7 +764,7 @@ func (sv *SliceView) RowGrabFocus(row int) *gi.WidgetBase {
 	if sg == nil {
 		return nil
 	}
-	ridx := nWidgPerRow * row
+	ridx := nWidgPerRow * (row - sv.StartIdx)
 	widg := sg.KnownChild(ridx + idxOff).(gi.Node2D).AsWidget()
 	if widg.HasFocus() {
 		return widg
@@ -557,8 +790,8 @@ func (sv *SliceView) RowPos(row int) image.Point {
 
 // RowFromPos returns the row that contains given vertical position, false if not found
 func (sv *SliceView) RowFromPos(posY int) (int, bool) {
-	// todo: could optimize search to approx loc, and search up / down from there
-	for rw := 0; rw < sv.BuiltSize; rw++ {
+	for i := range sv.Values {
+		rw := sv.StartIdx + i
 		widg, ok := sv.RowFirstWidget(rw)
 		if ok {
 			if widg.WinBBox.Min.Y < posY && posY < widg.WinBBox.Max.Y {
@@ -569,15 +802,33 @@ func (sv *SliceView) RowFromPos(posY int) (int, bool) {
 	return -1, false
 }
 
-// ScrollToRow ensures that given row is visible by scrolling layout as needed
-// -- returns true if any scrolling was performed
+// ScrollToRow ensures that given row is visible by scrolling the rows as
+// needed -- returns true if any scrolling was performed
 func (sv *SliceView) ScrollToRow(row int) bool {
 	row = ints.MinInt(row, sv.BuiltSize-1)
-	sg, _ := sv.SliceGrid()
-	if widg, ok := sv.RowFirstWidget(row); ok {
-		return sg.ScrollToItem(widg)
+	if row < 0 || sv.RowIsVisible(row) {
+		return false
+	}
+	sv.SetStartIdx(SliceStartIdxForRow(sv.StartIdx, row, len(sv.Values)))
+	return true
+}
+
+// ScrollRowsEvent scrolls the rows in response to the vertical component of
+// a mouse wheel event, which is in units of RowHeight
+func (sv *SliceView) ScrollRowsEvent(me *mouse.ScrollEvent) {
+	if me.Delta.Y == 0 || sv.RowHeight <= 0 || sv.BuiltSize <= len(sv.Values) {
+		return
+	}
+	del := int(float32(me.Delta.Y) / sv.RowHeight)
+	if del == 0 {
+		del = ints.MaxInt(ints.MinInt(me.Delta.Y, 1), -1)
+	}
+	sv.SetStartIdx(sv.StartIdx + del)
+	if me.Delta.X != 0 {
+		me.Delta.Y = 0 // let grid scroll horizontally
+	} else {
+		me.SetProcessed()
 	}
-	return false
 }
 
 // SelectVal sets SelVal and attempts to find corresponding row, setting
@@ -638,6 +889,7 @@ func (sv *SliceView) MoveDownAction(selMode mouse.SelectModes) int {
 	nrow := sv.MoveDown(selMode)
 	if nrow >= 0 {
 		sv.ScrollToRow(nrow)
+		sv.RowGrabFocus(nrow)
 		sv.WidgetSig.Emit(sv.This, int64(gi.WidgetSelected), nrow)
 	}
 	return nrow
@@ -667,6 +919,7 @@ func (sv *SliceView) MoveUpAction(selMode mouse.SelectModes) int {
 	nrow := sv.MoveUp(selMode)
 	if nrow >= 0 {
 		sv.ScrollToRow(nrow)
+		sv.RowGrabFocus(nrow)
 		sv.WidgetSig.Emit(sv.This, int64(gi.WidgetSelected), nrow)
 	}
 	return nrow
@@ -675,11 +928,16 @@ func (sv *SliceView) MoveUpAction(selMode mouse.SelectModes) int {
 //////////////////////////////////////////////////////////////////////////////
 //    Selection: user operates on the index labels
 
-// SelectRowWidgets sets the selection state of given row of widgets
+// SelectRowWidgets sets the selection state of given row of widgets, if
+// visible -- the state of other rows is set from SelectedRows when they are
+// scrolled into view
 func (sv *SliceView) SelectRowWidgets(idx int, sel bool) {
+	if !sv.RowIsVisible(idx) {
+		return
+	}
 	sg, _ := sv.SliceGrid()
 	nWidgPerRow, idxOff := sv.RowWidgetNs()
-	rowidx := idx * nWidgPerRow
+	rowidx := (idx - sv.StartIdx) * nWidgPerRow
 	if sv.ShowIndex {
 		if sg.Kids.IsValidIndex(rowidx) {
 			widg := sg.KnownChild(rowidx).(gi.Node2D).AsNode2D()
@@ -764,13 +1022,13 @@ func (sv *SliceView) UnselectRow(row int) {
 
 // UnselectAllRows unselects all selected rows
 func (sv *SliceView) UnselectAllRows() {
-	win := sv.Viewport.Win
+	win := sv.ParentWindow()
 	updt := false
 	if win != nil {
 		updt = win.UpdateStart()
 	}
-	for r, _ := range sv.SelectedRows {
-		sv.SelectRowWidgets(r, false)
+	for i := range sv.Values {
+		sv.SelectRowWidgets(sv.StartIdx+i, false)
 	}
 	sv.SelectedRows = make(map[int]struct{}, 10)
 	if win != nil {
@@ -780,7 +1038,7 @@ func (sv *SliceView) UnselectAllRows() {
 
 // SelectAllRows selects all rows
 func (sv *SliceView) SelectAllRows() {
-	win := sv.Viewport.Win
+	win := sv.ParentWindow()
 	updt := false
 	if win != nil {
 		updt = win.UpdateStart()
@@ -789,7 +1047,9 @@ func (sv *SliceView) SelectAllRows() {
 	sv.SelectedRows = make(map[int]struct{}, sv.BuiltSize)
 	for row := 0; row < sv.BuiltSize; row++ {
 		sv.SelectedRows[row] = struct{}{}
-		sv.SelectRowWidgets(row, true)
+	}
+	for i := range sv.Values {
+		sv.SelectRowWidgets(sv.StartIdx+i, true)
 	}
 	if win != nil {
 		win.UpdateEnd(updt)
@@ -1086,6 +1346,16 @@ func (sv *SliceView) DragNDropStart() {
 	}
 	rws := sv.SelectedRowsList(true) // descending sort
 	widg, ok := sv.RowFirstWidget(rws[0])
+	if !ok { // use any visible selected row, else first visible row
+		for _, r := range rws {
+			if widg, ok = sv.RowFirstWidget(r); ok {
+				break
+			}
+		}
+		if !ok {
+			widg, ok = sv.RowFirstWidget(sv.StartIdx)
+		}
+	}
 	if ok {
 		bi := &gi.Bitmap{}
 		bi.InitName(bi, sv.UniqueName())
@@ -1353,6 +1623,11 @@ func (sv *SliceView) KeyInputInactive(kt *key.ChordEvent) {
 }
 
 func (sv *SliceView) SliceViewEvents() {
+	sv.ConnectEvent(oswin.MouseScrollEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		me := d.(*mouse.ScrollEvent)
+		svv := recv.Embed(KiT_SliceView).(*SliceView)
+		svv.ScrollRowsEvent(me)
+	})
 	if sv.IsInactive() {
 		if sv.InactKeyNav {
 			sv.ConnectEvent(oswin.KeyChordEvent, gi.LowPri, func(recv, send ki.Ki, sig int64, d interface{}) {
@@ -0,0 +1,223 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+)
+
+// testSliceSize is the number of elements in the large benchmark slices
+const testSliceSize = 1000000
+
+type testTableRow struct {
+	Name  string
+	Value int
+	Score float32
+}
+
+// testTableSlice returns a slice of n rows with values in reverse order
+func testTableSlice(n int) []testTableRow {
+	sl := make([]testTableRow, n)
+	for i := range sl {
+		sl[i] = testTableRow{Name: fmt.Sprintf("row%07d", i), Value: n - i, Score: float32(i%1000) / 10}
+	}
+	return sl
+}
+
+// testApp starts the offscreen driver if no app is running yet, as the
+// views configure toolbar shortcuts that depend on the platform, and sets
+// the default prefs, for the fonts used in laying out labels
+func testApp() {
+	if oswin.TheApp == nil {
+		offscreen.Main(func(app oswin.App) {})
+	}
+	if gi.Prefs.LogicalDPIScale == 0 {
+		gi.Prefs.Defaults()
+		gi.Prefs.Apply()
+	}
+}
+
+// testSliceView returns a new SliceView showing given slice
+func testSliceView(sl interface{}) *SliceView {
+	testApp()
+	sv := &SliceView{}
+	sv.InitName(sv, "test")
+	sv.SetSlice(sl, nil)
+	return sv
+}
+
+// testTableView returns a new TableView showing given slice
+func testTableView(sl interface{}) *TableView {
+	testApp()
+	tv := &TableView{}
+	tv.InitName(tv, "test")
+	tv.SetSlice(sl, nil)
+	return tv
+}
+
+func TestSliceStartIdx(t *testing.T) {
+	tests := []struct {
+		start, row, nrows, sz, want int
+	}{
+		{0, 5, 10, 100, 0},
+		{0, 10, 10, 100, 1},
+		{50, 10, 10, 100, 10},
+		{50, 99, 10, 100, 90},
+		{95, 99, 10, 100, 90}, // clamped
+		{0, 3, 10, 5, 0},      // all rows fit
+	}
+	for _, tt := range tests {
+		st := SliceStartIdxClamp(SliceStartIdxForRow(tt.start, tt.row, tt.nrows), tt.sz, tt.nrows)
+		if st != tt.want {
+			t.Errorf("start %d row %d nrows %d sz %d: %d != %d", tt.start, tt.row, tt.nrows, tt.sz, st, tt.want)
+		}
+	}
+}
+
+func TestSliceViewVisRows(t *testing.T) {
+	sl := make([]int, testSliceSize)
+	sv := testSliceView(&sl)
+	if len(sv.Values) != SliceViewInitRows {
+		t.Fatalf("Values: %d != %d", len(sv.Values), SliceViewInitRows)
+	}
+	nWidgPerRow, _ := sv.RowWidgetNs()
+	sg, _ := sv.SliceGrid()
+	if len(sg.Kids) != nWidgPerRow*SliceViewInitRows {
+		t.Errorf("grid widgets: %d != %d", len(sg.Kids), nWidgPerRow*SliceViewInitRows)
+	}
+	sv.SetStartIdx(testSliceSize) // clamped
+	if sv.StartIdx != testSliceSize-SliceViewInitRows {
+		t.Errorf("StartIdx: %d", sv.StartIdx)
+	}
+	sv.SelectAllRows()
+	if len(sv.SelectedRowsList(false)) != testSliceSize {
+		t.Errorf("SelectedRows: %d != %d", len(sv.SelectedRows), testSliceSize)
+	}
+	sv.ScrollToRow(5)
+	if !sv.RowIsVisible(5) || sv.StartIdx != 5 {
+		t.Errorf("ScrollToRow: StartIdx %d", sv.StartIdx)
+	}
+	if _, ok := sv.RowFirstWidget(5 + SliceViewInitRows); ok {
+		t.Errorf("RowFirstWidget returned widget for row that is not visible")
+	}
+}
+
+func TestTableViewSortSelection(t *testing.T) {
+	sl := testTableSlice(100)
+	tv := testTableView(&sl)
+	tv.SelectRow(5)
+	tv.SelectRow(7)
+	tv.SelectedIdx = 7
+	names := map[string]bool{sl[5].Name: true, sl[7].Name: true}
+	selName := sl[7].Name
+	for _, desc := range []bool{false, true} {
+		tv.SortSliceAction(1) // Value, toggles descending the second time
+		if tv.SortDesc != desc {
+			t.Errorf("SortDesc: %v != %v", tv.SortDesc, desc)
+		}
+		for i := 1; i < len(sl); i++ {
+			if (sl[i-1].Value > sl[i].Value) != desc {
+				t.Fatalf("not sorted at %d: %d, %d", i, sl[i-1].Value, sl[i].Value)
+			}
+		}
+		rows := tv.SelectedRowsList(false)
+		if len(rows) != 2 || !names[sl[rows[0]].Name] || !names[sl[rows[1]].Name] {
+			t.Errorf("selected rows after sort: %v", rows)
+		}
+		if tv.SelectedIdx < 0 || sl[tv.SelectedIdx].Name != selName {
+			t.Errorf("SelectedIdx after sort: %d", tv.SelectedIdx)
+		}
+	}
+	if rows := tv.SelectedRowsList(false); !reflect.DeepEqual(rows, []int{5, 7}) {
+		t.Errorf("selected rows after sorting back: %v", rows)
+	}
+}
+
+func TestStructSliceSortPerm(t *testing.T) {
+	rows := []testTableRow{{"c", 2, 0}, {"a", 1, 0}, {"b", 2, 0}, {"d", 0, 0}}
+	ptrs := make([]*testTableRow, len(rows))
+	for i := range rows {
+		ptrs[i] = &rows[i]
+	}
+	orig := append([]testTableRow(nil), rows...)
+	perm := StructSliceSortPerm(&rows, []int{1}, true)
+	for ni, oi := range perm {
+		if rows[ni] != orig[oi] {
+			t.Errorf("value slice: element %d is %v, not old %d %v", ni, rows[ni], oi, orig[oi])
+		}
+	}
+	if rows[0].Name != "d" || rows[1].Name != "a" || rows[3].Value != 2 {
+		t.Errorf("value slice not sorted: %v", rows)
+	}
+	optrs := append([]*testTableRow(nil), ptrs...)
+	perm = StructSliceSortPerm(ptrs, []int{0}, false)
+	for ni, oi := range perm {
+		if ptrs[ni] != optrs[oi] {
+			t.Errorf("pointer slice: element %d is not old %d", ni, oi)
+		}
+	}
+	if ptrs[0].Name != "d" || ptrs[3].Name != "a" {
+		t.Errorf("pointer slice not sorted descending by name")
+	}
+}
+
+func BenchmarkSliceViewSetSlice(b *testing.B) {
+	sl := make([]int, testSliceSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		testSliceView(&sl)
+	}
+}
+
+func BenchmarkSliceViewScroll(b *testing.B) {
+	sl := make([]int, testSliceSize)
+	sv := testSliceView(&sl)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sv.SetStartIdx((i * 7919) % testSliceSize)
+	}
+}
+
+func BenchmarkSliceViewSelectAll(b *testing.B) {
+	sl := make([]int, testSliceSize)
+	sv := testSliceView(&sl)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sv.SelectAllRows()
+		sv.UnselectAllRows()
+	}
+}
+
+func BenchmarkTableViewSetSlice(b *testing.B) {
+	sl := testTableSlice(testSliceSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		testTableView(&sl)
+	}
+}
+
+func BenchmarkTableViewScroll(b *testing.B) {
+	sl := testTableSlice(testSliceSize)
+	tv := testTableView(&sl)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tv.SetStartIdx((i * 7919) % testSliceSize)
+	}
+}
+
+func BenchmarkTableViewSort(b *testing.B) {
+	sl := testTableSlice(testSliceSize)
+	tv := testTableView(&sl)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tv.SortSliceAction(i % tv.NVisFields)
+	}
+}
@@ -51,7 +51,7 @@ type TableView struct {
 	StyleFunc        TableViewStyleFunc `view:"-" json:"-" xml:"-" desc:"optional styling function"`
 	ShowViewCtxtMenu bool               `desc:"if the object we're viewing has its own CtxtMenu property defined, should we also still show the view's standard context menu?"`
 	Changed          bool               `desc:"has the table been edited?"`
-	Values           [][]ValueView      `json:"-" xml:"-" desc:"ValueView representations of the slice field values -- outer dimension is fields, inner is rows (generally more rows than fields, so this minimizes number of slices allocated)"`
+	Values           [][]ValueView      `json:"-" xml:"-" desc:"ValueView representations of the visible slice field values -- outer dimension is fields, inner is visible rows, where Values[fli][i] represents slice index StartIdx+i"`
 	ShowIndex        bool               `xml:"index" desc:"whether to show index or not (default true) -- updated from "index" property (bool)"`
 	InactKeyNav      bool               `xml:"inact-key-nav" desc:"support key navigation when inactive (default true) -- updated from "intact-key-nav" property (bool) -- no focus really plausible in inactive case, so it uses a low-pri capture of up / down events"`
 	SelField         string             `view:"-" json:"-" xml:"-" desc:"current selection field -- initially select value in this field"`
@@ -68,6 +68,9 @@ type TableView struct {
 	TmpSave      ValueView   `json:"-" xml:"-" desc:"value view that needs to have SaveTmp called on it whenever a change is made to one of the underlying values -- pass this down to any sub-views created from a parent"`
 	BuiltSlice   interface{} `view:"-" json:"-" xml:"-" desc:"the built slice"`
 	BuiltSize    int
+	StartIdx     int         `json:"-" xml:"-" desc:"slice index of the first visible row -- widgets are only built for the visible rows, and are re-used for other rows as the view is scrolled"`
+	VisRows      int         `json:"-" xml:"-" desc:"number of rows that fit within the SliceGrid, computed during layout -- number of rows of widgets built is the min of this and BuiltSize"`
+	RowHeight    float32     `json:"-" xml:"-" desc:"height of one row of widgets, including spacing, as measured during layout"`
 	ToolbarSlice interface{} `desc:"the slice that we successfully set a toolbar for"`
 	StruType     reflect.Type
 	NVisFields   int
@@ -183,6 +186,19 @@ func (tv *TableView) StructType() reflect.Type {
 	return tv.StruType
 }
 
+// AccessInfo returns the accessibility info for the table, named by the
+// struct type (unless it has a tooltip) -- the cells are the widgets
+// within it
+func (tv *TableView) AccessInfo() gi.AccessInfo {
+	ai := tv.Frame.AccessInfo()
+	ai.Role = gi.RoleTable
+	ai.Name = tv.Tooltip
+	if ai.Name == "" && tv.Slice != nil {
+		ai.Name = tv.StructType().Name()
+	}
+	return ai
+}
+
 // CacheVisFields computes the number of visible fields in nVisFields and
 // caches those to skip in fieldSkip
 func (tv *TableView) CacheVisFields() {
@@ -237,14 +253,34 @@ func (tv *TableView) SliceFrame() (*gi.Frame, int) {
 	return tv.KnownChild(idx).(*gi.Frame), idx
 }
 
-// SliceGrid returns the SliceGrid grid frame widget, which contains all the
-// fields and values, within SliceFrame
-func (tv *TableView) SliceGrid() *gi.Frame {
+// GridLayout returns the layout containing the SliceGrid and its ScrollBar,
+// within SliceFrame
+func (tv *TableView) GridLayout() *gi.Layout {
 	sf, _ := tv.SliceFrame()
 	if sf == nil {
 		return nil
 	}
-	return sf.KnownChild(1).(*gi.Frame)
+	return sf.KnownChild(1).(*gi.Layout)
+}
+
+// SliceGrid returns the SliceGrid grid frame widget, which contains all the
+// fields and values, within GridLayout
+func (tv *TableView) SliceGrid() *gi.Frame {
+	gl := tv.GridLayout()
+	if gl == nil {
+		return nil
+	}
+	return gl.KnownChild(0).(*gi.Frame)
+}
+
+// ScrollBar returns the vertical scrollbar that scrolls the rows of the
+// SliceGrid through the slice, within GridLayout
+func (tv *TableView) ScrollBar() *gi.ScrollBar {
+	gl := tv.GridLayout()
+	if gl == nil {
+		return nil
+	}
+	return gl.KnownChild(1).(*gi.ScrollBar)
 }
 
 // SliceHeader returns the Toolbar header for slice grid
@@ -269,7 +305,15 @@ func (tv *TableView) ToolBar() *gi.ToolBar {
 func (tv *TableView) StdSliceFrameConfig() kit.TypeAndNameList {
 	config := kit.TypeAndNameList{}
 	config.Add(gi.KiT_ToolBar, "header")
+	config.Add(gi.KiT_Layout, "grid-lay")
+	return config
+}
+
+// StdGridLayoutConfig returns a TypeAndNameList for configuring the grid-lay
+func (tv *TableView) StdGridLayoutConfig() kit.TypeAndNameList {
+	config := kit.TypeAndNameList{}
 	config.Add(gi.KiT_Frame, "grid")
+	config.Add(gi.KiT_ScrollBar, "scrollbar")
 	return config
 }
 
@@ -306,12 +350,6 @@ func (tv *TableView) ConfigSliceGrid(forceUpdt bool) {
 
 	nWidgPerRow, idxOff := tv.RowWidgetNs()
 
-	// always start fresh!
-	tv.Values = make([][]ValueView, tv.NVisFields)
-	for fli := 0; fli < tv.NVisFields; fli++ {
-		tv.Values[fli] = make([]ValueView, sz)
-	}
-
 	sg, _ := tv.SliceFrame()
 	if sg == nil {
 		return
@@ -321,9 +359,9 @@ func (tv *TableView) ConfigSliceGrid(forceUpdt bool) {
 	sg.SetStretchMaxHeight() // for this to work, ALL layers above need it too
 	sg.SetStretchMaxWidth()  // for this to work, ALL layers above need it too
 
-	if sz > TableViewWaitCursorSize {
-		oswin.TheApp.Cursor(tv.Viewport.Win.OSWin).Push(cursor.Wait)
-		defer oswin.TheApp.Cursor(tv.Viewport.Win.OSWin).Pop()
+	if win := tv.ParentWindow(); win != nil && sz > TableViewWaitCursorSize {
+		oswin.TheApp.Cursor(win.OSWin).Push(cursor.Wait)
+		defer oswin.TheApp.Cursor(win.OSWin).Pop()
 	}
 
 	sgcfg := tv.StdSliceFrameConfig()
@@ -340,6 +378,12 @@ func (tv *TableView) ConfigSliceGrid(forceUpdt bool) {
 	sgh.SetProp("spacing", 0)
 	// sgh.SetStretchMaxWidth()
 
+	gl := tv.GridLayout()
+	gl.Lay = gi.LayoutHoriz
+	gl.SetStretchMaxHeight()
+	gl.SetStretchMaxWidth()
+	_, updtgl := gl.ConfigChildren(tv.StdGridLayoutConfig(), false)
+
 	sgf := tv.SliceGrid()
 	sgf.Lay = gi.LayoutGrid
 	sgf.Stripes = gi.RowStripes
@@ -408,32 +452,102 @@ func (tv *TableView) ConfigSliceGrid(forceUpdt bool) {
 		lbl.Tooltip = "delete row"
 	}
 
-	sgf.DeleteChildren(true)
-	sgf.Kids = make(ki.Slice, nWidgPerRow*sz)
-
 	if tv.SortIdx >= 0 {
-		rawIdx := tv.VisFields[tv.SortIdx].Index
-		kit.StructSliceSort(tv.Slice, rawIdx, !tv.SortDesc)
+		tv.SortSlice()
 	}
-	tv.ConfigSliceGridRows()
+	if tv.SelField != "" && tv.SelVal != nil {
+		tv.SelectedIdx, _ = StructSliceRowByValue(tv.Slice, tv.SelField, tv.SelVal)
+	}
+	if tv.VisRows == 0 {
+		tv.VisRows = SliceViewInitRows
+	}
+	if tv.SelectedIdx >= 0 {
+		if tv.IsInactive() {
+			tv.SelectedRows[tv.SelectedIdx] = true
+		}
+		tv.StartIdx = SliceStartIdxForRow(tv.StartIdx, tv.SelectedIdx, ints.MinInt(tv.VisRows, sz))
+	}
+	tv.ConfigVisRows()
 
 	sg.SetFullReRender()
+	gl.UpdateEnd(updtgl)
 	sgh.UpdateEnd(updth)
 	sg.UpdateEnd(updtg)
 }
 
-// ConfigSliceGridRows configures the SliceGrid rows for the current slice --
-// assumes .Kids is created at the right size -- only call this for a direct
-// re-render e.g., after sorting
+// ConfigVisRows creates the Values and SliceGrid widgets for the number of
+// visible rows (min of VisRows and BuiltSize), and configures them to show
+// the slice starting at StartIdx -- if the selected row was visible, it is
+// kept visible
+func (tv *TableView) ConfigVisRows() {
+	sgf := tv.SliceGrid()
+	if sgf == nil {
+		return
+	}
+	selVis := tv.SelectedIdx >= 0 && tv.RowIsVisible(tv.SelectedIdx)
+	nrows := ints.MinInt(tv.VisRows, tv.BuiltSize)
+	if selVis {
+		tv.StartIdx = SliceStartIdxForRow(tv.StartIdx, tv.SelectedIdx, nrows)
+	}
+	tv.StartIdx = SliceStartIdxClamp(tv.StartIdx, tv.BuiltSize, nrows)
+
+	nWidgPerRow, _ := tv.RowWidgetNs()
+	tv.Values = make([][]ValueView, tv.NVisFields)
+	for fli := 0; fli < tv.NVisFields; fli++ {
+		tv.Values[fli] = make([]ValueView, nrows)
+	}
+	sgf.DeleteChildren(true)
+	sgf.Kids = make(ki.Slice, nWidgPerRow*nrows)
+
+	tv.ConfigScrollBar()
+	tv.ConfigSliceGridRows()
+}
+
+// NRows returns the number of visible rows that have widgets
+func (tv *TableView) NRows() int {
+	if len(tv.Values) == 0 {
+		return 0
+	}
+	return len(tv.Values[0])
+}
+
+// ConfigScrollBar configures the ScrollBar to scroll through the rows of the
+// slice, in units of rows
+func (tv *TableView) ConfigScrollBar() {
+	sb := tv.ScrollBar()
+	if sb == nil {
+		return
+	}
+	nrows := tv.NRows()
+	sb.Defaults()
+	sb.Dim = gi.Y
+	sb.Tracking = true
+	sb.Min = 0
+	sb.Max = float32(tv.BuiltSize)
+	sb.Step = 1
+	sb.PageStep = float32(ints.MaxInt(nrows-1, 1))
+	sb.TrackThr = 1
+	sb.ThumbVal = float32(nrows)
+	sb.Value = float32(tv.StartIdx)
+	sb.SetFixedWidth(units.NewValue(16, units.Px)) // default layout scrollbar-width
+	sb.SetMinPrefHeight(units.NewValue(10, units.Em))
+	sb.SetStretchMaxHeight()
+	sb.SliderSig.ConnectOnly(tv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig != int64(gi.SliderValueChanged) {
+			return
+		}
+		tvv := recv.Embed(KiT_TableView).(*TableView)
+		tvv.SetStartIdx(int(data.(float32)))
+	})
+}
+
+// ConfigSliceGridRows configures the SliceGrid rows to show the slice
+// starting at StartIdx -- assumes .Kids and Values are created for the
+// visible rows, and re-uses any existing widgets -- call this for a direct
+// re-render e.g., after sorting or scrolling
 func (tv *TableView) ConfigSliceGridRows() {
 	mv := reflect.ValueOf(tv.Slice)
 	mvnp := kit.NonPtrValue(mv)
-	sz := mvnp.Len()
-
-	if sz > TableViewWaitCursorSize {
-		oswin.TheApp.Cursor(tv.Viewport.Win.OSWin).Push(cursor.Wait)
-		defer oswin.TheApp.Cursor(tv.Viewport.Win.OSWin).Pop()
-	}
 
 	nWidgPerRow, idxOff := tv.RowWidgetNs()
 	sgf := tv.SliceGrid()
@@ -441,12 +555,19 @@ func (tv *TableView) ConfigSliceGridRows() {
 	updt := sgf.UpdateStart()
 	defer sgf.UpdateEnd(updt)
 
-	for i := 0; i < sz; i++ {
+	nrows := tv.NRows()
+	for i := 0; i < nrows; i++ {
+		si := tv.StartIdx + i
 		ridx := i * nWidgPerRow
-		val := kit.OnePtrValue(mvnp.Index(i)) // deal with pointer lists
+		val := kit.OnePtrValue(mvnp.Index(si)) // deal with pointer lists
 		stru := val.Interface()
-		idxtxt := fmt.Sprintf("%05d", i)
-		labnm := fmt.Sprintf("index-%v", idxtxt)
+		idxtxt := fmt.Sprintf("%05d", si)
+		rowtxt := fmt.Sprintf("%05d", i)
+		labnm := fmt.Sprintf("index-%v", rowtxt)
+		sel := tv.RowIsSelected(si)
+		if tv.IsInactive() {
+			sel = si == tv.SelectedIdx
+		}
 		if tv.ShowIndex {
 			var idxlab *gi.Label
 			if sgf.Kids[ridx] != nil {
@@ -454,18 +575,19 @@ func (tv *TableView) ConfigSliceGridRows() {
 			} else {
 				idxlab = &gi.Label{}
 				sgf.SetChild(idxlab, ridx, labnm)
+				idxlab.WidgetSig.ConnectOnly(tv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+					if sig == int64(gi.WidgetSelected) {
+						wbb := send.(gi.Node2D).AsWidget()
+						idx := wbb.KnownProp("tv-index").(int)
+						tvv := recv.Embed(KiT_TableView).(*TableView)
+						tvv.UpdateSelect(idx, wbb.IsSelected())
+					}
+				})
 			}
 			idxlab.Text = idxtxt
-			idxlab.SetProp("tv-index", i)
+			idxlab.SetProp("tv-index", si)
 			idxlab.Selectable = true
-			idxlab.WidgetSig.ConnectOnly(tv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
-				if sig == int64(gi.WidgetSelected) {
-					wbb := send.(gi.Node2D).AsWidget()
-					idx := wbb.KnownProp("tv-index").(int)
-					tvv := recv.Embed(KiT_TableView).(*TableView)
-					tvv.UpdateSelect(idx, wbb.IsSelected())
-				}
-			})
+			idxlab.SetSelectedState(sel)
 		}
 
 		for fli := 0; fli < tv.NVisFields; fli++ {
@@ -476,22 +598,27 @@ func (tv *TableView) ConfigSliceGridRows() {
 				continue
 			}
 			vv.SetStructValue(fval.Addr(), stru, &field, tv.TmpSave)
+			tv.Values[fli][i] = vv
 			vtyp := vv.WidgetType()
-			valnm := fmt.Sprintf("value-%v.%v", fli, idxtxt)
+			valnm := fmt.Sprintf("value-%v.%v", fli, rowtxt)
 			cidx := ridx + idxOff + fli
 			var widg gi.Node2D
-			if sgf.Kids[cidx] != nil {
+			if sgf.Kids[cidx] != nil && reflect.TypeOf(sgf.Kids[cidx]).Elem() == vtyp {
 				widg = sgf.Kids[cidx].(gi.Node2D)
 			} else {
-				tv.Values[fli][i] = vv
-				widg = ki.NewOfType(vtyp).(gi.Node2D)
-				sgf.SetChild(widg, cidx, valnm)
+				if sgf.Kids[cidx] != nil { // different type of value in this row now
+					sgf.DeleteChildAtIndex(cidx, true)
+					widg = sgf.InsertNewChild(vtyp, cidx, valnm).(gi.Node2D)
+				} else {
+					widg = ki.NewOfType(vtyp).(gi.Node2D)
+					sgf.SetChild(widg, cidx, valnm)
+				}
 			}
 			vv.ConfigWidget(widg)
 			wb := widg.AsWidget()
 			if wb != nil {
-				wb.SetProp("tv-index", i)
-				wb.ClearSelected()
+				wb.SetProp("tv-index", si)
+				wb.SetSelectedState(sel)
 				wb.WidgetSig.ConnectOnly(tv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 					if sig == int64(gi.WidgetSelected) || sig == int64(gi.WidgetFocused) {
 						wbb := send.(gi.Node2D).AsWidget()
@@ -512,17 +639,23 @@ func (tv *TableView) ConfigSliceGridRows() {
 						tvv, _ := recv.Embed(KiT_TableView).(*TableView)
 						tvv.SetChanged()
 					})
-
-				addnm := fmt.Sprintf("add-%v", idxtxt)
-				delnm := fmt.Sprintf("del-%v", idxtxt)
+			}
+			if tv.StyleFunc != nil {
+				tv.StyleFunc(tv, mvnp.Interface(), widg, si, fli, vv)
+			}
+		}
+		if !tv.IsInactive() {
+			aidx := ridx + idxOff + tv.NVisFields
+			if sgf.Kids[aidx] == nil {
+				addnm := fmt.Sprintf("add-%v", rowtxt)
+				delnm := fmt.Sprintf("del-%v", rowtxt)
 				addact := gi.Action{}
 				delact := gi.Action{}
-				sgf.SetChild(&addact, ridx+1+tv.NVisFields, addnm)
-				sgf.SetChild(&delact, ridx+1+tv.NVisFields+1, delnm)
+				sgf.SetChild(&addact, aidx, addnm)
+				sgf.SetChild(&delact, aidx+1, delnm)
 
 				addact.SetIcon("plus")
 				addact.Tooltip = "insert a new element at this index"
-				addact.Data = i
 				addact.ActionSig.ConnectOnly(tv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 					act := send.(*gi.Action)
 					tvv := recv.Embed(KiT_TableView).(*TableView)
@@ -530,24 +663,64 @@ func (tv *TableView) ConfigSliceGridRows() {
 				})
 				delact.SetIcon("minus")
 				delact.Tooltip = "delete this element"
-				delact.Data = i
 				delact.ActionSig.ConnectOnly(tv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 					act := send.(*gi.Action)
 					tvv := recv.Embed(KiT_TableView).(*TableView)
 					tvv.SliceDelete(act.Data.(int), true)
 				})
 			}
-			if tv.StyleFunc != nil {
-				tv.StyleFunc(tv, mvnp.Interface(), widg, i, fli, vv)
-			}
+			sgf.Kids[aidx].(*gi.Action).Data = si
+			sgf.Kids[aidx+1].(*gi.Action).Data = si
 		}
 	}
-	if tv.SelField != "" && tv.SelVal != nil {
-		tv.SelectedIdx, _ = StructSliceRowByValue(tv.Slice, tv.SelField, tv.SelVal)
+}
+
+// SetStartIdx sets the slice index of the first visible row, keeping it
+// within range, and re-configures the row widgets to show the slice from
+// there -- this is how the view scrolls
+func (tv *TableView) SetStartIdx(idx int) {
+	idx = SliceStartIdxClamp(idx, tv.BuiltSize, tv.NRows())
+	if idx == tv.StartIdx {
+		return
 	}
-	if tv.IsInactive() && tv.SelectedIdx >= 0 {
-		tv.SelectRow(tv.SelectedIdx)
+	tv.StartIdx = idx
+	if sb := tv.ScrollBar(); sb != nil && int(sb.Value) != idx {
+		sb.SetValue(float32(idx))
 	}
+	updt := tv.UpdateStart()
+	tv.SetFullReRender() // header is sized in our Layout2D
+	tv.ConfigSliceGridRows()
+	tv.UpdateEnd(updt)
+}
+
+// UpdateVisRows computes the number of rows that fit within the allocated
+// height of the SliceGrid, and re-builds the row widgets if that changes the
+// number of rows shown -- returns true if so, in which case the layout must
+// be redone -- called during Layout2D
+func (tv *TableView) UpdateVisRows() bool {
+	sgf := tv.SliceGrid()
+	if sgf == nil {
+		return false
+	}
+	nvis, rowHt := SliceGridVisRows(sgf)
+	if nvis == 0 {
+		return false
+	}
+	tv.RowHeight = rowHt
+	if nvis == tv.VisRows {
+		return false
+	}
+	tv.VisRows = nvis
+	if ints.MinInt(nvis, tv.BuiltSize) == tv.NRows() {
+		return false
+	}
+	updt := tv.UpdateStart()
+	tv.ConfigVisRows()
+	gl := tv.GridLayout()
+	gl.Init2DTree()
+	gl.Style2DTree()
+	tv.UpdateEndNoSig(updt)
+	return true
 }
 
 // SetChanged sets the Changed flag and emits the ViewSig signal for the
@@ -602,8 +775,10 @@ func (tv *TableView) SliceDelete(idx int, reconfig bool) {
 // SortSliceAction sorts the slice for given field index -- toggles ascending
 // vs. descending if already sorting on this dimension
 func (tv *TableView) SortSliceAction(fldIdx int) {
-	oswin.TheApp.Cursor(tv.Viewport.Win.OSWin).Push(cursor.Wait)
-	defer oswin.TheApp.Cursor(tv.Viewport.Win.OSWin).Pop()
+	if win := tv.ParentWindow(); win != nil {
+		oswin.TheApp.Cursor(win.OSWin).Push(cursor.Wait)
+		defer oswin.TheApp.Cursor(win.OSWin).Pop()
+	}
 
 	sgh := tv.SliceHeader()
 	sgh.SetFullReRender()
@@ -634,15 +809,79 @@ func (tv *TableView) SortSliceAction(fldIdx int) {
 	}
 
 	tv.SortIdx = fldIdx
-	rawIdx := tv.VisFields[fldIdx].Index
 
 	sgf := tv.SliceGrid()
 	sgf.SetFullReRender()
 
-	kit.StructSliceSort(tv.Slice, rawIdx, !tv.SortDesc)
+	tv.SortSlice()
 	tv.ConfigSliceGridRows()
 }
 
+// SortSlice sorts the slice on the SortIdx field, in SortDesc order, keeping
+// the selected rows on the same elements, which generally move
+func (tv *TableView) SortSlice() {
+	if tv.SortIdx < 0 || tv.SortIdx >= len(tv.VisFields) {
+		return
+	}
+	rawIdx := tv.VisFields[tv.SortIdx].Index
+	perm := StructSliceSortPerm(tv.Slice, rawIdx, !tv.SortDesc)
+	newIdx := make([]int, len(perm))
+	for ni, oi := range perm {
+		newIdx[oi] = ni
+	}
+	if tv.SelectedIdx >= 0 && tv.SelectedIdx < len(newIdx) {
+		tv.SelectedIdx = newIdx[tv.SelectedIdx]
+	}
+	sel := make(map[int]bool, len(tv.SelectedRows))
+	for r := range tv.SelectedRows {
+		if r >= 0 && r < len(newIdx) {
+			sel[newIdx[r]] = true
+		}
+	}
+	tv.SelectedRows = sel
+}
+
+// StructSliceSortPerm sorts a slice of structs (or pointers to them) on
+// given field, in the same way as kit.StructSliceSort, and returns the
+// permutation that was applied: for each new index, the old index of the
+// element now there
+func StructSliceSortPerm(struSlice interface{}, fldIdx []int, ascending bool) []int {
+	svnp := kit.NonPtrValue(reflect.ValueOf(struSlice))
+	sz := svnp.Len()
+	isPtr := svnp.Type().Elem().Kind() == reflect.Ptr
+	ptyp := svnp.Type().Elem()
+	if !isPtr {
+		ptyp = reflect.PtrTo(ptyp)
+	}
+	// sort pointers to the elements, whose addresses give their old index
+	ptrs := reflect.MakeSlice(reflect.SliceOf(ptyp), sz, sz)
+	oldIdx := make(map[uintptr][]int, sz)
+	for i := 0; i < sz; i++ {
+		p := svnp.Index(i)
+		if !isPtr {
+			p = p.Addr()
+		}
+		ptrs.Index(i).Set(p)
+		oldIdx[p.Pointer()] = append(oldIdx[p.Pointer()], i)
+	}
+	kit.StructSliceSort(ptrs.Interface(), fldIdx, ascending)
+	perm := make([]int, sz)
+	vals := reflect.MakeSlice(svnp.Type(), sz, sz)
+	for i := 0; i < sz; i++ {
+		p := ptrs.Index(i)
+		ois := oldIdx[p.Pointer()]
+		perm[i] = ois[0]
+		oldIdx[p.Pointer()] = ois[1:]
+		if isPtr {
+			vals.Index(i).Set(p)
+		} else {
+			vals.Index(i).Set(p.Elem())
+		}
+	}
+	reflect.Copy(svnp, vals)
+	return perm
+}
+
 // ConfigToolbar configures the toolbar actions
 func (tv *TableView) ConfigToolbar() {
 	if kit.IfaceIsNil(tv.Slice) || tv.IsInactive() {
@@ -751,6 +990,9 @@ func (tv *TableView) Layout2D(parBBox image.Rectangle, iter int) bool {
 		sgh.SetMinPrefWidth(units.NewValue(sumwd, units.Dot))
 		sgh.Layout2D(parBBox, iter)
 	}
+	if iter == 0 && tv.UpdateVisRows() {
+		return true
+	}
 	return redo
 }
 
@@ -770,9 +1012,6 @@ func (tv *TableView) Render2D() {
 		tv.RenderScrolls()
 		tv.Render2DChildren()
 		tv.PopBounds()
-		if tv.SelectedIdx > -1 {
-			tv.ScrollToRow(tv.SelectedIdx)
-		}
 	} else {
 		tv.DisconnectAllEvents(gi.AllPris)
 	}
@@ -808,33 +1047,45 @@ func (tv *TableView) RowFirstWidget(row int) (*gi.WidgetBase, bool) {
 	if tv.RowStruct(row) == nil { // range check
 		return nil, false
 	}
+	if !tv.RowIsVisible(row) {
+		return nil, false
+	}
 	nWidgPerRow, _ := tv.RowWidgetNs()
 	sg, _ := tv.SliceFrame()
 	if sg == nil {
 		return nil, false
 	}
 	sgf := tv.SliceGrid()
-	widg := sgf.Kids[row*nWidgPerRow].(gi.Node2D).AsWidget()
+	widg := sgf.Kids[(row-tv.StartIdx)*nWidgPerRow].(gi.Node2D).AsWidget()
 	return widg, true
 }
 
+// RowIsVisible returns true if given slice row is currently shown, i.e.,
+// has widgets in the SliceGrid
+func (tv *TableView) RowIsVisible(row int) bool {
+	return row >= tv.StartIdx && row < tv.StartIdx+tv.NRows()
+}
+
 // RowFirstVisWidget returns the first visible widget for given row (could be
 // index or not) -- false if out of range
 func (tv *TableView) RowFirstVisWidget(row int) (*gi.WidgetBase, bool) {
 	if tv.RowStruct(row) == nil { // range check
 		return nil, false
 	}
+	if !tv.RowIsVisible(row) {
+		return nil, false
+	}
 	nWidgPerRow, idxOff := tv.RowWidgetNs()
 	sg, _ := tv.SliceFrame()
 	if sg == nil {
 		return nil, false
 	}
 	sgf := tv.SliceGrid()
-	widg := sgf.Kids[row*nWidgPerRow].(gi.Node2D).AsWidget()
+	ridx := nWidgPerRow * (row - tv.StartIdx)
+	widg := sgf.Kids[ridx].(gi.Node2D).AsWidget()
 	if widg.VpBBox != image.ZR {
 		return widg, true
 	}
-	ridx := nWidgPerRow * row
 	for fli := 0; fli < tv.NVisFields; fli++ {
 		widg := sgf.KnownChild(ridx + idxOff + fli).(gi.Node2D).AsWidget()
 		if widg.VpBBox != image.ZR {
@@ -846,9 +1097,9 @@ func (tv *TableView) RowFirstVisWidget(row int) (*gi.WidgetBase, bool) {
 
 // RowGrabFocus grabs the focus for the first focusable widget in given row --
 // returns that element or nil if not successful -- note: grid must have
-// already rendered for focus to be grabbed!
+// already rendered for focus to be grabbed, and row must be visible!
 func (tv *TableView) RowGrabFocus(row int) *gi.WidgetBase {
-	if tv.RowStruct(row) == nil || tv.inFocusGrab { // range check
+	if !tv.RowIsVisible(row) || tv.inFocusGrab { // range check
 		return nil
 	}
 	// fmt.Printf("grab row focus: %v\n", row)
@@ -857,7 +1108,7 @@ func (tv *TableView) RowGrabFocus(row int) *gi.WidgetBase {
 	if sg == nil {
 		return nil
 	}
-	ridx := nWidgPerRow * row
+	ridx := nWidgPerRow * (row - tv.StartIdx)
 	sgf := tv.SliceGrid()
 	// first check if we already have focus
 	for fli := 0; fli < tv.NVisFields; fli++ {
@@ -890,8 +1141,9 @@ func (tv *TableView) RowPos(row int) image.Point {
 
 // RowFromPos returns the row that contains given vertical position, false if not found
 func (tv *TableView) RowFromPos(posY int) (int, bool) {
-	// todo: could optimize search to approx loc, and search up / down from there
-	for rw := 0; rw < tv.BuiltSize; rw++ {
+	nrows := tv.NRows()
+	for i := 0; i < nrows; i++ {
+		rw := tv.StartIdx + i
 		widg, ok := tv.RowFirstWidget(rw)
 		if ok {
 			if widg.ObjBBox.Min.Y < posY && posY < widg.ObjBBox.Max.Y {
@@ -902,15 +1154,33 @@ func (tv *TableView) RowFromPos(posY int) (int, bool) {
 	return -1, false
 }
 
-// ScrollToRow ensures that given row is visible by scrolling layout as needed
-// -- returns true if any scrolling was performed
+// ScrollToRow ensures that given row is visible by scrolling the rows as
+// needed -- returns true if any scrolling was performed
 func (tv *TableView) ScrollToRow(row int) bool {
 	row = ints.MinInt(row, tv.BuiltSize-1)
-	sgf := tv.SliceGrid()
-	if widg, ok := tv.RowFirstWidget(row); ok {
-		return sgf.ScrollToItem(widg)
+	if row < 0 || tv.RowIsVisible(row) {
+		return false
+	}
+	tv.SetStartIdx(SliceStartIdxForRow(tv.StartIdx, row, tv.NRows()))
+	return true
+}
+
+// ScrollRowsEvent scrolls the rows in response to the vertical component of
+// a mouse wheel event, which is in units of RowHeight
+func (tv *TableView) ScrollRowsEvent(me *mouse.ScrollEvent) {
+	if me.Delta.Y == 0 || tv.RowHeight <= 0 || tv.BuiltSize <= tv.NRows() {
+		return
+	}
+	del := int(float32(me.Delta.Y) / tv.RowHeight)
+	if del == 0 {
+		del = ints.MaxInt(ints.MinInt(me.Delta.Y, 1), -1)
+	}
+	tv.SetStartIdx(tv.StartIdx + del)
+	if me.Delta.X != 0 {
+		me.Delta.Y = 0 // let grid scroll horizontally
+	} else {
+		me.SetProcessed()
 	}
-	return false
 }
 
 // SelectFieldVal sets SelField and SelVal and attempts to find corresponding
@@ -981,6 +1251,7 @@ func (tv *TableView) MoveDownAction(selMode mouse.SelectModes) int {
 	nrow := tv.MoveDown(selMode)
 	if nrow >= 0 {
 		tv.ScrollToRow(nrow)
+		tv.RowGrabFocus(nrow)
 		tv.WidgetSig.Emit(tv.This, int64(gi.WidgetSelected), nrow)
 	}
 	return nrow
@@ -1010,6 +1281,7 @@ func (tv *TableView) MoveUpAction(selMode mouse.SelectModes) int {
 	nrow := tv.MoveUp(selMode)
 	if nrow >= 0 {
 		tv.ScrollToRow(nrow)
+		tv.RowGrabFocus(nrow)
 		tv.WidgetSig.Emit(tv.This, int64(gi.WidgetSelected), nrow)
 	}
 	return nrow
@@ -1018,9 +1290,11 @@ func (tv *TableView) MoveUpAction(selMode mouse.SelectModes) int {
 //////////////////////////////////////////////////////////////////////////////
 //    Selection: user operates on the index labels
 
-// SelectRowWidgets sets the selection state of given row of widgets
+// SelectRowWidgets sets the selection state of given row of widgets, if
+// visible -- the state of other rows is set from SelectedRows when they are
+// scrolled into view
 func (tv *TableView) SelectRowWidgets(idx int, sel bool) {
-	if idx < 0 {
+	if !tv.RowIsVisible(idx) {
 		return
 	}
 	var win *gi.Window
@@ -1033,7 +1307,7 @@ func (tv *TableView) SelectRowWidgets(idx int, sel bool) {
 	}
 	sgf := tv.SliceGrid()
 	nWidgPerRow, idxOff := tv.RowWidgetNs()
-	ridx := idx * nWidgPerRow
+	ridx := (idx - tv.StartIdx) * nWidgPerRow
 	for fli := 0; fli < tv.NVisFields; fli++ {
 		seldx := ridx + idxOff + fli
 		if sgf.Kids.IsValidIndex(seldx) {
@@ -1125,13 +1399,14 @@ func (tv *TableView) UnselectRow(row int) {
 
 // UnselectAllRows unselects all selected rows
 func (tv *TableView) UnselectAllRows() {
-	win := tv.Viewport.Win
+	win := tv.ParentWindow()
 	updt := false
 	if win != nil {
 		updt = win.UpdateStart()
 	}
-	for r, _ := range tv.SelectedRows {
-		tv.SelectRowWidgets(r, false)
+	nrows := tv.NRows()
+	for i := 0; i < nrows; i++ {
+		tv.SelectRowWidgets(tv.StartIdx+i, false)
 	}
 	tv.SelectedRows = make(map[int]bool, 10)
 	if win != nil {
@@ -1141,7 +1416,7 @@ func (tv *TableView) UnselectAllRows() {
 
 // SelectAllRows selects all rows
 func (tv *TableView) SelectAllRows() {
-	win := tv.Viewport.Win
+	win := tv.ParentWindow()
 	updt := false
 	if win != nil {
 		updt = win.UpdateStart()
@@ -1150,7 +1425,10 @@ func (tv *TableView) SelectAllRows() {
 	tv.SelectedRows = make(map[int]bool, tv.BuiltSize)
 	for row := 0; row < tv.BuiltSize; row++ {
 		tv.SelectedRows[row] = true
-		tv.SelectRowWidgets(row, true)
+	}
+	nrows := tv.NRows()
+	for i := 0; i < nrows; i++ {
+		tv.SelectRowWidgets(tv.StartIdx+i, true)
 	}
 	if win != nil {
 		win.UpdateEnd(updt)
@@ -1447,6 +1725,16 @@ func (tv *TableView) DragNDropStart() {
 	}
 	rws := tv.SelectedRowsList(true) // descending sort
 	widg, ok := tv.RowFirstVisWidget(rws[0])
+	if !ok { // use any visible selected row, else first visible row
+		for _, r := range rws {
+			if widg, ok = tv.RowFirstVisWidget(r); ok {
+				break
+			}
+		}
+		if !ok {
+			widg, ok = tv.RowFirstVisWidget(tv.StartIdx)
+		}
+	}
 	if ok {
 		bi := &gi.Bitmap{}
 		bi.InitName(bi, tv.UniqueName())
@@ -1717,6 +2005,11 @@ func (tv *TableView) KeyInputInactive(kt *key.ChordEvent) {
 }
 
 func (tv *TableView) TableViewEvents() {
+	tv.ConnectEvent(oswin.MouseScrollEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		me := d.(*mouse.ScrollEvent)
+		tvv := recv.Embed(KiT_TableView).(*TableView)
+		tvv.ScrollRowsEvent(me)
+	})
 	if tv.IsInactive() {
 		if tv.InactKeyNav {
 			tv.ConnectEvent(oswin.KeyChordEvent, gi.LowPri, func(recv, send ki.Ki, sig int64, d interface{}) {
@@ -5,6 +5,7 @@
 package giv
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io/ioutil"
@@ -14,6 +15,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/alecthomas/chroma/lexers"
 	"github.com/goki/gi"
@@ -24,8 +26,9 @@ import (
 )
 
 // TextBuf is a buffer of text, which can be viewed by TextView(s).  It holds
-// the raw text lines (in original string and rune formats, and marked-up from
-// syntax highlighting), and sends signals for making edits to the text and
+// the raw text lines (in original bytes and rune formats, and marked-up from
+// syntax highlighting) in a LineRope, which keeps edits and line lookups fast
+// for large files, and sends signals for making edits to the text and
 // coordinating those edits across multiple views.  Views always only view a
 // single buffer, so they directly call methods on the buffer to drive
 // updates, which are then broadast.  It also has methods for loading and
@@ -35,25 +38,33 @@ import (
 // Windows/DOS CRLF format.
 type TextBuf struct {
 	ki.Node
-	Txt        []byte         `json:"-" xml:"text" desc:"the current value of the entire text being edited -- using []byte slice for greater efficiency"`
-	Autosave   bool           `desc:"if true, auto-save file after changes (in a separate routine)"`
-	Changed    bool           `json:"-" xml:"-" desc:"true if the text has been changed (edited) relative to the original, since last save"`
-	Filename   gi.FileName    `json:"-" xml:"-" desc:"filename of file last loaded or saved"`
-	Info       FileInfo       `desc:"full info about file"`
-	Hi         HiMarkup       `desc:"syntax highlighting markup parameters (language, style, etc)"`
-	NLines     int            `json:"-" xml:"-" desc:"number of lines"`
-	Lines      [][]rune       `json:"-" xml:"-" desc:"the live lines of text being edited, with latest modifications -- encoded as runes per line, which is necessary for one-to-one rune / glyph rendering correspondence"`
-	LineBytes  [][]byte       `json:"-" xml:"-" desc:"the live lines of text being edited, with latest modifications -- encoded in bytes per line -- these are initially just pointers into source Txt bytes"`
-	Markup     [][]byte       `json:"-" xml:"-" desc:"marked-up version of the edit text lines, after being run through the syntax highlighting process -- this is what is actually rendered"`
-	ByteOffs   []int          `json:"-" xml:"-" desc:"offsets for start of each line in Txt []byte slice -- this is NOT updated with edits -- call SetByteOffs to set it when needed -- used for re-generating the Txt in LinesToBytes, and set on initial open in BytesToLines"`
-	TotalBytes int            `json:"-" xml:"-" desc:"total bytes in document -- see ByteOffs for when it is updated"`
-	MarkupMu   sync.Mutex     `json:"-" xml:"-" desc:"mutex for updating markup"`
-	TextBufSig ki.Signal      `json:"-" xml:"-" view:"-" desc:"signal for buffer -- see TextBufSignals for the types"`
-	Views      []*TextView    `json:"-" xml:"-" desc:"the TextViews that are currently viewing this buffer"`
-	Undos      []*TextBufEdit `json:"-" xml:"-" desc:"undo stack of edits"`
-	UndoPos    int            `json:"-" xml:"-" desc:"undo position"`
-	FileModOk  bool           `json:"-" xml:"-" desc:"have already asked about fact that file has changed since being opened, user is ok"`
-	PosHistory []TextPos      `json:"-" xml:"-" desc:"history of cursor positions -- can move back through them"`
+	Txt          []byte               `json:"-" xml:"text" desc:"the text as last loaded, or as of the last call to Text or LinesToBytes -- the live text is in Lns, and is only copied back here on demand"`
+	Autosave     bool                 `desc:"if true, auto-save file after changes (in a separate routine)"`
+	Changed      bool                 `json:"-" xml:"-" desc:"true if the text has been changed (edited) relative to the original, since last save"`
+	Filename     gi.FileName          `json:"-" xml:"-" desc:"filename of file last loaded or saved"`
+	Info         FileInfo             `desc:"full info about file"`
+	Hi           HiMarkup             `desc:"syntax highlighting markup parameters (language, style, etc)"`
+	NLines       int                  `json:"-" xml:"-" desc:"number of lines"`
+	Lns          LineRope             `json:"-" xml:"-" view:"-" desc:"the live lines of text being edited, with latest modifications, and their syntax highlighting tokens -- see Line, LineBytes, LineHiTokens etc for access -- lines initially point into the source Txt bytes, and their runes are decoded when first needed"`
+	MarkupMu     sync.Mutex           `json:"-" xml:"-" desc:"mutex for updating markup"`
+	TextBufSig   ki.Signal            `json:"-" xml:"-" view:"-" desc:"signal for buffer -- see TextBufSignals for the types"`
+	Views        []*TextView          `json:"-" xml:"-" desc:"the TextViews that are currently viewing this buffer"`
+	Undos        []*TextBufEdit       `json:"-" xml:"-" desc:"undo stack of edits"`
+	UndoPos      int                  `json:"-" xml:"-" desc:"undo position"`
+	UndoGroup    int                  `json:"-" xml:"-" desc:"current undo group -- edits in the same group are undone and redone together -- see UndoGroupStart"`
+	UndoTree     bool                 `desc:"keep edits that were undone as alternative branches of the undo history when new edits are made, instead of discarding them -- see UndoBranchNext"`
+	UndoSave     bool                 `desc:"save the undo history next to the autosave file when the file is saved, and restore it when the file is opened again unchanged"`
+	UndoBranches []*TextBufUndoBranch `json:"-" xml:"-" desc:"alternative branches of undone edits, when UndoTree is on"`
+	FileModOk    bool                 `json:"-" xml:"-" desc:"have already asked about fact that file has changed since being opened, user is ok"`
+	PosHistory   []TextPos            `json:"-" xml:"-" desc:"history of cursor positions -- can move back through them"`
+	UseLSP       bool                 `desc:"attach to the language server for the language of the file when it is opened, if one is configured in lsp.Servers -- see LSPAttach"`
+	LSP          *TextBufLSP          `json:"-" xml:"-" view:"-" desc:"language server state, when attached -- see LSPAttach"`
+	Markers      []TextBufMarker      `json:"-" xml:"-" desc:"markers annotating regions of the text, e.g., diagnostics from the language server, breakpoints and bookmarks, sorted by position -- they move with edits -- uses MarkupMu"`
+	undoGrpLev   int
+	hiSt, hiEd   int
+	hiPend       bool
+	hiRunning    bool
+	txtStale     bool
 }
 
 var KiT_TextBuf = kit.Types.AddType(&TextBuf{}, TextBufProps)
@@ -74,8 +85,8 @@ var TextBufProps = ki.Props{
 type TextBufSignals int64
 
 const (
-	// TextBufDone means that editing was completed and applied to Txt field
-	// -- data is Txt bytes
+	// TextBufDone means that editing was completed -- data is nil, as the
+	// text is not copied into Txt until needed -- call Text to get it
 	TextBufDone TextBufSignals = iota
 
 	// TextBufNew signals that entirely new text is present -- all views
@@ -92,9 +103,11 @@ const (
 	// current state *after* the edit.
 	TextBufDelete
 
-	// TextBufMarkUpdt signals that the Markup text has been updated -- this
-	// signal is typically sent from a separate goroutine so should be used
-	// with a mutex
+	// TextBufMarkUpdt signals that the syntax highlighting or markers have
+	// been updated -- data is the TextRegion of the lines updated (End.Ln
+	// inclusive) for highlighting, else the whole text may have changed --
+	// this signal is typically sent from a separate goroutine so should be
+	// used with a mutex
 	TextBufMarkUpdt
 
 	TextBufSignalsN
@@ -102,21 +115,26 @@ const (
 
 //go:generate stringer -type=TextBufSignals
 
-// EditDone finalizes any current editing, sends signal
+// EditDone finalizes any current editing, sends signal -- the text is not
+// copied into Txt, which is only updated by Text
 func (tb *TextBuf) EditDone() {
 	if tb.Changed {
 		tb.AutoSaveDelete()
 		tb.Changed = false
-		tb.LinesToBytes()
-		tb.TextBufSig.Emit(tb.This, int64(TextBufDone), tb.Txt)
+		tb.txtStale = true
+		tb.TextBufSig.Emit(tb.This, int64(TextBufDone), nil)
 	}
 }
 
 // Text returns the current text as a []byte array, applying all current
 // changes -- calls EditDone and will generate that signal if there have been
-// changes
+// changes, and copies the lines into Txt if they have been edited since the
+// last call
 func (tb *TextBuf) Text() []byte {
 	tb.EditDone()
+	if tb.txtStale {
+		tb.LinesToBytes()
+	}
 	return tb.Txt
 }
 
@@ -131,24 +149,13 @@ func (tb *TextBuf) Refresh() {
 // New initializes a new buffer with n blank lines
 func (tb *TextBuf) New(nlines int) {
 	tb.MarkupMu.Lock()
-	tb.Lines = make([][]rune, nlines)
-	tb.LineBytes = make([][]byte, nlines)
-	tb.Markup = make([][]byte, nlines)
-
-	if cap(tb.ByteOffs) >= nlines {
-		tb.ByteOffs = tb.ByteOffs[:nlines]
-	} else {
-		tb.ByteOffs = make([]int, nlines)
-	}
-
-	if nlines == 1 { // this is used for a new blank doc
-		tb.ByteOffs[0] = 0 // by definition
-		tb.Lines[0] = []rune("")
-		tb.LineBytes[0] = []byte("")
-		tb.Markup[0] = []byte("")
+	lns := make([]*TextLine, nlines)
+	for ln := range lns {
+		lns[ln] = NewTextLine(nil)
 	}
-
+	tb.Lns.SetLines(lns)
 	tb.NLines = nlines
+	tb.hiPend = false
 	tb.MarkupMu.Unlock()
 	tb.Refresh()
 }
@@ -209,16 +216,28 @@ func (tb *TextBuf) Open(filename gi.FileName) error {
 		return err
 	}
 	tb.SetName(string(filename)) // todo: modify in any way?
+	if tb.UndoSave {
+		tb.ResetUndo()
+		tb.OpenUndoHist()
+	}
+	if tb.UseLSP {
+		tb.LSPDetach()
+		if err := tb.LSPAttach(); err != nil {
+			log.Println(err)
+		}
+	}
 
-	// markup the first 100 lines
-	mxhi := ints.MinInt(100, tb.NLines-1)
-	tb.MarkupLines(0, mxhi)
+	// highlight the first 100 lines now, and the rest in the background
+	if tb.Hi.HasHi() && tb.Hi.lexer != nil {
+		tb.MarkupMu.Lock()
+		tb.hiDirty(0, tb.NLines-1)
+		tb.hiLexChunk(100)
+		tb.hiStart()
+		tb.MarkupMu.Unlock()
+	}
 
 	// update views
 	tb.TextBufSig.Emit(tb.This, int64(TextBufNew), tb.Txt)
-
-	// do slow full update in background
-	go tb.MarkupAllLines() // then do all in background
 	return nil
 }
 
@@ -261,7 +280,6 @@ func (tb *TextBuf) ReOpen() bool {
 	diffs := tb.DiffBufs(ob)
 	tb.PatchFromBuf(ob, diffs, true) // true = send sigs for each update -- better than full, assuming changes are minor
 	tb.Changed = false
-	go tb.MarkupAllLines() // always do global reformat in bg
 	return true
 }
 
@@ -288,9 +306,10 @@ func (tb *TextBuf) SaveAs(filename gi.FileName) error {
 	}
 }
 
-// SaveFile writes current buffer to file, with no prompting, etc
+// SaveFile writes current buffer to file, with no prompting, etc -- the
+// lines are streamed directly to the file, without copying the text
 func (tb *TextBuf) SaveFile(filename gi.FileName) error {
-	err := ioutil.WriteFile(string(filename), tb.Txt, 0644)
+	err := tb.WriteFile(string(filename))
 	if err != nil {
 		gi.PromptDialog(nil, gi.DlgOpts{Title: "Could not Save to File", Prompt: err.Error()}, true, false, nil, nil)
 		log.Println(err)
@@ -298,6 +317,27 @@ func (tb *TextBuf) SaveFile(filename gi.FileName) error {
 		tb.Filename = filename
 		tb.SetName(string(filename)) // todo: modify in any way?
 		tb.Stat()
+		if tb.UndoSave {
+			tb.SaveUndoHist()
+		}
+		tb.LSPSaved()
+	}
+	return err
+}
+
+// WriteFile writes the current lines to given file, via a buffered writer
+func (tb *TextBuf) WriteFile(filename string) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(fp)
+	_, err = tb.Lns.WriteTo(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := fp.Close(); err == nil {
+		err = cerr
 	}
 	return err
 }
@@ -364,6 +404,7 @@ func (tb *TextBuf) Close() bool {
 		}
 		return false // awaiting decisions..
 	}
+	tb.LSPDetach()
 	for _, tve := range tb.Views {
 		tve.SetBuf(nil) // automatically disconnects signals, views
 	}
@@ -418,7 +459,7 @@ func (tb *TextBuf) EndPos() TextPos {
 	if tb.NLines == 0 {
 		return TextPosZero
 	}
-	ed := TextPos{tb.NLines - 1, len(tb.Lines[tb.NLines-1])}
+	ed := TextPos{tb.NLines - 1, tb.LineLen(tb.NLines - 1)}
 	return ed
 }
 
@@ -474,7 +515,7 @@ func (tb *TextBuf) AppendTextMarkup(text []byte, markup []byte, saveUndo, signal
 		el = ints.MinInt(st+len(msplt)-1, el)
 	}
 	for ln := st; ln <= el; ln++ {
-		tb.Markup[ln] = msplt[ln-st]
+		tb.Lns.Line(ln).Markup = msplt[ln-st]
 	}
 	if signal {
 		tb.TextBufSig.Emit(tb.This, int64(TextBufInsert), tbe)
@@ -504,7 +545,7 @@ func (tb *TextBuf) AppendTextLineMarkup(text []byte, markup []byte, saveUndo, si
 		efft = tcpy
 	}
 	tbe := tb.InsertText(ed, efft, saveUndo, false)
-	tb.Markup[tbe.Reg.Start.Ln] = markup
+	tb.Lns.Line(tbe.Reg.Start.Ln).Markup = markup
 	if signal {
 		tb.TextBufSig.Emit(tb.This, int64(TextBufInsert), tbe)
 	}
@@ -550,14 +591,55 @@ func (tb *TextBuf) AutoScrollViews() {
 /////////////////////////////////////////////////////////////////////////////
 //   Accessing Text
 
-// SetByteOffs sets the byte offsets for each line into the raw text
-func (tb *TextBuf) SetByteOffs() {
-	bo := 0
-	for ln, txt := range tb.LineBytes {
-		tb.ByteOffs[ln] = bo
-		bo += len(txt) + 1 // lf
+// Line returns the runes of given line -- must be a valid line, and the
+// runes must not be modified -- edit using InsertText, DeleteText
+func (tb *TextBuf) Line(ln int) []rune {
+	return tb.Lns.Line(ln).Runes()
+}
+
+// LineLen returns the number of runes in given line
+func (tb *TextBuf) LineLen(ln int) int {
+	return tb.Lns.Line(ln).NRunes()
+}
+
+// LineBytes returns the bytes of given line, without the newline -- must
+// not be modified
+func (tb *TextBuf) LineBytes(ln int) []byte {
+	return tb.Lns.Line(ln).Bytes
+}
+
+// LineMarkup returns the markup for given line, which is what is rendered
+// when the buffer is not syntax highlighted -- see LineHiTokens
+func (tb *TextBuf) LineMarkup(ln int) []byte {
+	return tb.Lns.Line(ln).Markup
+}
+
+// NBytes returns the total number of bytes in the text, with a newline at
+// the end of each line
+func (tb *TextBuf) NBytes() int {
+	return tb.Lns.NBytes()
+}
+
+// ByteOffset returns the byte offset of given position within the text,
+// with a newline at the end of each line
+func (tb *TextBuf) ByteOffset(pos TextPos) int {
+	if tb.NLines == 0 {
+		return 0
+	}
+	pos = tb.ValidPos(pos)
+	return tb.Lns.Offset(pos.Ln) + tb.Lns.Line(pos.Ln).RuneByteOff(pos.Ch)
+}
+
+// PosFromByteOffset returns the position for given byte offset within the
+// text -- the inverse of ByteOffset
+func (tb *TextBuf) PosFromByteOffset(off int) TextPos {
+	if tb.NLines == 0 {
+		return TextPosZero
 	}
-	tb.TotalBytes = bo
+	ln, lnoff := tb.Lns.LineAtOffset(off)
+	b := tb.Lns.Line(ln).Bytes
+	bo := ints.MinInt(ints.MaxInt(off-lnoff, 0), len(b))
+	return TextPos{Ln: ln, Ch: utf8.RuneCount(b[:bo])}
 }
 
 // LinesToBytes converts current Lines back to the Txt slice of bytes.
@@ -566,62 +648,46 @@ func (tb *TextBuf) LinesToBytes() {
 		if tb.Txt != nil {
 			tb.Txt = tb.Txt[:0]
 		}
+		tb.txtStale = false
 		return
 	}
-
 	tb.Txt = tb.LinesToBytesCopy()
-
-	// the following does not work because LineBytes is just pointers into txt!
-	// tb.SetByteOffs()
-	// totsz := tb.TotalBytes
-
-	// if cap(tb.Txt) < totsz {
-	// 	tb.Txt = make([]byte, totsz)
-	// } else {
-	// 	tb.Txt = tb.Txt[:totsz]
-	// }
-
-	// for ln := range tb.Lines {
-	// 	bo := tb.ByteOffs[ln]
-	// 	lsz := len(tb.LineBytes[ln])
-	// 	copy(tb.Txt[bo:bo+lsz], tb.LineBytes[ln])
-	// 	tb.Txt[bo+lsz] = '\n'
-	// }
+	tb.txtStale = false
 }
 
 // LinesToBytesCopy converts current Lines into a separate text byte copy --
 // e.g., for autosave or other "offline" uses of the text -- doesn't affect
-// byte offsets etc
+// Txt
 func (tb *TextBuf) LinesToBytesCopy() []byte {
-	txt := bytes.Join(tb.LineBytes, []byte("\n"))
-	txt = append(txt, '\n')
-	return txt
+	return tb.Lns.Bytes()
 }
 
 // BytesToLines converts current Txt bytes into lines, and initializes markup
-// with raw text
+// with raw text -- the lines point into Txt, and the runes for each line
+// are only decoded when needed
 func (tb *TextBuf) BytesToLines() {
 	tb.Hi.Init()
+	tb.txtStale = false
 	if len(tb.Txt) == 0 {
 		tb.New(1)
 		return
 	}
 	lns := bytes.Split(tb.Txt, []byte("\n"))
-	tb.NLines = len(lns)
-	if len(lns[tb.NLines-1]) == 0 { // lines have lf at end typically
-		tb.NLines--
-		lns = lns[:tb.NLines]
+	nlines := len(lns)
+	if len(lns[nlines-1]) == 0 { // lines have lf at end typically
+		nlines--
+		lns = lns[:nlines]
 	}
-	tb.New(tb.NLines)
-	bo := 0
+	tls := make([]*TextLine, nlines)
 	for ln, txt := range lns {
-		tb.ByteOffs[ln] = bo
-		tb.Lines[ln] = bytes.Runes(txt)
-		tb.LineBytes[ln] = txt
-		tb.Markup[ln] = tb.LineBytes[ln]
-		bo += len(txt) + 1 // lf
+		tls[ln] = NewTextLine(txt)
 	}
-	tb.TotalBytes = bo
+	tb.MarkupMu.Lock()
+	tb.Lns.SetLines(tls)
+	tb.NLines = nlines
+	tb.hiPend = false
+	tb.MarkupMu.Unlock()
+	tb.Refresh()
 }
 
 /////////////////////////////////////////////////////////////////////////////
@@ -644,7 +710,8 @@ func (tb *TextBuf) Search(find []byte, ignoreCase bool) (int, []FileSearchMatch)
 	mstsz := len(mst)
 	med := []byte("</mark>")
 	medsz := len(med)
-	for ln, b := range tb.LineBytes {
+	tb.Lns.Range(0, tb.NLines, func(ln int, tl *TextLine) bool {
+		b := tl.Bytes
 		if ignoreCase {
 			b = bytes.ToLower(b)
 		}
@@ -674,7 +741,8 @@ func (tb *TextBuf) Search(find []byte, ignoreCase bool) (int, []FileSearchMatch)
 			matches = append(matches, FileSearchMatch{Reg: reg, Text: txt})
 			cnt++
 		}
-	}
+		return true
+	})
 	return cnt, matches
 }
 
@@ -764,6 +832,44 @@ type TextBufEdit struct {
 	Reg    TextRegion `desc:"region for the edit (start is same for previous and current, end is in original pre-delete text for a delete, and in new lines data for an insert"`
 	Delete bool       `desc:"action is either a deletion or an insertion"`
 	Text   [][]rune   `desc:"text to be inserted"`
+	Group  int        `desc:"undo group that this edit belongs to -- all edits in a group are undone and redone together"`
+	Time   time.Time  `desc:"time when the edit was saved for undo"`
+}
+
+// AdjustPos returns the given position adjusted for this edit having been
+// applied: positions after the edit are shifted by the inserted or deleted
+// text, and positions within a deleted region move to its start -- used to
+// keep other cursors, selections etc in place
+func (te *TextBufEdit) AdjustPos(pos TextPos) TextPos {
+	st, ed := te.Reg.Start, te.Reg.End
+	if te.Delete {
+		switch {
+		case !st.IsLess(pos):
+			return pos
+		case pos.IsLess(ed):
+			return st
+		case pos.Ln == ed.Ln:
+			return TextPos{Ln: st.Ln, Ch: st.Ch + pos.Ch - ed.Ch}
+		default:
+			pos.Ln -= ed.Ln - st.Ln
+			return pos
+		}
+	}
+	switch {
+	case pos.IsLess(st):
+		return pos
+	case pos.Ln == st.Ln:
+		return TextPos{Ln: ed.Ln, Ch: ed.Ch + pos.Ch - st.Ch}
+	default:
+		pos.Ln += ed.Ln - st.Ln
+		return pos
+	}
+}
+
+// AdjustReg returns the given region adjusted for this edit having been
+// applied -- see AdjustPos
+func (te *TextBufEdit) AdjustReg(reg TextRegion) TextRegion {
+	return TextRegion{Start: te.AdjustPos(reg.Start), End: te.AdjustPos(reg.End)}
 }
 
 // ToBytes returns the Text of this edit record to a byte string, with
@@ -797,8 +903,8 @@ func (tb *TextBuf) ValidPos(pos TextPos) TextPos {
 	if pos.Ln < 0 {
 		pos.Ln = 0
 	}
-	pos.Ln = ints.MinInt(pos.Ln, len(tb.Lines)-1)
-	llen := len(tb.Lines[pos.Ln])
+	pos.Ln = ints.MinInt(pos.Ln, tb.NLines-1)
+	llen := tb.LineLen(pos.Ln)
 	pos.Ch = ints.MinInt(pos.Ch, llen)
 	if pos.Ch < 0 {
 		pos.Ch = 0
@@ -806,6 +912,40 @@ func (tb *TextBuf) ValidPos(pos TextPos) TextPos {
 	return pos
 }
 
+// PosForward returns the position given number of characters after given
+// position, counting the end of each line as one character
+func (tb *TextBuf) PosForward(pos TextPos, steps int) TextPos {
+	pos = tb.ValidPos(pos)
+	for i := 0; i < steps; i++ {
+		if pos.Ch < tb.LineLen(pos.Ln) {
+			pos.Ch++
+		} else if pos.Ln < tb.NLines-1 {
+			pos.Ln++
+			pos.Ch = 0
+		} else {
+			break
+		}
+	}
+	return pos
+}
+
+// PosBackward returns the position given number of characters before given
+// position, counting the end of each line as one character
+func (tb *TextBuf) PosBackward(pos TextPos, steps int) TextPos {
+	pos = tb.ValidPos(pos)
+	for i := 0; i < steps; i++ {
+		if pos.Ch > 0 {
+			pos.Ch--
+		} else if pos.Ln > 0 {
+			pos.Ln--
+			pos.Ch = tb.LineLen(pos.Ln)
+		} else {
+			break
+		}
+	}
+	return pos
+}
+
 // DeleteText deletes region of text between start and end positions, signaling
 // views after text lines have been updated.
 func (tb *TextBuf) DeleteText(st, ed TextPos, saveUndo, signal bool) *TextBufEdit {
@@ -822,29 +962,32 @@ func (tb *TextBuf) DeleteText(st, ed TextPos, saveUndo, signal bool) *TextBufEdi
 	tb.Changed = true
 	tbe := tb.Region(st, ed)
 	tbe.Delete = true
+	stl := tb.Line(st.Ln)
+	edl := stl
+	if ed.Ln != st.Ln {
+		edl = tb.Line(ed.Ln)
+	}
+	nt := make([]rune, 0, st.Ch+len(edl)-ed.Ch)
+	nt = append(nt, stl[:st.Ch]...)
+	nt = append(nt, edl[ed.Ch:]...)
+	tb.MarkupMu.Lock()
+	if ed.Ln == st.Ln {
+		tb.Lns.SetLine(st.Ln, NewTextLineRunes(nt))
+	} else {
+		tb.Lns.Replace(st.Ln, ed.Ln+1, []*TextLine{NewTextLineRunes(nt)})
+		tb.NLines = tb.Lns.Len()
+	}
+	tb.MarkupMu.Unlock()
+	tb.adjustMarkers(tbe)
 	if ed.Ln == st.Ln {
-		tb.Lines[st.Ln] = append(tb.Lines[st.Ln][:st.Ch], tb.Lines[st.Ln][ed.Ch:]...)
 		tb.LinesEdited(tbe)
 	} else {
-		// first get chars on start and end
-		stln := st.Ln + 1
-		cpln := st.Ln
-		tb.Lines[st.Ln] = tb.Lines[st.Ln][:st.Ch]
-		eoedl := len(tb.Lines[ed.Ln][ed.Ch:])
-		var eoed []rune
-		if eoedl > 0 { // save it
-			eoed = make([]rune, eoedl)
-			copy(eoed, tb.Lines[ed.Ln][ed.Ch:])
-		}
-		tb.Lines = append(tb.Lines[:stln], tb.Lines[ed.Ln+1:]...)
-		if eoed != nil {
-			tb.Lines[cpln] = append(tb.Lines[cpln], eoed...)
-		}
-		tb.NLines = len(tb.Lines)
 		tb.LinesDeleted(tbe)
 	}
 	if signal {
 		tb.TextBufSig.Emit(tb.This, int64(TextBufDelete), tbe)
+	} else {
+		tb.lspUnsent()
 	}
 	if tb.Autosave {
 		go tb.AutoSave()
@@ -861,58 +1004,55 @@ func (tb *TextBuf) InsertText(st TextPos, text []byte, saveUndo, signal bool) *T
 	if len(text) == 0 {
 		return nil
 	}
-	if len(tb.Lines) == 0 {
+	if tb.NLines == 0 {
 		tb.New(1)
 	}
 	st = tb.ValidPos(st)
 	tb.FileModCheck()
 	tb.Changed = true
+	text = append([]byte(nil), text...) // new lines point into our own copy
 	lns := bytes.Split(text, []byte("\n"))
 	sz := len(lns)
+	cur := tb.Line(st.Ln)
 	rs := bytes.Runes(lns[0])
-	rsz := len(rs)
 	ed := st
 	var tbe *TextBufEdit
 	if sz == 1 {
-		nt := append(tb.Lines[st.Ln], rs...) // first append to end to extend capacity
-		copy(nt[st.Ch+rsz:], nt[st.Ch:])     // move stuff to end
-		copy(nt[st.Ch:], rs)                 // copy into position
-		tb.Lines[st.Ln] = nt
-		ed.Ch += rsz
+		nt := make([]rune, 0, len(cur)+len(rs))
+		nt = append(nt, cur[:st.Ch]...)
+		nt = append(nt, rs...)
+		nt = append(nt, cur[st.Ch:]...)
+		tb.MarkupMu.Lock()
+		tb.Lns.SetLine(st.Ln, NewTextLineRunes(nt))
+		tb.MarkupMu.Unlock()
+		ed.Ch += len(rs)
 		tbe = tb.Region(st, ed)
+		tb.adjustMarkers(tbe)
 		tb.LinesEdited(tbe)
 	} else {
-		if tb.Lines[st.Ln] == nil {
-			tb.Lines[st.Ln] = []rune("")
-		}
-		eostl := len(tb.Lines[st.Ln][st.Ch:]) // end of starting line
-		var eost []rune
-		if eostl > 0 { // save it
-			eost = make([]rune, eostl)
-			copy(eost, tb.Lines[st.Ln][st.Ch:])
-		}
-		tb.Lines[st.Ln] = append(tb.Lines[st.Ln][:st.Ch], rs...)
-		nsz := sz - 1
-		tmp := make([][]rune, nsz)
-		for i := 1; i < sz; i++ {
-			tmp[i-1] = bytes.Runes(lns[i])
-		}
-		stln := st.Ln + 1
-		nt := append(tb.Lines, tmp...) // first append to end to extend capacity
-		copy(nt[stln+nsz:], nt[stln:]) // move stuff to end
-		copy(nt[stln:], tmp)           // copy into position
-		tb.Lines = nt
-		tb.NLines = len(tb.Lines)
-		ed.Ln += nsz
-		ed.Ch = len(tb.Lines[ed.Ln])
-		if eost != nil {
-			tb.Lines[ed.Ln] = append(tb.Lines[ed.Ln], eost...)
+		nls := make([]*TextLine, sz)
+		fst := make([]rune, 0, st.Ch+len(rs))
+		fst = append(fst, cur[:st.Ch]...)
+		nls[0] = NewTextLineRunes(append(fst, rs...))
+		for i := 1; i < sz-1; i++ {
+			nls[i] = NewTextLine(lns[i])
 		}
+		lrs := bytes.Runes(lns[sz-1])
+		ed.Ln += sz - 1
+		ed.Ch = len(lrs)
+		nls[sz-1] = NewTextLineRunes(append(lrs, cur[st.Ch:]...))
+		tb.MarkupMu.Lock()
+		tb.Lns.Replace(st.Ln, st.Ln+1, nls)
+		tb.NLines = tb.Lns.Len()
+		tb.MarkupMu.Unlock()
 		tbe = tb.Region(st, ed)
+		tb.adjustMarkers(tbe)
 		tb.LinesInserted(tbe)
 	}
 	if signal {
 		tb.TextBufSig.Emit(tb.This, int64(TextBufInsert), tbe)
+	} else {
+		tb.lspUnsent()
 	}
 	if tb.Autosave {
 		go tb.AutoSave()
@@ -939,33 +1079,23 @@ func (tb *TextBuf) Region(st, ed TextPos) *TextBufEdit {
 		sz := ed.Ch - st.Ch
 		tbe.Text = make([][]rune, 1)
 		tbe.Text[0] = make([]rune, sz)
-		copy(tbe.Text[0][:sz], tb.Lines[st.Ln][st.Ch:ed.Ch])
+		copy(tbe.Text[0][:sz], tb.Line(st.Ln)[st.Ch:ed.Ch])
 	} else {
-		// first get chars on start and end
 		nlns := (ed.Ln - st.Ln) + 1
 		tbe.Text = make([][]rune, nlns)
-		stln := st.Ln
-		if st.Ch > 0 {
-			ec := len(tb.Lines[st.Ln])
-			sz := ec - st.Ch
-			if sz > 0 {
-				tbe.Text[0] = make([]rune, sz)
-				copy(tbe.Text[0][0:sz], tb.Lines[st.Ln][st.Ch:])
+		tb.Lns.Range(st.Ln, ed.Ln+1, func(ln int, tl *TextLine) bool {
+			lr := tl.Runes()
+			switch ln {
+			case st.Ln:
+				lr = lr[st.Ch:]
+			case ed.Ln:
+				lr = lr[:ed.Ch]
 			}
-			stln++
-		}
-		edln := ed.Ln
-		if ed.Ch < len(tb.Lines[ed.Ln]) {
-			tbe.Text[ed.Ln-st.Ln] = make([]rune, ed.Ch)
-			copy(tbe.Text[ed.Ln-st.Ln], tb.Lines[ed.Ln][:ed.Ch])
-			edln--
-		}
-		for ln := stln; ln <= edln; ln++ {
 			ti := ln - st.Ln
-			sz := len(tb.Lines[ln])
-			tbe.Text[ti] = make([]rune, sz)
-			copy(tbe.Text[ti], tb.Lines[ln])
-		}
+			tbe.Text[ti] = make([]rune, len(lr))
+			copy(tbe.Text[ti], lr)
+			return true
+		})
 	}
 	return tbe
 }
@@ -990,173 +1120,222 @@ func (tb *TextBuf) SavePosHistory(pos TextPos) bool {
 /////////////////////////////////////////////////////////////////////////////
 //   Syntax Highlighting Markup
 
-// LinesInserted inserts new lines in Markup corresponding to lines
-// inserted in Lines text.  Locks and unlocks the Markup mutex
-func (tb *TextBuf) LinesInserted(tbe *TextBufEdit) {
-	stln := tbe.Reg.Start.Ln + 1
-	nsz := (tbe.Reg.End.Ln - tbe.Reg.Start.Ln)
+// HiChunkLines is the number of lines that the background syntax
+// highlighting lexes at a time while holding the MarkupMu mutex, signaling
+// TextBufMarkUpdt after each chunk
+var HiChunkLines = 500
 
+// LinesInserted updates the highlighting for lines inserted in the text.
+// Locks and unlocks the Markup mutex.
+func (tb *TextBuf) LinesInserted(tbe *TextBufEdit) {
 	tb.MarkupMu.Lock()
-
-	// LineBytes
-	tmplb := make([][]byte, nsz)
-	nlb := append(tb.LineBytes, tmplb...)
-	copy(nlb[stln+nsz:], nlb[stln:])
-	copy(nlb[stln:], tmplb)
-	tb.LineBytes = nlb
-
-	// Markup
-	tmpmu := make([][]byte, nsz)
-	nmu := append(tb.Markup, tmpmu...) // first append to end to extend capacity
-	copy(nmu[stln+nsz:], nmu[stln:])   // move stuff to end
-	copy(nmu[stln:], tmpmu)            // copy into position
-	tb.Markup = nmu
-
-	// ByteOffs -- maintain mem updt
-	tmpof := make([]int, nsz)
-	nof := append(tb.ByteOffs, tmpof...)
-	copy(nof[stln+nsz:], nof[stln:])
-	copy(nof[stln:], tmpof)
-	tb.ByteOffs = nof
-
-	st, ed := tbe.Reg.Start.Ln, tbe.Reg.End.Ln
-	bo := tb.ByteOffs[st]
-	for ln := st; ln <= ed; ln++ {
-		tb.LineBytes[ln] = []byte(string(tb.Lines[ln]))
-		tb.Markup[ln] = tb.LineBytes[ln]
-		tb.ByteOffs[ln] = bo
-		bo += len(tb.LineBytes[ln]) + 1
-	}
-	tb.MarkupLines(st, ed)
+	tb.hiAdjust(tbe)
+	tb.MarkupLines(tbe.Reg.Start.Ln, tbe.Reg.End.Ln)
 	tb.MarkupMu.Unlock()
-
-	go tb.MarkupAllLines() // always do global reformat in bg
 }
 
-// LinesDeleted deletes lines in Markup corresponding to lines
-// deleted in Lines text.  Locks and unlocks the Markup mutex.
+// LinesDeleted updates the highlighting for the line remaining after lines
+// were deleted in the text.  Locks and unlocks the Markup mutex.
 func (tb *TextBuf) LinesDeleted(tbe *TextBufEdit) {
 	tb.MarkupMu.Lock()
-
-	stln := tbe.Reg.Start.Ln
-	edln := tbe.Reg.End.Ln
-
-	tb.LineBytes = append(tb.LineBytes[:stln], tb.LineBytes[edln:]...)
-	tb.Markup = append(tb.Markup[:stln], tb.Markup[edln:]...)
-	tb.ByteOffs = append(tb.ByteOffs[:stln], tb.ByteOffs[edln:]...)
-
+	tb.hiAdjust(tbe)
 	st := tbe.Reg.Start.Ln
-	tb.LineBytes[st] = []byte(string(tb.Lines[st]))
-	tb.Markup[st] = tb.LineBytes[st]
 	tb.MarkupLines(st, st)
 	tb.MarkupMu.Unlock()
-	// probably don't need to do global markup here..
 }
 
-// LinesEdited re-marks-up lines in edit (typically only 1).  Locks and
-// unlocks the Markup mutex.
+// LinesEdited updates the highlighting for lines in edit (typically only
+// 1).  Locks and unlocks the Markup mutex.
 func (tb *TextBuf) LinesEdited(tbe *TextBufEdit) {
 	tb.MarkupMu.Lock()
-
-	st, ed := tbe.Reg.Start.Ln, tbe.Reg.End.Ln
-	for ln := st; ln <= ed; ln++ {
-		tb.LineBytes[ln] = []byte(string(tb.Lines[ln]))
-		tb.Markup[ln] = tb.LineBytes[ln]
-	}
-	tb.MarkupLines(st, ed)
+	tb.MarkupLines(tbe.Reg.Start.Ln, tbe.Reg.End.Ln)
 	tb.MarkupMu.Unlock()
-	// probably don't need to do global markup here..
 }
 
-// MarkupAllLines does syntax highlighting markup for all lines in buffer,
-// calling MarkupMu mutex when setting the marked-up lines with the result --
-// designed to be called in a separate goroutine
+// MarkupAllLines starts syntax highlighting all lines in buffer, in a
+// separate goroutine that signals TextBufMarkUpdt as lines are done.  Locks
+// and unlocks the Markup mutex.
 func (tb *TextBuf) MarkupAllLines() {
 	if !tb.Hi.HasHi() || tb.NLines == 0 || tb.Hi.lexer == nil {
 		return
 	}
-	tb.LinesToBytes() // todo: could need another mutex here?
-	mtlns, err := tb.Hi.MarkupText(tb.Txt)
-	if err != nil {
-		return
-	}
-
 	tb.MarkupMu.Lock()
-	maxln := ints.MinInt(len(mtlns)-1, tb.NLines)
-	for ln := 0; ln < maxln; ln++ {
-		mt := mtlns[ln]
-		tb.Markup[ln] = tb.Hi.FixMarkupLine(mt)
-	}
+	tb.hiDirty(0, tb.NLines-1)
+	tb.hiStart()
 	tb.MarkupMu.Unlock()
-	tb.TextBufSig.Emit(tb.This, int64(TextBufMarkUpdt), tb.Txt)
 }
 
-// MarkupLines generates markup of given range of lines. end is *inclusive*
-// line.  returns true if all lines were marked up successfully.  This does
-// NOT lock the MarkupMu mutex (done at outer loop)
+// MarkupLines updates the syntax highlighting tokens of given range of
+// lines, end is *inclusive* line, lexing them right away from a line
+// shortly before them that is known to end in the root lexer state -- any
+// further lines whose highlighting changes as a result (e.g., from starting
+// a multi-line comment) are done in the background.  Returns true if all the lines were highlighted now.  This
+// does NOT lock the MarkupMu mutex (done at outer loop)
 func (tb *TextBuf) MarkupLines(st, ed int) bool {
-	if !tb.Hi.HasHi() || tb.NLines == 0 {
+	if !tb.Hi.HasHi() || tb.NLines == 0 || tb.Hi.lexer == nil {
 		return false
 	}
 	if ed >= tb.NLines {
 		ed = tb.NLines - 1
 	}
-	allgood := true
-	for ln := st; ln <= ed; ln++ {
-		mu, err := tb.Hi.MarkupLine(tb.LineBytes[ln])
-		if err == nil {
-			tb.Markup[ln] = mu
-		} else {
-			allgood = false
+	tb.hiClearRoots(st)
+	lst := st
+	for lst > 0 && st-lst < 2*HiRootStride && !tb.hiLineRoot(lst-1) {
+		lst--
+	}
+	if lst > 0 && !tb.hiLineRoot(lst-1) {
+		tb.hiDirty(st, ed)
+		tb.hiStart()
+		return false
+	}
+	st = lst
+	e := ints.MinInt(ed+1+HiLookAheadLines, tb.NLines)
+	// one more line than edited, to check whether the state has converged
+	n, conv, err := tb.Hi.TokenizeLines(tb.hiLines(st, e), ed-st, ed-st+2)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	if !conv && st+n < tb.NLines { // state changed: continue in background
+		tb.hiDirty(st+n, st+n)
+		tb.hiStart()
+	}
+	return true
+}
+
+// LineHiTokens returns the syntax highlighting tokens for given line, and
+// false if the buffer is not highlighted with tokens, in which case its
+// Markup should be rendered -- lines not yet highlighted have no tokens.
+// Locks and unlocks the Markup mutex.
+func (tb *TextBuf) LineHiTokens(ln int) ([]HiToken, bool) {
+	if !tb.Hi.HasHi() || tb.Hi.lexer == nil {
+		return nil, false
+	}
+	tb.MarkupMu.Lock()
+	toks := tb.Lns.Line(ln).HiToks
+	tb.MarkupMu.Unlock()
+	return toks, true
+}
+
+// hiLineRoot returns true if given line has been highlighted and is known
+// to end in the root lexer state, so lexing can start at the next line
+func (tb *TextBuf) hiLineRoot(ln int) bool {
+	tl := tb.Lns.Line(ln)
+	return tl.HiOk && tl.HiRoot
+}
+
+// hiClearRoots clears the HiRoot of the lines before given edited line
+// that were checked against its old text, as the lines after them are
+// lexed afresh for the check (see TokenizeLines)
+func (tb *TextBuf) hiClearRoots(st int) {
+	for ln := st - 1; ln >= 0; ln-- {
+		nb := 0
+		ed := ln + 1
+		for ; nb < HiProbeLines && ed < st; ed++ {
+			if len(bytes.TrimSpace(tb.Lns.Line(ed).Bytes)) > 0 {
+				nb++
+			}
+		}
+		if ed+HiProbeLines <= st {
+			return
 		}
+		tb.Lns.Line(ln).HiRoot = false
 	}
-	return allgood
 }
 
-/////////////////////////////////////////////////////////////////////////////
-//   Undo
+// hiLines returns the lines from st up to (not including) ed
+func (tb *TextBuf) hiLines(st, ed int) []*TextLine {
+	lns := make([]*TextLine, 0, ed-st)
+	tb.Lns.Range(st, ed, func(ln int, tl *TextLine) bool {
+		lns = append(lns, tl)
+		return true
+	})
+	return lns
+}
 
-// SaveUndo saves given edit to undo stack
-func (tb *TextBuf) SaveUndo(tbe *TextBufEdit) {
-	if tb.UndoPos < len(tb.Undos) {
-		tb.Undos = tb.Undos[:tb.UndoPos]
+// hiDirty adds lines st..ed (inclusive) to those pending highlighting in
+// the background -- must be called with MarkupMu locked
+func (tb *TextBuf) hiDirty(st, ed int) {
+	if !tb.hiPend {
+		tb.hiSt, tb.hiEd = st, ed
+		tb.hiPend = true
+		return
 	}
-	tb.Undos = append(tb.Undos, tbe)
-	tb.UndoPos = len(tb.Undos)
+	tb.hiSt = ints.MinInt(tb.hiSt, st)
+	tb.hiEd = ints.MaxInt(tb.hiEd, ed)
 }
 
-// Undo undoes next item on the undo stack, and returns that record -- nil if no more
-func (tb *TextBuf) Undo() *TextBufEdit {
-	if tb.UndoPos == 0 {
-		tb.Changed = false // should be!
-		tb.AutoSaveDelete()
-		return nil
+// hiAdjust moves the lines pending highlighting for given edit that has just
+// been made -- must be called with MarkupMu locked
+func (tb *TextBuf) hiAdjust(tbe *TextBufEdit) {
+	if !tb.hiPend {
+		return
 	}
-	tb.UndoPos--
-	tbe := tb.Undos[tb.UndoPos]
-	if tbe.Delete {
-		// fmt.Printf("undoing delete at: %v text: %v\n", tbe.Reg, string(tbe.ToBytes()))
-		tb.InsertText(tbe.Reg.Start, tbe.ToBytes(), false, true)
-	} else {
-		// fmt.Printf("undoing insert at: %v text: %v\n", tbe.Reg, string(tbe.ToBytes()))
-		tb.DeleteText(tbe.Reg.Start, tbe.Reg.End, false, true)
+	tb.hiSt = tbe.AdjustPos(TextPos{Ln: tb.hiSt}).Ln
+	tb.hiEd = tbe.AdjustPos(TextPos{Ln: tb.hiEd}).Ln
+}
+
+// hiStart starts the background highlighting goroutine if there are lines
+// pending and it is not already running -- must be called with MarkupMu
+// locked
+func (tb *TextBuf) hiStart() {
+	if !tb.hiPend || tb.hiRunning || tb.Hi.lexer == nil {
+		return
 	}
-	return tbe
+	tb.hiRunning = true
+	go tb.hiRun()
 }
 
-// Redo redoes next item on the undo stack, and returns that record, nil if no more
-func (tb *TextBuf) Redo() *TextBufEdit {
-	if tb.UndoPos >= len(tb.Undos) {
-		return nil
+// hiRun highlights the pending lines one chunk at a time, signaling
+// TextBufMarkUpdt with the TextRegion of the lines updated by each chunk
+func (tb *TextBuf) hiRun() {
+	for {
+		tb.MarkupMu.Lock()
+		if !tb.hiPend {
+			tb.hiRunning = false
+			tb.MarkupMu.Unlock()
+			return
+		}
+		st, ed := tb.hiLexChunk(HiChunkLines)
+		tb.MarkupMu.Unlock()
+		if ed >= st {
+			tb.TextBufSig.Emit(tb.This, int64(TextBufMarkUpdt), TextRegion{Start: TextPos{Ln: st}, End: TextPos{Ln: ed}})
+		}
 	}
-	tbe := tb.Undos[tb.UndoPos]
-	if tbe.Delete {
-		tb.DeleteText(tbe.Reg.Start, tbe.Reg.End, false, true)
-	} else {
-		tb.InsertText(tbe.Reg.Start, tbe.ToBytes(), false, true)
+}
+
+// hiLexChunk lexes about max lines of those pending highlighting, starting
+// from the nearest line before them that starts in a clean lexer state, and
+// stopping once the state has converged after them -- returns the range of
+// lines updated (ed inclusive), with the rest left pending.  Must be called
+// with MarkupMu locked.
+func (tb *TextBuf) hiLexChunk(max int) (st, ed int) {
+	if !tb.hiPend || tb.NLines == 0 || tb.Hi.lexer == nil {
+		tb.hiPend = false
+		return 0, -1
 	}
-	tb.UndoPos++
-	return tbe
+	hs := ints.MinInt(tb.hiSt, tb.NLines-1)
+	he := ints.MinInt(tb.hiEd, tb.NLines-1)
+	st = hs
+	for st > 0 && hs-st < HiLookAheadLines && !tb.hiLineRoot(st-1) {
+		st--
+	}
+	mx := ints.MaxInt(ints.MinInt(he-st+1, max), hs-st+1) // always get past hs
+	e := ints.MinInt(st+mx+HiLookAheadLines, tb.NLines)
+	n, conv, err := tb.Hi.TokenizeLines(tb.hiLines(st, e), he-st, mx)
+	if err != nil {
+		log.Println(err)
+		tb.hiPend = false
+		return 0, -1
+	}
+	ed = st + n - 1
+	if conv || ed == tb.NLines-1 {
+		tb.hiPend = false
+		return st, ed
+	}
+	tb.hiSt = ed + 1
+	tb.hiEd = ints.MaxInt(he, tb.hiSt)
+	return st, ed
 }
 
 /////////////////////////////////////////////////////////////////////////////
@@ -1166,11 +1345,11 @@ func (tb *TextBuf) Redo() *TextBufEdit {
 // if line starts with tabs, then those are counted, else spaces --
 // combinations of tabs and spaces won't produce sensible results
 func (tb *TextBuf) LineIndent(ln int, tabSz int) (n int, spc bool) {
-	sz := len(tb.Lines[ln])
+	txt := tb.Line(ln)
+	sz := len(txt)
 	if sz == 0 {
 		return
 	}
-	txt := tb.Lines[ln]
 	if txt[0] == ' ' {
 		spc = true
 		n = 1
@@ -1244,12 +1423,12 @@ func (tb *TextBuf) IndentLine(ln int, n, tabSz int, spc bool) *TextBufEdit {
 func (tb *TextBuf) PrevLineIndent(ln int, tabSz int) (n int, spc bool, txt string) {
 	ln--
 	for ln >= 0 {
-		if len(tb.Lines[ln]) == 0 {
+		if tb.LineLen(ln) == 0 {
 			ln--
 			continue
 		}
 		n, spc = tb.LineIndent(ln, tabSz)
-		txt = strings.TrimSpace(string(tb.Lines[ln]))
+		txt = strings.TrimSpace(string(tb.LineBytes(ln)))
 		if cmidx := strings.Index(txt, "// "); cmidx > 0 {
 			txt = strings.TrimSpace(txt[:cmidx])
 		}
@@ -1267,7 +1446,7 @@ func (tb *TextBuf) PrevLineIndent(ln int, tabSz int) (n int, spc bool, txt strin
 // indent of the current line.
 func (tb *TextBuf) AutoIndent(ln int, spc bool, tabSz int, indents, unindents []string) (tbe *TextBufEdit, indLev, chPos int) {
 	li, _, prvln := tb.PrevLineIndent(ln, tabSz)
-	curln := strings.TrimSpace(string(tb.Lines[ln]))
+	curln := strings.TrimSpace(string(tb.LineBytes(ln)))
 	ind := false
 	und := false
 	for _, us := range unindents {
@@ -1298,6 +1477,8 @@ func (tb *TextBuf) AutoIndent(ln int, spc bool, tabSz int, indents, unindents []
 
 // AutoIndentRegion does auto-indent over given region -- end is *exclusive*
 func (tb *TextBuf) AutoIndentRegion(st, ed int, spc bool, tabSz int, indents, unindents []string) {
+	tb.UndoGroupStart()
+	defer tb.UndoGroupEnd()
 	for ln := st; ln < ed; ln++ {
 		if ln >= tb.NLines {
 			break
@@ -1308,6 +1489,8 @@ func (tb *TextBuf) AutoIndentRegion(st, ed int, spc bool, tabSz int, indents, un
 
 // CommentRegion inserts comment marker on given lines -- end is *exclusive*
 func (tb *TextBuf) CommentRegion(st, ed int, comment []byte, tabSz int) {
+	tb.UndoGroupStart()
+	defer tb.UndoGroupEnd()
 	ch := 0
 	li, spc := tb.LineIndent(st, tabSz)
 	if li > 0 {
@@ -1345,12 +1528,14 @@ func (tb *TextBuf) DiffBufs(ob *TextBuf) TextDiffs {
 	astr := make([]string, tb.NLines)
 	bstr := make([]string, ob.NLines)
 
-	for ai, al := range tb.Lines {
-		astr[ai] = string(al)
-	}
-	for bi, bl := range ob.Lines {
-		bstr[bi] = string(bl)
-	}
+	tb.Lns.Range(0, tb.NLines, func(ai int, al *TextLine) bool {
+		astr[ai] = string(al.Bytes)
+		return true
+	})
+	ob.Lns.Range(0, ob.NLines, func(bi int, bl *TextLine) bool {
+		bstr[bi] = string(bl.Bytes)
+		return true
+	})
 
 	m := difflib.NewMatcherWithJunk(astr, bstr, false, nil) // no junk
 	return m.GetOpCodes()
@@ -1366,12 +1551,14 @@ func (tb *TextBuf) DiffBufsUnified(ob *TextBuf, context int) []byte {
 	astr := make([]string, tb.NLines)
 	bstr := make([]string, ob.NLines)
 
-	for ai, al := range tb.Lines {
-		astr[ai] = string(al)
-	}
-	for bi, bl := range ob.Lines {
-		bstr[bi] = string(bl)
-	}
+	tb.Lns.Range(0, tb.NLines, func(ai int, al *TextLine) bool {
+		astr[ai] = string(al.Bytes)
+		return true
+	})
+	ob.Lns.Range(0, ob.NLines, func(bi int, bl *TextLine) bool {
+		bstr[bi] = string(bl.Bytes)
+		return true
+	})
 
 	ud := difflib.UnifiedDiff{A: astr, FromFile: string(tb.Filename), FromDate: tb.Info.ModTime.String(),
 		B: bstr, ToFile: string(ob.Filename), ToDate: ob.Info.ModTime.String(), Context: context}
@@ -1380,31 +1567,98 @@ func (tb *TextBuf) DiffBufsUnified(ob *TextBuf, context int) []byte {
 	return buf.Bytes()
 }
 
+// Reverse returns the diff operations that convert the other buffer (b)
+// back into this one (a), with inserts and deletes swapped
+func (td TextDiffs) Reverse() TextDiffs {
+	rd := make(TextDiffs, len(td))
+	for i, df := range td {
+		tag := df.Tag
+		switch tag {
+		case 'd':
+			tag = 'i'
+		case 'i':
+			tag = 'd'
+		}
+		rd[i] = difflib.OpCode{Tag: tag, I1: df.J1, I2: df.J2, J1: df.I1, J2: df.I2}
+	}
+	return rd
+}
+
 // PatchFromBuf patches (edits) this buffer using content from other buffer,
 // according to diff operations (e.g., as generated from DiffBufs).  signal
 // determines whether each patch is signaled -- if an overall signal will be
 // sent at the end, then that would not be necessary (typical)
 func (tb *TextBuf) PatchFromBuf(ob *TextBuf, diffs TextDiffs, signal bool) bool {
+	return tb.patchFromBuf(ob, diffs, false, signal)
+}
+
+// patchFromBuf applies the diff operations from the last one back, so that
+// the line numbers of those before are not affected by the edits
+func (tb *TextBuf) patchFromBuf(ob *TextBuf, diffs TextDiffs, saveUndo, signal bool) bool {
 	mods := false
-	for _, df := range diffs {
-		switch df.Tag {
-		case 'r':
-			tb.DeleteText(TextPos{Ln: df.I1}, TextPos{Ln: df.I2}, false, signal)
-			ot := ob.Region(TextPos{Ln: df.J1}, TextPos{Ln: df.J2})
-			tb.InsertText(TextPos{Ln: df.I1}, ot.ToBytes(), false, signal)
-			mods = true
-		case 'd':
-			tb.DeleteText(TextPos{Ln: df.I1}, TextPos{Ln: df.I2}, false, signal)
-			mods = true
-		case 'i':
-			ot := ob.Region(TextPos{Ln: df.J1}, TextPos{Ln: df.J2})
-			tb.InsertText(TextPos{Ln: df.I1}, ot.ToBytes(), false, signal)
-			mods = true
+	for i := len(diffs) - 1; i >= 0; i-- {
+		df := diffs[i]
+		if df.Tag == 'e' {
+			continue
+		}
+		empty := false
+		if df.I2 > df.I1 {
+			empty = tb.deleteLines(df.I1, df.I2, saveUndo, signal)
+		}
+		if df.J2 > df.J1 {
+			tb.insertLines(df.I1, ob.linesBytes(df.J1, df.J2), empty, saveUndo, signal)
 		}
+		mods = true
 	}
 	return mods
 }
 
+// linesBytes returns the text of lines st up to (not including) ed, each
+// ending in a newline
+func (tb *TextBuf) linesBytes(st, ed int) []byte {
+	var buf bytes.Buffer
+	tb.Lns.Range(st, ed, func(ln int, tl *TextLine) bool {
+		buf.Write(tl.Bytes)
+		buf.WriteByte('\n')
+		return true
+	})
+	return buf.Bytes()
+}
+
+// deleteLines deletes whole lines st up to (not including) ed -- at the end
+// of the buffer, the newline ending the line before goes with them.  Returns
+// true if all lines were deleted, leaving just one empty line.
+func (tb *TextBuf) deleteLines(st, ed int, saveUndo, signal bool) bool {
+	lst := tb.NLines - 1
+	switch {
+	case ed <= lst:
+		tb.DeleteText(TextPos{Ln: st}, TextPos{Ln: ed}, saveUndo, signal)
+	case st > 0:
+		tb.DeleteText(TextPos{Ln: st - 1, Ch: tb.LineLen(st - 1)}, TextPos{Ln: lst, Ch: tb.LineLen(lst)}, saveUndo, signal)
+	default:
+		tb.DeleteText(TextPos{}, TextPos{Ln: lst, Ch: tb.LineLen(lst)}, saveUndo, signal)
+		return true
+	}
+	return false
+}
+
+// insertLines inserts text of whole lines, each ending in a newline, before
+// line ln -- at the end of the buffer, they are added after a newline
+// instead, and if empty, they replace the one empty line left by deleting
+// all lines
+func (tb *TextBuf) insertLines(ln int, text []byte, empty, saveUndo, signal bool) {
+	switch {
+	case empty:
+		tb.InsertText(TextPos{}, text[:len(text)-1], saveUndo, signal)
+	case ln < tb.NLines:
+		tb.InsertText(TextPos{Ln: ln}, text, saveUndo, signal)
+	default:
+		lst := tb.NLines - 1
+		txt := append([]byte{'\n'}, text[:len(text)-1]...)
+		tb.InsertText(TextPos{Ln: lst, Ch: tb.LineLen(lst)}, txt, saveUndo, signal)
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////
 //   TextBufList, TextBufs
 
@@ -0,0 +1,491 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/lsp"
+	"github.com/goki/ki"
+)
+
+// testTextLines is the number of lines in the large benchmark text
+const testTextLines = 200000
+
+// testText returns text with given number of lines, of varying length
+func testText(nlines int) []byte {
+	var b bytes.Buffer
+	for ln := 0; ln < nlines; ln++ {
+		fmt.Fprintf(&b, "%6d\tfunc (tb *TextBuf) Line%d(ln int) []rune { // ünïcödé %s\n", ln, ln%97, "x"[:ln%2])
+	}
+	return b.Bytes()
+}
+
+// testTextBuf returns a new TextBuf with given text
+func testTextBuf(txt []byte) *TextBuf {
+	tb := &TextBuf{}
+	tb.InitName(tb, "test")
+	tb.FileModOk = true
+	tb.Txt = txt
+	tb.BytesToLines()
+	return tb
+}
+
+func TestTextBufEdit(t *testing.T) {
+	txt := testText(1000)
+	tb := testTextBuf(append([]byte(nil), txt...))
+	if tb.NLines != 1000 {
+		t.Fatalf("NLines: %d != 1000", tb.NLines)
+	}
+	if tb.NBytes() != len(txt) {
+		t.Errorf("NBytes: %d != %d", tb.NBytes(), len(txt))
+	}
+	pos := TextPos{Ln: 500, Ch: 40}
+	off := tb.ByteOffset(pos)
+	if pp := tb.PosFromByteOffset(off); pp != pos {
+		t.Errorf("PosFromByteOffset(%d): %v != %v", off, pp, pos)
+	}
+	ins := []byte("inserted\nmultiple\nlines ü")
+	tbe := tb.InsertText(pos, ins, true, false)
+	if tbe.Reg.End != (TextPos{Ln: 502, Ch: 7}) {
+		t.Errorf("insert end: %v", tbe.Reg.End)
+	}
+	if tb.NLines != 1002 {
+		t.Errorf("NLines after insert: %d != 1002", tb.NLines)
+	}
+	if string(tbe.ToBytes()) != string(ins) {
+		t.Errorf("insert region: %q != %q", tbe.ToBytes(), ins)
+	}
+	tb.DeleteText(tbe.Reg.Start, tbe.Reg.End, true, false)
+	tb.LinesToBytes()
+	if !bytes.Equal(tb.Txt, txt) {
+		t.Errorf("text not restored after insert, delete")
+	}
+}
+
+func BenchmarkTextBufOpen(b *testing.B) {
+	txt := testText(testTextLines)
+	b.SetBytes(int64(len(txt)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		testTextBuf(txt)
+	}
+}
+
+func BenchmarkTextBufInsert(b *testing.B) {
+	tb := testTextBuf(testText(testTextLines))
+	ins := []byte("some\ninserted text\n")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ln := (i * 7919) % tb.NLines
+		tb.InsertText(TextPos{Ln: ln, Ch: 3}, ins, false, false)
+	}
+}
+
+func BenchmarkTextBufInsertChar(b *testing.B) {
+	tb := testTextBuf(testText(testTextLines))
+	ins := []byte("x")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ln := (i * 7919) % tb.NLines
+		tb.InsertText(TextPos{Ln: ln, Ch: 3}, ins, false, false)
+	}
+}
+
+func BenchmarkTextBufDelete(b *testing.B) {
+	tb := testTextBuf(testText(testTextLines))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if tb.NLines < 10 {
+			b.StopTimer()
+			tb = testTextBuf(testText(testTextLines))
+			b.StartTimer()
+		}
+		ln := (i * 7919) % (tb.NLines - 2)
+		tb.DeleteText(TextPos{Ln: ln, Ch: 3}, TextPos{Ln: ln + 1, Ch: 5}, false, false)
+	}
+}
+
+func BenchmarkTextBufSave(b *testing.B) {
+	tb := testTextBuf(testText(testTextLines))
+	tb.InsertText(TextPos{Ln: testTextLines / 2}, []byte("edit\n"), false, false)
+	dir, err := ioutil.TempDir("", "textbuf")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "save.txt")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tb.EditDone()
+		if err := tb.WriteFile(fn); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTextBufSaveFile(t *testing.T) {
+	txt := testText(100)
+	tb := testTextBuf(append([]byte(nil), txt...))
+	tb.InsertText(TextPos{Ln: 10, Ch: 2}, []byte("edit\nü"), true, false)
+	var done []interface{}
+	tb.TextBufSig.Connect(tb.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if TextBufSignals(sig) == TextBufDone {
+			done = append(done, data)
+		}
+	})
+	tb.EditDone()
+	if len(done) != 1 || done[0] != nil {
+		t.Errorf("expected one TextBufDone signal with nil data, got: %v", done)
+	}
+	if !bytes.Equal(tb.Txt, txt) {
+		t.Errorf("EditDone should not copy the lines into Txt")
+	}
+	dir, err := ioutil.TempDir("", "textbuf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "save.txt")
+	if err := tb.SaveFile(gi.FileName(fn)); err != nil {
+		t.Fatal(err)
+	}
+	exp := tb.LinesToBytesCopy()
+	got, err := ioutil.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, exp) {
+		t.Errorf("saved file does not match the lines")
+	}
+	if !bytes.Equal(tb.Text(), exp) {
+		t.Errorf("Text does not match the lines after edit")
+	}
+}
+
+func TestTextBufUndoGroup(t *testing.T) {
+	txt := testText(100)
+	tb := testTextBuf(append([]byte(nil), txt...))
+	tb.InsertText(TextPos{Ln: 10, Ch: 2}, []byte("single"), true, false)
+	tb.UndoGroupStart()
+	pos := []TextPos{{Ln: 50, Ch: 3}, {Ln: 20, Ch: 0}, {Ln: 5, Ch: 7}}
+	for _, p := range pos {
+		tb.InsertText(p, []byte("a\nb"), true, false)
+	}
+	tb.DeleteText(TextPos{Ln: 1, Ch: 0}, TextPos{Ln: 3, Ch: 4}, true, false)
+	tb.UndoGroupEnd()
+	tb.Undo()
+	if tb.UndoPos != 1 {
+		t.Errorf("UndoPos after group undo: %d != 1", tb.UndoPos)
+	}
+	tb.Undo()
+	tb.LinesToBytes()
+	if !bytes.Equal(tb.Txt, txt) {
+		t.Errorf("text not restored after undo")
+	}
+	tb.Redo()
+	tb.Redo()
+	if tb.UndoPos != 5 {
+		t.Errorf("UndoPos after redo: %d != 5", tb.UndoPos)
+	}
+}
+
+func TestTextBufEditAdjustPos(t *testing.T) {
+	ins := &TextBufEdit{Reg: TextRegion{Start: TextPos{Ln: 2, Ch: 3}, End: TextPos{Ln: 4, Ch: 1}}}
+	del := &TextBufEdit{Reg: ins.Reg, Delete: true}
+	tests := []struct {
+		tbe      *TextBufEdit
+		pos, adj TextPos
+	}{
+		{ins, TextPos{Ln: 2, Ch: 2}, TextPos{Ln: 2, Ch: 2}},
+		{ins, TextPos{Ln: 2, Ch: 3}, TextPos{Ln: 4, Ch: 1}},
+		{ins, TextPos{Ln: 2, Ch: 5}, TextPos{Ln: 4, Ch: 3}},
+		{ins, TextPos{Ln: 3, Ch: 5}, TextPos{Ln: 5, Ch: 5}},
+		{del, TextPos{Ln: 2, Ch: 3}, TextPos{Ln: 2, Ch: 3}},
+		{del, TextPos{Ln: 3, Ch: 9}, TextPos{Ln: 2, Ch: 3}},
+		{del, TextPos{Ln: 4, Ch: 6}, TextPos{Ln: 2, Ch: 8}},
+		{del, TextPos{Ln: 7, Ch: 6}, TextPos{Ln: 5, Ch: 6}},
+	}
+	for _, tst := range tests {
+		if adj := tst.tbe.AdjustPos(tst.pos); adj != tst.adj {
+			t.Errorf("AdjustPos(%v) delete: %v: %v != %v", tst.pos, tst.tbe.Delete, adj, tst.adj)
+		}
+	}
+}
+
+func TestTextBufUndoCoalesce(t *testing.T) {
+	txt := testText(10)
+	tb := testTextBuf(append([]byte(nil), txt...))
+	tb.UndoTree = true
+	pos := TextPos{Ln: 2, Ch: 4}
+	for _, r := range "one two" {
+		tbe := tb.InsertText(pos, []byte(string(r)), true, false)
+		pos = tbe.Reg.End
+	}
+	if tb.UndoGroup != tb.Undos[0].Group+1 {
+		t.Errorf("typing not coalesced into two groups: %d, %d", tb.Undos[0].Group, tb.UndoGroup)
+	}
+	tb.Undo()
+	if tb.UndoPos != 3 {
+		t.Errorf("UndoPos after undoing word: %d != 3", tb.UndoPos)
+	}
+	tb.Undo()
+	tb.InsertText(TextPos{Ln: 5}, []byte("other"), true, false)
+	if tb.NUndoBranches() != 0 {
+		t.Errorf("NUndoBranches at end: %d != 0", tb.NUndoBranches())
+	}
+	tb.Undo()
+	if tb.NUndoBranches() != 1 {
+		t.Fatalf("NUndoBranches after undo: %d != 1", tb.NUndoBranches())
+	}
+	tb.UndoBranchNext()
+	tb.Redo()
+	tb.Redo()
+	tb.LinesToBytes()
+	if ln := string(tb.LineBytes(2)); ln[:11] != "    one two" {
+		t.Errorf("branch not redone: %q", ln)
+	}
+	tb.Undo()
+	tb.Undo()
+	tb.UndoBranchNext()
+	tb.Redo()
+	if ln := string(tb.LineBytes(5)); ln[:5] != "other" {
+		t.Errorf("other branch not redone: %q", ln)
+	}
+}
+
+func TestTextBufReplaceAll(t *testing.T) {
+	tb := testTextBuf([]byte("ünï Foo(a, b) foo\nfoobar Foo(c, d)\n"))
+	n, matches, err := tb.Find("foo", TextSearchOpts{WholeWord: true, IgnoreCase: true})
+	if err != nil || n != 3 {
+		t.Fatalf("Find whole word: %d != 3, %v", n, err)
+	}
+	if reg := matches[1].Reg; reg.Start != (TextPos{Ln: 0, Ch: 14}) || reg.End.Ch != 17 {
+		t.Errorf("Find rune region: %v", reg)
+	}
+	opts := TextSearchOpts{Regexp: true}
+	n, err = tb.ReplaceAll(`Foo\((\w+), (\w+)\)`, "Bar($2, $1)", opts)
+	if err != nil || n != 2 {
+		t.Fatalf("ReplaceAll: %d != 2, %v", n, err)
+	}
+	tb.LinesToBytes()
+	if exp := "ünï Bar(b, a) foo\nfoobar Bar(d, c)\n"; string(tb.Txt) != exp {
+		t.Errorf("ReplaceAll: %q != %q", tb.Txt, exp)
+	}
+	tb.Undo()
+	tb.LinesToBytes()
+	if exp := "ünï Foo(a, b) foo\nfoobar Foo(c, d)\n"; string(tb.Txt) != exp {
+		t.Errorf("ReplaceAll not undone as one edit: %q", tb.Txt)
+	}
+	rb, n, _ := ReplaceBytes(tb.Txt, "foo", "$1x", TextSearchOpts{})
+	if n != 2 || string(rb) != "ünï Foo(a, b) $1x\n$1xbar Foo(c, d)\n" {
+		t.Errorf("ReplaceBytes literal: %d %q", n, rb)
+	}
+}
+
+func TestTextBufPatch(t *testing.T) {
+	txts := []string{"", "a\n", "a\nb\nc\n", "b\nc\nd\n", "x\na\ny\nc\n", "\n\na\n", "c\nb\na\nb\nc\n"}
+	for _, as := range txts {
+		for _, bs := range txts {
+			ta := testTextBuf([]byte(as))
+			tb := testTextBuf([]byte(bs))
+			exp := string(tb.LinesToBytesCopy())
+			ta.PatchFromBuf(tb, ta.DiffBufs(tb), false)
+			if got := string(ta.LinesToBytesCopy()); got != exp {
+				t.Errorf("PatchFromBuf %q to %q: %q", as, bs, got)
+			}
+			ta = testTextBuf([]byte(as))
+			exp = string(ta.LinesToBytesCopy())
+			tb.PatchFromBuf(ta, ta.DiffBufs(tb).Reverse(), false)
+			if got := string(tb.LinesToBytesCopy()); got != exp {
+				t.Errorf("PatchFromBuf reversed %q to %q: %q", bs, as, got)
+			}
+		}
+	}
+	ta := testTextBuf([]byte("a\nb\nc\n"))
+	tb := testTextBuf([]byte("x\na\ny\nz\n"))
+	lna, lnb, opln := DiffAlign(ta.DiffBufs(tb))
+	if fmt.Sprint(lna, lnb, opln) != "[-1 0 1 2] [0 1 2 3] [0 1 2]" {
+		t.Errorf("DiffAlign: %v %v %v", lna, lnb, opln)
+	}
+}
+
+func TestTextBufLSP(t *testing.T) {
+	txt := []byte("hello wörld\n𝔸 error here\n")
+	tb := testTextBuf(append([]byte(nil), txt...))
+	tb.Filename = "test.txt"
+	fs, cl := lsp.NewFakeServer()
+	defer cl.Shutdown()
+	if err := tb.LSPAttachClient(cl); err != nil {
+		t.Fatal(err)
+	}
+	tb.InsertText(TextPos{Ln: 1, Ch: 1}, []byte("x\n𝔹y"), true, true)
+	tb.DeleteText(TextPos{Ln: 0, Ch: 7}, TextPos{Ln: 1, Ch: 1}, true, true)
+	tb.DeleteText(TextPos{Ln: 1, Ch: 0}, TextPos{Ln: 1, Ch: 2}, true, true)
+	cl.Completion(tb.LSP.URI, lsp.Position{}) // server has handled all changes after a call
+	tb.LinesToBytes()
+	if srv := fs.Text(tb.LSP.URI); srv != string(tb.Txt) {
+		t.Errorf("server text not synced: %q != %q", srv, tb.Txt)
+	}
+	if pos := tb.TextPosFromLSP(tb.LSPPos(TextPos{Ln: 1, Ch: 3})); pos != (TextPos{Ln: 1, Ch: 3}) {
+		t.Errorf("TextPosFromLSP(LSPPos): %v", pos)
+	}
+	tb.LSPDetach()
+	if cl.NDocs() != 0 {
+		t.Errorf("NDocs after detach: %d", cl.NDocs())
+	}
+}
+
+func TestTextBufMarkers(t *testing.T) {
+	tb := testTextBuf(testText(20))
+	tb.AddMarker(TextBufMarker{Kind: TextMarkerError, Reg: TextRegion{Start: TextPos{Ln: 5, Ch: 10}, End: TextPos{Ln: 5, Ch: 14}}, Msg: "err"})
+	tb.AddMarker(TextBufMarker{Kind: TextMarkerWarning, Reg: TextRegion{Start: TextPos{Ln: 12, Ch: 2}, End: TextPos{Ln: 12, Ch: 3}}})
+	if !tb.ToggleLineMarker(TextMarkerBreakpoint, 8) {
+		t.Errorf("breakpoint not added")
+	}
+	tb.InsertText(TextPos{Ln: 5, Ch: 2}, []byte("a\nb"), true, false)
+	tb.DeleteText(TextPos{Ln: 1, Ch: 0}, TextPos{Ln: 3, Ch: 0}, true, false)
+	if mks := tb.MarkersAt(TextPos{Ln: 4, Ch: 10}); len(mks) != 1 || mks[0].Msg != "err" {
+		t.Errorf("error marker not moved with edits: %+v", tb.Markers)
+	}
+	if mks := tb.LineMarkers(7); len(mks) != 1 || mks[0].Kind != TextMarkerBreakpoint {
+		t.Errorf("breakpoint not moved with edits: %+v", tb.Markers)
+	}
+	mk, _ := tb.NextDiag(TextPos{Ln: 4, Ch: 10}, true)
+	if mk.Kind != TextMarkerWarning || mk.Reg.Start.Ln != 11 {
+		t.Errorf("NextDiag: %+v", mk)
+	}
+	if mk, _ = tb.NextDiag(TextPos{Ln: 15}, true); mk.Kind != TextMarkerError {
+		t.Errorf("NextDiag did not wrap: %+v", mk)
+	}
+	if mk, _ = tb.NextDiag(TextPos{Ln: 4, Ch: 9}, false); mk.Kind != TextMarkerWarning {
+		t.Errorf("NextDiag backward did not wrap: %+v", mk)
+	}
+	if tb.ToggleLineMarker(TextMarkerBreakpoint, 7) || len(tb.Markers) != 2 {
+		t.Errorf("breakpoint not toggled off: %+v", tb.Markers)
+	}
+}
+
+func TestTextBufIndentFolds(t *testing.T) {
+	tb := testTextBuf([]byte("a:\n  b:\n    c\n\n    d\n  e\n\tf\n\t\tg\nh\n  i"))
+	folds := tb.IndentFolds(4)
+	exp := []TextFold{{St: 0, Ed: 7}, {St: 1, Ed: 4}, {St: 5, Ed: 7}, {St: 6, Ed: 7}, {St: 8, Ed: 9}}
+	if fmt.Sprint(folds) != fmt.Sprint(exp) {
+		t.Errorf("IndentFolds: %v != %v", folds, exp)
+	}
+}
+
+// testHiSrc is Go source with comments and strings spanning lines
+const testHiSrc = "package main\n\nimport \"fmt\"\n\n/* a comment\n   over lines */\nfunc main() {\n\ts := `raw\nstring`\n\tfmt.Println(\"hi\", s) // done\n}\n"
+
+// testHiWait waits for the background highlighting to finish
+func testHiWait(tb *TextBuf) {
+	for {
+		tb.MarkupMu.Lock()
+		run := tb.hiRunning
+		tb.MarkupMu.Unlock()
+		if !run {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// testHiCheck checks that the highlighting of the buffer is the same as from
+// lexing all of its lines afresh
+func testHiCheck(t *testing.T, tb *TextBuf, what string) bool {
+	lns := make([]*TextLine, tb.NLines)
+	for ln := range lns {
+		lns[ln] = NewTextLine(tb.LineBytes(ln))
+	}
+	tb.Hi.TokenizeLines(lns, len(lns), len(lns))
+	for ln, exp := range lns {
+		tl := tb.Lns.Line(ln)
+		if !tl.HiOk || fmt.Sprint(tl.HiToks) != fmt.Sprint(exp.HiToks) {
+			t.Errorf("%v: line %v %q: tokens %v != %v", what, ln, tl.Bytes, tl.HiToks, exp.HiToks)
+			return false
+		}
+	}
+	return true
+}
+
+// testHiEdits highlights the source repeated in a buffer with given
+// language and checks the highlighting after random edits inserting the
+// given strings or deleting text
+func testHiEdits(t *testing.T, lang, src string, ins []string, seed int64) {
+	defer func(n int) { HiChunkLines = n }(HiChunkLines)
+	HiChunkLines = 100
+	tb := &TextBuf{}
+	tb.InitName(tb, "test")
+	tb.FileModOk = true
+	tb.Hi.Lang = lang
+	tb.Hi.Style = "emacs"
+	tb.Txt = bytes.Repeat([]byte(src), 20)
+	tb.BytesToLines()
+	tb.MarkupAllLines()
+	testHiWait(tb)
+	if !testHiCheck(t, tb, lang+" open") {
+		return
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	for i := 0; i < 100; i++ {
+		st := TextPos{Ln: rnd.Intn(tb.NLines)}
+		st.Ch = rnd.Intn(tb.LineLen(st.Ln) + 1)
+		if rnd.Intn(3) == 0 {
+			tb.DeleteText(st, tb.PosForward(st, 1+rnd.Intn(20)), false, false)
+		} else {
+			tb.InsertText(st, []byte(ins[rnd.Intn(len(ins))]), false, false)
+		}
+		testHiWait(tb)
+		if !testHiCheck(t, tb, fmt.Sprintf("%v edit %v at %v", lang, i, st)) {
+			return
+		}
+	}
+}
+
+func TestTextBufHiTokens(t *testing.T) {
+	testHiEdits(t, "Go", testHiSrc, []string{"/*", "*/", "`", "\"", "//", "x", "\n", "\n/* c\n", "a */\n"}, 1)
+}
+
+// testHiHTMLSrc is html with a tag spanning lines and a script block,
+// which lex in states other than the root state
+const testHiHTMLSrc = "<html>\n<body>\n<div\n  class=\"main\"\n  id=\"top\">\ntext here\n</div>\n<script>\nvar x = 1;\nfunction f(a) {\n  return a + x;\n}\n</script>\n<p>more text</p>\n</body>\n</html>\n"
+
+func TestTextBufHiTokensHTML(t *testing.T) {
+	tb := &TextBuf{}
+	tb.InitName(tb, "test")
+	tb.Hi.Lang = "HTML"
+	tb.Hi.Style = "emacs"
+	tb.Hi.Init()
+	lns := make([]*TextLine, 0)
+	for _, ln := range strings.Split(strings.Repeat(testHiHTMLSrc, 20), "\n") {
+		lns = append(lns, NewTextLine([]byte(ln)))
+	}
+	defer func(n int) { HiRootStride = n }(HiRootStride)
+	HiRootStride = 1 // check every line
+	tb.Hi.TokenizeLines(lns, len(lns), len(lns))
+	for ln, tl := range lns[:16] {
+		switch {
+		case ln >= 2 && ln <= 3, ln >= 7 && ln <= 10: // in tag or script
+			if tl.HiRoot {
+				t.Errorf("line %v %q: expected not to end in root state", ln, tl.Bytes)
+			}
+		case ln == 0, ln == 12, ln == 13:
+			if !tl.HiRoot {
+				t.Errorf("line %v %q: expected to end in root state", ln, tl.Bytes)
+			}
+		}
+	}
+	testHiEdits(t, "HTML", testHiHTMLSrc, []string{"<div\n", ">", "\n", "<script>\n", "</script>\n", "x", "\"", "<!-- c\n", "-->"}, 1)
+}
@@ -0,0 +1,497 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/mimedata"
+	"github.com/goki/ki/ints"
+)
+
+// TextCursor is an additional cursor in a TextView for multi-cursor
+// editing, with its own selection between Start and Pos -- there is no
+// selection if they are the same
+type TextCursor struct {
+	Pos   TextPos `desc:"cursor position"`
+	Start TextPos `desc:"other end of the selection from the cursor position -- the same as Pos if nothing is selected"`
+}
+
+// HasSelection returns true if the cursor has a selected region
+func (tc *TextCursor) HasSelection() bool {
+	return tc.Start != tc.Pos
+}
+
+// Region returns the selected region of the cursor, in order
+func (tc *TextCursor) Region() TextRegion {
+	if tc.Pos.IsLess(tc.Start) {
+		return TextRegion{Start: tc.Pos, End: tc.Start}
+	}
+	return TextRegion{Start: tc.Start, End: tc.Pos}
+}
+
+// HasCursors returns true if there are additional cursors beyond the main
+// CursorPos, for multi-cursor editing
+func (tv *TextView) HasCursors() bool {
+	return len(tv.Cursors) > 0
+}
+
+// MainCursor returns the main CursorPos and SelectReg as a TextCursor
+func (tv *TextView) MainCursor() TextCursor {
+	tc := TextCursor{Pos: tv.CursorPos, Start: tv.CursorPos}
+	if tv.HasSelection() {
+		if tv.CursorPos == tv.SelectReg.Start {
+			tc.Start = tv.SelectReg.End
+		} else {
+			tc.Pos = tv.SelectReg.End
+			tc.Start = tv.SelectReg.Start
+		}
+	}
+	return tc
+}
+
+// SetMainCursor sets the main CursorPos and SelectReg from given TextCursor
+func (tv *TextView) SetMainCursor(tc TextCursor) {
+	tv.CursorPos = tc.Pos
+	tv.SelectStart = tc.Start
+	if tc.HasSelection() {
+		tv.SelectReg = tc.Region()
+	} else {
+		tv.SelectReg = TextRegionZero
+	}
+}
+
+// AllCursors returns the main cursor followed by any additional Cursors
+func (tv *TextView) AllCursors() []TextCursor {
+	cs := make([]TextCursor, 0, len(tv.Cursors)+1)
+	cs = append(cs, tv.MainCursor())
+	return append(cs, tv.Cursors...)
+}
+
+// SetAllCursors sets the main cursor to the first of given cursors, and the
+// additional Cursors to the rest, removing any at the same position as a
+// previous one
+func (tv *TextView) SetAllCursors(cs []TextCursor) {
+	tv.SetMainCursor(cs[0])
+	tv.Cursors = tv.Cursors[:0]
+	for i := 1; i < len(cs); i++ {
+		dup := false
+		for j := 0; j < i; j++ {
+			if cs[j].Pos == cs[i].Pos {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			tv.Cursors = append(tv.Cursors, cs[i])
+		}
+	}
+}
+
+// ClearCursors removes all the additional cursors, and ends any box
+// selection, leaving the main cursor -- returns true if there were any
+func (tv *TextView) ClearCursors() bool {
+	had := tv.HasCursors() || tv.BoxSelect
+	tv.Cursors = nil
+	tv.BoxSelect = false
+	if had {
+		tv.RenderAllLines()
+		tv.RenderCursor(true)
+	}
+	return had
+}
+
+// AddCursor adds an additional cursor at given position, or removes it if
+// there already is one there -- the main cursor moves to the new one
+func (tv *TextView) AddCursor(pos TextPos) {
+	pos = tv.Buf.ValidPos(pos)
+	cs := tv.AllCursors()
+	for i := range cs {
+		if cs[i].Pos == pos && len(cs) > 1 {
+			cs = append(cs[:i], cs[i+1:]...)
+			tv.SetAllCursors(cs)
+			tv.RenderAllLines()
+			tv.RenderCursor(true)
+			return
+		}
+	}
+	tv.BoxSelect = false
+	tv.SelectMode = false
+	ncs := append([]TextCursor{{Pos: pos, Start: pos}}, cs...)
+	tv.SetAllCursors(ncs)
+	tv.RenderAllLines()
+	tv.RenderCursor(true)
+}
+
+// CursorAddNextMatch adds a cursor selecting the next occurrence of the text
+// selected by the main cursor, after the last one added -- if there is no
+// selection, the word at the cursor is selected first
+func (tv *TextView) CursorAddNextMatch() {
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	if !tv.HasSelection() {
+		reg := tv.WordRegion(tv.CursorPos)
+		if reg.Start == reg.End {
+			return
+		}
+		tv.SetMainCursor(TextCursor{Pos: reg.End, Start: reg.Start})
+		tv.RenderAllLines()
+		tv.RenderCursor(true)
+		return
+	}
+	tbe := tv.Selection()
+	find := tbe.ToBytes()
+	_, matches := tv.Buf.Search(find, false)
+	if len(matches) == 0 {
+		return
+	}
+	cs := tv.AllCursors()
+	after := tv.SelectReg.End
+	mi := -1
+	for i, m := range matches {
+		if !m.Reg.Start.IsLess(after) {
+			mi = i
+			break
+		}
+	}
+	if mi < 0 {
+		mi = 0 // wrap around
+	}
+	for n := 0; n < len(matches); n++ {
+		m := matches[(mi+n)%len(matches)]
+		used := false
+		for _, tc := range cs {
+			if tc.Region() == m.Reg {
+				used = true
+				break
+			}
+		}
+		if used {
+			continue
+		}
+		tv.BoxSelect = false
+		tv.SelectMode = false
+		ncs := append([]TextCursor{{Pos: m.Reg.End, Start: m.Reg.Start}}, cs...)
+		tv.SetAllCursors(ncs)
+		tv.ScrollCursorToCenterIfHidden()
+		tv.RenderAllLines()
+		tv.RenderCursor(true)
+		return
+	}
+}
+
+// CursorSplitLines splits the selections of the main and additional cursors
+// into a cursor for each line, at the end of the selected part of the line
+func (tv *TextView) CursorSplitLines() {
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	var ncs []TextCursor
+	for _, tc := range tv.AllCursors() {
+		reg := tc.Region()
+		if reg.Start.Ln == reg.End.Ln {
+			ncs = append(ncs, tc)
+			continue
+		}
+		for ln := reg.Start.Ln; ln <= reg.End.Ln; ln++ {
+			st := TextPos{Ln: ln}
+			ed := TextPos{Ln: ln, Ch: tv.Buf.LineLen(ln)}
+			if ln == reg.Start.Ln {
+				st = reg.Start
+			}
+			if ln == reg.End.Ln {
+				if reg.End.Ch == 0 {
+					break
+				}
+				ed = reg.End
+			}
+			ncs = append(ncs, TextCursor{Pos: ed, Start: st})
+		}
+	}
+	if len(ncs) == 0 {
+		return
+	}
+	// last one is the main cursor
+	ncs = append([]TextCursor{ncs[len(ncs)-1]}, ncs[:len(ncs)-1]...)
+	tv.SelectMode = false
+	tv.BoxSelect = false
+	tv.SetAllCursors(ncs)
+	tv.RenderAllLines()
+	tv.RenderCursor(true)
+}
+
+// StartBoxSelect starts a rectangular (column) box selection at given
+// position, which is then extended with SetBoxSelect
+func (tv *TextView) StartBoxSelect(pos TextPos) {
+	tv.Cursors = nil
+	tv.SelectReset()
+	tv.SetCursor(pos)
+	tv.SelectStart = tv.CursorPos
+	tv.BoxSelect = true
+	tv.RenderAllLines()
+	tv.RenderCursor(true)
+}
+
+// SetBoxSelect sets a rectangular (column) box selection between the given
+// corner positions, as a cursor on each line with the part of the line
+// within the columns of the box selected -- lines that are too short to
+// reach the box are skipped.  The main cursor is on the line of ed.  Columns
+// are in characters (runes), not visual positions.
+func (tv *TextView) SetBoxSelect(st, ed TextPos) {
+	st = tv.Buf.ValidPos(st)
+	ed = tv.Buf.ValidPos(ed)
+	stln, edln := ints.MinInt(st.Ln, ed.Ln), ints.MaxInt(st.Ln, ed.Ln)
+	stc, edc := ints.MinInt(st.Ch, ed.Ch), ints.MaxInt(st.Ch, ed.Ch)
+	var ncs []TextCursor
+	for ln := stln; ln <= edln; ln++ {
+		llen := tv.Buf.LineLen(ln)
+		if llen < stc && stc != edc && ln != ed.Ln {
+			continue
+		}
+		sp := TextPos{Ln: ln, Ch: ints.MinInt(st.Ch, llen)}
+		ep := TextPos{Ln: ln, Ch: ints.MinInt(ed.Ch, llen)}
+		tc := TextCursor{Pos: ep, Start: sp}
+		if ln == ed.Ln {
+			ncs = append([]TextCursor{tc}, ncs...)
+		} else {
+			ncs = append(ncs, tc)
+		}
+	}
+	tv.BoxSelect = true
+	tv.SelectStart = st
+	cs := tv.AllCursors()
+	tv.SetAllCursors(ncs)
+	tv.SelectStart = st
+	tv.RenderCursorLines(cs)
+	tv.RenderCursor(true)
+}
+
+// RenderCursorLines renders the lines of all the current cursors and
+// selections, and those of the given previous cursors
+func (tv *TextView) RenderCursorLines(prev []TextCursor) {
+	stln, edln := tv.CursorPos.Ln, tv.CursorPos.Ln
+	for _, tc := range append(prev, tv.AllCursors()...) {
+		reg := tc.Region()
+		stln = ints.MinInt(stln, reg.Start.Ln)
+		edln = ints.MaxInt(edln, reg.End.Ln)
+	}
+	tv.RenderLines(stln, edln)
+}
+
+// WordRegion returns the region of the word at given position, which is
+// empty if the position is not on a word
+func (tv *TextView) WordRegion(pos TextPos) TextRegion {
+	pos = tv.Buf.ValidPos(pos)
+	txt := tv.Buf.Line(pos.Ln)
+	st, ed := pos.Ch, pos.Ch
+	for st > 0 && !tv.IsWordBreak(txt[st-1]) {
+		st--
+	}
+	for ed < len(txt) && !tv.IsWordBreak(txt[ed]) {
+		ed++
+	}
+	return TextRegion{Start: TextPos{Ln: pos.Ln, Ch: st}, End: TextPos{Ln: pos.Ln, Ch: ed}}
+}
+
+// EditCursors calls given edit function for the main and additional
+// cursors, in reverse order of position, as one undo group -- the function
+// makes its edits through the TextBuf, saving them for undo, and returns the
+// cursor after the edit -- the other cursors are moved along with the
+// edits it made
+func (tv *TextView) EditCursors(fun func(tc TextCursor) TextCursor) {
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	cs := tv.AllCursors()
+	ord := make([]int, len(cs))
+	for i := range ord {
+		ord[i] = i
+	}
+	sort.Slice(ord, func(i, j int) bool {
+		return cs[ord[j]].Pos.IsLess(cs[ord[i]].Pos)
+	})
+	tv.Buf.UndoGroupStart()
+	for _, ci := range ord {
+		upos := tv.Buf.UndoPos
+		cs[ci] = fun(cs[ci])
+		for _, tbe := range tv.Buf.Undos[upos:tv.Buf.UndoPos] {
+			for oi := range cs {
+				if oi == ci {
+					continue
+				}
+				cs[oi].Pos = tbe.AdjustPos(cs[oi].Pos)
+				cs[oi].Start = tbe.AdjustPos(cs[oi].Start)
+			}
+		}
+	}
+	tv.Buf.UndoGroupEnd()
+	tv.SelectMode = false
+	tv.BoxSelect = false
+	tv.SetAllCursors(cs)
+	tv.SetCursorCol(tv.CursorPos)
+	tv.ScrollCursorToCenterIfHidden()
+	tv.RenderAllLines()
+	tv.RenderCursor(true)
+}
+
+// deleteCursorSel deletes the selected text of given cursor, returning the
+// cursor at the start of it
+func (tv *TextView) deleteCursorSel(tc TextCursor) TextCursor {
+	if !tc.HasSelection() {
+		return tc
+	}
+	reg := tc.Region()
+	tv.Buf.DeleteText(reg.Start, reg.End, true, true)
+	return TextCursor{Pos: reg.Start, Start: reg.Start}
+}
+
+// CursorsInsert inserts given text at each of the cursors, replacing any
+// selected text
+func (tv *TextView) CursorsInsert(txt []byte) {
+	tv.EditCursors(func(tc TextCursor) TextCursor {
+		tc = tv.deleteCursorSel(tc)
+		tbe := tv.Buf.InsertText(tc.Pos, txt, true, true)
+		if tbe == nil {
+			return tc
+		}
+		return TextCursor{Pos: tbe.Reg.End, Start: tbe.Reg.End}
+	})
+}
+
+// CursorsBackspace deletes the selected text, or the character(s) before
+// the cursor, at each of the cursors
+func (tv *TextView) CursorsBackspace(steps int) {
+	tv.EditCursors(func(tc TextCursor) TextCursor {
+		if tc.HasSelection() {
+			return tv.deleteCursorSel(tc)
+		}
+		st := tv.Buf.PosBackward(tc.Pos, steps)
+		tv.Buf.DeleteText(st, tc.Pos, true, true)
+		return TextCursor{Pos: st, Start: st}
+	})
+}
+
+// CursorsDelete deletes the selected text, or the character(s) after the
+// cursor, at each of the cursors
+func (tv *TextView) CursorsDelete(steps int) {
+	tv.EditCursors(func(tc TextCursor) TextCursor {
+		if tc.HasSelection() {
+			return tv.deleteCursorSel(tc)
+		}
+		ed := tv.Buf.PosForward(tc.Pos, steps)
+		tv.Buf.DeleteText(tc.Pos, ed, true, true)
+		return tc
+	})
+}
+
+// CursorsText returns the text selected by each of the cursors that have a
+// selection, in order of position
+func (tv *TextView) CursorsText() [][]byte {
+	cs := tv.AllCursors()
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].Pos.IsLess(cs[j].Pos)
+	})
+	var txts [][]byte
+	for _, tc := range cs {
+		if !tc.HasSelection() {
+			continue
+		}
+		reg := tc.Region()
+		txts = append(txts, tv.Buf.Region(reg.Start, reg.End).ToBytes())
+	}
+	return txts
+}
+
+// CursorsCopy copies the text selected by each of the cursors to the
+// clipboard, one per line
+func (tv *TextView) CursorsCopy() [][]byte {
+	txts := tv.CursorsText()
+	if len(txts) == 0 {
+		return nil
+	}
+	oswin.TheApp.ClipBoard(tv.Viewport.Win.OSWin).Write(mimedata.NewTextBytes(bytes.Join(txts, []byte("\n"))))
+	return txts
+}
+
+// CursorsCut copies the text selected by each of the cursors to the
+// clipboard, one per line, and deletes it
+func (tv *TextView) CursorsCut() [][]byte {
+	txts := tv.CursorsCopy()
+	if txts != nil {
+		tv.EditCursors(tv.deleteCursorSel)
+	}
+	return txts
+}
+
+// CursorsPaste inserts given pasted text at each of the cursors -- if the
+// text has one line per cursor, e.g., from CursorsCopy, each cursor gets
+// its own line, in order of position, otherwise all get the whole text
+func (tv *TextView) CursorsPaste(txt []byte) {
+	lns := bytes.Split(txt, []byte("\n"))
+	cs := tv.AllCursors()
+	if len(lns) != len(cs) {
+		tv.CursorsInsert(txt)
+		return
+	}
+	ord := make([]TextPos, len(cs))
+	for i := range cs {
+		ord[i] = cs[i].Pos
+	}
+	sort.Slice(ord, func(i, j int) bool {
+		return ord[i].IsLess(ord[j])
+	})
+	lnfor := make(map[TextPos][]byte, len(ord))
+	for i, pos := range ord {
+		lnfor[pos] = lns[i]
+	}
+	tv.EditCursors(func(tc TextCursor) TextCursor {
+		ln := lnfor[tc.Pos]
+		tc = tv.deleteCursorSel(tc)
+		tbe := tv.Buf.InsertText(tc.Pos, ln, true, true)
+		if tbe == nil {
+			return tc
+		}
+		return TextCursor{Pos: tbe.Reg.End, Start: tbe.Reg.End}
+	})
+}
+
+// MoveCursors moves the additional cursors for given cursor motion key
+// function, along with the main cursor -- if selecting, their selections
+// are extended, otherwise they are reset
+func (tv *TextView) MoveCursors(kf gi.KeyFuns, sel bool) {
+	if !tv.HasCursors() {
+		return
+	}
+	for i := range tv.Cursors {
+		tc := &tv.Cursors[i]
+		pos := tc.Pos
+		switch kf {
+		case gi.KeyFunMoveRight:
+			pos = tv.Buf.PosForward(pos, 1)
+		case gi.KeyFunMoveLeft:
+			pos = tv.Buf.PosBackward(pos, 1)
+		case gi.KeyFunMoveUp:
+			if pos.Ln > 0 {
+				pos.Ln--
+			}
+		case gi.KeyFunMoveDown:
+			if pos.Ln < tv.Buf.NLines-1 {
+				pos.Ln++
+			}
+		case gi.KeyFunHome:
+			pos.Ch = 0
+		case gi.KeyFunEnd:
+			pos.Ch = tv.Buf.LineLen(pos.Ln)
+		}
+		tc.Pos = tv.Buf.ValidPos(pos)
+		if !sel {
+			tc.Start = tc.Pos
+		}
+	}
+	tv.SetAllCursors(tv.AllCursors())
+	tv.RenderAllLines()
+	tv.RenderCursor(true)
+}
@@ -0,0 +1,461 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"image"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/alecthomas/chroma"
+	"github.com/chewxy/math32"
+	"github.com/goki/gi"
+)
+
+// TextFold is a region of lines that can be folded (collapsed) in a
+// TextView -- when folded, the lines after the first one through Ed are
+// hidden, and the first line shows a fold marker in the gutter
+type TextFold struct {
+	St int `desc:"starting line of the region, which stays visible when folded"`
+	Ed int `desc:"ending line of the region (inclusive)"`
+}
+
+// Hides returns true if given line is hidden when this region is folded
+func (tf TextFold) Hides(ln int) bool {
+	return ln > tf.St && ln <= tf.Ed
+}
+
+// FoldIndentLangs are the languages whose fold regions are computed from
+// indentation even though they have a lexer, as their blocks are not
+// delimited by braces
+var FoldIndentLangs = map[string]bool{
+	"Python":   true,
+	"YAML":     true,
+	"Makefile": true,
+	"Haskell":  true,
+}
+
+// FoldRegions returns the regions of lines that can be folded, sorted by
+// starting line, with at most one region starting on any line -- computed
+// from the brackets found by the syntax highlighting lexer, or from the
+// indentation of the lines (with tabs of given size) for plain text and
+// for the languages in FoldIndentLangs
+func (tb *TextBuf) FoldRegions(tabSz int) []TextFold {
+	if tb.NLines == 0 {
+		return nil
+	}
+	if tb.Hi.lexer != nil && !FoldIndentLangs[tb.Hi.Lang] {
+		tb.MarkupMu.Lock()
+		txt := tb.LinesToBytesCopy()
+		tb.MarkupMu.Unlock()
+		return tb.Hi.BracketFolds(txt)
+	}
+	return tb.IndentFolds(tabSz)
+}
+
+// IndentFolds returns the fold regions from the indentation of the lines,
+// with tabs of given size: each line followed by more indented lines starts
+// a region through the last of them -- blank lines are ignored
+func (tb *TextBuf) IndentFolds(tabSz int) []TextFold {
+	var folds []TextFold
+	var stack []TextFold // St = line, Ed = indent
+	prv := -1            // last non-blank line
+	for ln := 0; ln < tb.NLines; ln++ {
+		if tb.lineIsBlank(ln) {
+			continue
+		}
+		ind, spc := tb.LineIndent(ln, tabSz)
+		if !spc {
+			ind *= tabSz
+		}
+		for len(stack) > 0 && stack[len(stack)-1].Ed >= ind {
+			st := stack[len(stack)-1].St
+			stack = stack[:len(stack)-1]
+			if prv > st {
+				folds = append(folds, TextFold{St: st, Ed: prv})
+			}
+		}
+		stack = append(stack, TextFold{St: ln, Ed: ind})
+		prv = ln
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		if st := stack[i].St; prv > st {
+			folds = append(folds, TextFold{St: st, Ed: prv})
+		}
+	}
+	return sortFolds(folds)
+}
+
+// lineIsBlank returns true if given line has only white space
+func (tb *TextBuf) lineIsBlank(ln int) bool {
+	for _, r := range tb.Line(ln) {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// BracketFolds returns the fold regions for the brackets (punctuation
+// tokens) in given text from the lexer: each bracket spanning more than two
+// lines gives a region from its opening line to the line before its
+// closing one, which stays visible
+func (hm *HiMarkup) BracketFolds(txt []byte) []TextFold {
+	if hm.lexer == nil {
+		return nil
+	}
+	iterator, err := hm.lexer.Tokenise(nil, string(txt))
+	if err != nil {
+		return nil
+	}
+	var folds []TextFold
+	var stack []int
+	ln := 0
+	for _, tok := range iterator.Tokens() {
+		if !tok.Type.InCategory(chroma.Punctuation) {
+			ln += strings.Count(tok.Value, "\n")
+			continue
+		}
+		for _, r := range tok.Value {
+			switch r {
+			case '\n':
+				ln++
+			case '{', '(', '[':
+				stack = append(stack, ln)
+			case '}', ')', ']':
+				if len(stack) == 0 {
+					continue
+				}
+				st := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if ln-1 > st {
+					folds = append(folds, TextFold{St: st, Ed: ln - 1})
+				}
+			}
+		}
+	}
+	return sortFolds(folds)
+}
+
+// sortFolds sorts fold regions by starting line, keeping only the largest
+// region starting on any line
+func sortFolds(folds []TextFold) []TextFold {
+	sort.Slice(folds, func(i, j int) bool {
+		if folds[i].St == folds[j].St {
+			return folds[i].Ed > folds[j].Ed
+		}
+		return folds[i].St < folds[j].St
+	})
+	n := 0
+	for i, f := range folds {
+		if i > 0 && f.St == folds[n-1].St {
+			continue
+		}
+		folds[n] = f
+		n++
+	}
+	return folds[:n]
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   TextView
+
+// UpdateFolds recomputes the fold regions (Folds) from the buffer -- done
+// automatically on layout after the text has changed
+func (tv *TextView) UpdateFolds() {
+	tv.foldsStale = false
+	if tv.Buf == nil {
+		tv.Folds = nil
+		return
+	}
+	tv.Folds = tv.Buf.FoldRegions(tv.Sty.Text.TabSize)
+}
+
+// FoldAt returns the fold region starting on given line, if any
+func (tv *TextView) FoldAt(ln int) (TextFold, bool) {
+	idx := sort.Search(len(tv.Folds), func(i int) bool { return tv.Folds[i].St >= ln })
+	if idx < len(tv.Folds) && tv.Folds[idx].St == ln {
+		return tv.Folds[idx], true
+	}
+	return TextFold{}, false
+}
+
+// foldedIdx returns the index in Folded of the folded region starting on
+// given line, or where it would be inserted, and whether it is there
+func (tv *TextView) foldedIdx(ln int) (int, bool) {
+	idx := sort.Search(len(tv.Folded), func(i int) bool { return tv.Folded[i].St >= ln })
+	return idx, idx < len(tv.Folded) && tv.Folded[idx].St == ln
+}
+
+// IsFolded returns true if the region starting on given line is folded
+func (tv *TextView) IsFolded(ln int) bool {
+	_, ok := tv.foldedIdx(ln)
+	return ok
+}
+
+// IsLineHidden returns true if given line is hidden in a folded region
+func (tv *TextView) IsLineHidden(ln int) bool {
+	return ln >= 0 && ln < len(tv.hidden) && tv.hidden[ln]
+}
+
+// NextVisibleLine returns the next line after given one that is not hidden,
+// or -1 if none
+func (tv *TextView) NextVisibleLine(ln int) int {
+	for ln++; ln < tv.NLines; ln++ {
+		if !tv.IsLineHidden(ln) {
+			return ln
+		}
+	}
+	return -1
+}
+
+// PrevVisibleLine returns the previous line before given one that is not
+// hidden, or -1 if none
+func (tv *TextView) PrevVisibleLine(ln int) int {
+	for ln--; ln >= 0; ln-- {
+		if !tv.IsLineHidden(ln) {
+			return ln
+		}
+	}
+	return -1
+}
+
+// VisibleRegion returns given region limited to the visible lines: a start
+// on a hidden line moves to the next visible line, and an end on a hidden
+// line moves to the end of the previous visible line -- false if the region
+// is entirely hidden
+func (tv *TextView) VisibleRegion(reg TextRegion) (TextRegion, bool) {
+	if tv.IsLineHidden(reg.Start.Ln) {
+		ln := tv.NextVisibleLine(reg.Start.Ln)
+		if ln < 0 {
+			return reg, false
+		}
+		reg.Start = TextPos{Ln: ln}
+	}
+	if tv.IsLineHidden(reg.End.Ln) {
+		ln := tv.PrevVisibleLine(reg.End.Ln)
+		reg.End = TextPos{Ln: ln, Ch: tv.Buf.LineLen(ln)}
+	}
+	if reg.End.IsLess(reg.Start) {
+		return reg, false
+	}
+	return reg, true
+}
+
+// LineSize returns the rendered height of given line: zero if it is hidden
+func (tv *TextView) LineSize(ln int) float32 {
+	if tv.IsLineHidden(ln) {
+		return 0
+	}
+	return gi.Max32(tv.Renders[ln].Size.Y, tv.LineHeight)
+}
+
+// Fold folds the region starting on given line, or else the innermost
+// region containing it -- returns false if there is no such region
+func (tv *TextView) Fold(ln int) bool {
+	if tv.foldsStale {
+		tv.UpdateFolds()
+	}
+	tf, ok := tv.FoldAt(ln)
+	if !ok {
+		for _, f := range tv.Folds {
+			if f.St > ln {
+				break
+			}
+			if f.Hides(ln) && !tv.IsLineHidden(f.St) {
+				tf, ok = f, true // later ones are inner
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	idx, has := tv.foldedIdx(tf.St)
+	if has {
+		return true
+	}
+	tv.Folded = append(tv.Folded, TextFold{})
+	copy(tv.Folded[idx+1:], tv.Folded[idx:])
+	tv.Folded[idx] = tf
+	tv.FoldsChanged()
+	return true
+}
+
+// Unfold unfolds the region starting on given line -- returns false if it
+// was not folded
+func (tv *TextView) Unfold(ln int) bool {
+	idx, has := tv.foldedIdx(ln)
+	if !has {
+		return false
+	}
+	tv.Folded = append(tv.Folded[:idx], tv.Folded[idx+1:]...)
+	tv.FoldsChanged()
+	return true
+}
+
+// ToggleFold unfolds the region starting on given line if it is folded,
+// and otherwise folds it (see Fold) -- returns false if nothing to fold
+func (tv *TextView) ToggleFold(ln int) bool {
+	if tv.Unfold(ln) {
+		return true
+	}
+	return tv.Fold(ln)
+}
+
+// FoldAll folds all the regions
+func (tv *TextView) FoldAll() {
+	tv.UpdateFolds()
+	tv.Folded = append(tv.Folded[:0], tv.Folds...)
+	tv.FoldsChanged()
+}
+
+// UnfoldAll unfolds all the regions
+func (tv *TextView) UnfoldAll() {
+	if len(tv.Folded) == 0 {
+		return
+	}
+	tv.Folded = nil
+	tv.FoldsChanged()
+}
+
+// RevealLine unfolds the regions that hide given line -- returns false if
+// it was not hidden
+func (tv *TextView) RevealLine(ln int) bool {
+	if !tv.IsLineHidden(ln) {
+		return false
+	}
+	n := 0
+	for _, f := range tv.Folded {
+		if !f.Hides(ln) {
+			tv.Folded[n] = f
+			n++
+		}
+	}
+	tv.Folded = tv.Folded[:n]
+	tv.FoldsChanged()
+	return true
+}
+
+// FoldsChanged updates the view after the folded regions have changed,
+// moving the cursor out of any hidden lines
+func (tv *TextView) FoldsChanged() {
+	tv.setHidden()
+	if tv.Renders == nil || tv.Viewport == nil {
+		return
+	}
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	if tv.IsLineHidden(tv.CursorPos.Ln) {
+		ln := tv.PrevVisibleLine(tv.CursorPos.Ln)
+		tv.CursorPos = TextPos{Ln: ln, Ch: tv.Buf.LineLen(ln)}
+		tv.CursorMovedSig()
+	}
+	tv.LayoutOffs()
+	tv.RenderAllLines()
+	tv.ScrollCursorToCenterIfHidden()
+	tv.RenderCursor(true)
+}
+
+// setHidden sets the hidden flags for the lines from the folded regions
+func (tv *TextView) setHidden() {
+	nln := 0
+	if tv.Buf != nil {
+		nln = tv.Buf.NLines
+	}
+	if cap(tv.hidden) >= nln {
+		tv.hidden = tv.hidden[:nln]
+		for i := range tv.hidden {
+			tv.hidden[i] = false
+		}
+	} else {
+		tv.hidden = make([]bool, nln)
+	}
+	for _, f := range tv.Folded {
+		for ln := f.St + 1; ln <= f.Ed && ln < nln; ln++ {
+			tv.hidden[ln] = true
+		}
+	}
+}
+
+// adjustFolds adjusts the fold regions for given edit of the buffer,
+// dropping those that are now empty
+func (tv *TextView) adjustFolds(tbe *TextBufEdit) {
+	tv.foldsStale = true
+	if tbe.Reg.Start.Ln == tbe.Reg.End.Ln {
+		return
+	}
+	adj := func(folds []TextFold) []TextFold {
+		n := 0
+		for _, f := range folds {
+			f.St = tbe.AdjustPos(TextPos{Ln: f.St}).Ln
+			f.Ed = tbe.AdjustPos(TextPos{Ln: f.Ed + 1}).Ln - 1
+			if f.Ed > f.St {
+				folds[n] = f
+				n++
+			}
+		}
+		return sortFolds(folds[:n])
+	}
+	tv.Folds = adj(tv.Folds)
+	tv.Folded = adj(tv.Folded)
+	tv.setHidden()
+}
+
+// LayoutOffs recomputes the line offsets from the current line renders,
+// with hidden lines taking no space, and resizes as needed
+func (tv *TextView) LayoutOffs() {
+	off := float32(0)
+	for ln := 0; ln < tv.NLines; ln++ {
+		tv.Offs[ln] = off
+		off += tv.LineSize(ln)
+	}
+	extraHalf := tv.LineHeight * 0.5 * float32(tv.VisSize.Y)
+	nwSz := gi.Vec2D{float32(tv.LinesSize.X), off + extraHalf}.ToPointCeil()
+	tv.ResizeIfNeeded(nwSz)
+}
+
+// RenderFoldMarker renders the fold toggle in the gutter for a fold region
+// starting on given line: pointing down when open, and right when folded --
+// called within context of other render
+func (tv *TextView) RenderFoldMarker(ln int) {
+	if _, ok := tv.FoldAt(ln); !ok && !tv.IsFolded(ln) {
+		return
+	}
+	rs := &tv.Viewport.Render
+	pc := &rs.Paint
+	sty := &tv.Sty
+	r := 0.35 * math32.Min(tv.LineHeight, 2*sty.Font.Ch)
+	cx := tv.foldMarkerX()
+	cy := tv.CharStartPos(TextPos{Ln: ln}).Y + 0.5*tv.LineHeight
+	pc.StrokeStyle.SetColor(nil)
+	pc.FillStyle.SetColor(&sty.Font.Color)
+	if tv.IsFolded(ln) {
+		pc.DrawPolygon(rs, []gi.Vec2D{{cx - 0.6*r, cy - r}, {cx + 0.6*r, cy}, {cx - 0.6*r, cy + r}})
+	} else {
+		pc.DrawPolygon(rs, []gi.Vec2D{{cx - r, cy - 0.6*r}, {cx + r, cy - 0.6*r}, {cx, cy + 0.6*r}})
+	}
+	pc.FillStrokeClear(rs)
+}
+
+// foldMarkerX returns the x position of the center of the fold markers in
+// the gutter, in Viewport coordinates
+func (tv *TextView) foldMarkerX() float32 {
+	return tv.RenderStartPos().X + (float32(tv.LineNoDigs)+2.8)*tv.Sty.Font.Ch
+}
+
+// FoldMarkerAt returns true if given point, relative to the view (see
+// PointToRelPos), is on the fold marker of given line in the gutter -- the
+// line must start a fold region, or a folded one, for a marker to be shown
+func (tv *TextView) FoldMarkerAt(pt image.Point, ln int) bool {
+	if !tv.Opts.LineNos {
+		return false
+	}
+	if _, ok := tv.FoldAt(ln); !ok && !tv.IsFolded(ln) {
+		return false
+	}
+	cx := tv.foldMarkerX() - float32(tv.VpBBox.Min.X)
+	return math32.Abs(float32(pt.X)-cx) <= 0.8*tv.Sty.Font.Ch
+}
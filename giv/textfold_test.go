@@ -0,0 +1,163 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/oswin/driver/offscreen"
+	"github.com/goki/gi/oswin/mouse"
+)
+
+// testFoldSrc is Go source with nested fold regions: lines 2-8 and 3-5
+const testFoldSrc = "package main\n\nfunc f(a int) {\n\tif a > 0 {\n\t\ta++\n\t\ta--\n\t}\n\tx := []int{1,\n\t\t2}\n}\n\nfunc g() {}\n"
+
+func TestBracketFolds(t *testing.T) {
+	tests := []struct {
+		src   string
+		folds []TextFold
+	}{
+		{testFoldSrc, []TextFold{{2, 8}, {3, 5}}},
+		{"f(\n\ta,\n\tb)\n", []TextFold{{0, 1}}},
+		{"{\n}\n", nil},                                  // nothing between the brackets
+		{"// {\nx := \"{\"\ny := '('\nz := 1\n}\n", nil}, // not punctuation
+		{"var x = [][]int{\n\t{1,\n\t\t2,\n\t\t3},\n}\n", []TextFold{{0, 3}, {1, 2}}},
+	}
+	hm := &HiMarkup{Lang: "Go", Style: "emacs"}
+	hm.Init()
+	for _, tt := range tests {
+		folds := hm.BracketFolds([]byte(tt.src))
+		if !reflect.DeepEqual(folds, tt.folds) {
+			t.Errorf("%q: folds %v != %v", tt.src, folds, tt.folds)
+		}
+	}
+}
+
+// testTextView returns a new TextView showing Go source with line numbers,
+// in a running window that must be closed with testClose
+func testTextView(t *testing.T, src string) (*gi.Window, *TextView) {
+	win, mfr := testWindow(t, "textview-test", 600, 400)
+	tv := mfr.AddNewChild(KiT_TextView, "textview").(*TextView)
+	tb := testTextBuf([]byte(src))
+	tb.Hi.Lang = "Go"
+	tb.Hi.Style = "emacs"
+	tb.Hi.Init()
+	tv.Opts.LineNos = true
+	tv.SetBuf(tb)
+	testStart(t, win)
+	return win, tv
+}
+
+// testTextPt returns the point, relative to the view, in the middle of the
+// character at given position
+func testTextPt(tv *TextView, pos TextPos) image.Point {
+	sp := tv.CharStartPos(pos)
+	return image.Point{X: int(sp.X+0.4*tv.Sty.Font.Ch) - tv.VpBBox.Min.X, Y: int(sp.Y+0.5*tv.LineHeight) - tv.VpBBox.Min.Y}
+}
+
+func TestTextViewFolds(t *testing.T) {
+	win, tv := testTextView(t, testFoldSrc)
+	defer testClose(t, win)
+	testInLoop(t, win, func() {
+		tv.UpdateFolds()
+		if exp := []TextFold{{2, 8}, {3, 5}}; !reflect.DeepEqual(tv.Folds, exp) {
+			t.Errorf("Folds: %v != %v", tv.Folds, exp)
+			return
+		}
+		if !tv.Fold(4) || !tv.IsFolded(3) { // innermost region containing line
+			t.Errorf("Fold(4) did not fold the region at 3: %v", tv.Folded)
+		}
+		for ln := 0; ln < tv.NLines; ln++ {
+			if hid := ln == 4 || ln == 5; tv.IsLineHidden(ln) != hid {
+				t.Errorf("line %d hidden: %v", ln, tv.IsLineHidden(ln))
+			}
+		}
+
+		// cursor movement skips the hidden lines
+		tv.SetCursor(TextPos{Ln: 3, Ch: 2})
+		tv.SetCursorCol(tv.CursorPos)
+		tv.CursorDown(1)
+		if exp := (TextPos{Ln: 6, Ch: 2}); tv.CursorPos != exp {
+			t.Errorf("CursorDown over fold: %v != %v", tv.CursorPos, exp)
+		}
+		tv.CursorUp(1)
+		if exp := (TextPos{Ln: 3, Ch: 2}); tv.CursorPos != exp {
+			t.Errorf("CursorUp over fold: %v != %v", tv.CursorPos, exp)
+		}
+		tv.SetCursor(TextPos{Ln: 3, Ch: tv.Buf.LineLen(3)})
+		tv.CursorForward(1)
+		if exp := (TextPos{Ln: 6}); tv.CursorPos != exp {
+			t.Errorf("CursorForward over fold: %v != %v", tv.CursorPos, exp)
+		}
+		tv.CursorBackward(1)
+		if exp := (TextPos{Ln: 3, Ch: tv.Buf.LineLen(3)}); tv.CursorPos != exp {
+			t.Errorf("CursorBackward over fold: %v != %v", tv.CursorPos, exp)
+		}
+
+		// hidden lines take no space
+		for _, pos := range []TextPos{{Ln: 0, Ch: 3}, {Ln: 3, Ch: 2}, {Ln: 6, Ch: 1}, {Ln: 7, Ch: 5}} {
+			if got := tv.PixelToCursor(testTextPt(tv, pos)); got != pos {
+				t.Errorf("PixelToCursor of %v: %v", pos, got)
+			}
+		}
+		pt := testTextPt(tv, TextPos{Ln: 3, Ch: 1})
+		pt.Y += int(tv.LineHeight)
+		if got := tv.PixelToCursor(pt); got.Ln != 6 {
+			t.Errorf("PixelToCursor below folded line: %v", got)
+		}
+
+		// moving the cursor into a folded region unfolds it
+		tv.SetCursor(TextPos{Ln: 5})
+		if tv.IsFolded(3) || tv.IsLineHidden(5) {
+			t.Errorf("SetCursor on hidden line did not unfold it")
+		}
+		if got := tv.PixelToCursor(testTextPt(tv, TextPos{Ln: 5, Ch: 1})); got != (TextPos{Ln: 5, Ch: 1}) {
+			t.Errorf("PixelToCursor after unfold: %v", got)
+		}
+	})
+}
+
+func TestTextViewFoldGutter(t *testing.T) {
+	win, tv := testTextView(t, testFoldSrc)
+	defer testClose(t, win)
+	var mark, num, none image.Point
+	testInLoop(t, win, func() {
+		tv.UpdateFolds()
+		mark = testTextPt(tv, TextPos{Ln: 2})
+		mark.X = int(tv.foldMarkerX()) - tv.VpBBox.Min.X
+		num = mark
+		num.X = int(tv.RenderStartPos().X+tv.Sty.Font.Ch) - tv.VpBBox.Min.X // line number
+		none = testTextPt(tv, TextPos{Ln: 1})
+		none.X = mark.X
+		if !tv.FoldMarkerAt(mark, 2) {
+			t.Errorf("FoldMarkerAt marker of line 2: false")
+		}
+		if tv.FoldMarkerAt(num, 2) {
+			t.Errorf("FoldMarkerAt line number of line 2: true")
+		}
+		if tv.FoldMarkerAt(none, 1) {
+			t.Errorf("FoldMarkerAt line 1 without a fold: true")
+		}
+	})
+	wpt := func(pt image.Point) image.Point { return pt.Add(tv.WinBBox.Min) }
+	offscreen.MouseClick(win.OSWin, wpt(num), mouse.Left)
+	testSync(t, win)
+	if tv.IsFolded(2) {
+		t.Errorf("click on line number folded line 2")
+	}
+	offscreen.MouseClick(win.OSWin, wpt(none), mouse.Left)
+	testSync(t, win)
+	if len(tv.Folded) != 0 {
+		t.Errorf("click on line without a fold folded: %v", tv.Folded)
+	}
+	offscreen.MouseClick(win.OSWin, wpt(mark), mouse.Left)
+	testSync(t, win)
+	if !tv.IsFolded(2) || !tv.IsLineHidden(8) {
+		t.Errorf("click on fold marker did not fold line 2")
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"fmt"
+	"go/token"
+	"log"
+	"unicode"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/complete"
+	"github.com/goki/gi/lsp"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/ki"
+)
+
+// TextBufLSP is the state of a TextBuf that is attached to a language
+// server -- see TextBuf.LSPAttach
+type TextBufLSP struct {
+	Client  *lsp.Client `desc:"client for the language server"`
+	URI     string      `desc:"URI of the buffer's file for the language server"`
+	Version int         `desc:"version of the text, incremented with each change sent to the server"`
+	Unsent  bool        `desc:"the buffer has been edited without a signal since the last change sent to the server, so the full text is sent with the next change"`
+}
+
+// LSPAttach attaches the buffer to the language server for its language
+// (Hi.Lang), as configured in lsp.Servers, starting the server if needed
+// -- the buffer must have a Filename -- edits are then synced to the
+// server, and its diagnostics are set as Markers
+func (tb *TextBuf) LSPAttach() error {
+	if tb.LSP != nil {
+		return nil
+	}
+	if tb.Filename == "" {
+		return fmt.Errorf("giv.TextBuf LSPAttach: buffer has no filename")
+	}
+	cl, err := lsp.ClientFor(tb.Hi.Lang, lsp.FindRoot(string(tb.Filename)))
+	if err != nil {
+		return err
+	}
+	return tb.LSPAttachClient(cl)
+}
+
+// LSPAttachClient attaches the buffer to the language server for given
+// client -- see LSPAttach
+func (tb *TextBuf) LSPAttachClient(cl *lsp.Client) error {
+	tb.LSPDetach()
+	ls := &TextBufLSP{Client: cl, URI: lsp.FileURI(string(tb.Filename)), Version: 1}
+	tb.LSP = ls
+	txt := tb.LinesToBytesCopy()
+	err := cl.DidOpen(ls.URI, lsp.LangID(tb.Hi.Lang), ls.Version, string(txt), func(uri string, diags []lsp.Diagnostic) {
+		tb.LSPSetDiags(diags)
+	})
+	if err != nil {
+		tb.LSP = nil
+		return err
+	}
+	tb.TextBufSig.Connect(tb.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		tbf := recv.Embed(KiT_TextBuf).(*TextBuf)
+		tbf.LSPSigRecv(TextBufSignals(sig), data)
+	})
+	return nil
+}
+
+// LSPDetach detaches the buffer from its language server, if attached
+func (tb *TextBuf) LSPDetach() {
+	if tb.LSP == nil {
+		return
+	}
+	tb.TextBufSig.Disconnect(tb.This)
+	tb.LSP.Client.DidClose(tb.LSP.URI)
+	tb.LSP = nil
+	tb.SetMarkers(TextMarkerSourceLSP, nil)
+}
+
+// LSPSigRecv sends the changes to the buffer given by its signal to the
+// language server -- inserts and deletes are sent incrementally, if the
+// server supports that, and otherwise the full text is sent.  Edits made
+// without signaling are not seen by the server until the next signal, which
+// then sends the full text (see TextBufLSP.Unsent).
+func (tb *TextBuf) LSPSigRecv(sig TextBufSignals, data interface{}) {
+	ls := tb.LSP
+	if ls == nil {
+		return
+	}
+	var chg lsp.TextDocumentContentChangeEvent
+	switch sig {
+	case TextBufInsert, TextBufDelete:
+		tbe := data.(*TextBufEdit)
+		if ls.Unsent || ls.Client.SyncKind != lsp.SyncIncremental {
+			chg.Text = string(tb.LinesToBytesCopy())
+			break
+		}
+		st := tb.LSPPos(tbe.Reg.Start)
+		rng := &lsp.Range{Start: st, End: st}
+		if tbe.Delete {
+			// deleted text is no longer in the buffer, so end is relative to start
+			nl := len(tbe.Text)
+			rng.End.Line = st.Line + nl - 1
+			if nl == 1 {
+				rng.End.Character = st.Character + utf16Len(tbe.Text[0])
+			} else {
+				rng.End.Character = utf16Len(tbe.Text[nl-1])
+			}
+		} else {
+			chg.Text = string(tbe.ToBytes())
+		}
+		chg.Range = rng
+	case TextBufNew:
+		chg.Text = string(tb.LinesToBytesCopy())
+	default:
+		return
+	}
+	ls.Unsent = false
+	ls.Version++
+	ls.Client.DidChange(ls.URI, ls.Version, []lsp.TextDocumentContentChangeEvent{chg})
+}
+
+// lspUnsent records an edit made without a signal, which the language
+// server does not see until the full text is sent with the next change
+func (tb *TextBuf) lspUnsent() {
+	if tb.LSP != nil {
+		tb.LSP.Unsent = true
+	}
+}
+
+// LSPSaved tells the language server that the buffer was saved
+func (tb *TextBuf) LSPSaved() {
+	if tb.LSP == nil {
+		return
+	}
+	tb.LSP.Client.DidSave(tb.LSP.URI)
+}
+
+// TextMarkerSourceLSP is the Source of the markers for language server
+// diagnostics
+const TextMarkerSourceLSP = "lsp"
+
+// LSPSetDiags sets the markers for given language server diagnostics, and
+// signals the views to update -- called on the goroutine reading from the
+// server
+func (tb *TextBuf) LSPSetDiags(diags []lsp.Diagnostic) {
+	mks := make([]TextBufMarker, len(diags))
+	tb.MarkupMu.Lock()
+	for i, d := range diags {
+		mk := &mks[i]
+		mk.Reg = TextRegion{Start: tb.TextPosFromLSP(d.Range.Start), End: tb.TextPosFromLSP(d.Range.End)}
+		switch d.Severity {
+		case lsp.SeverityError:
+			mk.Kind = TextMarkerError
+		case lsp.SeverityWarning:
+			mk.Kind = TextMarkerWarning
+		default:
+			mk.Kind = TextMarkerInfo
+		}
+		mk.Msg = d.Message
+		if d.Source != "" {
+			mk.Msg = d.Source + ": " + d.Message
+		}
+	}
+	tb.MarkupMu.Unlock()
+	tb.SetMarkers(TextMarkerSourceLSP, mks)
+}
+
+// utf16RuneLen returns the number of UTF-16 code units for given rune,
+// which is how the language server protocol counts characters
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// utf16Len returns the number of UTF-16 code units for given runes
+func utf16Len(rs []rune) int {
+	n := 0
+	for _, r := range rs {
+		n += utf16RuneLen(r)
+	}
+	return n
+}
+
+// LSPPos returns the language server position for given position, with the
+// character in UTF-16 code units
+func (tb *TextBuf) LSPPos(pos TextPos) lsp.Position {
+	pos = tb.ValidPos(pos)
+	if tb.NLines == 0 {
+		return lsp.Position{}
+	}
+	return lsp.Position{Line: pos.Ln, Character: utf16Len(tb.Line(pos.Ln)[:pos.Ch])}
+}
+
+// TextPosFromLSP returns the position for given language server position
+func (tb *TextBuf) TextPosFromLSP(lp lsp.Position) TextPos {
+	if lp.Line >= tb.NLines {
+		return tb.EndPos()
+	}
+	rs := tb.Line(lp.Line)
+	ch, n := 0, 0
+	for ch < len(rs) && n < lp.Character {
+		n += utf16RuneLen(rs[ch])
+		ch++
+	}
+	return TextPos{Ln: lp.Line, Ch: ch}
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   TextView
+
+// LSPSeed returns the seed for completion from given text before the
+// cursor: the trailing identifier characters
+func LSPSeed(text string) string {
+	rs := []rune(text)
+	st := len(rs)
+	for st > 0 && (unicode.IsLetter(rs[st-1]) || unicode.IsDigit(rs[st-1]) || rs[st-1] == '_') {
+		st--
+	}
+	return string(rs[st:])
+}
+
+// CompleteLSP is a complete.MatchFunc that gets completions from the
+// language server of the buffer of the TextView passed as data -- see
+// SetLSPCompleter
+func CompleteLSP(data interface{}, text string, pos token.Position) (matches complete.Completions, seed string) {
+	tv, ok := data.(*TextView)
+	if !ok || tv.Buf == nil || tv.Buf.LSP == nil {
+		return
+	}
+	ls := tv.Buf.LSP
+	seed = LSPSeed(text)
+	items, err := ls.Client.Completion(ls.URI, tv.Buf.LSPPos(TextPos{Ln: pos.Line, Ch: pos.Column}))
+	if err != nil {
+		log.Printf("giv.CompleteLSP: %v\n", err)
+		return
+	}
+	results := make([]complete.Completion, len(items))
+	for i, it := range items {
+		txt := it.InsertText
+		if txt == "" {
+			txt = it.Label
+		}
+		results[i] = complete.Completion{Text: txt, Desc: it.Detail}
+	}
+	matches = complete.MatchSeedCompletion(results, seed)
+	return matches, seed
+}
+
+// CompleteLSPEdit is a complete.EditFunc for CompleteLSP
+func CompleteLSPEdit(data interface{}, text string, cursorPos int, completion string, seed string) (s string, delta int) {
+	return complete.EditWord(text, cursorPos, completion, seed)
+}
+
+// SetLSPCompleter sets the completer to use the language server of the
+// buffer -- the buffer should be attached with LSPAttach
+func (tv *TextView) SetLSPCompleter() {
+	tv.SetCompleter(tv, CompleteLSP, CompleteLSPEdit)
+}
+
+// LSPHover returns the hover text from the language server for given
+// position -- empty if none
+func (tv *TextView) LSPHover(pos TextPos) string {
+	if tv.Buf == nil || tv.Buf.LSP == nil {
+		return ""
+	}
+	ls := tv.Buf.LSP
+	txt, err := ls.Client.Hover(ls.URI, tv.Buf.LSPPos(pos))
+	if err != nil {
+		log.Printf("giv.TextView LSPHover: %v\n", err)
+	}
+	return txt
+}
+
+// LSPDefinition goes to the definition of the symbol at the cursor, from
+// the language server -- the cursor moves to a definition in the same
+// file, and otherwise the definition is opened as a file:// link with
+// OpenLink -- returns false if there is no definition
+func (tv *TextView) LSPDefinition() bool {
+	if tv.Buf == nil || tv.Buf.LSP == nil {
+		return false
+	}
+	ls := tv.Buf.LSP
+	locs, err := ls.Client.Definition(ls.URI, tv.Buf.LSPPos(tv.CursorPos))
+	if err != nil {
+		log.Printf("giv.TextView LSPDefinition: %v\n", err)
+	}
+	if len(locs) == 0 {
+		return false
+	}
+	loc := locs[0]
+	if loc.URI == ls.URI {
+		pos := tv.Buf.TextPosFromLSP(loc.Range.Start)
+		tv.SavePosHistory(tv.CursorPos)
+		tv.SetCursorShow(pos)
+		tv.SavePosHistory(pos)
+		return true
+	}
+	st := loc.Range.Start
+	tl := &gi.TextLink{URL: fmt.Sprintf("%v#L%vC%v", loc.URI, st.Line+1, st.Character+1)}
+	tv.OpenLink(tl)
+	return true
+}
+
+// HoverEvent connects to the hover event, to show a tooltip with the
+// marker messages and language server hover text for the text under the
+// mouse, or for the markers on the line in the gutter, or the Tooltip if
+// none
+func (tv *TextView) HoverEvent() {
+	tv.ConnectEvent(oswin.MouseHoverEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		me := d.(*mouse.HoverEvent)
+		txf := recv.Embed(KiT_TextView).(*TextView)
+		tt := ""
+		if txf.Buf != nil {
+			pt := txf.PointToRelPos(me.Pos())
+			pos := txf.PixelToCursor(pt)
+			ingut := float32(pt.X) < txf.LineNoOff
+			tt = txf.MarkerTooltip(pos, ingut)
+			if !ingut {
+				if ht := txf.LSPHover(pos); ht != "" {
+					if tt != "" {
+						tt += "\n\n"
+					}
+					tt += ht
+				}
+			}
+		}
+		if tt == "" {
+			tt = txf.Tooltip
+		}
+		if tt == "" {
+			return
+		}
+		me.SetProcessed()
+		pt := me.Pos()
+		gi.PopupTooltip(tt, pt.X, pt.Y+int(txf.LineHeight), txf.Viewport, txf.Nm)
+	})
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/lsp"
+)
+
+// testLSPText waits for the server's text of the buffer to match the buffer
+func testLSPText(t *testing.T, fs *lsp.FakeServer, tb *TextBuf, what string) {
+	exp := string(tb.LinesToBytesCopy())
+	txt := ""
+	for i := 0; i < 500; i++ {
+		if txt = fs.Text(tb.LSP.URI); txt == exp {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("%v: server text %q != %q", what, txt, exp)
+}
+
+func TestTextBufLSPSync(t *testing.T) {
+	fs, cl := lsp.NewFakeServer()
+	defer cl.Shutdown()
+	tb := testTextBuf([]byte("hello world\nsecond line\n"))
+	tb.Filename = gi.FileName("test.txt")
+	if err := tb.LSPAttachClient(cl); err != nil {
+		t.Fatal(err)
+	}
+	defer tb.LSPDetach()
+	testLSPText(t, fs, tb, "open")
+
+	tb.InsertText(TextPos{Ln: 0, Ch: 5}, []byte(","), true, true)
+	testLSPText(t, fs, tb, "signaled insert")
+
+	tb.InsertText(TextPos{Ln: 1, Ch: 0}, []byte("unsent\n"), true, false)
+	tb.DeleteText(TextPos{Ln: 0, Ch: 0}, TextPos{Ln: 0, Ch: 1}, true, false)
+	if !tb.LSP.Unsent {
+		t.Errorf("Unsent not set after edits without signal")
+	}
+	tb.InsertText(TextPos{Ln: 2, Ch: 0}, []byte("more "), true, true)
+	if tb.LSP.Unsent {
+		t.Errorf("Unsent still set after signaled edit")
+	}
+	testLSPText(t, fs, tb, "edits without signal")
+
+	tb.DeleteText(TextPos{Ln: 1, Ch: 0}, TextPos{Ln: 2, Ch: 0}, true, true)
+	testLSPText(t, fs, tb, "incremental delete")
+}
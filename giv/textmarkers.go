@@ -0,0 +1,351 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"sort"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/gi"
+	"github.com/goki/ki/kit"
+)
+
+// TextMarkerKinds are the kinds of markers that annotate regions of a
+// TextBuf -- see TextBufMarker
+type TextMarkerKinds int32
+
+const (
+	// TextMarkerError is an error diagnostic, e.g., from a language server
+	TextMarkerError TextMarkerKinds = iota
+
+	// TextMarkerWarning is a warning diagnostic
+	TextMarkerWarning
+
+	// TextMarkerInfo is an informational diagnostic, or hint
+	TextMarkerInfo
+
+	// TextMarkerBreakpoint is a debugger breakpoint on a line
+	TextMarkerBreakpoint
+
+	// TextMarkerBookmark is a user bookmark on a line
+	TextMarkerBookmark
+
+	TextMarkerKindsN
+)
+
+//go:generate stringer -type=TextMarkerKinds
+
+var KiT_TextMarkerKinds = kit.Enums.AddEnumAltLower(TextMarkerKindsN, false, nil, "TextMarker")
+
+// IsDiag returns true for the diagnostic kinds of markers: error, warning
+// and info, which are shown with a squiggly underline of their region
+func (mk TextMarkerKinds) IsDiag() bool {
+	return mk <= TextMarkerInfo
+}
+
+// TextMarkerColors are the colors for rendering each kind of marker, in
+// the gutter and for the underline of diagnostics
+var TextMarkerColors = [TextMarkerKindsN]string{"#e02020", "#e0a000", "#2080e0", "#c00000", "#2090a0"}
+
+// TextBufMarker is an annotation for a region of a TextBuf -- its region
+// moves with edits to the text -- line markers such as breakpoints have an
+// empty region at the start of their line
+type TextBufMarker struct {
+	Kind   TextMarkerKinds `desc:"kind of marker"`
+	Reg    TextRegion      `desc:"region of text that the marker applies to"`
+	Msg    string          `desc:"message for the marker, shown in its tooltip"`
+	Source string          `desc:"where the marker came from, e.g., lsp for language server diagnostics -- markers from a source can be replaced together with SetMarkers"`
+}
+
+// AddMarker adds given marker, and signals the views to update
+func (tb *TextBuf) AddMarker(mk TextBufMarker) {
+	tb.MarkupMu.Lock()
+	tb.Markers = append(tb.Markers, mk)
+	tb.sortMarkers()
+	tb.MarkupMu.Unlock()
+	tb.TextBufSig.Emit(tb.This, int64(TextBufMarkUpdt), tb.Txt)
+}
+
+// SetMarkers replaces all the markers from given source with the given
+// ones, and signals the views to update -- can be called from another
+// goroutine
+func (tb *TextBuf) SetMarkers(src string, mks []TextBufMarker) {
+	tb.MarkupMu.Lock()
+	nm := tb.Markers[:0]
+	for _, mk := range tb.Markers {
+		if mk.Source != src {
+			nm = append(nm, mk)
+		}
+	}
+	for _, mk := range mks {
+		mk.Source = src
+		nm = append(nm, mk)
+	}
+	tb.Markers = nm
+	tb.sortMarkers()
+	tb.MarkupMu.Unlock()
+	tb.TextBufSig.Emit(tb.This, int64(TextBufMarkUpdt), tb.Txt)
+}
+
+// ToggleLineMarker adds a marker of given kind on given line, or deletes
+// it if the line already has one -- for breakpoints, bookmarks etc --
+// returns true if added
+func (tb *TextBuf) ToggleLineMarker(kind TextMarkerKinds, ln int) bool {
+	tb.MarkupMu.Lock()
+	for i, mk := range tb.Markers {
+		if mk.Kind == kind && mk.Reg.Start.Ln == ln {
+			tb.Markers = append(tb.Markers[:i], tb.Markers[i+1:]...)
+			tb.MarkupMu.Unlock()
+			tb.TextBufSig.Emit(tb.This, int64(TextBufMarkUpdt), tb.Txt)
+			return false
+		}
+	}
+	tb.MarkupMu.Unlock()
+	pos := TextPos{Ln: ln}
+	tb.AddMarker(TextBufMarker{Kind: kind, Reg: TextRegion{Start: pos, End: pos}})
+	return true
+}
+
+// sortMarkers sorts the markers by start position -- must be called under
+// MarkupMu
+func (tb *TextBuf) sortMarkers() {
+	sort.SliceStable(tb.Markers, func(i, j int) bool {
+		return tb.Markers[i].Reg.Start.IsLess(tb.Markers[j].Reg.Start)
+	})
+}
+
+// adjustMarkers moves the markers for given edit that has just been made
+func (tb *TextBuf) adjustMarkers(tbe *TextBufEdit) {
+	tb.MarkupMu.Lock()
+	for i := range tb.Markers {
+		tb.Markers[i].Reg = tbe.AdjustReg(tb.Markers[i].Reg)
+	}
+	tb.MarkupMu.Unlock()
+}
+
+// MarkersAt returns the markers whose region contains given position --
+// empty regions contain their start position
+func (tb *TextBuf) MarkersAt(pos TextPos) []TextBufMarker {
+	tb.MarkupMu.Lock()
+	defer tb.MarkupMu.Unlock()
+	var mks []TextBufMarker
+	for _, mk := range tb.Markers {
+		if !pos.IsLess(mk.Reg.Start) && (pos.IsLess(mk.Reg.End) || pos == mk.Reg.Start) {
+			mks = append(mks, mk)
+		}
+	}
+	return mks
+}
+
+// LineMarkers returns the markers that start on given line
+func (tb *TextBuf) LineMarkers(ln int) []TextBufMarker {
+	tb.MarkupMu.Lock()
+	defer tb.MarkupMu.Unlock()
+	var mks []TextBufMarker
+	for _, mk := range tb.Markers {
+		if mk.Reg.Start.Ln == ln {
+			mks = append(mks, mk)
+		}
+	}
+	return mks
+}
+
+// NextDiag returns the next diagnostic marker after given position, or the
+// previous one before it if !forward, wrapping around the end of the text
+// -- returns false if there are none
+func (tb *TextBuf) NextDiag(pos TextPos, forward bool) (TextBufMarker, bool) {
+	tb.MarkupMu.Lock()
+	defer tb.MarkupMu.Unlock()
+	var diags []TextBufMarker
+	for _, mk := range tb.Markers {
+		if mk.Kind.IsDiag() {
+			diags = append(diags, mk)
+		}
+	}
+	n := len(diags)
+	if n == 0 {
+		return TextBufMarker{}, false
+	}
+	if forward {
+		for _, mk := range diags {
+			if pos.IsLess(mk.Reg.Start) {
+				return mk, true
+			}
+		}
+		return diags[0], true
+	}
+	for i := n - 1; i >= 0; i-- {
+		if diags[i].Reg.Start.IsLess(pos) {
+			return diags[i], true
+		}
+	}
+	return diags[n-1], true
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   TextView
+
+// NextDiag moves the cursor to the next diagnostic marker after the cursor,
+// or the previous one if !forward -- returns false if there are none
+func (tv *TextView) NextDiag(forward bool) bool {
+	if tv.Buf == nil {
+		return false
+	}
+	mk, ok := tv.Buf.NextDiag(tv.CursorPos, forward)
+	if !ok {
+		return false
+	}
+	tv.SavePosHistory(tv.CursorPos)
+	tv.SetCursorShow(mk.Reg.Start)
+	tv.SavePosHistory(mk.Reg.Start)
+	return true
+}
+
+// ToggleBreakpoint toggles a breakpoint marker on the cursor line
+func (tv *TextView) ToggleBreakpoint() {
+	if tv.Buf != nil {
+		tv.Buf.ToggleLineMarker(TextMarkerBreakpoint, tv.CursorPos.Ln)
+	}
+}
+
+// ToggleBookmark toggles a bookmark marker on the cursor line
+func (tv *TextView) ToggleBookmark() {
+	if tv.Buf != nil {
+		tv.Buf.ToggleLineMarker(TextMarkerBookmark, tv.CursorPos.Ln)
+	}
+}
+
+// MarkerTooltip returns the tooltip text for the markers at given
+// position, or on its line if inGutter -- empty if none
+func (tv *TextView) MarkerTooltip(pos TextPos, inGutter bool) string {
+	var mks []TextBufMarker
+	if inGutter {
+		mks = tv.Buf.LineMarkers(pos.Ln)
+	} else {
+		mks = tv.Buf.MarkersAt(pos)
+	}
+	tt := ""
+	for _, mk := range mks {
+		if mk.Msg == "" {
+			continue
+		}
+		if tt != "" {
+			tt += "\n"
+		}
+		tt += mk.Msg
+	}
+	return tt
+}
+
+// RenderMarkers renders the squiggly underlines for the diagnostic markers
+// within given range of lines (-1 for all) -- always called within context
+// of outer RenderLines or RenderAllLines
+func (tv *TextView) RenderMarkers(stln, edln int) {
+	if tv.Buf == nil {
+		return
+	}
+	tv.Buf.MarkupMu.Lock()
+	defer tv.Buf.MarkupMu.Unlock()
+	for _, mk := range tv.Buf.Markers {
+		reg := mk.Reg
+		if !mk.Kind.IsDiag() || (stln >= 0 && (reg.Start.Ln > edln || reg.End.Ln < stln)) {
+			continue
+		}
+		for ln := reg.Start.Ln; ln <= reg.End.Ln && ln < tv.NLines; ln++ {
+			if tv.IsLineHidden(ln) {
+				continue
+			}
+			st := TextPos{Ln: ln}
+			ed := TextPos{Ln: ln, Ch: tv.Buf.LineLen(ln)}
+			if ln == reg.Start.Ln {
+				st.Ch = reg.Start.Ch
+			}
+			if ln == reg.End.Ln {
+				ed.Ch = reg.End.Ch
+			}
+			tv.RenderSquiggle(st, ed, mk.Kind)
+		}
+	}
+}
+
+// RenderSquiggle renders a squiggly underline from st to ed on one line, in
+// the color for given kind of marker -- an empty range gets one char
+func (tv *TextView) RenderSquiggle(st, ed TextPos, kind TextMarkerKinds) {
+	rs := &tv.Viewport.Render
+	pc := &rs.Paint
+	sty := &tv.Sty
+	spos := tv.CharStartPos(st)
+	epos := tv.CharStartPos(ed)
+	if epos.Y != spos.Y { // wrapped -- just underline to the end of the first row
+		epos.X = float32(tv.VpBBox.Max.X) - sty.BoxSpace()
+	}
+	if epos.X-spos.X < sty.Font.Ch {
+		epos.X = spos.X + sty.Font.Ch
+	}
+	amp := math32.Max(1.5, 0.08*tv.LineHeight)
+	y := spos.Y + tv.LineHeight - 2*amp
+	if int(math32.Ceil(y+amp)) < tv.VpBBox.Min.Y || int(math32.Floor(y-amp)) > tv.VpBBox.Max.Y {
+		return
+	}
+	clr, _ := gi.ColorFromString(TextMarkerColors[kind], nil)
+	pc.FillStyle.SetColor(nil)
+	pc.StrokeStyle.SetColor(&clr)
+	pc.StrokeStyle.Width.Dots = 1
+	pc.MoveTo(rs, spos.X, y)
+	up := true
+	for x := spos.X + amp; x < epos.X+amp; x += amp {
+		if up {
+			pc.LineTo(rs, x, y-amp)
+		} else {
+			pc.LineTo(rs, x, y)
+		}
+		up = !up
+	}
+	pc.FillStrokeClear(rs)
+}
+
+// RenderLineMarkers renders the gutter icon for the markers on given line,
+// after the line number -- called within context of other render
+func (tv *TextView) RenderLineMarkers(ln int) {
+	if !tv.Opts.LineNos || tv.Buf == nil {
+		return
+	}
+	mks := tv.Buf.LineMarkers(ln)
+	if len(mks) == 0 {
+		return
+	}
+	kind := TextMarkerKindsN // breakpoint, then most severe diagnostic, then bookmark
+	for _, mk := range mks {
+		switch {
+		case mk.Kind == TextMarkerBreakpoint:
+			kind = mk.Kind
+		case kind == TextMarkerBreakpoint:
+		case mk.Kind < kind:
+			kind = mk.Kind
+		}
+	}
+	rs := &tv.Viewport.Render
+	pc := &rs.Paint
+	sty := &tv.Sty
+	pos := tv.RenderStartPos()
+	sz := math32.Min(tv.LineHeight, 2*sty.Font.Ch) * 0.7
+	cx := pos.X + (float32(tv.LineNoDigs)+1.5)*sty.Font.Ch
+	cy := tv.CharStartPos(TextPos{Ln: ln}).Y + 0.5*tv.LineHeight
+	clr, _ := gi.ColorFromString(TextMarkerColors[kind], nil)
+	pc.StrokeStyle.SetColor(nil)
+	pc.FillStyle.SetColor(&clr)
+	r := 0.5 * sz
+	switch kind {
+	case TextMarkerBreakpoint, TextMarkerError:
+		pc.DrawCircle(rs, cx, cy, r)
+	case TextMarkerWarning:
+		pc.DrawPolygon(rs, []gi.Vec2D{{cx, cy - r}, {cx + r, cy + r}, {cx - r, cy + r}})
+	case TextMarkerInfo:
+		pc.DrawCircle(rs, cx, cy, 0.6*r)
+	case TextMarkerBookmark:
+		pc.DrawPolygon(rs, []gi.Vec2D{{cx - 0.7*r, cy - r}, {cx + 0.7*r, cy - r}, {cx + 0.7*r, cy + r}, {cx, cy + 0.4*r}, {cx - 0.7*r, cy + r}})
+	}
+	pc.FillStrokeClear(rs)
+}
@@ -0,0 +1,455 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// TextLine is one line of text in a TextBuf, without the trailing newline
+// -- the bytes are the primary representation, and the runes needed for
+// rendering and editing are only decoded when first needed, so that large
+// files open quickly.  Bytes can point into the original file text, and
+// must not be modified in place -- edits replace the line.
+type TextLine struct {
+	Bytes  []byte    `desc:"text of the line in utf8 bytes"`
+	Markup []byte    `desc:"marked-up version of the line, rendered when the buffer is not syntax highlighted -- initially the same as Bytes"`
+	HiToks []HiToken `desc:"syntax highlighting tokens for the line -- set by the TextBuf under its MarkupMu, and replaced, never modified in place"`
+	HiRoot bool      `desc:"true if the lexer is known to be in its root state at the end of the line, so highlighting can restart at the next line -- see TokenizeLines"`
+	HiOk   bool      `desc:"true if HiToks and HiRoot have been set by the highlighter"`
+	runes  []rune
+	nrunes int
+}
+
+// NewTextLine returns a new line for given bytes, which are not copied
+func NewTextLine(b []byte) *TextLine {
+	return &TextLine{Bytes: b, Markup: b, nrunes: -1}
+}
+
+// NewTextLineRunes returns a new line for given runes, which are not copied
+func NewTextLineRunes(r []rune) *TextLine {
+	b := []byte(string(r))
+	return &TextLine{Bytes: b, Markup: b, runes: r, nrunes: len(r)}
+}
+
+// Runes returns the line as runes, decoding them from the bytes the first
+// time -- must not be modified
+func (tl *TextLine) Runes() []rune {
+	if tl.runes == nil && len(tl.Bytes) > 0 {
+		tl.runes = bytes.Runes(tl.Bytes)
+		tl.nrunes = len(tl.runes)
+	}
+	return tl.runes
+}
+
+// NRunes returns the number of runes in the line -- does not decode the
+// runes
+func (tl *TextLine) NRunes() int {
+	if tl.nrunes < 0 {
+		tl.nrunes = utf8.RuneCount(tl.Bytes)
+	}
+	return tl.nrunes
+}
+
+// RuneByteOff returns the byte offset of the given rune position within
+// the line
+func (tl *TextLine) RuneByteOff(ch int) int {
+	if ch <= 0 {
+		return 0
+	}
+	if tl.NRunes() == len(tl.Bytes) { // ascii
+		if ch > len(tl.Bytes) {
+			return len(tl.Bytes)
+		}
+		return ch
+	}
+	off := 0
+	for i := 0; i < ch && off < len(tl.Bytes); i++ {
+		_, sz := utf8.DecodeRune(tl.Bytes[off:])
+		off += sz
+	}
+	return off
+}
+
+////////////////////////////////////////////////////////////////////////////
+//   LineRope
+
+// LineRopeLeafMax is the maximum number of lines in each leaf of a LineRope
+const LineRopeLeafMax = 128
+
+// LineRope is a rope of text lines: a balanced (AVL) binary tree whose
+// leaves hold runs of up to LineRopeLeafMax lines, and whose nodes record
+// the number of lines and bytes below them.  Finding a line by number or
+// byte offset, and inserting or deleting runs of lines, are O(log n) in the
+// number of lines, so editing large files does not copy or shift the whole
+// line list.  Lines are counted with a trailing newline for byte offsets.
+type LineRope struct {
+	root *ropeNode
+}
+
+// ropeNode is a node in a LineRope -- leaves have lines and height 0
+type ropeNode struct {
+	left, right *ropeNode
+	lines       []*TextLine
+	height      int
+	nlines      int
+	nbytes      int
+}
+
+// Len returns the number of lines
+func (lr *LineRope) Len() int {
+	if lr.root == nil {
+		return 0
+	}
+	return lr.root.nlines
+}
+
+// NBytes returns the total number of bytes, including a newline for each
+// line
+func (lr *LineRope) NBytes() int {
+	if lr.root == nil {
+		return 0
+	}
+	return lr.root.nbytes
+}
+
+// SetLines replaces all the lines in the rope with given lines (which are
+// used directly, not copied)
+func (lr *LineRope) SetLines(lns []*TextLine) {
+	lr.root = ropeBuild(lns)
+}
+
+// Line returns the given line -- must be a valid index
+func (lr *LineRope) Line(ln int) *TextLine {
+	n := lr.root
+	for n.height > 0 {
+		if ln < n.left.nlines {
+			n = n.left
+		} else {
+			ln -= n.left.nlines
+			n = n.right
+		}
+	}
+	return n.lines[ln]
+}
+
+// SetLine replaces the given line -- must be a valid index
+func (lr *LineRope) SetLine(ln int, tl *TextLine) {
+	ropeSet(lr.root, ln, tl)
+}
+
+// Offset returns the byte offset of the start of the given line -- ln can
+// be Len(), for the total number of bytes
+func (lr *LineRope) Offset(ln int) int {
+	off := 0
+	n := lr.root
+	if n == nil || ln >= n.nlines {
+		return lr.NBytes()
+	}
+	for n.height > 0 {
+		if ln < n.left.nlines {
+			n = n.left
+		} else {
+			ln -= n.left.nlines
+			off += n.left.nbytes
+			n = n.right
+		}
+	}
+	for i := 0; i < ln; i++ {
+		off += len(n.lines[i].Bytes) + 1
+	}
+	return off
+}
+
+// LineAtOffset returns the line containing given byte offset, and the
+// offset of the start of that line -- offsets past the end return the last
+// line
+func (lr *LineRope) LineAtOffset(off int) (ln, lnoff int) {
+	n := lr.root
+	if n == nil {
+		return 0, 0
+	}
+	if off >= n.nbytes {
+		ln = n.nlines - 1
+		return ln, lr.Offset(ln)
+	}
+	for n.height > 0 {
+		if off < n.left.nbytes {
+			n = n.left
+		} else {
+			off -= n.left.nbytes
+			lnoff += n.left.nbytes
+			ln += n.left.nlines
+			n = n.right
+		}
+	}
+	for _, tl := range n.lines {
+		sz := len(tl.Bytes) + 1
+		if off < sz {
+			break
+		}
+		off -= sz
+		lnoff += sz
+		ln++
+	}
+	return ln, lnoff
+}
+
+// Replace replaces lines st up to (not including) ed with given lines --
+// use st == ed to insert and no lines to delete
+func (lr *LineRope) Replace(st, ed int, lns []*TextLine) {
+	left, rest := ropeSplit(lr.root, st)
+	_, right := ropeSplit(rest, ed-st)
+	lr.root = ropeJoin(ropeJoin(left, ropeBuild(lns)), right)
+}
+
+// Insert inserts lines before line ln
+func (lr *LineRope) Insert(ln int, lns ...*TextLine) {
+	lr.Replace(ln, ln, lns)
+}
+
+// Delete deletes lines st up to (not including) ed
+func (lr *LineRope) Delete(st, ed int) {
+	lr.Replace(st, ed, nil)
+}
+
+// Range calls fun for each line from st up to (not including) ed, in
+// order, stopping if it returns false
+func (lr *LineRope) Range(st, ed int, fun func(ln int, tl *TextLine) bool) {
+	if lr.root == nil || st >= ed {
+		return
+	}
+	ropeRange(lr.root, st, ed, 0, fun)
+}
+
+// WriteTo writes all the lines, each followed by a newline, to w
+func (lr *LineRope) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	var err error
+	nl := []byte("\n")
+	lr.Range(0, lr.Len(), func(ln int, tl *TextLine) bool {
+		var c int
+		if c, err = w.Write(tl.Bytes); err != nil {
+			return false
+		}
+		n += int64(c)
+		if c, err = w.Write(nl); err != nil {
+			return false
+		}
+		n += int64(c)
+		return true
+	})
+	return n, err
+}
+
+// Bytes returns all the lines as one slice of bytes, each line followed by
+// a newline
+func (lr *LineRope) Bytes() []byte {
+	b := make([]byte, 0, lr.NBytes())
+	lr.Range(0, lr.Len(), func(ln int, tl *TextLine) bool {
+		b = append(b, tl.Bytes...)
+		b = append(b, '\n')
+		return true
+	})
+	return b
+}
+
+func ropeHeight(n *ropeNode) int {
+	if n == nil {
+		return -1
+	}
+	return n.height
+}
+
+// ropeLeaf returns a new leaf for given lines
+func ropeLeaf(lns []*TextLine) *ropeNode {
+	n := &ropeNode{lines: lns, nlines: len(lns)}
+	for _, tl := range lns {
+		n.nbytes += len(tl.Bytes) + 1
+	}
+	return n
+}
+
+// ropeNew returns a new internal node with given children
+func ropeNew(l, r *ropeNode) *ropeNode {
+	n := &ropeNode{left: l, right: r}
+	n.update()
+	return n
+}
+
+// update updates the node's height and counts from its children
+func (n *ropeNode) update() {
+	n.height = 1 + ropeHeight(n.left)
+	if rh := ropeHeight(n.right); rh >= n.height {
+		n.height = rh + 1
+	}
+	n.nlines = n.left.nlines + n.right.nlines
+	n.nbytes = n.left.nbytes + n.right.nbytes
+}
+
+// ropeBuild returns a balanced tree for given lines
+func ropeBuild(lns []*TextLine) *ropeNode {
+	if len(lns) == 0 {
+		return nil
+	}
+	nlv := (len(lns) + LineRopeLeafMax - 1) / LineRopeLeafMax
+	leaves := make([]*ropeNode, nlv)
+	for i := range leaves {
+		st := i * LineRopeLeafMax
+		ed := st + LineRopeLeafMax
+		if ed > len(lns) {
+			ed = len(lns)
+		}
+		lv := make([]*TextLine, ed-st, LineRopeLeafMax)
+		copy(lv, lns[st:ed])
+		leaves[i] = ropeLeaf(lv)
+	}
+	return ropeBuildNodes(leaves)
+}
+
+// ropeBuildNodes builds a balanced tree over given leaves
+func ropeBuildNodes(nds []*ropeNode) *ropeNode {
+	if len(nds) == 1 {
+		return nds[0]
+	}
+	mid := len(nds) / 2
+	return ropeNew(ropeBuildNodes(nds[:mid]), ropeBuildNodes(nds[mid:]))
+}
+
+func ropeRotRight(n *ropeNode) *ropeNode {
+	l := n.left
+	n.left = l.right
+	n.update()
+	l.right = n
+	l.update()
+	return l
+}
+
+func ropeRotLeft(n *ropeNode) *ropeNode {
+	r := n.right
+	n.right = r.left
+	n.update()
+	r.left = n
+	r.update()
+	return r
+}
+
+// ropeBalance restores the AVL balance of n, whose children can differ in
+// height by at most 2
+func ropeBalance(n *ropeNode) *ropeNode {
+	bf := ropeHeight(n.left) - ropeHeight(n.right)
+	switch {
+	case bf > 1:
+		if ropeHeight(n.left.left) < ropeHeight(n.left.right) {
+			n.left = ropeRotLeft(n.left)
+		}
+		return ropeRotRight(n)
+	case bf < -1:
+		if ropeHeight(n.right.right) < ropeHeight(n.right.left) {
+			n.right = ropeRotRight(n.right)
+		}
+		return ropeRotLeft(n)
+	}
+	return n
+}
+
+// ropeJoin joins two trees, with all of l before all of r
+func ropeJoin(l, r *ropeNode) *ropeNode {
+	if l == nil || l.nlines == 0 {
+		return r
+	}
+	if r == nil || r.nlines == 0 {
+		return l
+	}
+	if l.height == 0 && r.height == 0 && l.nlines+r.nlines <= LineRopeLeafMax {
+		return ropeLeaf(append(l.lines[:l.nlines:l.nlines], r.lines...))
+	}
+	switch {
+	case l.height > r.height+1:
+		l.right = ropeJoin(l.right, r)
+		l.update()
+		return ropeBalance(l)
+	case r.height > l.height+1:
+		r.left = ropeJoin(l, r.left)
+		r.update()
+		return ropeBalance(r)
+	}
+	return ropeNew(l, r)
+}
+
+// ropeSplit splits the tree into the first ln lines and the rest
+func ropeSplit(n *ropeNode, ln int) (*ropeNode, *ropeNode) {
+	if n == nil {
+		return nil, nil
+	}
+	if ln <= 0 {
+		return nil, n
+	}
+	if ln >= n.nlines {
+		return n, nil
+	}
+	if n.height == 0 {
+		l := make([]*TextLine, ln, LineRopeLeafMax)
+		copy(l, n.lines[:ln])
+		r := make([]*TextLine, n.nlines-ln, LineRopeLeafMax)
+		copy(r, n.lines[ln:])
+		return ropeLeaf(l), ropeLeaf(r)
+	}
+	if ln < n.left.nlines {
+		a, b := ropeSplit(n.left, ln)
+		return a, ropeJoin(b, n.right)
+	}
+	a, b := ropeSplit(n.right, ln-n.left.nlines)
+	return ropeJoin(n.left, a), b
+}
+
+// ropeSet sets line ln below n, updating byte counts
+func ropeSet(n *ropeNode, ln int, tl *TextLine) {
+	if n.height == 0 {
+		n.nbytes += len(tl.Bytes) - len(n.lines[ln].Bytes)
+		n.lines[ln] = tl
+		return
+	}
+	if ln < n.left.nlines {
+		ropeSet(n.left, ln, tl)
+	} else {
+		ropeSet(n.right, ln-n.left.nlines, tl)
+	}
+	n.nbytes = n.left.nbytes + n.right.nbytes
+}
+
+// ropeRange calls fun for lines st..ed (relative to n, with n starting at
+// line base) -- returns false if stopped
+func ropeRange(n *ropeNode, st, ed, base int, fun func(ln int, tl *TextLine) bool) bool {
+	if st < 0 {
+		st = 0
+	}
+	if ed > n.nlines {
+		ed = n.nlines
+	}
+	if st >= ed {
+		return true
+	}
+	if n.height == 0 {
+		for i := st; i < ed; i++ {
+			if !fun(base+i, n.lines[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	nl := n.left.nlines
+	if st < nl {
+		if !ropeRange(n.left, st, ed, base, fun) {
+			return false
+		}
+	}
+	if ed > nl {
+		return ropeRange(n.right, st-nl, ed-nl, base+nl, fun)
+	}
+	return true
+}
@@ -0,0 +1,376 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goki/ki"
+	"github.com/goki/ki/ints"
+)
+
+// TextSearchOpts are the options for finding and replacing text with Find,
+// Replace, ReplaceAll etc -- matches are always within a single line
+type TextSearchOpts struct {
+	Regexp     bool `desc:"find string is a regular expression, in Go regexp syntax, and the replace string can refer to capture groups as $1, ${name} etc -- otherwise both are literal text"`
+	WholeWord  bool `desc:"only match whole words"`
+	IgnoreCase bool `desc:"ignore case when matching"`
+}
+
+// Compile returns the regular expression for finding given string with
+// these options
+func (so *TextSearchOpts) Compile(find string) (*regexp.Regexp, error) {
+	pat := find
+	if !so.Regexp {
+		pat = regexp.QuoteMeta(find)
+	}
+	if so.WholeWord {
+		pat = `\b(?:` + pat + `)\b`
+	}
+	if so.IgnoreCase {
+		pat = `(?i)` + pat
+	}
+	return regexp.Compile(pat)
+}
+
+// expand returns the replacement for the match of re at given submatch
+// indexes in line -- capture groups are only expanded for Regexp
+func (so *TextSearchOpts) expand(re *regexp.Regexp, repl []byte, line []byte, idx []int) []byte {
+	if !so.Regexp {
+		return repl
+	}
+	return re.Expand(nil, repl, line, idx)
+}
+
+// searchMatchText returns the text around a match between byte offsets st,
+// ed in line b, with the match marked, for FileSearchMatch Text
+func searchMatchText(b []byte, st, ed int) []byte {
+	cist := ints.MaxInt(st-FileSearchContext, 0)
+	cied := ints.MinInt(ed+FileSearchContext, len(b))
+	txt := make([]byte, 0, cied-cist+13)
+	txt = append(txt, b[cist:st]...)
+	txt = append(txt, "<mark>"...)
+	txt = append(txt, b[st:ed]...)
+	txt = append(txt, "</mark>"...)
+	return append(txt, b[ed:cied]...)
+}
+
+// searchLine appends the matches of re in line ln with text b, with
+// positions in runes
+func searchLine(re *regexp.Regexp, ln int, b []byte, matches []FileSearchMatch) []FileSearchMatch {
+	for _, idx := range re.FindAllIndex(b, -1) {
+		if idx[0] == idx[1] {
+			continue // empty matches are not useful
+		}
+		sch := utf8.RuneCount(b[:idx[0]])
+		ech := sch + utf8.RuneCount(b[idx[0]:idx[1]])
+		reg := TextRegion{Start: TextPos{Ln: ln, Ch: sch}, End: TextPos{Ln: ln, Ch: ech}}
+		matches = append(matches, FileSearchMatch{Reg: reg, Text: searchMatchText(b, idx[0], idx[1])})
+	}
+	return matches
+}
+
+// ByteBufSearchRegexp looks for matches of given regexp within a byte
+// buffer, line by line, returning number of occurences and specific match
+// position list -- column positions are in runes.
+func ByteBufSearchRegexp(reader io.Reader, re *regexp.Regexp) (int, []FileSearchMatch) {
+	var matches []FileSearchMatch
+	scan := bufio.NewScanner(reader)
+	scan.Buffer(nil, 16*1024*1024)
+	ln := 0
+	for scan.Scan() {
+		matches = searchLine(re, ln, scan.Bytes(), matches)
+		ln++
+	}
+	if err := scan.Err(); err != nil {
+		log.Printf("giv.ByteBufSearchRegexp error: %v\n", err)
+	}
+	return len(matches), matches
+}
+
+// FileSearchRegexp looks for matches of given regexp within a file,
+// returning number of occurences and specific match position list -- column
+// positions are in runes.
+func FileSearchRegexp(filename string, re *regexp.Regexp) (int, []FileSearchMatch) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		log.Printf("giv.FileSearchRegexp file open error: %v\n", err)
+		return 0, nil
+	}
+	defer fp.Close()
+	return ByteBufSearchRegexp(fp, re)
+}
+
+// replaceLine replaces all the matches of re in line b with repl, which
+// are all found in the original line, returning the number of them and the
+// replacement nb for the part of the line from byte st to ed that they span
+func (so *TextSearchOpts) replaceLine(re *regexp.Regexp, b, repl []byte) (n, st, ed int, nb []byte) {
+	for _, idx := range re.FindAllSubmatchIndex(b, -1) {
+		if idx[0] == idx[1] {
+			continue
+		}
+		if n == 0 {
+			st = idx[0]
+		} else {
+			nb = append(nb, b[ed:idx[0]]...)
+		}
+		nb = append(nb, so.expand(re, repl, b, idx)...)
+		ed = idx[1]
+		n++
+	}
+	return
+}
+
+// ReplaceBytes replaces all the matches of find with repl in given text,
+// line by line, returning the new text and number of replacements
+func ReplaceBytes(txt []byte, find, repl string, opts TextSearchOpts) ([]byte, int, error) {
+	re, err := opts.Compile(find)
+	if err != nil {
+		return txt, 0, err
+	}
+	rb := []byte(repl)
+	lns := bytes.Split(txt, []byte("\n"))
+	n := 0
+	for li, b := range lns {
+		ln, st, ed, nb := opts.replaceLine(re, b, rb)
+		if ln == 0 {
+			continue
+		}
+		lns[li] = append(append(append([]byte(nil), b[:st]...), nb...), b[ed:]...)
+		n += ln
+	}
+	if n == 0 {
+		return txt, 0, nil
+	}
+	return bytes.Join(lns, []byte("\n")), n, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   TextBuf
+
+// Find looks for matches of given find string with given options within
+// the buffer, returning number of occurences and specific match position
+// list -- column positions are in runes, as for all TextPos
+func (tb *TextBuf) Find(find string, opts TextSearchOpts) (int, []FileSearchMatch, error) {
+	if find == "" {
+		return 0, nil, nil
+	}
+	re, err := opts.Compile(find)
+	if err != nil {
+		return 0, nil, err
+	}
+	var matches []FileSearchMatch
+	tb.Lns.Range(0, tb.NLines, func(ln int, tl *TextLine) bool {
+		matches = searchLine(re, ln, tl.Bytes, matches)
+		return true
+	})
+	return len(matches), matches, nil
+}
+
+// replaceMatch replaces the match of re at given region, which must be a
+// match returned from Find, with repl -- returns nil if it is not a match
+func (tb *TextBuf) replaceMatch(re *regexp.Regexp, reg TextRegion, repl []byte, opts TextSearchOpts) *TextBufEdit {
+	if reg.Start.Ln != reg.End.Ln || reg.Start.Ln >= tb.NLines {
+		return nil
+	}
+	tl := tb.Lns.Line(reg.Start.Ln)
+	b := tl.Bytes
+	sb := tl.RuneByteOff(reg.Start.Ch)
+	eb := tl.RuneByteOff(reg.End.Ch)
+	for _, idx := range re.FindAllSubmatchIndex(b, -1) {
+		if idx[0] != sb || idx[1] != eb {
+			continue
+		}
+		nt := opts.expand(re, repl, b, idx)
+		tb.UndoGroupStart()
+		tb.DeleteText(reg.Start, reg.End, true, true)
+		tbe := tb.InsertText(reg.Start, nt, true, true)
+		tb.UndoGroupEnd()
+		if tbe == nil { // replaced with nothing
+			tbe = &TextBufEdit{Reg: TextRegion{Start: reg.Start, End: reg.Start}}
+		}
+		return tbe
+	}
+	return nil
+}
+
+// Replace replaces the match of find with given options at given region,
+// which must be a match returned from Find, with repl, as one undoable edit
+// -- with Regexp, repl can refer to capture groups as $1, ${name} etc --
+// returns the edit for the inserted replacement text, or nil if the region
+// is not a match
+func (tb *TextBuf) Replace(reg TextRegion, find, repl string, opts TextSearchOpts) (*TextBufEdit, error) {
+	re, err := opts.Compile(find)
+	if err != nil {
+		return nil, err
+	}
+	return tb.replaceMatch(re, reg, []byte(repl), opts), nil
+}
+
+// ReplaceAll replaces all the matches of find with given options with
+// repl, as one undoable edit -- with Regexp, repl can refer to capture
+// groups as $1, ${name} etc -- returns the number of replacements, which is
+// the number of matches from Find: the matches in each line are all found
+// before it is changed, so none of them are missed when the replacement of
+// another changes their context, and the result is the same as ReplaceBytes
+func (tb *TextBuf) ReplaceAll(find, repl string, opts TextSearchOpts) (int, error) {
+	re, err := opts.Compile(find)
+	if err != nil || find == "" {
+		return 0, err
+	}
+	rb := []byte(repl)
+	n := 0
+	for ln := tb.NLines - 1; ln >= 0; ln-- { // from end, so lines stay valid
+		b := tb.Lns.Line(ln).Bytes
+		lnn, st, ed, nb := opts.replaceLine(re, b, rb)
+		if lnn == 0 {
+			continue
+		}
+		if n == 0 {
+			tb.UndoGroupStart()
+		}
+		reg := TextRegion{Start: TextPos{Ln: ln, Ch: utf8.RuneCount(b[:st])}, End: TextPos{Ln: ln, Ch: utf8.RuneCount(b[:ed])}}
+		tb.DeleteText(reg.Start, reg.End, true, true)
+		tb.InsertText(reg.Start, nb, true, true)
+		n += lnn
+	}
+	if n > 0 {
+		tb.UndoGroupEnd()
+	}
+	return n, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   FileTree
+
+// FileSearchResults are the matches of a search within one file of a
+// FileTree
+type FileSearchResults struct {
+	Node    *FileNode         `desc:"file node for the file"`
+	Count   int               `desc:"number of matches"`
+	Matches []FileSearchMatch `desc:"the matches within the file, with context -- column positions are in runes"`
+}
+
+// fileSearchBuf returns the open buffer for the file, if it is open
+func (fn *FileNode) fileSearchBuf() *TextBuf {
+	if fn.Buf != nil && fn.Buf.Filename == fn.FPath {
+		return fn.Buf
+	}
+	return nil
+}
+
+// SearchFiles looks for matches of find with given options in all the
+// files within this node, for those whose names end with one of the given
+// extensions (all if none) -- open files are searched in their buffers,
+// including any unsaved edits, and others are read from disk -- results are
+// sorted by number of matches, most first
+func (fn *FileNode) SearchFiles(find string, opts TextSearchOpts, exts ...string) ([]FileSearchResults, error) {
+	if find == "" {
+		return nil, nil
+	}
+	re, err := opts.Compile(find)
+	if err != nil {
+		return nil, err
+	}
+	var res []FileSearchResults
+	fn.FuncDownMeFirst(0, fn, func(k ki.Ki, level int, d interface{}) bool {
+		sfn := k.Embed(KiT_FileNode).(*FileNode)
+		if sfn.IsDir() || !sfn.hasExt(exts) {
+			return true
+		}
+		var cnt int
+		var matches []FileSearchMatch
+		if buf := sfn.fileSearchBuf(); buf != nil {
+			cnt, matches, _ = buf.Find(find, opts)
+		} else {
+			cnt, matches = FileSearchRegexp(string(sfn.FPath), re)
+		}
+		if cnt > 0 {
+			res = append(res, FileSearchResults{Node: sfn, Count: cnt, Matches: matches})
+		}
+		return true
+	})
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Count > res[j].Count
+	})
+	return res, nil
+}
+
+// ReplaceFiles replaces all the matches of find with given options with
+// repl in all the files within this node, for those whose names end with
+// one of the given extensions (all if none) -- open files are replaced in their
+// buffers, as one undoable edit, and others are rewritten on disk --
+// returns the total number of replacements
+func (fn *FileNode) ReplaceFiles(find, repl string, opts TextSearchOpts, exts ...string) (int, error) {
+	if _, err := opts.Compile(find); err != nil {
+		return 0, err
+	}
+	tot := 0
+	var rerr error
+	fn.FuncDownMeFirst(0, fn, func(k ki.Ki, level int, d interface{}) bool {
+		sfn := k.Embed(KiT_FileNode).(*FileNode)
+		if sfn.IsDir() || !sfn.hasExt(exts) {
+			return true
+		}
+		if buf := sfn.fileSearchBuf(); buf != nil {
+			n, _ := buf.ReplaceAll(find, repl, opts)
+			tot += n
+			return true
+		}
+		n, err := ReplaceInFile(string(sfn.FPath), find, repl, opts)
+		if err != nil {
+			rerr = err
+		}
+		tot += n
+		return true
+	})
+	return tot, rerr
+}
+
+// hasExt returns true if the file name ends with one of given extensions,
+// or there are none
+func (fn *FileNode) hasExt(exts []string) bool {
+	if len(exts) == 0 {
+		return true
+	}
+	for _, ext := range exts {
+		if strings.HasSuffix(fn.Nm, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceInFile replaces all the matches of find with given options with
+// repl in given file on disk, returning the number of replacements -- the
+// file is only written if there were any
+func ReplaceInFile(filename string, find, repl string, opts TextSearchOpts) (int, error) {
+	txt, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	ntxt, n, err := ReplaceBytes(txt, find, repl, opts)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	mode := os.FileMode(0644)
+	if st, err := os.Stat(filename); err == nil {
+		mode = st.Mode()
+	}
+	if err := ioutil.WriteFile(filename, ntxt, mode); err != nil {
+		log.Printf("giv.ReplaceInFile: could not write file: %v, error: %v\n", filename, err)
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTextBufReplaceAllContext(t *testing.T) {
+	// replacing the second x would stop the first one from matching
+	src := "xxy\nax xx\n"
+	opts := TextSearchOpts{Regexp: true}
+	tb := testTextBuf([]byte(src))
+	nf, _, _ := tb.Find(`x\B`, opts)
+	n, err := tb.ReplaceAll(`x\B`, ".", opts)
+	if err != nil || n != nf || n != 3 {
+		t.Errorf("ReplaceAll: %d replacements for %d matches, %v", n, nf, err)
+	}
+	rb, rn, _ := ReplaceBytes([]byte(src), `x\B`, ".", opts)
+	tb.LinesToBytes()
+	if string(tb.Txt) != string(rb) || rn != n {
+		t.Errorf("ReplaceAll: %q != ReplaceBytes: %q (%d)", tb.Txt, rb, rn)
+	}
+	if exp := "..y\nax .x\n"; string(tb.Txt) != exp {
+		t.Errorf("ReplaceAll: %q != %q", tb.Txt, exp)
+	}
+	tb.Undo()
+	tb.LinesToBytes()
+	if string(tb.Txt) != src {
+		t.Errorf("ReplaceAll not undone as one edit: %q", tb.Txt)
+	}
+}
+
+// testSearchFiles are the files for the FileTree search tests
+var testSearchFiles = map[string]string{
+	"a.go":     "foo foo\nbar\n",
+	"b.txt":    "foo\n",
+	"c.go.bak": "foo\n",
+	"d.go":     "nothing\n",
+}
+
+// testSearchTree returns a FileTree for a temp dir with testSearchFiles,
+// which must be removed
+func testSearchTree(t *testing.T) (*FileTree, string) {
+	dir, err := ioutil.TempDir("", "giv-search-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fnm, txt := range testSearchFiles {
+		if err := ioutil.WriteFile(filepath.Join(dir, fnm), []byte(txt), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ft := &FileTree{}
+	ft.InitName(ft, "test")
+	ft.OpenPath(dir)
+	return ft, dir
+}
+
+// testSearchCounts returns the count of matches by file name
+func testSearchCounts(res []FileSearchResults) map[string]int {
+	cnts := make(map[string]int)
+	for _, r := range res {
+		cnts[r.Node.Nm] = r.Count
+	}
+	return cnts
+}
+
+func TestFileNodeSearchFiles(t *testing.T) {
+	ft, dir := testSearchTree(t)
+	defer os.RemoveAll(dir)
+	res, err := ft.SearchFiles("foo", TextSearchOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cnts := testSearchCounts(res); len(res) != 3 || cnts["a.go"] != 2 || cnts["b.txt"] != 1 || cnts["c.go.bak"] != 1 {
+		t.Errorf("SearchFiles all: %v", cnts)
+	}
+	if res[0].Node.Nm != "a.go" {
+		t.Errorf("SearchFiles not sorted by count: %v first", res[0].Node.Nm)
+	}
+	res, _ = ft.SearchFiles("foo", TextSearchOpts{}, ".go")
+	if cnts := testSearchCounts(res); len(res) != 1 || cnts["a.go"] != 2 {
+		t.Errorf("SearchFiles .go, not .go.bak: %v", cnts)
+	}
+	if m := res[0].Matches[1]; m.Reg.Start != (TextPos{Ln: 0, Ch: 4}) || string(m.Text) != "foo <mark>foo</mark>" {
+		t.Errorf("SearchFiles match: %v %q", m.Reg, m.Text)
+	}
+
+	// open files are searched in their buffers, with unsaved edits
+	fn, ok := ft.FindFile("b.txt")
+	if !ok {
+		t.Fatal("b.txt not found")
+	}
+	if _, err := fn.OpenBuf(); err != nil {
+		t.Fatal(err)
+	}
+	fn.Buf.InsertText(TextPos{}, []byte("foo "), true, true)
+	res, _ = ft.SearchFiles("foo", TextSearchOpts{}, ".txt")
+	if cnts := testSearchCounts(res); cnts["b.txt"] != 2 {
+		t.Errorf("SearchFiles open buffer: %v", cnts)
+	}
+}
+
+func TestFileNodeReplaceFiles(t *testing.T) {
+	ft, dir := testSearchTree(t)
+	defer os.RemoveAll(dir)
+	fn, ok := ft.FindFile("b.txt")
+	if !ok {
+		t.Fatal("b.txt not found")
+	}
+	if _, err := fn.OpenBuf(); err != nil {
+		t.Fatal(err)
+	}
+	n, err := ft.ReplaceFiles("foo", "baz", TextSearchOpts{WholeWord: true}, ".go", ".txt")
+	if err != nil || n != 3 {
+		t.Errorf("ReplaceFiles: %d != 3, %v", n, err)
+	}
+	read := func(fnm string) string {
+		b, err := ioutil.ReadFile(filepath.Join(dir, fnm))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if txt := read("a.go"); txt != "baz baz\nbar\n" {
+		t.Errorf("unopened file not rewritten on disk: %q", txt)
+	}
+	if txt := read("b.txt"); txt != "foo\n" {
+		t.Errorf("open file rewritten on disk: %q", txt)
+	}
+	if txt := string(fn.Buf.LinesToBytesCopy()); txt != "baz\n" {
+		t.Errorf("open file not replaced in buffer: %q", txt)
+	}
+	if txt := read("c.go.bak"); txt != "foo\n" {
+		t.Errorf("file without the extensions rewritten: %q", txt)
+	}
+	if n, err := ft.ReplaceFiles("(", "x", TextSearchOpts{Regexp: true}); err == nil || n != 0 {
+		t.Errorf("ReplaceFiles with bad regexp: %d, %v", n, err)
+	}
+}
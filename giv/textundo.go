@@ -0,0 +1,368 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/fnv"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+	"unicode"
+)
+
+// TextBufUndoCoalesceMSec is the maximum time between typed characters, or
+// deleted characters, for them to be coalesced into one undo group, so that
+// a run of typing is undone in one step
+var TextBufUndoCoalesceMSec = 1000
+
+// TextBufUndoBranch is an alternative sequence of edits in the undo history,
+// which were undone and then replaced by new edits -- these are only kept
+// when TextBuf.UndoTree is on, and are navigated with UndoBranchNext
+type TextBufUndoBranch struct {
+	Pos      int                  `desc:"position in the Undos edits where this branch starts"`
+	Undos    []*TextBufEdit       `desc:"the edits of this branch, continuing from Pos"`
+	Branches []*TextBufUndoBranch `desc:"branches off of this branch, at positions after Pos"`
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   Undo
+
+// SaveUndo saves given edit to undo stack, in the current undo group if
+// one is open -- otherwise a run of typed or deleted single characters is
+// coalesced into one group -- any undone edits are discarded, unless
+// UndoTree is on, in which case they are kept as a branch
+func (tb *TextBuf) SaveUndo(tbe *TextBufEdit) {
+	if tb.UndoPos < len(tb.Undos) {
+		if tb.UndoTree {
+			tb.undoBranchOff()
+		}
+		tb.Undos = tb.Undos[:tb.UndoPos]
+	}
+	tbe.Time = time.Now()
+	switch {
+	case tb.undoGrpLev > 0:
+	case tb.UndoPos > 0 && tb.undoCoalesce(tb.Undos[tb.UndoPos-1], tbe):
+	default:
+		tb.UndoGroup++
+	}
+	tbe.Group = tb.UndoGroup
+	tb.Undos = append(tb.Undos, tbe)
+	tb.UndoPos = len(tb.Undos)
+}
+
+// undoCoalesce returns true if given edit continues the run of typing or
+// deleting of the previous edit, within TextBufUndoCoalesceMSec -- a run of
+// typing ends at the start of whitespace
+func (tb *TextBuf) undoCoalesce(prv, tbe *TextBufEdit) bool {
+	if prv.Group != tb.UndoGroup || prv.Delete != tbe.Delete {
+		return false
+	}
+	if len(prv.Text) != 1 || len(tbe.Text) != 1 || len(prv.Text[0]) != 1 || len(tbe.Text[0]) != 1 {
+		return false
+	}
+	if tbe.Time.Sub(prv.Time) > time.Duration(TextBufUndoCoalesceMSec)*time.Millisecond {
+		return false
+	}
+	if tbe.Delete {
+		return tbe.Reg.End == prv.Reg.Start || tbe.Reg.Start == prv.Reg.Start // backspace or delete
+	}
+	if tbe.Reg.Start != prv.Reg.End {
+		return false
+	}
+	return !unicode.IsSpace(tbe.Text[0][0]) || unicode.IsSpace(prv.Text[0][0])
+}
+
+// UndoGroupStart starts a group of edits that are undone and redone together
+// as one step, e.g., the edits at each cursor of a multi-cursor edit --
+// must be matched by a call to UndoGroupEnd -- groups can be nested, in
+// which case the outermost group applies
+func (tb *TextBuf) UndoGroupStart() {
+	if tb.undoGrpLev == 0 {
+		tb.UndoGroup++
+	}
+	tb.undoGrpLev++
+}
+
+// UndoGroupEnd ends a group of edits started with UndoGroupStart -- the
+// next edit starts a new group
+func (tb *TextBuf) UndoGroupEnd() {
+	if tb.undoGrpLev > 0 {
+		tb.undoGrpLev--
+	}
+	if tb.undoGrpLev == 0 {
+		tb.UndoGroup++
+	}
+}
+
+// Undo undoes next group of edits on the undo stack, and returns the first
+// edit record of the group, which was undone last -- nil if no more
+func (tb *TextBuf) Undo() *TextBufEdit {
+	if tb.UndoPos == 0 {
+		tb.Changed = false // should be!
+		tb.AutoSaveDelete()
+		return nil
+	}
+	grp := tb.Undos[tb.UndoPos-1].Group
+	var tbe *TextBufEdit
+	for tb.UndoPos > 0 && tb.Undos[tb.UndoPos-1].Group == grp {
+		tb.UndoPos--
+		tbe = tb.Undos[tb.UndoPos]
+		if tbe.Delete {
+			// fmt.Printf("undoing delete at: %v text: %v\n", tbe.Reg, string(tbe.ToBytes()))
+			tb.InsertText(tbe.Reg.Start, tbe.ToBytes(), false, true)
+		} else {
+			// fmt.Printf("undoing insert at: %v text: %v\n", tbe.Reg, string(tbe.ToBytes()))
+			tb.DeleteText(tbe.Reg.Start, tbe.Reg.End, false, true)
+		}
+	}
+	tb.UndoGroup++ // no coalescing with undone edits
+	return tbe
+}
+
+// Redo redoes next group of edits on the undo stack, and returns the last
+// edit record of the group, nil if no more
+func (tb *TextBuf) Redo() *TextBufEdit {
+	if tb.UndoPos >= len(tb.Undos) {
+		return nil
+	}
+	grp := tb.Undos[tb.UndoPos].Group
+	var tbe *TextBufEdit
+	for tb.UndoPos < len(tb.Undos) && tb.Undos[tb.UndoPos].Group == grp {
+		tbe = tb.Undos[tb.UndoPos]
+		if tbe.Delete {
+			tb.DeleteText(tbe.Reg.Start, tbe.Reg.End, false, true)
+		} else {
+			tb.InsertText(tbe.Reg.Start, tbe.ToBytes(), false, true)
+		}
+		tb.UndoPos++
+	}
+	tb.UndoGroup++
+	return tbe
+}
+
+// ResetUndo clears the undo history
+func (tb *TextBuf) ResetUndo() {
+	tb.Undos = nil
+	tb.UndoPos = 0
+	tb.UndoBranches = nil
+	tb.UndoGroup++
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   Undo Tree
+
+// undoBranchOff moves the undone edits after UndoPos to a new branch, along
+// with any branches within them
+func (tb *TextBuf) undoBranchOff() {
+	pos := tb.UndoPos
+	if pos >= len(tb.Undos) {
+		return
+	}
+	br := &TextBufUndoBranch{Pos: pos, Undos: append([]*TextBufEdit(nil), tb.Undos[pos:]...)}
+	var keep []*TextBufUndoBranch
+	for _, ob := range tb.UndoBranches {
+		if ob.Pos > pos {
+			br.Branches = append(br.Branches, ob)
+		} else {
+			keep = append(keep, ob)
+		}
+	}
+	tb.UndoBranches = append(keep, br)
+}
+
+// NUndoBranches returns the number of alternative branches of undone edits
+// at the current undo position, in addition to the current one that Redo
+// would redo -- see UndoBranchNext
+func (tb *TextBuf) NUndoBranches() int {
+	n := 0
+	for _, br := range tb.UndoBranches {
+		if br.Pos == tb.UndoPos {
+			n++
+		}
+	}
+	return n
+}
+
+// UndoBranchNext switches the edits that Redo will redo to the next
+// alternative branch at the current undo position, cycling through all the
+// branches there -- the text is not changed until Redo -- returns false if
+// there are no alternative branches here.  Only available with UndoTree.
+func (tb *TextBuf) UndoBranchNext() bool {
+	pos := tb.UndoPos
+	bi := -1
+	for i, br := range tb.UndoBranches {
+		if br.Pos == pos {
+			bi = i
+			break
+		}
+	}
+	if bi < 0 {
+		return false
+	}
+	br := tb.UndoBranches[bi]
+	tb.UndoBranches = append(tb.UndoBranches[:bi], tb.UndoBranches[bi+1:]...)
+	tb.undoBranchOff()
+	tb.Undos = append(tb.Undos[:pos], br.Undos...)
+	tb.UndoBranches = append(tb.UndoBranches, br.Branches...)
+	tb.UndoGroup++
+	return true
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   Undo History Files
+
+// textBufUndoEdit is the saved form of a TextBufEdit
+type textBufUndoEdit struct {
+	Reg    TextRegion
+	Delete bool
+	Text   string
+	Group  int
+	Time   time.Time
+}
+
+// textBufUndoBranch is the saved form of a TextBufUndoBranch
+type textBufUndoBranch struct {
+	Pos      int
+	Undos    []textBufUndoEdit
+	Branches []textBufUndoBranch
+}
+
+// textBufUndoHist is the saved form of the undo history of a TextBuf
+type textBufUndoHist struct {
+	Hash     uint64 `desc:"hash of the text that the history applies to"`
+	Size     int    `desc:"size of the text that the history applies to"`
+	UndoPos  int
+	Undos    []textBufUndoEdit
+	Branches []textBufUndoBranch
+}
+
+func undoEditsToSave(tbes []*TextBufEdit) []textBufUndoEdit {
+	se := make([]textBufUndoEdit, len(tbes))
+	for i, tbe := range tbes {
+		se[i] = textBufUndoEdit{Reg: tbe.Reg, Delete: tbe.Delete, Text: string(tbe.ToBytes()), Group: tbe.Group, Time: tbe.Time}
+	}
+	return se
+}
+
+func undoEditsFromSave(se []textBufUndoEdit) []*TextBufEdit {
+	tbes := make([]*TextBufEdit, len(se))
+	for i, e := range se {
+		tbe := &TextBufEdit{Reg: e.Reg, Delete: e.Delete, Group: e.Group, Time: e.Time}
+		lns := bytes.Split([]byte(e.Text), []byte("\n"))
+		tbe.Text = make([][]rune, len(lns))
+		for li, ln := range lns {
+			tbe.Text[li] = bytes.Runes(ln)
+		}
+		tbes[i] = tbe
+	}
+	return tbes
+}
+
+func undoBranchesToSave(brs []*TextBufUndoBranch) []textBufUndoBranch {
+	sb := make([]textBufUndoBranch, len(brs))
+	for i, br := range brs {
+		sb[i] = textBufUndoBranch{Pos: br.Pos, Undos: undoEditsToSave(br.Undos), Branches: undoBranchesToSave(br.Branches)}
+	}
+	return sb
+}
+
+func undoBranchesFromSave(sb []textBufUndoBranch) []*TextBufUndoBranch {
+	brs := make([]*TextBufUndoBranch, len(sb))
+	for i, b := range sb {
+		brs[i] = &TextBufUndoBranch{Pos: b.Pos, Undos: undoEditsFromSave(b.Undos), Branches: undoBranchesFromSave(b.Branches)}
+	}
+	return brs
+}
+
+// textHash returns the hash used to check that saved undo history applies
+// to given text
+func textHash(txt []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(txt)
+	return h.Sum64()
+}
+
+// linesHash returns the textHash and size in bytes of the current lines,
+// without copying them
+func (tb *TextBuf) linesHash() (uint64, int) {
+	h := fnv.New64a()
+	n, _ := tb.Lns.WriteTo(h)
+	return h.Sum64(), int(n)
+}
+
+// UndoHistFilename returns the filename where the undo history is saved,
+// next to the autosave file
+func (tb *TextBuf) UndoHistFilename() string {
+	return tb.AutoSaveFilename() + ".undo"
+}
+
+// SaveUndoHist saves the undo history to UndoHistFilename, recording the
+// current text that it applies to -- called on saving the file when UndoSave
+// is on
+func (tb *TextBuf) SaveUndoHist() error {
+	hash, size := tb.linesHash()
+	hist := textBufUndoHist{Hash: hash, Size: size, UndoPos: tb.UndoPos, Undos: undoEditsToSave(tb.Undos), Branches: undoBranchesToSave(tb.UndoBranches)}
+	b, err := json.Marshal(&hist)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	fn := tb.UndoHistFilename()
+	err = ioutil.WriteFile(fn, b, 0644)
+	if err != nil {
+		log.Printf("giv.TextBuf: Could not save undo history: %v, error: %v\n", fn, err)
+	}
+	return err
+}
+
+// OpenUndoHist restores the undo history from UndoHistFilename, if it
+// exists and applies to the current Txt -- called on opening the file when
+// UndoSave is on -- returns false if not restored
+func (tb *TextBuf) OpenUndoHist() bool {
+	b, err := ioutil.ReadFile(tb.UndoHistFilename())
+	if err != nil {
+		return false
+	}
+	var hist textBufUndoHist
+	if err := json.Unmarshal(b, &hist); err != nil {
+		log.Printf("giv.TextBuf: Could not read undo history: %v, error: %v\n", tb.UndoHistFilename(), err)
+		return false
+	}
+	if hist.Size != len(tb.Txt) || hist.Hash != textHash(tb.Txt) {
+		return false // file changed since
+	}
+	tb.Undos = undoEditsFromSave(hist.Undos)
+	tb.UndoPos = hist.UndoPos
+	if tb.UndoPos > len(tb.Undos) {
+		tb.UndoPos = len(tb.Undos)
+	}
+	tb.UndoBranches = undoBranchesFromSave(hist.Branches)
+	tb.UndoGroup = maxUndoGroup(tb.Undos, tb.UndoBranches) + 1
+	return true
+}
+
+// maxUndoGroup returns the highest undo group in given edits and branches
+func maxUndoGroup(tbes []*TextBufEdit, brs []*TextBufUndoBranch) int {
+	mx := 0
+	for _, tbe := range tbes {
+		if tbe.Group > mx {
+			mx = tbe.Group
+		}
+	}
+	for _, br := range brs {
+		if bmx := maxUndoGroup(br.Undos, br.Branches); bmx > mx {
+			mx = bmx
+		}
+	}
+	return mx
+}
+
+// UndoHistDelete deletes any saved undo history file
+func (tb *TextBuf) UndoHistDelete() {
+	os.Remove(tb.UndoHistFilename())
+}
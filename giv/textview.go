@@ -10,11 +10,14 @@ import (
 	"image"
 	"image/draw"
 	"log"
+	"math"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unicode"
 
+	"github.com/alecthomas/chroma"
 	"github.com/chewxy/math32"
 	"github.com/goki/gi"
 	"github.com/goki/gi/complete"
@@ -54,6 +57,7 @@ type TextView struct {
 	Opts              TextViewOpts              `desc:"options for how text editing / viewing works"`
 	CursorWidth       units.Value               `xml:"cursor-width" desc:"width of cursor -- set from cursor-width property (inherited)"`
 	LineIcons         map[int]gi.IconName       `desc:"icons for each line -- use SetLineIcon and DeleteLineIcon"`
+	LineColors        map[int]gi.Color          `desc:"background colors for each line -- use SetLineColor and DeleteLineColor"`
 	FocusActive       bool                      `json:"-" xml:"-" desc:"true if the keyboard focus is active or not -- when we lose active focus we apply changes"`
 	NLines            int                       `json:"-" xml:"-" desc:"number of lines in the view -- sync'd with the Buf after edits, but always reflects storage size of Renders etc"`
 	Renders           []gi.TextRender           `json:"-" xml:"-" desc:"renders of the text lines, with one render per line (each line could visibly wrap-around, so these are logical lines, not display lines)"`
@@ -68,6 +72,8 @@ type TextView struct {
 	PosHistIdx        int                       `json:"-" xml:"-" desc:"current index within PosHistory"`
 	SelectStart       TextPos                   `json:"-" xml:"-" desc:"starting point for selection -- will either be the start or end of selected region depending on subsequent selection."`
 	SelectReg         TextRegion                `json:"-" xml:"-" desc:"current selection region"`
+	Cursors           []TextCursor              `json:"-" xml:"-" desc:"additional cursors for multi-cursor editing, each with its own selection -- typing, deleting, cut, copy and paste apply at these and at the main CursorPos and SelectReg, as one undo group"`
+	BoxSelect         bool                      `json:"-" xml:"-" desc:"if true, a rectangular (column) box selection is being made, from SelectStart to CursorPos, as a cursor on each line"`
 	PrevSelectReg     TextRegion                `json:"-" xml:"-" desc:"previous selection region, that was actually rendered -- needed to update render"`
 	Highlights        []TextRegion              `json:"-" xml:"-" desc:"highlighed regions, e.g., for search results"`
 	SelectMode        bool                      `json:"-" xml:"-" desc:"if true, select text as cursor moves"`
@@ -89,11 +95,20 @@ type TextView struct {
 	BlinkOn           bool                      `json:"-" xml:"-" oscillates between on and off for blinking"`
 	Complete          *gi.Complete              `json:"-" xml:"-" desc:"functions and data for textfield completion"`
 	CompleteTimer     *time.Timer               `json:"-" xml:"-" desc:"timer for delay before completion popup menu appears"`
+	Folds             []TextFold                `json:"-" xml:"-" desc:"regions of lines that can be folded, computed from the buffer -- see TextBuf.FoldRegions"`
+	Folded            []TextFold                `json:"-" xml:"-" desc:"currently folded regions, sorted by starting line -- their lines after the first are hidden"`
 	needsRefresh      int32                     // used in atomically safe way to indicate when refresh required
 	reLayout          bool
 	lastRecenter      int
 	lastFilename      gi.FileName
 	lastWasTabAI      bool
+	nCursorSprites    int
+	hidden            []bool
+	foldsStale        bool
+	hiFonts           map[chroma.TokenType]*gi.FontStyle
+	markMu            sync.Mutex
+	markUpdt          TextRegion
+	markPend          bool
 }
 
 var KiT_TextView = kit.Types.AddType(&TextView{}, TextViewProps)
@@ -218,15 +233,63 @@ func (tv *TextView) ClearNeedsRefresh() {
 	atomic.StoreInt32(&tv.needsRefresh, 0)
 }
 
-// RefreshIfNeeded re-displays everything if SetNeedsRefresh was called --
+// RefreshIfNeeded re-displays the lines updated by the buffer in another
+// goroutine (e.g., syntax highlighting) if SetNeedsRefresh was called --
 // returns true if refrehshed
 func (tv *TextView) RefreshIfNeeded() bool {
-	if tv.NeedsRefresh() {
+	if !tv.NeedsRefresh() {
+		return false
+	}
+	tv.ClearNeedsRefresh()
+	tv.markMu.Lock()
+	reg, pend := tv.markUpdt, tv.markPend
+	tv.markPend = false
+	tv.markMu.Unlock()
+	st := ints.MaxInt(reg.Start.Ln, 0)
+	ed := ints.MinInt(reg.End.Ln, tv.NLines-1)
+	if !pend || tv.Renders == nil || tv.Buf == nil || tv.NLines != tv.Buf.NLines || (st == 0 && ed == tv.NLines-1) {
 		tv.Refresh()
-		tv.ClearNeedsRefresh()
 		return true
 	}
-	return false
+	if st > ed {
+		return true
+	}
+	if tv.LayoutLines(st, ed, false) {
+		tv.RenderAllLines()
+	} else {
+		tv.RenderLines(st, ed)
+	}
+	return true
+}
+
+// addMarkUpdt records the lines updated by a TextBufMarkUpdt signal, to be
+// refreshed by RefreshIfNeeded -- data other than a TextRegion means all
+// lines -- called from other goroutines
+func (tv *TextView) addMarkUpdt(data interface{}) {
+	reg, ok := data.(TextRegion)
+	if !ok {
+		reg = TextRegion{End: TextPos{Ln: math.MaxInt32}}
+	}
+	tv.markMu.Lock()
+	if tv.markPend {
+		reg.Start.Ln = ints.MinInt(reg.Start.Ln, tv.markUpdt.Start.Ln)
+		reg.End.Ln = ints.MaxInt(reg.End.Ln, tv.markUpdt.End.Ln)
+	}
+	tv.markUpdt = reg
+	tv.markPend = true
+	tv.markMu.Unlock()
+	tv.SetNeedsRefresh()
+}
+
+// adjustMarkUpdt moves the lines pending refresh for given edit that has
+// just been made
+func (tv *TextView) adjustMarkUpdt(tbe *TextBufEdit) {
+	tv.markMu.Lock()
+	if tv.markPend {
+		tv.markUpdt.Start.Ln = tbe.AdjustPos(TextPos{Ln: tv.markUpdt.Start.Ln}).Ln
+		tv.markUpdt.End.Ln = tbe.AdjustPos(TextPos{Ln: tv.markUpdt.End.Ln}).Ln
+	}
+	tv.markMu.Unlock()
 }
 
 func (tv *TextView) IsChanged() bool {
@@ -244,6 +307,9 @@ func (tv *TextView) ResetState() {
 	tv.SelectReset()
 	tv.Highlights = nil
 	tv.ISearchMode = false
+	tv.Folded = nil
+	tv.hidden = nil
+	tv.foldsStale = true
 	if tv.Buf == nil || tv.lastFilename != tv.Buf.Filename { // don't reset if reopening..
 		tv.CursorPos = TextPos{}
 	}
@@ -335,6 +401,8 @@ func TextViewBufSigRecv(rvwki, sbufki ki.Ki, sig int64, data interface{}) {
 			return
 		}
 		tbe := data.(*TextBufEdit)
+		tv.adjustFolds(tbe)
+		tv.adjustMarkUpdt(tbe)
 		// fmt.Printf("tv %v got %v\n", tv.Nm, tbe.Reg.Start)
 		if tbe.Reg.Start.Ln != tbe.Reg.End.Ln {
 			tv.LinesInserted(tbe)
@@ -351,6 +419,8 @@ func TextViewBufSigRecv(rvwki, sbufki ki.Ki, sig int64, data interface{}) {
 			return
 		}
 		tbe := data.(*TextBufEdit)
+		tv.adjustFolds(tbe)
+		tv.adjustMarkUpdt(tbe)
 		if tbe.Reg.Start.Ln != tbe.Reg.End.Ln {
 			tv.LinesDeleted(tbe)
 		} else {
@@ -362,7 +432,7 @@ func TextViewBufSigRecv(rvwki, sbufki ki.Ki, sig int64, data interface{}) {
 			}
 		}
 	case TextBufMarkUpdt:
-		tv.SetNeedsRefresh() // comes from another goroutine
+		tv.addMarkUpdt(data) // comes from another goroutine
 	}
 }
 
@@ -415,6 +485,7 @@ func (tv *TextView) RenderSize() gi.Vec2D {
 
 // HiStyle applies the highlighting styles from buffer markup style
 func (tv *TextView) HiStyle() {
+	tv.hiFonts = nil
 	if !tv.Buf.Hi.HasHi() {
 		return
 	}
@@ -448,6 +519,10 @@ func (tv *TextView) LayoutAllLines(inLayout bool) bool {
 
 	tv.NLines = tv.Buf.NLines
 	nln := tv.NLines
+	if tv.foldsStale {
+		tv.UpdateFolds()
+	}
+	tv.setHidden()
 	if cap(tv.Renders) >= nln {
 		tv.Renders = tv.Renders[:nln]
 	} else {
@@ -470,11 +545,10 @@ func (tv *TextView) LayoutAllLines(inLayout bool) bool {
 	mxwd := sz.X // always start with our render size
 
 	for ln := 0; ln < nln; ln++ {
-		tv.Renders[ln].SetHTMLPre(tv.Buf.Markup[ln], &fst, &sty.Text, &sty.UnContext, tv.CSS)
+		tv.SetLineText(ln, &fst)
 		tv.Renders[ln].LayoutStdLR(&sty.Text, &sty.Font, &sty.UnContext, sz)
 		tv.Offs[ln] = off
-		lsz := gi.Max32(tv.Renders[ln].Size.Y, tv.LineHeight)
-		off += lsz
+		off += tv.LineSize(ln)
 		mxwd = gi.Max32(mxwd, tv.Renders[ln].Size.X)
 	}
 
@@ -489,6 +563,47 @@ func (tv *TextView) LayoutAllLines(inLayout bool) bool {
 	}
 }
 
+// SetLineText sets the text of the render for given line, from its syntax
+// highlighting tokens if the buffer is highlighted, else from its markup,
+// using given font style as the default
+func (tv *TextView) SetLineText(ln int, fst *gi.FontStyle) {
+	sty := &tv.Sty
+	toks, hi := tv.Buf.LineHiTokens(ln)
+	if !hi {
+		tv.Renders[ln].SetHTMLPre(tv.Buf.LineMarkup(ln), fst, &sty.Text, &sty.UnContext, tv.CSS)
+		return
+	}
+	runs := make([]gi.TextStyleRun, len(toks))
+	for i, tk := range toks {
+		runs[i] = gi.TextStyleRun{Ed: tk.Ed, Font: tv.HiFont(tk.Type, fst)}
+	}
+	tv.Renders[ln].SetStyledRunes(tv.Buf.Line(ln), runs, fst, &sty.Text, &sty.UnContext)
+}
+
+// HiFont returns the font style for given highlighting token type, from
+// the CSS class for it in the highlighting style applied to given default
+// font style -- nil if the type has no style of its own -- cached until the
+// next HiStyle
+func (tv *TextView) HiFont(tt chroma.TokenType, fst *gi.FontStyle) *gi.FontStyle {
+	if fs, ok := tv.hiFonts[tt]; ok {
+		return fs
+	}
+	if tv.hiFonts == nil {
+		tv.hiFonts = make(map[chroma.TokenType]*gi.FontStyle)
+	}
+	var fs *gi.FontStyle
+	if cls := HiClass(tt); cls != "" && tv.CSS != nil {
+		if aggp, ok := ki.SubProps(tv.CSS, "."+cls); ok {
+			nf := *fst
+			nf.SetStyleProps(nil, aggp)
+			nf.OpenFont(&tv.Sty.UnContext)
+			fs = &nf
+		}
+	}
+	tv.hiFonts[tt] = fs
+	return fs
+}
+
 // SetSize updates our size only if larger than our allocation
 func (tv *TextView) SetSize() bool {
 	sty := &tv.Sty
@@ -552,7 +667,7 @@ func (tv *TextView) LayoutLines(st, ed int, isDel bool) bool {
 
 	for ln := st; ln <= ed; ln++ {
 		curspans := len(tv.Renders[ln].Spans)
-		tv.Renders[ln].SetHTMLPre(tv.Buf.Markup[ln], &fst, &sty.Text, &sty.UnContext, tv.CSS)
+		tv.SetLineText(ln, &fst)
 		tv.Renders[ln].LayoutStdLR(&sty.Text, &sty.Font, &sty.UnContext, tv.RenderSz)
 		nwspans := len(tv.Renders[ln].Spans)
 		if nwspans != curspans && (nwspans > 1 || curspans > 1) {
@@ -570,8 +685,7 @@ func (tv *TextView) LayoutLines(st, ed int, isDel bool) bool {
 		off := tv.Offs[ofst]
 		for ln := ofst; ln < tv.NLines; ln++ {
 			tv.Offs[ln] = off
-			lsz := gi.Max32(tv.Renders[ln].Size.Y, tv.LineHeight)
-			off += lsz
+			off += tv.LineSize(ln)
 		}
 		extraHalf := tv.LineHeight * 0.5 * float32(tv.VisSize.Y)
 		nwSz := gi.Vec2D{mxwd, off + extraHalf}.ToPointCeil()
@@ -619,13 +733,15 @@ func (tv *TextView) WrappedLineNo(pos TextPos) (si, ri int, ok bool) {
 	return tv.Renders[pos.Ln].RuneSpanPos(pos.Ch)
 }
 
-// SetCursor sets a new cursor position, enforcing it in range
+// SetCursor sets a new cursor position, enforcing it in range, and
+// unfolding any folded regions that hide it
 func (tv *TextView) SetCursor(pos TextPos) {
 	if tv.NLines == 0 || tv.Buf == nil {
 		tv.CursorPos = TextPosZero
 		return
 	}
 	tv.CursorPos = tv.Buf.ValidPos(pos)
+	tv.RevealLine(tv.CursorPos.Ln)
 	tv.CursorMovedSig()
 }
 
@@ -740,12 +856,12 @@ func (tv *TextView) CursorForward(steps int) {
 	org := tv.CursorPos
 	for i := 0; i < steps; i++ {
 		tv.CursorPos.Ch++
-		if tv.CursorPos.Ch > len(tv.Buf.Lines[tv.CursorPos.Ln]) {
-			if tv.CursorPos.Ln < tv.NLines-1 {
+		if tv.CursorPos.Ch > tv.Buf.LineLen(tv.CursorPos.Ln) {
+			if nln := tv.NextVisibleLine(tv.CursorPos.Ln); nln >= 0 {
 				tv.CursorPos.Ch = 0
-				tv.CursorPos.Ln++
+				tv.CursorPos.Ln = nln
 			} else {
-				tv.CursorPos.Ch = len(tv.Buf.Lines[tv.CursorPos.Ln])
+				tv.CursorPos.Ch = tv.Buf.LineLen(tv.CursorPos.Ln)
 			}
 		}
 	}
@@ -772,12 +888,12 @@ func (tv *TextView) CursorDown(steps int) {
 			}
 		}
 		if !gotwrap {
-			pos.Ln++
-			if pos.Ln >= tv.NLines {
-				pos.Ln = tv.NLines - 1
+			nln := tv.NextVisibleLine(pos.Ln) // skips folded lines
+			if nln < 0 {
 				break
 			}
-			mxlen := ints.MinInt(len(tv.Buf.Lines[pos.Ln]), tv.CursorCol)
+			pos.Ln = nln
+			mxlen := ints.MinInt(tv.Buf.LineLen(pos.Ln), tv.CursorCol)
 			if tv.CursorCol < mxlen {
 				pos.Ch = tv.CursorCol
 			} else {
@@ -802,7 +918,7 @@ func (tv *TextView) CursorPageDown(steps int) {
 		if tv.CursorPos.Ln >= tv.NLines {
 			tv.CursorPos.Ln = tv.NLines - 1
 		}
-		tv.CursorPos.Ch = ints.MinInt(len(tv.Buf.Lines[tv.CursorPos.Ln]), tv.CursorCol)
+		tv.CursorPos.Ch = ints.MinInt(tv.Buf.LineLen(tv.CursorPos.Ln), tv.CursorCol)
 		tv.ScrollCursorToTop()
 		tv.RenderCursor(true)
 	}
@@ -819,9 +935,9 @@ func (tv *TextView) CursorBackward(steps int) {
 	for i := 0; i < steps; i++ {
 		tv.CursorPos.Ch--
 		if tv.CursorPos.Ch < 0 {
-			if tv.CursorPos.Ln > 0 {
-				tv.CursorPos.Ln--
-				tv.CursorPos.Ch = len(tv.Buf.Lines[tv.CursorPos.Ln])
+			if pln := tv.PrevVisibleLine(tv.CursorPos.Ln); pln >= 0 {
+				tv.CursorPos.Ln = pln
+				tv.CursorPos.Ch = tv.Buf.LineLen(tv.CursorPos.Ln)
 			} else {
 				tv.CursorPos.Ch = 0
 			}
@@ -832,6 +948,52 @@ func (tv *TextView) CursorBackward(steps int) {
 	tv.CursorSelect(org)
 }
 
+// CursorRight moves the cursor visually to the right -- this is the same
+// as CursorForward except on lines with bidi (e.g., right-to-left) text,
+// where the cursor moves in visual order within each line
+func (tv *TextView) CursorRight(steps int) {
+	tv.CursorVisual(steps, 1)
+}
+
+// CursorLeft moves the cursor visually to the left -- this is the same
+// as CursorBackward except on lines with bidi (e.g., right-to-left) text,
+// where the cursor moves in visual order within each line
+func (tv *TextView) CursorLeft(steps int) {
+	tv.CursorVisual(steps, -1)
+}
+
+// CursorVisual moves the cursor visually right (dir > 0) or left (dir < 0)
+// -- at the visual edge of a line, it moves to the next or previous line
+// according to the direction of the line's text
+func (tv *TextView) CursorVisual(steps, dir int) {
+	for i := 0; i < steps; i++ {
+		tv.ValidateCursor()
+		ln := tv.CursorPos.Ln
+		fwd := dir > 0
+		if ln < len(tv.Renders) && tv.Renders[ln].HasBidi() {
+			rend := &tv.Renders[ln]
+			if nc, ok := rend.VisualMove(tv.CursorPos.Ch, dir); ok {
+				updt := tv.Viewport.Win.UpdateStart()
+				org := tv.CursorPos
+				tv.CursorPos.Ch = nc
+				tv.SetCursorCol(tv.CursorPos)
+				tv.SetCursorShow(tv.CursorPos)
+				tv.CursorSelect(org)
+				tv.Viewport.Win.UpdateEnd(updt)
+				continue
+			}
+			if rend.Dir == gi.RLTB {
+				fwd = !fwd
+			}
+		}
+		if fwd {
+			tv.CursorForward(1)
+		} else {
+			tv.CursorBackward(1)
+		}
+	}
+}
+
 // CursorUp moves the cursor up line(s)
 func (tv *TextView) CursorUp(steps int) {
 	updt := tv.Viewport.Win.UpdateStart()
@@ -852,18 +1014,18 @@ func (tv *TextView) CursorUp(steps int) {
 			}
 		}
 		if !gotwrap {
-			pos.Ln--
-			if pos.Ln < 0 {
-				pos.Ln = 0
+			pln := tv.PrevVisibleLine(pos.Ln) // skips folded lines
+			if pln < 0 {
 				break
 			}
+			pos.Ln = pln
 			if wln := tv.WrappedLines(pos.Ln); wln > 1 { // just entered end of wrapped line
 				si := wln - 1
 				ri := tv.CursorCol
 				nwc, _ := tv.Renders[pos.Ln].SpanPosToRuneIdx(si, ri)
 				pos.Ch = nwc
 			} else {
-				mxlen := ints.MinInt(len(tv.Buf.Lines[pos.Ln]), tv.CursorCol)
+				mxlen := ints.MinInt(tv.Buf.LineLen(pos.Ln), tv.CursorCol)
 				if tv.CursorCol < mxlen {
 					pos.Ch = tv.CursorCol
 				} else {
@@ -889,7 +1051,7 @@ func (tv *TextView) CursorPageUp(steps int) {
 		if tv.CursorPos.Ln <= 0 {
 			tv.CursorPos.Ln = 0
 		}
-		tv.CursorPos.Ch = ints.MinInt(len(tv.Buf.Lines[tv.CursorPos.Ln]), tv.CursorCol)
+		tv.CursorPos.Ch = ints.MinInt(tv.Buf.LineLen(tv.CursorPos.Ln), tv.CursorCol)
 		tv.ScrollCursorToBottom()
 		tv.RenderCursor(true)
 	}
@@ -985,7 +1147,7 @@ func (tv *TextView) CursorEndLine() {
 		gotwrap = true
 	}
 	if !gotwrap {
-		tv.CursorPos.Ch = len(tv.Buf.Lines[tv.CursorPos.Ln])
+		tv.CursorPos.Ch = tv.Buf.LineLen(tv.CursorPos.Ln)
 		tv.CursorCol = tv.CursorPos.Ch
 	}
 	tv.SetCursor(tv.CursorPos)
@@ -1002,7 +1164,7 @@ func (tv *TextView) CursorEndDoc() {
 	tv.ValidateCursor()
 	org := tv.CursorPos
 	tv.CursorPos.Ln = ints.MaxInt(tv.NLines-1, 0)
-	tv.CursorPos.Ch = len(tv.Buf.Lines[tv.CursorPos.Ln])
+	tv.CursorPos.Ch = tv.Buf.LineLen(tv.CursorPos.Ln)
 	tv.CursorCol = tv.CursorPos.Ch
 	tv.SetCursor(tv.CursorPos)
 	tv.ScrollCursorToBottom()
@@ -1019,6 +1181,10 @@ func (tv *TextView) CursorBackspace(steps int) {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
 	tv.ValidateCursor()
+	if tv.HasCursors() {
+		tv.CursorsBackspace(steps)
+		return
+	}
 	org := tv.CursorPos
 	if tv.HasSelection() {
 		tv.DeleteSelection()
@@ -1037,6 +1203,10 @@ func (tv *TextView) CursorDelete(steps int) {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
 	tv.ValidateCursor()
+	if tv.HasCursors() {
+		tv.CursorsDelete(steps)
+		return
+	}
 	if tv.HasSelection() {
 		tv.DeleteSelection()
 		return
@@ -1054,7 +1224,7 @@ func (tv *TextView) CursorKill() {
 	defer tv.Viewport.Win.UpdateEnd(updt)
 	tv.ValidateCursor()
 	org := tv.CursorPos
-	if tv.CursorPos.Ch == 0 && len(tv.Buf.Lines[tv.CursorPos.Ln]) == 0 {
+	if tv.CursorPos.Ch == 0 && tv.Buf.LineLen(tv.CursorPos.Ln) == 0 {
 		tv.CursorForward(1)
 	} else {
 		tv.CursorEndLine()
@@ -1120,7 +1290,7 @@ func (tv *TextView) FindPrevLink(pos TextPos) (TextPos, TextRegion, bool) {
 		if len(tv.Renders[ln].Links) == 0 {
 			pos.Ln = ln - 1
 			if ln-1 >= 0 {
-				pos.Ch = len(tv.Buf.Lines[ln-1]) - 2
+				pos.Ch = tv.Buf.LineLen(ln-1) - 2
 			}
 			continue
 		}
@@ -1139,7 +1309,7 @@ func (tv *TextView) FindPrevLink(pos TextPos) (TextPos, TextRegion, bool) {
 		}
 		pos.Ln = ln - 1
 		if ln-1 >= 0 {
-			pos.Ch = len(tv.Buf.Lines[ln-1]) - 2
+			pos.Ch = tv.Buf.LineLen(ln-1) - 2
 		}
 	}
 	return pos, TextRegion{}, false
@@ -1206,6 +1376,7 @@ func (tv *TextView) CursorPrevLink(wraparound bool) bool {
 func (tv *TextView) Undo() {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
+	tv.ClearCursors()
 	tbe := tv.Buf.Undo()
 	if tbe != nil {
 		if tbe.Delete { // now an insert
@@ -1223,6 +1394,7 @@ func (tv *TextView) Undo() {
 func (tv *TextView) Redo() {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
+	tv.ClearCursors()
 	tbe := tv.Buf.Redo()
 	if tbe != nil {
 		if tbe.Delete {
@@ -1431,6 +1603,7 @@ func (tv *TextView) EscPressed() {
 	case tv.ISearchMode:
 		tv.ISearchCancel()
 		tv.SetCursorShow(tv.ISearchStartPos)
+	case tv.ClearCursors():
 	case tv.HasSelection():
 		tv.SelectReset()
 	}
@@ -1563,10 +1736,15 @@ func (tv *TextView) RenderSelectLines() {
 ///////////////////////////////////////////////////////////////////////////////
 //    Cut / Copy / Paste
 
-// Cut cuts any selected text and adds it to the clipboard, also returns cut text
+// Cut cuts any selected text and adds it to the clipboard, also returns cut
+// text -- with multiple cursors, see CursorsCut
 func (tv *TextView) Cut() *TextBufEdit {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
+	if tv.HasCursors() {
+		tv.CursorsCut()
+		return nil
+	}
 	org := tv.SelectReg.Start
 	cut := tv.DeleteSelection()
 	if cut != nil {
@@ -1586,10 +1764,15 @@ func (tv *TextView) DeleteSelection() *TextBufEdit {
 }
 
 // Copy copies any selected text to the clipboard, and returns that text,
-// optionaly resetting the current selection
+// optionaly resetting the current selection -- with multiple cursors, see
+// CursorsCopy
 func (tv *TextView) Copy(reset bool) *TextBufEdit {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
+	if tv.HasCursors() {
+		tv.CursorsCopy()
+		return nil
+	}
 	tbe := tv.Selection()
 	if tbe == nil {
 		return nil
@@ -1608,7 +1791,13 @@ func (tv *TextView) Paste() {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
 	data := oswin.TheApp.ClipBoard(tv.Viewport.Win.OSWin).Read([]string{mimedata.TextPlain})
+	if data != nil && tv.HasCursors() {
+		tv.CursorsPaste(data.TypeData(mimedata.TextPlain))
+		return
+	}
 	if data != nil {
+		tv.Buf.UndoGroupStart()
+		defer tv.Buf.UndoGroupEnd()
 		if tv.SelectReg.Start.IsLess(tv.CursorPos) && tv.CursorPos.IsLess(tv.SelectReg.End) {
 			tv.DeleteSelection()
 		}
@@ -1617,11 +1806,18 @@ func (tv *TextView) Paste() {
 	}
 }
 
-// InsertAtCursor inserts given text at current cursor position
+// InsertAtCursor inserts given text at current cursor position, and at any
+// additional Cursors
 func (tv *TextView) InsertAtCursor(txt []byte) {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
+	if tv.HasCursors() {
+		tv.CursorsInsert(txt)
+		return
+	}
 	if tv.HasSelection() {
+		tv.Buf.UndoGroupStart()
+		defer tv.Buf.UndoGroupEnd()
 		tv.Cut()
 	}
 	tbe := tv.Buf.InsertText(tv.CursorPos, txt, true, true)
@@ -1707,7 +1903,7 @@ func (tv *TextView) MakeContextMenu(m *gi.Menu) {
 //	}
 //
 //	tpos := token.Position{} // text position
-//	count := tv.Buf.ByteOffs[tv.CursorPos.Ln] + tv.CursorPos.Ch
+//	count := tv.Buf.ByteOffset(tv.CursorPos)
 //	tpos.Line = tv.CursorPos.Ln
 //	tpos.Column = tv.CursorPos.Ch
 //	tpos.Offset = count
@@ -1744,7 +1940,7 @@ func (tv *TextView) OfferComplete(forcecomplete bool) {
 	}
 
 	tpos := token.Position{} // text position
-	count := tv.Buf.ByteOffs[tv.CursorPos.Ln] + tv.CursorPos.Ch
+	count := tv.Buf.ByteOffset(tv.CursorPos)
 	tpos.Line = tv.CursorPos.Ln
 	tpos.Column = tv.CursorPos.Ch
 	tpos.Offset = count
@@ -2038,6 +2234,20 @@ func (tv *TextView) CharEndPos(pos TextPos) gi.Vec2D {
 	return spos
 }
 
+// CharCaretPos returns the render coords of the text caret (cursor) for the
+// given position -- the same as CharStartPos except for bidi text, where the
+// caret before a right-to-left char is at its right edge
+func (tv *TextView) CharCaretPos(pos TextPos) gi.Vec2D {
+	spos := tv.CharStartPos(pos)
+	if pos.Ln < 0 || pos.Ln >= len(tv.Renders) || !tv.Renders[pos.Ln].HasBidi() {
+		return spos
+	}
+	rrp, _, _, _ := tv.Renders[pos.Ln].RuneRelPos(pos.Ch)
+	crp, _, _, _ := tv.Renders[pos.Ln].RuneCaretPos(pos.Ch)
+	spos.X += crp.X - rrp.X
+	return spos
+}
+
 // TextViewBlinker is the time.Ticker for blinking cursors for text fields,
 // only one of which can be active at at a time
 var TextViewBlinker *time.Ticker
@@ -2133,7 +2343,20 @@ func (tv *TextView) RenderCursor(on bool) {
 		} else {
 			win.InactivateSprite(sp.Nm)
 		}
-		sp.Geom.Pos = tv.CharStartPos(tv.CursorPos).ToPointFloor()
+		sp.Geom.Pos = tv.CharCaretPos(tv.CursorPos).ToPointFloor()
+		for i := range tv.Cursors {
+			csp := tv.CursorSpriteIdx(i + 1)
+			if on {
+				win.ActivateSprite(csp.Nm)
+			} else {
+				win.InactivateSprite(csp.Nm)
+			}
+			csp.Geom.Pos = tv.CharCaretPos(tv.Cursors[i].Pos).ToPointFloor()
+		}
+		for i := len(tv.Cursors); i < tv.nCursorSprites; i++ {
+			win.InactivateSprite(tv.CursorSpriteIdx(i + 1).Nm)
+		}
+		tv.nCursorSprites = len(tv.Cursors)
 		win.RenderOverlays() // needs an explicit call!
 		win.UpdateSig()      // publish
 	}
@@ -2143,12 +2366,21 @@ func (tv *TextView) RenderCursor(on bool) {
 // only rendered once with a vertical bar, and just activated and inactivated
 // depending on render status)
 func (tv *TextView) CursorSprite() *gi.Viewport2D {
+	return tv.CursorSpriteIdx(0)
+}
+
+// CursorSpriteIdx returns the sprite Viewport2D for the cursor of given
+// index: 0 is the main cursor, and others are for the additional Cursors
+func (tv *TextView) CursorSpriteIdx(idx int) *gi.Viewport2D {
 	win := tv.Viewport.Win
 	if win == nil {
 		return nil
 	}
 	sty := &tv.StateStyles[TextViewActive]
 	spnm := fmt.Sprintf("%v-%v", TextViewSpriteName, tv.FontHeight)
+	if idx > 0 {
+		spnm += fmt.Sprintf("-%v", idx)
+	}
 	sp, ok := win.Sprites[spnm]
 	if !ok {
 		bbsz := image.Point{int(math32.Ceil(tv.CursorWidth.Dots)), int(math32.Ceil(tv.FontHeight))}
@@ -2164,6 +2396,12 @@ func (tv *TextView) CursorSprite() *gi.Viewport2D {
 // RenderSelect renders the selection region as a selected background color
 // -- always called within context of outer RenderLines or RenderAllLines
 func (tv *TextView) RenderSelect() {
+	for i := range tv.Cursors {
+		tc := &tv.Cursors[i]
+		if tc.HasSelection() {
+			tv.RenderRegionBox(tc.Region(), TextViewSel)
+		}
+	}
 	if !tv.HasSelection() {
 		return
 	}
@@ -2182,6 +2420,51 @@ func (tv *TextView) RenderHighlights(stln, edln int) {
 	}
 }
 
+// SetLineColor sets the background color for given line, which is
+// rendered across the full width of the line -- call RenderLines to update
+func (tv *TextView) SetLineColor(ln int, clr gi.Color) {
+	if tv.LineColors == nil {
+		tv.LineColors = make(map[int]gi.Color)
+	}
+	tv.LineColors[ln] = clr
+}
+
+// DeleteLineColor deletes the background color for given line, or all of
+// them if ln < 0
+func (tv *TextView) DeleteLineColor(ln int) {
+	if ln < 0 {
+		tv.LineColors = nil
+		return
+	}
+	delete(tv.LineColors, ln)
+}
+
+// RenderLineColors renders the LineColors backgrounds of lines in range
+// stln..edln (inclusive), or all if stln < 0 -- always called within context
+// of outer RenderLines or RenderAllLines
+func (tv *TextView) RenderLineColors(stln, edln int) {
+	if len(tv.LineColors) == 0 {
+		return
+	}
+	rs := &tv.Viewport.Render
+	pc := &rs.Paint
+	spc := tv.Sty.BoxSpace()
+	sx := tv.RenderStartPos().X + tv.LineNoOff
+	ex := float32(tv.VpBBox.Max.X) - spc
+	for ln, clr := range tv.LineColors {
+		if ln >= tv.NLines || (stln >= 0 && (ln < stln || ln > edln)) || tv.IsLineHidden(ln) {
+			continue
+		}
+		spos := tv.CharStartPos(TextPos{Ln: ln})
+		sz := gi.Vec2D{X: ex - sx, Y: math32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)}
+		if int(math32.Ceil(spos.Y+sz.Y)) < tv.VpBBox.Min.Y || int(math32.Floor(spos.Y)) > tv.VpBBox.Max.Y {
+			continue
+		}
+		spos.X = sx
+		pc.FillBoxColor(rs, spos, sz, clr)
+	}
+}
+
 // UpdateHighlights re-renders lines from previous highlights and current
 // highlights -- assumed to be within a window update block
 func (tv *TextView) UpdateHighlights(prev []TextRegion) {
@@ -2195,6 +2478,10 @@ func (tv *TextView) UpdateHighlights(prev []TextRegion) {
 
 // RenderRegionBox renders a region in background color according to given state style
 func (tv *TextView) RenderRegionBox(reg TextRegion, state TextViewStates) {
+	reg, ok := tv.VisibleRegion(reg)
+	if !ok {
+		return
+	}
 	st := reg.Start
 	ed := reg.End
 	spos := tv.CharStartPos(st)
@@ -2269,7 +2556,7 @@ func (tv *TextView) VisSizes() {
 	}
 	tv.LineNoDigs = ints.MaxInt(1+int(math32.Log10(float32(tv.NLines))), 3)
 	if tv.Opts.LineNos {
-		tv.LineNoOff = float32(tv.LineNoDigs+3)*sty.Font.Ch + spc // space for icon
+		tv.LineNoOff = float32(tv.LineNoDigs+4)*sty.Font.Ch + spc // space for icon and fold marker
 	} else {
 		tv.LineNoOff = 0
 	}
@@ -2308,10 +2595,14 @@ func (tv *TextView) RenderAllLinesInBounds() {
 		tv.RenderStdBox(sty)
 	}
 	tv.RenderLineNosBoxAll()
-	tv.RenderHighlights(-1, -1) // all
+	tv.RenderLineColors(-1, -1) // all
+	tv.RenderHighlights(-1, -1)
 	tv.RenderSelect()
 	pos := tv.RenderStartPos()
 	for ln := 0; ln < tv.NLines; ln++ {
+		if tv.IsLineHidden(ln) {
+			continue
+		}
 		lst := pos.Y + tv.Offs[ln]
 		led := lst + math32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
 		if int(math32.Ceil(led)) < tv.VpBBox.Min.Y {
@@ -2326,6 +2617,7 @@ func (tv *TextView) RenderAllLinesInBounds() {
 		tv.Renders[ln].Render(rs, lp) // not top pos -- already has baseline offset
 		tv.RenderLineNo(ln)
 	}
+	tv.RenderMarkers(-1, -1)
 }
 
 // RenderLineNosBoxAll renders the background for the line numbers in a darker shade
@@ -2381,6 +2673,8 @@ func (tv *TextView) RenderLineNo(ln int) {
 	lst := tv.CharStartPos(TextPos{Ln: ln}).Y // note: charstart pos includes descent
 	pos.Y = lst + gi.FixedToFloat32(sty.Font.Face.Metrics().Ascent) - +gi.FixedToFloat32(sty.Font.Face.Metrics().Descent)
 	tv.LineNoRender.Render(rs, pos)
+	tv.RenderLineMarkers(ln)
+	tv.RenderFoldMarker(ln)
 	// if ic, ok := tv.LineIcons[ln]; ok {
 	// 	// todo: render icon!
 	// }
@@ -2411,6 +2705,9 @@ func (tv *TextView) RenderLines(st, ed int) bool {
 		visSt := -1
 		visEd := -1
 		for ln := st; ln <= ed; ln++ {
+			if tv.IsLineHidden(ln) {
+				continue
+			}
 			lst := tv.CharStartPos(TextPos{Ln: ln}).Y // note: charstart pos includes descent
 			led := lst + math32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
 			if int(math32.Ceil(led)) < tv.VpBBox.Min.Y {
@@ -2435,11 +2732,15 @@ func (tv *TextView) RenderLines(st, ed int) bool {
 			pc.FillBox(rs, boxMin, boxMax.Sub(boxMin), &sty.Font.BgColor)
 			// fmt.Printf("lns: st: %v ed: %v vis st: %v ed %v box: min %v max: %v\n", st, ed, visSt, visEd, boxMin, boxMax)
 
+			tv.RenderLineColors(visSt, visEd)
 			tv.RenderHighlights(visSt, visEd)
 			tv.RenderSelect()
 			tv.RenderLineNosBox(visSt, visEd)
 
 			for ln := visSt; ln <= visEd; ln++ {
+				if tv.IsLineHidden(ln) {
+					continue
+				}
 				lst := pos.Y + tv.Offs[ln]
 				lp := pos
 				lp.Y = lst
@@ -2447,6 +2748,7 @@ func (tv *TextView) RenderLines(st, ed int) bool {
 				tv.Renders[ln].Render(rs, lp) // not top pos -- already has baseline offset
 				tv.RenderLineNo(ln)
 			}
+			tv.RenderMarkers(visSt, visEd)
 
 			tBBox := image.Rectangle{boxMin.ToPointFloor(), boxMax.ToPointCeil()}
 			vprel := tBBox.Min.Sub(tv.VpBBox.Min)
@@ -2474,6 +2776,9 @@ func (tv *TextView) FirstVisibleLine(stln int) int {
 			stln = 0
 		}
 		for ln := stln; ln < tv.NLines; ln++ {
+			if tv.IsLineHidden(ln) {
+				continue
+			}
 			cpos := tv.CharStartPos(TextPos{Ln: ln})
 			if int(math32.Floor(cpos.Y)) >= tv.VpBBox.Min.Y { // top definitely on screen
 				stln = ln
@@ -2483,6 +2788,9 @@ func (tv *TextView) FirstVisibleLine(stln int) int {
 	}
 	lastln := stln
 	for ln := stln - 1; ln >= 0; ln-- {
+		if tv.IsLineHidden(ln) {
+			continue
+		}
 		cpos := tv.CharStartPos(TextPos{Ln: ln})
 		if int(math32.Ceil(cpos.Y)) < tv.VpBBox.Min.Y { // top just offscreen
 			break
@@ -2497,6 +2805,9 @@ func (tv *TextView) FirstVisibleLine(stln int) int {
 func (tv *TextView) LastVisibleLine(stln int) int {
 	lastln := stln
 	for ln := stln + 1; ln < tv.NLines; ln++ {
+		if tv.IsLineHidden(ln) {
+			continue
+		}
 		pos := TextPos{Ln: ln}
 		cpos := tv.CharStartPos(pos)
 		if int(math32.Floor(cpos.Y)) > tv.VpBBox.Max.Y { // just offscreen
@@ -2526,6 +2837,9 @@ func (tv *TextView) PixelToCursor(pt image.Point) TextPos {
 	} else {
 		got := false
 		for ln := stln; ln < tv.NLines; ln++ {
+			if tv.IsLineHidden(ln) {
+				continue
+			}
 			ls := tv.CharStartPos(TextPos{Ln: ln}).Y - yoff
 			es := ls
 			es += math32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
@@ -2539,8 +2853,11 @@ func (tv *TextView) PixelToCursor(pt image.Point) TextPos {
 			cln = tv.NLines - 1
 		}
 	}
+	if tv.IsLineHidden(cln) {
+		cln = tv.PrevVisibleLine(cln)
+	}
 	// fmt.Printf("cln: %v  pt: %v\n", cln, pt)
-	lnsz := len(tv.Buf.Lines[cln])
+	lnsz := tv.Buf.LineLen(cln)
 	if lnsz == 0 {
 		return TextPos{Ln: cln, Ch: 0}
 	}
@@ -2569,6 +2886,11 @@ func (tv *TextView) PixelToCursor(pt image.Point) TextPos {
 	if rsz == 0 {
 		return TextPos{Ln: cln, Ch: spoff}
 	}
+	if sr := &tv.Renders[cln].Spans[si]; sr.Visual { // positions not in logical order
+		spos := tv.RenderStartPos()
+		lx := float32(pt.X) + xoff - spos.X - tv.LineNoOff - sr.RelPos.X
+		return TextPos{Ln: cln, Ch: spoff + sr.CaretIdxAtLR(lx)}
+	}
 	// fmt.Printf("sc: %v  rsz: %v\n", sc, rsz)
 
 	c, _ := tv.Renders[cln].SpanPosToRuneIdx(si, rsz-1) // end
@@ -2714,24 +3036,28 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 		tv.ISearchCancel() // note: may need to generalize to cancel more stuff
 		kt.SetProcessed()
 		tv.ShiftSelect(kt)
-		tv.CursorForward(1)
+		tv.CursorRight(1)
+		tv.MoveCursors(kf, tv.SelectMode)
 		tv.OfferComplete(dontforce)
 	case gi.KeyFunMoveLeft:
 		tv.ISearchCancel()
 		kt.SetProcessed()
 		tv.ShiftSelect(kt)
-		tv.CursorBackward(1)
+		tv.CursorLeft(1)
+		tv.MoveCursors(kf, tv.SelectMode)
 		tv.OfferComplete(dontforce)
 	case gi.KeyFunMoveUp:
 		tv.ISearchCancel()
 		kt.SetProcessed()
 		tv.ShiftSelect(kt)
 		tv.CursorUp(1)
+		tv.MoveCursors(kf, tv.SelectMode)
 	case gi.KeyFunMoveDown:
 		tv.ISearchCancel()
 		kt.SetProcessed()
 		tv.ShiftSelect(kt)
 		tv.CursorDown(1)
+		tv.MoveCursors(kf, tv.SelectMode)
 	case gi.KeyFunPageUp:
 		cancelAll()
 		kt.SetProcessed()
@@ -2747,11 +3073,13 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 		kt.SetProcessed()
 		tv.ShiftSelect(kt)
 		tv.CursorStartLine()
+		tv.MoveCursors(kf, tv.SelectMode)
 	case gi.KeyFunEnd:
 		tv.ISearchCancel()
 		kt.SetProcessed()
 		tv.ShiftSelect(kt)
 		tv.CursorEndLine()
+		tv.MoveCursors(kf, tv.SelectMode)
 	case gi.KeyFunDocHome:
 		cancelAll()
 		kt.SetProcessed()
@@ -2798,6 +3126,14 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 	case gi.KeyFunHistNext:
 		kt.SetProcessed()
 		tv.CursorToHistNext()
+	case gi.KeyFunCursorAddNext:
+		cancelAll()
+		kt.SetProcessed()
+		tv.CursorAddNextMatch()
+	case gi.KeyFunCursorSplitLines:
+		cancelAll()
+		kt.SetProcessed()
+		tv.CursorSplitLines()
 	}
 	if tv.IsInactive() {
 		switch {
@@ -2861,11 +3197,32 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 		tv.ISearchCancel()
 		kt.SetProcessed()
 		tv.OfferComplete(force)
+	case gi.KeyFunDefinition:
+		tv.ISearchCancel()
+		kt.SetProcessed()
+		tv.LSPDefinition()
+	case gi.KeyFunNextDiag, gi.KeyFunPrevDiag:
+		tv.ISearchCancel()
+		kt.SetProcessed()
+		tv.NextDiag(kf == gi.KeyFunNextDiag)
+	case gi.KeyFunFoldToggle:
+		tv.ISearchCancel()
+		kt.SetProcessed()
+		tv.ToggleFold(tv.CursorPos.Ln)
+	case gi.KeyFunFoldAll:
+		tv.ISearchCancel()
+		kt.SetProcessed()
+		tv.FoldAll()
+	case gi.KeyFunUnfoldAll:
+		tv.ISearchCancel()
+		kt.SetProcessed()
+		tv.UnfoldAll()
 	case gi.KeyFunEnter:
 		tv.ISearchCancel()
 		if !kt.HasAnyModifier(key.Control, key.Meta) {
 			kt.SetProcessed()
 			updt := tv.Viewport.Win.UpdateStart()
+			tv.Buf.UndoGroupStart()
 			tv.InsertAtCursor([]byte("\n"))
 			if tv.Opts.AutoIndent {
 				tbe, _, _ := tv.Buf.AutoIndent(tv.CursorPos.Ln, tv.Opts.SpaceIndent, tv.Sty.Text.TabSize, DefaultIndentStrings, DefaultUnindentStrings)
@@ -2873,6 +3230,7 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 					tv.SetCursorShow(tbe.Reg.End)
 				}
 			}
+			tv.Buf.UndoGroupEnd()
 			tv.Viewport.Win.UpdateEnd(updt)
 		}
 		// todo: KeFunFocusPrev -- unindent
@@ -2993,9 +3351,18 @@ func (tv *TextView) MouseEvent(me *mouse.Event) {
 	case mouse.Left:
 		if me.Action == mouse.Press {
 			me.SetProcessed()
-			if _, got := tv.OpenLinkAt(newPos); got {
-			} else {
-				tv.SetCursorFromMouse(pt, newPos, me.SelectMode())
+			switch {
+			case tv.FoldMarkerAt(pt, newPos.Ln) && tv.ToggleFold(newPos.Ln):
+			case me.HasAllModifier(key.Shift, key.Alt):
+				tv.StartBoxSelect(newPos)
+			case me.HasAnyModifier(key.Alt):
+				tv.AddCursor(newPos)
+			default:
+				tv.ClearCursors()
+				if _, got := tv.OpenLinkAt(newPos); got {
+				} else {
+					tv.SetCursorFromMouse(pt, newPos, me.SelectMode())
+				}
 			}
 		} else if me.Action == mouse.DoubleClick {
 			me.SetProcessed()
@@ -3025,16 +3392,21 @@ func (tv *TextView) MouseEvent(me *mouse.Event) {
 }
 
 func (tv *TextView) TextViewEvents() {
-	tv.HoverTooltipEvent()
+	tv.HoverEvent()
 	tv.ConnectEvent(oswin.MouseDragEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
 		me := d.(*mouse.DragEvent)
 		me.SetProcessed()
 		txf := recv.Embed(KiT_TextView).(*TextView)
+		pt := txf.PointToRelPos(me.Pos())
+		newPos := txf.PixelToCursor(pt)
+		if txf.BoxSelect {
+			txf.SetBoxSelect(txf.SelectStart, newPos)
+			txf.AutoScroll(me.Pos())
+			return
+		}
 		if !txf.SelectMode {
 			txf.SelectModeToggle()
 		}
-		pt := txf.PointToRelPos(me.Pos())
-		newPos := txf.PixelToCursor(pt)
 		txf.SetCursorFromMouse(pt, newPos, mouse.NoSelectMode)
 	})
 	tv.ConnectEvent(oswin.MouseEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
@@ -3182,3 +3554,17 @@ func (tv *TextView) FocusChanged2D(change gi.FocusChanges) {
 		// todo: see about cursor
 	}
 }
+
+// AccessInfo returns the accessibility info for the text view -- the value
+// is the full text of the buffer
+func (tv *TextView) AccessInfo() gi.AccessInfo {
+	ai := tv.WidgetBase.AccessInfo()
+	ai.Role = gi.RoleTextArea
+	ai.Name = tv.Placeholder
+	if tv.Buf != nil {
+		ai.Value = string(tv.Buf.Text())
+	}
+	ai.SetState(true, gi.AccessMultiLine)
+	ai.SetState(!tv.IsInactive(), gi.AccessEditable)
+	return ai
+}
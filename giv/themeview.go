@@ -0,0 +1,82 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"reflect"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  ThemeValueView
+
+// ThemeValueView presents an action for displaying a ThemeName and selecting
+// from the list of available themes
+type ThemeValueView struct {
+	ValueViewBase
+}
+
+var KiT_ThemeValueView = kit.Types.AddType(&ThemeValueView{}, nil)
+
+func (vv *ThemeValueView) WidgetType() reflect.Type {
+	vv.WidgetTyp = gi.KiT_Action
+	return vv.WidgetTyp
+}
+
+func (vv *ThemeValueView) UpdateWidget() {
+	if vv.Widget == nil {
+		return
+	}
+	ac := vv.Widget.(*gi.Action)
+	txt := kit.ToString(vv.Value.Interface())
+	if txt == "" {
+		txt = "(none)"
+	}
+	ac.SetFullReRender()
+	ac.SetText(txt)
+}
+
+func (vv *ThemeValueView) ConfigWidget(widg gi.Node2D) {
+	vv.Widget = widg
+	ac := vv.Widget.(*gi.Action)
+	ac.SetProp("border-radius", units.NewValue(4, units.Px))
+	ac.ActionSig.ConnectOnly(vv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		vvv, _ := recv.Embed(KiT_ThemeValueView).(*ThemeValueView)
+		ac := vvv.Widget.(*gi.Action)
+		vvv.Activate(ac.Viewport, nil, nil)
+	})
+	vv.UpdateWidget()
+}
+
+func (vv *ThemeValueView) HasAction() bool {
+	return true
+}
+
+func (vv *ThemeValueView) Activate(vp *gi.Viewport2D, dlgRecv ki.Ki, dlgFunc ki.RecvFunc) {
+	if vv.IsInactive() {
+		return
+	}
+	cur := kit.ToString(vv.Value.Interface())
+	nms := gi.AvailThemes.Names()
+	desc, _ := vv.Tag("desc")
+	SliceViewSelectDialog(vp, &nms, cur, DlgOpts{Title: "Select a Theme", Prompt: desc}, nil,
+		vv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig == int64(gi.DialogAccepted) {
+				ddlg, _ := send.(*gi.Dialog)
+				si := SliceViewSelectDialogValue(ddlg)
+				if si >= 0 && si < len(nms) {
+					vv.SetValue(nms[si])
+					vv.UpdateWidget()
+				}
+			}
+			if dlgRecv != nil && dlgFunc != nil {
+				dlgFunc(dlgRecv, send, sig, data)
+			}
+		})
+}
@@ -1615,6 +1615,7 @@ func (tv *TreeView) StyleTreeView() {
 	for i := 0; i < int(TreeViewStatesN); i++ {
 		tv.StateStyles[i].CopyFrom(&tv.Sty)
 		tv.StateStyles[i].SetStyleProps(pst, tv.StyleProps(TreeViewSelectors[i]))
+		tv.StateStyles[i].StyleCSS(tv.This.(gi.Node2D), tv.CSSAgg, TreeViewSelectors[i])
 		tv.StateStyles[i].CopyUnitContext(&tv.Sty.UnContext)
 	}
 	tv.Indent.SetFmInheritProp("indent", tv.This, false, true) // no inherit, yes type defaults
@@ -1776,3 +1777,45 @@ func (tv *TreeView) FocusChanged2D(change gi.FocusChanges) {
 	case gi.FocusActive:
 	}
 }
+
+// AccessInfo returns the accessibility info for the tree view node, which is
+// a tree item named by its Label -- nodes under a closed parent are
+// invisible
+func (tv *TreeView) AccessInfo() gi.AccessInfo {
+	ai := tv.WidgetBase.AccessInfo()
+	ai.Role = gi.RoleTreeItem
+	if tv.SrcNode.Ptr != nil {
+		ai.Name = tv.Label()
+	}
+	ai.Bounds = tv.WinBBox
+	ai.SetState(true, gi.AccessSelectable)
+	ai.SetState(tv.HasClosedParent(), gi.AccessInvisible)
+	if tv.HasChildren() {
+		ai.SetState(true, gi.AccessExpandable)
+		ai.SetState(!tv.IsClosed(), gi.AccessExpanded)
+		if tv.IsClosed() {
+			ai.AddAction(gi.AccessExpand)
+		} else {
+			ai.AddAction(gi.AccessCollapse)
+		}
+	}
+	ai.AddAction(gi.AccessSelect)
+	return ai
+}
+
+// AccessAction performs accessibility actions for the tree view node:
+// expand (open), collapse (close), and select
+func (tv *TreeView) AccessAction(act gi.AccessActions, val string) bool {
+	switch act {
+	case gi.AccessExpand:
+		tv.Open()
+		return true
+	case gi.AccessCollapse:
+		tv.Close()
+		return true
+	case gi.AccessSelect:
+		tv.SelectAction(mouse.NoSelectMode)
+		return true
+	}
+	return tv.AccessActionBase(act, val)
+}
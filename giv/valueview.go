@@ -115,6 +115,11 @@ func ToValueView(it interface{}) ValueView {
 		vv.Init(&vv)
 		return &vv
 	}
+	if nptyp == reflect.TypeOf(gi.ThemeName("")) {
+		vv := ThemeValueView{}
+		vv.Init(&vv)
+		return &vv
+	}
 	if nptyp == reflect.TypeOf(key.Chord("")) {
 		vv := KeyChordValueView{}
 		vv.Init(&vv)
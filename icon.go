@@ -5,6 +5,7 @@
 package gi
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 
@@ -32,8 +33,12 @@ func (inm IconName) IsNil() bool {
 }
 
 // IsValid tests whether the icon name is valid -- represents a non-nil icon
-// available in the current or default icon set
+// available in the current or default icon set -- false if the svg package
+// (which provides the icons) is not loaded
 func (inm IconName) IsValid() bool {
+	if TheIconMgr == nil {
+		return false
+	}
 	return TheIconMgr.IsValid(string(inm))
 }
 
@@ -65,6 +70,9 @@ func (ic *Icon) SetIcon(name string) (bool, error) {
 	if ic.HasChildren() && ic.UniqueNm == name {
 		return false, nil
 	}
+	if TheIconMgr == nil {
+		return false, fmt.Errorf("gi.Icon SetIcon: no icon manager set -- import the svg package to provide icons")
+	}
 	err := TheIconMgr.SetIcon(ic, name)
 	if err == nil {
 		ic.UniqueNm = string(name)
@@ -100,6 +108,13 @@ func (ic *Icon) Style2D() {
 	if sic != nil {
 		sic.Nm = ic.Nm
 		sic.Props = ic.Props
+		if tint := ThemeIconTint(); tint != nil {
+			sic.Props = make(ki.Props, len(ic.Props)+1)
+			for k, v := range ic.Props {
+				sic.Props[k] = v
+			}
+			sic.Props["fill"] = tint
+		}
 		sic.CSS = ic.CSS
 		sic.Sty = ic.Sty
 		sic.DefStyle = ic.DefStyle
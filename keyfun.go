@@ -77,6 +77,14 @@ const (
 	KeyFunJump   // jump to line
 	KeyFunHistPrev
 	KeyFunHistNext
+	KeyFunCursorAddNext    // add a cursor at next occurrence of selection
+	KeyFunCursorSplitLines // split selection into a cursor on each line
+	KeyFunDefinition       // go to definition of symbol at cursor
+	KeyFunNextDiag         // go to next diagnostic, e.g., error
+	KeyFunPrevDiag         // go to previous diagnostic
+	KeyFunFoldToggle       // fold or unfold the region at the cursor
+	KeyFunFoldAll          // fold all regions
+	KeyFunUnfoldAll        // unfold all regions
 	KeyFunsN
 )
 
@@ -540,6 +548,14 @@ var StdKeyMaps = KeyMaps{
 		"Meta+]":                  KeyFunHistNext,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
+		"Meta+D":                  KeyFunCursorAddNext,
+		"Shift+Meta+L":            KeyFunCursorSplitLines,
+		"F12":                     KeyFunDefinition,
+		"F8":                      KeyFunNextDiag,
+		"Shift+F8":                KeyFunPrevDiag,
+		"Control+Alt+[":           KeyFunFoldToggle,
+		"Control+Alt+-":           KeyFunFoldAll,
+		"Control+Alt+=":           KeyFunUnfoldAll,
 	}},
 	{"MacEmacs", "Mac with emacs-style navigation -- emacs wins in conflicts", KeyMap{
 		"UpArrow":                 KeyFunMoveUp,
@@ -640,6 +656,14 @@ var StdKeyMaps = KeyMaps{
 		"Meta+]":                  KeyFunHistNext,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
+		"Meta+D":                  KeyFunCursorAddNext,
+		"Shift+Meta+L":            KeyFunCursorSplitLines,
+		"F12":                     KeyFunDefinition,
+		"F8":                      KeyFunNextDiag,
+		"Shift+F8":                KeyFunPrevDiag,
+		"Control+Alt+[":           KeyFunFoldToggle,
+		"Control+Alt+-":           KeyFunFoldAll,
+		"Control+Alt+=":           KeyFunUnfoldAll,
 	}},
 	{"LinuxStd", "Standard Linux KeyMap", KeyMap{
 		"UpArrow": KeyFunMoveUp,
@@ -702,6 +726,14 @@ var StdKeyMaps = KeyMaps{
 		"Control+J":       KeyFunJump,
 		"Control+[":       KeyFunHistPrev,
 		"Control+]":       KeyFunHistNext,
+		"Shift+Control+D": KeyFunCursorAddNext,
+		"Shift+Control+L": KeyFunCursorSplitLines,
+		"F12":             KeyFunDefinition,
+		"F8":              KeyFunNextDiag,
+		"Shift+F8":        KeyFunPrevDiag,
+		"Control+Alt+[":   KeyFunFoldToggle,
+		"Control+Alt+-":   KeyFunFoldAll,
+		"Control+Alt+=":   KeyFunUnfoldAll,
 	}},
 	{"LinuxEmacs", "Linux with emacs-style navigation -- emacs wins in conflicts", KeyMap{
 		"UpArrow":            KeyFunMoveUp,
@@ -791,6 +823,14 @@ var StdKeyMaps = KeyMaps{
 		"Control+J":               KeyFunJump,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
+		"Shift+Control+D":         KeyFunCursorAddNext,
+		"Shift+Control+L":         KeyFunCursorSplitLines,
+		"F12":                     KeyFunDefinition,
+		"F8":                      KeyFunNextDiag,
+		"Shift+F8":                KeyFunPrevDiag,
+		"Control+Alt+[":           KeyFunFoldToggle,
+		"Control+Alt+-":           KeyFunFoldAll,
+		"Control+Alt+=":           KeyFunUnfoldAll,
 	}},
 	{"WindowsStd", "Standard Windows KeyMap", KeyMap{
 		"UpArrow": KeyFunMoveUp,
@@ -849,6 +889,14 @@ var StdKeyMaps = KeyMaps{
 		"Control+.":       KeyFunComplete,
 		"Control+[":       KeyFunHistPrev,
 		"Control+]":       KeyFunHistNext,
+		"Shift+Control+D": KeyFunCursorAddNext,
+		"Shift+Control+L": KeyFunCursorSplitLines,
+		"F12":             KeyFunDefinition,
+		"F8":              KeyFunNextDiag,
+		"Shift+F8":        KeyFunPrevDiag,
+		"Control+Alt+[":   KeyFunFoldToggle,
+		"Control+Alt+-":   KeyFunFoldAll,
+		"Control+Alt+=":   KeyFunUnfoldAll,
 	}},
 	{"ChromeStd", "Standard chrome-browser and linux-under-chrome bindings", KeyMap{
 		"UpArrow": KeyFunMoveUp,
@@ -909,5 +957,13 @@ var StdKeyMaps = KeyMaps{
 		"Control+J":       KeyFunJump,
 		"Control+[":       KeyFunHistPrev,
 		"Control+]":       KeyFunHistNext,
+		"Shift+Control+D": KeyFunCursorAddNext,
+		"Shift+Control+L": KeyFunCursorSplitLines,
+		"F12":             KeyFunDefinition,
+		"F8":              KeyFunNextDiag,
+		"Shift+F8":        KeyFunPrevDiag,
+		"Control+Alt+[":   KeyFunFoldToggle,
+		"Control+Alt+-":   KeyFunFoldAll,
+		"Control+Alt+=":   KeyFunUnfoldAll,
 	}},
 }
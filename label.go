@@ -325,6 +325,7 @@ func (lb *Label) StyleLabel() {
 	for i := 0; i < int(LabelStatesN); i++ {
 		lb.StateStyles[i].CopyFrom(&lb.Sty)
 		lb.StateStyles[i].SetStyleProps(pst, lb.StyleProps(LabelSelectors[i]))
+		lb.StateStyles[i].StyleCSS(lb.This.(Node2D), lb.CSSAgg, LabelSelectors[i])
 		lb.StateStyles[i].CopyUnitContext(&lb.Sty.UnContext)
 	}
 }
@@ -397,3 +398,12 @@ func (lb *Label) Render2D() {
 func (lb *Label) ConnectEvents2D() {
 	lb.LabelEvents()
 }
+
+// AccessInfo returns the accessibility info for the label
+func (lb *Label) AccessInfo() AccessInfo {
+	ai := lb.WidgetBase.AccessInfo()
+	ai.Role = RoleLabel
+	ai.Name = lb.Text
+	ai.SetState(lb.Selectable, AccessSelectable)
+	return ai
+}
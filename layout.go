@@ -42,26 +42,40 @@ import (
 
 // LayoutStyle contains style preferences on the layout of the element.
 type LayoutStyle struct {
-	ZIndex         int         `xml:"z-index" desc:"ordering factor for rendering depth -- lower numbers rendered first -- sort children according to this factor"`
-	AlignH         Align       `xml:"horizontal-align" desc:"horizontal alignment -- for widget layouts -- not a standard css property"`
-	AlignV         Align       `xml:"vertical-align" desc:"vertical alignment -- for widget layouts -- not a standard css property"`
-	PosX           units.Value `xml:"x" desc:"horizontal position -- often superceded by layout but otherwise used"`
-	PosY           units.Value `xml:"y" desc:"vertical position -- often superceded by layout but otherwise used"`
-	Width          units.Value `xml:"width" desc:"specified size of element -- 0 if not specified"`
-	Height         units.Value `xml:"height" desc:"specified size of element -- 0 if not specified"`
-	MaxWidth       units.Value `xml:"max-width" desc:"specified maximum size of element -- 0  means just use other values, negative means stretch"`
-	MaxHeight      units.Value `xml:"max-height" desc:"specified maximum size of element -- 0 means just use other values, negative means stretch"`
-	MinWidth       units.Value `xml:"min-width" desc:"specified mimimum size of element -- 0 if not specified"`
-	MinHeight      units.Value `xml:"min-height" desc:"specified mimimum size of element -- 0 if not specified"`
-	Margin         units.Value `xml:"margin" desc:"outer-most transparent space around box element -- todo: can be specified per side"`
-	Padding        units.Value `xml:"padding" desc:"transparent space around central content of box -- todo: if 4 values it is top, right, bottom, left; 3 is top, right&left, bottom; 2 is top & bottom, right and left"`
-	Overflow       Overflow    `xml:"overflow" desc:"what to do with content that overflows -- default is Auto add of scrollbars as needed -- todo: can have separate -x -y values"`
-	Columns        int         `xml:"columns" alt:"grid-cols" desc:"number of columns to use in a grid layout -- used as a constraint in layout if individual elements do not specify their row, column positions"`
-	Row            int         `xml:"row" desc:"specifies the row that this element should appear within a grid layout"`
-	Col            int         `xml:"col" desc:"specifies the column that this element should appear within a grid layout"`
-	RowSpan        int         `xml:"row-span" desc:"specifies the number of sequential rows that this element should occupy within a grid layout (todo: not currently supported)"`
-	ColSpan        int         `xml:"col-span" desc:"specifies the number of sequential columns that this element should occupy within a grid layout"`
-	ScrollBarWidth units.Value `xml:"scrollbar-width" desc:"width of a layout scrollbar"`
+	ZIndex            int         `xml:"z-index" desc:"ordering factor for rendering depth -- lower numbers rendered first -- sort children according to this factor"`
+	AlignH            Align       `xml:"horizontal-align" desc:"horizontal alignment -- for widget layouts -- not a standard css property"`
+	AlignV            Align       `xml:"vertical-align" desc:"vertical alignment -- for widget layouts -- not a standard css property"`
+	PosX              units.Value `xml:"x" desc:"horizontal position -- often superceded by layout but otherwise used"`
+	PosY              units.Value `xml:"y" desc:"vertical position -- often superceded by layout but otherwise used"`
+	Width             units.Value `xml:"width" desc:"specified size of element -- 0 if not specified"`
+	Height            units.Value `xml:"height" desc:"specified size of element -- 0 if not specified"`
+	MaxWidth          units.Value `xml:"max-width" desc:"specified maximum size of element -- 0  means just use other values, negative means stretch"`
+	MaxHeight         units.Value `xml:"max-height" desc:"specified maximum size of element -- 0 means just use other values, negative means stretch"`
+	MinWidth          units.Value `xml:"min-width" desc:"specified mimimum size of element -- 0 if not specified"`
+	MinHeight         units.Value `xml:"min-height" desc:"specified mimimum size of element -- 0 if not specified"`
+	Margin            units.Value `xml:"margin" desc:"outer-most transparent space around box element -- todo: can be specified per side"`
+	Padding           units.Value `xml:"padding" desc:"transparent space around central content of box -- todo: if 4 values it is top, right, bottom, left; 3 is top, right&left, bottom; 2 is top & bottom, right and left"`
+	Overflow          Overflow    `xml:"overflow" desc:"what to do with content that overflows -- default is Auto add of scrollbars as needed -- todo: can have separate -x -y values"`
+	Columns           int         `xml:"columns" alt:"grid-cols" desc:"number of columns to use in a grid layout -- used as a constraint in layout if individual elements do not specify their row, column positions"`
+	Row               int         `xml:"row" desc:"specifies the row that this element should appear within a grid layout"`
+	Col               int         `xml:"col" desc:"specifies the column that this element should appear within a grid layout"`
+	RowSpan           int         `xml:"row-span" desc:"specifies the number of sequential rows that this element should occupy within a grid layout (only supported in LayoutGridIrreg)"`
+	ColSpan           int         `xml:"col-span" desc:"specifies the number of sequential columns that this element should occupy within a grid layout"`
+	GridTemplateCols  string      `xml:"grid-template-columns" desc:"for LayoutGridIrreg, sizes of the columns: fixed units (100px), percent of the layout (20%), fractions of remaining space (1fr), auto (sized to content), minmax(min, max) and repeat(n, tracks)"`
+	GridTemplateRows  string      `xml:"grid-template-rows" desc:"for LayoutGridIrreg, sizes of the rows -- same format as grid-template-columns -- any implicit rows beyond these are auto"`
+	GridTemplateAreas string      `xml:"grid-template-areas" desc:"for LayoutGridIrreg, named areas as a sequence of quoted row strings, each listing the area name for each column, e.g., 'head head' 'side main' -- use . for an unnamed cell"`
+	GridRow           string      `xml:"grid-row" desc:"for LayoutGridIrreg, the row placement of this element as CSS start / end lines (1-based, negative from the end), e.g., 2, 2 / 4, 2 / span 3, span 2, 1 / -1, or an area name -- if empty, row and row-span are used"`
+	GridCol           string      `xml:"grid-column" desc:"for LayoutGridIrreg, the column placement of this element -- same format as grid-row -- if empty, col and col-span are used"`
+	GridArea          string      `xml:"grid-area" desc:"for LayoutGridIrreg, the name of a template area that this element occupies, or row-start / col-start / row-end / col-end -- overrides grid-row and grid-column"`
+	FlexGrow          float32     `xml:"flex-grow" desc:"for flex layouts (LayoutHorizFlow, LayoutVertFlow), how much this element grows relative to its siblings to fill any extra space along the main axis -- 0 = does not grow"`
+	FlexShrink        float32     `xml:"flex-shrink" desc:"for flex layouts, how much this element shrinks relative to its siblings (scaled by its basis) when there is not enough space along the main axis -- never below its min size"`
+	FlexBasis         units.Value `xml:"flex-basis" desc:"for flex layouts, initial main-axis size of this element, before growing or shrinking -- 0 = auto, which uses the preferred size"`
+	Wrap              FlexWraps   `xml:"flex-wrap" desc:"for flex layouts, whether items wrap onto multiple lines -- flow layouts wrap by default"`
+	JustifyContent    Align       `xml:"justify-content" desc:"for flex layouts, how extra space along the main axis is distributed within each line: at the start (FlexStart, Left, Top), Center, at the end (FlexEnd, Right, Bottom), Justify (space-between) or SpaceAround"`
+	AlignItems        Align       `xml:"align-items" desc:"for flex layouts, how items are aligned along the cross axis within their line -- Stretch (the default) fills the line, up to the max size"`
+	AlignContent      Align       `xml:"align-content" desc:"for flex layouts, how lines are distributed along the cross axis when there is extra space -- Stretch (the default) grows each line equally, and otherwise as for justify-content"`
+	Constraints       string      `xml:"constraints" desc:"for LayoutConstraints, linear constraints on this element, separated by ; -- each is lhs op rhs [!strength], where op is ==, <= or >=, strength is required (the default), strong, medium or weak, and lhs, rhs are sums of references to [element.]attribute (left, right, top, bottom, width, height, centerx, centery), where element is empty or this for this element, parent for the layout, or the name of a sibling, optionally multiplied by a number or percent, and constants with units, e.g., left == sib.right + 1em; width >= 30% parent.width !strong"`
+	ScrollBarWidth    units.Value `xml:"scrollbar-width" desc:"width of a layout scrollbar"`
 }
 
 func (ls *LayoutStyle) Defaults() {
@@ -69,6 +83,10 @@ func (ls *LayoutStyle) Defaults() {
 	ls.MinWidth.Set(2.0, units.Px)
 	ls.MinHeight.Set(2.0, units.Px)
 	ls.ScrollBarWidth.Set(16.0, units.Px)
+	ls.FlexShrink = 1
+	ls.Wrap = FlexWrap
+	ls.AlignItems = AlignStretch
+	ls.AlignContent = AlignStretch
 }
 
 func (ls *LayoutStyle) SetStylePost(props ki.Props) {
@@ -130,6 +148,8 @@ const (
 	AlignSub
 	// align to superscript
 	AlignSuper
+	// stretch to fill the space, as in CSS align-items and align-content
+	AlignStretch
 	AlignN
 )
 
@@ -214,18 +234,18 @@ func (sp SizePrefs) CanStretchNeed(d Dims2D) bool {
 // within a layout -- includes computed values of style prefs -- everything is
 // concrete and specified here, whereas style may not be fully resolved
 type LayoutData struct {
-	Size          SizePrefs `desc:"size constraints for this item -- from layout style"`
-	AllocSize     Vec2D     `desc:"allocated size of this item, by the parent layout"`
-	AllocPos      Vec2D     `desc:"position of this item, computed by adding in the AllocPosRel to parent position"`
-	AllocPosRel   Vec2D     `desc:"allocated relative position of this item, computed by the parent layout"`
-	AllocSizeOrig Vec2D     `desc:"original copy of allocated size of this item, by the parent layout -- some widgets will resize themselves within a given layout (e.g., a TextView), but still need access to their original allocated size"`
-	AllocPosOrig  Vec2D     `desc:"original copy of allocated relative position of this item, by the parent layout -- need for scrolling which can update AllocPos"`
+	Size          SizePrefs   `desc:"size constraints for this item -- from layout style"`
+	AllocSize     Vec2D       `desc:"allocated size of this item, by the parent layout"`
+	AllocPos      Vec2D       `desc:"position of this item, computed by adding in the AllocPosRel to parent position"`
+	AllocPosRel   Vec2D       `desc:"allocated relative position of this item, computed by the parent layout"`
+	AllocSizeOrig Vec2D       `desc:"original copy of allocated size of this item, by the parent layout -- some widgets will resize themselves within a given layout (e.g., a TextView), but still need access to their original allocated size"`
+	AllocPosOrig  Vec2D       `desc:"original copy of allocated relative position of this item, by the parent layout -- need for scrolling which can update AllocPos"`
+	GridPos       image.Point `desc:"position within a LayoutGridIrreg grid (X = col, Y = row) -- computed by the parent layout"`
+	GridSpan      image.Point `desc:"number of grid cells that we take up in each direction within a LayoutGridIrreg grid -- computed by the parent layout"`
 }
 
 // todo: not using yet:
 // Margins Margins   `desc:"margins around this item"`
-// GridPos      image.Point `desc:"position within a grid"`
-// GridSpan     image.Point `desc:"number of grid elements that we take up in each direction"`
 
 func (ld *LayoutData) Defaults() {
 }
@@ -294,20 +314,25 @@ var LayoutFocusNameTabMSec = 2000
 // can automatically add scrollbars depending on the Overflow layout style.
 type Layout struct {
 	WidgetBase
-	Lay           Layouts             `xml:"lay" desc:"type of layout to use"`
-	Spacing       units.Value         `xml:"spacing" desc:"extra space to add between elements in the layout"`
-	StackTop      int                 `desc:"for Stacked layout, index of node to use as the top of the stack -- only node at this index is rendered -- if not a valid index, nothing is rendered"`
-	ChildSize     Vec2D               `json:"-" xml:"-" desc:"total max size of children as laid out"`
-	ExtraSize     Vec2D               `json:"-" xml:"-" desc:"extra size in each dim due to scrollbars we add"`
-	HasScroll     [Dims2DN]bool       `json:"-" xml:"-" desc:"whether scrollbar is used for given dim"`
-	Scrolls       [Dims2DN]*ScrollBar `json:"-" xml:"-" desc:"scroll bars -- we fully manage them as needed"`
-	GridSize      image.Point         `json:"-" xml:"-" desc:"computed size of a grid layout based on all the constraints -- computed during Size2D pass"`
-	GridData      [RowColN][]GridData `json:"-" xml:"-" desc:"grid data for rows in [0] and cols in [1]"`
-	NeedsRedo     bool                `json:"-" xml:"-" desc:"true if this layout got a redo = true on previous iteration -- otherwise it just skips any re-layout on subsequent iteration"`
-	FocusName     string              `json:"-" xml:"-" desc:"accumulated name to search for when keys are typed"`
-	FocusNameTime time.Time           `json:"-" xml:"-" desc:"time of last focus name event -- for timeout"`
-	FocusNameLast ki.Ki               `json:"-" xml:"-" desc:"last element focused on -- used as a starting point if name is the same"`
-	ScrollsOff    bool                `json:"-" xml:"-" desc:"scrollbars have been manually turned off due to layout being invisible -- must be reactivated when re-visible"`
+	Lay           Layouts                    `xml:"lay" desc:"type of layout to use"`
+	Spacing       units.Value                `xml:"spacing" alt:"gap" desc:"extra space to add between elements in the layout"`
+	StackTop      int                        `desc:"for Stacked layout, index of node to use as the top of the stack -- only node at this index is rendered -- if not a valid index, nothing is rendered"`
+	ChildSize     Vec2D                      `json:"-" xml:"-" desc:"total max size of children as laid out"`
+	ExtraSize     Vec2D                      `json:"-" xml:"-" desc:"extra size in each dim due to scrollbars we add"`
+	HasScroll     [Dims2DN]bool              `json:"-" xml:"-" desc:"whether scrollbar is used for given dim"`
+	Scrolls       [Dims2DN]*ScrollBar        `json:"-" xml:"-" desc:"scroll bars -- we fully manage them as needed"`
+	GridSize      image.Point                `json:"-" xml:"-" desc:"computed size of a grid layout based on all the constraints -- computed during Size2D pass"`
+	GridData      [RowColN][]GridData        `json:"-" xml:"-" desc:"grid data for rows in [0] and cols in [1]"`
+	GridTracks    [RowColN][]GridTrack       `json:"-" xml:"-" desc:"for LayoutGridIrreg, parsed grid-template track sizes for rows in [0] and cols in [1]"`
+	GridAreas     map[string]image.Rectangle `json:"-" xml:"-" desc:"for LayoutGridIrreg, parsed grid-template-areas -- Min is the starting col, row and Max is the ending (exclusive) col, row"`
+	ConsErrs      []error                    `json:"-" xml:"-" desc:"for LayoutConstraints, errors from the last size and layout passes: constraints that could not be parsed, referred to unknown elements, or could not be satisfied -- these are logged when they change"`
+	ConsErrsLog   string                     `json:"-" xml:"-" desc:"for LayoutConstraints, the ConsErrs messages that were last logged"`
+	FlexCrossSize float32                    `json:"-" xml:"-" desc:"for flex layouts, total cross-axis size of the wrapped lines from the last layout, including gaps -- used for sizing on the next pass"`
+	NeedsRedo     bool                       `json:"-" xml:"-" desc:"true if this layout got a redo = true on previous iteration -- otherwise it just skips any re-layout on subsequent iteration"`
+	FocusName     string                     `json:"-" xml:"-" desc:"accumulated name to search for when keys are typed"`
+	FocusNameTime time.Time                  `json:"-" xml:"-" desc:"time of last focus name event -- for timeout"`
+	FocusNameLast ki.Ki                      `json:"-" xml:"-" desc:"last element focused on -- used as a starting point if name is the same"`
+	ScrollsOff    bool                       `json:"-" xml:"-" desc:"scrollbars have been manually turned off due to layout being invisible -- must be reactivated when re-visible"`
 }
 
 var KiT_Layout = kit.Types.AddType(&Layout{}, nil)
@@ -325,15 +350,21 @@ const (
 	// LayoutGrid arranges items according to a regular grid
 	LayoutGrid
 
-	// todo: add LayoutGridIrreg that deals with irregular grids with spans etc -- keep
-	// the basic grid for fully regular cases -- need high performance for large grids
-
-	// LayoutHorizFlow arranges items horizontally across a row, overflowing
-	// vertically as needed
+	// LayoutGridIrreg arranges items on an irregular grid as in CSS Grid --
+	// items can span multiple rows and columns, and the layout specifies the
+	// track sizes and named template areas -- the basic LayoutGrid is kept
+	// for fully regular cases, where high performance is needed for large
+	// grids
+	LayoutGridIrreg
+
+	// LayoutHorizFlow is a flex layout that arranges items horizontally
+	// across a row, wrapping onto more rows as needed -- see flex-grow,
+	// flex-shrink, flex-basis, flex-wrap, justify-content, align-items and
+	// align-content styles
 	LayoutHorizFlow
 
-	// LayoutVertFlow arranges items vertically within a column, overflowing
-	// horizontally as needed
+	// LayoutVertFlow is a flex layout that arranges items vertically within
+	// a column, wrapping onto more columns as needed
 	LayoutVertFlow
 
 	// LayoutStacked arranges items stacked on top of each other -- Top index
@@ -341,6 +372,12 @@ const (
 	// dimension
 	LayoutStacked
 
+	// LayoutConstraints positions and sizes items according to linear
+	// constraints among them and the layout, with priorities, solved by a
+	// Cassowary constraint solver -- see the constraints style -- items
+	// default to their preferred size at the top left of the layout
+	LayoutConstraints
+
 	// LayoutNil is a nil layout -- doesn't do anything -- for cases when a
 	// parent wants to take over the job of the layout
 	LayoutNil
@@ -704,7 +741,7 @@ func (ly *Layout) LayoutSharedDimImpl(avail, need, pref, max, spc float32, al Al
 			pos += 0.5 * extra
 		} else if IsAlignEnd(al) {
 			pos += extra
-		} else if al == AlignJustify { // treat justify as stretch
+		} else if al == AlignJustify || al == AlignStretch { // treat justify as stretch
 			size += extra
 		}
 	}
@@ -1580,7 +1617,7 @@ func (ly *Layout) FocusPrevChild(updn bool) bool {
 // LayoutKeys is key processing for layouts -- focus name and arrow keys
 func (ly *Layout) LayoutKeys(kt *key.ChordEvent) {
 	kf := KeyFun(kt.Chord())
-	if ly.Lay == LayoutHoriz || ly.Lay == LayoutGrid || ly.Lay == LayoutHorizFlow {
+	if ly.Lay == LayoutHoriz || ly.Lay == LayoutGrid || ly.Lay == LayoutGridIrreg || ly.Lay == LayoutHorizFlow || ly.Lay == LayoutConstraints {
 		switch kf {
 		case KeyFunMoveRight:
 			if ly.FocusNextChild(false) { // allow higher layers to try..
@@ -1594,7 +1631,7 @@ func (ly *Layout) LayoutKeys(kt *key.ChordEvent) {
 			return
 		}
 	}
-	if ly.Lay == LayoutVert || ly.Lay == LayoutGrid || ly.Lay == LayoutVertFlow {
+	if ly.Lay == LayoutVert || ly.Lay == LayoutGrid || ly.Lay == LayoutGridIrreg || ly.Lay == LayoutVertFlow || ly.Lay == LayoutConstraints {
 		switch kf {
 		case KeyFunMoveDown:
 			if ly.FocusNextChild(true) {
@@ -1760,9 +1797,16 @@ func (ly *Layout) Style2D() {
 
 func (ly *Layout) Size2D(iter int) {
 	ly.InitLayout2D()
-	if ly.Lay == LayoutGrid {
+	switch ly.Lay {
+	case LayoutGrid:
 		ly.GatherSizesGrid()
-	} else {
+	case LayoutGridIrreg:
+		ly.GatherSizesGridIrreg()
+	case LayoutHorizFlow, LayoutVertFlow:
+		ly.GatherSizesFlex()
+	case LayoutConstraints:
+		ly.GatherSizesConstraints()
+	default:
 		ly.GatherSizes()
 	}
 }
@@ -1775,6 +1819,7 @@ func (ly *Layout) Layout2D(parBBox image.Rectangle, iter int) bool {
 	//}
 	ly.AllocFromParent()                 // in case we didn't get anything
 	ly.Layout2DBase(parBBox, true, iter) // init style
+	flexRedo := false
 	switch ly.Lay {
 	case LayoutHoriz:
 		ly.LayoutAlongDim(X)
@@ -1784,6 +1829,12 @@ func (ly *Layout) Layout2D(parBBox image.Rectangle, iter int) bool {
 		ly.LayoutSharedDim(X)
 	case LayoutGrid:
 		ly.LayoutGrid()
+	case LayoutGridIrreg:
+		ly.LayoutGridIrreg()
+	case LayoutHorizFlow, LayoutVertFlow:
+		flexRedo = ly.LayoutFlex(iter)
+	case LayoutConstraints:
+		ly.LayoutConstraints()
 	case LayoutStacked:
 		ly.LayoutSharedDim(X)
 		ly.LayoutSharedDim(Y)
@@ -1792,7 +1843,7 @@ func (ly *Layout) Layout2D(parBBox image.Rectangle, iter int) bool {
 	}
 	ly.FinalizeLayout()
 	ly.ManageOverflow()
-	ly.NeedsRedo = ly.Layout2DChildren(iter) // layout done with canonical positions
+	ly.NeedsRedo = ly.Layout2DChildren(iter) || flexRedo // layout done with canonical positions
 
 	if !ly.NeedsRedo || iter == 1 {
 		delta := ly.Move2DDelta(image.ZP)
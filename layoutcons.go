@@ -0,0 +1,511 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/goki/gi/cassowary"
+	"github.com/goki/gi/units"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   LayoutConstraints -- linear constraints among children
+
+// LayoutConsAttrs are the attributes of an element that can be used in
+// layout constraints -- see the constraints style
+var LayoutConsAttrs = []string{"left", "right", "top", "bottom", "width", "height", "centerx", "centery"}
+
+// LayoutConsRef is a reference to an attribute of an element in a layout
+// constraint, written as [node.]attr
+type LayoutConsRef struct {
+	Node string `desc:"name of the element: empty or this for the element owning the constraint, parent for the layout, or the name of a sibling"`
+	Attr string `desc:"attribute: one of LayoutConsAttrs"`
+}
+
+// LayoutConsTerm is one term of a layout constraint expression: either a
+// coefficient times a reference, or a constant value
+type LayoutConsTerm struct {
+	Coef  float64       `desc:"coefficient multiplying the reference"`
+	Ref   LayoutConsRef `desc:"reference -- Attr is empty for a constant term"`
+	Const units.Value   `desc:"constant value, if Ref is empty -- converted to dots in the context of the element owning the constraint"`
+}
+
+// IsConst returns true if this is a constant term
+func (lt *LayoutConsTerm) IsConst() bool {
+	return lt.Ref.Attr == ""
+}
+
+// LayoutConstraint is a parsed layout constraint: Lhs Op Rhs with a strength
+type LayoutConstraint struct {
+	Lhs      []LayoutConsTerm `desc:"terms of the left-hand side, summed"`
+	Op       cassowary.Op     `desc:"relational operator"`
+	Rhs      []LayoutConsTerm `desc:"terms of the right-hand side, summed"`
+	Strength float64          `desc:"strength of the constraint -- cassowary.Required by default"`
+	Src      string           `desc:"source text of the constraint, for error messages"`
+}
+
+// LayoutConsStrengths maps the !strength suffixes of layout constraints to
+// solver strengths
+var LayoutConsStrengths = map[string]float64{
+	"required": cassowary.Required,
+	"strong":   cassowary.Strong,
+	"medium":   cassowary.Medium,
+	"weak":     cassowary.Weak,
+}
+
+// ParseLayoutConstraints parses a list of layout constraints separated by ;
+// -- returns the ones that parsed, and an error for each one that did not
+func ParseLayoutConstraints(str string) ([]LayoutConstraint, []error) {
+	var lcs []LayoutConstraint
+	var errs []error
+	for _, src := range strings.Split(str, ";") {
+		if strings.TrimSpace(src) == "" {
+			continue
+		}
+		lc, err := ParseLayoutConstraint(src)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		lcs = append(lcs, lc)
+	}
+	return lcs, errs
+}
+
+// ParseLayoutConstraint parses one layout constraint, of the form:
+// lhs op rhs [!strength], where op is ==, =, <= or >=, strength is
+// required (the default), strong, medium or weak, and each side is a sum
+// of terms: a reference ([node.]attr), a number or % times a reference
+// (30% parent.width, 2 * width), or a constant with units (1em, 10px) --
+// a - between two names is part of the name, so use spaces to subtract
+// one reference from another
+func ParseLayoutConstraint(src string) (LayoutConstraint, error) {
+	lc := LayoutConstraint{Strength: cassowary.Required, Src: strings.TrimSpace(src)}
+	str := lc.Src
+	if bi := strings.LastIndex(str, "!"); bi >= 0 {
+		snm := strings.ToLower(strings.TrimSpace(str[bi+1:]))
+		st, ok := LayoutConsStrengths[snm]
+		if !ok {
+			return lc, fmt.Errorf("layout constraint %q: unknown strength: %v", lc.Src, snm)
+		}
+		lc.Strength = st
+		str = str[:bi]
+	}
+	ops := []struct {
+		str string
+		op  cassowary.Op
+	}{{"<=", cassowary.LE}, {">=", cassowary.GE}, {"==", cassowary.EQ}, {"=", cassowary.EQ}}
+	oi := -1
+	var lhs, rhs string
+	for _, op := range ops {
+		if oi = strings.Index(str, op.str); oi >= 0 {
+			lc.Op = op.op
+			lhs, rhs = str[:oi], str[oi+len(op.str):]
+			break
+		}
+	}
+	if oi < 0 {
+		return lc, fmt.Errorf("layout constraint %q: no relational operator (==, <=, >=)", lc.Src)
+	}
+	var err error
+	if lc.Lhs, err = parseLayoutConsExpr(lhs); err != nil {
+		return lc, fmt.Errorf("layout constraint %q: %v", lc.Src, err)
+	}
+	if lc.Rhs, err = parseLayoutConsExpr(rhs); err != nil {
+		return lc, fmt.Errorf("layout constraint %q: %v", lc.Src, err)
+	}
+	return lc, nil
+}
+
+// parseLayoutConsExpr parses a sum of terms
+func parseLayoutConsExpr(str string) ([]LayoutConsTerm, error) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return nil, errors.New("empty expression")
+	}
+	var terms []LayoutConsTerm
+	sign := 1.0
+	st := 0
+	for i := 0; i <= len(str); i++ {
+		if i < len(str) && str[i] != '+' && str[i] != '-' {
+			continue
+		}
+		if i < len(str) && i > 0 && (str[i-1] == 'e' || str[i-1] == 'E') && i-1 > st && isLayoutConsDigit(str[i-2]) {
+			continue // exponent, e.g., 1e-3
+		}
+		if i < len(str) && str[i] == '-' && i > 0 && i+1 < len(str) && isLayoutConsNameChar(str[i-1]) && isLayoutConsLetter(str[i+1]) {
+			continue // within a name, e.g., ok-button.right
+		}
+		tstr := strings.TrimSpace(str[st:i])
+		if tstr == "" {
+			if st > 0 || i == len(str) {
+				return nil, fmt.Errorf("missing term in: %v", str)
+			}
+		} else {
+			lt, err := parseLayoutConsTerm(tstr)
+			if err != nil {
+				return nil, err
+			}
+			lt.Coef *= sign
+			lt.Const.Val *= float32(sign)
+			terms = append(terms, lt)
+		}
+		if i < len(str) {
+			if str[i] == '-' {
+				sign = -1
+			} else {
+				sign = 1
+			}
+		}
+		st = i + 1
+	}
+	return terms, nil
+}
+
+func isLayoutConsDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '.'
+}
+
+func isLayoutConsLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
+
+func isLayoutConsNameChar(c byte) bool {
+	return isLayoutConsLetter(c) || (c >= '0' && c <= '9')
+}
+
+// parseLayoutConsTerm parses a product of factors separated by * or space
+func parseLayoutConsTerm(str string) (LayoutConsTerm, error) {
+	lt := LayoutConsTerm{Coef: 1}
+	fs := strings.Fields(strings.Replace(str, "*", " ", -1))
+	var un units.Unit = units.Px
+	hasUnits, hasPct := false, false
+	for _, f := range fs {
+		if !isLayoutConsDigit(f[0]) {
+			if lt.Ref.Attr != "" {
+				return lt, fmt.Errorf("term %q: products of references are not linear", str)
+			}
+			ref, err := parseLayoutConsRef(f)
+			if err != nil {
+				return lt, err
+			}
+			lt.Ref = ref
+			continue
+		}
+		ne := 0
+		for ne < len(f) && (isLayoutConsDigit(f[ne]) || ((f[ne] == 'e' || f[ne] == 'E') && ne+1 < len(f) && (isLayoutConsDigit(f[ne+1]) || f[ne+1] == '-'))) {
+			if f[ne] == 'e' || f[ne] == 'E' {
+				ne++
+			}
+			ne++
+		}
+		val, err := strconv.ParseFloat(f[:ne], 64)
+		if err != nil {
+			return lt, fmt.Errorf("term %q: invalid number: %v", str, f)
+		}
+		lt.Coef *= val
+		sfx := strings.ToLower(f[ne:])
+		switch sfx {
+		case "":
+		case "%", "pct":
+			lt.Coef /= 100
+			hasPct = true
+		default:
+			found := false
+			for i, nm := range units.UnitNames {
+				if nm == sfx {
+					un = units.Unit(i)
+					found = true
+					break
+				}
+			}
+			if !found || hasUnits {
+				return lt, fmt.Errorf("term %q: invalid units: %v", str, f)
+			}
+			hasUnits = true
+		}
+	}
+	if lt.Ref.Attr == "" {
+		if hasPct {
+			return lt, fmt.Errorf("term %q: percent requires a reference, e.g., 30%% parent.width", str)
+		}
+		lt.Const.Set(float32(lt.Coef), un)
+		lt.Coef = 0
+		return lt, nil
+	}
+	if hasUnits {
+		return lt, fmt.Errorf("term %q: a reference can only be multiplied by plain numbers or percents", str)
+	}
+	return lt, nil
+}
+
+// parseLayoutConsRef parses a [node.]attr reference
+func parseLayoutConsRef(str string) (LayoutConsRef, error) {
+	ref := LayoutConsRef{Attr: strings.ToLower(str)}
+	if di := strings.LastIndex(str, "."); di >= 0 {
+		ref.Node = str[:di]
+		ref.Attr = strings.ToLower(str[di+1:])
+		if ref.Node == "" {
+			return ref, fmt.Errorf("reference %q: missing element name", str)
+		}
+	}
+	for _, at := range LayoutConsAttrs {
+		if at == ref.Attr {
+			return ref, nil
+		}
+	}
+	return ref, fmt.Errorf("reference %q: unknown attribute: %v -- must be one of: %v", str, ref.Attr, strings.Join(LayoutConsAttrs, ", "))
+}
+
+// layoutConsVars are the solver variables for one element
+type layoutConsVars struct {
+	Left, Top, Width, Height *cassowary.Variable
+}
+
+func newLayoutConsVars(nm string) *layoutConsVars {
+	return &layoutConsVars{Left: cassowary.NewVariable(nm + ".left"), Top: cassowary.NewVariable(nm + ".top"),
+		Width: cassowary.NewVariable(nm + ".width"), Height: cassowary.NewVariable(nm + ".height")}
+}
+
+// Expr returns the expression for given attribute -- the parent Left, Top
+// are nil, as the children are positioned relative to it
+func (lv *layoutConsVars) Expr(attr string) cassowary.Expression {
+	pos := func(v *cassowary.Variable) cassowary.Expression {
+		if v == nil {
+			return cassowary.Const(0)
+		}
+		return cassowary.Expr(v)
+	}
+	switch attr {
+	case "left":
+		return pos(lv.Left)
+	case "right":
+		return pos(lv.Left).Add(cassowary.Expr(lv.Width))
+	case "top":
+		return pos(lv.Top)
+	case "bottom":
+		return pos(lv.Top).Add(cassowary.Expr(lv.Height))
+	case "width":
+		return cassowary.Expr(lv.Width)
+	case "height":
+		return cassowary.Expr(lv.Height)
+	case "centerx":
+		return pos(lv.Left).Add(cassowary.Expr(lv.Width).Mul(0.5))
+	case "centery":
+		return pos(lv.Top).Add(cassowary.Expr(lv.Height).Mul(0.5))
+	}
+	return cassowary.Const(0)
+}
+
+// LayoutConsSizeMin is the strength with which the size of a
+// LayoutConstraints layout is minimized when gathering sizes -- weaker than
+// everything else, so it only takes up slack
+var LayoutConsSizeMin = cassowary.CreateStrength(0, 0, 0.1, 1)
+
+// SolveConstraints sets up and solves the constraints among the children
+// for LayoutConstraints -- if avail is nil, the size of the layout is
+// solved for (children fit within it), and otherwise it is fixed at avail --
+// children default to their preferred size (or need size if useNeed), at
+// the top left, and within their need and max sizes -- returns the
+// variables for the layout and each child, and any errors
+func (ly *Layout) SolveConstraints(avail *Vec2D, useNeed bool) (*layoutConsVars, []*layoutConsVars, []error) {
+	s := cassowary.NewSolver()
+	var errs []error
+	add := func(ni *WidgetBase, c *cassowary.Constraint, desc string) {
+		c.Desc = desc
+		if err := s.AddConstraint(c); err != nil {
+			errs = append(errs, fmt.Errorf("child %v: %v", ni.Nm, err))
+		}
+	}
+	NC := cassowary.NewConstraint
+	E, C := cassowary.Expr, cassowary.Const
+
+	pv := &layoutConsVars{Width: cassowary.NewVariable("parent.width"), Height: cassowary.NewVariable("parent.height")}
+	if avail != nil {
+		s.AddConstraint(NC(E(pv.Width), cassowary.EQ, C(float64(avail.X)), cassowary.Required))
+		s.AddConstraint(NC(E(pv.Height), cassowary.EQ, C(float64(avail.Y)), cassowary.Required))
+	} else {
+		s.AddConstraint(NC(E(pv.Width), cassowary.GE, C(0), cassowary.Required))
+		s.AddConstraint(NC(E(pv.Height), cassowary.GE, C(0), cassowary.Required))
+		s.AddConstraint(NC(E(pv.Width), cassowary.EQ, C(0), LayoutConsSizeMin))
+		s.AddConstraint(NC(E(pv.Height), cassowary.EQ, C(0), LayoutConsSizeMin))
+	}
+	inside := cassowary.Required
+	if avail != nil {
+		inside = cassowary.Medium // allow overflow rather than failing
+	}
+
+	var kvs []*layoutConsVars
+	for _, c := range ly.Kids {
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			kvs = append(kvs, nil)
+			continue
+		}
+		kv := newLayoutConsVars(ni.Nm)
+		kvs = append(kvs, kv)
+		sz := &ni.LayData.Size
+		tsz := sz.Pref
+		if useNeed {
+			tsz = sz.Need
+		}
+		for d := X; d <= Y; d++ {
+			v, pos, pe := kv.Width, kv.Left, "right"
+			if d == Y {
+				v, pos, pe = kv.Height, kv.Top, "bottom"
+			}
+			add(ni, NC(E(v), cassowary.GE, C(float64(sz.Need.Dim(d))), cassowary.Strong), ni.Nm+" need size")
+			if mx := sz.Max.Dim(d); mx > 0 {
+				add(ni, NC(E(v), cassowary.LE, C(float64(mx)), cassowary.Strong), ni.Nm+" max size")
+			}
+			add(ni, NC(E(v), cassowary.EQ, C(float64(tsz.Dim(d))), cassowary.Weak), ni.Nm+" size")
+			add(ni, NC(E(pos), cassowary.GE, C(0), cassowary.Strong), ni.Nm+" inside")
+			add(ni, NC(E(pos), cassowary.EQ, C(0), cassowary.CreateStrength(0, 0, 0.5, 1)), ni.Nm+" position")
+			add(ni, NC(kv.Expr(pe), cassowary.LE, pv.Expr(pe), inside), ni.Nm+" inside")
+		}
+	}
+
+	for i, c := range ly.Kids {
+		ni := c.(Node2D).AsWidget()
+		if ni == nil || ni.Sty.Layout.Constraints == "" {
+			continue
+		}
+		lcs, perrs := ParseLayoutConstraints(ni.Sty.Layout.Constraints)
+		for _, err := range perrs {
+			errs = append(errs, fmt.Errorf("child %v: %v", ni.Nm, err))
+		}
+	lcLoop:
+		for _, lc := range lcs {
+			var side [2]cassowary.Expression
+			for si, terms := range [][]LayoutConsTerm{lc.Lhs, lc.Rhs} {
+				for _, lt := range terms {
+					if lt.IsConst() {
+						side[si] = side[si].AddConst(float64(lt.Const.ToDots(&ni.Sty.UnContext)))
+						continue
+					}
+					var rv *layoutConsVars
+					switch lt.Ref.Node {
+					case "", "this":
+						rv = kvs[i]
+					case "parent":
+						rv = pv
+					default:
+						if idx, ok := ly.Kids.IndexByName(lt.Ref.Node, i); ok && kvs[idx] != nil {
+							rv = kvs[idx]
+						}
+					}
+					if rv == nil {
+						errs = append(errs, fmt.Errorf("child %v: layout constraint %q: element not found: %v", ni.Nm, lc.Src, lt.Ref.Node))
+						continue lcLoop
+					}
+					side[si] = side[si].Add(rv.Expr(lt.Ref.Attr).Mul(lt.Coef))
+				}
+			}
+			add(ni, NC(side[0], lc.Op, side[1], lc.Strength), ni.Nm+": "+lc.Src)
+		}
+	}
+	s.UpdateVariables()
+	return pv, kvs, errs
+}
+
+// AddConsErrs adds errors to ConsErrs, skipping any that are
+// already there
+func (ly *Layout) AddConsErrs(errs []error) {
+	for _, err := range errs {
+		has := false
+		for _, ce := range ly.ConsErrs {
+			if ce.Error() == err.Error() {
+				has = true
+				break
+			}
+		}
+		if !has {
+			ly.ConsErrs = append(ly.ConsErrs, err)
+		}
+	}
+}
+
+// LogConsErrs logs the ConsErrs if they differ from those
+// logged last time, so they are reported once instead of on every pass
+func (ly *Layout) LogConsErrs() {
+	var msgs []string
+	for _, err := range ly.ConsErrs {
+		msgs = append(msgs, err.Error())
+	}
+	msg := strings.Join(msgs, "\n")
+	if msg == ly.ConsErrsLog {
+		return
+	}
+	ly.ConsErrsLog = msg
+	for _, m := range msgs {
+		log.Printf("gi.Layout %v: %v\n", ly.PathUnique(), m)
+	}
+}
+
+// GatherSizesConstraints is size first pass: gather the size information
+// from the children, LayoutConstraints version -- solves for the smallest
+// size that holds the children at their preferred and need sizes
+func (ly *Layout) GatherSizesConstraints() {
+	if len(ly.Kids) == 0 {
+		return
+	}
+	for _, c := range ly.Kids {
+		if ni := c.(Node2D).AsWidget(); ni != nil {
+			ni.LayData.UpdateSizes()
+		}
+	}
+	npv, _, nerrs := ly.SolveConstraints(nil, true)
+	ppv, _, perrs := ly.SolveConstraints(nil, false)
+	ly.ConsErrs = nil
+	ly.AddConsErrs(nerrs)
+	ly.AddConsErrs(perrs)
+	spc := ly.Sty.BoxSpace()
+	need := Vec2D{float32(npv.Width.Value), float32(npv.Height.Value)}
+	pref := Vec2D{float32(ppv.Width.Value), float32(ppv.Height.Value)}
+	for d := X; d <= Y; d++ {
+		if ly.LayData.Size.Pref.Dim(d) == 0 {
+			ly.LayData.Size.Need.SetMaxDim(d, need.Dim(d)+2.0*spc)
+			ly.LayData.Size.Pref.SetMaxDim(d, pref.Dim(d)+2.0*spc)
+		} else { // use target size from style otherwise
+			ly.LayData.Size.Need.SetDim(d, ly.LayData.Size.Pref.Dim(d))
+		}
+	}
+
+	ly.LayData.UpdateSizes() // enforce max and normal ordering, etc
+	if Layout2DTrace {
+		fmt.Printf("Size:   %v gather sizes constraints need: %v, pref: %v\n", ly.PathUnique(), ly.LayData.Size.Need, ly.LayData.Size.Pref)
+	}
+}
+
+// LayoutConstraints manages overall LayoutConstraints layout of children:
+// solves the constraints within the allocated size, and positions the
+// children accordingly
+func (ly *Layout) LayoutConstraints() {
+	if len(ly.Kids) == 0 {
+		return
+	}
+	spc := ly.Sty.BoxSpace()
+	avail := ly.LayData.AllocSize.SubVal(2.0 * spc)
+	_, kvs, errs := ly.SolveConstraints(&avail, false)
+	ly.AddConsErrs(errs)
+	ly.LogConsErrs()
+	for i, c := range ly.Kids {
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		kv := kvs[i]
+		ni.LayData.AllocSize = Vec2D{Max32(float32(kv.Width.Value), 0), Max32(float32(kv.Height.Value), 0)}
+		ni.LayData.AllocPosRel = Vec2D{spc + float32(kv.Left.Value), spc + float32(kv.Top.Value)}
+		if Layout2DTrace {
+			fmt.Printf("Layout: %v constraints Child: %v, pos: %v, size: %v\n", ly.PathUnique(), ni.UniqueNm, ni.LayData.AllocPosRel, ni.LayData.AllocSize)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+
+	"github.com/goki/gi/cassowary"
+	"github.com/goki/gi/units"
+)
+
+func TestParseLayoutConstraint(t *testing.T) {
+	lc, err := ParseLayoutConstraint(" left == ok-button.right + 1em ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lc.Op != cassowary.EQ || lc.Strength != cassowary.Required || lc.Src != "left == ok-button.right + 1em" {
+		t.Errorf("unexpected constraint: %+v", lc)
+	}
+	if len(lc.Lhs) != 1 || lc.Lhs[0].Ref != (LayoutConsRef{Attr: "left"}) || lc.Lhs[0].Coef != 1 {
+		t.Errorf("unexpected lhs: %+v", lc.Lhs)
+	}
+	if len(lc.Rhs) != 2 || lc.Rhs[0].Ref != (LayoutConsRef{Node: "ok-button", Attr: "right"}) || !lc.Rhs[1].IsConst() ||
+		lc.Rhs[1].Const.Val != 1 || lc.Rhs[1].Const.Un != units.Em {
+		t.Errorf("unexpected rhs: %+v", lc.Rhs)
+	}
+
+	lc, err = ParseLayoutConstraint("width >= 30% parent.width - 2 * sib.width - 10px !strong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lc.Op != cassowary.GE || lc.Strength != cassowary.Strong || len(lc.Rhs) != 3 {
+		t.Fatalf("unexpected constraint: %+v", lc)
+	}
+	if d := lc.Rhs[0].Coef - 0.3; d > 1.0e-9 || d < -1.0e-9 || lc.Rhs[0].Ref.Node != "parent" {
+		t.Errorf("unexpected percent term: %+v", lc.Rhs[0])
+	}
+	if lc.Rhs[1].Coef != -2 || lc.Rhs[1].Ref.Node != "sib" {
+		t.Errorf("unexpected product term: %+v", lc.Rhs[1])
+	}
+	if lc.Rhs[2].Const.Val != -10 || lc.Rhs[2].Const.Un != units.Px {
+		t.Errorf("unexpected constant term: %+v", lc.Rhs[2])
+	}
+
+	bad := []string{
+		"left right",
+		"left == 30%",
+		"left == width * height",
+		"left == sib.middle",
+		"left == 2em * width",
+		"left == 1zz",
+		"left == + ",
+		"left == 0 !very",
+	}
+	for _, src := range bad {
+		if _, err := ParseLayoutConstraint(src); err == nil {
+			t.Errorf("expected an error for: %v", src)
+		}
+	}
+
+	lcs, errs := ParseLayoutConstraints("left == 0; ; top >= 1e-1 * parent.height !weak; width = = 2")
+	if len(lcs) != 2 || len(errs) != 1 {
+		t.Errorf("expected 2 constraints and 1 error, got: %+v %v", lcs, errs)
+	}
+}
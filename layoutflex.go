@@ -0,0 +1,415 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/ki/kit"
+)
+
+// LayoutHorizFlow and LayoutVertFlow are flex layouts, along the lines of CSS
+// Flexbox: items are laid out along the main axis (X for HorizFlow, Y for
+// VertFlow), wrapping onto multiple lines according to flex-wrap.  Within
+// each line, items start at their flex-basis (or preferred size) and then
+// grow according to flex-grow to fill extra space, or shrink according to
+// flex-shrink when there is not enough, always within their min and max
+// sizes.  Any remaining space is distributed according to justify-content.
+// Along the cross axis, items are aligned within their line according to
+// align-items, and lines according to align-content.  The layout spacing
+// (also settable as gap) is used between items and between lines.
+
+// FlexWraps determines whether the items in a flex layout wrap onto
+// multiple lines
+type FlexWraps int32
+
+const (
+	// FlexNoWrap keeps all items on a single line, shrinking them as needed
+	FlexNoWrap FlexWraps = iota
+
+	// FlexWrap wraps items onto multiple lines, added along the cross axis
+	FlexWrap
+
+	// FlexWrapReverse wraps items onto multiple lines, added in the reverse
+	// direction along the cross axis (e.g., bottom to top for
+	// LayoutHorizFlow)
+	FlexWrapReverse
+
+	FlexWrapsN
+)
+
+//go:generate stringer -type=FlexWraps
+
+var KiT_FlexWraps = kit.Enums.AddEnumAltLower(FlexWrapsN, false, StylePropProps, "Flex")
+
+func (ev FlexWraps) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *FlexWraps) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// FlexItem is the sizing of one item along the main axis of a flex layout,
+// along with its resolved size and position
+type FlexItem struct {
+	Basis  float32 `desc:"initial main size, before growing or shrinking"`
+	Min    float32 `desc:"minimum main size"`
+	Max    float32 `desc:"maximum main size -- <= 0 = no maximum"`
+	Grow   float32 `desc:"flex-grow factor"`
+	Shrink float32 `desc:"flex-shrink factor"`
+	Cross  float32 `desc:"hypothetical cross size: preferred size within min and max"`
+	Size   float32 `desc:"resolved main size"`
+	Pos    float32 `desc:"resolved main position, relative to the start of the line"`
+	Frozen bool    `desc:"size has been fixed while resolving flexible sizes"`
+}
+
+// Clamp returns size within the min and max of the item
+func (fi *FlexItem) Clamp(sz float32) float32 {
+	if fi.Max > 0 && sz > fi.Max {
+		sz = fi.Max
+	}
+	return Max32(sz, fi.Min)
+}
+
+// Hyp returns the hypothetical main size: the basis within min and max
+func (fi *FlexItem) Hyp() float32 {
+	return fi.Clamp(fi.Basis)
+}
+
+// FlexLine is one line of items in a flex layout
+type FlexLine struct {
+	Start     int     `desc:"index of first item in the line"`
+	End       int     `desc:"index just past the last item in the line"`
+	CrossSize float32 `desc:"size of the line along the cross axis"`
+	CrossPos  float32 `desc:"position of the line along the cross axis"`
+}
+
+// FlexWrapLines collects items into lines, starting a new line whenever the
+// next item's hypothetical size would not fit in avail (if wrapping) --
+// there is always at least one item per line.  The cross size of each line
+// is the largest Cross of its items.
+func FlexWrapLines(items []FlexItem, avail, gap float32, wrap bool) []FlexLine {
+	var lines []FlexLine
+	if len(items) == 0 {
+		return lines
+	}
+	ln := FlexLine{}
+	sum := float32(0)
+	for i := range items {
+		hyp := items[i].Hyp()
+		if wrap && i > ln.Start && sum+gap+hyp > avail+0.1 {
+			ln.End = i
+			lines = append(lines, ln)
+			ln = FlexLine{Start: i}
+			sum = 0
+		}
+		if i > ln.Start {
+			sum += gap
+		}
+		sum += hyp
+		ln.CrossSize = Max32(ln.CrossSize, items[i].Cross)
+	}
+	ln.End = len(items)
+	return append(lines, ln)
+}
+
+// FlexResolveLine resolves the main sizes and positions of the items in one
+// line, given the space avail for the line including gaps -- items grow or
+// shrink from their basis in proportion to their flex factors, and any that
+// hit their min or max are frozen there and the rest redistributed, as in
+// CSS.  Any remaining space is distributed according to justify-content jc.
+func FlexResolveLine(items []FlexItem, avail, gap float32, jc Align) {
+	n := len(items)
+	if n == 0 {
+		return
+	}
+	avail -= float32(n-1) * gap
+	sumHyp := float32(0)
+	for i := range items {
+		it := &items[i]
+		it.Size = it.Hyp()
+		sumHyp += it.Size
+	}
+	grow := sumHyp < avail
+	for i := range items {
+		it := &items[i]
+		it.Frozen = (grow && (it.Grow == 0 || it.Basis > it.Size)) || (!grow && (it.Shrink == 0 || it.Basis < it.Size))
+	}
+
+	viol := make([]float32, n)
+	for {
+		free := avail
+		sumFlex := float32(0)
+		sumScaled := float32(0)
+		nflex := 0
+		for i := range items {
+			it := &items[i]
+			if it.Frozen {
+				free -= it.Size
+				continue
+			}
+			free -= it.Basis
+			nflex++
+			if grow {
+				sumFlex += it.Grow
+			} else {
+				sumFlex += it.Shrink
+				sumScaled += it.Shrink * it.Basis
+			}
+		}
+		if nflex == 0 || (!grow && sumScaled == 0) {
+			break
+		}
+		if sumFlex < 1 { // factors summing to < 1 only take that share
+			free *= sumFlex
+		}
+		totViol := float32(0)
+		for i := range items {
+			it := &items[i]
+			if it.Frozen {
+				continue
+			}
+			targ := it.Basis
+			if grow {
+				targ += free * it.Grow / sumFlex
+			} else {
+				targ += free * it.Shrink * it.Basis / sumScaled
+			}
+			it.Size = it.Clamp(targ)
+			viol[i] = it.Size - targ
+			totViol += viol[i]
+		}
+		for i := range items {
+			it := &items[i]
+			if it.Frozen {
+				continue
+			}
+			switch {
+			case math32.Abs(totViol) < 0.01:
+				it.Frozen = true
+			case totViol > 0 && viol[i] > 0: // min violations
+				it.Frozen = true
+			case totViol < 0 && viol[i] < 0: // max violations
+				it.Frozen = true
+			}
+		}
+	}
+
+	used := float32(0)
+	for i := range items {
+		used += items[i].Size
+	}
+	extra := avail - used
+	pos := float32(0)
+	between := gap
+	if extra > 0 {
+		switch {
+		case IsAlignMiddle(jc):
+			pos = 0.5 * extra
+		case IsAlignEnd(jc):
+			pos = extra
+		case jc == AlignJustify:
+			if n > 1 {
+				between += extra / float32(n-1)
+			}
+		case jc == AlignSpaceAround:
+			pos = 0.5 * extra / float32(n)
+			between += extra / float32(n)
+		}
+	}
+	for i := range items {
+		it := &items[i]
+		it.Pos = pos
+		pos += it.Size + between
+	}
+}
+
+// FlexAlignLines positions the lines along the cross axis, given the space
+// avail for all lines including gaps, distributing any extra space according
+// to align-content ac -- if reverse (wrap-reverse), lines go from the end of
+// the cross axis back toward the start.
+func FlexAlignLines(lines []FlexLine, avail, gap float32, ac Align, reverse bool) {
+	n := len(lines)
+	if n == 0 {
+		return
+	}
+	sum := float32(n-1) * gap
+	for i := range lines {
+		sum += lines[i].CrossSize
+	}
+	extra := avail - sum
+	pos := float32(0)
+	between := gap
+	if extra > 0 {
+		switch {
+		case ac == AlignStretch:
+			for i := range lines {
+				lines[i].CrossSize += extra / float32(n)
+			}
+		case IsAlignMiddle(ac):
+			pos = 0.5 * extra
+		case IsAlignEnd(ac):
+			pos = extra
+		case ac == AlignJustify:
+			if n > 1 {
+				between += extra / float32(n-1)
+			}
+		case ac == AlignSpaceAround:
+			pos = 0.5 * extra / float32(n)
+			between += extra / float32(n)
+		}
+	}
+	for i := range lines {
+		ln := &lines[i]
+		ln.CrossPos = pos
+		if reverse {
+			ln.CrossPos = avail - pos - ln.CrossSize
+		}
+		pos += ln.CrossSize + between
+	}
+}
+
+// FlexMainDim returns the main axis for a flex layout
+func (ly *Layout) FlexMainDim() Dims2D {
+	if ly.Lay == LayoutVertFlow {
+		return Y
+	}
+	return X
+}
+
+// FlexItemFor returns the flex sizing for given child along main dim md
+func (ly *Layout) FlexItemFor(ni *WidgetBase, md Dims2D) FlexItem {
+	lst := &ni.Sty.Layout
+	sz := &ni.LayData.Size
+	cd := OtherDim(md)
+	it := FlexItem{Basis: lst.FlexBasis.Dots, Min: sz.Need.Dim(md), Max: sz.Max.Dim(md), Grow: lst.FlexGrow, Shrink: lst.FlexShrink}
+	if it.Basis <= 0 {
+		it.Basis = sz.Pref.Dim(md)
+	}
+	it.Cross = sz.Pref.Dim(cd)
+	if cmax := sz.Max.Dim(cd); cmax > 0 && it.Cross > cmax {
+		it.Cross = cmax
+	}
+	it.Cross = Max32(it.Cross, sz.Need.Dim(cd))
+	return it
+}
+
+// GatherSizesFlex is size first pass: gather the size information from the
+// children, flex version -- when wrapping, the main-axis need is just the
+// largest item, and the cross-axis size comes from the lines of the previous
+// layout pass
+func (ly *Layout) GatherSizesFlex() {
+	md := ly.FlexMainDim()
+	cd := OtherDim(md)
+	var sumHyp, sumMin, maxMin, crossNeed, crossPref float32
+	n := 0
+	for _, c := range ly.Kids {
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		ni.LayData.UpdateSizes()
+		it := ly.FlexItemFor(ni, md)
+		sumHyp += it.Hyp()
+		sumMin += it.Min
+		maxMin = Max32(maxMin, it.Min)
+		crossNeed = Max32(crossNeed, ni.LayData.Size.Need.Dim(cd))
+		crossPref = Max32(crossPref, it.Cross)
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	gaps := float32(n-1) * ly.Spacing.Dots
+	mainNeed := sumMin + gaps
+	if ly.Sty.Layout.Wrap != FlexNoWrap {
+		mainNeed = maxMin
+		crossNeed = Max32(crossNeed, ly.FlexCrossSize)
+		crossPref = Max32(crossPref, ly.FlexCrossSize)
+	}
+
+	need := [Dims2DN]float32{}
+	pref := [Dims2DN]float32{}
+	need[md], pref[md] = mainNeed, sumHyp+gaps
+	need[cd], pref[cd] = crossNeed, crossPref
+	spc := ly.Sty.BoxSpace()
+	for d := X; d <= Y; d++ {
+		if ly.LayData.Size.Pref.Dim(d) == 0 {
+			ly.LayData.Size.Need.SetMaxDim(d, need[d]+2.0*spc)
+			ly.LayData.Size.Pref.SetMaxDim(d, pref[d]+2.0*spc)
+		} else { // use target size from style otherwise
+			ly.LayData.Size.Need.SetDim(d, ly.LayData.Size.Pref.Dim(d))
+		}
+	}
+
+	ly.LayData.UpdateSizes() // enforce max and normal ordering, etc
+	if Layout2DTrace {
+		fmt.Printf("Size:   %v gather sizes flex need: %v, pref: %v\n", ly.PathUnique(), ly.LayData.Size.Need, ly.LayData.Size.Pref)
+	}
+}
+
+// LayoutFlex manages overall flex layout of children -- returns true if
+// the wrapped lines changed the cross-axis size of the layout, so it needs
+// a redo to get the right size from its parent
+func (ly *Layout) LayoutFlex(iter int) bool {
+	md := ly.FlexMainDim()
+	cd := OtherDim(md)
+	var kids []*WidgetBase
+	var items []FlexItem
+	for _, c := range ly.Kids {
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		kids = append(kids, ni)
+		items = append(items, ly.FlexItemFor(ni, md))
+	}
+	if len(kids) == 0 {
+		return false
+	}
+	lst := &ly.Sty.Layout
+	gap := ly.Spacing.Dots
+	spc := ly.Sty.BoxSpace()
+	avail := ly.LayData.AllocSize.SubVal(2.0 * spc)
+
+	wrap := lst.Wrap != FlexNoWrap
+	lines := FlexWrapLines(items, avail.Dim(md), gap, wrap)
+	if !wrap { // single line fills the layout
+		lines[0].CrossSize = Max32(lines[0].CrossSize, avail.Dim(cd))
+	}
+	crossSize := float32(len(lines)-1) * gap
+	for i := range lines {
+		ln := &lines[i]
+		crossSize += ln.CrossSize
+		FlexResolveLine(items[ln.Start:ln.End], avail.Dim(md), gap, lst.JustifyContent)
+	}
+	FlexAlignLines(lines, avail.Dim(cd), gap, lst.AlignContent, lst.Wrap == FlexWrapReverse)
+
+	for _, ln := range lines {
+		for i := ln.Start; i < ln.End; i++ {
+			ni := kids[i]
+			it := &items[i]
+			ni.LayData.AllocSize.SetDim(md, it.Size)
+			ni.LayData.AllocPosRel.SetDim(md, spc+it.Pos)
+
+			need := ni.LayData.Size.Need.Dim(cd)
+			pref := ni.LayData.Size.Pref.Dim(cd)
+			max := ni.LayData.Size.Max.Dim(cd)
+			pos, size := ly.LayoutSharedDimImpl(ln.CrossSize, need, pref, max, 0, lst.AlignItems)
+			if max > 0 && size > max { // stretch stops at max
+				size = Max32(max, need)
+			}
+			ni.LayData.AllocSize.SetDim(cd, size)
+			ni.LayData.AllocPosRel.SetDim(cd, spc+ln.CrossPos+pos)
+			if Layout2DTrace {
+				fmt.Printf("Layout: %v flex Child: %v, pos: %v, size: %v\n", ly.PathUnique(), ni.UniqueNm, ni.LayData.AllocPosRel, ni.LayData.AllocSize)
+			}
+		}
+	}
+
+	if !wrap {
+		return false
+	}
+	redo := iter == 0 && math32.Abs(crossSize-ly.FlexCrossSize) > 0.5
+	ly.FlexCrossSize = crossSize
+	return redo
+}
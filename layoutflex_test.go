@@ -0,0 +1,110 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+)
+
+func testFlexSizes(t *testing.T, nm string, items []FlexItem, exp []float32) {
+	for i := range exp {
+		if d := items[i].Size - exp[i]; d > .01 || d < -.01 {
+			t.Errorf("%v item %v: expected size %v, got %v", nm, i, exp[i], items[i].Size)
+		}
+	}
+}
+
+func testFlexPos(t *testing.T, nm string, items []FlexItem, exp []float32) {
+	for i := range exp {
+		if d := items[i].Pos - exp[i]; d > .01 || d < -.01 {
+			t.Errorf("%v item %v: expected pos %v, got %v", nm, i, exp[i], items[i].Pos)
+		}
+	}
+}
+
+func TestFlexResolveLine(t *testing.T) {
+	items := []FlexItem{{Basis: 50, Grow: 1}, {Basis: 50, Grow: 2}, {Basis: 50}}
+	FlexResolveLine(items, 300, 0, AlignFlexStart)
+	testFlexSizes(t, "grow", items, []float32{100, 150, 50})
+	testFlexPos(t, "grow", items, []float32{0, 100, 250})
+
+	// first item hits its max, so the rest goes to the second
+	items = []FlexItem{{Basis: 50, Grow: 1, Max: 60}, {Basis: 50, Grow: 1}}
+	FlexResolveLine(items, 300, 0, AlignFlexStart)
+	testFlexSizes(t, "grow max", items, []float32{60, 240})
+
+	// shrink in proportion to basis, but not below min
+	items = []FlexItem{{Basis: 100, Shrink: 1, Min: 90}, {Basis: 200, Shrink: 1}}
+	FlexResolveLine(items, 150, 0, AlignFlexStart)
+	testFlexSizes(t, "shrink min", items, []float32{90, 60})
+
+	// growth factors summing to less than 1 only take that share
+	items = []FlexItem{{Basis: 50, Grow: 0.5}}
+	FlexResolveLine(items, 150, 0, AlignFlexStart)
+	testFlexSizes(t, "grow fraction", items, []float32{100})
+
+	items = []FlexItem{{Basis: 50}, {Basis: 50}}
+	FlexResolveLine(items, 200, 10, AlignJustify)
+	testFlexPos(t, "justify", items, []float32{0, 150})
+	FlexResolveLine(items, 200, 10, AlignCenter)
+	testFlexPos(t, "center", items, []float32{45, 105})
+	FlexResolveLine(items, 200, 10, AlignFlexEnd)
+	testFlexPos(t, "end", items, []float32{90, 150})
+	FlexResolveLine(items, 200, 10, AlignSpaceAround)
+	testFlexPos(t, "space around", items, []float32{22.5, 127.5})
+}
+
+func TestFlexWrapLines(t *testing.T) {
+	items := []FlexItem{{Basis: 40, Cross: 10}, {Basis: 40, Cross: 20}, {Basis: 40, Cross: 10}, {Basis: 20, Min: 40, Cross: 10}, {Basis: 40, Cross: 30}}
+	lines := FlexWrapLines(items, 100, 10, true)
+	exp := []FlexLine{{Start: 0, End: 2, CrossSize: 20}, {Start: 2, End: 4, CrossSize: 10}, {Start: 4, End: 5, CrossSize: 30}}
+	if len(lines) != len(exp) {
+		t.Fatalf("expected %v lines, got: %+v", len(exp), lines)
+	}
+	for i := range exp {
+		if lines[i] != exp[i] {
+			t.Errorf("line %v: expected %+v, got %+v", i, exp[i], lines[i])
+		}
+	}
+
+	lines = FlexWrapLines(items, 100, 10, false)
+	if len(lines) != 1 || lines[0].End != 5 || lines[0].CrossSize != 30 {
+		t.Errorf("expected a single line without wrap, got: %+v", lines)
+	}
+
+	// an item bigger than avail still gets its own line
+	lines = FlexWrapLines([]FlexItem{{Basis: 200}, {Basis: 10}}, 100, 0, true)
+	if len(lines) != 2 || lines[0].End != 1 {
+		t.Errorf("expected 2 lines, got: %+v", lines)
+	}
+}
+
+func TestFlexAlignLines(t *testing.T) {
+	mk := func() []FlexLine {
+		return []FlexLine{{CrossSize: 20}, {CrossSize: 10}, {CrossSize: 30}}
+	}
+	tests := []struct {
+		nm      string
+		al      Align
+		reverse bool
+		size    []float32
+		pos     []float32
+	}{
+		{"stretch", AlignStretch, false, []float32{30, 20, 40}, []float32{0, 35, 60}},
+		{"start", AlignFlexStart, false, []float32{20, 10, 30}, []float32{0, 25, 40}},
+		{"end", AlignFlexEnd, false, []float32{20, 10, 30}, []float32{30, 55, 70}},
+		{"justify", AlignJustify, false, []float32{20, 10, 30}, []float32{0, 40, 70}},
+		{"reverse", AlignFlexStart, true, []float32{20, 10, 30}, []float32{80, 65, 30}},
+	}
+	for _, ts := range tests {
+		lines := mk()
+		FlexAlignLines(lines, 100, 5, ts.al, ts.reverse)
+		for i := range lines {
+			if lines[i].CrossSize != ts.size[i] || lines[i].CrossPos != ts.pos[i] {
+				t.Errorf("%v line %v: expected size %v pos %v, got %+v", ts.nm, i, ts.size[i], ts.pos[i], lines[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,862 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ints"
+)
+
+// LayoutGridIrreg implements a grid along the lines of CSS Grid: the layout
+// specifies the sizes of its column and row tracks in grid-template-columns
+// and grid-template-rows, and optionally names areas of the grid in
+// grid-template-areas, e.g.:
+//
+//	"lay":                   gi.LayoutGridIrreg,
+//	"grid-template-columns": "120px 1fr minmax(100px, 2fr)",
+//	"grid-template-rows":    "auto 1fr auto",
+//	"grid-template-areas":   "'head head head' 'side main main' 'foot foot foot'",
+//
+// and each child declares where it goes with grid-row / grid-column (CSS
+// start / end lines, e.g., "2", "2 / 4", "1 / span 2", "1 / -1"), or
+// grid-area (an area name).  Children without a position are auto-placed
+// into the next free cells, row by row, adding implicit auto-sized rows as
+// needed.  The layout spacing is used as the gap between tracks.
+
+////////////////////////////////////////////////////////////////////////////////////////
+//     Track sizes
+
+// GridTrackSize is one limit of the sizing of a grid track: auto (sized to
+// the content), a fixed or percent units value, or a fraction (fr) of the
+// remaining space.
+type GridTrackSize struct {
+	Auto bool        `desc:"size to the content -- also used for min-content and max-content"`
+	Fr   float32     `desc:"if > 0, fraction of the remaining space (only valid as the max)"`
+	Val  units.Value `desc:"fixed size -- percent units are relative to the size of the layout"`
+}
+
+// IsFixed returns true if this is a fixed-size (non-percent) value
+func (gs *GridTrackSize) IsFixed() bool {
+	return !gs.Auto && gs.Fr == 0 && gs.Val.Un != units.Pct
+}
+
+// IsPct returns true if this is a percent value
+func (gs *GridTrackSize) IsPct() bool {
+	return !gs.Auto && gs.Fr == 0 && gs.Val.Un == units.Pct
+}
+
+// GridTrack specifies the sizing of one row or column of a LayoutGridIrreg
+// -- Min and Max are the same except for minmax(min, max).
+type GridTrack struct {
+	Min GridTrackSize `desc:"minimum size of the track"`
+	Max GridTrackSize `desc:"maximum size of the track"`
+}
+
+// GridTrackAuto is the sizing used for implicit tracks not in the template
+var GridTrackAuto = GridTrack{Min: GridTrackSize{Auto: true}, Max: GridTrackSize{Auto: true}}
+
+// gridTrack returns the track for given index, which is auto beyond those
+// specified
+func gridTrack(tracks []GridTrack, idx int) *GridTrack {
+	if idx < len(tracks) {
+		return &tracks[idx]
+	}
+	return &GridTrackAuto
+}
+
+// ParseGridTracks parses a grid-template-columns / rows track list, e.g.,
+// "100px 1fr auto minmax(50px, 2fr) repeat(3, 1fr) 20%"
+func ParseGridTracks(str string) ([]GridTrack, error) {
+	var tracks []GridTrack
+	for _, tok := range gridSplit(str, ' ') {
+		ltok := strings.ToLower(tok)
+		switch {
+		case strings.HasPrefix(ltok, "repeat("):
+			args := gridSplit(gridFuncArgs(tok), ',')
+			if len(args) != 2 {
+				return tracks, fmt.Errorf("gi.ParseGridTracks: repeat needs count and tracks: %v", tok)
+			}
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return tracks, fmt.Errorf("gi.ParseGridTracks: repeat count must be a positive integer: %v", tok)
+			}
+			rtr, err := ParseGridTracks(args[1])
+			if err != nil {
+				return tracks, err
+			}
+			for i := 0; i < n; i++ {
+				tracks = append(tracks, rtr...)
+			}
+		case strings.HasPrefix(ltok, "minmax("):
+			args := gridSplit(gridFuncArgs(tok), ',')
+			if len(args) != 2 {
+				return tracks, fmt.Errorf("gi.ParseGridTracks: minmax needs min and max: %v", tok)
+			}
+			var tr GridTrack
+			var err error
+			if tr.Min, err = ParseGridTrackSize(args[0]); err != nil {
+				return tracks, err
+			}
+			if tr.Max, err = ParseGridTrackSize(args[1]); err != nil {
+				return tracks, err
+			}
+			if tr.Min.Fr > 0 { // not valid as a min, per CSS
+				tr.Min = GridTrackSize{Auto: true}
+			}
+			tracks = append(tracks, tr)
+		default:
+			sz, err := ParseGridTrackSize(tok)
+			if err != nil {
+				return tracks, err
+			}
+			tr := GridTrack{Min: sz, Max: sz}
+			if sz.Fr > 0 { // fr tracks can shrink down to their content
+				tr.Min = GridTrackSize{Auto: true}
+			}
+			tracks = append(tracks, tr)
+		}
+	}
+	return tracks, nil
+}
+
+// ParseGridTrackSize parses a single track size: auto, min-content,
+// max-content, 1fr, or a units value such as 100px or 20%
+func ParseGridTrackSize(str string) (GridTrackSize, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	switch {
+	case str == "auto" || str == "min-content" || str == "max-content":
+		return GridTrackSize{Auto: true}, nil
+	case strings.HasSuffix(str, "fr"):
+		fr, err := strconv.ParseFloat(strings.TrimSpace(str[:len(str)-2]), 32)
+		if err != nil || fr <= 0 {
+			return GridTrackSize{Auto: true}, fmt.Errorf("gi.ParseGridTrackSize: invalid fr value: %v", str)
+		}
+		return GridTrackSize{Fr: float32(fr)}, nil
+	case len(str) > 0 && (strings.IndexByte("0123456789.", str[0]) >= 0 || strings.HasPrefix(str, "calc(")):
+		return GridTrackSize{Val: units.StringToValue(str)}, nil
+	}
+	return GridTrackSize{Auto: true}, fmt.Errorf("gi.ParseGridTrackSize: invalid track size: %v", str)
+}
+
+// gridSplit splits string at given separator, outside of any parentheses,
+// trimming space and skipping empty fields
+func gridSplit(str string, sep byte) []string {
+	var flds []string
+	depth := 0
+	st := 0
+	add := func(ed int) {
+		if fs := strings.TrimSpace(str[st:ed]); fs != "" {
+			flds = append(flds, fs)
+		}
+	}
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && (c == sep || (sep == ' ' && (c == '\t' || c == '\n'))):
+			add(i)
+			st = i + 1
+		}
+	}
+	add(len(str))
+	return flds
+}
+
+// gridFuncArgs returns the args within the parentheses of a function call
+func gridFuncArgs(str string) string {
+	st := strings.IndexByte(str, '(')
+	ed := strings.LastIndexByte(str, ')')
+	if st < 0 || ed < st {
+		return ""
+	}
+	return str[st+1 : ed]
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//     Template areas
+
+// ParseGridAreas parses grid-template-areas, a sequence of quoted rows, each
+// listing the area name of each column, with . for unnamed cells -- returns
+// the area rects (Min = start col, row; Max = end col, row, exclusive) and
+// the grid size (X = cols, Y = rows).  Each area must be a rectangle.
+func ParseGridAreas(str string) (map[string]image.Rectangle, image.Point, error) {
+	var rows [][]string
+	for {
+		st := strings.IndexAny(str, `"'`)
+		if st < 0 {
+			break
+		}
+		ed := strings.IndexByte(str[st+1:], str[st])
+		if ed < 0 {
+			return nil, image.ZP, fmt.Errorf("gi.ParseGridAreas: unterminated quote in: %v", str)
+		}
+		rows = append(rows, strings.Fields(str[st+1:st+1+ed]))
+		str = str[st+ed+2:]
+	}
+	if len(rows) == 0 {
+		return nil, image.ZP, nil
+	}
+	cols := len(rows[0])
+	areas := make(map[string]image.Rectangle)
+	cnt := make(map[string]int)
+	for r, rw := range rows {
+		if len(rw) != cols {
+			return nil, image.ZP, fmt.Errorf("gi.ParseGridAreas: row %v has %v columns instead of %v", r, len(rw), cols)
+		}
+		for c, nm := range rw {
+			if strings.Trim(nm, ".") == "" {
+				continue
+			}
+			cell := image.Rect(c, r, c+1, r+1)
+			if ar, has := areas[nm]; has {
+				areas[nm] = ar.Union(cell)
+			} else {
+				areas[nm] = cell
+			}
+			cnt[nm]++
+		}
+	}
+	for nm, ar := range areas {
+		if ar.Dx()*ar.Dy() != cnt[nm] {
+			return nil, image.ZP, fmt.Errorf("gi.ParseGridAreas: area %v is not a rectangle", nm)
+		}
+	}
+	return areas, image.Point{cols, len(rows)}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//     Placement
+
+// GridLine is one side of a grid placement: a 1-based line number as in CSS
+// (negative counts back from the end of the explicit grid), a span, or an
+// area name -- all zero / empty = auto.
+type GridLine struct {
+	Line int
+	Span int
+	Name string
+}
+
+// IsAuto returns true if the line is not specified
+func (gl *GridLine) IsAuto() bool {
+	return gl.Line == 0 && gl.Span == 0 && gl.Name == ""
+}
+
+// GridPlacement is the placement of an item along one dimension of the grid,
+// in terms of start and end lines
+type GridPlacement struct {
+	Start GridLine
+	End   GridLine
+}
+
+// ParseGridLine parses one side of a grid placement: auto, 3, -1, span 2, or
+// an area name
+func ParseGridLine(str string) (GridLine, error) {
+	str = strings.TrimSpace(str)
+	lstr := strings.ToLower(str)
+	switch {
+	case lstr == "" || lstr == "auto":
+		return GridLine{}, nil
+	case strings.HasPrefix(lstr, "span"):
+		n, err := strconv.Atoi(strings.TrimSpace(lstr[4:]))
+		if err != nil || n < 1 {
+			return GridLine{}, fmt.Errorf("gi.ParseGridLine: invalid span: %v", str)
+		}
+		return GridLine{Span: n}, nil
+	}
+	if n, err := strconv.Atoi(lstr); err == nil {
+		if n == 0 {
+			return GridLine{}, errors.New("gi.ParseGridLine: grid line 0 is not valid -- lines start at 1")
+		}
+		return GridLine{Line: n}, nil
+	}
+	return GridLine{Name: str}, nil
+}
+
+// ParseGridPlacement parses a grid-row or grid-column placement: start or
+// start / end
+func ParseGridPlacement(str string) (GridPlacement, error) {
+	var gp GridPlacement
+	var err error
+	parts := strings.Split(str, "/")
+	if len(parts) > 2 {
+		return gp, fmt.Errorf("gi.ParseGridPlacement: too many / in: %v", str)
+	}
+	if gp.Start, err = ParseGridLine(parts[0]); err != nil {
+		return gp, err
+	}
+	if len(parts) == 2 {
+		gp.End, err = ParseGridLine(parts[1])
+	}
+	return gp, err
+}
+
+// ParseGridArea parses a grid-area: an area name, or row-start / col-start /
+// row-end / col-end, returning the row and col placements
+func ParseGridArea(str string) (row, col GridPlacement, err error) {
+	parts := strings.Split(str, "/")
+	if len(parts) > 4 {
+		return row, col, fmt.Errorf("gi.ParseGridArea: too many / in: %v", str)
+	}
+	lns := make([]GridLine, 4)
+	for i, p := range parts {
+		if lns[i], err = ParseGridLine(p); err != nil {
+			return
+		}
+	}
+	if len(parts) == 1 && lns[0].Name != "" { // just an area name
+		lns[1] = lns[0]
+	}
+	row = GridPlacement{Start: lns[0], End: lns[2]}
+	col = GridPlacement{Start: lns[1], End: lns[3]}
+	return
+}
+
+// Resolve returns the 0-based starting track (-1 = auto) and span of this
+// placement, given the number of tracks in the explicit grid and the named
+// areas, along given row / col
+func (gp *GridPlacement) Resolve(ntracks int, areas map[string]image.Rectangle, rowcol RowCol) (start, span int) {
+	line := func(gl *GridLine, end bool) int { // 0-based line, -1 = not definite
+		if gl.Name != "" {
+			ar, has := areas[gl.Name]
+			if !has {
+				return -1
+			}
+			mn, mx := ar.Min.X, ar.Max.X
+			if rowcol == Row {
+				mn, mx = ar.Min.Y, ar.Max.Y
+			}
+			if end {
+				return mx
+			}
+			return mn
+		}
+		if gl.Line > 0 {
+			return gl.Line - 1
+		}
+		if gl.Line < 0 {
+			return ints.MaxInt(ntracks+1+gl.Line, 0)
+		}
+		return -1
+	}
+	st := line(&gp.Start, false)
+	ed := -1
+	if gp.End.IsAuto() && gp.Start.Name != "" { // area name alone spans the area
+		ed = line(&gp.Start, true)
+	} else {
+		ed = line(&gp.End, true)
+	}
+	switch {
+	case st >= 0 && ed >= 0:
+		if ed < st {
+			st, ed = ed, st
+		}
+		return st, ints.MaxInt(ed-st, 1)
+	case st >= 0:
+		return st, ints.MaxInt(gp.End.Span, 1)
+	case ed >= 0:
+		span := ints.MaxInt(gp.Start.Span, 1)
+		return ints.MaxInt(ed-span, 0), span
+	}
+	return -1, ints.MaxInt(ints.MaxInt(gp.Start.Span, gp.End.Span), 1)
+}
+
+// GridPlacements returns the row and col placements for this element within a
+// LayoutGridIrreg, from grid-area, grid-row, grid-column, or else row, col,
+// row-span, col-span (where row, col are 0-based indexes, used if > 0)
+func (ls *LayoutStyle) GridPlacements() (row, col GridPlacement, err error) {
+	if ls.GridArea != "" {
+		return ParseGridArea(ls.GridArea)
+	}
+	if ls.GridRow != "" {
+		if row, err = ParseGridPlacement(ls.GridRow); err != nil {
+			return
+		}
+	} else {
+		if ls.Row > 0 {
+			row.Start.Line = ls.Row + 1
+		}
+		if ls.RowSpan > 1 {
+			row.End.Span = ls.RowSpan
+		}
+	}
+	if ls.GridCol != "" {
+		col, err = ParseGridPlacement(ls.GridCol)
+	} else {
+		if ls.Col > 0 {
+			col.Start.Line = ls.Col + 1
+		}
+		if ls.ColSpan > 1 {
+			col.End.Span = ls.ColSpan
+		}
+	}
+	return
+}
+
+// GridPlaceItems places items on the grid given their resolved positions (X
+// = col, Y = row, -1 = auto) and spans, which are updated in place --
+// definitely-placed items go first, and then the rest are auto-placed in
+// order into the next free cells, row by row.  cols is the number of columns
+// in the explicit grid, which is expanded as needed for items that do not
+// fit.  Returns the resulting grid size.
+func GridPlaceItems(pos, span []image.Point, cols int) image.Point {
+	gsz := image.Point{cols, 0}
+	for i := range pos {
+		if pos[i].X >= 0 {
+			gsz.X = ints.MaxInt(gsz.X, pos[i].X+span[i].X)
+		} else {
+			gsz.X = ints.MaxInt(gsz.X, span[i].X)
+		}
+		if pos[i].Y >= 0 {
+			gsz.Y = ints.MaxInt(gsz.Y, pos[i].Y+span[i].Y)
+		}
+	}
+	if gsz.X == 0 {
+		gsz.X = ints.MaxInt(int(math32.Sqrt(float32(len(pos)))), 1) // same as LayoutGrid
+	}
+
+	var occ [][]bool // [row][col]
+	occupied := func(p, s image.Point) bool {
+		for r := p.Y; r < p.Y+s.Y && r < len(occ); r++ {
+			for c := p.X; c < p.X+s.X; c++ {
+				if occ[r][c] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	occupy := func(p, s image.Point) {
+		for len(occ) < p.Y+s.Y {
+			occ = append(occ, make([]bool, gsz.X))
+		}
+		for r := p.Y; r < p.Y+s.Y; r++ {
+			for c := p.X; c < p.X+s.X; c++ {
+				occ[r][c] = true
+			}
+		}
+	}
+
+	for i := range pos {
+		if pos[i].X >= 0 && pos[i].Y >= 0 {
+			occupy(pos[i], span[i])
+		}
+	}
+	cur := image.ZP // auto-placement cursor
+	for i := range pos {
+		p, s := pos[i], span[i]
+		switch {
+		case p.X >= 0 && p.Y >= 0:
+			continue
+		case p.Y >= 0: // fixed row: first free col
+			p.X = 0
+			for p.X+s.X < gsz.X && occupied(p, s) {
+				p.X++
+			}
+		case p.X >= 0: // fixed col: next free row from cursor
+			p.Y = cur.Y
+			if p.X < cur.X {
+				p.Y++
+			}
+			for occupied(p, s) {
+				p.Y++
+			}
+			cur = image.Point{p.X + s.X, p.Y}
+		default:
+			p = cur
+			for {
+				if p.X+s.X > gsz.X {
+					p.X = 0
+					p.Y++
+					continue
+				}
+				if !occupied(p, s) {
+					break
+				}
+				p.X++
+			}
+			cur = image.Point{p.X + s.X, p.Y}
+		}
+		pos[i] = p
+		occupy(p, s)
+	}
+	gsz.Y = ints.MaxInt(gsz.Y, len(occ))
+	return gsz
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//     Track sizing
+
+// GridItem is the placement and size of one item along one dimension, for
+// computing LayoutGridIrreg track sizes
+type GridItem struct {
+	Start   int
+	Span    int
+	Need    float32
+	Pref    float32
+	Stretch bool
+}
+
+// GridTrackSizes computes the SizeNeed, SizePref and SizeMax of each track
+// from the track sizes and the items placed in the tracks -- items spanning
+// one track go first, and then spanning items distribute any extra they need
+// equally among their content-sized tracks.  gap is the spacing between
+// tracks, and fixed track sizes must have their Dots computed.
+func GridTrackSizes(tracks []GridTrack, gds []GridData, items []GridItem, gap float32) {
+	for i := range gds {
+		tr := gridTrack(tracks, i)
+		gd := &gds[i]
+		*gd = GridData{}
+		if tr.Min.IsFixed() {
+			gd.SizeNeed = tr.Min.Val.Dots
+		}
+		if tr.Max.IsFixed() {
+			gd.SizePref = tr.Max.Val.Dots
+			gd.SizeMax = Max32(tr.Max.Val.Dots, gd.SizeNeed)
+		}
+		if tr.Max.Fr > 0 {
+			gd.SizeMax = -1
+		}
+	}
+	sitems := make([]GridItem, len(items))
+	copy(sitems, items)
+	sort.SliceStable(sitems, func(i, j int) bool {
+		return sitems[i].Span < sitems[j].Span
+	})
+	for _, it := range sitems {
+		ed := ints.MinInt(it.Start+it.Span, len(gds))
+		if it.Start < 0 || it.Start >= ed {
+			continue
+		}
+		var sumNeed, sumPref float32
+		var nneed, npref int
+		for i := it.Start; i < ed; i++ {
+			tr := gridTrack(tracks, i)
+			sumNeed += gds[i].SizeNeed
+			sumPref += gds[i].SizePref
+			if !tr.Min.IsFixed() {
+				nneed++
+			}
+			if !tr.Max.IsFixed() {
+				npref++
+			}
+		}
+		gaps := float32(ed-it.Start-1) * gap
+		exNeed := it.Need - (sumNeed + gaps)
+		exPref := it.Pref - (sumPref + gaps)
+		for i := it.Start; i < ed; i++ {
+			tr := gridTrack(tracks, i)
+			gd := &gds[i]
+			if exNeed > 0 && !tr.Min.IsFixed() {
+				gd.SizeNeed += exNeed / float32(nneed)
+			}
+			if exPref > 0 && !tr.Max.IsFixed() {
+				gd.SizePref += exPref / float32(npref)
+			}
+			if it.Stretch && tr.Max.Auto {
+				gd.SizeMax = -1
+			}
+		}
+	}
+	for i := range gds {
+		SetMax32(&gds[i].SizePref, gds[i].SizeNeed)
+	}
+}
+
+// GridTrackLayout allocates the size and position of each track, given the
+// total size available for the tracks, including the gaps between them,
+// starting at given position -- percent tracks get their share of avail,
+// other tracks get their preferred size if everything fits, and remaining
+// space goes to fr tracks in proportion to their fractions, or else to
+// stretchy tracks, or else is used for alignment (end or justify, as in
+// LayoutGrid).
+func GridTrackLayout(tracks []GridTrack, gds []GridData, pos, avail, gap float32, al Align) {
+	n := len(gds)
+	if n == 0 {
+		return
+	}
+	avail -= float32(n-1) * gap
+	var sumPref, sumNeed, frTot float32
+	for i := range gds {
+		tr := gridTrack(tracks, i)
+		gd := &gds[i]
+		switch {
+		case tr.Max.IsPct():
+			gd.AllocSize = Max32(0.01*tr.Max.Val.Val*avail, gd.SizeNeed)
+			sumPref += gd.AllocSize
+			sumNeed += gd.AllocSize
+		case tr.Max.Fr > 0:
+			frTot += tr.Max.Fr
+			sumPref += gd.SizeNeed
+			sumNeed += gd.SizeNeed
+		default:
+			sumPref += gd.SizePref
+			sumNeed += gd.SizeNeed
+		}
+	}
+	usePref := sumPref <= avail+0.1
+	for i := range gds {
+		tr := gridTrack(tracks, i)
+		gd := &gds[i]
+		switch {
+		case tr.Max.IsPct():
+		case tr.Max.Fr > 0 || !usePref:
+			gd.AllocSize = gd.SizeNeed
+		default:
+			gd.AllocSize = gd.SizePref
+		}
+	}
+	extra := avail - sumNeed
+	if usePref {
+		extra = avail - sumPref
+	}
+	switch {
+	case extra <= 0:
+		extra = 0
+	case !usePref: // grow toward pref in proportion to how much more they want
+		diffTot := sumPref - sumNeed
+		for i := range gds {
+			tr := gridTrack(tracks, i)
+			gd := &gds[i]
+			if diffTot > 0 && !tr.Max.IsPct() && tr.Max.Fr == 0 {
+				gd.AllocSize += extra * (gd.SizePref - gd.SizeNeed) / diffTot
+			}
+		}
+		extra = 0
+	case frTot > 0:
+		// find the size of 1fr: tracks whose need exceeds their share are
+		// treated as inflexible, and the rest share what is left
+		flex := make([]bool, n)
+		space := extra
+		for i := range gds {
+			if gridTrack(tracks, i).Max.Fr > 0 {
+				flex[i] = true
+				space += gds[i].SizeNeed
+			}
+		}
+		frSz := space / frTot
+		for again := true; again; {
+			again = false
+			for i := range gds {
+				fr := gridTrack(tracks, i).Max.Fr
+				if flex[i] && gds[i].SizeNeed > fr*frSz {
+					flex[i] = false
+					space -= gds[i].SizeNeed
+					frTot -= fr
+					again = true
+				}
+			}
+			if frTot <= 0 {
+				break
+			}
+			frSz = space / frTot
+		}
+		for i := range gds {
+			if flex[i] {
+				gds[i].AllocSize = gridTrack(tracks, i).Max.Fr * frSz
+			}
+		}
+		extra = 0
+	default:
+		var stretchTot float32
+		nstretch := 0
+		for i := range gds {
+			if gds[i].SizeMax < 0 {
+				nstretch++
+				stretchTot += gds[i].SizePref
+			}
+		}
+		for i := range gds {
+			gd := &gds[i]
+			if gd.SizeMax >= 0 {
+				continue
+			}
+			if stretchTot > 0 {
+				gd.AllocSize += extra * (gd.SizePref / stretchTot)
+			} else {
+				gd.AllocSize += extra / float32(nstretch)
+			}
+		}
+		if nstretch > 0 {
+			extra = 0
+		}
+	}
+
+	extraSpace := float32(0)
+	if extra > 0 {
+		if IsAlignEnd(al) {
+			pos += extra
+		} else if al == AlignJustify && n > 1 {
+			extraSpace = extra / float32(n-1)
+		}
+	}
+	for i := range gds {
+		gd := &gds[i]
+		gd.AllocPosRel = pos
+		pos += gd.AllocSize + gap + extraSpace
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//     Layout
+
+// ParseGridTemplate parses the grid-template styles of this LayoutGridIrreg
+// into GridTracks and GridAreas, computing the dots for the track sizes --
+// returns the size of the explicit grid (X = cols, Y = rows)
+func (ly *Layout) ParseGridTemplate() image.Point {
+	lst := &ly.Sty.Layout
+	var err error
+	if ly.GridAreas, _, err = ParseGridAreas(lst.GridTemplateAreas); err != nil {
+		log.Printf("gi.Layout %v: %v\n", ly.PathUnique(), err)
+	}
+	tmpls := [RowColN]string{lst.GridTemplateRows, lst.GridTemplateCols}
+	for rc := Row; rc < RowColN; rc++ {
+		if ly.GridTracks[rc], err = ParseGridTracks(tmpls[rc]); err != nil {
+			log.Printf("gi.Layout %v: %v\n", ly.PathUnique(), err)
+		}
+		for i := range ly.GridTracks[rc] {
+			tr := &ly.GridTracks[rc][i]
+			tr.Min.Val.ToDots(&ly.Sty.UnContext)
+			tr.Max.Val.ToDots(&ly.Sty.UnContext)
+		}
+	}
+	gsz := image.Point{ints.MaxInt(len(ly.GridTracks[Col]), lst.Columns), len(ly.GridTracks[Row])}
+	for _, ar := range ly.GridAreas {
+		gsz.X = ints.MaxInt(gsz.X, ar.Max.X)
+		gsz.Y = ints.MaxInt(gsz.Y, ar.Max.Y)
+	}
+	return gsz
+}
+
+// GatherSizesGridIrreg is size first pass: gather the size information from
+// the children, LayoutGridIrreg version -- places the children on the grid
+// and computes the track sizes
+func (ly *Layout) GatherSizesGridIrreg() {
+	gsz := ly.ParseGridTemplate()
+
+	var kids []*WidgetBase
+	for _, c := range ly.Kids {
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		kids = append(kids, ni)
+	}
+	pos := make([]image.Point, len(kids))
+	span := make([]image.Point, len(kids))
+	for i, ni := range kids {
+		row, col, err := ni.Sty.Layout.GridPlacements()
+		if err != nil {
+			log.Printf("gi.Layout %v: child %v: %v\n", ly.PathUnique(), ni.Nm, err)
+		}
+		pos[i].X, span[i].X = col.Resolve(gsz.X, ly.GridAreas, Col)
+		pos[i].Y, span[i].Y = row.Resolve(gsz.Y, ly.GridAreas, Row)
+	}
+	psz := GridPlaceItems(pos, span, gsz.X)
+	ly.GridSize = image.Point{psz.X, ints.MaxInt(psz.Y, gsz.Y)}
+
+	var items [RowColN][]GridItem
+	for i, ni := range kids {
+		ni.LayData.UpdateSizes()
+		ni.LayData.GridPos = pos[i]
+		ni.LayData.GridSpan = span[i]
+		sz := &ni.LayData.Size
+		items[Row] = append(items[Row], GridItem{Start: pos[i].Y, Span: span[i].Y, Need: sz.Need.Y, Pref: sz.Pref.Y, Stretch: sz.HasMaxStretch(Y)})
+		items[Col] = append(items[Col], GridItem{Start: pos[i].X, Span: span[i].X, Need: sz.Need.X, Pref: sz.Pref.X, Stretch: sz.HasMaxStretch(X)})
+	}
+
+	gn := [RowColN]int{ly.GridSize.Y, ly.GridSize.X}
+	spc := ly.Sty.BoxSpace()
+	for rc := Row; rc < RowColN; rc++ {
+		if len(ly.GridData[rc]) != gn[rc] {
+			ly.GridData[rc] = make([]GridData, gn[rc])
+		}
+		GridTrackSizes(ly.GridTracks[rc], ly.GridData[rc], items[rc], ly.Spacing.Dots)
+
+		dim := X
+		if rc == Row {
+			dim = Y
+		}
+		var sumNeed, sumPref float32
+		for _, gd := range ly.GridData[rc] {
+			sumNeed += gd.SizeNeed
+			sumPref += gd.SizePref
+		}
+		if gn[rc] > 1 {
+			sumNeed += float32(gn[rc]-1) * ly.Spacing.Dots
+			sumPref += float32(gn[rc]-1) * ly.Spacing.Dots
+		}
+		if ly.LayData.Size.Pref.Dim(dim) == 0 {
+			ly.LayData.Size.Need.SetMaxDim(dim, sumNeed+2.0*spc)
+			ly.LayData.Size.Pref.SetMaxDim(dim, sumPref+2.0*spc)
+		} else { // use target size from style otherwise
+			ly.LayData.Size.Need.SetDim(dim, ly.LayData.Size.Pref.Dim(dim))
+		}
+	}
+
+	ly.LayData.UpdateSizes() // enforce max and normal ordering, etc
+	if Layout2DTrace {
+		fmt.Printf("Size:   %v gather sizes grid irreg: %v need: %v, pref: %v\n", ly.PathUnique(), ly.GridSize, ly.LayData.Size.Need, ly.LayData.Size.Pref)
+	}
+}
+
+// LayoutGridIrreg manages overall LayoutGridIrreg layout of children:
+// allocates the tracks and then positions each child within its area
+func (ly *Layout) LayoutGridIrreg() {
+	if len(ly.Kids) == 0 {
+		return
+	}
+	spc := ly.Sty.BoxSpace()
+	for rc := Row; rc < RowColN; rc++ {
+		dim := X
+		if rc == Row {
+			dim = Y
+		}
+		avail := ly.LayData.AllocSize.Dim(dim) - 2.0*spc
+		GridTrackLayout(ly.GridTracks[rc], ly.GridData[rc], spc, avail, ly.Spacing.Dots, ly.Sty.Layout.AlignDim(dim))
+	}
+
+	for _, c := range ly.Kids {
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		lst := &ni.Sty.Layout
+		for dim := X; dim <= Y; dim++ {
+			gds := ly.GridData[Col]
+			st, sp := ni.LayData.GridPos.X, ni.LayData.GridSpan.X
+			if dim == Y {
+				gds = ly.GridData[Row]
+				st, sp = ni.LayData.GridPos.Y, ni.LayData.GridSpan.Y
+			}
+			ed := ints.MinInt(st+sp, len(gds)) - 1
+			if st < 0 || ed < st {
+				continue
+			}
+			apos := gds[st].AllocPosRel
+			avail := gds[ed].AllocPosRel + gds[ed].AllocSize - apos
+			pref := ni.LayData.Size.Pref.Dim(dim)
+			need := ni.LayData.Size.Need.Dim(dim)
+			max := ni.LayData.Size.Max.Dim(dim)
+			pos, size := ly.LayoutSharedDimImpl(avail, need, pref, max, 0, lst.AlignDim(dim))
+			ni.LayData.AllocSize.SetDim(dim, size)
+			ni.LayData.AllocPosRel.SetDim(dim, pos+apos)
+		}
+		if Layout2DTrace {
+			fmt.Printf("Layout: %v grid irreg pos: %v span: %v alloc pos: %v size: %v\n", ly.PathUnique(), ni.LayData.GridPos, ni.LayData.GridSpan, ni.LayData.AllocPosRel, ni.LayData.AllocSize)
+		}
+	}
+}
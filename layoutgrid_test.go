@@ -0,0 +1,217 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"testing"
+)
+
+func TestParseGridTracks(t *testing.T) {
+	tracks, err := ParseGridTracks("100px 1fr auto minmax(50px, 2fr) repeat(2, 20% minmax(auto, 30px))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 8 {
+		t.Fatalf("expected 8 tracks, got %v", len(tracks))
+	}
+	if !tracks[0].Min.IsFixed() || tracks[0].Max.Val.Val != 100 {
+		t.Errorf("track 0 should be fixed 100: %+v", tracks[0])
+	}
+	if !tracks[1].Min.Auto || tracks[1].Max.Fr != 1 {
+		t.Errorf("track 1 should be auto / 1fr: %+v", tracks[1])
+	}
+	if !tracks[2].Min.Auto || !tracks[2].Max.Auto {
+		t.Errorf("track 2 should be auto: %+v", tracks[2])
+	}
+	if tracks[3].Min.Val.Val != 50 || tracks[3].Max.Fr != 2 {
+		t.Errorf("track 3 should be minmax(50px, 2fr): %+v", tracks[3])
+	}
+	if !tracks[4].Max.IsPct() || tracks[6].Max.Val.Val != 20 {
+		t.Errorf("tracks 4, 6 should be 20%%: %+v %+v", tracks[4], tracks[6])
+	}
+	if !tracks[7].Min.Auto || tracks[7].Max.Val.Val != 30 {
+		t.Errorf("track 7 should be minmax(auto, 30px): %+v", tracks[7])
+	}
+	for _, bad := range []string{"1fr foo", "repeat(0, 1fr)", "minmax(1px)", "-1fr"} {
+		if _, err := ParseGridTracks(bad); err == nil {
+			t.Errorf("expected error for: %v", bad)
+		}
+	}
+}
+
+func TestParseGridAreas(t *testing.T) {
+	areas, sz, err := ParseGridAreas(`"head head head" 'side main main' ". foot foot"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sz != image.Pt(3, 3) {
+		t.Errorf("size should be 3x3: %v", sz)
+	}
+	exp := map[string]image.Rectangle{
+		"head": image.Rect(0, 0, 3, 1),
+		"side": image.Rect(0, 1, 1, 2),
+		"main": image.Rect(1, 1, 3, 2),
+		"foot": image.Rect(1, 2, 3, 3),
+	}
+	if len(areas) != len(exp) {
+		t.Errorf("expected %v areas, got: %v", len(exp), areas)
+	}
+	for nm, ar := range exp {
+		if areas[nm] != ar {
+			t.Errorf("area %v: expected %v, got %v", nm, ar, areas[nm])
+		}
+	}
+	if _, _, err := ParseGridAreas(`"a b" "b a"`); err == nil {
+		t.Error("expected error for non-rectangular areas")
+	}
+	if _, _, err := ParseGridAreas(`"a b" "a"`); err == nil {
+		t.Error("expected error for ragged rows")
+	}
+}
+
+func TestGridPlacementResolve(t *testing.T) {
+	areas := map[string]image.Rectangle{"main": image.Rect(1, 1, 3, 2)}
+	tests := []struct {
+		str         string
+		rowcol      RowCol
+		start, span int
+	}{
+		{"2", Col, 1, 1},
+		{"2 / 4", Col, 1, 2},
+		{"4 / 2", Col, 1, 2},
+		{"2 / span 3", Col, 1, 3},
+		{"span 2", Col, -1, 2},
+		{"span 2 / 4", Col, 1, 2},
+		{"1 / -1", Col, 0, 4},
+		{"auto", Col, -1, 1},
+		{"main", Col, 1, 2},
+		{"main", Row, 1, 1},
+		{"nope", Row, -1, 1},
+	}
+	for _, ts := range tests {
+		gp, err := ParseGridPlacement(ts.str)
+		if err != nil {
+			t.Errorf("%v: %v", ts.str, err)
+			continue
+		}
+		st, sp := gp.Resolve(4, areas, ts.rowcol)
+		if st != ts.start || sp != ts.span {
+			t.Errorf("%v: expected start %v span %v, got %v %v", ts.str, ts.start, ts.span, st, sp)
+		}
+	}
+	row, col, err := ParseGridArea("2 / 1 / 4 / span 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rst, rsp := row.Resolve(4, nil, Row)
+	cst, csp := col.Resolve(4, nil, Col)
+	if rst != 1 || rsp != 2 || cst != 0 || csp != 2 {
+		t.Errorf("grid-area: got row %v %v col %v %v", rst, rsp, cst, csp)
+	}
+	if _, err := ParseGridPlacement("0"); err == nil {
+		t.Error("expected error for line 0")
+	}
+}
+
+func TestGridPlaceItems(t *testing.T) {
+	// header across the top, then auto items flowing around a 2x2 block
+	pos := []image.Point{{0, 0}, {-1, -1}, {1, 1}, {-1, -1}, {-1, -1}, {-1, 3}}
+	span := []image.Point{{3, 1}, {1, 1}, {2, 2}, {1, 1}, {1, 1}, {2, 1}}
+	gsz := GridPlaceItems(pos, span, 3)
+	exp := []image.Point{{0, 0}, {0, 1}, {1, 1}, {0, 2}, {0, 3}, {1, 3}}
+	for i := range exp {
+		if pos[i] != exp[i] {
+			t.Errorf("item %v: expected %v, got %v", i, exp[i], pos[i])
+		}
+	}
+	if gsz != image.Pt(3, 4) {
+		t.Errorf("grid size should be 3x4: %v", gsz)
+	}
+
+	// no columns specified: grows to fit the widest item
+	pos = []image.Point{{-1, -1}, {-1, -1}}
+	span = []image.Point{{2, 1}, {1, 1}}
+	gsz = GridPlaceItems(pos, span, 0)
+	if gsz.X != 2 || pos[1] != image.Pt(0, 1) {
+		t.Errorf("expected 2 cols with second item on row 1: %v %v", gsz, pos)
+	}
+}
+
+// testGridTracks parses tracks, using px values directly as dots
+func testGridTracks(t *testing.T, str string) []GridTrack {
+	tracks, err := ParseGridTracks(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range tracks {
+		tracks[i].Min.Val.Dots = tracks[i].Min.Val.Val
+		tracks[i].Max.Val.Dots = tracks[i].Max.Val.Val
+	}
+	return tracks
+}
+
+func TestGridTrackSizes(t *testing.T) {
+	tracks := testGridTracks(t, "100px auto 1fr")
+	gds := make([]GridData, 3)
+	items := []GridItem{
+		{Start: 0, Span: 1, Need: 20, Pref: 200},
+		{Start: 1, Span: 1, Need: 30, Pref: 50},
+		{Start: 2, Span: 1, Need: 10, Pref: 40},
+		{Start: 1, Span: 2, Need: 100, Pref: 150},
+	}
+	GridTrackSizes(tracks, gds, items, 10)
+	// spanning item needs 100 - 10 gap - (30 + 10) = 50 more, split over 2 tracks
+	expNeed := []float32{100, 55, 35}
+	expPref := []float32{100, 75, 65}
+	for i := range gds {
+		if gds[i].SizeNeed != expNeed[i] || gds[i].SizePref != expPref[i] {
+			t.Errorf("track %v: expected need %v pref %v, got %+v", i, expNeed[i], expPref[i], gds[i])
+		}
+	}
+	if gds[2].SizeMax >= 0 {
+		t.Errorf("fr track should stretch: %+v", gds[2])
+	}
+
+	GridTrackLayout(tracks, gds, 5, 400, 10, AlignLeft)
+	// 400 - 20 gaps = 380: fixed 100, auto pref 75, fr gets the remaining 205
+	expSize := []float32{100, 75, 205}
+	expPos := []float32{5, 115, 200}
+	for i := range gds {
+		if gds[i].AllocSize != expSize[i] || gds[i].AllocPosRel != expPos[i] {
+			t.Errorf("track %v: expected pos %v size %v, got %+v", i, expPos[i], expSize[i], gds[i])
+		}
+	}
+
+	// fr tracks share in proportion, except where they need more
+	tracks = testGridTracks(t, "1fr 2fr 1fr")
+	gds = make([]GridData, 3)
+	GridTrackSizes(tracks, gds, []GridItem{{Start: 0, Span: 1, Need: 150, Pref: 150}}, 0)
+	GridTrackLayout(tracks, gds, 0, 400, 0, AlignLeft)
+	expSize = []float32{150, 500.0 / 3, 250.0 / 3}
+	for i := range gds {
+		if d := gds[i].AllocSize - expSize[i]; d > .01 || d < -.01 {
+			t.Errorf("fr track %v: expected size %v, got %v", i, expSize[i], gds[i].AllocSize)
+		}
+	}
+
+	// not enough room for pref: need plus a share of the rest
+	tracks = testGridTracks(t, "auto auto")
+	gds = make([]GridData, 2)
+	GridTrackSizes(tracks, gds, []GridItem{{Start: 0, Span: 1, Need: 10, Pref: 110}, {Start: 1, Span: 1, Need: 10, Pref: 30}}, 0)
+	GridTrackLayout(tracks, gds, 0, 80, 0, AlignLeft)
+	if gds[0].AllocSize != 60 || gds[1].AllocSize != 20 {
+		t.Errorf("expected sizes 60, 20, got %v, %v", gds[0].AllocSize, gds[1].AllocSize)
+	}
+
+	// percent of avail, and end alignment of leftover space
+	tracks = testGridTracks(t, "25% 50px")
+	gds = make([]GridData, 2)
+	GridTrackSizes(tracks, gds, nil, 0)
+	GridTrackLayout(tracks, gds, 0, 200, 0, AlignRight)
+	if gds[0].AllocSize != 50 || gds[0].AllocPosRel != 100 || gds[1].AllocPosRel != 150 {
+		t.Errorf("expected pct size 50 aligned to end, got %+v", gds)
+	}
+}
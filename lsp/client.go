@@ -0,0 +1,273 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lsp provides a Language Server Protocol client, using JSON-RPC
+// over the stdin / stdout of a language server process
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// DiagnosticsFunc is called with the current diagnostics for a document,
+// whenever the server publishes them -- it is called on the goroutine
+// reading from the server
+type DiagnosticsFunc func(uri string, diags []Diagnostic)
+
+// Client is a Language Server Protocol client, connected to one server,
+// which can have any number of documents open
+type Client struct {
+	Conn     *Conn                `desc:"the JSON-RPC connection to the server"`
+	Cmd      *exec.Cmd            `desc:"the server process, if started by StartClient"`
+	RootURI  string               `desc:"URI of the root directory of the workspace"`
+	SyncKind TextDocumentSyncKind `desc:"how the server wants documents to be synced, from its capabilities"`
+	Timeout  time.Duration        `desc:"maximum time to wait for completion, hover and definition results, which are typically waited for interactively"`
+	mu       sync.Mutex
+	diags    map[string]DiagnosticsFunc
+}
+
+// ClientTimeout is the default Timeout for new clients
+var ClientTimeout = 2 * time.Second
+
+// NewClient returns a new client over given stream to the server, which
+// must still be initialized with Initialize
+func NewClient(rwc io.ReadWriteCloser) *Client {
+	cl := &Client{Timeout: ClientTimeout, diags: make(map[string]DiagnosticsFunc)}
+	cl.Conn = NewConn(rwc, cl.handle)
+	return cl
+}
+
+// cmdPipe combines the stdin and stdout pipes of a server process
+type cmdPipe struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (cp *cmdPipe) Close() error {
+	cp.WriteCloser.Close()
+	return cp.ReadCloser.Close()
+}
+
+// StartClient starts the language server process given by command line,
+// in given root directory, and returns an initialized client for it
+func StartClient(cmdline []string, rootDir string) (*Client, error) {
+	if len(cmdline) == 0 {
+		return nil, fmt.Errorf("lsp.StartClient: no server command")
+	}
+	cmd := exec.Command(cmdline[0], cmdline[1:]...)
+	cmd.Dir = rootDir
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	cl := NewClient(&cmdPipe{ReadCloser: out, WriteCloser: in})
+	cl.Cmd = cmd
+	if err := cl.Initialize(rootDir); err != nil {
+		cl.Conn.Close()
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	return cl, nil
+}
+
+// clientCaps are the capabilities the client announces to the server
+var clientCaps = map[string]interface{}{
+	"textDocument": map[string]interface{}{
+		"synchronization":    map[string]interface{}{"didSave": true},
+		"completion":         map[string]interface{}{"completionItem": map[string]interface{}{"snippetSupport": false}},
+		"hover":              map[string]interface{}{"contentFormat": []string{"plaintext", "markdown"}},
+		"definition":         map[string]interface{}{},
+		"publishDiagnostics": map[string]interface{}{},
+	},
+}
+
+// Initialize does the initialize handshake with the server, for the
+// workspace at given root directory, and gets its capabilities
+func (cl *Client) Initialize(rootDir string) error {
+	cl.RootURI = FileURI(rootDir)
+	params := map[string]interface{}{
+		"processId":    os.Getpid(),
+		"rootUri":      cl.RootURI,
+		"capabilities": clientCaps,
+	}
+	var res struct {
+		Capabilities struct {
+			TextDocumentSync json.RawMessage `json:"textDocumentSync"`
+		} `json:"capabilities"`
+	}
+	if err := cl.Conn.Call("initialize", params, &res); err != nil {
+		return err
+	}
+	cl.SyncKind = SyncFull
+	tds := res.Capabilities.TextDocumentSync
+	var kind TextDocumentSyncKind
+	var opts struct {
+		Change TextDocumentSyncKind `json:"change"`
+	}
+	if json.Unmarshal(tds, &kind) == nil {
+		cl.SyncKind = kind
+	} else if json.Unmarshal(tds, &opts) == nil {
+		cl.SyncKind = opts.Change
+	}
+	return cl.Conn.Notify("initialized", struct{}{})
+}
+
+// Shutdown asks the server to shut down and exit, and closes the connection
+func (cl *Client) Shutdown() error {
+	err := cl.Conn.Call("shutdown", nil, nil)
+	cl.Conn.Notify("exit", nil)
+	if cl.Cmd != nil {
+		wdone := make(chan struct{})
+		go func() {
+			cl.Cmd.Wait()
+			close(wdone)
+		}()
+		select {
+		case <-wdone:
+		case <-time.After(2 * time.Second):
+			cl.Cmd.Process.Kill()
+		}
+	}
+	cl.Conn.Close()
+	return err
+}
+
+// handle handles notifications and requests from the server
+func (cl *Client) handle(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "textDocument/publishDiagnostics":
+		var pd PublishDiagnosticsParams
+		if err := json.Unmarshal(params, &pd); err != nil {
+			return nil, err
+		}
+		cl.mu.Lock()
+		fun := cl.diags[pd.URI]
+		cl.mu.Unlock()
+		if fun != nil {
+			fun(pd.URI, pd.Diagnostics)
+		}
+	case "workspace/configuration":
+		var cp struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(params, &cp)
+		return make([]interface{}, len(cp.Items)), nil
+	}
+	return nil, nil // requests we don't support get a null result
+}
+
+// DidOpen tells the server that given document is open, with given full
+// text, and registers the function to receive its diagnostics (can be nil)
+func (cl *Client) DidOpen(uri, langID string, version int, text string, diagFun DiagnosticsFunc) error {
+	cl.mu.Lock()
+	cl.diags[uri] = diagFun
+	cl.mu.Unlock()
+	return cl.Conn.Notify("textDocument/didOpen", &DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: langID, Version: version, Text: text}})
+}
+
+// DidChange sends the changes to given document, which has the given new
+// version -- changes are applied in order
+func (cl *Client) DidChange(uri string, version int, changes []TextDocumentContentChangeEvent) error {
+	return cl.Conn.Notify("textDocument/didChange", &DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: version},
+		ContentChanges: changes})
+}
+
+// DidSave tells the server that given document was saved
+func (cl *Client) DidSave(uri string) error {
+	return cl.Conn.Notify("textDocument/didSave", &DidSaveTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: uri}})
+}
+
+// DidClose tells the server that given document was closed
+func (cl *Client) DidClose(uri string) error {
+	cl.mu.Lock()
+	delete(cl.diags, uri)
+	cl.mu.Unlock()
+	return cl.Conn.Notify("textDocument/didClose", &DidCloseTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: uri}})
+}
+
+// NDocs returns the number of documents open on the server
+func (cl *Client) NDocs() int {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return len(cl.diags)
+}
+
+// Completion returns the possible completions at given position in document
+func (cl *Client) Completion(uri string, pos Position) ([]CompletionItem, error) {
+	var res json.RawMessage
+	err := cl.Conn.CallTimeout("textDocument/completion", &TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri}, Position: pos}, &res, cl.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	var items []CompletionItem
+	if json.Unmarshal(res, &items) == nil {
+		return items, nil
+	}
+	var lst completionList
+	if err := json.Unmarshal(res, &lst); err != nil {
+		return nil, err
+	}
+	return lst.Items, nil
+}
+
+// Hover returns the hover text for given position in document -- empty if
+// there is none
+func (cl *Client) Hover(uri string, pos Position) (string, error) {
+	var res *hover
+	err := cl.Conn.CallTimeout("textDocument/hover", &TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri}, Position: pos}, &res, cl.Timeout)
+	if err != nil || res == nil {
+		return "", err
+	}
+	return hoverText(res.Contents), nil
+}
+
+// Definition returns the locations of the definition of the symbol at given
+// position in document
+func (cl *Client) Definition(uri string, pos Position) ([]Location, error) {
+	var res json.RawMessage
+	err := cl.Conn.CallTimeout("textDocument/definition", &TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri}, Position: pos}, &res, cl.Timeout)
+	if err != nil || len(res) == 0 || string(res) == "null" {
+		return nil, err
+	}
+	var loc Location
+	if json.Unmarshal(res, &loc) == nil && loc.URI != "" {
+		return []Location{loc}, nil
+	}
+	var lls []locationLink
+	if err := json.Unmarshal(res, &lls); err != nil {
+		return nil, err
+	}
+	locs := make([]Location, 0, len(lls))
+	for _, ll := range lls {
+		if ll.TargetURI != "" {
+			locs = append(locs, Location{URI: ll.TargetURI, Range: ll.TargetSelectionRange})
+		}
+	}
+	if len(locs) == 0 { // were Locations after all
+		json.Unmarshal(res, &locs)
+	}
+	return locs, nil
+}
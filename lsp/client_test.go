@@ -0,0 +1,73 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	fs, cl := NewFakeServer()
+	defer cl.Shutdown()
+	if cl.SyncKind != SyncIncremental {
+		t.Errorf("SyncKind: %v != %v", cl.SyncKind, SyncIncremental)
+	}
+	uri := FileURI("test.txt")
+	dch := make(chan []Diagnostic, 10)
+	cl.DidOpen(uri, "plaintext", 1, "hello wörld\n𝔸 error here", func(uri string, diags []Diagnostic) {
+		dch <- diags
+	})
+	diags := waitDiags(t, dch)
+	if len(diags) != 1 || diags[0].Range.Start != (Position{Line: 1, Character: 3}) {
+		t.Errorf("diagnostics after open: %+v", diags)
+	}
+	rng := &Range{Start: Position{Line: 0, Character: 6}, End: Position{Line: 1, Character: 2}}
+	cl.DidChange(uri, 2, []TextDocumentContentChangeEvent{{Range: rng, Text: "there\nerror"}})
+	diags = waitDiags(t, dch)
+	if exp := "hello there\nerror error here"; fs.Text(uri) != exp {
+		t.Errorf("text after change: %q != %q", fs.Text(uri), exp)
+	}
+	if len(diags) != 2 {
+		t.Errorf("diagnostics after change: %+v", diags)
+	}
+
+	items, err := cl.Completion(uri, Position{Line: 0, Character: 8})
+	if err != nil || len(items) != 1 || items[0].Label != "there" {
+		t.Errorf("completion: %+v, %v", items, err)
+	}
+	hv, err := cl.Hover(uri, Position{Line: 1, Character: 13})
+	if err != nil || hv != "here" {
+		t.Errorf("hover: %q, %v", hv, err)
+	}
+	locs, err := cl.Definition(uri, Position{Line: 1, Character: 8})
+	if err != nil || len(locs) != 1 || locs[0].Range.Start != (Position{Line: 1, Character: 0}) {
+		t.Errorf("definition: %+v, %v", locs, err)
+	}
+	cl.DidClose(uri)
+	if cl.NDocs() != 0 {
+		t.Errorf("NDocs after close: %d", cl.NDocs())
+	}
+}
+
+func waitDiags(t *testing.T, dch chan []Diagnostic) []Diagnostic {
+	select {
+	case diags := <-dch:
+		return diags
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for diagnostics")
+	}
+	return nil
+}
+
+func TestFileURI(t *testing.T) {
+	uri := FileURI("/tmp/a b/c.go")
+	if uri != "file:///tmp/a%20b/c.go" {
+		t.Errorf("FileURI: %v", uri)
+	}
+	if pth := URIPath(uri); pth != "/tmp/a b/c.go" {
+		t.Errorf("URIPath: %v", pth)
+	}
+}
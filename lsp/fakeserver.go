@@ -0,0 +1,195 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf16"
+)
+
+// FakeServer is a minimal in-process language server, for testing clients
+// without running a real server.  It keeps the text of open documents,
+// applying changes, publishes an error diagnostic for each occurrence of the
+// word "error", completes words in the document by prefix, returns the word
+// as hover text, and the first occurrence of the word as its definition.
+type FakeServer struct {
+	Conn *Conn
+	mu   sync.Mutex
+	docs map[string][]string
+}
+
+// NewFakeServer returns a new fake server and an initialized client
+// connected to it
+func NewFakeServer() (*FakeServer, *Client) {
+	cp, sp := net.Pipe()
+	fs := &FakeServer{docs: make(map[string][]string)}
+	fs.Conn = NewConn(sp, fs.handle)
+	cl := NewClient(cp)
+	cl.Initialize(".")
+	return fs, cl
+}
+
+// Text returns the current text of given document on the server
+func (fs *FakeServer) Text(uri string) string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return strings.Join(fs.docs[uri], "\n")
+}
+
+func (fs *FakeServer) handle(method string, params json.RawMessage) (interface{}, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	switch method {
+	case "initialize":
+		return map[string]interface{}{"capabilities": map[string]interface{}{
+			"textDocumentSync": map[string]interface{}{"openClose": true, "change": SyncIncremental}}}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var p DidOpenTextDocumentParams
+		json.Unmarshal(params, &p)
+		fs.docs[p.TextDocument.URI] = strings.Split(p.TextDocument.Text, "\n")
+		fs.publish(p.TextDocument.URI)
+	case "textDocument/didChange":
+		var p DidChangeTextDocumentParams
+		json.Unmarshal(params, &p)
+		uri := p.TextDocument.URI
+		for _, ch := range p.ContentChanges {
+			fs.docs[uri] = applyChange(fs.docs[uri], ch)
+		}
+		fs.publish(uri)
+	case "textDocument/didClose":
+		var p DidCloseTextDocumentParams
+		json.Unmarshal(params, &p)
+		delete(fs.docs, p.TextDocument.URI)
+	case "textDocument/completion":
+		var p TextDocumentPositionParams
+		json.Unmarshal(params, &p)
+		lns := fs.docs[p.TextDocument.URI]
+		word, _, _ := wordAt(lns, p.Position, false)
+		var items []CompletionItem
+		seen := map[string]bool{word: true}
+		for _, ln := range lns {
+			for _, w := range strings.FieldsFunc(ln, notWordRune) {
+				if strings.HasPrefix(w, word) && !seen[w] {
+					seen[w] = true
+					items = append(items, CompletionItem{Label: w, Kind: CompletionVariable})
+				}
+			}
+		}
+		return completionList{Items: items}, nil
+	case "textDocument/hover":
+		var p TextDocumentPositionParams
+		json.Unmarshal(params, &p)
+		word, st, ed := wordAt(fs.docs[p.TextDocument.URI], p.Position, true)
+		if word == "" {
+			return nil, nil
+		}
+		return map[string]interface{}{"contents": markupContent{Kind: "plaintext", Value: word},
+			"range": Range{Start: Position{p.Position.Line, st}, End: Position{p.Position.Line, ed}}}, nil
+	case "textDocument/definition":
+		var p TextDocumentPositionParams
+		json.Unmarshal(params, &p)
+		lns := fs.docs[p.TextDocument.URI]
+		word, _, _ := wordAt(lns, p.Position, true)
+		if word == "" {
+			return nil, nil
+		}
+		for li, ln := range lns {
+			if rng, ok := findWord(ln, word); ok {
+				return []Location{{URI: p.TextDocument.URI, Range: Range{Start: Position{li, rng[0]}, End: Position{li, rng[1]}}}}, nil
+			}
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
+
+// publish sends the diagnostics for given document
+func (fs *FakeServer) publish(uri string) {
+	diags := []Diagnostic{}
+	for li, ln := range fs.docs[uri] {
+		u16 := utf16.Encode([]rune(ln))
+		for st := 0; ; {
+			rng, ok := findWord(string(utf16.Decode(u16[st:])), "error")
+			if !ok {
+				break
+			}
+			diags = append(diags, Diagnostic{Range: Range{Start: Position{li, st + rng[0]}, End: Position{li, st + rng[1]}},
+				Severity: SeverityError, Source: "fake", Message: "found error"})
+			st += rng[1]
+		}
+	}
+	fs.Conn.Notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{URI: uri, Diagnostics: diags})
+}
+
+// applyChange applies a change event to lines of text
+func applyChange(lns []string, ch TextDocumentContentChangeEvent) []string {
+	if ch.Range == nil {
+		return strings.Split(ch.Text, "\n")
+	}
+	st, ed := ch.Range.Start, ch.Range.End
+	if st.Line >= len(lns) || ed.Line >= len(lns) {
+		return lns
+	}
+	sl := utf16.Encode([]rune(lns[st.Line]))
+	el := utf16.Encode([]rune(lns[ed.Line]))
+	pre := string(utf16.Decode(sl[:st.Character]))
+	post := string(utf16.Decode(el[ed.Character:]))
+	nls := strings.Split(pre+ch.Text+post, "\n")
+	res := make([]string, 0, len(lns)+len(nls))
+	res = append(res, lns[:st.Line]...)
+	res = append(res, nls...)
+	return append(res, lns[ed.Line+1:]...)
+}
+
+func notWordRune(r rune) bool {
+	return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// wordAt returns the word at given position, with its start and end in
+// UTF-16 units -- only the part before the position unless whole
+func wordAt(lns []string, pos Position, whole bool) (string, int, int) {
+	if pos.Line >= len(lns) {
+		return "", 0, 0
+	}
+	u16 := utf16.Encode([]rune(lns[pos.Line]))
+	if pos.Character > len(u16) {
+		return "", 0, 0
+	}
+	st := pos.Character
+	for st > 0 && !notWordRune(rune(u16[st-1])) {
+		st--
+	}
+	ed := pos.Character
+	if whole {
+		for ed < len(u16) && !notWordRune(rune(u16[ed])) {
+			ed++
+		}
+	}
+	return string(utf16.Decode(u16[st:ed])), st, ed
+}
+
+// findWord returns the UTF-16 range of the first whole-word occurrence of
+// word in line
+func findWord(ln, word string) ([2]int, bool) {
+	rs := []rune(ln)
+	wr := []rune(word)
+	for i := 0; i+len(wr) <= len(rs); i++ {
+		if string(rs[i:i+len(wr)]) != word {
+			continue
+		}
+		if (i > 0 && !notWordRune(rs[i-1])) || (i+len(wr) < len(rs) && !notWordRune(rs[i+len(wr)])) {
+			continue
+		}
+		st := len(utf16.Encode(rs[:i]))
+		return [2]int{st, st + len(utf16.Encode(wr))}, true
+	}
+	return [2]int{}, false
+}
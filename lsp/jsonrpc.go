@@ -0,0 +1,287 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned for calls on a connection that has been closed
+var ErrClosed = errors.New("lsp: connection closed")
+
+// ErrTimeout is returned for calls that did not get a response within the
+// Conn Timeout
+var ErrTimeout = errors.New("lsp: call timed out")
+
+// Error is an error response from the other side of a connection
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("lsp: error %d: %s", e.Code, e.Message)
+}
+
+// standard JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeMethodNotFound = -32601
+	CodeInternalError  = -32603
+)
+
+// Handler handles requests and notifications from the other side of a
+// connection -- the result is sent back for requests, and ignored for
+// notifications, which have no id.  Handlers are called in order on the
+// goroutine reading the connection, so they must not block on calls over
+// the same connection.
+type Handler func(method string, params json.RawMessage) (interface{}, error)
+
+// message is a JSON-RPC 2.0 request, notification or response
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *Error           `json:"error,omitempty"`
+}
+
+// Conn is a JSON-RPC 2.0 connection over a stream, such as the stdin /
+// stdout of a language server process, using the LSP base protocol framing
+// of a Content-Length header before each message -- it works symmetrically,
+// so it can be used for either side of the connection
+type Conn struct {
+	Timeout time.Duration `desc:"maximum time to wait for the response to a call -- 0 = wait forever"`
+	Handler Handler       `desc:"handler for requests and notifications from the other side"`
+	rwc     io.ReadWriteCloser
+	wmu     sync.Mutex
+	mu      sync.Mutex
+	seq     int64
+	pending map[int64]chan *message
+	closed  bool
+	done    chan struct{}
+}
+
+// ConnTimeout is the default Timeout for new connections
+var ConnTimeout = 10 * time.Second
+
+// NewConn returns a new connection over given stream, with given handler
+// for messages from the other side (can be nil), and starts reading from it
+func NewConn(rwc io.ReadWriteCloser, handler Handler) *Conn {
+	cn := &Conn{Timeout: ConnTimeout, Handler: handler, rwc: rwc}
+	cn.pending = make(map[int64]chan *message)
+	cn.done = make(chan struct{})
+	go cn.readLoop()
+	return cn
+}
+
+// Call sends a request with given method and params, and waits for the
+// response, which is decoded into result unless that is nil
+func (cn *Conn) Call(method string, params, result interface{}) error {
+	return cn.CallTimeout(method, params, result, cn.Timeout)
+}
+
+// CallTimeout is Call with given maximum time to wait for the response,
+// instead of the Conn Timeout -- 0 = wait forever
+func (cn *Conn) CallTimeout(method string, params, result interface{}, timeout time.Duration) error {
+	cn.mu.Lock()
+	if cn.closed {
+		cn.mu.Unlock()
+		return ErrClosed
+	}
+	cn.seq++
+	id := cn.seq
+	rch := make(chan *message, 1)
+	cn.pending[id] = rch
+	cn.mu.Unlock()
+
+	rid := json.RawMessage(strconv.FormatInt(id, 10))
+	msg := &message{ID: &rid, Method: method}
+	err := cn.send(msg, params)
+	if err == nil {
+		var tmo <-chan time.Time
+		if timeout > 0 {
+			tm := time.NewTimer(timeout)
+			defer tm.Stop()
+			tmo = tm.C
+		}
+		select {
+		case rsp, ok := <-rch:
+			switch {
+			case !ok:
+				return ErrClosed
+			case rsp.Error != nil:
+				return rsp.Error
+			case result != nil && len(rsp.Result) > 0:
+				return json.Unmarshal(rsp.Result, result)
+			}
+			return nil
+		case <-tmo:
+			err = ErrTimeout
+		}
+	}
+	cn.mu.Lock()
+	delete(cn.pending, id)
+	cn.mu.Unlock()
+	return err
+}
+
+// Notify sends a notification with given method and params, which has no
+// response
+func (cn *Conn) Notify(method string, params interface{}) error {
+	return cn.send(&message{Method: method}, params)
+}
+
+// Close closes the connection and the underlying stream -- any pending
+// calls return ErrClosed
+func (cn *Conn) Close() error {
+	err := cn.rwc.Close()
+	cn.shutdown()
+	return err
+}
+
+// Done returns a channel that is closed when the connection is closed,
+// including by the other side
+func (cn *Conn) Done() <-chan struct{} {
+	return cn.done
+}
+
+// shutdown marks the connection as closed and releases pending calls
+func (cn *Conn) shutdown() {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	if cn.closed {
+		return
+	}
+	cn.closed = true
+	for id, rch := range cn.pending {
+		close(rch)
+		delete(cn.pending, id)
+	}
+	close(cn.done)
+}
+
+// send encodes params into message and writes it with its header
+func (cn *Conn) send(msg *message, params interface{}) error {
+	msg.JSONRPC = "2.0"
+	if params != nil {
+		pb, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = pb
+	}
+	return cn.write(msg)
+}
+
+// write writes message with its Content-Length header
+func (cn *Conn) write(msg *message) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	cn.wmu.Lock()
+	defer cn.wmu.Unlock()
+	if _, err := fmt.Fprintf(cn.rwc, "Content-Length: %d\r\n\r\n", len(b)); err != nil {
+		return err
+	}
+	_, err = cn.rwc.Write(b)
+	return err
+}
+
+// readLoop reads and dispatches messages until the stream is closed
+func (cn *Conn) readLoop() {
+	defer cn.shutdown()
+	rd := textproto.NewReader(bufio.NewReader(cn.rwc))
+	for {
+		hdr, err := rd.ReadMIMEHeader()
+		if err != nil {
+			if err != io.EOF && !cn.isClosed() {
+				log.Printf("lsp.Conn read error: %v\n", err)
+			}
+			return
+		}
+		sz, err := strconv.Atoi(hdr.Get("Content-Length"))
+		if err != nil || sz < 0 {
+			log.Printf("lsp.Conn bad Content-Length header: %v\n", hdr.Get("Content-Length"))
+			return
+		}
+		b := make([]byte, sz)
+		if _, err := io.ReadFull(rd.R, b); err != nil {
+			return
+		}
+		msg := &message{}
+		if err := json.Unmarshal(b, msg); err != nil {
+			log.Printf("lsp.Conn bad message: %v\n", err)
+			continue
+		}
+		cn.dispatch(msg)
+	}
+}
+
+// isClosed returns true if the connection has been closed
+func (cn *Conn) isClosed() bool {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	return cn.closed
+}
+
+// dispatch sends a response to its pending call, or calls the Handler for
+// requests and notifications, and sends back the response to requests
+func (cn *Conn) dispatch(msg *message) {
+	if msg.Method == "" {
+		if msg.ID == nil {
+			return
+		}
+		id, err := strconv.ParseInt(string(*msg.ID), 10, 64)
+		if err != nil {
+			return
+		}
+		cn.mu.Lock()
+		rch, ok := cn.pending[id]
+		delete(cn.pending, id)
+		cn.mu.Unlock()
+		if ok {
+			rch <- msg
+		}
+		return
+	}
+	var res interface{}
+	var err error
+	if cn.Handler != nil {
+		res, err = cn.Handler(msg.Method, msg.Params)
+	} else {
+		err = &Error{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	if msg.ID == nil { // notification
+		return
+	}
+	rsp := &message{JSONRPC: "2.0", ID: msg.ID}
+	if err != nil {
+		rerr, ok := err.(*Error)
+		if !ok {
+			rerr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		rsp.Error = rerr
+	} else {
+		rsp.Result, err = json.Marshal(res)
+		if err != nil {
+			rsp.Result = nil
+			rsp.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+	}
+	cn.write(rsp)
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// This file has the subset of the Language Server Protocol types used by
+// the Client -- see https://microsoft.github.io/language-server-protocol
+
+// Position is a zero-based line and character offset within a document --
+// Character counts UTF-16 code units, as per the protocol
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a range within a document -- End is exclusive
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range within a given document
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// locationLink is the alternative form of definition results
+type locationLink struct {
+	TargetURI            string `json:"targetUri"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// TextDocumentIdentifier identifies a document by its URI
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// VersionedTextDocumentIdentifier identifies a given version of a document
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+// TextDocumentItem is a document opened on the client, with its full text
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+// TextDocumentContentChangeEvent is a change to a document -- if Range is
+// nil, Text is the full new text of the document, otherwise it replaces the
+// Range
+type TextDocumentContentChangeEvent struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+// TextDocumentPositionParams are the params for requests at a position
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// DidOpenTextDocumentParams are the params for textDocument/didOpen
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+// DidChangeTextDocumentParams are the params for textDocument/didChange
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+// DidSaveTextDocumentParams are the params for textDocument/didSave
+type DidSaveTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// DidCloseTextDocumentParams are the params for textDocument/didClose
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// TextDocumentSyncKind is how documents are synced to the server
+type TextDocumentSyncKind int
+
+const (
+	// SyncNone means documents are not synced
+	SyncNone TextDocumentSyncKind = iota
+
+	// SyncFull means the full text is sent on each change
+	SyncFull
+
+	// SyncIncremental means only the changed ranges are sent
+	SyncIncremental
+)
+
+// CompletionItemKind is the kind of a completion
+type CompletionItemKind int
+
+// the completion kinds that are distinguished by the client
+const (
+	CompletionMethod    CompletionItemKind = 2
+	CompletionFunction  CompletionItemKind = 3
+	CompletionField     CompletionItemKind = 5
+	CompletionVariable  CompletionItemKind = 6
+	CompletionClass     CompletionItemKind = 7
+	CompletionInterface CompletionItemKind = 8
+	CompletionModule    CompletionItemKind = 9
+	CompletionKeyword   CompletionItemKind = 14
+	CompletionConstant  CompletionItemKind = 21
+	CompletionStruct    CompletionItemKind = 22
+)
+
+// CompletionItem is one possible completion
+type CompletionItem struct {
+	Label      string             `json:"label"`
+	Kind       CompletionItemKind `json:"kind,omitempty"`
+	Detail     string             `json:"detail,omitempty"`
+	InsertText string             `json:"insertText,omitempty"`
+}
+
+// completionList is the alternative form of completion results
+type completionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+// DiagnosticSeverity is the severity of a diagnostic
+type DiagnosticSeverity int
+
+const (
+	SeverityNone DiagnosticSeverity = iota
+	SeverityError
+	SeverityWarning
+	SeverityInfo
+	SeverityHint
+)
+
+// Diagnostic is an error, warning etc for a range of a document
+type Diagnostic struct {
+	Range    Range              `json:"range"`
+	Severity DiagnosticSeverity `json:"severity,omitempty"`
+	Source   string             `json:"source,omitempty"`
+	Message  string             `json:"message"`
+}
+
+// PublishDiagnosticsParams are the params for
+// textDocument/publishDiagnostics from the server
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// markupContent is hover text, or the value of a marked string
+type markupContent struct {
+	Kind     string `json:"kind,omitempty"`
+	Language string `json:"language,omitempty"`
+	Value    string `json:"value"`
+}
+
+// hover is the result of textDocument/hover
+type hover struct {
+	Contents json.RawMessage `json:"contents"`
+	Range    *Range          `json:"range,omitempty"`
+}
+
+// hoverText returns the text of hover contents, which can be a
+// MarkupContent, a MarkedString (string or language, value pair), or a
+// list of MarkedStrings
+func hoverText(contents json.RawMessage) string {
+	var s string
+	if json.Unmarshal(contents, &s) == nil {
+		return s
+	}
+	var mc markupContent
+	if json.Unmarshal(contents, &mc) == nil && mc.Value != "" {
+		return mc.Value
+	}
+	var lst []json.RawMessage
+	if json.Unmarshal(contents, &lst) == nil {
+		txt := make([]string, 0, len(lst))
+		for _, c := range lst {
+			if t := hoverText(c); t != "" {
+				txt = append(txt, t)
+			}
+		}
+		return strings.Join(txt, "\n")
+	}
+	return ""
+}
+
+// FileURI returns the file:// URI for given file path
+func FileURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// URIPath returns the file path for given file:// URI, or the URI itself if
+// it is not a file URI
+func URIPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
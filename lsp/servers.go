@@ -0,0 +1,107 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Servers are the command lines for the language servers for each language,
+// by the language names used for syntax highlighting (chroma lexer names)
+// -- add or change entries to configure other servers
+var Servers = map[string][]string{
+	"Go":         {"gopls"},
+	"Python":     {"pyls"},
+	"C":          {"clangd"},
+	"C++":        {"clangd"},
+	"Rust":       {"rls"},
+	"JavaScript": {"typescript-language-server", "--stdio"},
+	"TypeScript": {"typescript-language-server", "--stdio"},
+}
+
+// LangIDs are the LSP language identifiers for language names that are not
+// just the lower-case name -- see LangID
+var LangIDs = map[string]string{
+	"C++": "cpp",
+}
+
+// LangID returns the LSP language identifier for given language name
+func LangID(lang string) string {
+	if id, ok := LangIDs[lang]; ok {
+		return id
+	}
+	return strings.ToLower(lang)
+}
+
+// RootMarkers are the names of files or directories that mark the root
+// directory of a workspace -- see FindRoot
+var RootMarkers = []string{".git", "go.mod", ".hg", ".svn"}
+
+// FindRoot returns the root directory of the workspace for given file: the
+// nearest enclosing directory with one of the RootMarkers, or else the
+// directory of the file
+func FindRoot(filename string) string {
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return filepath.Dir(filename)
+	}
+	for d := dir; ; {
+		for _, mk := range RootMarkers {
+			if _, err := os.Stat(filepath.Join(d, mk)); err == nil {
+				return d
+			}
+		}
+		pd := filepath.Dir(d)
+		if pd == d {
+			return dir
+		}
+		d = pd
+	}
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = map[string]*Client{}
+)
+
+// ClientFor returns the running client for the server for given language
+// and workspace root directory, starting it if needed -- there is one
+// client per server command and root, shared by all documents
+func ClientFor(lang, rootDir string) (*Client, error) {
+	cmdline, ok := Servers[lang]
+	if !ok || len(cmdline) == 0 {
+		return nil, fmt.Errorf("lsp.ClientFor: no language server for language: %v", lang)
+	}
+	key := strings.Join(cmdline, " ") + "\t" + rootDir
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if cl, ok := clients[key]; ok {
+		select {
+		case <-cl.Conn.Done(): // server has exited -- restart
+		default:
+			return cl, nil
+		}
+	}
+	cl, err := StartClient(cmdline, rootDir)
+	if err != nil {
+		return nil, err
+	}
+	clients[key] = cl
+	return cl, nil
+}
+
+// ShutdownAll shuts down all the clients started by ClientFor
+func ShutdownAll() {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	for key, cl := range clients {
+		cl.Shutdown()
+		delete(clients, key)
+	}
+}
@@ -530,3 +530,11 @@ func (sp *Separator) Render2D() {
 		sp.PopBounds()
 	}
 }
+
+// AccessInfo returns the accessibility info for the separator
+func (sp *Separator) AccessInfo() AccessInfo {
+	ai := sp.WidgetBase.AccessInfo()
+	ai.Role = RoleSeparator
+	ai.SetState(!sp.Horiz, AccessVertical)
+	return ai
+}
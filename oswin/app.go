@@ -153,6 +153,10 @@ const (
 	// Windows is a Microsoft Windows machine
 	Windows
 
+	// Offscreen is the headless in-memory driver, with no physical display --
+	// used for testing and server-side rendering
+	Offscreen
+
 	PlatformsN
 )
 
@@ -44,6 +44,11 @@
 //
 // Complete examples can be found in the gi/examples directory.
 //
+// The offscreen driver renders entirely into memory, with no display, and
+// lets a test harness inject synthetic events and retrieve the rendered
+// images -- build with the offscreen tag (go test -tags offscreen) to make
+// it the default driver, or call offscreen.Main directly.
+//
 // Each driver package provides App, Screen, Image, Texture and Window
 // implementations that work together. Such types are interface types because
 // this package is driver-independent, but those interfaces aren't expected to
@@ -12,16 +12,20 @@
 // +build !windows
 // +build !dragonfly
 // +build !openbsd
+// +build !offscreen
 
 package driver
 
 import (
-	"errors"
+	"log"
 
 	"github.com/goki/gi/oswin"
-	"github.com/goki/gi/oswin/driver/internal/errscreen"
+	"github.com/goki/gi/oswin/driver/offscreen"
 )
 
+// no hardware driver available on this platform -- use the offscreen driver
+// so that apps can still run (e.g., for testing or rendering to images)
 func main(f func(oswin.App)) {
-	f(errscreen.Stub(errors.New("no driver for accessing a screen")))
+	log.Println("oswin: no hardware driver available on this platform -- using the offscreen driver, windows will not be displayed")
+	offscreen.Main(f)
 }
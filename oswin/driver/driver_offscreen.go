@@ -0,0 +1,18 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build offscreen
+
+package driver
+
+import (
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+)
+
+// the offscreen build tag selects the headless in-memory driver on all
+// platforms, e.g., for running tests: go test -tags offscreen
+func main(f func(oswin.App)) {
+	offscreen.Main(f)
+}
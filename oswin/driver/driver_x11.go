@@ -7,7 +7,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// +build linux,!android dragonfly openbsd
+// +build linux,!android,!offscreen dragonfly,!offscreen openbsd,!offscreen
 
 package driver
 
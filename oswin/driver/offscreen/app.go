@@ -0,0 +1,333 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/clip"
+	"github.com/goki/gi/oswin/cursor"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki/bitflag"
+)
+
+// PrefsPath is the directory returned by App.PrefsDir -- if empty, a
+// GoGiOffscreen directory under os.TempDir() is used, so that tests never
+// read or write the user's actual preferences.
+var PrefsPath = ""
+
+type appImpl struct {
+	mu            sync.Mutex
+	windows       []*windowImpl
+	screens       []*oswin.Screen
+	ctxtwin       *windowImpl
+	focuswin      *windowImpl
+	name          string
+	about         string
+	openedURLs    []string
+	quitting      bool
+	quitReqFunc   func()
+	quitCleanFunc func()
+}
+
+var theApp *appImpl
+
+func newAppImpl() *appImpl {
+	app := &appImpl{
+		windows: make([]*windowImpl, 0),
+		name:    "GoGi",
+	}
+	ldpi := ScreenLogicalDPI
+	if ldpi == 0 {
+		ldpi = ScreenPhysicalDPI
+	}
+	const mmPerInch = 25.4
+	sc := &oswin.Screen{
+		ScreenNumber:     0,
+		Geometry:         image.Rectangle{Max: ScreenSize},
+		Depth:            32,
+		LogicalDPI:       ldpi,
+		PhysicalDPI:      ScreenPhysicalDPI,
+		DevicePixelRatio: 1,
+		RefreshRate:      60,
+		Name:             "offscreen:0",
+	}
+	sc.PhysicalSize = image.Point{
+		int(mmPerInch * float32(ScreenSize.X) / ScreenPhysicalDPI),
+		int(mmPerInch * float32(ScreenSize.Y) / ScreenPhysicalDPI),
+	}
+	app.screens = []*oswin.Screen{sc}
+	theCursor = cursorImpl{CursorBase: cursor.CursorBase{Vis: true}}
+	theClip = clipImpl{}
+
+	oswin.TheApp = app
+	theApp = app
+	return app
+}
+
+func (app *appImpl) NewImage(size image.Point) (oswin.Image, error) {
+	return newImageImpl(size), nil
+}
+
+func (app *appImpl) NewTexture(win oswin.Window, size image.Point) (oswin.Texture, error) {
+	return newTextureImpl(size), nil
+}
+
+func (app *appImpl) NewWindow(opts *oswin.NewWindowOptions) (oswin.Window, error) {
+	if opts == nil {
+		opts = &oswin.NewWindowOptions{}
+	}
+	opts.Fixup()
+	if opts.Size.X <= 0 || opts.Size.Y <= 0 {
+		return nil, fmt.Errorf("offscreen: invalid window size: %v", opts.Size)
+	}
+
+	sc := app.Screen(0)
+	w := &windowImpl{
+		app: app,
+		img: image.NewRGBA(image.Rectangle{Max: opts.Size}),
+		WindowBase: oswin.WindowBase{
+			Titl:    opts.GetTitle(),
+			Sz:      opts.Size,
+			Pos:     opts.Pos,
+			PhysDPI: sc.PhysicalDPI,
+			LogDPI:  sc.LogicalDPI,
+			Scrn:    sc,
+			Flag:    opts.Flags,
+		},
+	}
+	bitflag.Clear(&w.Flag, int(oswin.Minimized))
+
+	app.mu.Lock()
+	app.windows = append(app.windows, w)
+	app.mu.Unlock()
+
+	sendWindowEvent(w, window.Paint)
+	app.setFocus(w)
+	return w, nil
+}
+
+// setFocus makes given window the focus window, sending Focus / DeFocus
+// events as appropriate -- nil clears the focus.
+func (app *appImpl) setFocus(w *windowImpl) {
+	app.mu.Lock()
+	prv := app.focuswin
+	app.focuswin = w
+	app.mu.Unlock()
+	if prv == w {
+		return
+	}
+	if prv != nil {
+		bitflag.Clear(&prv.Flag, int(oswin.Focus))
+		sendWindowEvent(prv, window.DeFocus)
+	}
+	if w != nil {
+		bitflag.Set(&w.Flag, int(oswin.Focus))
+		sendWindowEvent(w, window.Focus)
+	}
+}
+
+func (app *appImpl) deleteWin(w *windowImpl) {
+	app.mu.Lock()
+	for i, wl := range app.windows {
+		if wl == w {
+			app.windows = append(app.windows[:i], app.windows[i+1:]...)
+			break
+		}
+	}
+	if app.ctxtwin == w {
+		app.ctxtwin = nil
+	}
+	refocus := app.focuswin == w
+	if refocus {
+		app.focuswin = nil
+	}
+	nw := len(app.windows)
+	app.mu.Unlock()
+	if refocus && nw > 0 {
+		app.setFocus(app.windows[nw-1])
+	}
+}
+
+func (app *appImpl) NScreens() int {
+	return len(app.screens)
+}
+
+func (app *appImpl) Screen(scrN int) *oswin.Screen {
+	if scrN < 0 || scrN >= len(app.screens) {
+		return nil
+	}
+	return app.screens[scrN]
+}
+
+func (app *appImpl) NWindows() int {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	return len(app.windows)
+}
+
+func (app *appImpl) Window(win int) oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if win < 0 || win >= len(app.windows) {
+		return nil
+	}
+	return app.windows[win]
+}
+
+func (app *appImpl) WindowByName(name string) oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for _, win := range app.windows {
+		if win.Name() == name {
+			return win
+		}
+	}
+	return nil
+}
+
+func (app *appImpl) WindowInFocus() oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if app.focuswin == nil {
+		return nil
+	}
+	return app.focuswin
+}
+
+func (app *appImpl) ContextWindow() oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if app.ctxtwin == nil {
+		return nil
+	}
+	return app.ctxtwin
+}
+
+func (app *appImpl) Platform() oswin.Platforms {
+	return oswin.Offscreen
+}
+
+func (app *appImpl) Name() string {
+	return app.name
+}
+
+func (app *appImpl) SetName(name string) {
+	app.name = name
+}
+
+func (app *appImpl) PrefsDir() string {
+	if PrefsPath != "" {
+		return PrefsPath
+	}
+	return filepath.Join(os.TempDir(), "GoGiOffscreen")
+}
+
+func (app *appImpl) GoGiPrefsDir() string {
+	pdir := filepath.Join(app.PrefsDir(), "GoGi")
+	os.MkdirAll(pdir, 0755)
+	return pdir
+}
+
+func (app *appImpl) AppPrefsDir() string {
+	pdir := filepath.Join(app.PrefsDir(), app.Name())
+	os.MkdirAll(pdir, 0755)
+	return pdir
+}
+
+func (app *appImpl) FontPaths() []string {
+	return []string{"/usr/share/fonts/truetype", "/Library/Fonts", "C:\\Windows\\Fonts"}
+}
+
+func (app *appImpl) ClipBoard(win oswin.Window) clip.Board {
+	app.mu.Lock()
+	app.ctxtwin, _ = win.(*windowImpl)
+	app.mu.Unlock()
+	return &theClip
+}
+
+func (app *appImpl) Cursor(win oswin.Window) cursor.Cursor {
+	app.mu.Lock()
+	app.ctxtwin, _ = win.(*windowImpl)
+	app.mu.Unlock()
+	return &theCursor
+}
+
+func (app *appImpl) About() string {
+	return app.about
+}
+
+func (app *appImpl) SetAbout(about string) {
+	app.about = about
+}
+
+// OpenURL just records the url, which can be retrieved via OpenedURLs --
+// there is no browser to open it in.
+func (app *appImpl) OpenURL(url string) {
+	app.mu.Lock()
+	app.openedURLs = append(app.openedURLs, url)
+	app.mu.Unlock()
+}
+
+func (app *appImpl) SetQuitReqFunc(fun func()) {
+	app.quitReqFunc = fun
+}
+
+func (app *appImpl) SetQuitCleanFunc(fun func()) {
+	app.quitCleanFunc = fun
+}
+
+func (app *appImpl) QuitReq() {
+	if app.quitting {
+		return
+	}
+	if app.quitReqFunc != nil {
+		app.quitReqFunc()
+	} else {
+		app.Quit()
+	}
+}
+
+func (app *appImpl) IsQuitting() bool {
+	return app.quitting
+}
+
+func (app *appImpl) QuitClean() {
+	app.quitting = true
+	if app.quitCleanFunc != nil {
+		app.quitCleanFunc()
+	}
+	app.mu.Lock()
+	wins := make([]*windowImpl, len(app.windows))
+	copy(wins, app.windows)
+	app.mu.Unlock()
+	for i := len(wins) - 1; i >= 0; i-- {
+		wins[i].Close()
+	}
+}
+
+func (app *appImpl) Quit() {
+	app.QuitClean()
+}
+
+// OpenedURLs returns the list of urls passed to App.OpenURL so far.
+func OpenedURLs() []string {
+	if theApp == nil {
+		return nil
+	}
+	theApp.mu.Lock()
+	defer theApp.mu.Unlock()
+	urls := make([]string, len(theApp.openedURLs))
+	copy(urls, theApp.openedURLs)
+	return urls
+}
+
+// check for interface implementation
+var _ oswin.App = &appImpl{}
@@ -0,0 +1,58 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"sync"
+
+	"github.com/goki/gi/oswin/mimedata"
+)
+
+// clipImpl is a purely in-process clipboard
+type clipImpl struct {
+	mu   sync.Mutex
+	data mimedata.Mimes
+}
+
+var theClip = clipImpl{}
+
+func (ci *clipImpl) IsEmpty() bool {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	return len(ci.data) == 0
+}
+
+func (ci *clipImpl) Read(types []string) mimedata.Mimes {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	if len(ci.data) == 0 {
+		return nil
+	}
+	if types == nil {
+		return ci.data
+	}
+	var rval mimedata.Mimes
+	for _, typ := range types {
+		for _, d := range ci.data {
+			if d.Type == typ || (typ == mimedata.TextAny && mimedata.IsText(d.Type)) {
+				rval = append(rval, d)
+			}
+		}
+	}
+	return rval
+}
+
+func (ci *clipImpl) Write(data mimedata.Mimes) error {
+	ci.mu.Lock()
+	ci.data = data
+	ci.mu.Unlock()
+	return nil
+}
+
+func (ci *clipImpl) Clear() {
+	ci.mu.Lock()
+	ci.data = nil
+	ci.mu.Unlock()
+}
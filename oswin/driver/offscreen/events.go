@@ -0,0 +1,307 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/gi/oswin/window"
+)
+
+// This file contains the functions used by a test harness to drive offscreen
+// windows: injecting synthetic mouse, key and window events, and retrieving
+// the rendered image.  All of them take the generic oswin.Window and return
+// an error (or do nothing) if it is not an offscreen window.
+
+// lastMouse records the last mouse position and press event for each window,
+// to fill in the From fields of move events and detect double-clicks
+type lastMouse struct {
+	pos   image.Point
+	press *mouse.Event
+	but   mouse.Buttons
+}
+
+func asImpl(win oswin.Window) (*windowImpl, error) {
+	w, ok := win.(*windowImpl)
+	if !ok || w == nil {
+		return nil, fmt.Errorf("offscreen: window is not an offscreen window: %T", win)
+	}
+	return w, nil
+}
+
+// mouseState returns the mouse state for the window -- only accessed by the
+// test harness, which is assumed to drive a given window from one goroutine
+func mouseState(w *windowImpl) *lastMouse {
+	return &w.lastMouse
+}
+
+// SendEvent sends an arbitrary event to the window, after calling its Init
+// method to set the time.
+func SendEvent(win oswin.Window, ev oswin.Event) error {
+	w, err := asImpl(win)
+	if err != nil {
+		return err
+	}
+	ev.Init()
+	w.Send(ev)
+	return nil
+}
+
+// MouseMove sends a mouse move event to given position (in raw window
+// pixels) -- if a button is currently held down via MouseDown, a drag event
+// is sent instead.
+func MouseMove(win oswin.Window, where image.Point, mods ...key.Modifiers) error {
+	w, err := asImpl(win)
+	if err != nil {
+		return err
+	}
+	lm := mouseState(w)
+	mev := mouse.MoveEvent{
+		Event: mouse.Event{
+			Where:  where,
+			Button: lm.but,
+			Action: mouse.Move,
+		},
+		From: lm.pos,
+	}
+	mev.SetModifiers(mods...)
+	lm.pos = where
+	if lm.but != mouse.NoButton {
+		mev.Action = mouse.Drag
+		return SendEvent(w, &mouse.DragEvent{MoveEvent: mev})
+	}
+	return SendEvent(w, &mev)
+}
+
+// MouseDown sends a mouse button press event at given position -- a second
+// press within mouse.DoubleClickMSec of the previous one is sent as a
+// DoubleClick, as the hardware drivers do.
+func MouseDown(win oswin.Window, where image.Point, but mouse.Buttons, mods ...key.Modifiers) error {
+	w, err := asImpl(win)
+	if err != nil {
+		return err
+	}
+	lm := mouseState(w)
+	act := mouse.Press
+	if lm.press != nil {
+		interval := time.Now().Sub(lm.press.Time())
+		if (interval / time.Millisecond) < time.Duration(mouse.DoubleClickMSec) {
+			act = mouse.DoubleClick
+		}
+	}
+	mev := &mouse.Event{
+		Where:  where,
+		Button: but,
+		Action: act,
+	}
+	mev.SetModifiers(mods...)
+	lm.pos = where
+	lm.but = but
+	if err := SendEvent(w, mev); err != nil {
+		return err
+	}
+	if act == mouse.Press {
+		lm.press = mev
+	} else {
+		lm.press = nil
+	}
+	return nil
+}
+
+// MouseUp sends a mouse button release event at given position.
+func MouseUp(win oswin.Window, where image.Point, but mouse.Buttons, mods ...key.Modifiers) error {
+	w, err := asImpl(win)
+	if err != nil {
+		return err
+	}
+	lm := mouseState(w)
+	mev := &mouse.Event{
+		Where:  where,
+		Button: but,
+		Action: mouse.Release,
+	}
+	mev.SetModifiers(mods...)
+	lm.pos = where
+	lm.but = mouse.NoButton
+	return SendEvent(w, mev)
+}
+
+// MouseClick sends a press and release of given button at given position.
+func MouseClick(win oswin.Window, where image.Point, but mouse.Buttons, mods ...key.Modifiers) error {
+	if err := MouseDown(win, where, but, mods...); err != nil {
+		return err
+	}
+	return MouseUp(win, where, but, mods...)
+}
+
+// MouseDrag presses given button at from, moves to to in nsteps equal steps
+// (at least 1), and releases it there.  Each step is delayed by stepDur,
+// which should be larger than gi.DragStartMSec / nsteps for the drag to
+// register as such in gi.Window.
+func MouseDrag(win oswin.Window, from, to image.Point, but mouse.Buttons, nsteps int, stepDur time.Duration) error {
+	if err := MouseDown(win, from, but); err != nil {
+		return err
+	}
+	if nsteps < 1 {
+		nsteps = 1
+	}
+	del := to.Sub(from)
+	for i := 1; i <= nsteps; i++ {
+		time.Sleep(stepDur)
+		pt := from.Add(del.Mul(i).Div(nsteps))
+		if err := MouseMove(win, pt); err != nil {
+			return err
+		}
+	}
+	return MouseUp(win, to, but)
+}
+
+// MouseScroll sends a scroll wheel event at given position with given delta
+// (positive Y = down) -- see mouse.ScrollWheelRate for the standard delta
+// for one wheel step.
+func MouseScroll(win oswin.Window, where image.Point, delta image.Point, mods ...key.Modifiers) error {
+	w, err := asImpl(win)
+	if err != nil {
+		return err
+	}
+	lm := mouseState(w)
+	mev := &mouse.ScrollEvent{
+		Event: mouse.Event{
+			Where:  where,
+			Button: lm.but,
+			Action: mouse.Scroll,
+		},
+		Delta: delta,
+	}
+	mev.SetModifiers(mods...)
+	lm.pos = where
+	return SendEvent(w, mev)
+}
+
+// KeyEvent sends a single key event (press or release) with given rune (-1
+// for none), code and modifier bits -- a press of a non-modifier key also
+// generates a key.ChordEvent, as the hardware drivers do.
+func KeyEvent(win oswin.Window, r rune, code key.Codes, mods int32, act key.Actions) error {
+	w, err := asImpl(win)
+	if err != nil {
+		return err
+	}
+	kev := &key.Event{
+		Rune:      r,
+		Code:      code,
+		Modifiers: mods,
+		Action:    act,
+	}
+	if err := SendEvent(w, kev); err != nil {
+		return err
+	}
+	if act == key.Press && !key.CodeIsModifier(code) {
+		che := &key.ChordEvent{Event: *kev}
+		w.Send(che)
+	}
+	return nil
+}
+
+// KeyChord sends a key press and release for given chord, e.g., "a",
+// "Control+S", or "Shift+ReturnEnter" -- see key.Chord.DecodeCode for the
+// format.
+func KeyChord(win oswin.Window, ch key.Chord) error {
+	r, code, mods, err := ch.DecodeCode()
+	if err != nil {
+		return err
+	}
+	if err := KeyEvent(win, r, code, mods, key.Press); err != nil {
+		return err
+	}
+	return KeyEvent(win, r, code, mods, key.Release)
+}
+
+// TypeString sends key press and release events for each rune in the given
+// string, as if typed with no modifiers.
+func TypeString(win oswin.Window, str string) error {
+	for _, r := range str {
+		code := key.CodeFromRune(r)
+		if err := KeyEvent(win, r, code, 0, key.Press); err != nil {
+			return err
+		}
+		if err := KeyEvent(win, r, code, 0, key.Release); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WindowAction sends a window.Event with given action -- to actually change
+// the size of the window use SetSize, which generates its own Resize event.
+func WindowAction(win oswin.Window, act window.Actions) error {
+	w, err := asImpl(win)
+	if err != nil {
+		return err
+	}
+	sendWindowEvent(w, act)
+	return nil
+}
+
+// WindowImage returns a copy of the window image as of the most recent
+// Publish call -- returns nil if not an offscreen window or nothing has been
+// published yet.
+func WindowImage(win oswin.Window) *image.RGBA {
+	w, err := asImpl(win)
+	if err != nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.front == nil {
+		return nil
+	}
+	img := image.NewRGBA(w.front.Rect)
+	copy(img.Pix, w.front.Pix)
+	return img
+}
+
+// PublishCount returns the number of times Publish has been called on the
+// window -- use with WaitPublish to synchronize with rendering.
+func PublishCount(win oswin.Window) int {
+	w, err := asImpl(win)
+	if err != nil {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.nPublish
+}
+
+// WaitPublish waits until the window has been published more than n times
+// (e.g., n = PublishCount prior to sending events), or the timeout expires,
+// or the window is closed -- returns true if the publish happened.
+func WaitPublish(win oswin.Window, n int, timeout time.Duration) bool {
+	w, err := asImpl(win)
+	if err != nil {
+		return false
+	}
+	timedOut := false
+	tmr := time.AfterFunc(timeout, func() {
+		w.mu.Lock()
+		timedOut = true
+		w.initCond()
+		w.pubCond.Broadcast()
+		w.mu.Unlock()
+	})
+	defer tmr.Stop()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.initCond()
+	for w.nPublish <= n && !timedOut && !w.released {
+		w.pubCond.Wait()
+	}
+	return w.nPublish > n
+}
@@ -0,0 +1,42 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package offscreen provides a headless, in-memory driver for oswin.  All
+// windows and textures render into image.RGBA buffers, and events are only
+// generated by explicit calls (e.g., from a test harness) -- this allows full
+// gi.Window EventLoop operation on machines with no display, such as CI
+// servers, and server-side rendering of GoGi interfaces.
+//
+// Use it directly via offscreen.Main, or build with the "offscreen" tag to
+// have the standard driver.Main use it instead of the platform driver.
+package offscreen
+
+import (
+	"image"
+
+	"github.com/goki/gi/oswin"
+)
+
+// ScreenSize is the size of the single offscreen screen, in raw pixels --
+// must be set prior to calling Main.
+var ScreenSize = image.Point{1920, 1080}
+
+// ScreenPhysicalDPI is the physical dots-per-inch of the offscreen screen --
+// must be set prior to calling Main.
+var ScreenPhysicalDPI = float32(96)
+
+// ScreenLogicalDPI is the logical dots-per-inch of the offscreen screen -- if
+// 0 it is the same as ScreenPhysicalDPI -- must be set prior to calling Main.
+var ScreenLogicalDPI = float32(0)
+
+// Main is called by the program's main function to run the graphical
+// application.
+//
+// It calls f on the App in the same goroutine, and returns when f returns.
+// Unlike the hardware drivers, Main can be called repeatedly (e.g., once per
+// test) -- each call creates a fresh App with no windows.
+func Main(f func(oswin.App)) {
+	app := newAppImpl()
+	f(app)
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/gi/oswin/window"
+)
+
+func TestRender(t *testing.T) {
+	Main(func(app oswin.App) {
+		sz := image.Point{64, 48}
+		win, err := app.NewWindow(&oswin.NewWindowOptions{Size: sz, Title: "test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if win.Size() != sz {
+			t.Errorf("window size: %v != %v", win.Size(), sz)
+		}
+		tex, _ := app.NewTexture(win, sz)
+		img, _ := app.NewImage(sz)
+		red := color.RGBA{255, 0, 0, 255}
+		img.RGBA().Set(3, 4, red)
+		tex.Upload(image.ZP, img, img.Bounds())
+		win.Copy(image.Point{10, 10}, tex, tex.Bounds(), oswin.Src, nil)
+
+		if WindowImage(win) != nil {
+			t.Errorf("window image should be nil before Publish")
+		}
+		win.Publish()
+		wimg := WindowImage(win)
+		if wimg == nil {
+			t.Fatal("nil window image after Publish")
+		}
+		if c := wimg.RGBAAt(13, 14); c != red {
+			t.Errorf("pixel at 13,14: %v != %v", c, red)
+		}
+		if PublishCount(win) != 1 {
+			t.Errorf("publish count: %v != 1", PublishCount(win))
+		}
+		win.Close()
+		if app.NWindows() != 0 {
+			t.Errorf("NWindows after close: %v != 0", app.NWindows())
+		}
+	})
+}
+
+func TestEvents(t *testing.T) {
+	Main(func(app oswin.App) {
+		win, _ := app.NewWindow(&oswin.NewWindowOptions{Size: image.Point{100, 100}})
+		// initial events from window opening
+		for _, act := range []window.Actions{window.Paint, window.Focus} {
+			ev := win.NextEvent().(*window.Event)
+			if ev.Action != act {
+				t.Errorf("initial window event: %v != %v", ev.Action, act)
+			}
+		}
+
+		MouseClick(win, image.Point{20, 30}, mouse.Left)
+		for _, act := range []mouse.Actions{mouse.Press, mouse.Release} {
+			ev := win.NextEvent().(*mouse.Event)
+			if ev.Action != act || ev.Where != (image.Point{20, 30}) {
+				t.Errorf("mouse event: %v at %v", ev.Action, ev.Where)
+			}
+		}
+
+		KeyChord(win, "Control+ReturnEnter")
+		win.NextEvent() // press
+		che := win.NextEvent().(*key.ChordEvent)
+		if che.Chord() != "Control+ReturnEnter" {
+			t.Errorf("chord: %v", che.Chord())
+		}
+		win.NextEvent() // release
+
+		win.SetSize(image.Point{50, 60})
+		if ev := win.NextEvent(); ev.Type() != oswin.WindowResizeEvent {
+			t.Errorf("resize event type: %v", ev.Type())
+		}
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			win.Publish()
+		}()
+		if !WaitPublish(win, 0, time.Second) {
+			t.Errorf("WaitPublish timed out")
+		}
+		if WindowImage(win).Bounds().Size() != (image.Point{50, 60}) {
+			t.Errorf("image size after resize: %v", WindowImage(win).Bounds())
+		}
+	})
+}
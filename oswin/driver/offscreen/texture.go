@@ -0,0 +1,107 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/goki/gi/oswin"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// imageImpl is an oswin.Image backed by a plain image.RGBA
+type imageImpl struct {
+	rgba image.RGBA
+	size image.Point
+}
+
+func newImageImpl(size image.Point) *imageImpl {
+	return &imageImpl{
+		rgba: *image.NewRGBA(image.Rectangle{Max: size}),
+		size: size,
+	}
+}
+
+func (b *imageImpl) Release()                {}
+func (b *imageImpl) Size() image.Point       { return b.size }
+func (b *imageImpl) Bounds() image.Rectangle { return image.Rectangle{Max: b.size} }
+func (b *imageImpl) RGBA() *image.RGBA       { return &b.rgba }
+
+// textureImpl is an oswin.Texture backed by a plain image.RGBA
+type textureImpl struct {
+	mu   sync.Mutex
+	rgba *image.RGBA
+	size image.Point
+}
+
+func newTextureImpl(size image.Point) *textureImpl {
+	return &textureImpl{
+		rgba: image.NewRGBA(image.Rectangle{Max: size}),
+		size: size,
+	}
+}
+
+func (t *textureImpl) Release()                {}
+func (t *textureImpl) Size() image.Point       { return t.size }
+func (t *textureImpl) Bounds() image.Rectangle { return image.Rectangle{Max: t.size} }
+
+func (t *textureImpl) Upload(dp image.Point, src oswin.Image, sr image.Rectangle) {
+	t.mu.Lock()
+	upload(t.rgba, dp, src, sr)
+	t.mu.Unlock()
+}
+
+func (t *textureImpl) Fill(dr image.Rectangle, src color.Color, op draw.Op) {
+	t.mu.Lock()
+	fill(t.rgba, dr, src, op)
+	t.mu.Unlock()
+}
+
+// upload copies the sub-image sr of src to dst at dp, using draw.Src
+func upload(dst *image.RGBA, dp image.Point, src oswin.Image, sr image.Rectangle) {
+	sr = sr.Intersect(src.Bounds())
+	dr := image.Rectangle{Min: dp, Max: dp.Add(sr.Size())}
+	draw.Draw(dst, dr, src.RGBA(), sr.Min, draw.Src)
+}
+
+// fill fills dr in dst with uniform color
+func fill(dst *image.RGBA, dr image.Rectangle, src color.Color, op draw.Op) {
+	draw.Draw(dst, dr, image.NewUniform(src), image.ZP, op)
+}
+
+// isTranslate returns true if the transform is a pure integer translation,
+// in which case the much faster draw.Draw can be used
+func isTranslate(s2d f64.Aff3) bool {
+	return s2d[0] == 1 && s2d[1] == 0 && s2d[3] == 0 && s2d[4] == 1 &&
+		s2d[2] == float64(int(s2d[2])) && s2d[5] == float64(int(s2d[5]))
+}
+
+// drawTex draws the sub-texture sr of src to dst using given transform
+func drawTex(dst *image.RGBA, s2d f64.Aff3, src *textureImpl, sr image.Rectangle, op draw.Op) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	sr = sr.Intersect(src.Bounds())
+	if isTranslate(s2d) {
+		dp := image.Point{int(s2d[2]), int(s2d[5])}.Add(sr.Min)
+		dr := image.Rectangle{Min: dp, Max: dp.Add(sr.Size())}
+		draw.Draw(dst, dr, src.rgba, sr.Min, op)
+		return
+	}
+	xdraw.NearestNeighbor.Transform(dst, s2d, src.rgba, sr, op, nil)
+}
+
+// drawUniform draws the region sr, transformed by s2d, with uniform color
+func drawUniform(dst *image.RGBA, s2d f64.Aff3, src color.Color, sr image.Rectangle, op draw.Op) {
+	if isTranslate(s2d) {
+		dr := sr.Add(image.Point{int(s2d[2]), int(s2d[5])})
+		draw.Draw(dst, dr, image.NewUniform(src), image.ZP, op)
+		return
+	}
+	xdraw.NearestNeighbor.Transform(dst, s2d, image.NewUniform(src), sr, op, nil)
+}
@@ -0,0 +1,211 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/internal/drawer"
+	"github.com/goki/gi/oswin/driver/internal/event"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki/bitflag"
+	"golang.org/x/image/math/f64"
+)
+
+type windowImpl struct {
+	oswin.WindowBase
+	event.Deque
+
+	app *appImpl
+
+	// mu protects the images, size, publish count and released state
+	mu       sync.Mutex
+	pubCond  sync.Cond
+	img      *image.RGBA // back buffer -- all drawing goes here
+	front    *image.RGBA // front buffer -- copy of img as of last Publish
+	nPublish int
+	released bool
+
+	closeReqFunc   func(win oswin.Window)
+	closeCleanFunc func(win oswin.Window)
+
+	// lastMouse is the mouse state for synthesized mouse events
+	lastMouse lastMouse
+}
+
+// for sending window.Event's
+func sendWindowEvent(w *windowImpl, act window.Actions) {
+	winEv := window.Event{
+		Action: act,
+	}
+	winEv.Init()
+	w.Send(&winEv)
+}
+
+func (w *windowImpl) Upload(dp image.Point, src oswin.Image, sr image.Rectangle) {
+	w.mu.Lock()
+	upload(w.img, dp, src, sr)
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) Fill(dr image.Rectangle, src color.Color, op draw.Op) {
+	w.mu.Lock()
+	fill(w.img, dr, src, op)
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) DrawUniform(src2dst f64.Aff3, src color.Color, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	w.mu.Lock()
+	drawUniform(w.img, src2dst, src, sr, op)
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) Draw(src2dst f64.Aff3, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	w.mu.Lock()
+	drawTex(w.img, src2dst, src.(*textureImpl), sr, op)
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) Copy(dp image.Point, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	drawer.Copy(w, dp, src, sr, op, opts)
+}
+
+func (w *windowImpl) Scale(dr image.Rectangle, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	drawer.Scale(w, dr, src, sr, op, opts)
+}
+
+// Publish copies the back buffer to the front buffer, which is what
+// WindowImage returns, and wakes up anyone waiting in WaitPublish.
+func (w *windowImpl) Publish() oswin.PublishResult {
+	w.mu.Lock()
+	if w.front == nil || w.front.Rect != w.img.Rect {
+		w.front = image.NewRGBA(w.img.Rect)
+	}
+	copy(w.front.Pix, w.img.Pix)
+	w.nPublish++
+	w.initCond()
+	w.pubCond.Broadcast()
+	w.mu.Unlock()
+	return oswin.PublishResult{BackImagePreserved: true}
+}
+
+// initCond lazily initializes the publish condition -- must be called with
+// mu locked.
+func (w *windowImpl) initCond() {
+	if w.pubCond.L == nil {
+		w.pubCond.L = &w.mu
+	}
+}
+
+func (w *windowImpl) SetTitle(title string) {
+	w.Titl = title
+}
+
+// Size returns the size of the window -- locked, as SetSize can be called
+// from any goroutine
+func (w *windowImpl) Size() image.Point {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.Sz
+}
+
+// SetSize resizes the window buffer immediately (preserving existing
+// contents) and sends a window.Resize event.
+func (w *windowImpl) SetSize(sz image.Point) {
+	if sz.X <= 0 || sz.Y <= 0 {
+		return
+	}
+	w.mu.Lock()
+	if w.Sz == sz {
+		w.mu.Unlock()
+		return
+	}
+	nimg := image.NewRGBA(image.Rectangle{Max: sz})
+	draw.Draw(nimg, nimg.Bounds(), w.img, image.ZP, draw.Src)
+	w.img = nimg
+	w.Sz = sz
+	w.mu.Unlock()
+	sendWindowEvent(w, window.Resize)
+}
+
+func (w *windowImpl) SetPos(pos image.Point) {
+	if w.Pos == pos {
+		return
+	}
+	w.Pos = pos
+	sendWindowEvent(w, window.Move)
+}
+
+func (w *windowImpl) SetGeom(pos image.Point, sz image.Point) {
+	w.SetPos(pos)
+	w.SetSize(sz)
+}
+
+func (w *windowImpl) MainMenu() oswin.MainMenu {
+	return nil
+}
+
+func (w *windowImpl) Raise() {
+	if bitflag.Has(w.Flag, int(oswin.Minimized)) {
+		bitflag.Clear(&w.Flag, int(oswin.Minimized))
+		sendWindowEvent(w, window.Paint)
+	}
+	w.app.setFocus(w)
+}
+
+func (w *windowImpl) Minimize() {
+	bitflag.Set(&w.Flag, int(oswin.Minimized))
+	sendWindowEvent(w, window.Minimize)
+}
+
+func (w *windowImpl) SetCloseReqFunc(fun func(win oswin.Window)) {
+	w.closeReqFunc = fun
+}
+
+func (w *windowImpl) SetCloseCleanFunc(fun func(win oswin.Window)) {
+	w.closeCleanFunc = fun
+}
+
+func (w *windowImpl) CloseReq() {
+	if theApp.quitting {
+		w.Close()
+		return
+	}
+	if w.closeReqFunc != nil {
+		w.closeReqFunc(w)
+	} else {
+		w.Close()
+	}
+}
+
+func (w *windowImpl) CloseClean() {
+	if w.closeCleanFunc != nil {
+		w.closeCleanFunc(w)
+	}
+}
+
+// Close is the final common path for all window closes -- there is no
+// separate asynchronous destroy notification as in hardware drivers.
+func (w *windowImpl) Close() {
+	w.mu.Lock()
+	released := w.released
+	w.released = true
+	w.initCond()
+	w.pubCond.Broadcast()
+	w.mu.Unlock()
+	if released {
+		return
+	}
+	w.CloseClean()
+	sendWindowEvent(w, window.Close)
+	w.app.deleteWin(w)
+}
+
+// check for interface implementation
+var _ oswin.Window = &windowImpl{}
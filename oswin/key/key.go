@@ -173,6 +173,99 @@ func (ch Chord) Decode() (r rune, mods int32, err error) {
 	return
 }
 
+// DecodeCode decodes a chord string into rune, key code and modifiers (set
+// as bit flags) -- unlike Decode, it also handles the non-printable key
+// names generated by Event.Chord (e.g., "Control+ReturnEnter"), for which
+// the rune is -1.  Suitable for regenerating key events from chords.
+func (ch Chord) DecodeCode() (r rune, code Codes, mods int32, err error) {
+	cs := string(ch)
+	for m := Shift; m < ModifiersN; m++ {
+		mstr := interface{}(m).(fmt.Stringer).String() + "+"
+		if strings.HasPrefix(cs, mstr) {
+			mods |= (1 << uint32(m))
+			cs = strings.TrimPrefix(cs, mstr)
+		}
+	}
+	if cs == "Spacebar" {
+		return ' ', CodeSpacebar, mods, nil
+	}
+	rs := ([]rune)(cs)
+	if len(rs) == 1 {
+		r = rs[0]
+		code = CodeFromRune(r)
+		return
+	}
+	var ok bool
+	if code, ok = CodeFromName(cs); ok {
+		r = -1
+		return
+	}
+	err = fmt.Errorf("gi.oswin.key.DecodeCode: could not decode key from remaining chord: %v\n", cs)
+	return
+}
+
+// CodeFromName returns the Codes value for given name, which can be with or
+// without the Code prefix (e.g., "ReturnEnter" or "CodeReturnEnter")
+func CodeFromName(nm string) (Codes, bool) {
+	if !strings.HasPrefix(nm, "Code") {
+		nm = "Code" + nm
+	}
+	if nm == CodeCompose.String() {
+		return CodeCompose, true
+	}
+	for c := CodeA; c <= CodeRightGUI; c++ {
+		if c.String() == nm {
+			return c, true
+		}
+	}
+	return CodeUnknown, false
+}
+
+// CodeFromRune returns the key code for given rune on a standard US keyboard
+// layout, for letters, digits, space, and basic punctuation -- returns
+// CodeUnknown for anything else.
+func CodeFromRune(r rune) Codes {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return CodeA + Codes(r-'a')
+	case r >= 'A' && r <= 'Z':
+		return CodeA + Codes(r-'A')
+	case r >= '1' && r <= '9':
+		return Code1 + Codes(r-'1')
+	case r == '0':
+		return Code0
+	}
+	switch r {
+	case ' ':
+		return CodeSpacebar
+	case '\t':
+		return CodeTab
+	case '-', '_':
+		return CodeHyphenMinus
+	case '=', '+':
+		return CodeEqualSign
+	case '[', '{':
+		return CodeLeftSquareBracket
+	case ']', '}':
+		return CodeRightSquareBracket
+	case '\\', '|':
+		return CodeBackslash
+	case ';', ':':
+		return CodeSemicolon
+	case '\'', '"':
+		return CodeApostrophe
+	case '`', '~':
+		return CodeGraveAccent
+	case ',', '<':
+		return CodeComma
+	case '.', '>':
+		return CodeFullStop
+	case '/', '?':
+		return CodeSlash
+	}
+	return CodeUnknown
+}
+
 // Shortcut transforms chord string into short form suitable for display to users
 func (ch Chord) Shortcut() string {
 	cs := strings.Replace(string(ch), "Control+", "^", 1) // ⌃ doesn't look as good
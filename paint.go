@@ -64,6 +64,7 @@ type Paint struct {
 	StyleSet    bool          `desc:"have the styles already been set?"`
 	PropsNil    bool          `desc:"set to true if parent node has no props -- allows optimization of styling"`
 	UnContext   units.Context `xml:"-" desc:"units context -- parameters necessary for anchoring relative units"`
+	Vars        ki.Props      `xml:"-" desc:"CSS custom properties (--name) in effect for this element, including those inherited from parent -- see CSSVarsFromProps"`
 	StrokeStyle StrokeStyle
 	FillStyle   FillStyle
 	FontStyle   FontStyle    `desc:"font also has global opacity setting, along with generic color, background-color settings, which can be copied into stroke / fill as needed"`
@@ -126,6 +127,7 @@ func (pc *Paint) CopyStyleFrom(cp *Paint) {
 func (pc *Paint) InheritFields(par *Paint) {
 	pc.FontStyle.InheritFields(&par.FontStyle)
 	pc.TextStyle.InheritFields(&par.TextStyle)
+	pc.Vars = par.Vars
 }
 
 // SetStyleProps sets paint values based on given property map (name: value
@@ -136,6 +138,8 @@ func (pc *Paint) SetStyleProps(par *Paint, props ki.Props) {
 		// PaintFields.Inherit(pc, par) // very slow..
 		pc.InheritFields(par)
 	}
+	pc.Vars = CSSVarsFromProps(pc.Vars, props)
+	props = ResolveCSSVars(pc.Vars, props)
 	PaintFields.Style(pc, par, props)
 	pc.StrokeStyle.SetStylePost(props)
 	pc.FillStyle.SetStylePost(props)
@@ -72,7 +72,10 @@ type User struct {
 type Preferences struct {
 	LogicalDPIScale float32                `min:"0.1" step:"0.1" desc:"overall scaling factor for Logical DPI as a multiplier on Physical DPI -- smaller numbers produce smaller font sizes etc"`
 	ScreenPrefs     map[string]ScreenPrefs `desc:"screen-specific preferences -- will override overall defaults if set"`
-	Colors          ColorPrefs             `desc:"color preferences"`
+	Theme           ThemeName              `desc:"name of the theme to use, from the available themes (built-in and files in the themes prefs directory) -- the theme sets the Colors and adds to the styles -- leave empty to use the Colors and CustomStyles here as-is"`
+	DarkTheme       ThemeName              `desc:"name of the theme to use when FollowDesktop is set and the desktop prefers dark colors"`
+	FollowDesktop   bool                   `desc:"follow the desktop preference for light or dark colors, using DarkTheme when it prefers dark colors, and Theme otherwise"`
+	Colors          ColorPrefs             `desc:"color preferences -- the palette of the Theme is used in place of these while one is selected, and they are restored when it is cleared"`
 	Params          ParamPrefs             `desc:"parameters controlling GUI behavior"`
 	KeyMap          KeyMapName             `desc:"select the active keymap from list of available keymaps -- see Edit KeyMaps for editing / saving / loading that list"`
 	SaveKeyMaps     bool                   `desc:"if set, the current available set of key maps is saved to your preferences directory, and automatically loaded at startup -- this should be set if you are using custom key maps, but it may be safer to keep it <i>OFF</i> if you are <i>not</i> using custom key maps, so that you'll always have the latest compiled-in standard key maps with all the current key functions bound to standard key chords"`
@@ -105,6 +108,33 @@ func (pf *ColorPrefs) Defaults() {
 	pf.Link.SetString("#00F", nil)
 }
 
+// DarkDefaults sets the colors to the defaults for the built-in Dark theme
+func (pf *ColorPrefs) DarkDefaults() {
+	pf.Font.SetString("#DDD", nil)
+	pf.Border.SetString("#888", nil)
+	pf.Background.SetString("#202124", nil)
+	pf.Shadow.SetString("darker-50", &pf.Background)
+	pf.Control.SetString("#3A3D48", nil)
+	pf.Icon.SetString("#8AB4F8", nil)
+	pf.Select.SetString("#2F5A3A", nil)
+	pf.Highlight.SetString("#6B5E1F", nil)
+	pf.Link.SetString("#8AB4F8", nil)
+}
+
+// HighContrastDefaults sets the colors to the defaults for the built-in
+// HighContrast theme
+func (pf *ColorPrefs) HighContrastDefaults() {
+	pf.Font.SetColor(color.White)
+	pf.Border.SetColor(color.White)
+	pf.Background.SetColor(color.Black)
+	pf.Shadow.SetColor(color.Black)
+	pf.Control.SetColor(color.Black)
+	pf.Icon.SetString("#FF0", nil)
+	pf.Select.SetString("#00A", nil)
+	pf.Highlight.SetString("#850", nil)
+	pf.Link.SetString("#0FF", nil)
+}
+
 // PrefColor returns preference color of given name (case insensitive)
 func (pf *ColorPrefs) PrefColor(clrName string) *Color {
 	lc := strings.Replace(strings.ToLower(clrName), "-", "", -1)
@@ -141,6 +171,7 @@ func (pf *ParamPrefs) Defaults() {
 func (pf *Preferences) Defaults() {
 	pf.LogicalDPIScale = 1.0
 	pf.Colors.Defaults()
+	pf.DarkTheme = "Dark"
 	pf.Params.Defaults()
 	pf.FavPaths.SetToDefaults()
 	pf.FontFamily = "Go"
@@ -161,10 +192,14 @@ func (pf *Preferences) Open() error {
 		// log.Println(err) // ok to be non-existant
 		return err
 	}
+	if pf == &Prefs {
+		userColors = nil // the opened colors are the user's own
+	}
 	err = json.Unmarshal(b, pf)
 	if pf.SaveKeyMaps {
 		AvailKeyMaps.OpenPrefs()
 	}
+	AvailThemes.OpenPrefs()
 
 	if pf.User.Username == "" {
 		pf.UpdateUser()
@@ -178,7 +213,13 @@ func (pf *Preferences) Open() error {
 func (pf *Preferences) Save() error {
 	pdir := oswin.TheApp.GoGiPrefsDir()
 	pnm := filepath.Join(pdir, PrefsFileName)
-	b, err := json.MarshalIndent(pf, "", "  ")
+	sp := pf
+	if pf == &Prefs && userColors != nil { // save the user's colors, not the theme's
+		cp := *pf
+		cp.Colors = *userColors
+		sp = &cp
+	}
+	b, err := json.MarshalIndent(sp, "", "  ")
 	if err != nil {
 		log.Println(err)
 		return err
@@ -233,9 +274,35 @@ func (pf *Preferences) Apply() {
 	} else {
 		FontLibrary.InitFontPaths(oswin.TheApp.FontPaths()...)
 	}
+	pf.ApplyTheme()
 	pf.ApplyDPI()
 }
 
+// PrefTheme returns the name of the theme that should be in effect: Theme,
+// or DarkTheme if FollowDesktop is set and the desktop prefers dark colors
+func (pf *Preferences) PrefTheme() ThemeName {
+	if pf.FollowDesktop && pf.DarkTheme != "" && DesktopPrefersDark() {
+		return pf.DarkTheme
+	}
+	return pf.Theme
+}
+
+// ApplyTheme sets the current theme according to preferences (see
+// PrefTheme), without updating the open windows -- see SetTheme to switch
+// themes live.
+func (pf *Preferences) ApplyTheme() {
+	nm := pf.PrefTheme()
+	if nm == "" {
+		setCurTheme(nil)
+		return
+	}
+	th, _, ok := AvailThemes.ThemeByName(nm)
+	if !ok {
+		log.Printf("gi.Preferences.ApplyTheme: theme named: %v not found\n", nm)
+	}
+	setCurTheme(th)
+}
+
 // ApplyDPI updates the screen LogicalDPI values according to current
 // preferences and zoom factor, and then updates all open windows as well.
 func (pf *Preferences) ApplyDPI() {
@@ -258,16 +325,7 @@ func (pf *Preferences) ApplyDPI() {
 func (pf *Preferences) Update() {
 	ZoomFactor = 1 // reset so saved dpi is used
 	pf.Apply()
-
-	RebuildDefaultStyles = true
-	for _, w := range AllWindows {
-		w.FullReRender()
-	}
-	RebuildDefaultStyles = false
-	// needs another pass through to get it right..
-	for _, w := range AllWindows {
-		w.FullReRender()
-	}
+	UpdateAllStyles()
 }
 
 // ScreenInfo returns screen info for all screens on the console.
@@ -136,8 +136,13 @@ func (sb *SliderBase) SetSliderState(state SliderStates) {
 	if state == SliderActive && sb.HasFocus() {
 		state = SliderFocus
 	}
+	prev := sb.State
 	sb.State = state
+	from := sb.Sty
 	sb.Sty = sb.StateStyles[state] // get relevant styles
+	if prev != state {
+		sb.StartTransitions(&from)
+	}
 }
 
 // SliderPressed sets the slider in the down state -- mouse clicked down but
@@ -472,6 +477,45 @@ func (sb *SliderBase) ConfigPartsIfNeeded(render bool) {
 	}
 }
 
+// AccessInfo returns the accessibility info for the slider, including its
+// value and range
+func (sb *SliderBase) AccessInfo() AccessInfo {
+	ai := sb.WidgetBase.AccessInfo()
+	ai.Role = RoleSlider
+	ai.Value = kit.ToString(sb.Value)
+	ai.Min = sb.Min
+	ai.Max = sb.Max
+	ai.Step = sb.Step
+	ai.SetState(sb.Dim == Y, AccessVertical)
+	if !sb.IsInactive() {
+		ai.AddAction(AccessIncrement, AccessDecrement, AccessSetValue)
+	}
+	return ai
+}
+
+// AccessAction performs accessibility actions for the slider, which set the
+// value and emit the SliderValueChanged signal
+func (sb *SliderBase) AccessAction(act AccessActions, val string) bool {
+	if sb.IsInactive() {
+		return sb.AccessActionBase(act, val)
+	}
+	switch act {
+	case AccessIncrement:
+		sb.SetValueAction(sb.Value + sb.Step)
+		return true
+	case AccessDecrement:
+		sb.SetValueAction(sb.Value - sb.Step)
+		return true
+	case AccessSetValue:
+		if fv, ok := kit.ToFloat32(val); ok {
+			sb.SetValueAction(fv)
+			return true
+		}
+		return false
+	}
+	return sb.AccessActionBase(act, val)
+}
+
 // SliderDefault is default obj that can be used when property specifies "default"
 var SliderDefault SliderBase
 
@@ -561,6 +605,7 @@ func (sr *Slider) StyleSlider() {
 	for i := 0; i < int(SliderStatesN); i++ {
 		sr.StateStyles[i].CopyFrom(&sr.Sty)
 		sr.StateStyles[i].SetStyleProps(pst, sr.StyleProps(SliderSelectors[i]))
+		sr.StateStyles[i].StyleCSS(sr.This.(Node2D), sr.CSSAgg, SliderSelectors[i])
 		sr.StateStyles[i].CopyUnitContext(&sr.Sty.UnContext)
 	}
 	SliderFields.Style(sr, nil, sr.Props)
@@ -756,6 +801,7 @@ func (sb *ScrollBar) StyleScrollBar() {
 	for i := 0; i < int(SliderStatesN); i++ {
 		sb.StateStyles[i].CopyFrom(&sb.Sty)
 		sb.StateStyles[i].SetStyleProps(pst, sb.StyleProps(SliderSelectors[i]))
+		sb.StateStyles[i].StyleCSS(sb.This.(Node2D), sb.CSSAgg, SliderSelectors[i])
 		sb.StateStyles[i].CopyUnitContext(&sb.Sty.UnContext)
 	}
 	SliderFields.Style(sb, nil, sb.Props)
@@ -844,3 +890,10 @@ func (sb *ScrollBar) FocusChanged2D(change FocusChanges) {
 	case FocusActive:
 	}
 }
+
+// AccessInfo returns the accessibility info for the scrollbar
+func (sb *ScrollBar) AccessInfo() AccessInfo {
+	ai := sb.SliderBase.AccessInfo()
+	ai.Role = RoleScrollBar
+	return ai
+}
@@ -307,3 +307,52 @@ func (sb *SpinBox) HasFocus2D() bool {
 	}
 	return sb.ContainsFocus() // needed for getting key events
 }
+
+// AccessInfo returns the accessibility info for the spinbox, including its
+// value and range (if it has both a min and max)
+func (sb *SpinBox) AccessInfo() AccessInfo {
+	ai := sb.WidgetBase.AccessInfo()
+	ai.Role = RoleSpinBox
+	ai.Value = kit.ToString(sb.Value)
+	if sb.HasMin && sb.HasMax {
+		ai.Min = sb.Min
+		ai.Max = sb.Max
+	}
+	ai.Step = sb.Step
+	ai.SetState(sb.ContainsFocus(), AccessFocused)
+	if !sb.IsInactive() {
+		ai.SetState(true, AccessFocusable, AccessEditable)
+		ai.AddAction(AccessIncrement, AccessDecrement, AccessSetValue, AccessFocus)
+	}
+	return ai
+}
+
+// AccessAction performs accessibility actions for the spinbox, which set the
+// value and emit the SpinBoxSig signal
+func (sb *SpinBox) AccessAction(act AccessActions, val string) bool {
+	if sb.IsInactive() {
+		return false
+	}
+	switch act {
+	case AccessIncrement:
+		sb.IncrValue(1)
+		return true
+	case AccessDecrement:
+		sb.IncrValue(-1)
+		return true
+	case AccessSetValue:
+		if fv, ok := kit.ToFloat32(val); ok {
+			sb.SetValueAction(fv)
+			return true
+		}
+		return false
+	case AccessFocus:
+		if sb.Parts.HasChildren() {
+			if tf, ok := sb.Parts.KnownChild(sbTextFieldIdx).(*TextField); ok {
+				tf.GrabFocus()
+				return true
+			}
+		}
+	}
+	return sb.AccessActionBase(act, val)
+}
@@ -8,6 +8,7 @@ import (
 	"image"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/goki/gi/oswin"
 	"github.com/goki/gi/oswin/key"
@@ -50,6 +51,10 @@ var SplitViewProps = ki.Props{
 	"padding":     0,
 }
 
+// SplitViewAnimDur is the duration of the animation when collapsing and
+// restoring children in a SplitView -- 0 = no animation
+var SplitViewAnimDur = 150 * time.Millisecond
+
 // UpdateSplits updates the splits to be same length as number of children,
 // and normalized
 func (sv *SplitView) UpdateSplits() {
@@ -128,10 +133,11 @@ func (sv *SplitView) RestoreSplits() {
 // optionally saving the prior splits for later Restore function -- does an
 // Update -- triggered by double-click of splitter
 func (sv *SplitView) CollapseChild(save bool, idxs ...int) {
-	updt := sv.UpdateStart()
 	if save {
 		sv.SaveSplits()
 	}
+	sv.UpdateSplits()
+	cur := append([]float32{}, sv.Splits...)
 	sz := len(sv.Kids)
 	for _, idx := range idxs {
 		if idx >= 0 && idx < sz {
@@ -139,12 +145,15 @@ func (sv *SplitView) CollapseChild(save bool, idxs ...int) {
 		}
 	}
 	sv.UpdateSplits()
-	sv.UpdateEnd(updt)
+	trg := append([]float32{}, sv.Splits...)
+	copy(sv.Splits, cur)
+	sv.AnimateSplits(trg...)
 }
 
 // RestoreChild restores given child(ren) -- does an Update
 func (sv *SplitView) RestoreChild(idxs ...int) {
-	updt := sv.UpdateStart()
+	sv.UpdateSplits()
+	cur := append([]float32{}, sv.Splits...)
 	sz := len(sv.Kids)
 	for _, idx := range idxs {
 		if idx >= 0 && idx < sz {
@@ -152,7 +161,39 @@ func (sv *SplitView) RestoreChild(idxs ...int) {
 		}
 	}
 	sv.UpdateSplits()
-	sv.UpdateEnd(updt)
+	trg := append([]float32{}, sv.Splits...)
+	copy(sv.Splits, cur)
+	sv.AnimateSplits(trg...)
+}
+
+// AnimateSplits animates the split proportions from their current values to
+// the given values over SplitViewAnimDur -- splits are normalized -- see
+// SetSplits for the non-animated version
+func (sv *SplitView) AnimateSplits(splits ...float32) {
+	sv.UpdateSplits()
+	sz := len(sv.Splits)
+	from := append([]float32{}, sv.Splits...)
+	to := append([]float32{}, sv.Splits...)
+	copy(to, splits)
+	sum := float32(0)
+	for _, sp := range to {
+		sum += sp
+	}
+	if sum == 0 {
+		return
+	}
+	for i := range to {
+		to[i] /= sum
+	}
+	sv.Animate("splits", SplitViewAnimDur, EaseInOut, func(t float32) {
+		if len(sv.Splits) != sz {
+			return
+		}
+		for i := range sv.Splits {
+			sv.Splits[i] = LerpFloat32(from[i], to[i], t)
+		}
+		sv.SetFullReRender()
+	})
 }
 
 // SetSplitsAction sets the new splitter value, for given splitter -- new
@@ -492,6 +533,7 @@ func (sr *Splitter) Style2D() {
 	for i := 0; i < int(SliderStatesN); i++ {
 		sr.StateStyles[i].CopyFrom(&sr.Sty)
 		sr.StateStyles[i].SetStyleProps(pst, sr.StyleProps(SliderSelectors[i]))
+		sr.StateStyles[i].StyleCSS(sr.This.(Node2D), sr.CSSAgg, SliderSelectors[i])
 		sr.StateStyles[i].CopyUnitContext(&sr.Sty.UnContext)
 	}
 	SliderFields.Style(sr, nil, sr.Props)
@@ -632,3 +674,11 @@ func (sr *Splitter) FocusChanged2D(change FocusChanges) {
 	case FocusActive:
 	}
 }
+
+// AccessInfo returns the accessibility info for the splitter -- the value is
+// the split position
+func (sr *Splitter) AccessInfo() AccessInfo {
+	ai := sr.SliderBase.AccessInfo()
+	ai.Role = RoleSplitter
+	return ai
+}
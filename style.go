@@ -30,25 +30,25 @@ import (
 
 // Style has all the CSS-based style elements -- used for widget-type objects
 type Style struct {
-	Display       bool          `xml:"display" desc:"todo big enum of how to display item -- controls layout etc"`
-	Visible       bool          `xml:"visible" desc:"todo big enum of how to display item -- controls layout etc"`
-	Inactive      bool          `xml:"inactive" desc:"make a control inactive so it does not respond to input"`
-	Layout        LayoutStyle   `desc:"layout styles -- do not prefix with any xml"`
-	Border        BorderStyle   `xml:"border" desc:"border around the box element -- todo: can have separate ones for different sides"`
-	BoxShadow     ShadowStyle   `xml:"box-shadow" desc:"type of shadow to render around box"`
-	Font          FontStyle     `desc:"font parameters -- no xml prefix -- also has color, background-color"`
-	Text          TextStyle     `desc:"text parameters -- no xml prefix"`
-	Outline       BorderStyle   `xml:"outline" desc:"draw an outline around an element -- mostly same styles as border -- default to none"`
-	PointerEvents bool          `xml:"pointer-events" desc:"does this element respond to pointer events -- default is true"`
-	UnContext     units.Context `xml:"-" desc:"units context -- parameters necessary for anchoring relative units"`
-	IsSet         bool          `desc:"has this style been set from object values yet?"`
-	PropsNil      bool          `desc:"set to true if parent node has no props -- allows optimization of styling"`
+	Display       bool              `xml:"display" desc:"todo big enum of how to display item -- controls layout etc"`
+	Visible       bool              `xml:"visible" desc:"todo big enum of how to display item -- controls layout etc"`
+	Inactive      bool              `xml:"inactive" desc:"make a control inactive so it does not respond to input"`
+	Layout        LayoutStyle       `desc:"layout styles -- do not prefix with any xml"`
+	Border        BorderStyle       `xml:"border" desc:"border around the box element -- todo: can have separate ones for different sides"`
+	BoxShadow     ShadowStyle       `xml:"box-shadow" desc:"type of shadow to render around box"`
+	Font          FontStyle         `desc:"font parameters -- no xml prefix -- also has color, background-color"`
+	Text          TextStyle         `desc:"text parameters -- no xml prefix"`
+	Outline       BorderStyle       `xml:"outline" desc:"draw an outline around an element -- mostly same styles as border -- default to none"`
+	PointerEvents bool              `xml:"pointer-events" desc:"does this element respond to pointer events -- default is true"`
+	UnContext     units.Context     `xml:"-" desc:"units context -- parameters necessary for anchoring relative units"`
+	Vars          ki.Props          `xml:"-" desc:"CSS custom properties (--name) in effect for this element, including those inherited from parent -- shared with parent unless new ones are defined -- see CSSVarsFromProps"`
+	Transition    []StyleTransition `xml:"-" desc:"transitions for animating changes in style properties between states -- set from the transition property -- see StyleTransition"`
+	IsSet         bool              `desc:"has this style been set from object values yet?"`
+	PropsNil      bool              `desc:"set to true if parent node has no props -- allows optimization of styling"`
 	dotsSet       bool
 	lastUnCtxt    units.Context
 }
 
-// todo: Animation
-
 // Clear -- no floating elements
 
 // Clip -- clip images
@@ -236,6 +236,7 @@ func (s *Style) CopyFrom(cp *Style) {
 func (s *Style) InheritFields(par *Style) {
 	s.Font.InheritFields(&par.Font)
 	s.Text.InheritFields(&par.Text)
+	s.Vars = par.Vars
 }
 
 // SetStyleProps sets style values based on given property map (name: value pairs),
@@ -245,7 +246,14 @@ func (s *Style) SetStyleProps(par *Style, props ki.Props) {
 		// StyleFields.Inherit(s, par) // very slow for some mysterious reason
 		s.InheritFields(par)
 	}
+	s.Vars = CSSVarsFromProps(s.Vars, props)
+	props = ResolveCSSVars(s.Vars, props)
 	StyleFields.Style(s, par, props)
+	if tr, ok := props["transition"]; ok {
+		if trs, ok := tr.(string); ok {
+			s.Transition = ParseStyleTransitions(trs)
+		}
+	}
 	s.Text.AlignV = s.Layout.AlignV
 	if s.Layout.Margin.Val > 0 && s.Text.ParaSpacing.Val == 0 {
 		s.Text.ParaSpacing = s.Layout.Margin
@@ -325,39 +333,19 @@ func (s *Style) BoxSpace() float32 {
 	return s.Layout.Margin.Dots + s.Border.Width.Dots + s.Layout.Padding.Dots
 }
 
-// ApplyCSS applies css styles for given node, using key to select sub-props
-// from overall properties list, and optional selector to select a further
-// :name selector within that key
-func (s *Style) ApplyCSS(node Node2D, css ki.Props, key, selector string) bool {
-	pp, got := css[key]
-	if !got {
-		return false
-	}
-	pmap, ok := pp.(ki.Props) // must be a props map
-	if !ok {
-		return false
-	}
-	if selector != "" {
-		pmap, ok = SubProps(pmap, selector)
-		if !ok {
-			return false
-		}
+// StyleCSS applies css style properties to given Widget node, for all the
+// css selector keys that match the node (see CSSSelector), in order of
+// specificity, for given widget state selector (:hover, :active etc, as in
+// StateStyles), or "" for the base style
+func (s *Style) StyleCSS(node Node2D, css ki.Props, selector string) {
+	pms := CSSMatches(node, css, selector)
+	if len(pms) == 0 {
+		return
 	}
 	parSty := node.AsNode2D().ParentStyle()
-	s.SetStyleProps(parSty, pmap)
-	return true
-}
-
-// StyleCSS applies css style properties to given Widget node, parsing out
-// type, .class, and #name selectors, along with optional sub-selector
-// (:hover, :active etc)
-func (s *Style) StyleCSS(node Node2D, css ki.Props, selector string) {
-	tyn := strings.ToLower(node.Type().Name()) // type is most general, first
-	s.ApplyCSS(node, css, tyn, selector)
-	cln := "." + strings.ToLower(node.AsNode2D().Class) // then class
-	s.ApplyCSS(node, css, cln, selector)
-	idnm := "#" + strings.ToLower(node.Name()) // then name
-	s.ApplyCSS(node, css, idnm, selector)
+	for _, pmap := range pms {
+		s.SetStyleProps(parSty, pmap)
+	}
 }
 
 // SubProps returns a sub-property map from given prop map for a given styling
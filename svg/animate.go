@@ -0,0 +1,504 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goki/gi"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// Animate is an SVG SMIL <animate> or <animateTransform> element, which
+// animates an attribute of its parent element over time -- it does not
+// render anything itself.  Animations start when the SVG is first rendered
+// in a window, and are driven by the window's animation timeline (see
+// gi.Tween).  Values are interpolated as lists of numbers, or colors, or
+// otherwise set discretely.  The geometry attributes of rect, circle,
+// ellipse and line are set directly, and all other attributes are set as
+// properties and the element is re-styled.
+type Animate struct {
+	NodeBase
+	Attr       string          `desc:"name of the attribute to animate (attributeName) -- transform for animateTransform"`
+	XFormType  string          `desc:"for animateTransform, the type of transform: translate, scale, rotate, skewX or skewY"`
+	Values     []string        `desc:"values to animate through -- from values, or from / to / by"`
+	KeyTimes   []float32       `desc:"times (0-1) for each of the values -- default is evenly spaced"`
+	KeySplines []gi.EasingFunc `view:"-" desc:"easing for each interval between values, for spline calcMode"`
+	CalcMode   string          `desc:"how values are interpolated: linear, discrete, paced (same as linear here) or spline"`
+	By         string          `desc:"by value, added to the from (or current) value if there are no values or to"`
+	Dur        time.Duration   `desc:"duration of one run of the animation"`
+	Begin      time.Duration   `desc:"delay from the start of the SVG to the start of the animation"`
+	Repeat     int             `desc:"number of additional runs after the first -- -1 = indefinite"`
+	Freeze     bool            `desc:"keep the final value at the end, instead of restoring the original value (fill=freeze)"`
+	Additive   bool            `desc:"add to the original value instead of replacing it (additive=sum) -- transforms are appended, numbers are added"`
+	orig       string
+	started    bool
+}
+
+var KiT_Animate = kit.Types.AddType(&Animate{}, nil)
+
+// SetXMLAttr sets an attribute from the svg file for the animation --
+// returns false if not an animation attribute
+func (an *Animate) SetXMLAttr(name, val string) bool {
+	val = strings.TrimSpace(val)
+	switch name {
+	case "attributeName":
+		an.Attr = val
+	case "type":
+		an.XFormType = val
+	case "values":
+		an.Values = nil
+		for _, v := range strings.Split(val, ";") {
+			if v = strings.TrimSpace(v); v != "" {
+				an.Values = append(an.Values, v)
+			}
+		}
+	case "from":
+		an.setEndValue(0, val)
+	case "to":
+		an.setEndValue(1, val)
+	case "by":
+		an.By = val
+	case "keyTimes":
+		an.KeyTimes = nil
+		for _, v := range strings.Split(val, ";") {
+			if kt, err := gi.ParseFloat32(strings.TrimSpace(v)); err == nil {
+				an.KeyTimes = append(an.KeyTimes, kt)
+			}
+		}
+	case "keySplines":
+		an.KeySplines = nil
+		for _, v := range strings.Split(val, ";") {
+			pts := gi.ReadPoints(v)
+			if len(pts) == 4 {
+				an.KeySplines = append(an.KeySplines, gi.CubicBezierEasing(pts[0], pts[1], pts[2], pts[3]))
+			}
+		}
+	case "calcMode":
+		an.CalcMode = val
+	case "dur":
+		an.Dur, _ = ParseClockValue(val)
+	case "begin":
+		an.Begin, _ = ParseClockValue(val)
+	case "repeatCount":
+		if val == "indefinite" {
+			an.Repeat = -1
+		} else if rc, err := gi.ParseFloat32(val); err == nil && rc >= 1 {
+			an.Repeat = int(rc+0.5) - 1
+		}
+	case "repeatDur":
+		if val == "indefinite" {
+			an.Repeat = -1
+		}
+	case "fill":
+		an.Freeze = (val == "freeze")
+	case "additive":
+		an.Additive = (val == "sum")
+	default:
+		return false
+	}
+	return true
+}
+
+// setEndValue sets the from (0) or to (1) value -- a missing from value is
+// left empty, meaning the current value
+func (an *Animate) setEndValue(idx int, val string) {
+	for len(an.Values) < 2 {
+		an.Values = append(an.Values, "")
+	}
+	an.Values[idx] = val
+}
+
+// ParseClockValue parses an SMIL clock value: e.g., 2s, 150ms, 1.5min,
+// 0.5h, 01:30 or 00:01:30.5 -- a number without units is in seconds
+func ParseClockValue(str string) (time.Duration, error) {
+	str = strings.TrimSpace(str)
+	if strings.Contains(str, ":") {
+		parts := strings.Split(str, ":")
+		secs := 0.0
+		for _, p := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return 0, err
+			}
+			secs = secs*60 + v
+		}
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	mult := float64(time.Second)
+	switch {
+	case strings.HasSuffix(str, "ms"):
+		mult, str = float64(time.Millisecond), str[:len(str)-2]
+	case strings.HasSuffix(str, "min"):
+		mult, str = float64(time.Minute), str[:len(str)-3]
+	case strings.HasSuffix(str, "h"):
+		mult, str = float64(time.Hour), str[:len(str)-1]
+	case strings.HasSuffix(str, "s"):
+		str = str[:len(str)-1]
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil {
+		return 0, fmt.Errorf("svg.ParseClockValue: invalid clock value: %v", str)
+	}
+	return time.Duration(v * mult), nil
+}
+
+// Target returns the element that is animated: our parent
+func (an *Animate) Target() gi.Node2D {
+	if an.Par == nil {
+		return nil
+	}
+	pgi, _ := gi.KiToNode2D(an.Par)
+	return pgi
+}
+
+// IsTransform returns true if this is an animateTransform
+func (an *Animate) IsTransform() bool {
+	return an.XFormType != "" || an.Attr == "transform"
+}
+
+// AttrValue returns the current value of the animated attribute on the
+// target, as a string
+func (an *Animate) AttrValue() string {
+	tgt := an.Target()
+	if tgt == nil {
+		return ""
+	}
+	if v, ok := geomAttr(tgt, an.Attr); ok {
+		return fmt.Sprintf("%g", *v)
+	}
+	if pv, ok := tgt.Prop(an.Attr); ok {
+		return kit.ToString(pv)
+	}
+	return ""
+}
+
+// SetAttrValue sets the animated attribute on the target to given value
+func (an *Animate) SetAttrValue(val string) {
+	tgt := an.Target()
+	if tgt == nil {
+		return
+	}
+	if v, ok := geomAttr(tgt, an.Attr); ok {
+		if f, err := gi.ParseFloat32(val); err == nil {
+			*v = f
+		}
+		return
+	}
+	if val == "" {
+		tgt.DeleteProp(an.Attr)
+	} else {
+		tgt.SetProp(an.Attr, val)
+	}
+	tgt.FuncDownMeFirst(0, nil, func(k ki.Ki, level int, d interface{}) bool {
+		if gii, _ := gi.KiToNode2D(k); gii != nil {
+			StyleSVG(gii)
+		}
+		return true
+	})
+}
+
+// geomAttr returns a pointer to the geometry field for given attribute
+// name of the basic shapes, which are not properties
+func geomAttr(gii gi.Node2D, attr string) (*float32, bool) {
+	switch g := gii.(type) {
+	case *Rect:
+		switch attr {
+		case "x":
+			return &g.Pos.X, true
+		case "y":
+			return &g.Pos.Y, true
+		case "width":
+			return &g.Size.X, true
+		case "height":
+			return &g.Size.Y, true
+		case "rx":
+			return &g.Radius.X, true
+		case "ry":
+			return &g.Radius.Y, true
+		}
+	case *Circle:
+		switch attr {
+		case "cx":
+			return &g.Pos.X, true
+		case "cy":
+			return &g.Pos.Y, true
+		case "r":
+			return &g.Radius, true
+		}
+	case *Ellipse:
+		switch attr {
+		case "cx":
+			return &g.Pos.X, true
+		case "cy":
+			return &g.Pos.Y, true
+		case "rx":
+			return &g.Radii.X, true
+		case "ry":
+			return &g.Radii.Y, true
+		}
+	case *Line:
+		switch attr {
+		case "x1":
+			return &g.Start.X, true
+		case "y1":
+			return &g.Start.Y, true
+		case "x2":
+			return &g.End.X, true
+		case "y2":
+			return &g.End.Y, true
+		}
+	}
+	return nil, false
+}
+
+// ValueAt returns the value of the animation at given linear progress t
+// (0-1) through one run, as a string to set on the attribute
+func (an *Animate) ValueAt(t float32) string {
+	vals := an.Values
+	if an.By != "" && (len(vals) == 0 || (len(vals) == 2 && vals[1] == "")) {
+		from := ""
+		if len(vals) > 0 {
+			from = vals[0]
+		}
+		vals = []string{from, addNums(an.emptyValue(from, []string{an.By}), an.By)}
+	}
+	if len(vals) == 0 {
+		return an.orig
+	}
+	if len(vals) == 1 {
+		if an.CalcMode == "discrete" { // set
+			return vals[0]
+		}
+		vals = []string{"", vals[0]} // to-animation from current value
+	}
+	vs := make([]string, len(vals))
+	for i, v := range vals {
+		vs[i] = an.emptyValue(v, vals)
+	}
+	n := len(vs)
+	kt := an.KeyTimes
+	if len(kt) != n {
+		kt = make([]float32, n)
+		for i := range kt {
+			if an.CalcMode == "discrete" {
+				kt[i] = float32(i) / float32(n)
+			} else {
+				kt[i] = float32(i) / float32(n-1)
+			}
+		}
+	}
+	if an.CalcMode == "discrete" {
+		idx := 0
+		for i := range kt {
+			if t >= kt[i] {
+				idx = i
+			}
+		}
+		return vs[idx]
+	}
+	seg := n - 2
+	for i := 0; i < n-1; i++ {
+		if t < kt[i+1] {
+			seg = i
+			break
+		}
+	}
+	if seg < 0 {
+		return vs[0]
+	}
+	span := kt[seg+1] - kt[seg]
+	lt := float32(1)
+	if span > 0 {
+		lt = (t - kt[seg]) / span
+	}
+	if lt < 0 {
+		lt = 0
+	} else if lt > 1 {
+		lt = 1
+	}
+	if an.CalcMode == "spline" && seg < len(an.KeySplines) {
+		lt = an.KeySplines[seg](lt)
+	}
+	return InterpValues(vs[seg], vs[seg+1], lt)
+}
+
+// InterpValues interpolates between two attribute values by t: as lists of
+// numbers with the same length, as colors, or otherwise discretely (the
+// first value up to the half-way point)
+func InterpValues(a, b string, t float32) string {
+	if ap, bp := gi.ReadPoints(a), gi.ReadPoints(b); len(ap) > 0 && len(ap) == len(bp) && !strings.ContainsAny(a+b, "#(") {
+		strs := make([]string, len(ap))
+		for i := range ap {
+			strs[i] = fmt.Sprintf("%g", gi.LerpFloat32(ap[i], bp[i], t))
+		}
+		return strings.Join(strs, " ")
+	}
+	var ac, bc gi.Color
+	if a != "" && b != "" && ac.SetString(a, nil) == nil && bc.SetString(b, nil) == nil && !ac.IsNil() && !bc.IsNil() {
+		c := gi.LerpColor(ac, bc, t)
+		return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+	}
+	if t < 0.5 {
+		return a
+	}
+	return b
+}
+
+// addNums adds the numbers in two number lists, for by values
+func addNums(a, b string) string {
+	ap, bp := gi.ReadPoints(a), gi.ReadPoints(b)
+	if len(bp) == 0 {
+		return a
+	}
+	strs := make([]string, len(bp))
+	for i := range bp {
+		v := bp[i]
+		if i < len(ap) {
+			v += ap[i]
+		}
+		strs[i] = fmt.Sprintf("%g", v)
+	}
+	return strings.Join(strs, " ")
+}
+
+// emptyValue returns the value to use for v if it is empty (a missing from
+// value): the original value, or the identity transform
+func (an *Animate) emptyValue(v string, vals []string) string {
+	if v != "" {
+		return v
+	}
+	if an.IsTransform() {
+		return an.xformIdent(vals)
+	}
+	return an.orig
+}
+
+// xformIdent returns the identity values for our type of transform, used
+// for a missing from value -- with as many numbers as the other values
+func (an *Animate) xformIdent(vals []string) string {
+	n := 1
+	for _, v := range vals {
+		if pts := gi.ReadPoints(v); len(pts) > 0 {
+			n = len(pts)
+			break
+		}
+	}
+	id := "0"
+	if an.XFormType == "scale" {
+		id = "1"
+	}
+	return strings.TrimSpace(strings.Repeat(id+" ", n))
+}
+
+// XFormString returns the transform string for given numeric value list of
+// an animateTransform
+func (an *Animate) XFormString(val string) string {
+	pts := gi.ReadPoints(val)
+	typ := an.XFormType
+	if typ == "" {
+		typ = "translate"
+	}
+	switch {
+	case len(pts) == 0:
+		return ""
+	case typ == "translate" && len(pts) == 1:
+		pts = append(pts, 0)
+	case typ == "scale" && len(pts) == 1:
+		pts = append(pts, pts[0])
+	case typ == "rotate" && len(pts) == 2:
+		pts = pts[:1]
+	}
+	strs := make([]string, len(pts))
+	for i, p := range pts {
+		strs[i] = fmt.Sprintf("%g", p)
+	}
+	return typ + "(" + strings.Join(strs, " ") + ")"
+}
+
+// Apply sets the attribute on the target for given linear progress t
+func (an *Animate) Apply(t float32) {
+	val := an.ValueAt(t)
+	if an.IsTransform() {
+		an.Attr = "transform"
+		val = an.XFormString(val)
+		if an.Additive && an.orig != "" {
+			val = an.orig + " " + val
+		}
+	} else if an.Additive && an.orig != "" {
+		val = addNums(an.orig, val)
+	}
+	an.SetAttrValue(val)
+}
+
+// Start starts the animation in the window timeline of the parent SVG --
+// called automatically on first render
+func (an *Animate) Start() {
+	an.started = true
+	sv := an.ParentSVG()
+	if sv == nil || an.Target() == nil {
+		return
+	}
+	if an.IsTransform() {
+		an.Attr = "transform"
+	}
+	an.orig = an.AttrValue()
+	tw := &gi.Tween{Node: sv.This, Key: "smil:" + an.PathUnique(), Dur: an.Dur, Delay: an.Begin, Repeat: an.Repeat}
+	tw.Update = func(t float32) {
+		an.Apply(t)
+		sv.AnimFrame()
+	}
+	tw.Done = func() {
+		if !an.Freeze {
+			an.SetAttrValue(an.orig)
+			sv.AnimFrame()
+		}
+	}
+	win := sv.ParentWindow()
+	if an.Dur <= 0 || win == nil {
+		return
+	}
+	win.Animate(tw)
+}
+
+// AnimFrame is called on each frame in which animations have changed the
+// SVG, to ensure that it is re-rendered
+func (svg *SVG) AnimFrame() {
+	if ic, ok := svg.This.(*Icon); ok {
+		ic.Rendered = false
+	}
+	if !svg.Fill && svg.Pixels != nil {
+		draw.Draw(svg.Pixels, svg.Pixels.Bounds(), &image.Uniform{color.Transparent}, image.ZP, draw.Src)
+	}
+	svg.SetFullReRender()
+}
+
+// StopAnims stops all the running SMIL animations in this SVG
+func (svg *SVG) StopAnims() {
+	if win := svg.ParentWindow(); win != nil {
+		win.Anim.Stop(svg.This, "")
+	}
+}
+
+func (an *Animate) Init2D() {
+	an.Init2DBase()
+	an.started = false
+}
+
+func (an *Animate) Style2D() {
+}
+
+func (an *Animate) Render2D() {
+	if !an.started {
+		an.Start()
+	}
+}
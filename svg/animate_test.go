@@ -0,0 +1,132 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goki/ki"
+)
+
+const testAnimSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100">
+<g id="grp">
+<rect id="box" x="10" y="10" width="20" height="20" fill="#ff0000">
+	<animate attributeName="x" values="10; 50; 30" keyTimes="0;0.5;1" dur="2s" begin="500ms" repeatCount="indefinite"/>
+	<animate attributeName="fill" from="#ff0000" to="#0000ff" dur="1s" repeatCount="3" fill="freeze"/>
+	<animateTransform attributeName="transform" to="90" dur="1.5s" additive="sum" calcMode="spline" keySplines="0.4 0 0.2 1"/>
+	<set attributeName="visibility" to="hidden" begin="1s"/>
+</rect>
+<animateTransform attributeName="transform" type="scale" by="2" dur="00:00:03"/>
+</g>
+</svg>`
+
+func TestReadAnimate(t *testing.T) {
+	sv := &SVG{}
+	sv.InitName(sv, "svg")
+	if err := sv.ReadXML(strings.NewReader(testAnimSVG)); err != nil {
+		t.Fatal(err)
+	}
+	var ans []*Animate
+	sv.FuncDownMeFirst(0, nil, func(k ki.Ki, level int, d interface{}) bool {
+		if an, ok := k.(*Animate); ok {
+			ans = append(ans, an)
+		}
+		return true
+	})
+	if len(ans) != 5 {
+		t.Fatalf("expected 5 animation elements, got: %v", len(ans))
+	}
+	for i, an := range ans[:4] {
+		if rc, ok := an.Par.(*Rect); !ok || rc.Nm != "box" {
+			t.Errorf("animation %v: expected rect as parent, got: %v", i, an.Par)
+		}
+	}
+	if _, ok := ans[4].Par.(*Group); !ok {
+		t.Errorf("expected group as parent of animation after the rect, got: %v", ans[4].Par)
+	}
+
+	an := ans[0]
+	if an.Attr != "x" || len(an.Values) != 3 || len(an.KeyTimes) != 3 || an.Dur != 2*time.Second || an.Begin != 500*time.Millisecond || an.Repeat != -1 {
+		t.Errorf("unexpected animate: %+v", an)
+	}
+	vals := []struct {
+		t   float32
+		val string
+	}{{0, "10"}, {0.25, "30"}, {0.5, "50"}, {0.75, "40"}, {1, "30"}}
+	for _, vt := range vals {
+		if v := an.ValueAt(vt.t); v != vt.val {
+			t.Errorf("value at %v: %v != %v", vt.t, v, vt.val)
+		}
+	}
+
+	an = ans[1]
+	if an.Attr != "fill" || an.Repeat != 2 || !an.Freeze || an.Dur != time.Second {
+		t.Errorf("unexpected animate: %+v", an)
+	}
+	if v := an.ValueAt(0); v != "#ff0000ff" {
+		t.Errorf("color at start: %v", v)
+	}
+	if v := an.ValueAt(1); v != "#0000ffff" {
+		t.Errorf("color at end: %v", v)
+	}
+
+	an = ans[2]
+	if !an.IsTransform() || an.XFormType != "translate" || !an.Additive || an.CalcMode != "spline" || len(an.KeySplines) != 1 {
+		t.Errorf("unexpected animateTransform: %+v", an)
+	}
+	if v := an.ValueAt(0); v != "0" {
+		t.Errorf("transform from identity: %v != 0", v)
+	}
+	if v := an.ValueAt(1); v != "90" {
+		t.Errorf("transform at end: %v != 90", v)
+	}
+	if xf := an.XFormString("90"); xf != "translate(90 0)" {
+		t.Errorf("transform string: %v", xf)
+	}
+
+	an = ans[3]
+	if an.Attr != "visibility" || an.CalcMode != "discrete" || len(an.Values) != 1 || an.Begin != time.Second {
+		t.Errorf("unexpected set: %+v", an)
+	}
+	if v := an.ValueAt(0); v != "hidden" {
+		t.Errorf("set value: %v != hidden", v)
+	}
+
+	an = ans[4]
+	if an.XFormType != "scale" || an.Dur != 3*time.Second {
+		t.Errorf("unexpected animateTransform: %+v", an)
+	}
+	if v := an.ValueAt(0.5); v != "2" {
+		t.Errorf("scale by from identity at half way: %v != 2", v)
+	}
+}
+
+func TestParseClockValue(t *testing.T) {
+	tests := []struct {
+		str string
+		dur time.Duration
+	}{
+		{"2s", 2 * time.Second},
+		{"150ms", 150 * time.Millisecond},
+		{"1.5min", 90 * time.Second},
+		{"0.5h", 30 * time.Minute},
+		{"01:30", 90 * time.Second},
+		{"00:01:30.5", 90500 * time.Millisecond},
+		{" 3 ", 3 * time.Second},
+	}
+	for _, ts := range tests {
+		d, err := ParseClockValue(ts.str)
+		if err != nil || d != ts.dur {
+			t.Errorf("%q: %v %v != %v", ts.str, d, err, ts.dur)
+		}
+	}
+	for _, str := range []string{"", "fast", "1:x"} {
+		if _, err := ParseClockValue(str); err == nil {
+			t.Errorf("expected error for: %q", str)
+		}
+	}
+}
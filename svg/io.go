@@ -106,6 +106,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 	inTspn := false
 	var curTspn *Text
 	var defPrevPar gi.Node2D // previous parent before a def encountered
+	var curShape gi.Node2D   // current shape element, which owns nested animation elements
 
 	for {
 		var t xml.Token
@@ -182,6 +183,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 				}
 			case nm == "rect":
 				rect := curPar.AddNewChild(KiT_Rect, "rect").(*Rect)
+				curShape = rect
 				var x, y, w, h, rx, ry float32
 				for _, attr := range se.Attr {
 					if rect.SetStdXMLAttr(attr.Name.Local, attr.Value) {
@@ -212,6 +214,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 				rect.Radius.Set(rx, ry)
 			case nm == "circle":
 				circle := curPar.AddNewChild(KiT_Circle, "circle").(*Circle)
+				curShape = circle
 				var cx, cy, r float32
 				for _, attr := range se.Attr {
 					if circle.SetStdXMLAttr(attr.Name.Local, attr.Value) {
@@ -235,6 +238,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 				circle.Radius = r
 			case nm == "ellipse":
 				ellipse := curPar.AddNewChild(KiT_Ellipse, "ellipse").(*Ellipse)
+				curShape = ellipse
 				var cx, cy, rx, ry float32
 				for _, attr := range se.Attr {
 					if ellipse.SetStdXMLAttr(attr.Name.Local, attr.Value) {
@@ -260,6 +264,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 				ellipse.Radii.Set(rx, ry)
 			case nm == "line":
 				line := curPar.AddNewChild(KiT_Line, "line").(*Line)
+				curShape = line
 				var x1, x2, y1, y2 float32
 				for _, attr := range se.Attr {
 					if line.SetStdXMLAttr(attr.Name.Local, attr.Value) {
@@ -285,6 +290,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 				line.End.Set(x2, y2)
 			case nm == "polygon":
 				polygon := curPar.AddNewChild(KiT_Polygon, "polygon").(*Polygon)
+				curShape = polygon
 				for _, attr := range se.Attr {
 					if polygon.SetStdXMLAttr(attr.Name.Local, attr.Value) {
 						continue
@@ -314,6 +320,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 				}
 			case nm == "polyline":
 				polyline := curPar.AddNewChild(KiT_Polyline, "polyline").(*Polyline)
+				curShape = polyline
 				for _, attr := range se.Attr {
 					if polyline.SetStdXMLAttr(attr.Name.Local, attr.Value) {
 						continue
@@ -343,6 +350,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 				}
 			case nm == "path":
 				path := curPar.AddNewChild(KiT_Path, "path").(*Path)
+				curShape = path
 				for _, attr := range se.Attr {
 					if path.SetStdXMLAttr(attr.Name.Local, attr.Value) {
 						continue
@@ -562,6 +570,29 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 						}
 					}
 				}
+			case nm == "animate" || nm == "animateTransform" || nm == "set":
+				anPar := curPar
+				if curShape != nil {
+					anPar = curShape
+				}
+				an := anPar.AddNewChild(KiT_Animate, nm).(*Animate)
+				if nm == "set" {
+					an.CalcMode = "discrete"
+				}
+				for _, attr := range se.Attr {
+					if an.SetStdXMLAttr(attr.Name.Local, attr.Value) {
+						continue
+					}
+					if !an.SetXMLAttr(attr.Name.Local, attr.Value) {
+						an.SetProp(attr.Name.Local, attr.Value)
+					}
+				}
+				if nm == "animateTransform" && an.XFormType == "" {
+					an.XFormType = "translate"
+				}
+				if nm == "set" && len(an.Values) == 2 {
+					an.Values = an.Values[1:] // just the to value
+				}
 			case nm == "Work":
 				fallthrough
 			case nm == "RDF":
@@ -648,16 +679,14 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 					inDef = false
 					curPar = defPrevPar
 				}
-			case "rect":
-			case "circle":
-			case "ellipse":
-			case "line":
-			case "polygon":
-			case "polyline":
-			case "path":
+			case "rect", "circle", "ellipse", "line", "polygon", "polyline", "path":
+				curShape = nil
 			case "use":
 			case "linearGradient":
 			case "radialGradient":
+			case "animate":
+			case "animateTransform":
+			case "set":
 			default:
 				if curPar == svg.This {
 					break
@@ -8,6 +8,10 @@ import (
 	"log"
 	"reflect"
 
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/dnd"
+	"github.com/goki/gi/oswin/mimedata"
+	"github.com/goki/gi/oswin/mouse"
 	"github.com/goki/gi/units"
 	"github.com/goki/ki"
 	"github.com/goki/ki/kit"
@@ -222,6 +226,8 @@ func (tv *TabView) DeleteTabIndex(idx int, destroy bool) (Node2D, bool) {
 		} else if idx < sz-1 {
 			nxtidx = idx
 		}
+	} else if fr.StackTop > idx { // selected tab moves down
+		nxtidx = fr.StackTop - 1
 	}
 	fr.DeleteChildAtIndex(idx, destroy)
 	tb.DeleteChildAtIndex(idx, true) // always destroy -- we manage
@@ -317,6 +323,7 @@ func (tv *TabView) InitTabView() {
 	tabs.SetProp("margin", units.NewValue(0, units.Px))
 	tabs.SetProp("spacing", units.NewValue(4, units.Px))
 	tabs.SetProp("background-color", "linear-gradient(pref(Control), highlight-10)")
+	tabs.SetProp("access-role", RoleTabList)
 
 	frame := tv.AddNewChild(KiT_Frame, "frame").(*Frame)
 	frame.Lay = LayoutStacked
@@ -501,6 +508,27 @@ func (tb *TabButton) TabView() *TabView {
 	return tv.Embed(KiT_TabView).(*TabView)
 }
 
+// AccessInfo returns the accessibility info for the tab -- selected if it is
+// the current tab
+func (tb *TabButton) AccessInfo() AccessInfo {
+	ai := tb.ButtonBase.AccessInfo()
+	ai.Role = RoleTab
+	ai.SetState(true, AccessSelectable)
+	if !tb.IsInactive() {
+		ai.AddAction(AccessSelect)
+	}
+	return ai
+}
+
+// AccessAction performs accessibility actions for the tab: AccessSelect
+// clicks it
+func (tb *TabButton) AccessAction(act AccessActions, val string) bool {
+	if act == AccessSelect {
+		act = AccessClick
+	}
+	return tb.ButtonBase.AccessAction(act, val)
+}
+
 func (tb *TabButton) ConfigParts() {
 	config := kit.TypeAndNameList{}
 	clsIdx := 0
@@ -531,6 +559,74 @@ func (tb *TabButton) ConfigParts() {
 	}
 }
 
+// TabButtonMouseEvent handles the mouse events for the tab: the standard
+// button press and release, and a context menu on right click if the
+// CtxtMenuFunc is set (e.g., by DockView)
+func (tb *TabButton) TabButtonMouseEvent() {
+	tb.ConnectEvent(oswin.MouseEvent, RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		me := d.(*mouse.Event)
+		bw := recv.(ButtonWidget)
+		tbb := recv.Embed(KiT_TabButton).(*TabButton)
+		switch {
+		case me.Button == mouse.Left && (me.Action == mouse.Press || me.Action == mouse.DoubleClick):
+			me.SetProcessed()
+			tbb.ButtonPressed()
+		case me.Button == mouse.Left && me.Action == mouse.Release:
+			me.SetProcessed()
+			bw.ButtonRelease()
+		case me.Button == mouse.Right && me.Action == mouse.Release && tbb.CtxtMenuFunc != nil:
+			me.SetProcessed()
+			tbb.EmitContextMenuSignal()
+			tbb.This.(Node2D).ContextMenu()
+		}
+	})
+}
+
+// TabButtonDNDEvent handles the start of dragging the tab
+func (tb *TabButton) TabButtonDNDEvent() {
+	tb.ConnectEvent(oswin.DNDEvent, RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		de := d.(*dnd.Event)
+		if de.Action == dnd.Start {
+			tbb := recv.Embed(KiT_TabButton).(*TabButton)
+			tbb.DragNDropStart()
+		}
+	})
+}
+
+// DragNDropStart starts a drag-n-drop of the panel of this tab, if its
+// TabView is managed by a DockView -- dropping it on another part of the
+// DockView docks the panel there
+func (tb *TabButton) DragNDropStart() {
+	tv := tb.TabView()
+	if tv == nil {
+		return
+	}
+	dvk, ok := tv.ParentByType(KiT_DockView, true)
+	if !ok {
+		return
+	}
+	dv := dvk.Embed(KiT_DockView).(*DockView)
+	dn := dv.NodeForView(tv.This.(Node2D))
+	idx := tb.Data.(int)
+	if dn == nil || idx < 0 || idx >= len(dn.Panels) {
+		return
+	}
+	md := mimedata.Mimes{&mimedata.Data{Type: DockPanelMimeType, Data: []byte(dn.Panels[idx])}}
+	bi := &Bitmap{}
+	bi.InitName(bi, tb.UniqueName())
+	bi.GrabRenderFrom(tb)
+	ImageClearer(bi.Pixels, 50.0)
+	tb.Viewport.Win.StartDragNDrop(tb.This, md, bi)
+}
+
+func (tb *TabButton) ConnectEvents2D() {
+	tb.HoverTooltipEvent()
+	tb.TabButtonMouseEvent()
+	tb.MouseFocusEvent()
+	tb.KeyChordEvent()
+	tb.TabButtonDNDEvent()
+}
+
 func (tb *TabButton) Size2D(iter int) {
 	ppref := tb.Parts.LayData.Size.Pref // get from parts
 	spc := tb.Sty.BoxSpace()
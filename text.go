@@ -60,6 +60,8 @@ type RuneRender struct {
 	Size    Vec2D           `desc:"size of the rune itself, exclusive of spacing that might surround it"`
 	RotRad  float32         `desc:"rotation in radians for this character, relative to its lower-left baseline rendering position"`
 	ScaleX  float32         `desc:"scaling of the X dimension, in case of non-uniform scaling, 0 = no separate scaling"`
+	Glyph   rune            `desc:"glyph to draw for this rune as determined by shaping (contextual form, ligature, mirrored glyph) -- 0 = the rune itself, GlyphSkip = nothing, as part of a ligature drawn by a prior rune"`
+	Level   uint8           `desc:"bidi embedding level of this rune -- odd levels are right-to-left -- set by SetBidiLevels"`
 }
 
 // HasNil returns error if any of the key info (face, color) is nil -- only
@@ -128,6 +130,7 @@ type SpanRender struct {
 	LastPos Vec2D           `desc:"rune position for further edge of last rune -- for standard flat strings this is the overall length of the string -- used for size / layout computations -- you do not add RelPos to this -- it is in same TextRender relative coordinates"`
 	Dir     TextDirections  `desc:"where relevant, this is the (default, dominant) text direction for the span"`
 	HasDeco TextDecorations `desc:"mask of decorations that have been set on this span -- optimizes rendering passes"`
+	Visual  bool            `desc:"rune positions have been set in visual order, for bidi text -- otherwise positions always increase in logical order"`
 }
 
 // Init initializes a new span with given capacity
@@ -163,6 +166,9 @@ func (sr *SpanRender) SizeHV() Vec2D {
 	if sz.Y < 0 {
 		sz.Y = -sz.Y
 	}
+	if sr.Visual { // first rune is not at start
+		sz.X = sr.LastPos.X
+	}
 	return sz
 }
 
@@ -221,50 +227,24 @@ func (sr *SpanRender) AppendRune(r rune, face font.Face, clr, bg color.Color, de
 }
 
 // AppendString adds string and associated formatting info, optimized with
-// only first rune having non-nil face and color settings
+// only first rune having non-nil face and color settings -- runes missing
+// from the face get a fallback face (see SetFallbackFaces) based on sty
 func (sr *SpanRender) AppendString(str string, face font.Face, clr, bg color.Color, deco TextDecorations, sty *FontStyle, ctxt *units.Context) {
 	if len(str) == 0 {
 		return
 	}
-	ucfont := FontStyle{}
-	if oswin.TheApp.Platform() == oswin.MacOS {
-		ucfont.Family = "Arial Unicode"
-	} else {
-		ucfont.Family = "Arial"
-	}
-	ucfont.Size = sty.Size
-	ucfont.OpenFont(ctxt)
-
+	st := len(sr.Text)
 	nwr := []rune(str)
 	sz := len(nwr)
 	sr.Text = append(sr.Text, nwr...)
 	rr := RuneRender{Face: face, Color: clr, BgColor: bg, Deco: deco}
-	r := nwr[0]
-	lastUc := false
-	if r > 0xFF && unicode.IsSymbol(r) {
-		rr.Face = ucfont.Face
-		lastUc = true
-	}
 	sr.HasDecoUpdate(bg, deco)
 	sr.Render = append(sr.Render, rr)
 	for i := 1; i < sz; i++ { // optimize by setting rest to nil for same
 		rp := RuneRender{Deco: deco, BgColor: bg}
-		r := nwr[i]
-		if oswin.TheApp.Platform() == oswin.MacOS {
-			if r > 0xFF && unicode.IsSymbol(r) {
-				if !lastUc {
-					rp.Face = ucfont.Face
-					lastUc = true
-				}
-			} else {
-				if lastUc {
-					rp.Face = face
-					lastUc = false
-				}
-			}
-		}
 		sr.Render = append(sr.Render, rp)
 	}
+	sr.SetFallbackFaces(st, face, sty)
 }
 
 // SetRenders sets rendering parameters based on style
@@ -279,11 +259,6 @@ func (sr *SpanRender) SetRenders(sty *FontStyle, ctxt *units.Context, noBG bool,
 		bgc = nil
 	}
 
-	ucfont := FontStyle{}
-	ucfont.Family = "Arial Unicode"
-	ucfont.Size = sty.Size
-	ucfont.OpenFont(ctxt)
-
 	sr.HasDecoUpdate(bgc, sty.Deco)
 	sr.Render = make([]RuneRender, sz)
 	sr.Render[0].Face = sty.Face
@@ -307,21 +282,8 @@ func (sr *SpanRender) SetRenders(sty *FontStyle, ctxt *units.Context, noBG bool,
 			sr.Render[i].Deco = sty.Deco
 		}
 	}
-	// use unicode font for all non-ascii symbols
-	lastUc := false
-	for i, r := range sr.Text {
-		if r > 0xFF && unicode.IsSymbol(r) {
-			if !lastUc {
-				sr.Render[i].Face = ucfont.Face
-				lastUc = true
-			}
-		} else {
-			if lastUc {
-				sr.Render[i].Face = sty.Face
-				lastUc = false
-			}
-		}
-	}
+	// use fallback fonts for any runes missing from the style font
+	sr.SetFallbackFaces(0, sty.Face, sty)
 }
 
 // SetString initializes to given plain text string, with given default style
@@ -348,13 +310,30 @@ var TextFontRenderMu sync.Mutex
 
 // SetRunePosLR sets relative positions of each rune using a flat
 // left-to-right text layout, based on font size info and additional extra
-// letter and word spacing parameters (which can be negative)
+// letter and word spacing parameters (which can be negative) -- runes are
+// shaped (see ShapeLR) and, for bidi text, positioned in visual order (see
+// SetBidiLevels), so RelPos positions do not necessarily increase in
+// logical order
 func (sr *SpanRender) SetRunePosLR(letterSpace, wordSpace, chsz float32, tabSize int) {
+	sr.setRunePosLR(letterSpace, wordSpace, chsz, tabSize, true)
+}
+
+// SetRunePosLogicalLR is like SetRunePosLR except that runes are always
+// positioned in logical order, even for bidi text -- this is used for
+// finding line wrapping positions, prior to the final visual layout of
+// each line
+func (sr *SpanRender) SetRunePosLogicalLR(letterSpace, wordSpace, chsz float32, tabSize int) {
+	sr.setRunePosLR(letterSpace, wordSpace, chsz, tabSize, false)
+}
+
+func (sr *SpanRender) setRunePosLR(letterSpace, wordSpace, chsz float32, tabSize int, visual bool) {
 	if err := sr.IsValid(); err != nil {
 		// log.Println(err)
 		return
 	}
-	sr.Dir = LRTB
+	if sr.Dir != RLTB {
+		sr.Dir = LRTB
+	}
 	sz := len(sr.Text)
 	prevR := rune(-1)
 	lspc := letterSpace
@@ -363,18 +342,27 @@ func (sr *SpanRender) SetRunePosLR(letterSpace, wordSpace, chsz float32, tabSize
 		tabSize = 4
 	}
 	var fpos float32
-	curFace := sr.Render[0].Face
 	TextFontRenderMu.Lock()
 	defer TextFontRenderMu.Unlock()
+	faces := sr.Faces()
+	sr.ShapeLR(faces, letterSpace == 0)
+	var order []int
+	sr.Visual = false
+	if visual && sr.HasBidi() {
+		order = sr.VisualOrder()
+		sr.Visual = true
+	}
 	col := 0 // current column position -- todo: does NOT deal with indent
-	for i, r := range sr.Text {
+	for vi := 0; vi < sz; vi++ {
+		i := vi
+		if order != nil {
+			i = order[vi]
+		}
+		r := sr.Text[i]
 		rr := &(sr.Render[i])
-		curFace = rr.CurFace(curFace)
+		curFace := faces[i]
 
 		fht := FixedToFloat32(curFace.Metrics().Height)
-		if prevR >= 0 {
-			fpos += FixedToFloat32(curFace.Kern(prevR, r))
-		}
 		rr.RelPos.X = fpos
 		rr.RelPos.Y = 0
 
@@ -385,9 +373,26 @@ func (sr *SpanRender) SetRunePosLR(letterSpace, wordSpace, chsz float32, tabSize
 			rr.RelPos.Y = 0.15 * FixedToFloat32(curFace.Metrics().Ascent)
 		}
 
+		if rr.Glyph == GlyphSkip || IsZeroWidth(r) {
+			rr.Size = Vec2D{0, fht}
+			continue
+		}
+		gr := r
+		if rr.Glyph != 0 {
+			gr = rr.Glyph
+		}
+		if prevR >= 0 {
+			fpos += FixedToFloat32(curFace.Kern(prevR, gr))
+			rr.RelPos.X = fpos
+		}
+
 		// todo: could check for various types of special unicode space chars here
-		a, _ := curFace.GlyphAdvance(r)
+		a, _ := curFace.GlyphAdvance(gr)
 		a32 := FixedToFloat32(a)
+		if IsCombiningMark(r) { // positioned by PlaceMarksLR
+			rr.Size = Vec2D{a32, fht}
+			continue
+		}
 		if a32 == 0 {
 			a32 = .1 * fht // something..
 		}
@@ -404,17 +409,19 @@ func (sr *SpanRender) SetRunePosLR(letterSpace, wordSpace, chsz float32, tabSize
 		} else {
 			fpos += a32
 			col++
-			if i < sz-1 {
+			if vi < sz-1 {
 				fpos += lspc
 				if unicode.IsSpace(r) {
 					fpos += wspc
 				}
 			}
 		}
-		prevR = r
+		prevR = gr
 	}
 	sr.LastPos.X = fpos
 	sr.LastPos.Y = 0
+	sr.PlaceMarksLR()
+	sr.ReorderIndicLR()
 }
 
 // FindWrapPosLR finds a position to do word wrapping to fit within trgSize --
@@ -758,9 +765,27 @@ func (tr *TextRender) Render(rs *RenderState, pos Vec2D) {
 				int(math32.Ceil(ur.X)) < rs.Bounds.Min.X || int(math32.Ceil(ll.Y)) < rs.Bounds.Min.Y {
 				continue
 			}
+			if rr.Glyph == GlyphSkip {
+				continue
+			}
+			gr := r
+			if rr.Glyph != 0 {
+				gr = rr.Glyph
+			}
 			d.Face = curFace
 			d.Dot = rp.Fixed()
-			dr, mask, maskp, _, ok := d.Face.Glyph(d.Dot, r)
+			var src image.Image
+			var dr image.Rectangle
+			var mask image.Image
+			var maskp image.Point
+			ok := false
+			if cf, isc := curFace.(*ColorFace); isc {
+				dr, src, ok = cf.ColorGlyph(d.Dot, gr, curColor) // full color image, no mask
+			}
+			if !ok {
+				src = d.Src
+				dr, mask, maskp, _, ok = d.Face.Glyph(d.Dot, gr)
+			}
 			if !ok {
 				// fmt.Printf("not ok rendering rune: %v\n", string(r))
 				continue
@@ -768,7 +793,11 @@ func (tr *TextRender) Render(rs *RenderState, pos Vec2D) {
 			idr := dr.Intersect(rs.Bounds)
 			soff := idr.Min.Sub(dr.Min)
 			if rr.RotRad == 0 && (rr.ScaleX == 0 || rr.ScaleX == 1) {
-				draw.DrawMask(d.Dst, idr, d.Src, soff, mask, maskp, draw.Over)
+				if mask == nil {
+					draw.Draw(d.Dst, idr, src, soff, draw.Over)
+				} else {
+					draw.DrawMask(d.Dst, idr, d.Src, soff, mask, maskp, draw.Over)
+				}
 			} else {
 				srect := dr.Sub(dr.Min)
 				dbase := Vec2D{rp.X - float32(dr.Min.X), rp.Y - float32(dr.Min.Y)}
@@ -777,7 +806,7 @@ func (tr *TextRender) Render(rs *RenderState, pos Vec2D) {
 				fx, fy := float32(dr.Min.X), float32(dr.Min.Y)
 				m := Translate2D(fx+dbase.X, fy+dbase.Y).Scale(scx, 1).Rotate(rr.RotRad).Translate(-dbase.X, -dbase.Y)
 				s2d := f64.Aff3{float64(m.XX), float64(m.XY), float64(m.X0), float64(m.YX), float64(m.YY), float64(m.Y0)}
-				transformer.Transform(d.Dst, s2d, d.Src, srect, draw.Over, &draw.Options{
+				transformer.Transform(d.Dst, s2d, src, srect, draw.Over, &draw.Options{
 					SrcMask:  mask,
 					SrcMaskP: maskp,
 				})
@@ -983,7 +1012,7 @@ func (tr *TextRender) RenderTopPos(rs *RenderState, tpos Vec2D) {
 
 // SetString is for basic text rendering with a single style of text (see
 // SetHTML for tag-formatted text) -- configures a single SpanRender with the
-// entire string, and does standard layout (LR currently).  rot and scalex are
+// entire string, and does standard layout (LR, with bidi reordering).  rot and scalex are
 // general rotation and x-scaling to apply to all chars -- alternatively can
 // apply these per character after.  Be sure that OpenFont has been run so a
 // valid Face is available.  noBG ignores any BgColor in font style, and never
@@ -995,6 +1024,7 @@ func (tr *TextRender) SetString(str string, fontSty *FontStyle, ctxt *units.Cont
 	tr.Links = nil
 	sr := &(tr.Spans[0])
 	sr.SetString(str, fontSty, ctxt, noBG, rot, scalex)
+	tr.SetBidi(txtSty)
 	sr.SetRunePosLR(txtSty.LetterSpacing.Dots, txtSty.WordSpacing.Dots, fontSty.Ch, txtSty.TabSize)
 	ssz := sr.SizeHV()
 	vht := fontSty.Face.Metrics().Height
@@ -1004,7 +1034,7 @@ func (tr *TextRender) SetString(str string, fontSty *FontStyle, ctxt *units.Cont
 
 // SetRunes is for basic text rendering with a single style of text (see
 // SetHTML for tag-formatted text) -- configures a single SpanRender with the
-// entire string, and does standard layout (LR currently).  rot and scalex are
+// entire string, and does standard layout (LR, with bidi reordering).  rot and scalex are
 // general rotation and x-scaling to apply to all chars -- alternatively can
 // apply these per character after Be sure that OpenFont has been run so a
 // valid Face is available.  noBG ignores any BgColor in font style, and never
@@ -1016,6 +1046,7 @@ func (tr *TextRender) SetRunes(str []rune, fontSty *FontStyle, ctxt *units.Conte
 	tr.Links = nil
 	sr := &(tr.Spans[0])
 	sr.SetRunes(str, fontSty, ctxt, noBG, rot, scalex)
+	tr.SetBidi(txtSty)
 	sr.SetRunePosLR(txtSty.LetterSpacing.Dots, txtSty.WordSpacing.Dots, fontSty.Ch, txtSty.TabSize)
 	ssz := sr.SizeHV()
 	vht := fontSty.Face.Metrics().Height
@@ -1261,7 +1292,7 @@ func (tr *TextRender) SetHTMLNoPre(str []byte, font *FontStyle, txtSty *TextStyl
 					return unicode.IsSpace(r)
 				})
 			}
-			curSp.AppendString(sstr, curf.Face, curf.Color, curf.BgColor.ColorOrNil(), curf.Deco, font, ctxt)
+			curSp.AppendString(sstr, curf.Face, curf.Color, curf.BgColor.ColorOrNil(), curf.Deco, curf, ctxt)
 			if nextIsParaStart && atStart {
 				curSp.SetNewPara()
 			}
@@ -1272,6 +1303,7 @@ func (tr *TextRender) SetHTMLNoPre(str []byte, font *FontStyle, txtSty *TextStyl
 			}
 		}
 	}
+	tr.SetBidi(txtSty)
 }
 
 // SetHTMLPre sets preformatted HTML-styled text by decoding all standard
@@ -1317,7 +1349,7 @@ func (tr *TextRender) SetHTMLPre(str []byte, font *FontStyle, txtSty *TextStyle,
 				bidx += eidx + 2
 			} else { // get past <
 				curf := fstack[len(fstack)-1]
-				curSp.AppendString(string(str[bidx:bidx+1]), curf.Face, curf.Color, curf.BgColor.ColorOrNil(), curf.Deco, font, ctxt)
+				curSp.AppendString(string(str[bidx:bidx+1]), curf.Face, curf.Color, curf.BgColor.ColorOrNil(), curf.Deco, curf, ctxt)
 				bidx++
 			}
 		}
@@ -1469,7 +1501,7 @@ func (tr *TextRender) SetHTMLPre(str []byte, font *FontStyle, txtSty *TextStyle,
 					}
 				case '\n': // todo absorb other line endings
 					unestr := html.UnescapeString(string(tmpbuf))
-					curSp.AppendString(unestr, curf.Face, curf.Color, curf.BgColor.ColorOrNil(), curf.Deco, font, ctxt)
+					curSp.AppendString(unestr, curf.Face, curf.Color, curf.BgColor.ColorOrNil(), curf.Deco, curf, ctxt)
 					tmpbuf = tmpbuf[0:0]
 					tr.Spans = append(tr.Spans, SpanRender{})
 					curSp = &(tr.Spans[len(tr.Spans)-1])
@@ -1482,7 +1514,7 @@ func (tr *TextRender) SetHTMLPre(str []byte, font *FontStyle, txtSty *TextStyle,
 			if !didNl {
 				unestr := html.UnescapeString(string(tmpbuf))
 				// fmt.Printf("%v added: %v\n", bidx, unestr)
-				curSp.AppendString(unestr, curf.Face, curf.Color, curf.BgColor.ColorOrNil(), curf.Deco, font, ctxt)
+				curSp.AppendString(unestr, curf.Face, curf.Color, curf.BgColor.ColorOrNil(), curf.Deco, curf, ctxt)
 				if curLinkIdx >= 0 {
 					tl := &tr.Links[curLinkIdx]
 					tl.Label = unestr
@@ -1490,6 +1522,47 @@ func (tr *TextRender) SetHTMLPre(str []byte, font *FontStyle, txtSty *TextStyle,
 			}
 		}
 	}
+	tr.SetBidi(txtSty)
+}
+
+// TextStyleRun is a run of runes rendered with the same font style, for
+// SetStyledRunes -- it extends from the end of the previous run
+type TextStyleRun struct {
+	Ed   int        `desc:"ending rune index (exclusive) of the run"`
+	Font *FontStyle `desc:"font style for the run -- must have been opened with OpenFont -- nil uses the default font"`
+}
+
+// SetStyledRunes sets a single line of text with per-run font styles
+// directly, e.g., from syntax highlighting tokens, without the cost of
+// encoding and decoding HTML markup -- runes beyond the last run use the
+// default font style
+func (tr *TextRender) SetStyledRunes(str []rune, runs []TextStyleRun, font *FontStyle, txtSty *TextStyle, ctxt *units.Context) {
+	tr.Spans = make([]SpanRender, 1)
+	tr.Links = nil
+	sz := len(str)
+	if sz == 0 {
+		return
+	}
+	sr := &(tr.Spans[0])
+	sr.Init(sz)
+	font.OpenFont(ctxt)
+	st := 0
+	for _, rn := range runs {
+		ed := ints.MinInt(rn.Ed, sz)
+		if ed <= st {
+			continue
+		}
+		fs := rn.Font
+		if fs == nil {
+			fs = font
+		}
+		sr.AppendString(string(str[st:ed]), fs.Face, fs.Color, fs.BgColor.ColorOrNil(), fs.Deco, fs, ctxt)
+		st = ed
+	}
+	if st < sz {
+		sr.AppendString(string(str[st:]), font.Face, font.Color, font.BgColor.ColorOrNil(), font.Deco, font, ctxt)
+	}
+	tr.SetBidi(txtSty)
 }
 
 // RuneSpanPos returns the position (span, rune index within span) within a
@@ -1593,7 +1666,7 @@ type TextStyle struct {
 	LineHeight       float32        `xml:"line-height" inherit:"true" desc:"specified height of a line of text, in proportion to default font height, 0 = 1 = normal (todo: specific values such as pixels are not supported, in order to properly support percentage) -- text is centered within the overall lineheight"`
 	WhiteSpace       WhiteSpaces    `xml:"white-space" inherit:"true" desc:"specifies how white space is processed, and how lines are wrapped"`
 	UnicodeBidi      UnicodeBidi    `xml:"unicode-bidi" inherit:"true" desc:"determines how to treat unicode bidirectional information"`
-	Direction        TextDirections `xml:"direction" inherit:"true" desc:"direction of text -- only applicable for unicode-bidi = bidi-override or embed -- applies to all text elements"`
+	Direction        TextDirections `xml:"direction" inherit:"true" desc:"base direction of paragraphs of text (rtl or ltr) -- by default (lrtb) it is determined by the first strongly directional character in each paragraph -- with unicode-bidi = bidi-override all text is laid out in this direction"`
 	WritingMode      TextDirections `xml:"writing-mode" inherit:"true" desc:"overall writing mode -- only for text elements, not tspan"`
 	OrientationVert  float32        `xml:"glyph-orientation-vertical" inherit:"true" desc:"for TBRL writing mode (only), determines orientation of alphabetic characters -- 90 is default (rotated) -- 0 means keep upright"`
 	OrientationHoriz float32        `xml:"glyph-orientation-horizontal" inherit:"true" desc:"for horizontal LR/RL writing mode (only), determines orientation of all characters -- 0 is default (upright)"`
@@ -1732,6 +1805,24 @@ func (ts *TextStyle) HasPre() bool {
 	}
 }
 
+// BidiParaLevel returns the bidi paragraph embedding level specified by the
+// Direction style (0 = LTR, 1 = RTL, -1 = determined from the text) and
+// whether the UnicodeBidi style specifies a directional override
+func (ts *TextStyle) BidiParaLevel() (int, bool) {
+	lev := -1
+	switch ts.Direction {
+	case RLTB, RL, RTL:
+		lev = 1
+	case LR, LTR:
+		lev = 0
+	}
+	ovr := ts.UnicodeBidi == BidiBidiOverride
+	if ovr && lev < 0 {
+		lev = 0
+	}
+	return lev, ovr
+}
+
 func (ts *TextStyle) Defaults() {
 	ts.LineHeight = 1
 	ts.Align = AlignLeft
@@ -1815,7 +1906,6 @@ func (tr *TextRender) LayoutStdLR(txtSty *TextStyle, fontSty *FontStyle, ctxt *u
 	pr := prof.Start("TextRenderLayout")
 	defer pr.End()
 
-	tr.Dir = LRTB
 	fontSty.OpenFont(ctxt)
 	fht := fontSty.Height
 	dsc := FixedToFloat32(fontSty.Face.Metrics().Descent)
@@ -1833,8 +1923,8 @@ func (tr *TextRender) LayoutStdLR(txtSty *TextStyle, fontSty *FontStyle, ctxt *u
 			si++
 			continue
 		}
-		if sr.LastPos.X == 0 { // don't re-do unless necessary
-			sr.SetRunePosLR(txtSty.LetterSpacing.Dots, txtSty.WordSpacing.Dots, fontSty.Ch, txtSty.TabSize)
+		if sr.LastPos.X == 0 || sr.Visual { // don't re-do unless necessary
+			sr.SetRunePosLogicalLR(txtSty.LetterSpacing.Dots, txtSty.WordSpacing.Dots, fontSty.Ch, txtSty.TabSize)
 		}
 		if sr.IsNewPara() {
 			sr.RelPos.X = txtSty.Indent.Dots
@@ -1856,7 +1946,7 @@ func (tr *TextRender) LayoutStdLR(txtSty *TextStyle, fontSty *FontStyle, ctxt *u
 					}
 					si++
 					sr = &(tr.Spans[si]) // keep going with nsr
-					sr.SetRunePosLR(txtSty.LetterSpacing.Dots, txtSty.WordSpacing.Dots, fontSty.Ch, txtSty.TabSize)
+					sr.SetRunePosLogicalLR(txtSty.LetterSpacing.Dots, txtSty.WordSpacing.Dots, fontSty.Ch, txtSty.TabSize)
 					ssz = sr.SizeHV()
 
 					// fixup links
@@ -1900,6 +1990,14 @@ func (tr *TextRender) LayoutStdLR(txtSty *TextStyle, fontSty *FontStyle, ctxt *u
 		}
 		si++
 	}
+	// final visual layout of bidi text, now that lines are known
+	for si := range tr.Spans {
+		sr := &(tr.Spans[si])
+		if sr.IsValid() == nil && sr.HasBidi() {
+			sr.SetRunePosLR(txtSty.LetterSpacing.Dots, txtSty.WordSpacing.Dots, fontSty.Ch, txtSty.TabSize)
+		}
+	}
+
 	// have maxw, can do alignment cases..
 
 	// make sure links are still in range
@@ -1291,3 +1291,36 @@ func (tf *TextField) FocusChanged2D(change FocusChanges) {
 		// todo: see about cursor
 	}
 }
+
+// AccessInfo returns the accessibility info for the text field -- the value
+// is the current edited text
+func (tf *TextField) AccessInfo() AccessInfo {
+	ai := tf.WidgetBase.AccessInfo()
+	ai.Role = RoleTextField
+	ai.Name = tf.Placeholder
+	if tf.Edited {
+		ai.Value = string(tf.EditTxt)
+	} else {
+		ai.Value = tf.Txt
+	}
+	ai.SetState(!tf.IsInactive(), AccessEditable)
+	if !tf.IsInactive() {
+		ai.AddAction(AccessSetValue)
+	}
+	return ai
+}
+
+// AccessAction performs accessibility actions for the text field:
+// AccessSetValue sets the text and completes the edit, sending the
+// TextFieldDone signal
+func (tf *TextField) AccessAction(act AccessActions, val string) bool {
+	if act == AccessSetValue {
+		if tf.IsInactive() {
+			return false
+		}
+		tf.SetText(val)
+		tf.TextFieldSig.Emit(tf.This, int64(TextFieldDone), tf.Txt)
+		return true
+	}
+	return tf.AccessActionBase(act, val)
+}
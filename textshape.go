@@ -0,0 +1,542 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"math"
+	"unicode"
+
+	"github.com/chewxy/math32"
+	"golang.org/x/image/font"
+)
+
+// textshape.go contains the complex-script shaping and bidi layout support
+// for SpanRender -- Text and Render always remain in one-to-one logical
+// order, so that all indexing (cursor positions, selections, links) is
+// unaffected -- shaping only substitutes the glyph that is drawn for a rune
+// (RuneRender.Glyph), and bidi reordering only affects the rune RelPos
+// positions.  Shaping uses the presentation forms and ligature glyphs that
+// are available in the font: Arabic contextual joining and lam-alef
+// ligatures, standard Latin ligatures, combining mark positioning over
+// their base, mirrored glyphs in right-to-left text, and visual reordering
+// of pre-base vowel signs in Indic scripts.
+
+// GlyphSkip is the RuneRender.Glyph value for runes that are not drawn
+// because they are part of a ligature drawn by a prior rune
+const GlyphSkip = rune(-1)
+
+// Faces returns the font face for each rune in the span, resolving the nil
+// faces that use the prior non-nil face
+func (sr *SpanRender) Faces() []font.Face {
+	faces := make([]font.Face, len(sr.Render))
+	var curFace font.Face
+	for i := range sr.Render {
+		curFace = sr.Render[i].CurFace(curFace)
+		faces[i] = curFace
+	}
+	return faces
+}
+
+// HasBidi returns true if the span has any text that is not at the
+// left-to-right base level, and thus requires bidi reordering
+func (sr *SpanRender) HasBidi() bool {
+	for i := range sr.Render {
+		if sr.Render[i].Level > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SetBidiLevels computes the bidi embedding levels of the runes in this span
+// (as one paragraph), according to the direction and unicode-bidi text
+// styles -- sets Dir to RLTB for right-to-left paragraphs.  This must be
+// done before any line wrapping of the span.
+func (sr *SpanRender) SetBidiLevels(txtSty *TextStyle) {
+	plev, ovr := txtSty.BidiParaLevel()
+	levels, pl := BidiLevels(sr.Text, plev, ovr)
+	for i := range sr.Render {
+		if i < len(levels) {
+			sr.Render[i].Level = levels[i]
+		} else {
+			sr.Render[i].Level = pl
+		}
+	}
+	if pl == 1 {
+		sr.Dir = RLTB
+	} else {
+		sr.Dir = LRTB
+	}
+}
+
+// VisualOrder returns the logical rune index for each rune in left-to-right
+// visual order, for this span as one line of text (rule L1 is applied to
+// the levels)
+func (sr *SpanRender) VisualOrder() []int {
+	plev := uint8(0)
+	if sr.Dir == RLTB {
+		plev = 1
+	}
+	levels := make([]uint8, len(sr.Render))
+	for i := range sr.Render {
+		levels[i] = sr.Render[i].Level
+	}
+	BidiLineLevels(sr.Text, levels, plev)
+	return BidiVisualOrder(levels)
+}
+
+// ShapeLR sets the Glyph for each rune according to its context, for
+// horizontal text, using given faces (see Faces) -- ligatures are only used
+// if ligs is true (they are not used with letter spacing).  Must be called
+// with TextFontRenderMu locked.
+func (sr *SpanRender) ShapeLR(faces []font.Face, ligs bool) {
+	txt := sr.Text
+	sz := len(txt)
+	for i := range sr.Render {
+		rr := &sr.Render[i]
+		rr.Glyph = 0
+		if rr.Level&1 == 1 {
+			if mr := BidiMirror(txt[i]); mr != 0 && FaceHasGlyph(faces[i], mr) {
+				rr.Glyph = mr
+			}
+		}
+	}
+	for i := 0; i < sz; i++ {
+		r := txt[i]
+		switch {
+		case r >= 0x0600 && r <= 0x06FF:
+			sr.shapeArabic(faces, i)
+		case ligs && r == 'f':
+			sr.shapeLatinLig(faces, i)
+		}
+	}
+}
+
+// Arabic joining types
+const (
+	joinNone = iota
+	joinRight
+	joinDual
+	joinCausing
+	joinTransparent
+)
+
+// arabicForm records the isolated presentation form of an Arabic letter, and
+// its number of forms: 4 = isolated, final, initial, medial (dual joining),
+// 2 = isolated, final (right joining only)
+type arabicForm struct {
+	isol  rune
+	forms int
+}
+
+// arabicForms are the presentation forms for Arabic letters (including the
+// most common Persian and Urdu letters)
+var arabicForms = map[rune]arabicForm{
+	0x0622: {0xFE81, 2}, 0x0623: {0xFE83, 2}, 0x0624: {0xFE85, 2}, 0x0625: {0xFE87, 2},
+	0x0626: {0xFE89, 4}, 0x0627: {0xFE8D, 2}, 0x0628: {0xFE8F, 4}, 0x0629: {0xFE93, 2},
+	0x062A: {0xFE95, 4}, 0x062B: {0xFE99, 4}, 0x062C: {0xFE9D, 4}, 0x062D: {0xFEA1, 4},
+	0x062E: {0xFEA5, 4}, 0x062F: {0xFEA9, 2}, 0x0630: {0xFEAB, 2}, 0x0631: {0xFEAD, 2},
+	0x0632: {0xFEAF, 2}, 0x0633: {0xFEB1, 4}, 0x0634: {0xFEB5, 4}, 0x0635: {0xFEB9, 4},
+	0x0636: {0xFEBD, 4}, 0x0637: {0xFEC1, 4}, 0x0638: {0xFEC5, 4}, 0x0639: {0xFEC9, 4},
+	0x063A: {0xFECD, 4}, 0x0641: {0xFED1, 4}, 0x0642: {0xFED5, 4}, 0x0643: {0xFED9, 4},
+	0x0644: {0xFEDD, 4}, 0x0645: {0xFEE1, 4}, 0x0646: {0xFEE5, 4}, 0x0647: {0xFEE9, 4},
+	0x0648: {0xFEED, 2}, 0x0649: {0xFEEF, 2}, 0x064A: {0xFEF1, 4},
+	0x067E: {0xFB56, 4}, 0x0686: {0xFB7A, 4}, 0x0698: {0xFB8A, 2}, 0x06A9: {0xFB8E, 4},
+	0x06AF: {0xFB92, 4}, 0x06CC: {0xFBFC, 4},
+}
+
+// lamAlef are the isolated lam-alef ligature forms for each alef (the final
+// form is the next code point)
+var lamAlef = map[rune]rune{
+	0x0622: 0xFEF5, 0x0623: 0xFEF7, 0x0625: 0xFEF9, 0x0627: 0xFEFB,
+}
+
+// arabicJoining returns the joining type of given rune
+func arabicJoining(r rune) int {
+	if af, ok := arabicForms[r]; ok {
+		if af.forms == 4 {
+			return joinDual
+		}
+		return joinRight
+	}
+	switch {
+	case r == 0x0640 || r == 0x200D: // tatweel, zwj
+		return joinCausing
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return joinTransparent
+	}
+	return joinNone
+}
+
+// arabicPrevJoins returns true if the rune before i (skipping transparent
+// marks) joins to the following rune
+func (sr *SpanRender) arabicPrevJoins(i int) bool {
+	for p := i - 1; p >= 0; p-- {
+		switch arabicJoining(sr.Text[p]) {
+		case joinTransparent:
+			continue
+		case joinDual, joinCausing:
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// arabicNext returns the index of the next rune after i that is not a
+// transparent mark, and whether it joins to the preceding rune
+func (sr *SpanRender) arabicNext(i int) (int, bool) {
+	for n := i + 1; n < len(sr.Text); n++ {
+		switch arabicJoining(sr.Text[n]) {
+		case joinTransparent:
+			continue
+		case joinDual, joinCausing, joinRight:
+			return n, true
+		default:
+			return n, false
+		}
+	}
+	return -1, false
+}
+
+// shapeArabic sets the contextual form for the Arabic rune at i
+func (sr *SpanRender) shapeArabic(faces []font.Face, i int) {
+	r := sr.Text[i]
+	af, ok := arabicForms[r]
+	rr := &sr.Render[i]
+	if !ok || rr.Glyph == GlyphSkip {
+		return
+	}
+	prev := sr.arabicPrevJoins(i)
+	if r == 0x0644 && i+1 < len(sr.Text) { // lam-alef ligature
+		if lig, ok := lamAlef[sr.Text[i+1]]; ok {
+			if prev {
+				lig++
+			}
+			if FaceHasGlyph(faces[i], lig) {
+				rr.Glyph = lig
+				sr.Render[i+1].Glyph = GlyphSkip
+				return
+			}
+		}
+	}
+	next := false
+	if af.forms == 4 {
+		_, next = sr.arabicNext(i)
+	}
+	form := af.isol
+	switch {
+	case prev && next:
+		form += 3
+	case prev:
+		form++
+	case next:
+		form += 2
+	}
+	if FaceHasGlyph(faces[i], form) {
+		rr.Glyph = form
+	}
+}
+
+// latinLigs are the standard Latin ligatures, longest first
+var latinLigs = []struct {
+	str string
+	lig rune
+}{
+	{"ffi", 0xFB03}, {"ffl", 0xFB04}, {"ff", 0xFB00}, {"fi", 0xFB01}, {"fl", 0xFB02},
+}
+
+// shapeLatinLig uses a Latin ligature starting at i if one applies and the
+// font has it -- all runes must be in the same face
+func (sr *SpanRender) shapeLatinLig(faces []font.Face, i int) {
+	if sr.Render[i].Glyph != 0 {
+		return
+	}
+	for _, ll := range latinLigs {
+		n := len(ll.str)
+		if i+n > len(sr.Text) || string(sr.Text[i:i+n]) != ll.str {
+			continue
+		}
+		same := true
+		for j := i + 1; j < i+n; j++ {
+			if faces[j] != faces[i] || sr.Render[j].Deco != sr.Render[i].Deco {
+				same = false
+			}
+		}
+		if !same || !FaceHasGlyph(faces[i], ll.lig) {
+			continue
+		}
+		sr.Render[i].Glyph = ll.lig
+		for j := i + 1; j < i+n; j++ {
+			sr.Render[j].Glyph = GlyphSkip
+		}
+		return
+	}
+}
+
+// IsCombiningMark returns true if rune is a non-spacing or enclosing
+// combining mark, which is drawn over or under its base rune and takes no
+// space of its own
+func IsCombiningMark(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me)
+}
+
+// IsZeroWidth returns true if rune is a zero-width format character (e.g.,
+// bidi controls, zero-width joiners) or variation selector (e.g., emoji
+// presentation selector)
+func IsZeroWidth(r rune) bool {
+	return unicode.Is(unicode.Cf, r) || unicode.Is(unicode.Variation_Selector, r)
+}
+
+// PlaceMarksLR positions combining marks relative to their base rune, after
+// RelPos has been set for all runes -- marks with their own advance are
+// centered over the base, and zero-advance marks (which fonts design to
+// extend back over the prior glyph) are positioned at the end of the base.
+// Mark Size.X is then set to 0, as marks take no space.
+func (sr *SpanRender) PlaceMarksLR() {
+	base := -1
+	for i, r := range sr.Text {
+		rr := &sr.Render[i]
+		if !IsCombiningMark(r) || rr.Glyph == GlyphSkip {
+			if !IsZeroWidth(r) {
+				base = i
+			}
+			continue
+		}
+		if base < 0 {
+			continue
+		}
+		br := &sr.Render[base]
+		if rr.Size.X > 0 {
+			rr.RelPos.X = br.RelPos.X + 0.5*(br.Size.X-rr.Size.X)
+		} else {
+			rr.RelPos.X = br.RelPos.X + br.Size.X
+		}
+		rr.Size.X = 0
+	}
+}
+
+// indicPreBase are the Indic vowel signs that are written before the
+// consonant cluster that they follow in logical order
+var indicPreBase = map[rune]bool{
+	0x093F: true,                             // devanagari i
+	0x09BF: true, 0x09C7: true, 0x09C8: true, // bengali i, e, ai
+	0x0A3F: true,                             // gurmukhi i
+	0x0ABF: true,                             // gujarati i
+	0x0B47: true,                             // oriya e
+	0x0BC6: true, 0x0BC7: true, 0x0BC8: true, // tamil e, ee, ai
+	0x0D46: true, 0x0D47: true, 0x0D48: true, // malayalam e, ee, ai
+	0x0DD9: true, 0x0DDA: true, 0x0DDB: true, // sinhala e, ee, ai
+}
+
+// isVirama returns true if rune is an Indic virama (halant), which joins
+// consonants into a cluster
+func isVirama(r rune) bool {
+	if r >= 0x0900 && r <= 0x0D7F {
+		return r&0x7F == 0x4D
+	}
+	return r == 0x0DCA
+}
+
+// isIndicLetter returns true if rune is a letter in one of the Indic
+// scripts, which a vowel sign can follow in a cluster
+func isIndicLetter(r rune) bool {
+	return r >= 0x0900 && r <= 0x0DFF && unicode.IsLetter(r)
+}
+
+// ReorderIndicLR moves pre-base vowel signs visually in front of the
+// consonant cluster that they follow logically, after RelPos has been set
+// for all runes -- the cluster is shifted over by the width of the sign.
+func (sr *SpanRender) ReorderIndicLR() {
+	txt := sr.Text
+	for i := 1; i < len(txt); i++ {
+		if !indicPreBase[txt[i]] || sr.Render[i].Level&1 == 1 {
+			continue
+		}
+		st := -1
+		k := i - 1
+		for k >= 0 {
+			for k >= 0 && IsCombiningMark(txt[k]) && !isVirama(txt[k]) { // nukta etc
+				k--
+			}
+			if k < 0 || !isIndicLetter(txt[k]) {
+				break
+			}
+			st = k
+			if k > 0 && isVirama(txt[k-1]) {
+				k -= 2
+				continue
+			}
+			break
+		}
+		if st < 0 {
+			continue
+		}
+		x0 := sr.Render[st].RelPos.X
+		wd := sr.Render[i].Size.X
+		for j := st; j < i; j++ {
+			sr.Render[j].RelPos.X += wd
+		}
+		sr.Render[i].RelPos.X = x0
+	}
+}
+
+// faceGlyphs caches whether each face has a glyph for given runes
+var faceGlyphs = map[font.Face]map[rune]bool{}
+
+// FaceHasGlyph returns true if the face has a real glyph for given rune,
+// not just the missing glyph -- results are cached.  Must be called with
+// TextFontRenderMu locked.
+func FaceHasGlyph(face font.Face, r rune) bool {
+	fg, ok := faceGlyphs[face]
+	if !ok {
+		fg = make(map[rune]bool)
+		faceGlyphs[face] = fg
+	}
+	if has, ok := fg[r]; ok {
+		return has
+	}
+	has := false
+	if bb, adv, ok := face.GlyphBounds(r); ok {
+		// faces typically return the missing glyph (index 0) for unknown
+		// runes, so compare against a noncharacter
+		mbb, madv, mok := face.GlyphBounds(0xFFFF)
+		has = !mok || bb != mbb || adv != madv
+	}
+	fg[r] = has
+	return has
+}
+
+// CaretPosLR returns the X position (relative to the span RelPos) of the
+// text caret (insertion point) before the given logical rune index, for
+// horizontal text -- for right-to-left runes this is the right edge of the
+// rune -- an index of len(Text) gives the caret position after the last
+// rune in logical order.
+func (sr *SpanRender) CaretPosLR(idx int) float32 {
+	sz := len(sr.Render)
+	if sz == 0 {
+		return 0
+	}
+	if idx >= sz {
+		rr := &sr.Render[sz-1]
+		if rr.Level&1 == 1 {
+			return rr.RelPos.X
+		}
+		return rr.RelPosAfterLR()
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	rr := &sr.Render[idx]
+	if rr.Level&1 == 1 {
+		return rr.RelPosAfterLR()
+	}
+	return rr.RelPos.X
+}
+
+// CaretIdxAtLR returns the logical caret index (0 - len(Text)) whose caret
+// position is closest to given X position (relative to the span RelPos),
+// for horizontal text -- used for mouse hit-testing.
+func (sr *SpanRender) CaretIdxAtLR(x float32) int {
+	best := 0
+	bestd := float32(math.MaxFloat32)
+	for i := 0; i <= len(sr.Render); i++ {
+		d := math32.Abs(sr.CaretPosLR(i) - x)
+		if d < bestd {
+			best = i
+			bestd = d
+		}
+	}
+	return best
+}
+
+// VisualMoveLR returns the logical caret index after moving the caret one
+// position visually to the right (dir > 0) or left (dir < 0) from the caret
+// at given logical index, for horizontal text -- returns -1 if the caret is
+// already at the visual edge of the span.
+func (sr *SpanRender) VisualMoveLR(idx, dir int) int {
+	cx := sr.CaretPosLR(idx)
+	best := -1
+	bestd := float32(0)
+	for i := 0; i <= len(sr.Render); i++ {
+		if i == idx {
+			continue
+		}
+		d := (sr.CaretPosLR(i) - cx) * float32(dir)
+		if d > 0.01 && (best < 0 || d < bestd) {
+			best = i
+			bestd = d
+		}
+	}
+	return best
+}
+
+// SetBidi computes the bidi embedding levels for each span (as one
+// paragraph) according to the text style -- see SpanRender.SetBidiLevels --
+// Dir is set from the first span.
+func (tr *TextRender) SetBidi(txtSty *TextStyle) {
+	for si := range tr.Spans {
+		tr.Spans[si].SetBidiLevels(txtSty)
+	}
+	if len(tr.Spans) > 0 {
+		tr.Dir = tr.Spans[0].Dir
+	}
+}
+
+// HasBidi returns true if any span has text requiring bidi reordering
+func (tr *TextRender) HasBidi() bool {
+	for si := range tr.Spans {
+		if tr.Spans[si].HasBidi() {
+			return true
+		}
+	}
+	return false
+}
+
+// RuneCaretPos returns the relative position of the text caret (insertion
+// point) before the given rune index, counting progressively through all
+// spans present -- the same as RuneRelPos except for right-to-left text,
+// where the caret is at the right edge of the rune.  If index > length,
+// the caret is after the last rune.  Returns also the span and rune index
+// within span, and false if index is out of range.
+func (tr *TextRender) RuneCaretPos(idx int) (pos Vec2D, si, ri int, ok bool) {
+	si, ri, ok = tr.RuneSpanPos(idx)
+	if !ok {
+		nsp := len(tr.Spans)
+		if nsp == 0 {
+			return Vec2DZero, -1, -1, false
+		}
+		si = nsp - 1
+		ri = len(tr.Spans[si].Render)
+	}
+	sr := &tr.Spans[si]
+	pos = sr.LastPos
+	if ri < len(sr.Render) {
+		pos = sr.RelPos.Add(sr.Render[ri].RelPos)
+	}
+	pos.X = sr.RelPos.X + sr.CaretPosLR(ri)
+	return pos, si, ri, ok
+}
+
+// VisualMove returns the rune index of the caret after moving one position
+// visually right (dir > 0) or left (dir < 0) from the caret at given rune
+// index, within the span (line) containing it -- returns false if already at
+// the visual edge of the span.
+func (tr *TextRender) VisualMove(idx, dir int) (int, bool) {
+	si, ri, ok := tr.RuneSpanPos(idx)
+	if !ok {
+		if len(tr.Spans) == 0 {
+			return idx, false
+		}
+		si = len(tr.Spans) - 1
+		ri = len(tr.Spans[si].Render)
+	}
+	nri := tr.Spans[si].VisualMoveLR(ri, dir)
+	if nri < 0 {
+		return idx, false
+	}
+	return idx - ri + nri, true
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/font"
+)
+
+// testShapeSpan returns a span for given text in given face, with bidi
+// levels set for an auto-direction paragraph, laid out by SetRunePosLR with
+// given letter spacing -- the test faces have an advance of 7 for their
+// runes and 9 for the missing glyph
+func testShapeSpan(str string, fc font.Face, letterSpace float32) *SpanRender {
+	sr := &SpanRender{Text: []rune(str)}
+	sr.Render = make([]RuneRender, len(sr.Text))
+	sr.Render[0].Face = fc
+	sr.Render[0].Color = color.Black
+	sr.SetBidiLevels(&TextStyle{})
+	sr.SetRunePosLR(letterSpace, 0, 7, 4)
+	return sr
+}
+
+// testGlyphs returns the Glyph of each rune of the span
+func testGlyphs(sr *SpanRender) []rune {
+	gls := make([]rune, len(sr.Render))
+	for i := range sr.Render {
+		gls[i] = sr.Render[i].Glyph
+	}
+	return gls
+}
+
+// testPosX returns the RelPos.X and Size.X of each rune of the span
+func testPosX(sr *SpanRender) [][2]float32 {
+	pos := make([][2]float32, len(sr.Render))
+	for i := range sr.Render {
+		pos[i] = [2]float32{sr.Render[i].RelPos.X, sr.Render[i].Size.X}
+	}
+	return pos
+}
+
+// testArabicFace returns a face with the Arabic letters used in the tests
+// and all of their presentation forms, but no lam-alef ligatures
+func testArabicFace(ligs ...rune) *testGlyphFace {
+	fc := newTestGlyphFace(ligs...)
+	for _, r := range []rune{0x0627, 0x0628, 0x062A, 0x062F, 0x0633, 0x0644, 0x064A, 0x064E} {
+		fc.runes[r] = true
+		af := arabicForms[r]
+		for f := 0; f < af.forms; f++ {
+			fc.runes[af.isol+rune(f)] = true
+		}
+	}
+	return fc
+}
+
+func TestShapeArabic(t *testing.T) {
+	fc := testArabicFace(0xFEFB, 0xFEFC)
+	tests := []struct {
+		str    string
+		glyphs []rune
+	}{
+		{"بيت", []rune{0xFE91, 0xFEF4, 0xFE96}},                // beh yeh teh: initial, medial, final
+		{"داد", []rune{0xFEA9, 0xFE8D, 0xFEA9}},                // dal alef dal: right-joining only, all isolated
+		{"بد", []rune{0xFE91, 0xFEAA}},                         // beh dal: initial, final
+		{"ب", []rune{0xFE8F}},                                  // isolated
+		{"بَت", []rune{0xFE91, 0, 0xFE96}},                     // fatha is transparent to joining
+		{"ب ت", []rune{0xFE8F, 0, 0xFE95}},                     // space breaks joining
+		{"سلا", []rune{0xFEB3, 0xFEFC, GlyphSkip}},             // seen lam-alef: final ligature
+		{"لا", []rune{0xFEFB, GlyphSkip}},                      // isolated ligature
+		{"لاب", []rune{0xFEFB, GlyphSkip, 0xFE8F}},             // alef does not join to the left
+		{"aب(b)", []rune{0, 0xFE8F, 0, 0, 0}},                  // not mirrored in LTR text
+		{"ب(ت)", []rune{0xFE8F, 0, 0xFE95, 0}},                 // no mirrored glyphs in face
+		{"ببب", []rune{0xFE91, 0xFE92, 0xFE90}},                // initial, medial, final
+		{"تَبَّ", []rune{0xFE97, 0, 0xFE90, 0, 0}},             // marks at the end
+		{"بـ", []rune{0xFE91, 0}},                              // tatweel joins
+		{"ب\u200Dت", []rune{0xFE91, 0, 0xFE96}},                // zwj joins
+		{"ب\u200Cت", []rune{0xFE8F, 0, 0xFE95}},                // zwnj does not
+		{"لالا", []rune{0xFEFB, GlyphSkip, 0xFEFB, GlyphSkip}}, // ligatures do not join
+	}
+	for _, tt := range tests {
+		sr := testShapeSpan(tt.str, fc, 0)
+		if gls := testGlyphs(sr); !reflect.DeepEqual(gls, tt.glyphs) {
+			t.Errorf("%q: glyphs %U != %U", tt.str, gls, tt.glyphs)
+		}
+	}
+
+	// forms that are not in the face are not used
+	sr := testShapeSpan("سلا", testArabicFace(), 0)
+	if gls, exp := testGlyphs(sr), []rune{0xFEB3, 0xFEE0, 0xFE8E}; !reflect.DeepEqual(gls, exp) {
+		t.Errorf("without lam-alef ligature: glyphs %U != %U", gls, exp)
+	}
+	sr = testShapeSpan("بيت", newTestGlyphFace(0x0628, 0x064A, 0x062A), 0)
+	if gls := testGlyphs(sr); !reflect.DeepEqual(gls, []rune{0, 0, 0}) {
+		t.Errorf("without presentation forms: glyphs %U", gls)
+	}
+
+	// the ligature takes the place of both runes, in right-to-left order
+	sr = testShapeSpan("سلا", fc, 0)
+	if pos, exp := testPosX(sr), [][2]float32{{7, 7}, {0, 7}, {0, 0}}; !reflect.DeepEqual(pos, exp) {
+		t.Errorf("lam-alef positions: %v != %v", pos, exp)
+	}
+}
+
+func TestShapeMirror(t *testing.T) {
+	fc := newTestGlyphFace('(', ')', '[', ']', 'a', 'א', 'ב')
+	tests := []struct {
+		str    string
+		glyphs []rune
+	}{
+		{"א(ב)", []rune{0, ')', 0, '('}},
+		{"א[ב]", []rune{0, ']', 0, '['}},
+		{"a(a)", []rune{0, 0, 0, 0}},
+		{"a(א)", []rune{0, 0, 0, 0}}, // brackets take the direction of the paragraph
+	}
+	for _, tt := range tests {
+		sr := testShapeSpan(tt.str, fc, 0)
+		if gls := testGlyphs(sr); !reflect.DeepEqual(gls, tt.glyphs) {
+			t.Errorf("%q: glyphs %q != %q", tt.str, gls, tt.glyphs)
+		}
+	}
+}
+
+func TestShapeLatinLigs(t *testing.T) {
+	all := newTestGlyphFace('o', 'f', 'i', 'l', 'c', 'e', 0xFB00, 0xFB01, 0xFB02, 0xFB03, 0xFB04)
+	fiOnly := newTestGlyphFace('o', 'f', 'i', 'l', 'c', 'e', 0xFB01)
+	tests := []struct {
+		str    string
+		fc     font.Face
+		glyphs []rune
+	}{
+		{"office", all, []rune{0, 0xFB03, GlyphSkip, GlyphSkip, 0, 0}},
+		{"offline", all, []rune{0, 0xFB04, GlyphSkip, GlyphSkip, 0, 0, 0}},
+		{"off", all, []rune{0, 0xFB00, GlyphSkip}},
+		{"fife", all, []rune{0xFB01, GlyphSkip, 0, 0}},
+		{"office", fiOnly, []rune{0, 0, 0xFB01, GlyphSkip, 0, 0}},
+		{"flo", fiOnly, []rune{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		sr := testShapeSpan(tt.str, tt.fc, 0)
+		if gls := testGlyphs(sr); !reflect.DeepEqual(gls, tt.glyphs) {
+			t.Errorf("%q: glyphs %U != %U", tt.str, gls, tt.glyphs)
+		}
+	}
+
+	// the ligature is drawn in place of all its runes
+	sr := testShapeSpan("office", all, 0)
+	if pos, exp := testPosX(sr), [][2]float32{{0, 7}, {7, 7}, {14, 0}, {14, 0}, {14, 7}, {21, 7}}; !reflect.DeepEqual(pos, exp) {
+		t.Errorf("ligature positions: %v != %v", pos, exp)
+	}
+	if sr.LastPos.X != 28 {
+		t.Errorf("ligature LastPos: %v != 28", sr.LastPos.X)
+	}
+
+	// no ligatures with letter spacing
+	sr = testShapeSpan("office", all, 1)
+	if gls := testGlyphs(sr); !reflect.DeepEqual(gls, make([]rune, 6)) {
+		t.Errorf("letter spaced: glyphs %U", gls)
+	}
+
+	// nor across a change of face or decoration
+	sr = &SpanRender{Text: []rune("fi")}
+	sr.Render = make([]RuneRender, 2)
+	sr.Render[0].Face = all
+	sr.Render[0].Color = color.Black
+	sr.Render[1].Face = newTestGlyphFace('i', 0xFB01)
+	sr.SetRunePosLR(0, 0, 7, 4)
+	if gls := testGlyphs(sr); !reflect.DeepEqual(gls, []rune{0, 0}) {
+		t.Errorf("across faces: glyphs %U", gls)
+	}
+	sr.Render[1].Face = nil
+	sr.Render[1].Deco = DecoUnderline
+	sr.SetRunePosLR(0, 0, 7, 4)
+	if gls := testGlyphs(sr); !reflect.DeepEqual(gls, []rune{0, 0}) {
+		t.Errorf("across decorations: glyphs %U", gls)
+	}
+}
+
+func TestPlaceMarks(t *testing.T) {
+	acute := rune(0x0301)
+	spacing := newTestGlyphFace('a', acute)
+	zero := newTestGlyphFace('a')
+	zero.zero = map[rune]bool{acute: true}
+	tests := []struct {
+		str string
+		fc  font.Face
+		pos [][2]float32
+	}{
+		// marks with an advance are centered over the base: x has the
+		// missing glyph, of width 9
+		{"x\u0301a", spacing, [][2]float32{{0, 9}, {1, 0}, {9, 7}}},
+		{"a\u0301a", spacing, [][2]float32{{0, 7}, {0, 0}, {7, 7}}},
+		// zero-advance marks are placed at the end of the base, which they
+		// extend back over
+		{"a\u0301a", zero, [][2]float32{{0, 7}, {7, 0}, {7, 7}}},
+		// all marks go over the same base, skipping zero-width format runes
+		{"a\u0301\u200D\u0301a", zero, [][2]float32{{0, 7}, {7, 0}, {7, 0}, {7, 0}, {7, 7}}},
+		// marks without a base take no space
+		{"\u0301a", zero, [][2]float32{{0, 0}, {0, 7}}},
+	}
+	for _, tt := range tests {
+		sr := testShapeSpan(tt.str, tt.fc, 0)
+		if pos := testPosX(sr); !reflect.DeepEqual(pos, tt.pos) {
+			t.Errorf("%q: positions %v != %v", tt.str, pos, tt.pos)
+		}
+	}
+
+	// marks go over their base in right-to-left text too
+	fc := newTestGlyphFace('א', 'ב')
+	fc.zero = map[rune]bool{0x05B8: true} // qamats
+	sr := testShapeSpan("א\u05B8ב", fc, 0)
+	if pos, exp := testPosX(sr), [][2]float32{{7, 7}, {14, 0}, {0, 7}}; !reflect.DeepEqual(pos, exp) {
+		t.Errorf("RTL mark positions: %v != %v", pos, exp)
+	}
+}
+
+func TestReorderIndic(t *testing.T) {
+	fc := newTestGlyphFace('क', 'ष', 'ि', 'ा', 'ं', 'a')
+	fc.zero = map[rune]bool{'्': true, '़': true}
+	tests := []struct {
+		str string
+		pos [][2]float32
+	}{
+		// ka i: the i sign is drawn before the ka
+		{"कि", [][2]float32{{7, 7}, {0, 7}}},
+		// ka aa: not a pre-base sign
+		{"का", [][2]float32{{0, 7}, {7, 7}}},
+		// ka virama ssa i: before the whole cluster
+		{"क्षि", [][2]float32{{7, 7}, {14, 0}, {14, 7}, {0, 7}}},
+		// ka nukta i: the nukta stays with the ka
+		{"क़ि", [][2]float32{{7, 7}, {14, 0}, {0, 7}}},
+		// only back to the start of the cluster
+		{"कषि", [][2]float32{{0, 7}, {14, 7}, {7, 7}}},
+		// i sign after a letter of another script
+		{"aि", [][2]float32{{0, 7}, {7, 7}}},
+		// marks after the sign stay at the end of the syllable
+		{"किं", [][2]float32{{7, 7}, {0, 7}, {7, 0}}},
+	}
+	for _, tt := range tests {
+		sr := testShapeSpan(tt.str, fc, 0)
+		if pos := testPosX(sr); !reflect.DeepEqual(pos, tt.pos) {
+			t.Errorf("%q: positions %v != %v", tt.str, pos, tt.pos)
+		}
+	}
+}
+
+// testBidiSpan is "ab אב" in a face with all its runes -- visually
+// "ab בא", with runes (logical index) at x: a(0) 0, b(1) 7, space(2) 14,
+// ב(4) 21, א(3) 28, ending at 35
+func testBidiSpan() *SpanRender {
+	return testShapeSpan("ab אב", newTestGlyphFace('a', 'b', ' ', 'א', 'ב'), 0)
+}
+
+func TestCaretPos(t *testing.T) {
+	sr := testBidiSpan()
+	if !sr.Visual || sr.Dir != LRTB {
+		t.Errorf("span not visual LRTB: %v %v", sr.Visual, sr.Dir)
+	}
+	// the caret before a right-to-left rune is at its right edge, and the
+	// end of the text is after ב, at the left of the Hebrew word
+	carets := []float32{0, 7, 14, 35, 28, 21}
+	for i, exp := range carets {
+		if x := sr.CaretPosLR(i); x != exp {
+			t.Errorf("CaretPosLR(%d): %v != %v", i, x, exp)
+		}
+	}
+	hits := []struct {
+		x   float32
+		idx int
+	}{
+		{-5, 0}, {2, 0}, {6, 1}, {13, 2}, {20, 5}, {26, 4}, {33, 3}, {50, 3},
+	}
+	for _, ht := range hits {
+		if idx := sr.CaretIdxAtLR(ht.x); idx != ht.idx {
+			t.Errorf("CaretIdxAtLR(%v): %d != %d", ht.x, idx, ht.idx)
+		}
+	}
+
+	// right-to-left paragraph: visually "ab בא" too, but the space is
+	// right-to-left, and the end is after the b
+	sr = testShapeSpan("אב ab", newTestGlyphFace('a', 'b', ' ', 'א', 'ב'), 0)
+	if sr.Dir != RLTB {
+		t.Errorf("RTL paragraph Dir: %v != RLTB", sr.Dir)
+	}
+	carets = []float32{35, 28, 21, 0, 7, 14}
+	for i, exp := range carets {
+		if x := sr.CaretPosLR(i); x != exp {
+			t.Errorf("RTL paragraph CaretPosLR(%d): %v != %v", i, x, exp)
+		}
+	}
+
+	tr := &TextRender{Spans: []SpanRender{*testBidiSpan()}}
+	for i, exp := range []float32{0, 7, 14, 35, 28, 21, 21} {
+		pos, si, _, _ := tr.RuneCaretPos(i)
+		if pos.X != exp || si != 0 {
+			t.Errorf("RuneCaretPos(%d): %v in span %d != %v", i, pos.X, si, exp)
+		}
+	}
+}
+
+func TestVisualMove(t *testing.T) {
+	sr := testBidiSpan()
+	// logical caret indexes in visual order, left to right
+	vis := []int{0, 1, 2, 5, 4, 3}
+	for vi, idx := range vis {
+		right, left := -1, -1
+		if vi < len(vis)-1 {
+			right = vis[vi+1]
+		}
+		if vi > 0 {
+			left = vis[vi-1]
+		}
+		if got := sr.VisualMoveLR(idx, 1); got != right {
+			t.Errorf("VisualMoveLR(%d, right): %d != %d", idx, got, right)
+		}
+		if got := sr.VisualMoveLR(idx, -1); got != left {
+			t.Errorf("VisualMoveLR(%d, left): %d != %d", idx, got, left)
+		}
+	}
+
+	// indexes are across all spans
+	tr := &TextRender{Spans: []SpanRender{*testShapeSpan("xy", newTestGlyphFace('x', 'y'), 0), *testBidiSpan()}}
+	if idx, ok := tr.VisualMove(4, 1); !ok || idx != 7 {
+		t.Errorf("VisualMove(4, right): %d %v != 7 true", idx, ok)
+	}
+	if idx, ok := tr.VisualMove(5, 1); ok || idx != 5 {
+		t.Errorf("VisualMove(5, right) at edge: %d %v != 5 false", idx, ok)
+	}
+	if idx, ok := tr.VisualMove(7, -1); !ok || idx != 4 {
+		t.Errorf("VisualMove(end, left): %d %v != 4 true", idx, ok)
+	}
+	if idx, ok := tr.VisualMove(2, -1); ok || idx != 2 {
+		t.Errorf("VisualMove(2, left) at edge: %d %v != 2 false", idx, ok)
+	}
+}
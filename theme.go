@@ -0,0 +1,375 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Theme
+
+// ThemeName has an associated ValueView for selecting from the list of
+// available theme names, for use in preferences etc.  An empty name means
+// no theme: the Colors and CustomStyles in Prefs are used as-is.
+type ThemeName string
+
+// Theme is a named look for the GUI: a color palette, a style sheet with
+// per-widget styles, and an optional tint for all icons.  Themes are
+// switched live for all open windows using SetTheme -- see AvailThemes for
+// the list of themes, which includes the built-in StdThemes and any theme
+// files in the themes directory of the GoGi prefs directory.
+type Theme struct {
+	Name       string     `width:"20" desc:"name of the theme"`
+	Desc       string     `desc:"description of the theme"`
+	Dark       bool       `desc:"this is a dark theme -- selects the prefers-color-scheme: dark @media rules in style sheets, and is used for following the desktop preference for dark colors"`
+	Colors     ColorPrefs `desc:"color palette -- copied into Prefs.Colors when the theme is applied, which all the default widget styles use"`
+	IconTint   Color      `desc:"if set (non-nil), all icons are drawn in this color, instead of the icon colors from the palette and widget styles"`
+	Styles     ki.Props   `desc:"style sheet applied at the top of every window, below any app-specific styles -- keys can be any CSS selector (e.g., button, .class, #name), define --name custom properties for use in other values with var(--name), or be @media rules"`
+	StyleSheet string     `desc:"style sheet in CSS text format, which is merged on top of Styles -- convenient for theme files"`
+	css        ki.Props
+}
+
+var KiT_Theme = kit.Types.AddType(&Theme{}, nil)
+
+// CSSProps returns the full style sheet for this theme: Styles merged with
+// the parsed StyleSheet -- cached after the first call
+func (th *Theme) CSSProps() ki.Props {
+	if th.css != nil {
+		return th.css
+	}
+	th.css = make(ki.Props, len(th.Styles))
+	for key, val := range th.Styles {
+		th.css[key] = themeProps(val)
+	}
+	if strings.TrimSpace(th.StyleSheet) != "" {
+		ss := &StyleSheet{}
+		if err := ss.ParseString(th.StyleSheet); err == nil {
+			AggCSS(&th.css, ss.CSSProps())
+		} else {
+			log.Printf("gi.Theme: style sheet parse error in theme: %v: %v\n", th.Name, err)
+		}
+	}
+	return th.css
+}
+
+// themeProps converts nested maps loaded from JSON into ki.Props as needed
+// for use as CSS
+func themeProps(val interface{}) interface{} {
+	mp, ok := val.(map[string]interface{})
+	if !ok {
+		if kp, ok := val.(ki.Props); ok {
+			mp = kp
+		} else {
+			return val
+		}
+	}
+	pr := make(ki.Props, len(mp))
+	for k, v := range mp {
+		pr[k] = themeProps(v)
+	}
+	return pr
+}
+
+// OpenJSON opens a theme from a JSON-formatted file.
+func (th *Theme) OpenJSON(filename FileName) error {
+	b, err := ioutil.ReadFile(string(filename))
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	th.css = nil
+	return json.Unmarshal(b, th)
+}
+
+// SaveJSON saves the theme to a JSON-formatted file.
+func (th *Theme) SaveJSON(filename FileName) error {
+	b, err := json.MarshalIndent(th, "", "  ")
+	if err != nil {
+		log.Println(err) // unlikely
+		return err
+	}
+	err = ioutil.WriteFile(string(filename), b, 0644)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Themes
+
+// Themes is a list of themes
+type Themes []*Theme
+
+var KiT_Themes = kit.Types.AddType(&Themes{}, nil)
+
+// AvailThemes is the list of themes available for use -- initialized to
+// StdThemes, and themes are added from the prefs directory by OpenPrefs.
+var AvailThemes Themes
+
+// StdThemes are the built-in themes: Light (the default colors), Dark, and
+// HighContrast
+var StdThemes Themes
+
+// CurTheme is the theme currently in effect, set by SetTheme -- nil if no
+// theme has been set
+var CurTheme *Theme
+
+// ThemeCSS is the style sheet in effect for the top of every window and
+// popup: the CurTheme style sheet plus Prefs.CustomStyles -- app-specific
+// styles set on nodes are applied after these
+var ThemeCSS ki.Props
+
+// ThemesDirName is the name of the directory within the GoGi prefs
+// directory that holds theme files (one JSON-formatted Theme per file)
+var ThemesDirName = "themes"
+
+func init() {
+	light := &Theme{Name: "Light", Desc: "the default light colors"}
+	light.Colors.Defaults()
+	dark := &Theme{Name: "Dark", Desc: "light text on dark backgrounds", Dark: true}
+	dark.Colors.DarkDefaults()
+	hc := &Theme{Name: "HighContrast", Desc: "white text on black, with bright borders and thicker lines, for low vision", Dark: true}
+	hc.Colors.HighContrastDefaults()
+	hc.Styles = ki.Props{
+		"--border-width": "2px",
+		"button": ki.Props{
+			"border-width": "var(--border-width)",
+		},
+		"textfield": ki.Props{
+			"border-width": "var(--border-width)",
+		},
+		"spinbox": ki.Props{
+			"border-width": "var(--border-width)",
+		},
+		"combobox": ki.Props{
+			"border-width": "var(--border-width)",
+		},
+	}
+	StdThemes = Themes{light, dark, hc}
+	AvailThemes = append(Themes{}, StdThemes...)
+}
+
+// ThemeByName returns a theme and its index by name -- returns false if
+// not found
+func (ts *Themes) ThemeByName(name ThemeName) (*Theme, int, bool) {
+	for i, th := range *ts {
+		if th.Name == string(name) {
+			return th, i, true
+		}
+	}
+	return nil, -1, false
+}
+
+// Names returns the names of the themes
+func (ts *Themes) Names() []string {
+	nms := make([]string, len(*ts))
+	for i, th := range *ts {
+		nms[i] = th.Name
+	}
+	return nms
+}
+
+// Add adds given theme, replacing any existing theme with the same name
+func (ts *Themes) Add(th *Theme) {
+	if _, idx, ok := ts.ThemeByName(ThemeName(th.Name)); ok {
+		(*ts)[idx] = th
+		return
+	}
+	*ts = append(*ts, th)
+}
+
+// OpenDir adds all the JSON-formatted theme files in given directory --
+// themes with the same name as existing ones replace them
+func (ts *Themes) OpenDir(dir string) error {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, fi := range fis {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != ".json" {
+			continue
+		}
+		th := &Theme{}
+		if err := th.OpenJSON(FileName(filepath.Join(dir, fi.Name()))); err != nil {
+			continue
+		}
+		if th.Name == "" {
+			th.Name = strings.TrimSuffix(fi.Name(), ".json")
+		}
+		ts.Add(th)
+	}
+	return nil
+}
+
+// ThemesPrefsDir returns the directory holding theme files in the GoGi
+// prefs directory
+func ThemesPrefsDir() string {
+	return filepath.Join(oswin.TheApp.GoGiPrefsDir(), ThemesDirName)
+}
+
+// OpenPrefs adds the themes in the themes directory of the GoGi prefs
+// directory (see ThemesDirName) -- it is not an error for it not to exist
+func (ts *Themes) OpenPrefs() error {
+	err := ts.OpenDir(ThemesPrefsDir())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// SavePrefs saves given theme to the themes directory of the GoGi prefs
+// directory, named by the theme name, so that it is loaded at startup
+func (th *Theme) SavePrefs() error {
+	dir := ThemesPrefsDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Println(err)
+		return err
+	}
+	return th.SaveJSON(FileName(filepath.Join(dir, th.Name+".json")))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Applying themes
+
+// userColors is the user's own palette from Prefs.Colors while a theme
+// palette is in effect there -- it is what Prefs.Save saves, and it is
+// restored when the theme is cleared
+var userColors *ColorPrefs
+
+// SetTheme makes given theme the current theme and restyles all open
+// windows to use it -- the theme palette is put in Prefs.Colors in place of
+// the user's own colors, and its style sheet is used for ThemeCSS.  A nil
+// theme restores the user's Colors, uses the CustomStyles in Prefs as-is,
+// and goes back to the desktop preference for dark colors.
+func SetTheme(th *Theme) {
+	setCurTheme(th)
+	UpdateAllStyles()
+}
+
+// setCurTheme sets CurTheme and the settings that depend on it, without
+// updating windows
+func setCurTheme(th *Theme) {
+	CurTheme = th
+	if th != nil {
+		if userColors == nil {
+			uc := Prefs.Colors
+			userColors = &uc
+		}
+		Prefs.Colors = th.Colors
+		PrefersDarkColors = th.Dark
+	} else {
+		if userColors != nil {
+			Prefs.Colors = *userColors
+			userColors = nil
+		}
+		PrefersDarkColors = DesktopPrefersDark()
+	}
+	UpdateThemeCSS()
+}
+
+// SetThemeName sets the current theme by name from those in AvailThemes
+// (see SetTheme) -- an empty name reverts to the Colors and CustomStyles in
+// Prefs.  Returns false if the theme is not found.
+func SetThemeName(name ThemeName) bool {
+	if name == "" {
+		SetTheme(nil)
+		return true
+	}
+	th, _, ok := AvailThemes.ThemeByName(name)
+	if !ok {
+		log.Printf("gi.SetThemeName: theme named: %v not found\n", name)
+		return false
+	}
+	SetTheme(th)
+	return true
+}
+
+// UpdateThemeCSS updates ThemeCSS from the CurTheme style sheet and
+// Prefs.CustomStyles
+func UpdateThemeCSS() {
+	ThemeCSS = nil
+	if CurTheme != nil {
+		AggCSS(&ThemeCSS, CurTheme.CSSProps())
+	}
+	if len(Prefs.CustomStyles) > 0 {
+		AggCSS(&ThemeCSS, Prefs.CustomStyles)
+	}
+}
+
+// ThemeIconTint returns the icon tint color of the current theme, or nil
+// if there is no tint
+func ThemeIconTint() *Color {
+	if CurTheme == nil || CurTheme.IconTint.IsNil() {
+		return nil
+	}
+	return &CurTheme.IconTint
+}
+
+// UpdateAllStyles rebuilds the default styles and fully re-renders all
+// open windows, including any popups, so that style changes (e.g., from
+// the theme or prefs) take effect
+func UpdateAllStyles() {
+	RebuildDefaultStyles = true
+	for _, w := range AllWindows {
+		w.FullReRender()
+		w.ReRenderPopups()
+	}
+	RebuildDefaultStyles = false
+	// needs another pass through to get it right..
+	for _, w := range AllWindows {
+		w.FullReRender()
+		w.ReRenderPopups()
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Desktop preference
+
+// DesktopPrefersDarkFunc returns true if the desktop environment prefers
+// dark colors -- platforms or apps can set this to query the native
+// setting.  The default checks for a :dark GTK_THEME variant.
+var DesktopPrefersDarkFunc = func() bool {
+	return strings.HasSuffix(strings.ToLower(os.Getenv("GTK_THEME")), ":dark")
+}
+
+// DesktopPrefersDark returns true if the desktop environment prefers dark
+// colors, according to DesktopPrefersDarkFunc
+func DesktopPrefersDark() bool {
+	if DesktopPrefersDarkFunc == nil {
+		return false
+	}
+	return DesktopPrefersDarkFunc()
+}
+
+// DesktopThemeChanged checks for a change in the desktop preference for
+// light or dark colors -- it is called whenever a window gains the focus,
+// and can also be called from a platform notification.  If
+// Prefs.FollowDesktop is set, this switches to the corresponding theme, or
+// updates PrefersDarkColors if no theme is used.
+func DesktopThemeChanged() {
+	if !Prefs.FollowDesktop {
+		return
+	}
+	nm := Prefs.PrefTheme()
+	if nm == "" {
+		if CurTheme == nil && PrefersDarkColors == DesktopPrefersDark() {
+			return
+		}
+	} else if CurTheme != nil && CurTheme.Name == string(nm) {
+		return
+	}
+	SetThemeName(nm)
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goki/gi/oswin/driver/offscreen"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki"
+)
+
+// testSaveThemes saves the theme state and returns a function restoring it
+func testSaveThemes() func() {
+	svPrefs, svAvail, svCur, svCSS := Prefs, AvailThemes, CurTheme, ThemeCSS
+	svDark, svDarkFunc, svUser := PrefersDarkColors, DesktopPrefersDarkFunc, userColors
+	AvailThemes = append(Themes{}, AvailThemes...)
+	return func() {
+		Prefs, AvailThemes, CurTheme, ThemeCSS = svPrefs, svAvail, svCur, svCSS
+		PrefersDarkColors, DesktopPrefersDarkFunc, userColors = svDark, svDarkFunc, svUser
+	}
+}
+
+func TestThemesOpenDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"ocean.json": `{"Name": "Ocean", "Dark": true,
+			"Styles": {"--accent": "#08f", "button": {"border-width": "3px"}},
+			"StyleSheet": "label { color: #0000ff; }"}`,
+		"Unnamed.json": `{"Desc": "no name"}`,
+		"Dark.json":    `{"Name": "Dark", "Desc": "replaced"}`,
+		"broken.json":  `{"Name": `,
+		"notes.txt":    `not a theme`,
+	}
+	for fn, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, fn), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := append(Themes{}, StdThemes...)
+	if err := ts.OpenDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != len(StdThemes)+2 {
+		t.Errorf("expected %v themes, got: %v", len(StdThemes)+2, ts.Names())
+	}
+	if th, _, ok := ts.ThemeByName("Dark"); !ok || th.Desc != "replaced" {
+		t.Errorf("expected Dark theme to be replaced, got: %+v", th)
+	}
+	if _, _, ok := ts.ThemeByName("Unnamed"); !ok {
+		t.Errorf("expected theme named from file, got: %v", ts.Names())
+	}
+	th, _, ok := ts.ThemeByName("Ocean")
+	if !ok || !th.Dark {
+		t.Fatalf("expected dark Ocean theme, got: %+v", th)
+	}
+	css := th.CSSProps()
+	if css["--accent"] != "#08f" {
+		t.Errorf("expected --accent custom property, got: %v", css)
+	}
+	if bp, ok := css["button"].(ki.Props); !ok || bp["border-width"] != "3px" {
+		t.Errorf("expected button props converted to ki.Props, got: %#v", css["button"])
+	}
+	if _, ok := css["label"].(ki.Props); !ok {
+		t.Errorf("expected label props from the style sheet, got: %v", css)
+	}
+}
+
+func TestSetTheme(t *testing.T) {
+	defer testSaveThemes()()
+	vp, lay := testViewport(t, 300, 200)
+	lb := lay.AddNewChild(KiT_Label, "label").(*Label)
+	lb.SetText("themed")
+	acc := lay.AddNewChild(KiT_Frame, "accent").(*Frame) // label states override background
+	acc.Class = "accent"
+
+	blue := Color{0, 0, 255, 255}
+	green := Color{0, 255, 0, 255}
+	th := &Theme{Name: "Test"}
+	th.Colors.Defaults()
+	th.Colors.Background.SetString("#eee", nil)
+	th.Styles = ki.Props{
+		"--accent": "#0f0",
+		"label": ki.Props{
+			"color": "#00f",
+		},
+		".accent": ki.Props{
+			"background-color": "var(--accent)",
+		},
+	}
+	AvailThemes.Add(th)
+	Prefs.CustomStyles = nil
+
+	if !SetThemeName("Test") || CurTheme != th {
+		t.Fatalf("could not set Test theme")
+	}
+	if Prefs.Colors != th.Colors || PrefersDarkColors {
+		t.Errorf("expected theme colors in Prefs, got: %v", Prefs.Colors.Background)
+	}
+	vp.FullRender2DTree()
+	if _, ok := vp.CSSAgg["label"]; !ok {
+		t.Errorf("expected theme styles at the top of the viewport, got: %v", vp.CSSAgg)
+	}
+	if _, ok := lb.CSSAgg["label"]; !ok {
+		t.Errorf("expected theme styles inherited by label, got: %v", lb.CSSAgg)
+	}
+	if lb.Sty.Font.Color != blue {
+		t.Errorf("expected blue label from theme, got: %v", lb.Sty.Font.Color)
+	}
+	if acc.Sty.Font.BgColor.Color != green {
+		t.Errorf("expected green background from var(--accent), got: %v", acc.Sty.Font.BgColor.Color)
+	}
+
+	dark, _, _ := AvailThemes.ThemeByName("Dark")
+	if !SetThemeName("Dark") || CurTheme != dark {
+		t.Fatalf("could not set Dark theme")
+	}
+	if Prefs.Colors != dark.Colors || !PrefersDarkColors {
+		t.Errorf("expected dark theme colors in Prefs")
+	}
+	vp.FullRender2DTree()
+	if _, ok := lb.CSSAgg["label"]; ok {
+		t.Errorf("expected Test theme styles to be gone, got: %v", lb.CSSAgg)
+	}
+	if lb.Sty.Font.Color == blue || acc.Sty.Font.BgColor.Color == green {
+		t.Errorf("expected label colors to be restyled, got: %v %v", lb.Sty.Font.Color, acc.Sty.Font.BgColor.Color)
+	}
+
+	// custom styles in prefs apply on top of the theme
+	Prefs.CustomStyles = ki.Props{"label": ki.Props{"color": "#0f0"}}
+	SetThemeName("Dark")
+	vp.FullRender2DTree()
+	if lb.Sty.Font.Color != green {
+		t.Errorf("expected green label from custom styles, got: %v", lb.Sty.Font.Color)
+	}
+
+	if SetThemeName("NoSuchTheme") || CurTheme != dark {
+		t.Errorf("expected failure setting missing theme, with current theme unchanged")
+	}
+	if !SetThemeName("") || CurTheme != nil {
+		t.Errorf("expected empty name to clear the theme")
+	}
+}
+
+func TestThemeUserColors(t *testing.T) {
+	defer testSaveThemes()()
+	testViewport(t, 100, 100)
+	dir, err := ioutil.TempDir("", "prefs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(pp string) { offscreen.PrefsPath = pp }(offscreen.PrefsPath)
+	offscreen.PrefsPath = dir
+
+	DesktopPrefersDarkFunc = func() bool { return true }
+	SetTheme(nil)
+	Prefs.Colors.Defaults()
+	Prefs.Colors.Font.SetString("#123", nil)
+	user := Prefs.Colors
+	light, _, _ := AvailThemes.ThemeByName("Light")
+	if !SetThemeName("Light") || Prefs.Colors != light.Colors || PrefersDarkColors {
+		t.Fatalf("expected Light theme colors in Prefs")
+	}
+	SetThemeName("Dark") // switching themes keeps the user colors
+	if err := Prefs.Save(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "GoGi", PrefsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv := Preferences{}
+	if err := json.Unmarshal(b, &sv); err != nil {
+		t.Fatal(err)
+	}
+	if sv.Colors != user {
+		t.Errorf("expected user colors to be saved, got: %v", sv.Colors.Font)
+	}
+	if dark, _, _ := AvailThemes.ThemeByName("Dark"); Prefs.Colors != dark.Colors {
+		t.Errorf("expected theme colors to stay in effect after saving")
+	}
+	PrefersDarkColors = false
+	SetThemeName("")
+	if Prefs.Colors != user || !PrefersDarkColors {
+		t.Errorf("expected user colors and desktop dark preference restored, got: %v %v", Prefs.Colors.Font, PrefersDarkColors)
+	}
+}
+
+func TestDesktopThemeChanged(t *testing.T) {
+	defer testSaveThemes()()
+	desktopDark := false
+	DesktopPrefersDarkFunc = func() bool { return desktopDark }
+	Prefs.Theme = "Light"
+	Prefs.DarkTheme = "Dark"
+	Prefs.FollowDesktop = true
+	SetThemeName(Prefs.PrefTheme())
+	if CurTheme == nil || CurTheme.Name != "Light" {
+		t.Fatalf("expected Light theme, got: %v", CurTheme)
+	}
+
+	desktopDark = true
+	DesktopThemeChanged()
+	if CurTheme == nil || CurTheme.Name != "Dark" || !PrefersDarkColors {
+		t.Errorf("expected Dark theme after desktop change, got: %v", CurTheme)
+	}
+	desktopDark = false
+	DesktopThemeChanged()
+	if CurTheme == nil || CurTheme.Name != "Light" || PrefersDarkColors {
+		t.Errorf("expected Light theme after desktop change, got: %v", CurTheme)
+	}
+
+	// a window gaining the focus checks the desktop
+	win, _ := testWindow(t, "desktop-test", 200, 100)
+	win.GoStartEventLoop()
+	defer testClose(t, win)
+	desktopDark = true
+	offscreen.WindowAction(win.OSWin, window.Focus)
+	testSync(t, win)
+	if CurTheme == nil || CurTheme.Name != "Dark" {
+		t.Errorf("expected Dark theme after window focus, got: %v", CurTheme)
+	}
+
+	// without a theme, only the dark preference follows the desktop
+	Prefs.Theme = ""
+	Prefs.DarkTheme = ""
+	desktopDark = false
+	DesktopThemeChanged()
+	if CurTheme != nil || PrefersDarkColors {
+		t.Errorf("expected no theme and light colors, got: %v %v", CurTheme, PrefersDarkColors)
+	}
+	desktopDark = true
+	DesktopThemeChanged()
+	if !PrefersDarkColors {
+		t.Errorf("expected dark colors preferred after desktop change")
+	}
+
+	Prefs.FollowDesktop = false
+	desktopDark = false
+	DesktopThemeChanged()
+	if !PrefersDarkColors {
+		t.Errorf("expected no change when not following the desktop")
+	}
+}
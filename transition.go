@@ -0,0 +1,214 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// StyleTransition specifies a CSS transition for one style property: when
+// a widget changes state (e.g., hover, focus, pressed), the property is
+// animated from its old to its new value over the duration, instead of
+// changing immediately.  Transitions are set with the transition property,
+// e.g., "transition": "background-color 150ms ease-out, border-color 0.2s".
+// See StyleTransitionProps for the properties that can be animated.
+type StyleTransition struct {
+	Prop  string        `desc:"name of the style property, or all for all the properties in StyleTransitionProps"`
+	Dur   time.Duration `desc:"duration of the transition"`
+	Delay time.Duration `desc:"delay before the transition starts"`
+	Ease  EasingFunc    `view:"-" desc:"easing function -- nil = CSS default ease"`
+}
+
+// StyleTransitionFunc sets the value of a style property in s interpolated
+// between from and to by t
+type StyleTransitionFunc func(s, from, to *Style, t float32)
+
+// StyleTransitionProps are the style properties that can be animated with
+// transitions -- these only affect rendering, not layout
+var StyleTransitionProps = map[string]StyleTransitionFunc{
+	"color": func(s, from, to *Style, t float32) {
+		s.Font.Color = LerpColor(from.Font.Color, to.Font.Color, t)
+	},
+	"background-color": func(s, from, to *Style, t float32) {
+		s.Font.BgColor.Color = LerpColor(from.Font.BgColor.Color, to.Font.BgColor.Color, t)
+	},
+	"opacity": func(s, from, to *Style, t float32) {
+		s.Font.Opacity = LerpFloat32(from.Font.Opacity, to.Font.Opacity, t)
+	},
+	"border-color": func(s, from, to *Style, t float32) {
+		s.Border.Color = LerpColor(from.Border.Color, to.Border.Color, t)
+	},
+	"border-width": func(s, from, to *Style, t float32) {
+		s.Border.Width = LerpValue(from.Border.Width, to.Border.Width, t)
+	},
+	"border-radius": func(s, from, to *Style, t float32) {
+		s.Border.Radius = LerpValue(from.Border.Radius, to.Border.Radius, t)
+	},
+	"outline-color": func(s, from, to *Style, t float32) {
+		s.Outline.Color = LerpColor(from.Outline.Color, to.Outline.Color, t)
+	},
+	"box-shadow": func(s, from, to *Style, t float32) {
+		s.BoxShadow.Color = LerpColor(from.BoxShadow.Color, to.BoxShadow.Color, t)
+		s.BoxShadow.HOffset = LerpValue(from.BoxShadow.HOffset, to.BoxShadow.HOffset, t)
+		s.BoxShadow.VOffset = LerpValue(from.BoxShadow.VOffset, to.BoxShadow.VOffset, t)
+		s.BoxShadow.Blur = LerpValue(from.BoxShadow.Blur, to.BoxShadow.Blur, t)
+	},
+}
+
+// ParseStyleTransitions parses the transition property value: a
+// comma-separated list of: property duration [timing-function] [delay],
+// where the first time value is the duration and the second the delay --
+// entries with unknown properties are skipped, and none means no
+// transitions
+func ParseStyleTransitions(str string) []StyleTransition {
+	var trs []StyleTransition
+	for _, ent := range cssSplitList(str) {
+		flds := cssSplitFields(ent)
+		if len(flds) == 0 {
+			continue
+		}
+		tr := StyleTransition{Prop: "all"}
+		ntime := 0
+		for _, fl := range flds {
+			if d, ok := parseCSSTime(fl); ok {
+				if ntime == 0 {
+					tr.Dur = d
+				} else {
+					tr.Delay = d
+				}
+				ntime++
+				continue
+			}
+			if ef, err := EasingByName(fl); err == nil {
+				tr.Ease = ef
+				continue
+			}
+			tr.Prop = strings.ToLower(fl)
+		}
+		if tr.Prop == "none" {
+			return nil
+		}
+		if _, ok := StyleTransitionProps[tr.Prop]; !ok && tr.Prop != "all" {
+			continue
+		}
+		trs = append(trs, tr)
+	}
+	return trs
+}
+
+// cssSplitList splits a comma-separated list, ignoring commas within parens
+func cssSplitList(str string) []string {
+	var lst []string
+	depth, st := 0, 0
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				lst = append(lst, strings.TrimSpace(str[st:i]))
+				st = i + 1
+			}
+		}
+	}
+	return append(lst, strings.TrimSpace(str[st:]))
+}
+
+// cssSplitFields splits on white space, ignoring white space within parens,
+// e.g., in cubic-bezier(0.1, 0.7, 1, 0.1)
+func cssSplitFields(str string) []string {
+	var flds []string
+	depth, st := 0, -1
+	for i := 0; i < len(str); i++ {
+		switch c := str[i]; {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			if st >= 0 {
+				flds = append(flds, str[st:i])
+				st = -1
+			}
+			continue
+		}
+		if st < 0 {
+			st = i
+		}
+	}
+	if st >= 0 {
+		flds = append(flds, str[st:])
+	}
+	return flds
+}
+
+// parseCSSTime parses a CSS time value in s or ms units
+func parseCSSTime(str string) (time.Duration, bool) {
+	switch {
+	case strings.HasSuffix(str, "ms"):
+	case strings.HasSuffix(str, "s"):
+	default:
+		return 0, false
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// StartTransitions starts animating the style properties that have
+// transitions from their values in the given previous style to their values
+// in the current style -- called after a state change has set Sty to a new
+// state style.  The previous style values are applied immediately, so
+// there is no flash of the new style.
+func (wb *WidgetBase) StartTransitions(from *Style) {
+	if len(wb.Sty.Transition) == 0 {
+		return
+	}
+	fr := *from
+	to := wb.Sty
+	for _, tr := range wb.Sty.Transition {
+		if tr.Prop == "all" {
+			nms := make([]string, 0, len(StyleTransitionProps))
+			for nm := range StyleTransitionProps {
+				nms = append(nms, nm)
+			}
+			sort.Strings(nms)
+			for _, nm := range nms {
+				wb.startTransition(nm, tr, &fr, &to)
+			}
+			continue
+		}
+		wb.startTransition(tr.Prop, tr, &fr, &to)
+	}
+}
+
+// startTransition starts the transition for one property
+func (wb *WidgetBase) startTransition(prop string, tr StyleTransition, from, to *Style) {
+	tf, ok := StyleTransitionProps[prop]
+	if !ok {
+		return
+	}
+	ease := tr.Ease
+	if ease == nil {
+		ease = EaseCSS
+	}
+	tf(&wb.Sty, from, to, 0)
+	tw := &Tween{Node: wb.This, Key: "transition:" + prop, Dur: tr.Dur, Delay: tr.Delay, Ease: ease}
+	tw.Update = func(t float32) {
+		tf(&wb.Sty, from, to, t)
+	}
+	win := wb.ParentWindow()
+	if win == nil || tr.Dur <= 0 {
+		tw.Finish()
+		return
+	}
+	win.Animate(tw)
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseStyleTransitions(t *testing.T) {
+	trs := ParseStyleTransitions("background-color 150ms ease-out, border-color 0.2s 50ms, bogus 1s, 1s cubic-bezier(0.1, 0.7, 1, 0.1) 2s")
+	if len(trs) != 3 {
+		t.Fatalf("expected 3 transitions, got: %+v", trs)
+	}
+	if tr := trs[0]; tr.Prop != "background-color" || tr.Dur != 150*time.Millisecond || tr.Delay != 0 || tr.Ease == nil {
+		t.Errorf("unexpected transition: %+v", tr)
+	}
+	if tr := trs[1]; tr.Prop != "border-color" || tr.Dur != 200*time.Millisecond || tr.Delay != 50*time.Millisecond || tr.Ease != nil {
+		t.Errorf("unexpected transition: %+v", tr)
+	}
+	if tr := trs[2]; tr.Prop != "all" || tr.Dur != time.Second || tr.Delay != 2*time.Second || tr.Ease == nil || !testNear(tr.Ease(1), 1) {
+		t.Errorf("unexpected transition: %+v", tr)
+	}
+	if trs := ParseStyleTransitions("opacity 1s, none"); trs != nil {
+		t.Errorf("expected none to give no transitions, got: %+v", trs)
+	}
+	if trs := ParseStyleTransitions(""); len(trs) != 0 {
+		t.Errorf("expected no transitions, got: %+v", trs)
+	}
+}
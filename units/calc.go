@@ -0,0 +1,215 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package units
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CalcExpr is a parsed CSS calc() expression, which can mix values in
+// different units (e.g., calc(100% - 2em)) -- it is evaluated in a given
+// Context to get raw dots, so the relative units are resolved at that point.
+// Leaf nodes have Op == 0 and hold a Value (or a plain number if Num).
+type CalcExpr struct {
+	Op  byte      `desc:"operator: + - * / or 0 for a leaf value"`
+	Val Value     `desc:"leaf value"`
+	Num bool      `desc:"leaf is a plain number without units (for * and /)"`
+	A   *CalcExpr `desc:"left operand"`
+	B   *CalcExpr `desc:"right operand"`
+}
+
+// ParseCalc parses a calc() expression -- the outer calc( ) is optional.
+// Supports + - * / and parentheses (and nested calc), with the usual
+// precedence.
+func ParseCalc(str string) (*CalcExpr, error) {
+	str = strings.TrimSpace(str)
+	if strings.HasPrefix(strings.ToLower(str), "calc(") {
+		str = str[4:]
+	}
+	cp := &calcParser{str: str}
+	cp.tokenize()
+	if cp.err != nil {
+		return nil, cp.err
+	}
+	ce := cp.sum()
+	if cp.err == nil && cp.pos < len(cp.toks) {
+		cp.err = fmt.Errorf("units.ParseCalc: unexpected %q in: %v", cp.toks[cp.pos], str)
+	}
+	if cp.err != nil {
+		return nil, cp.err
+	}
+	return ce, nil
+}
+
+// Eval evaluates the expression in given context, returning raw dots, or a
+// plain number if num is true (for unitless expressions).  Plain numbers
+// added to lengths are treated as Px.
+func (ce *CalcExpr) Eval(ctxt *Context) (val float32, num bool) {
+	if ce.Op == 0 {
+		if ce.Num {
+			return ce.Val.Val, true
+		}
+		return ctxt.ToDots(ce.Val.Val, ce.Val.Un), false
+	}
+	a, an := ce.A.Eval(ctxt)
+	b, bn := ce.B.Eval(ctxt)
+	switch ce.Op {
+	case '+', '-':
+		if an != bn {
+			if an {
+				a = ctxt.ToDots(a, Px)
+			} else {
+				b = ctxt.ToDots(b, Px)
+			}
+		}
+		if ce.Op == '-' {
+			b = -b
+		}
+		return a + b, an && bn
+	case '*':
+		return a * b, an && bn
+	case '/':
+		if b == 0 {
+			return 0, an
+		}
+		return a / b, an
+	}
+	return 0, true
+}
+
+// Dots returns the value of the expression in raw dots in given context
+func (ce *CalcExpr) Dots(ctxt *Context) float32 {
+	v, num := ce.Eval(ctxt)
+	if num {
+		return ctxt.ToDots(v, Px)
+	}
+	return v
+}
+
+// String returns the expression in calc() form
+func (ce *CalcExpr) String() string {
+	return "calc(" + ce.str() + ")"
+}
+
+func (ce *CalcExpr) str() string {
+	if ce.Op == 0 {
+		vs := strconv.FormatFloat(float64(ce.Val.Val), 'g', -1, 32)
+		if ce.Num {
+			return vs
+		}
+		return vs + UnitNames[ce.Val.Un]
+	}
+	return "(" + ce.A.str() + " " + string(ce.Op) + " " + ce.B.str() + ")"
+}
+
+// calcParser is a simple recursive-descent parser for calc expressions
+type calcParser struct {
+	str  string
+	toks []string
+	pos  int
+	err  error
+}
+
+// tokenize splits the string into operators, parens, and values -- + and -
+// are signs when they directly precede a number at the start of an operand
+func (cp *calcParser) tokenize() {
+	rs := []rune(cp.str)
+	sz := len(rs)
+	operand := true // expecting an operand
+	for i := 0; i < sz; {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '*' || r == '/' || ((r == '+' || r == '-') && !(operand && i+1 < sz && (unicode.IsDigit(rs[i+1]) || rs[i+1] == '.'))):
+			cp.toks = append(cp.toks, string(r))
+			operand = r != ')'
+			i++
+		case unicode.IsLetter(r) && i+5 <= sz && strings.ToLower(string(rs[i:i+5])) == "calc(":
+			cp.toks = append(cp.toks, "(")
+			operand = true
+			i += 5
+		default:
+			st := i
+			i++
+			for i < sz && (unicode.IsDigit(rs[i]) || unicode.IsLetter(rs[i]) || rs[i] == '.' || rs[i] == '%') {
+				i++
+			}
+			cp.toks = append(cp.toks, string(rs[st:i]))
+			operand = false
+		}
+	}
+}
+
+func (cp *calcParser) peek() string {
+	if cp.pos < len(cp.toks) {
+		return cp.toks[cp.pos]
+	}
+	return ""
+}
+
+// sum parses + and - terms
+func (cp *calcParser) sum() *CalcExpr {
+	a := cp.product()
+	for cp.err == nil {
+		op := cp.peek()
+		if op != "+" && op != "-" {
+			break
+		}
+		cp.pos++
+		b := cp.product()
+		a = &CalcExpr{Op: op[0], A: a, B: b}
+	}
+	return a
+}
+
+// product parses * and / factors
+func (cp *calcParser) product() *CalcExpr {
+	a := cp.factor()
+	for cp.err == nil {
+		op := cp.peek()
+		if op != "*" && op != "/" {
+			break
+		}
+		cp.pos++
+		b := cp.factor()
+		a = &CalcExpr{Op: op[0], A: a, B: b}
+	}
+	return a
+}
+
+// factor parses a parenthesized expression or a value
+func (cp *calcParser) factor() *CalcExpr {
+	tok := cp.peek()
+	cp.pos++
+	switch tok {
+	case "":
+		cp.err = fmt.Errorf("units.ParseCalc: unexpected end of expression: %v", cp.str)
+		return nil
+	case "(":
+		ce := cp.sum()
+		if cp.err == nil && cp.peek() != ")" {
+			cp.err = fmt.Errorf("units.ParseCalc: missing ) in: %v", cp.str)
+		}
+		cp.pos++
+		return ce
+	case ")", "+", "-", "*", "/":
+		cp.err = fmt.Errorf("units.ParseCalc: unexpected %q in: %v", tok, cp.str)
+		return nil
+	}
+	if vf, err := strconv.ParseFloat(tok, 32); err == nil {
+		return &CalcExpr{Val: Value{Val: float32(vf)}, Num: true}
+	}
+	if r := tok[0]; !(r >= '0' && r <= '9' || r == '.' || r == '+' || r == '-') {
+		cp.err = fmt.Errorf("units.ParseCalc: invalid value %q in: %v", tok, cp.str)
+		return nil
+	}
+	ce := &CalcExpr{}
+	ce.Val.SetString(tok)
+	return ce
+}
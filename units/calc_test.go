@@ -0,0 +1,50 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package units
+
+import (
+	"testing"
+)
+
+func TestCalc(t *testing.T) {
+	var ctxt Context
+	ctxt.Defaults()
+	ctxt.ElW = 200
+	ctxt.FontEm = 10
+	tests := []struct {
+		expr string
+		dots float32
+	}{
+		{"calc(100% - 2em)", 180},
+		{"calc(50% + 10px)", 110},
+		{"calc((100% - 20px) / 3)", 60},
+		{"calc(2 * 1.5em)", 30},
+		{"calc(-1em + 100%)", 190},
+		{"calc(100% - calc(2em * 2))", 160},
+		{"calc(10)", 10},
+	}
+	for _, ts := range tests {
+		var v Value
+		v.SetString(ts.expr)
+		if v.Calc == nil {
+			t.Errorf("expr: %v  not parsed", ts.expr)
+			continue
+		}
+		if d := v.ToDots(&ctxt); d != ts.dots {
+			t.Errorf("expr: %v  dots: %v != %v  parsed: %v", ts.expr, d, ts.dots, v.String())
+		}
+	}
+	for _, bad := range []string{"calc(1em +)", "calc((1em)", "calc(1em foo)", "calc(auto)"} {
+		if _, err := ParseCalc(bad); err == nil {
+			t.Errorf("expr: %v  should be an error", bad)
+		}
+	}
+	var v Value
+	v.SetString("calc(1em)")
+	v.Set(5, Px)
+	if v.Calc != nil || v.ToDots(&ctxt) != 5 {
+		t.Errorf("Set did not clear calc")
+	}
+}
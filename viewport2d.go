@@ -268,6 +268,21 @@ func (vp *Viewport2D) AsViewport2D() *Viewport2D {
 	return vp
 }
 
+// AccessInfo returns the accessibility info for the viewport -- popups are
+// reported as menus, tooltips or dialogs, and others have no role
+func (vp *Viewport2D) AccessInfo() AccessInfo {
+	ai := vp.WidgetBase.AccessInfo()
+	switch {
+	case vp.IsMenu():
+		ai.Role = RoleMenu
+	case vp.IsTooltip():
+		ai.Role = RoleToolTip
+	case vp.IsPopup():
+		ai.Role = RoleDialog
+	}
+	return ai
+}
+
 func (vp *Viewport2D) Init2D() {
 	vp.Init2DWidget()
 	vp.SetCurWin()
@@ -138,6 +138,10 @@ func (wb *WidgetBase) Style2DWidget() {
 	if wb.Viewport == nil { // robust
 		gii.Init2D()
 	}
+	_, parNode := wb.Par.(Node2D)
+	if !parNode { // top of a window or popup: theme variables are in effect
+		wb.Sty.Vars = CSSVarsFromProps(nil, ThemeCSS)
+	}
 	styprops := *wb.Properties()
 	parSty := wb.ParentStyle()
 	wb.Sty.SetStyleProps(parSty, styprops)
@@ -150,11 +154,11 @@ func (wb *WidgetBase) Style2DWidget() {
 		wb.Sty.SetStyleProps(parSty, sp)
 	}
 
-	pagg := wb.ParentCSSAgg()
-	if pagg != nil {
-		AggCSS(&wb.CSSAgg, *pagg)
-	} else {
-		wb.CSSAgg = nil // restart
+	wb.CSSAgg = nil // restart
+	if parNode {
+		AggCSS(&wb.CSSAgg, *wb.ParentCSSAgg())
+	} else { // top of a window or popup: start with the theme styles
+		AggCSS(&wb.CSSAgg, ThemeCSS)
 	}
 	AggCSS(&wb.CSSAgg, wb.CSS)
 	wb.Sty.StyleCSS(gii, wb.CSSAgg, "")
@@ -139,6 +139,8 @@ type Window struct {
 	GotPaint         bool                                    `json:"-" xml:"-" desc:"have we received our first paint event yet?  ignore other window events before this point"`
 	EventSigs        [oswin.EventTypeN][EventPrisN]ki.Signal `json:"-" xml:"-" view:"-" desc:"signals for communicating each type of event, organized by priority"`
 	GoLoop           bool                                    `json:"-" xml:"-" desc:"true if we are running from GoStartEventLoop -- requires a WinWait.Done at end"`
+	Recorder         *EventRecorder                          `json:"-" xml:"-" view:"-" desc:"if non-nil, all input events received by the window are recorded here -- see StartRecording"`
+	Anim             Timeline                                `json:"-" xml:"-" view:"-" desc:"active animations for nodes in this window -- see Node2DBase.Animate"`
 	stopEventLoop    bool
 	updating         int32 // atomic flag around global updating -- routines can check IsUpdating and bail
 }
@@ -464,6 +466,7 @@ func (w *Window) Resized(sz image.Point) {
 
 // Closed frees any resources after the window has been closed.
 func (w *Window) Closed() {
+	w.accessClosed()
 	w.UpMu.Lock()
 	AllWindows.Delete(w)
 	MainWindows.Delete(w)
@@ -507,6 +510,7 @@ func Init() {
 		Prefs.Open()
 		Prefs.Apply()
 		WinGeomPrefs.Open()
+		DockLayoutPrefs.Open()
 	}
 }
 
@@ -605,6 +609,24 @@ func (w *Window) FullReRender() {
 			w.FocusNext(w.Focus)
 		}
 	}
+	w.AccessUpdate()
+}
+
+// ReRenderPopups fully re-renders all the current popups, e.g., after a
+// change in styles, and uploads them to the window
+func (w *Window) ReRenderPopups() {
+	if w.IsInactive() || (w.Popup == nil && len(w.PopupStack) == 0) {
+		return
+	}
+	for _, pop := range w.PopupStack {
+		if gii, _ := KiToNode2D(pop); gii != nil {
+			gii.AsViewport2D().FullRender2DTree()
+		}
+	}
+	if gii, _ := KiToNode2D(w.Popup); gii != nil {
+		gii.AsViewport2D().FullRender2DTree()
+	}
+	w.UploadAllViewports()
 }
 
 // UploadVpRegion uploads image for one viewport region on the screen, using
@@ -986,6 +1008,17 @@ mainloop:
 			fmt.Println("stop event loop")
 			break
 		}
+		switch se := evi.(type) {
+		case *syncEvent:
+			close(se.done)
+			continue
+		case *funcEvent:
+			se.fun()
+			continue
+		}
+		if w.Recorder != nil {
+			w.Recorder.Record(evi)
+		}
 		et := evi.Type()
 		if lastWinMenuUpdate != WinNewCloseTime {
 			if et != oswin.WindowEvent && et != oswin.WindowResizeEvent &&
@@ -1241,6 +1274,9 @@ mainloop:
 					}
 				}
 				w.Publish()
+			case window.Focus:
+				// the desktop may have switched to dark colors while we were not active
+				DesktopThemeChanged()
 			case window.Move:
 				e.SetProcessed()
 				if w.GotPaint { // moves before paint are not accurate on X11
@@ -1991,6 +2027,7 @@ func (w *Window) SetFocus(k ki.Ki) bool {
 	w.ClearNonFocus() // shouldn't need this but actually sometimes do
 	nii.FocusChanged2D(FocusGot)
 	w.UpdateEnd(updt)
+	w.accessFocus(k)
 	return true
 }
 